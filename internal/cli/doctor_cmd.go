@@ -0,0 +1,376 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"github.com/tildaslashalef/bazinga/internal/config"
+	"github.com/tildaslashalef/bazinga/internal/llm/bedrock"
+	"github.com/tildaslashalef/bazinga/internal/storage"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// probeTimeout bounds each provider connectivity check
+const probeTimeout = 10 * time.Second
+
+// checkStatus is the outcome of a single doctor check
+type checkStatus int
+
+const (
+	checkPass checkStatus = iota
+	checkWarn
+	checkFail
+)
+
+// String returns the report marker for the status
+func (s checkStatus) String() string {
+	switch s {
+	case checkPass:
+		return "✓"
+	case checkWarn:
+		return "!"
+	default:
+		return "✗"
+	}
+}
+
+// checkResult is one line of the doctor report
+type checkResult struct {
+	Name   string
+	Status checkStatus
+	Detail string
+	Hint   string // Remediation shown for warnings and failures
+}
+
+// newDoctorCommand creates the doctor subcommand. configErr reports a config file
+// that could not be read, which doctor includes in its report instead of aborting.
+func newDoctorCommand(configErr func() error) *cobra.Command {
+	return &cobra.Command{
+		Use:           "doctor",
+		Short:         "Check configuration, providers and local tools",
+		Long:          "Run a self-check of the configuration, provider credentials and connectivity, optional tools, the session directory and terminal capabilities.",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			results := runDoctorChecks(cmd.Context(), configErr())
+
+			out := cmd.OutOrStdout()
+			failures := 0
+			warnings := 0
+			for _, result := range results {
+				fmt.Fprintf(out, "%s %s: %s\n", result.Status, result.Name, result.Detail)
+				if result.Status != checkPass && result.Hint != "" {
+					fmt.Fprintf(out, "    → %s\n", result.Hint)
+				}
+				switch result.Status {
+				case checkFail:
+					failures++
+				case checkWarn:
+					warnings++
+				}
+			}
+
+			fmt.Fprintf(out, "\n%d checks, %d warning(s), %d failure(s)\n", len(results), warnings, failures)
+			if failures > 0 {
+				return fmt.Errorf("doctor found %d failure(s)", failures)
+			}
+			return nil
+		},
+	}
+}
+
+// runDoctorChecks runs every check and returns the results in report order
+func runDoctorChecks(ctx context.Context, configErr error) []checkResult {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	cfg, results := checkConfig(configErr)
+	if cfg != nil {
+		results = append(results, checkProviders(ctx, cfg)...)
+	}
+	results = append(results, checkOptionalTools()...)
+	if cfg != nil {
+		results = append(results, checkSessionDir(cfg))
+	}
+	results = append(results, checkTerminal()...)
+
+	return results
+}
+
+// checkConfig loads and validates the configuration
+func checkConfig(readErr error) (*config.Config, []checkResult) {
+	source := viper.ConfigFileUsed()
+	if source == "" {
+		source = "defaults (no config file)"
+	}
+
+	if readErr != nil {
+		return nil, []checkResult{{
+			Name:   "config",
+			Status: checkFail,
+			Detail: readErr.Error(),
+			Hint:   "Fix the YAML syntax in " + source + " or remove it and run bazinga again to regenerate defaults",
+		}}
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, []checkResult{{
+			Name:   "config",
+			Status: checkFail,
+			Detail: err.Error(),
+			Hint:   "Check the value types in " + source,
+		}}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		var results []checkResult
+		for _, problem := range strings.Split(err.Error(), "\n") {
+			results = append(results, checkResult{
+				Name:   "config",
+				Status: checkFail,
+				Detail: problem,
+				Hint:   "Edit " + source,
+			})
+		}
+		return cfg, results
+	}
+
+	return cfg, []checkResult{{Name: "config", Status: checkPass, Detail: "valid (" + source + ")"}}
+}
+
+// checkProviders verifies credentials and connectivity for each enabled provider
+func checkProviders(ctx context.Context, cfg *config.Config) []checkResult {
+	var results []checkResult
+
+	if cfg.Providers.Bedrock.Enabled {
+		results = append(results, checkBedrock(ctx, cfg.Providers.Bedrock))
+	}
+
+	if cfg.Providers.Anthropic.Enabled {
+		if cfg.Providers.Anthropic.APIKey == "" {
+			results = append(results, checkResult{
+				Name: "anthropic", Status: checkFail, Detail: "enabled but no API key",
+				Hint: "Set ANTHROPIC_API_KEY or providers.anthropic.api_key",
+			})
+		} else {
+			baseURL := cfg.Providers.Anthropic.BaseURL
+			if baseURL == "" {
+				baseURL = "https://api.anthropic.com"
+			}
+			results = append(results, probeHTTP(ctx, "anthropic", strings.TrimRight(baseURL, "/")+"/v1/models", map[string]string{
+				"x-api-key":         cfg.Providers.Anthropic.APIKey,
+				"anthropic-version": "2023-06-01",
+			}, "Check ANTHROPIC_API_KEY and providers.anthropic.base_url"))
+		}
+	}
+
+	if cfg.Providers.OpenAI.Enabled {
+		if cfg.Providers.OpenAI.APIKey == "" {
+			results = append(results, checkResult{
+				Name: "openai", Status: checkFail, Detail: "enabled but no API key",
+				Hint: "Set OPENAI_API_KEY or providers.openai.api_key",
+			})
+		} else {
+			baseURL := cfg.Providers.OpenAI.BaseURL
+			if baseURL == "" {
+				baseURL = "https://api.openai.com/v1"
+			}
+			results = append(results, probeHTTP(ctx, "openai", strings.TrimRight(baseURL, "/")+"/models", map[string]string{
+				"Authorization": "Bearer " + cfg.Providers.OpenAI.APIKey,
+			}, "Check OPENAI_API_KEY and providers.openai.base_url"))
+		}
+	}
+
+	if cfg.Providers.Ollama.Enabled {
+		baseURL := cfg.Providers.Ollama.BaseURL
+		if baseURL == "" {
+			baseURL = "http://localhost:11434"
+		}
+		results = append(results, probeHTTP(ctx, "ollama", strings.TrimRight(baseURL, "/")+"/api/tags", nil,
+			"Start Ollama with `ollama serve` or set OLLAMA_BASE_URL"))
+	}
+
+	if cfg.Providers.Gemini.Enabled {
+		if cfg.Providers.Gemini.APIKey == "" {
+			results = append(results, checkResult{
+				Name: "gemini", Status: checkFail, Detail: "enabled but no API key",
+				Hint: "Set GEMINI_API_KEY or providers.gemini.api_key",
+			})
+		} else {
+			baseURL := cfg.Providers.Gemini.BaseURL
+			if baseURL == "" {
+				baseURL = "https://generativelanguage.googleapis.com/v1beta"
+			}
+			results = append(results, probeHTTP(ctx, "gemini", strings.TrimRight(baseURL, "/")+"/models", map[string]string{
+				"x-goog-api-key": cfg.Providers.Gemini.APIKey,
+			}, "Check GEMINI_API_KEY and providers.gemini.base_url"))
+		}
+	}
+
+	if len(results) == 0 {
+		results = append(results, checkResult{
+			Name: "providers", Status: checkFail, Detail: "no provider is enabled",
+			Hint: "Enable a provider in the config or set ANTHROPIC_API_KEY / OPENAI_API_KEY",
+		})
+	}
+
+	return results
+}
+
+// checkBedrock loads AWS credentials and confirms them with STS
+func checkBedrock(ctx context.Context, cfg config.BedrockConfig) checkResult {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	awsCfg, err := bedrock.LoadAWSConfig(ctx, &bedrock.AuthConfig{
+		Method:          bedrock.AuthMethod(cfg.AuthMethod),
+		AccessKeyID:     cfg.AccessKeyID,
+		SecretAccessKey: cfg.SecretAccessKey,
+		SessionToken:    cfg.SessionToken,
+		Profile:         cfg.Profile,
+		Region:          cfg.Region,
+		RoleARN:         cfg.RoleARN,
+		RoleSessionName: cfg.RoleSessionName,
+		ExternalID:      cfg.ExternalID,
+	})
+	if err != nil {
+		return checkResult{
+			Name: "bedrock", Status: checkFail, Detail: err.Error(),
+			Hint: "Check providers.bedrock.auth_method and profile in the config",
+		}
+	}
+
+	if err := bedrock.ValidateCredentials(ctx, awsCfg); err != nil {
+		hint := "Refresh your AWS credentials"
+		if cfg.AuthMethod == "profile" {
+			hint = fmt.Sprintf("Run `aws sso login --profile %s` or check ~/.aws/config", cfg.Profile)
+		}
+		return checkResult{Name: "bedrock", Status: checkFail, Detail: err.Error(), Hint: hint}
+	}
+
+	return checkResult{Name: "bedrock", Status: checkPass, Detail: fmt.Sprintf("credentials valid (region %s)", cfg.Region)}
+}
+
+// probeHTTP performs an authenticated GET and maps the response to a check result
+func probeHTTP(ctx context.Context, name, url string, headers map[string]string, hint string) checkResult {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return checkResult{Name: name, Status: checkFail, Detail: err.Error(), Hint: hint}
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return checkResult{Name: name, Status: checkFail, Detail: "unreachable: " + err.Error(), Hint: hint}
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return checkResult{Name: name, Status: checkFail, Detail: "credentials rejected (" + resp.Status + ")", Hint: hint}
+	case resp.StatusCode >= 400:
+		return checkResult{Name: name, Status: checkWarn, Detail: "reachable but returned " + resp.Status, Hint: hint}
+	}
+
+	return checkResult{Name: name, Status: checkPass, Detail: "reachable"}
+}
+
+// checkOptionalTools reports external programs that enable faster or extra features
+func checkOptionalTools() []checkResult {
+	tools := []struct {
+		name string
+		hint string
+	}{
+		{"git", "Install git to enable git tools, /commit and /changes"},
+		{"rg", "Install ripgrep for fast grep; a slower built-in search is used otherwise"},
+		{"fzf", "Install fzf for better fuzzy_search; a built-in matcher is used otherwise"},
+		{"gopls", "Install gopls (go install golang.org/x/tools/gopls@latest) for Go code intelligence and the symbols tool"},
+	}
+
+	var results []checkResult
+	for _, tool := range tools {
+		path, err := exec.LookPath(tool.name)
+		if err != nil {
+			results = append(results, checkResult{Name: tool.name, Status: checkWarn, Detail: "not found in PATH", Hint: tool.hint})
+			continue
+		}
+		results = append(results, checkResult{Name: tool.name, Status: checkPass, Detail: path})
+	}
+	return results
+}
+
+// checkSessionDir confirms that sessions can be saved
+func checkSessionDir(cfg *config.Config) checkResult {
+	store, err := storage.NewStorageWithConfig(cfg)
+	if err != nil {
+		return checkResult{
+			Name: "sessions", Status: checkFail, Detail: err.Error(),
+			Hint: "Make sure ~/.bazinga exists and is writable",
+		}
+	}
+
+	dir := store.GetSessionsDir()
+	probe, err := os.CreateTemp(dir, ".doctor-*")
+	if err != nil {
+		return checkResult{
+			Name: "sessions", Status: checkFail, Detail: "not writable: " + err.Error(),
+			Hint: "Fix the permissions on " + dir,
+		}
+	}
+	probe.Close()
+	_ = os.Remove(probe.Name())
+
+	return checkResult{Name: "sessions", Status: checkPass, Detail: "writable (" + dir + ")"}
+}
+
+// checkTerminal reports whether the interactive UI will render correctly
+func checkTerminal() []checkResult {
+	var results []checkResult
+
+	if info, err := os.Stdout.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		// Color detection is meaningless when output is redirected
+		return append(results, checkResult{
+			Name: "terminal", Status: checkWarn, Detail: "stdout is not a terminal",
+			Hint: "Run bazinga directly in a terminal for the interactive UI",
+		})
+	}
+	results = append(results, checkResult{Name: "terminal", Status: checkPass, Detail: "stdout is a terminal"})
+
+	term := os.Getenv("TERM")
+	profile := lipgloss.ColorProfile().Name()
+	switch {
+	case term == "" || term == "dumb":
+		results = append(results, checkResult{
+			Name: "colors", Status: checkWarn, Detail: fmt.Sprintf("TERM=%q, color profile %s", term, profile),
+			Hint: "Set TERM (e.g. xterm-256color) for colors and cursor movement",
+		})
+	case profile == "Ascii":
+		results = append(results, checkResult{
+			Name: "colors", Status: checkWarn, Detail: "no color support detected (TERM=" + term + ")",
+			Hint: "Use a terminal with 256-color or true color support",
+		})
+	default:
+		results = append(results, checkResult{Name: "colors", Status: checkPass, Detail: profile + " (TERM=" + term + ")"})
+	}
+
+	return results
+}
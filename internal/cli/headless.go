@@ -0,0 +1,341 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"github.com/tildaslashalef/bazinga/internal/llm"
+	"github.com/tildaslashalef/bazinga/internal/session"
+	"github.com/tildaslashalef/bazinga/internal/ui"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// permissionPolicy decides tool calls that would prompt in the TUI
+type permissionPolicy int
+
+const (
+	policyDefault permissionPolicy = iota // Deny, with a hint about --yes
+	policyApprove
+	policyDeny
+)
+
+// headlessOutput writes a headless run: assistant text to stdout, tool activity and
+// errors to stderr. Styling is only used when the stream is a terminal.
+type headlessOutput struct {
+	stdout io.Writer
+	stderr io.Writer
+
+	tool    lipgloss.Style
+	success lipgloss.Style
+	failure lipgloss.Style
+	muted   lipgloss.Style
+
+	// Permission notes are written from the session's goroutine
+	mu          sync.Mutex
+	interleaved bool // Whether stdout and stderr both go to the terminal
+	atLineStart bool // Whether the last text written to stdout ended a line
+}
+
+func newHeadlessOutput(stdout, stderr *os.File) *headlessOutput {
+	out := &headlessOutput{
+		stdout:      stdout,
+		stderr:      stderr,
+		tool:        lipgloss.NewStyle(),
+		success:     lipgloss.NewStyle(),
+		failure:     lipgloss.NewStyle(),
+		muted:       lipgloss.NewStyle(),
+		interleaved: isTerminal(stdout) && isTerminal(stderr),
+		atLineStart: true,
+	}
+	if isTerminal(stderr) {
+		out.tool = out.tool.Foreground(ui.AccentColor)
+		out.success = out.success.Foreground(ui.SuccessColor)
+		out.failure = out.failure.Foreground(ui.ErrorColor)
+		out.muted = out.muted.Foreground(ui.TextMuted)
+	}
+	return out
+}
+
+// text writes streamed assistant text
+func (o *headlessOutput) text(text string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	_, _ = io.WriteString(o.stdout, text)
+	o.atLineStart = strings.HasSuffix(text, "\n")
+}
+
+// note writes a line to stderr. On a terminal any partial line of assistant text is
+// ended first so the two do not run together.
+func (o *headlessOutput) note(line string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.interleaved && !o.atLineStart {
+		_, _ = io.WriteString(o.stdout, "\n")
+		o.atLineStart = true
+	}
+	_, _ = fmt.Fprintln(o.stderr, line)
+}
+
+// handler returns the stream handler that prints a headless run
+func (o *headlessOutput) handler() session.StreamHandler {
+	return session.StreamHandler{
+		OnText: o.text,
+		OnError: func(message string) {
+			o.note(o.failure.Render("✗ " + message))
+		},
+		OnNotice: func(message string) {
+			o.note(o.muted.Render("ℹ " + message))
+		},
+		OnTaskStart: func(taskName string) {
+			o.note(o.tool.Render("⏺ " + taskName))
+		},
+		OnToolStart: func(completion *llm.ToolCompletion) {
+			o.note(o.tool.Render("⏺ " + describeToolCall(completion.ToolName, completion.Args)))
+		},
+		OnToolOutput: func(completion *llm.ToolCompletion) {
+			o.note(o.muted.Render("  " + completion.Result))
+		},
+		OnToolEnd: func(completion *llm.ToolCompletion) {
+			if completion.State == "error" {
+				o.note(o.failure.Render("  ✗ " + completion.Error))
+				return
+			}
+			o.note(o.success.Render("  ✓ " + firstLine(completion.Result)))
+		},
+	}
+}
+
+// describeToolCall returns a one-line description of a tool call, e.g. "Edit(main.go)"
+func describeToolCall(toolName string, args map[string]interface{}) string {
+	action := ui.GetToolActionName(toolName)
+	if action == "Executing" {
+		action = toolName
+	}
+
+	if command, ok := args["command"].(string); ok && toolName == "bash" {
+		return fmt.Sprintf("%s(%s)", action, firstLine(command))
+	}
+	if target := ui.GetToolDisplayFile(toolName, args); target != "" {
+		return fmt.Sprintf("%s(%s)", action, target)
+	}
+	return action
+}
+
+// firstLine returns the first non-empty line of text
+func firstLine(text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// isTerminal reports whether f is attached to a terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// stdinPiped reports whether input is being piped or redirected into bazinga
+func stdinPiped() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice == 0 && (info.Mode()&os.ModeNamedPipe != 0 || info.Mode().IsRegular())
+}
+
+// headlessPrompt builds the request from the --prompt flag and piped stdin. When both
+// are given, stdin is attached below the prompt, e.g. `git diff | bazinga -p "Review this"`.
+func headlessPrompt(prompt string, stdin io.Reader) (string, error) {
+	prompt = strings.TrimSpace(prompt)
+	if stdin == nil {
+		return prompt, nil
+	}
+
+	data, err := io.ReadAll(stdin)
+	if err != nil {
+		return "", fmt.Errorf("failed to read stdin: %w", err)
+	}
+	input := strings.TrimSpace(string(data))
+
+	switch {
+	case prompt == "":
+		return input, nil
+	case input == "":
+		return prompt, nil
+	default:
+		return prompt + "\n\n" + input, nil
+	}
+}
+
+// headlessPrinter writes the output of a headless run in one of the --format styles
+type headlessPrinter interface {
+	// handler returns the stream handler that prints the response as it arrives
+	handler() session.StreamHandler
+	// needsApproval reports a tool call denied because no --yes was given
+	needsApproval(toolCall *llm.ToolCall)
+	// finish writes the end of the run; err is nil if it succeeded
+	finish(summary *headlessSummary, err error)
+}
+
+// headlessSummary describes a finished headless run
+type headlessSummary struct {
+	SessionID    string
+	Text         string
+	InputTokens  int
+	OutputTokens int
+	Cost         float64
+}
+
+// newHeadlessPrinter returns the printer for a --format value
+func newHeadlessPrinter(format string) (headlessPrinter, error) {
+	switch format {
+	case "", "text":
+		return newHeadlessOutput(os.Stdout, os.Stderr), nil
+	case "json":
+		return newJSONOutput(os.Stdout), nil
+	default:
+		return nil, fmt.Errorf("unknown output format %q (use text or json)", format)
+	}
+}
+
+func (o *headlessOutput) needsApproval(toolCall *llm.ToolCall) {
+	o.note(o.muted.Render(fmt.Sprintf("  %s needs approval; rerun with --yes to allow it", describeToolCall(toolCall.Name, toolCall.Input))))
+}
+
+func (o *headlessOutput) finish(summary *headlessSummary, err error) {
+	if summary != nil && summary.Text != "" && !strings.HasSuffix(summary.Text, "\n") {
+		o.text("\n")
+	}
+}
+
+// runHeadless sends a single request through a session, prints the response and tool
+// activity, and returns an error if the request failed or the stream reported one
+func runHeadless(ctx context.Context, flags *GlobalFlags, files []string) error {
+	printer, err := newHeadlessPrinter(flags.Format)
+	if err != nil {
+		return err
+	}
+
+	summary, err := executeHeadless(ctx, flags, files, printer)
+	printer.finish(summary, err)
+	return err
+}
+
+// executeHeadless runs the request for runHeadless. The summary is nil if the request
+// could not be sent.
+func executeHeadless(ctx context.Context, flags *GlobalFlags, files []string, printer headlessPrinter) (*headlessSummary, error) {
+	var stdin io.Reader
+	if stdinPiped() {
+		stdin = os.Stdin
+	}
+	prompt, err := headlessPrompt(flags.Prompt, stdin)
+	if err != nil {
+		return nil, err
+	}
+	if prompt == "" {
+		return nil, fmt.Errorf("nothing to send: pass --prompt or pipe a request on stdin")
+	}
+
+	cfg, err := loadConfig(flags)
+	if err != nil {
+		return nil, err
+	}
+
+	llmManager, err := newLLMManager(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if len(llmManager.ListProviders()) == 0 {
+		return nil, fmt.Errorf("no LLM provider has usable credentials; run `bazinga doctor` to see what is missing")
+	}
+
+	sessionManager := session.NewManager(llmManager, cfg)
+
+	var sess *session.Session
+	if flags.SessionID != "" {
+		sess, err = sessionManager.LoadSession(ctx, flags.SessionID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load session %s: %w", flags.SessionID, err)
+		}
+	} else {
+		sess, err = sessionManager.CreateSession(ctx, &session.CreateOptions{
+			Files:           files,
+			AutoDetectFiles: len(files) == 0,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create session: %w", err)
+		}
+	}
+	defer func() {
+		_ = sess.Close()
+	}()
+
+	for _, file := range files {
+		if err := sess.AddFile(ctx, file); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to add file %s: %v\n", file, err)
+		}
+	}
+
+	policy := policyDefault
+	switch {
+	case flags.Yes:
+		policy = policyApprove
+	case flags.Deny:
+		policy = policyDeny
+	}
+	return runHeadlessSession(ctx, sess, prompt, policy, printer)
+}
+
+// runHeadlessSession sends prompt through sess, deciding the tool calls that would
+// prompt by policy, and prints the response with printer
+func runHeadlessSession(ctx context.Context, sess *session.Session, prompt string, policy permissionPolicy, printer headlessPrinter) (*headlessSummary, error) {
+	if permissionManager := sess.GetPermissionManager(); permissionManager != nil {
+		permissionManager.SetPromptCallback(func(toolCall *llm.ToolCall) bool {
+			if policy == policyApprove || sess.TerminatorApproves(toolCall) {
+				return true
+			}
+			if policy == policyDefault {
+				printer.needsApproval(toolCall)
+			}
+			return false
+		})
+	}
+	if toolExecutor := sess.GetToolExecutor(); toolExecutor != nil {
+		toolExecutor.SetFileChangeCallback(sess.RecordFileChange)
+	}
+
+	// Usage is reported for this request only, not for earlier turns of a resumed session
+	inputBefore, outputBefore := sess.GetSessionTokens()
+	costBefore := sess.GetSessionCost()
+
+	stream, err := sess.ProcessMessageStream(ctx, prompt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to process message: %w", err)
+	}
+
+	result, err := printer.handler().Consume(ctx, stream)
+
+	input, output := sess.GetSessionTokens()
+	summary := &headlessSummary{
+		SessionID:    sess.ID,
+		Text:         result.Text,
+		InputTokens:  input - inputBefore,
+		OutputTokens: output - outputBefore,
+		Cost:         sess.GetSessionCost() - costBefore,
+	}
+
+	if err != nil {
+		return summary, err
+	}
+	if len(result.Errors) > 0 {
+		return summary, fmt.Errorf("the response stream failed: %s", result.Errors[len(result.Errors)-1])
+	}
+
+	return summary, nil
+}
@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"encoding/json"
+	"github.com/tildaslashalef/bazinga/internal/llm"
+	"github.com/tildaslashalef/bazinga/internal/session"
+	"io"
+	"sync"
+)
+
+// jsonEvent is one line of --format json output. Type is one of text, thinking,
+// notice, task_start, tool_start, tool_output, tool_result, permission_required, error
+// and done.
+type jsonEvent struct {
+	Type string `json:"type"`
+
+	// text, thinking, notice and tool_output
+	Content string `json:"content,omitempty"`
+
+	// Tool events
+	Tool      string                 `json:"tool,omitempty"`
+	Args      map[string]interface{} `json:"args,omitempty"`
+	TaskGroup string                 `json:"task_group,omitempty"`
+	Result    string                 `json:"result,omitempty"`
+	IsError   bool                   `json:"is_error,omitempty"`
+
+	// error and done
+	Error string `json:"error,omitempty"`
+
+	// done
+	SessionID    string   `json:"session_id,omitempty"`
+	InputTokens  *int     `json:"input_tokens,omitempty"`
+	OutputTokens *int     `json:"output_tokens,omitempty"`
+	Cost         *float64 `json:"cost,omitempty"`
+}
+
+// jsonOutput writes a headless run as newline-delimited JSON events on stdout
+type jsonOutput struct {
+	mu      sync.Mutex // Permission events are written from the session's goroutine
+	encoder *json.Encoder
+}
+
+func newJSONOutput(w io.Writer) *jsonOutput {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	return &jsonOutput{encoder: encoder}
+}
+
+// emit writes one event per line
+func (o *jsonOutput) emit(event jsonEvent) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	_ = o.encoder.Encode(event)
+}
+
+func (o *jsonOutput) handler() session.StreamHandler {
+	return session.StreamHandler{
+		OnText: func(text string) {
+			o.emit(jsonEvent{Type: "text", Content: text})
+		},
+		OnError: func(message string) {
+			o.emit(jsonEvent{Type: "error", Error: message})
+		},
+		OnNotice: func(message string) {
+			o.emit(jsonEvent{Type: "notice", Content: message})
+		},
+		OnThinking: func(text string) {
+			o.emit(jsonEvent{Type: "thinking", Content: text})
+		},
+		OnTaskStart: func(taskName string) {
+			o.emit(jsonEvent{Type: "task_start", TaskGroup: taskName})
+		},
+		OnToolStart: func(completion *llm.ToolCompletion) {
+			o.emit(jsonEvent{Type: "tool_start", Tool: completion.ToolName, Args: completion.Args, TaskGroup: completion.TaskGroup})
+		},
+		OnToolOutput: func(completion *llm.ToolCompletion) {
+			o.emit(jsonEvent{Type: "tool_output", Tool: completion.ToolName, Content: completion.Result, TaskGroup: completion.TaskGroup})
+		},
+		OnToolEnd: func(completion *llm.ToolCompletion) {
+			o.emit(jsonEvent{
+				Type:      "tool_result",
+				Tool:      completion.ToolName,
+				Args:      completion.Args,
+				TaskGroup: completion.TaskGroup,
+				Result:    completion.Result,
+				IsError:   completion.State == "error",
+				Error:     completion.Error,
+			})
+		},
+	}
+}
+
+func (o *jsonOutput) needsApproval(toolCall *llm.ToolCall) {
+	o.emit(jsonEvent{Type: "permission_required", Tool: toolCall.Name, Args: toolCall.Input})
+}
+
+// finish writes the done event, which is always the last line of output
+func (o *jsonOutput) finish(summary *headlessSummary, err error) {
+	event := jsonEvent{Type: "done"}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	if summary != nil {
+		event.SessionID = summary.SessionID
+		event.InputTokens = &summary.InputTokens
+		event.OutputTokens = &summary.OutputTokens
+		event.Cost = &summary.Cost
+	}
+	o.emit(event)
+}
@@ -0,0 +1,175 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/tildaslashalef/bazinga/internal/config"
+	"github.com/tildaslashalef/bazinga/internal/llm"
+	"github.com/tildaslashalef/bazinga/internal/session"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scriptedProvider replays one scripted stream per request
+type scriptedProvider struct {
+	scripts  [][]*llm.StreamChunk
+	requests int
+}
+
+func (p *scriptedProvider) Name() string                    { return "scripted" }
+func (p *scriptedProvider) GetAvailableModels() []llm.Model { return nil }
+func (p *scriptedProvider) GetDefaultModel() string         { return "test-model" }
+func (p *scriptedProvider) SupportsFunctionCalling() bool   { return true }
+func (p *scriptedProvider) EstimateTokens(text string) int  { return len(text) / 4 }
+func (p *scriptedProvider) GetTokenLimit() int              { return 100000 }
+func (p *scriptedProvider) Close() error                    { return nil }
+
+func (p *scriptedProvider) GenerateResponse(ctx context.Context, req *llm.GenerateRequest) (*llm.Response, error) {
+	return &llm.Response{Content: "Scripted session"}, nil
+}
+
+func (p *scriptedProvider) StreamResponse(ctx context.Context, req *llm.GenerateRequest) (<-chan *llm.StreamChunk, error) {
+	script := p.scripts[min(p.requests, len(p.scripts)-1)]
+	p.requests++
+
+	ch := make(chan *llm.StreamChunk, len(script))
+	for _, chunk := range script {
+		ch <- chunk
+	}
+	close(ch)
+	return ch, nil
+}
+
+// newScriptedSession creates a session in an empty project directory whose model lists
+// the todos and writes notes.txt, then answers "Done."
+func newScriptedSession(t *testing.T) *session.Session {
+	t.Setenv("HOME", t.TempDir())
+	root := t.TempDir()
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(root))
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	provider := &scriptedProvider{scripts: [][]*llm.StreamChunk{
+		{
+			{Type: "content_block_start", Index: 0, ToolCall: &llm.ToolCall{ID: "call_todo", Name: "todo_read"}},
+			{Type: "content_block_delta", Index: 0, ToolInputDelta: `{}`},
+			{Type: "content_block_start", Index: 1, ToolCall: &llm.ToolCall{ID: "call_write", Name: "write_file"}},
+			{Type: "content_block_delta", Index: 1, ToolInputDelta: `{"file_path": "notes.txt", "content": "hello\n"}`},
+			{Type: "content_block_stop"},
+		},
+		{
+			{Type: "content_block_delta", Content: "Done."},
+		},
+	}}
+	llmManager := llm.NewManager()
+	require.NoError(t, llmManager.RegisterProvider("scripted", provider))
+	require.NoError(t, llmManager.SetDefaultProvider("scripted"))
+
+	cfg := config.DefaultConfig()
+	cfg.LLM.DefaultProvider = "scripted"
+	cfg.LLM.DefaultModel = "test-model"
+
+	sess, err := session.NewManager(llmManager, cfg).CreateSession(context.Background(), &session.CreateOptions{Name: "Headless"})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = sess.Close() })
+	return sess
+}
+
+// runJSON runs the scripted session headless with --format json and returns its events
+func runJSON(t *testing.T, policy permissionPolicy) ([]jsonEvent, *session.Session) {
+	sess := newScriptedSession(t)
+
+	var stdout bytes.Buffer
+	printer := newJSONOutput(&stdout)
+	summary, err := runHeadlessSession(context.Background(), sess, "Write some notes", policy, printer)
+	printer.finish(summary, err)
+	require.NoError(t, err)
+
+	var events []jsonEvent
+	for _, line := range strings.Split(strings.TrimSpace(stdout.String()), "\n") {
+		var event jsonEvent
+		require.NoError(t, json.Unmarshal([]byte(line), &event), "each line is one JSON event: %s", line)
+		events = append(events, event)
+	}
+	return events, sess
+}
+
+// eventsOf returns the events of a type, for a tool if one is given
+func eventsOf(events []jsonEvent, eventType, tool string) []jsonEvent {
+	var matched []jsonEvent
+	for _, event := range events {
+		if event.Type == eventType && (tool == "" || event.Tool == tool) {
+			matched = append(matched, event)
+		}
+	}
+	return matched
+}
+
+// TestHeadlessJSONEvents tests the events a headless run writes for tool calls, text and
+// the end of the run, with a call that needs approval
+func TestHeadlessJSONEvents(t *testing.T) {
+	events, sess := runJSON(t, policyDefault)
+
+	require.Len(t, eventsOf(events, "tool_start", "todo_read"), 1)
+	todoResults := eventsOf(events, "tool_result", "todo_read")
+	require.Len(t, todoResults, 1)
+	assert.False(t, todoResults[0].IsError)
+
+	// Without --yes the write is reported as needing approval and fails
+	required := eventsOf(events, "permission_required", "write_file")
+	require.Len(t, required, 1)
+	assert.Equal(t, "notes.txt", required[0].Args["file_path"])
+	writeResults := eventsOf(events, "tool_result", "write_file")
+	require.Len(t, writeResults, 1)
+	assert.True(t, writeResults[0].IsError)
+	assert.NoFileExists(t, filepath.Join(sess.GetRootPath(), "notes.txt"))
+
+	text := eventsOf(events, "text", "")
+	require.NotEmpty(t, text)
+	assert.Equal(t, "Done.", text[len(text)-1].Content)
+
+	done := events[len(events)-1]
+	assert.Equal(t, "done", done.Type, "the last line ends the run")
+	assert.Equal(t, sess.ID, done.SessionID)
+	assert.Empty(t, done.Error)
+	require.NotNil(t, done.InputTokens)
+	require.NotNil(t, done.Cost)
+}
+
+// TestHeadlessJSONDeny tests that --deny refuses calls that would prompt without
+// reporting them as needing approval
+func TestHeadlessJSONDeny(t *testing.T) {
+	events, sess := runJSON(t, policyDeny)
+
+	assert.Empty(t, eventsOf(events, "permission_required", ""))
+	writeResults := eventsOf(events, "tool_result", "write_file")
+	require.Len(t, writeResults, 1)
+	assert.True(t, writeResults[0].IsError)
+	assert.Contains(t, writeResults[0].Result+writeResults[0].Error, "permission denied")
+	assert.NoFileExists(t, filepath.Join(sess.GetRootPath(), "notes.txt"))
+
+	// Read-only calls still run
+	todoResults := eventsOf(events, "tool_result", "todo_read")
+	require.Len(t, todoResults, 1)
+	assert.False(t, todoResults[0].IsError)
+	assert.Equal(t, "done", events[len(events)-1].Type)
+}
+
+// TestHeadlessJSONApprove tests that --yes runs calls that would prompt
+func TestHeadlessJSONApprove(t *testing.T) {
+	events, sess := runJSON(t, policyApprove)
+
+	assert.Empty(t, eventsOf(events, "permission_required", ""))
+	writeResults := eventsOf(events, "tool_result", "write_file")
+	require.Len(t, writeResults, 1)
+	assert.False(t, writeResults[0].IsError, "error: %s", writeResults[0].Error)
+	assert.FileExists(t, filepath.Join(sess.GetRootPath(), "notes.txt"))
+	assert.Equal(t, "done", events[len(events)-1].Type)
+}
@@ -0,0 +1,162 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/tildaslashalef/bazinga/internal/config"
+	"github.com/tildaslashalef/bazinga/internal/llm"
+	"github.com/tildaslashalef/bazinga/internal/session"
+	"github.com/tildaslashalef/bazinga/internal/tools"
+	"github.com/tildaslashalef/bazinga/internal/ui"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+)
+
+// replayOptions controls how a saved session is replayed
+type replayOptions struct {
+	Step     bool // Wait for Enter between steps
+	Rerun    bool // Run read-only tools again against the current directory
+	MaxLines int  // Lines of tool output shown per result (0 = no limit)
+}
+
+// newReplayCommand creates the replay subcommand
+func newReplayCommand() *cobra.Command {
+	var opts replayOptions
+
+	cmd := &cobra.Command{
+		Use:   "replay <session-id>",
+		Short: "Replay a saved session's conversation and tool actions",
+		Long: `Replay the messages and tool actions of a saved session without calling the LLM.
+With --rerun, read-only tools (reads, searches and git queries) are run again against the
+current directory so their saved results can be compared with the current ones.`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.Load()
+			if err != nil {
+				return fmt.Errorf("failed to load configuration: %w", err)
+			}
+
+			// Replay never sends requests, so no providers are registered
+			manager := session.NewManager(llm.NewManager(), cfg)
+			saved, steps, err := manager.LoadReplay(args[0])
+			if err != nil {
+				return err
+			}
+
+			var executor *tools.ToolExecutor
+			if opts.Rerun {
+				cwd, err := os.Getwd()
+				if err != nil {
+					return fmt.Errorf("failed to get working directory: %w", err)
+				}
+				executor = tools.NewToolExecutor(cwd)
+			}
+
+			out := cmd.OutOrStdout()
+			fmt.Fprintf(out, "Replaying session %s (%s, %d steps)\n", saved.ID, saved.RootPath, len(steps))
+			return replaySteps(cmd.Context(), out, cmd.InOrStdin(), steps, executor, opts)
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.Step, "step", false, "Wait for Enter before each step (q to stop)")
+	cmd.Flags().BoolVar(&opts.Rerun, "rerun", false, "Run read-only tools again against the current directory")
+	cmd.Flags().IntVar(&opts.MaxLines, "max-lines", 20, "Lines of tool output to show per result (0 = no limit)")
+
+	return cmd
+}
+
+// replaySteps prints each step, pausing between them in step mode. Read-only tool calls
+// are run again with executor when it is set.
+func replaySteps(ctx context.Context, out io.Writer, in io.Reader, steps []session.ReplayStep, executor *tools.ToolExecutor, opts replayOptions) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	reader := bufio.NewReader(in)
+
+	for i, step := range steps {
+		if opts.Step && i > 0 {
+			fmt.Fprint(out, lipgloss.NewStyle().Foreground(ui.TextSecondary).Render("[Enter] next, q to stop "))
+			line, err := reader.ReadString('\n')
+			if strings.EqualFold(strings.TrimSpace(line), "q") || (err != nil && line == "") {
+				return nil
+			}
+		}
+
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, renderReplayStep(i+1, step, opts.MaxLines))
+
+		if executor != nil && step.Kind == session.ReplayToolCall && session.IsReadOnlyTool(step.ToolName) {
+			result, err := executor.ExecuteTool(ctx, &llm.ToolCall{ID: step.ToolCallID, Name: step.ToolName, Input: step.ToolInput})
+			current := session.ReplayStep{Kind: session.ReplayToolResult, ToolName: step.ToolName, Content: result}
+			if err != nil {
+				current.Content = "Error: " + err.Error()
+				current.IsError = true
+			}
+			fmt.Fprintln(out, lipgloss.NewStyle().Foreground(ui.WarningColor).Render("  ↻ current result:"))
+			fmt.Fprintln(out, indentReplay(truncateReplay(current.Content, opts.MaxLines)))
+		}
+	}
+
+	return nil
+}
+
+// renderReplayStep formats one step with a numbered header
+func renderReplayStep(n int, step session.ReplayStep, maxLines int) string {
+	label := lipgloss.NewStyle().Bold(true)
+	var header, body string
+
+	switch step.Kind {
+	case session.ReplayUser:
+		header = label.Foreground(ui.SecondaryColor).Render("👤 User")
+		body = step.Content
+	case session.ReplayAssistant:
+		header = label.Foreground(ui.AccentColor).Render("🧙 Assistant")
+		body = step.Content
+	case session.ReplaySystem:
+		header = label.Foreground(ui.TextSecondary).Render("⚙ System")
+		body = truncateReplay(step.Content, maxLines)
+	case session.ReplayToolCall:
+		header = label.Foreground(ui.WarningColor).Render("🔧 " + step.ToolName)
+		if input, err := json.Marshal(step.ToolInput); err == nil && len(step.ToolInput) > 0 {
+			body = string(input)
+		}
+	case session.ReplayToolResult:
+		if step.IsError {
+			header = label.Foreground(ui.ErrorColor).Render("✗ " + step.ToolName + " failed")
+		} else {
+			header = label.Foreground(ui.SuccessColor).Render("✓ " + step.ToolName + " result")
+		}
+		body = truncateReplay(step.Content, maxLines)
+	}
+
+	number := lipgloss.NewStyle().Foreground(ui.TextSecondary).Render(fmt.Sprintf("%3d.", n))
+	if body == "" {
+		return number + " " + header
+	}
+	return number + " " + header + "\n" + indentReplay(body)
+}
+
+// truncateReplay keeps the first maxLines lines of text and notes how many were left out
+func truncateReplay(text string, maxLines int) string {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	if maxLines <= 0 || len(lines) <= maxLines {
+		return strings.Join(lines, "\n")
+	}
+	return strings.Join(lines[:maxLines], "\n") + fmt.Sprintf("\n… %d more lines", len(lines)-maxLines)
+}
+
+// indentReplay indents each line of a step body under its header
+func indentReplay(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = "     " + line
+	}
+	return strings.Join(lines, "\n")
+}
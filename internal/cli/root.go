@@ -7,6 +7,7 @@ import (
 	"github.com/tildaslashalef/bazinga/internal/llm"
 	"github.com/tildaslashalef/bazinga/internal/llm/anthropic"
 	"github.com/tildaslashalef/bazinga/internal/llm/bedrock"
+	"github.com/tildaslashalef/bazinga/internal/llm/gemini"
 	"github.com/tildaslashalef/bazinga/internal/llm/ollama"
 	"github.com/tildaslashalef/bazinga/internal/llm/openai"
 	"github.com/tildaslashalef/bazinga/internal/loggy"
@@ -15,6 +16,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/spf13/cobra"
@@ -37,12 +39,20 @@ type GlobalFlags struct {
 	Provider   string
 	Region     string
 	SessionID  string
-	Terminator bool // Bypass all permission checks
+	Terminator bool   // Bypass all permission checks
+	Yolo       string // Bypass the permission checks of a terminator scope
+
+	// Headless mode
+	Prompt string // Run this request without the TUI
+	Yes    bool   // Approve tool calls that would prompt
+	Deny   bool   // Deny tool calls that would prompt
+	Format string // Output format: text or json
 }
 
 // NewRootCommand creates the root cobra command
 func NewRootCommand(buildInfo *BuildInfo) *cobra.Command {
 	var flags GlobalFlags
+	var configErr error
 
 	cmd := &cobra.Command{
 		Use:   "bazinga [files...]",
@@ -52,8 +62,16 @@ using Large Language Models. It supports multiple providers and maintains sessio
 for intelligent pair programming.`,
 		Version: fmt.Sprintf("%s (commit: %s, built: %s)", buildInfo.Version, buildInfo.Commit, buildInfo.Date),
 		Args:    cobra.ArbitraryArgs,
+		// Commands other than doctor cannot run with an unreadable config
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return configErr
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runInteractiveSession(cmd.Context(), &flags, args)
+			// A prompt or piped input runs one request without the TUI
+			if flags.Prompt != "" || stdinPiped() {
+				return runHeadless(cmd.Context(), &flags, args)
+			}
+			return runInteractiveSession(cmd.Context(), &flags, buildInfo, args)
 		},
 		SilenceUsage: true,
 	}
@@ -61,24 +79,34 @@ for intelligent pair programming.`,
 	// Global flags
 	cmd.PersistentFlags().StringVar(&flags.ConfigFile, "config", "", "config file (default: ~/.github.com/tildaslashalef/bazinga/config.yaml)")
 	cmd.PersistentFlags().StringVar(&flags.Model, "model", "", "LLM model to use")
-	cmd.PersistentFlags().StringVar(&flags.Provider, "provider", "", "LLM provider (bedrock, openai, anthropic, ollama)")
+	cmd.PersistentFlags().StringVar(&flags.Provider, "provider", "", "LLM provider (bedrock, openai, anthropic, ollama, gemini)")
 	cmd.PersistentFlags().StringVar(&flags.Region, "region", "", "AWS region for Bedrock")
 	cmd.PersistentFlags().StringVar(&flags.SessionID, "session", "", "Continue existing session by ID")
 	cmd.PersistentFlags().BoolVar(&flags.Terminator, "terminator", false, "DANGEROUS: Bypass all permission checks")
+	cmd.PersistentFlags().StringVar(&flags.Yolo, "yolo", "", "approve without asking: edits (file changes), safe (all but destructive operations) or all")
+
+	// Headless mode flags
+	cmd.Flags().StringVarP(&flags.Prompt, "prompt", "p", "", "run a single request without the TUI and print the response")
+	cmd.Flags().BoolVar(&flags.Yes, "yes", false, "headless mode: approve tool calls that would ask for permission")
+	cmd.Flags().BoolVar(&flags.Deny, "deny", false, "headless mode: deny tool calls that would ask for permission (the default)")
+	cmd.Flags().StringVar(&flags.Format, "format", "text", "headless mode: output format (text, json)")
+	cmd.MarkFlagsMutuallyExclusive("yes", "deny")
 
 	// Add subcommands
 	cmd.AddCommand(newVersionCommand(buildInfo))
+	cmd.AddCommand(newDoctorCommand(func() error { return configErr }))
+	cmd.AddCommand(newReplayCommand())
 
 	// Setup configuration
 	cobra.OnInitialize(func() {
-		initConfig(&flags)
+		configErr = initConfig(&flags)
 	})
 
 	return cmd
 }
 
 // initConfig initializes the configuration
-func initConfig(flags *GlobalFlags) {
+func initConfig(flags *GlobalFlags) error {
 	if flags.ConfigFile != "" {
 		// Use config file from the flag
 		viper.SetConfigFile(flags.ConfigFile)
@@ -86,8 +114,7 @@ func initConfig(flags *GlobalFlags) {
 		// Find home directory
 		home, err := os.UserHomeDir()
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error finding home directory: %v\n", err)
-			os.Exit(1)
+			return fmt.Errorf("error finding home directory: %w", err)
 		}
 
 		// Search config in home directory with name ".bazinga" (without extension)
@@ -107,7 +134,7 @@ func initConfig(flags *GlobalFlags) {
 	if err := viper.ReadInConfig(); err != nil {
 		// If config file not found, create a default one
 		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
-			fmt.Println("No config file found, creating default config...")
+			fmt.Fprintln(os.Stderr, "No config file found, creating default config...")
 			if err := config.Init(); err != nil {
 				fmt.Fprintf(os.Stderr, "Error creating default config: %v\n", err)
 			}
@@ -117,26 +144,61 @@ func initConfig(flags *GlobalFlags) {
 			}
 		} else {
 			// This is a different error than not found
-			fmt.Fprintf(os.Stderr, "Error reading config file: %v\n", err)
-			os.Exit(1)
+			return fmt.Errorf("error reading config file: %w", err)
 		}
 	}
+
+	return nil
 }
 
-// runInteractiveSession starts an interactive coding session
-func runInteractiveSession(ctx context.Context, flags *GlobalFlags, files []string) error {
-	// Load configuration
+// loadConfig loads the configuration, reconfigures logging and applies command-line overrides
+func loadConfig(flags *GlobalFlags) (*config.Config, error) {
 	cfg, err := config.Load()
 	if err != nil {
-		return fmt.Errorf("failed to load configuration: %w", err)
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
 	}
 
 	// Reconfigure logging with the loaded config
 	if err := loggy.Reconfigure(&cfg.Logging); err != nil {
-		fmt.Printf("Warning: Failed to reconfigure logging: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Warning: Failed to reconfigure logging: %v\n", err)
+	}
+
+	switch flags.Yolo {
+	case "", config.TerminatorAll, config.TerminatorEdits, config.TerminatorSafe:
+	default:
+		return nil, fmt.Errorf("--yolo must be edits, safe or all, got %q", flags.Yolo)
+	}
+
+	applyFlagOverrides(cfg, flags)
+	switch cfg.Security.TerminatorScope {
+	case config.TerminatorAll:
+		fmt.Fprintf(os.Stderr, "⚠️  TERMINATOR MODE ENABLED - All permission checks bypassed!\n")
+	case config.TerminatorEdits, config.TerminatorSafe:
+		fmt.Fprintf(os.Stderr, "⚠️  TERMINATOR MODE ENABLED (%s) - Some permission checks bypassed\n", cfg.Security.TerminatorScope)
+	}
+
+	return cfg, nil
+}
+
+// reloadConfig reads the config file again for /reload-config. Viper keeps its
+// previous values when the file does not parse, and the session manager reconfigures
+// logging once it accepts the result.
+func reloadConfig(flags *GlobalFlags) (*config.Config, error) {
+	if err := viper.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("error reading config file: %w", err)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
 	}
 
-	// Override config with command-line flags
+	applyFlagOverrides(cfg, flags)
+	return cfg, nil
+}
+
+// applyFlagOverrides overrides config with command-line flags
+func applyFlagOverrides(cfg *config.Config, flags *GlobalFlags) {
 	if flags.Model != "" {
 		cfg.LLM.DefaultModel = flags.Model
 	}
@@ -148,95 +210,145 @@ func runInteractiveSession(ctx context.Context, flags *GlobalFlags, files []stri
 	}
 	if flags.Terminator {
 		cfg.Security.Terminator = true
-		fmt.Printf("⚠️  TERMINATOR MODE ENABLED - All permission checks bypassed!\n")
+		cfg.Security.TerminatorScope = config.TerminatorAll
+	}
+	if flags.Yolo != "" {
+		cfg.Security.TerminatorScope = flags.Yolo
 	}
+}
+
+// providerNames are the supported providers, in the order they are registered
+var providerNames = []string{"bedrock", "openai", "anthropic", "ollama", "gemini"}
 
-	// Initialize LLM manager
+// newLLMManager registers every enabled provider that has usable credentials
+func newLLMManager(cfg *config.Config) (*llm.Manager, error) {
 	llmManager := llm.NewManager()
 
-	// Register Bedrock provider
-	if cfg.Providers.Bedrock.Enabled {
-		bedrockProvider, err := bedrock.NewProvider(&bedrock.Config{
+	for _, name := range providerNames {
+		provider, err := newProvider(cfg, name)
+		if err != nil {
+			// Missing credentials should not stop the TUI from starting
+			loggy.Warn("Provider unavailable", "provider", name, "error", err)
+			fmt.Fprintf(os.Stderr, "Warning: %s provider unavailable: %v\n", name, err)
+			continue
+		}
+		if provider == nil {
+			continue
+		}
+
+		if err := llmManager.RegisterProvider(name, provider); err != nil {
+			return nil, fmt.Errorf("failed to register %s provider: %w", name, err)
+		}
+
+		// Set as default if specified; Bedrock is the default when none is
+		if cfg.LLM.DefaultProvider == name || (cfg.LLM.DefaultProvider == "" && name == "bedrock") {
+			if err := llmManager.SetDefaultProvider(name); err != nil {
+				return nil, fmt.Errorf("failed to set default provider: %w", err)
+			}
+		}
+	}
+
+	if len(llmManager.ListProviders()) == 0 {
+		if cfg.LLM.RequireProvider {
+			return nil, fmt.Errorf("no LLM provider has usable credentials; run `bazinga doctor` to see what is missing")
+		}
+		fmt.Fprintln(os.Stderr, "Warning: No LLM provider has usable credentials. Starting without one; run `bazinga doctor` for details.")
+	}
+
+	return llmManager, nil
+}
+
+// newProvider creates the named provider from cfg. It returns a nil provider when the
+// provider is disabled or has no API key.
+func newProvider(cfg *config.Config, name string) (llm.Provider, error) {
+	retry := llm.RetryConfig{
+		MaxAttempts: cfg.LLM.Retry.MaxAttempts,
+		BaseDelay:   time.Duration(cfg.LLM.Retry.BaseDelayMs) * time.Millisecond,
+	}
+
+	switch name {
+	case "bedrock":
+		if !cfg.Providers.Bedrock.Enabled {
+			return nil, nil
+		}
+		provider, err := bedrock.NewProvider(&bedrock.Config{
 			Region:       cfg.Providers.Bedrock.Region,
 			AccessKeyID:  cfg.Providers.Bedrock.AccessKeyID,
 			SecretKey:    cfg.Providers.Bedrock.SecretAccessKey,
 			SessionToken: cfg.Providers.Bedrock.SessionToken,
 			Profile:      cfg.Providers.Bedrock.Profile,
 			AuthMethod:   cfg.Providers.Bedrock.AuthMethod,
+			Retry:        retry,
 		})
 		if err != nil {
-			return fmt.Errorf("failed to create Bedrock provider: %w", err)
+			return nil, err
 		}
+		return provider, nil
 
-		if err := llmManager.RegisterProvider("bedrock", bedrockProvider); err != nil {
-			return fmt.Errorf("failed to register Bedrock provider: %w", err)
+	case "openai":
+		if !cfg.Providers.OpenAI.Enabled || cfg.Providers.OpenAI.APIKey == "" {
+			return nil, nil
 		}
-
-		// Set as default if specified
-		if cfg.LLM.DefaultProvider == "bedrock" || cfg.LLM.DefaultProvider == "" {
-			if err := llmManager.SetDefaultProvider("bedrock"); err != nil {
-				return fmt.Errorf("failed to set default provider: %w", err)
-			}
-		}
-	}
-
-	// Register OpenAI provider
-	if cfg.Providers.OpenAI.Enabled && cfg.Providers.OpenAI.APIKey != "" {
-		openaiProvider := openai.NewProviderWithConfig(&openai.Config{
+		return openai.NewProviderWithConfig(&openai.Config{
 			APIKey:  cfg.Providers.OpenAI.APIKey,
 			BaseURL: cfg.Providers.OpenAI.BaseURL,
 			OrgID:   cfg.Providers.OpenAI.OrgID,
-		})
-		if err := llmManager.RegisterProvider("openai", openaiProvider); err != nil {
-			return fmt.Errorf("failed to register OpenAI provider: %w", err)
-		}
+			Retry:   retry,
+		}), nil
 
-		// Set as default if specified
-		if cfg.LLM.DefaultProvider == "openai" {
-			if err := llmManager.SetDefaultProvider("openai"); err != nil {
-				return fmt.Errorf("failed to set default provider: %w", err)
-			}
+	case "anthropic":
+		if !cfg.Providers.Anthropic.Enabled || cfg.Providers.Anthropic.APIKey == "" {
+			return nil, nil
 		}
-	}
-
-	// Register Anthropic provider
-	if cfg.Providers.Anthropic.Enabled && cfg.Providers.Anthropic.APIKey != "" {
-		anthropicProvider := anthropic.NewProviderWithConfig(&anthropic.Config{
+		return anthropic.NewProviderWithConfig(&anthropic.Config{
 			APIKey:  cfg.Providers.Anthropic.APIKey,
 			BaseURL: cfg.Providers.Anthropic.BaseURL,
-		})
-		if err := llmManager.RegisterProvider("anthropic", anthropicProvider); err != nil {
-			return fmt.Errorf("failed to register Anthropic provider: %w", err)
-		}
+			Retry:   retry,
+		}), nil
 
-		// Set as default if specified
-		if cfg.LLM.DefaultProvider == "anthropic" {
-			if err := llmManager.SetDefaultProvider("anthropic"); err != nil {
-				return fmt.Errorf("failed to set default provider: %w", err)
-			}
+	case "ollama":
+		if !cfg.Providers.Ollama.Enabled {
+			return nil, nil
 		}
-	}
-
-	// Register Ollama provider
-	if cfg.Providers.Ollama.Enabled {
-		ollamaProvider := ollama.NewProviderWithConfig(&ollama.Config{
+		return ollama.NewProviderWithConfig(&ollama.Config{
 			BaseURL: cfg.Providers.Ollama.BaseURL,
 			Model:   cfg.Providers.Ollama.Model,
-		})
-		if err := llmManager.RegisterProvider("ollama", ollamaProvider); err != nil {
-			return fmt.Errorf("failed to register Ollama provider: %w", err)
-		}
+			Retry:   retry,
+		}), nil
 
-		// Set as default if specified
-		if cfg.LLM.DefaultProvider == "ollama" {
-			if err := llmManager.SetDefaultProvider("ollama"); err != nil {
-				return fmt.Errorf("failed to set default provider: %w", err)
-			}
+	case "gemini":
+		if !cfg.Providers.Gemini.Enabled {
+			return nil, nil
 		}
+		return gemini.NewProviderWithConfig(&gemini.Config{
+			APIKey:  cfg.Providers.Gemini.APIKey,
+			BaseURL: cfg.Providers.Gemini.BaseURL,
+			Model:   cfg.Providers.Gemini.Model,
+			Retry:   retry,
+		}), nil
+
+	default:
+		return nil, fmt.Errorf("unknown provider %s", name)
+	}
+}
+
+// runInteractiveSession starts an interactive coding session
+func runInteractiveSession(ctx context.Context, flags *GlobalFlags, buildInfo *BuildInfo, files []string) error {
+	cfg, err := loadConfig(flags)
+	if err != nil {
+		return err
+	}
+
+	llmManager, err := newLLMManager(cfg)
+	if err != nil {
+		return err
 	}
 
-	// Create session manager
+	// Create session manager; /reload-config reads the config file again
 	sessionManager := session.NewManager(llmManager, cfg)
+	sessionManager.SetConfigReloader(func() (*config.Config, error) {
+		return reloadConfig(flags)
+	}, newProvider)
 
 	// Start or resume session
 	var sess *session.Session
@@ -303,12 +415,12 @@ func runInteractiveSession(ctx context.Context, flags *GlobalFlags, files []stri
 	}
 
 	// Start interactive mode with enhanced UI
-	return startTUI(ctx, sess, sessionManager, flags)
+	return startTUI(ctx, sess, sessionManager, flags, buildInfo)
 }
 
 // startEnhancedUI starts the Bubble Tea interface
-func startTUI(_ context.Context, sess *session.Session, sessionManager *session.Manager, flags *GlobalFlags) error {
-	var model tea.Model = ui.NewModel(sess, sessionManager)
+func startTUI(_ context.Context, sess *session.Session, sessionManager *session.Manager, flags *GlobalFlags, buildInfo *BuildInfo) error {
+	model := ui.NewModel(sess, sessionManager, buildInfo.Version)
 
 	// Configure Bubble Tea program
 	program := tea.NewProgram(
@@ -316,6 +428,7 @@ func startTUI(_ context.Context, sess *session.Session, sessionManager *session.
 		tea.WithAltScreen(), // Use alternate screen buffer
 		// Mouse support disabled to allow text selection
 	)
+	model.SetProgram(program)
 
 	// Run the program
 	if _, err := program.Run(); err != nil {
@@ -1,23 +1,70 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
+	"runtime"
 
 	"github.com/spf13/cobra"
 )
 
+// versionInfo is the full build provenance printed by the version command
+type versionInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	Date      string `json:"date"`
+	Author    string `json:"author"`
+	Email     string `json:"email"`
+	GoVersion string `json:"go_version"`
+	OS        string `json:"os"`
+	Arch      string `json:"arch"`
+}
+
+// newVersionInfo combines build-time information with the runtime platform
+func newVersionInfo(buildInfo *BuildInfo) versionInfo {
+	return versionInfo{
+		Version:   buildInfo.Version,
+		Commit:    buildInfo.Commit,
+		Date:      buildInfo.Date,
+		Author:    buildInfo.Author,
+		Email:     buildInfo.Email,
+		GoVersion: runtime.Version(),
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+	}
+}
+
 // newVersionCommand creates the version subcommand
 func newVersionCommand(buildInfo *BuildInfo) *cobra.Command {
+	var asJSON bool
+
 	cmd := &cobra.Command{
 		Use:   "version",
 		Short: "Show version information",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			fmt.Printf("bazinga version %s\n", buildInfo.Version)
-			fmt.Printf("  commit: %s\n", buildInfo.Commit)
-			fmt.Printf("  built: %s\n", buildInfo.Date)
+			info := newVersionInfo(buildInfo)
+
+			if asJSON {
+				data, err := json.MarshalIndent(info, "", "  ")
+				if err != nil {
+					return fmt.Errorf("failed to encode version information: %w", err)
+				}
+				fmt.Fprintln(cmd.OutOrStdout(), string(data))
+				return nil
+			}
+
+			out := cmd.OutOrStdout()
+			fmt.Fprintf(out, "bazinga version %s\n", info.Version)
+			fmt.Fprintf(out, "  commit: %s\n", info.Commit)
+			fmt.Fprintf(out, "  built: %s\n", info.Date)
+			fmt.Fprintf(out, "  author: %s <%s>\n", info.Author, info.Email)
+			fmt.Fprintf(out, "  go: %s\n", info.GoVersion)
+			fmt.Fprintf(out, "  platform: %s/%s\n", info.OS, info.Arch)
 			return nil
 		},
 	}
 
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Print version information as JSON")
+
 	return cmd
 }
@@ -1,9 +1,15 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
 
 	"github.com/spf13/viper"
 	"gopkg.in/yaml.v3"
@@ -16,6 +22,9 @@ type Config struct {
 	Git       GitConfig       `yaml:"git"`
 	Security  SecurityConfig  `yaml:"security"`
 	Logging   LoggingConfig   `yaml:"logging"`
+	Tools     ToolsConfig     `yaml:"tools"`
+	Project   ProjectConfig   `yaml:"project"`
+	UI        UIConfig        `yaml:"ui"`
 }
 
 // LLMConfig contains LLM-related configuration
@@ -24,6 +33,29 @@ type LLMConfig struct {
 	DefaultModel    string  `yaml:"default_model"`
 	MaxTokens       int     `yaml:"max_tokens"`
 	Temperature     float64 `yaml:"temperature"`
+	HistoryWindow   int     `yaml:"history_window"` // Most recent history messages sent with each request (0 = no limit)
+	// Summarize older history with the model when it nears the context window, instead of only dropping it
+	AutoCompact bool `yaml:"auto_compact"`
+	// Language the assistant should answer in, e.g. "de" or "Japanese" (empty = no preference)
+	ResponseLanguage string `yaml:"response_language"`
+	// Exit at startup when no provider has usable credentials instead of starting with onboarding hints
+	RequireProvider bool `yaml:"require_provider"`
+	// Retries of throttled and failed provider requests
+	Retry RetryConfig `yaml:"retry"`
+	// Limits on requests sent to any provider; calls over the limit wait instead of failing
+	RateLimit RateLimitConfig `yaml:"rate_limit"`
+}
+
+// RetryConfig controls how transient provider errors (429, 5xx, dropped connections) are retried
+type RetryConfig struct {
+	MaxAttempts int `yaml:"max_attempts"`  // Total attempts including the first; 1 disables retries
+	BaseDelayMs int `yaml:"base_delay_ms"` // Delay before the first retry, doubled for each one after
+}
+
+// RateLimitConfig limits how fast requests are sent, shared across all providers
+type RateLimitConfig struct {
+	RequestsPerMinute int `yaml:"requests_per_minute"` // 0 = unlimited
+	TokensPerMinute   int `yaml:"tokens_per_minute"`   // Estimated input tokens; 0 = unlimited
 }
 
 // ProvidersConfig contains provider-specific configurations
@@ -32,6 +64,7 @@ type ProvidersConfig struct {
 	OpenAI    OpenAIConfig    `yaml:"openai"`
 	Anthropic AnthropicConfig `yaml:"anthropic"`
 	Ollama    OllamaConfig    `yaml:"ollama"`
+	Gemini    GeminiConfig    `yaml:"gemini"`
 }
 
 // BedrockConfig contains AWS Bedrock configuration
@@ -70,15 +103,148 @@ type OllamaConfig struct {
 	Model   string `yaml:"model"`
 }
 
+// GeminiConfig contains Google Gemini configuration
+type GeminiConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	APIKey  string `yaml:"api_key"`
+	BaseURL string `yaml:"base_url"`
+	Model   string `yaml:"model"`
+}
+
 // GitConfig contains Git-related configuration
 type GitConfig struct {
-	AuthorName  string `yaml:"author_name"`
-	AuthorEmail string `yaml:"author_email"`
+	AuthorName          string `yaml:"author_name"`
+	AuthorEmail         string `yaml:"author_email"`
+	ConventionalCommits bool   `yaml:"conventional_commits"` // Generate commit messages as type(scope): summary
+	CoAuthoredBy        string `yaml:"co_authored_by"`       // "Name <email>" trailer added to generated commit messages
+	PreCommitCommand    string `yaml:"pre_commit_command"`   // Check run before every commit, ahead of the repo's pre-commit hook
+	SkipHooks           bool   `yaml:"skip_hooks"`           // Commit without running pre-commit checks
 }
 
+// coAuthorPattern matches the "Name <email>" form of a Co-authored-by trailer
+var coAuthorPattern = regexp.MustCompile(`^[^<>]+ <[^<>@\s]+@[^<>\s]+>$`)
+
 // SecurityConfig contains security-related configuration
 type SecurityConfig struct {
-	Terminator bool `yaml:"terminator"` // Bypass all permission checks (DANGEROUS)
+	Terminator       bool                  `yaml:"terminator"`         // Bypass all permission checks (DANGEROUS)
+	TerminatorScope  string                `yaml:"terminator_scope"`   // Bypass only some checks: "edits", "safe" or "all"; overrides terminator
+	RememberTTLHours int                   `yaml:"remember_ttl_hours"` // How long "always allow" decisions survive restarts (0 = until exit)
+	RedactSecrets    bool                  `yaml:"redact_secrets"`     // Replace secrets in file, command and web output before it reaches the model
+	RedactPatterns   []RedactPatternConfig `yaml:"redact_patterns"`    // Extra secret patterns, on top of the built-in ones
+	PermissionRules  []string              `yaml:"permission_rules"`   // "allow|prompt|deny <tool> [pattern]" rules consulted before prompting
+}
+
+// Terminator scopes: which tool calls that would prompt are approved without asking
+const (
+	TerminatorAll   = "all"   // Every call
+	TerminatorEdits = "edits" // File writes and edits, but not commands or destructive operations
+	TerminatorSafe  = "safe"  // Everything except destructive operations such as rm -rf and force pushes
+)
+
+// RedactPatternConfig defines an extra secret pattern. When the pattern has a capture
+// group, only the first group is redacted.
+type RedactPatternConfig struct {
+	Name    string `yaml:"name"`    // Shown in the [REDACTED:<name>] marker
+	Pattern string `yaml:"pattern"` // Go regular expression
+}
+
+// PermissionRuleSpec is a parsed security.permission_rules entry
+type PermissionRuleSpec struct {
+	Action  string // "allow", "prompt" or "deny"
+	Tool    string // Tool name, or a glob such as git_*
+	Pattern string // Glob of the files, or for bash the command, the rule covers (empty = every call)
+}
+
+// ParsePermissionRule parses a rule such as `allow bash "go test*"`: an action, a tool
+// and an optional pattern, quoted when it contains spaces
+func ParsePermissionRule(rule string) (PermissionRuleSpec, error) {
+	var fields []string
+	rest := strings.TrimSpace(rule)
+	for rest != "" {
+		var field string
+		if quote := rest[0]; quote == '"' || quote == '\'' {
+			end := strings.IndexByte(rest[1:], quote)
+			if end < 0 {
+				return PermissionRuleSpec{}, fmt.Errorf("unterminated quote in %q", rule)
+			}
+			field, rest = rest[1:end+1], rest[end+2:]
+		} else {
+			field, rest, _ = strings.Cut(rest, " ")
+		}
+		fields = append(fields, field)
+		rest = strings.TrimSpace(rest)
+	}
+
+	if len(fields) < 2 || len(fields) > 3 {
+		return PermissionRuleSpec{}, fmt.Errorf("%q must be \"<allow|prompt|deny> <tool> [pattern]\"; quote patterns that contain spaces", rule)
+	}
+	spec := PermissionRuleSpec{Action: strings.ToLower(fields[0]), Tool: fields[1]}
+	if len(fields) == 3 {
+		spec.Pattern = fields[2]
+	}
+
+	switch spec.Action {
+	case "allow", "prompt", "deny":
+	default:
+		return PermissionRuleSpec{}, fmt.Errorf("%q: unknown action %q (want allow, prompt or deny)", rule, fields[0])
+	}
+	if _, err := path.Match(spec.Tool, ""); err != nil {
+		return PermissionRuleSpec{}, fmt.Errorf("%q: invalid tool pattern %q", rule, spec.Tool)
+	}
+	return spec, nil
+}
+
+// ToolsConfig contains tool-related configuration
+type ToolsConfig struct {
+	Custom             []CustomToolConfig `yaml:"custom"`                // Project commands exposed as tools
+	LongLineThreshold  int                `yaml:"long_line_threshold"`   // Lines longer than this mark a file as minified/generated
+	LongLinePreview    int                `yaml:"long_line_preview"`     // Bytes of preview returned for such files
+	AllowedPaths       []string           `yaml:"allowed_paths"`         // Subtrees file and search tools may use (empty = whole project)
+	BashTimeout        int                `yaml:"bash_timeout"`          // Seconds a bash command may run unless the call sets its own timeout
+	MaxReadBytes       int                `yaml:"max_read_bytes"`        // Most bytes read_file returns at once; lowered further for small context windows
+	WebFetchMaxBytes   int                `yaml:"web_fetch_max_bytes"`   // Largest page web_fetch downloads
+	WebFetchTimeout    int                `yaml:"web_fetch_timeout"`     // Seconds a web_fetch may take, including reading the page
+	WebFetchCacheTTL   int                `yaml:"web_fetch_cache_ttl"`   // Seconds a fetched page is reused for identical web_fetch calls (0 = no cache)
+	WebFetchCacheSize  int                `yaml:"web_fetch_cache_size"`  // Most fetched pages kept; the least recently used is dropped first
+	MaxCallsPerRequest int                `yaml:"max_calls_per_request"` // Tool calls one message may trigger before the model must answer (0 = no limit)
+	EnabledTools       []string           `yaml:"enabled_tools"`         // Only these tools are offered to the model (empty = all)
+	DisabledTools      []string           `yaml:"disabled_tools"`        // Tools never offered to the model or run
+
+	// Tool results from earlier turns are cut to this size in the history (0 = keep whole)
+	HistoryResultMaxBytes int `yaml:"history_result_max_bytes"`
+}
+
+// CustomToolConfig maps a tool name and JSON-schema arguments to a templated command
+type CustomToolConfig struct {
+	Name        string                 `yaml:"name"`
+	Description string                 `yaml:"description"`
+	Command     string                 `yaml:"command"`    // e.g. "./scripts/deploy.sh {{env}}"; run without a shell
+	Parameters  map[string]interface{} `yaml:"parameters"` // JSON schema for the arguments
+	Risk        string                 `yaml:"risk"`       // low, medium, high (default: high)
+	Timeout     int                    `yaml:"timeout"`    // seconds (default: 60)
+}
+
+// ProjectConfig contains the limits of the project scan behind the file list in the prompt
+type ProjectConfig struct {
+	MaxFiles      int  `yaml:"max_files"`      // Most files listed; the list is marked partial beyond that (0 = default)
+	MaxDepth      int  `yaml:"max_depth"`      // Directory levels below the root scanned (0 = default)
+	IncludeHidden bool `yaml:"include_hidden"` // Scan dot files and directories such as .github
+}
+
+// UIConfig contains chat interface configuration
+type UIConfig struct {
+	ToolOutput          string            `yaml:"tool_output"`           // summary, inline, hidden
+	ToolOutputOverrides map[string]string `yaml:"tool_output_overrides"` // Per-tool mode, e.g. grep: inline
+	ToolOutputMaxLines  int               `yaml:"tool_output_max_lines"` // Result lines shown in inline mode
+
+	DiffMaxLines int    `yaml:"diff_max_lines"` // Diff lines shown inline before truncating (0 = no limit)
+	DiffPager    string `yaml:"diff_pager"`     // Command that opens a full diff (default: $PAGER, $EDITOR, less)
+
+	Theme string `yaml:"theme"` // Markdown style: dark, light, notty, dracula, ascii, or a path to a glamour JSON style
+
+	ConfirmExpensive      bool    `yaml:"confirm_expensive"`       // Ask before sending requests over a threshold
+	ConfirmCostThreshold  float64 `yaml:"confirm_cost_threshold"`  // Estimated USD above which to ask (0 = ignore cost)
+	ConfirmTokenThreshold int     `yaml:"confirm_token_threshold"` // Estimated prompt tokens above which to ask (0 = ignore tokens)
 }
 
 // LoggingConfig contains logging-related configuration
@@ -101,6 +267,15 @@ func DefaultConfig() *Config {
 			DefaultModel:    "eu.anthropic.claude-3-7-sonnet-20250219-v1:0",
 			MaxTokens:       4096,
 			Temperature:     0.7,
+			HistoryWindow:   0,
+			AutoCompact:     true,
+			Retry: RetryConfig{
+				MaxAttempts: 3,
+				BaseDelayMs: 1000,
+			},
+			RateLimit: RateLimitConfig{
+				RequestsPerMinute: 120,
+			},
 		},
 		Providers: ProvidersConfig{
 			Bedrock: BedrockConfig{
@@ -121,13 +296,46 @@ func DefaultConfig() *Config {
 				BaseURL: "http://localhost:11434",
 				Model:   "qwen2.5-coder:latest",
 			},
+			Gemini: GeminiConfig{
+				Enabled: false,
+				BaseURL: "https://generativelanguage.googleapis.com/v1beta",
+				Model:   "gemini-1.5-pro",
+			},
 		},
 		Git: GitConfig{
 			AuthorName:  "", // Will fallback to git config
 			AuthorEmail: "", // Will fallback to git config
 		},
 		Security: SecurityConfig{
-			Terminator: false, // Default to safe mode
+			Terminator:       false, // Default to safe mode
+			RememberTTLHours: 24,
+			RedactSecrets:    true,
+		},
+		Tools: ToolsConfig{
+			LongLineThreshold:  5000,
+			LongLinePreview:    2000,
+			BashTimeout:        120,
+			MaxReadBytes:       256 * 1024,
+			WebFetchMaxBytes:   10 * 1024 * 1024,
+			WebFetchTimeout:    30,
+			WebFetchCacheTTL:   900,
+			WebFetchCacheSize:  100,
+			MaxCallsPerRequest: 25,
+
+			HistoryResultMaxBytes: 16384,
+		},
+		Project: ProjectConfig{
+			MaxFiles: 500,
+			MaxDepth: 5,
+		},
+		UI: UIConfig{
+			ToolOutput:            "summary",
+			ToolOutputMaxLines:    20,
+			DiffMaxLines:          60,
+			Theme:                 "dracula",
+			ConfirmExpensive:      true,
+			ConfirmCostThreshold:  1.0,
+			ConfirmTokenThreshold: 150000,
 		},
 		Logging: LoggingConfig{
 			Level:      "info",
@@ -179,6 +387,12 @@ func Load() (*Config, error) {
 	if viper.IsSet("providers.bedrock.external_id") {
 		cfg.Providers.Bedrock.ExternalID = viper.GetString("providers.bedrock.external_id")
 	}
+	if viper.IsSet("providers.gemini.api_key") {
+		cfg.Providers.Gemini.APIKey = viper.GetString("providers.gemini.api_key")
+	}
+	if viper.IsSet("providers.gemini.base_url") {
+		cfg.Providers.Gemini.BaseURL = viper.GetString("providers.gemini.base_url")
+	}
 
 	// Override with viper values (for backward compatibility)
 	if viper.IsSet("llm.default_provider") {
@@ -187,6 +401,134 @@ func Load() (*Config, error) {
 	if viper.IsSet("llm.default_model") {
 		cfg.LLM.DefaultModel = viper.GetString("llm.default_model")
 	}
+	if viper.IsSet("llm.history_window") {
+		cfg.LLM.HistoryWindow = viper.GetInt("llm.history_window")
+	}
+	if viper.IsSet("llm.auto_compact") {
+		cfg.LLM.AutoCompact = viper.GetBool("llm.auto_compact")
+	}
+	if viper.IsSet("llm.response_language") {
+		cfg.LLM.ResponseLanguage = viper.GetString("llm.response_language")
+	}
+	if viper.IsSet("llm.require_provider") {
+		cfg.LLM.RequireProvider = viper.GetBool("llm.require_provider")
+	}
+	if viper.IsSet("llm.retry.max_attempts") {
+		cfg.LLM.Retry.MaxAttempts = viper.GetInt("llm.retry.max_attempts")
+	}
+	if viper.IsSet("llm.retry.base_delay_ms") {
+		cfg.LLM.Retry.BaseDelayMs = viper.GetInt("llm.retry.base_delay_ms")
+	}
+	if viper.IsSet("llm.rate_limit.requests_per_minute") {
+		cfg.LLM.RateLimit.RequestsPerMinute = viper.GetInt("llm.rate_limit.requests_per_minute")
+	}
+	if viper.IsSet("llm.rate_limit.tokens_per_minute") {
+		cfg.LLM.RateLimit.TokensPerMinute = viper.GetInt("llm.rate_limit.tokens_per_minute")
+	}
+	if viper.IsSet("security.terminator_scope") {
+		cfg.Security.TerminatorScope = viper.GetString("security.terminator_scope")
+	}
+	if viper.IsSet("security.remember_ttl_hours") {
+		cfg.Security.RememberTTLHours = viper.GetInt("security.remember_ttl_hours")
+	}
+	if viper.IsSet("git.conventional_commits") {
+		cfg.Git.ConventionalCommits = viper.GetBool("git.conventional_commits")
+	}
+	if viper.IsSet("git.co_authored_by") {
+		cfg.Git.CoAuthoredBy = viper.GetString("git.co_authored_by")
+	}
+	if viper.IsSet("git.pre_commit_command") {
+		cfg.Git.PreCommitCommand = viper.GetString("git.pre_commit_command")
+	}
+	if viper.IsSet("git.skip_hooks") {
+		cfg.Git.SkipHooks = viper.GetBool("git.skip_hooks")
+	}
+	if viper.IsSet("security.redact_secrets") {
+		cfg.Security.RedactSecrets = viper.GetBool("security.redact_secrets")
+	}
+	if viper.IsSet("security.redact_patterns") {
+		if err := viper.UnmarshalKey("security.redact_patterns", &cfg.Security.RedactPatterns); err != nil {
+			return nil, fmt.Errorf("error parsing security.redact_patterns: %w", err)
+		}
+	}
+	if viper.IsSet("security.permission_rules") {
+		cfg.Security.PermissionRules = viper.GetStringSlice("security.permission_rules")
+	}
+	if viper.IsSet("tools.long_line_threshold") {
+		cfg.Tools.LongLineThreshold = viper.GetInt("tools.long_line_threshold")
+	}
+	if viper.IsSet("tools.allowed_paths") {
+		cfg.Tools.AllowedPaths = viper.GetStringSlice("tools.allowed_paths")
+	}
+	if viper.IsSet("tools.enabled_tools") {
+		cfg.Tools.EnabledTools = viper.GetStringSlice("tools.enabled_tools")
+	}
+	if viper.IsSet("tools.disabled_tools") {
+		cfg.Tools.DisabledTools = viper.GetStringSlice("tools.disabled_tools")
+	}
+	if viper.IsSet("tools.bash_timeout") {
+		cfg.Tools.BashTimeout = viper.GetInt("tools.bash_timeout")
+	}
+	if viper.IsSet("tools.max_read_bytes") {
+		cfg.Tools.MaxReadBytes = viper.GetInt("tools.max_read_bytes")
+	}
+	if viper.IsSet("tools.web_fetch_max_bytes") {
+		cfg.Tools.WebFetchMaxBytes = viper.GetInt("tools.web_fetch_max_bytes")
+	}
+	if viper.IsSet("tools.web_fetch_timeout") {
+		cfg.Tools.WebFetchTimeout = viper.GetInt("tools.web_fetch_timeout")
+	}
+	if viper.IsSet("tools.web_fetch_cache_ttl") {
+		cfg.Tools.WebFetchCacheTTL = viper.GetInt("tools.web_fetch_cache_ttl")
+	}
+	if viper.IsSet("tools.web_fetch_cache_size") {
+		cfg.Tools.WebFetchCacheSize = viper.GetInt("tools.web_fetch_cache_size")
+	}
+	if viper.IsSet("tools.max_calls_per_request") {
+		cfg.Tools.MaxCallsPerRequest = viper.GetInt("tools.max_calls_per_request")
+	}
+	if viper.IsSet("tools.history_result_max_bytes") {
+		cfg.Tools.HistoryResultMaxBytes = viper.GetInt("tools.history_result_max_bytes")
+	}
+	if viper.IsSet("tools.long_line_preview") {
+		cfg.Tools.LongLinePreview = viper.GetInt("tools.long_line_preview")
+	}
+	if viper.IsSet("project.max_files") {
+		cfg.Project.MaxFiles = viper.GetInt("project.max_files")
+	}
+	if viper.IsSet("project.max_depth") {
+		cfg.Project.MaxDepth = viper.GetInt("project.max_depth")
+	}
+	if viper.IsSet("project.include_hidden") {
+		cfg.Project.IncludeHidden = viper.GetBool("project.include_hidden")
+	}
+	if viper.IsSet("ui.tool_output") {
+		cfg.UI.ToolOutput = viper.GetString("ui.tool_output")
+	}
+	if viper.IsSet("ui.tool_output_overrides") {
+		cfg.UI.ToolOutputOverrides = viper.GetStringMapString("ui.tool_output_overrides")
+	}
+	if viper.IsSet("ui.tool_output_max_lines") {
+		cfg.UI.ToolOutputMaxLines = viper.GetInt("ui.tool_output_max_lines")
+	}
+	if viper.IsSet("ui.diff_max_lines") {
+		cfg.UI.DiffMaxLines = viper.GetInt("ui.diff_max_lines")
+	}
+	if viper.IsSet("ui.diff_pager") {
+		cfg.UI.DiffPager = viper.GetString("ui.diff_pager")
+	}
+	if viper.IsSet("ui.theme") {
+		cfg.UI.Theme = viper.GetString("ui.theme")
+	}
+	if viper.IsSet("ui.confirm_expensive") {
+		cfg.UI.ConfirmExpensive = viper.GetBool("ui.confirm_expensive")
+	}
+	if viper.IsSet("ui.confirm_cost_threshold") {
+		cfg.UI.ConfirmCostThreshold = viper.GetFloat64("ui.confirm_cost_threshold")
+	}
+	if viper.IsSet("ui.confirm_token_threshold") {
+		cfg.UI.ConfirmTokenThreshold = viper.GetInt("ui.confirm_token_threshold")
+	}
 	if viper.IsSet("providers.bedrock.region") {
 		cfg.Providers.Bedrock.Region = viper.GetString("providers.bedrock.region")
 	}
@@ -231,9 +573,226 @@ func Load() (*Config, error) {
 		cfg.Providers.Ollama.Enabled = true
 	}
 
+	// Load Gemini credentials
+	if geminiKey := os.Getenv("GEMINI_API_KEY"); geminiKey != "" {
+		cfg.Providers.Gemini.APIKey = geminiKey
+		cfg.Providers.Gemini.Enabled = true
+	}
+
 	return cfg, nil
 }
 
+// Validate checks the configuration for values that would fail at runtime.
+// All problems are reported together, one per line.
+func (c *Config) Validate() error {
+	var problems []error
+
+	enabled := map[string]bool{
+		"bedrock":   c.Providers.Bedrock.Enabled,
+		"openai":    c.Providers.OpenAI.Enabled,
+		"anthropic": c.Providers.Anthropic.Enabled,
+		"ollama":    c.Providers.Ollama.Enabled,
+		"gemini":    c.Providers.Gemini.Enabled,
+	}
+	if c.LLM.DefaultProvider != "" {
+		if on, known := enabled[c.LLM.DefaultProvider]; !known {
+			problems = append(problems, fmt.Errorf("llm.default_provider %q is not a known provider", c.LLM.DefaultProvider))
+		} else if !on {
+			problems = append(problems, fmt.Errorf("llm.default_provider %q is not enabled", c.LLM.DefaultProvider))
+		}
+	}
+	anyEnabled := false
+	for _, on := range enabled {
+		anyEnabled = anyEnabled || on
+	}
+	if !anyEnabled {
+		problems = append(problems, fmt.Errorf("no provider is enabled"))
+	}
+
+	if c.LLM.MaxTokens <= 0 {
+		problems = append(problems, fmt.Errorf("llm.max_tokens must be positive, got %d", c.LLM.MaxTokens))
+	}
+	if c.LLM.Temperature < 0 || c.LLM.Temperature > 2 {
+		problems = append(problems, fmt.Errorf("llm.temperature must be between 0 and 2, got %g", c.LLM.Temperature))
+	}
+	if c.LLM.HistoryWindow < 0 {
+		problems = append(problems, fmt.Errorf("llm.history_window must not be negative, got %d", c.LLM.HistoryWindow))
+	}
+	if c.LLM.Retry.MaxAttempts < 0 {
+		problems = append(problems, fmt.Errorf("llm.retry.max_attempts must not be negative, got %d", c.LLM.Retry.MaxAttempts))
+	}
+	if c.LLM.Retry.BaseDelayMs < 0 {
+		problems = append(problems, fmt.Errorf("llm.retry.base_delay_ms must not be negative, got %d", c.LLM.Retry.BaseDelayMs))
+	}
+	if c.LLM.RateLimit.RequestsPerMinute < 0 {
+		problems = append(problems, fmt.Errorf("llm.rate_limit.requests_per_minute must not be negative, got %d", c.LLM.RateLimit.RequestsPerMinute))
+	}
+	if c.LLM.RateLimit.TokensPerMinute < 0 {
+		problems = append(problems, fmt.Errorf("llm.rate_limit.tokens_per_minute must not be negative, got %d", c.LLM.RateLimit.TokensPerMinute))
+	}
+
+	if c.Providers.Bedrock.Enabled {
+		switch c.Providers.Bedrock.AuthMethod {
+		case "", "default", "environment":
+		case "profile":
+			if c.Providers.Bedrock.Profile == "" {
+				problems = append(problems, fmt.Errorf("providers.bedrock.profile is required for profile authentication"))
+			}
+		case "static":
+			if c.Providers.Bedrock.AccessKeyID == "" || c.Providers.Bedrock.SecretAccessKey == "" {
+				problems = append(problems, fmt.Errorf("providers.bedrock.access_key_id and secret_access_key are required for static authentication"))
+			}
+		case "assume_role":
+			if c.Providers.Bedrock.RoleARN == "" {
+				problems = append(problems, fmt.Errorf("providers.bedrock.role_arn is required for assume_role authentication"))
+			}
+		default:
+			problems = append(problems, fmt.Errorf("providers.bedrock.auth_method %q is not supported", c.Providers.Bedrock.AuthMethod))
+		}
+	}
+
+	if c.Git.CoAuthoredBy != "" && !coAuthorPattern.MatchString(c.Git.CoAuthoredBy) {
+		problems = append(problems, fmt.Errorf("git.co_authored_by must look like \"Name <email>\", got %q", c.Git.CoAuthoredBy))
+	}
+
+	switch c.Security.TerminatorScope {
+	case "", TerminatorAll, TerminatorEdits, TerminatorSafe:
+	default:
+		problems = append(problems, fmt.Errorf("security.terminator_scope must be edits, safe or all, got %q", c.Security.TerminatorScope))
+	}
+
+	if c.Security.RememberTTLHours < 0 {
+		problems = append(problems, fmt.Errorf("security.remember_ttl_hours must not be negative, got %d", c.Security.RememberTTLHours))
+	}
+
+	for i, pattern := range c.Security.RedactPatterns {
+		if pattern.Name == "" || pattern.Pattern == "" {
+			problems = append(problems, fmt.Errorf("security.redact_patterns[%d] needs both a name and a pattern", i))
+			continue
+		}
+		if _, err := regexp.Compile(pattern.Pattern); err != nil {
+			problems = append(problems, fmt.Errorf("security.redact_patterns[%d] (%s) is not a valid regular expression: %w", i, pattern.Name, err))
+		}
+	}
+
+	for i, rule := range c.Security.PermissionRules {
+		if _, err := ParsePermissionRule(rule); err != nil {
+			problems = append(problems, fmt.Errorf("security.permission_rules[%d]: %w", i, err))
+		}
+	}
+
+	for i, tool := range c.Tools.Custom {
+		if tool.Name == "" || tool.Command == "" {
+			problems = append(problems, fmt.Errorf("tools.custom[%d] needs both a name and a command", i))
+		}
+	}
+
+	if c.Tools.BashTimeout < 0 {
+		problems = append(problems, fmt.Errorf("tools.bash_timeout must not be negative, got %d", c.Tools.BashTimeout))
+	}
+
+	if c.Tools.MaxReadBytes < 0 {
+		problems = append(problems, fmt.Errorf("tools.max_read_bytes must not be negative, got %d", c.Tools.MaxReadBytes))
+	}
+
+	if c.Tools.WebFetchMaxBytes < 0 {
+		problems = append(problems, fmt.Errorf("tools.web_fetch_max_bytes must not be negative, got %d", c.Tools.WebFetchMaxBytes))
+	}
+
+	if c.Tools.WebFetchTimeout < 0 {
+		problems = append(problems, fmt.Errorf("tools.web_fetch_timeout must not be negative, got %d", c.Tools.WebFetchTimeout))
+	}
+
+	if c.Tools.WebFetchCacheTTL < 0 {
+		problems = append(problems, fmt.Errorf("tools.web_fetch_cache_ttl must not be negative, got %d", c.Tools.WebFetchCacheTTL))
+	}
+
+	if c.Tools.WebFetchCacheSize < 0 {
+		problems = append(problems, fmt.Errorf("tools.web_fetch_cache_size must not be negative, got %d", c.Tools.WebFetchCacheSize))
+	}
+
+	if c.Tools.MaxCallsPerRequest < 0 {
+		problems = append(problems, fmt.Errorf("tools.max_calls_per_request must not be negative, got %d", c.Tools.MaxCallsPerRequest))
+	}
+
+	if c.Tools.HistoryResultMaxBytes < 0 {
+		problems = append(problems, fmt.Errorf("tools.history_result_max_bytes must not be negative, got %d", c.Tools.HistoryResultMaxBytes))
+	}
+
+	if c.Project.MaxFiles < 0 {
+		problems = append(problems, fmt.Errorf("project.max_files must not be negative, got %d", c.Project.MaxFiles))
+	}
+
+	if c.Project.MaxDepth < 0 {
+		problems = append(problems, fmt.Errorf("project.max_depth must not be negative, got %d", c.Project.MaxDepth))
+	}
+
+	for i, path := range c.Tools.AllowedPaths {
+		if clean := filepath.Clean(path); clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+			problems = append(problems, fmt.Errorf("tools.allowed_paths[%d] %q must not point outside the project", i, path))
+		}
+	}
+
+	for i, name := range c.Tools.DisabledTools {
+		if slices.Contains(c.Tools.EnabledTools, name) {
+			problems = append(problems, fmt.Errorf("tools.disabled_tools[%d] %q is also in tools.enabled_tools", i, name))
+		}
+	}
+
+	switch c.UI.ToolOutput {
+	case "", "summary", "inline", "hidden":
+	default:
+		problems = append(problems, fmt.Errorf("ui.tool_output %q must be summary, inline or hidden", c.UI.ToolOutput))
+	}
+
+	if c.UI.DiffMaxLines < 0 {
+		problems = append(problems, fmt.Errorf("ui.diff_max_lines must not be negative"))
+	}
+
+	if c.UI.ConfirmCostThreshold < 0 || c.UI.ConfirmTokenThreshold < 0 {
+		problems = append(problems, fmt.Errorf("ui.confirm_cost_threshold and ui.confirm_token_threshold must not be negative"))
+	}
+
+	switch c.Logging.Level {
+	case "", "debug", "info", "warn", "error":
+	default:
+		problems = append(problems, fmt.Errorf("logging.level %q must be debug, info, warn or error", c.Logging.Level))
+	}
+
+	return errors.Join(problems...)
+}
+
+// Diff returns the settings that differ between two configurations, as dotted YAML
+// keys such as "llm.temperature", in the order they appear in Config. Lists and maps
+// are compared as a whole.
+func Diff(previous, current *Config) []string {
+	var changed []string
+	diffValues(reflect.ValueOf(*previous), reflect.ValueOf(*current), "", &changed)
+	return changed
+}
+
+// diffValues appends the keys of the fields of two struct values that differ
+func diffValues(previous, current reflect.Value, prefix string, changed *[]string) {
+	for i := 0; i < previous.NumField(); i++ {
+		field := previous.Type().Field(i)
+		key, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if key == "" || key == "-" {
+			continue
+		}
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+
+		if field.Type.Kind() == reflect.Struct {
+			diffValues(previous.Field(i), current.Field(i), key, changed)
+			continue
+		}
+		if !reflect.DeepEqual(previous.Field(i).Interface(), current.Field(i).Interface()) {
+			*changed = append(*changed, key)
+		}
+	}
+}
+
 // Init creates a default configuration file
 func Init() error {
 	home, err := os.UserHomeDir()
@@ -251,7 +810,7 @@ func Init() error {
 
 	// Check if config file already exists
 	if _, err := os.Stat(configFile); err == nil {
-		fmt.Printf("Configuration file already exists: %s\n", configFile)
+		fmt.Fprintf(os.Stderr, "Configuration file already exists: %s\n", configFile)
 		return nil
 	}
 
@@ -268,8 +827,8 @@ func Init() error {
 		return fmt.Errorf("failed to write config file: %w", err)
 	}
 
-	fmt.Printf("Created configuration file: %s\n", configFile)
-	fmt.Println("Please edit the file to configure your providers.")
+	fmt.Fprintf(os.Stderr, "Created configuration file: %s\n", configFile)
+	fmt.Fprintln(os.Stderr, "Please edit the file to configure your providers.")
 
 	return nil
 }
@@ -2,6 +2,7 @@ package config
 
 import (
 	"os"
+	"strings"
 	"testing"
 )
 
@@ -83,3 +84,51 @@ func TestGetConfigDir(t *testing.T) {
 		t.Errorf("Expected config dir to end with '.bazinga', got '%s'", dir)
 	}
 }
+
+func TestValidate(t *testing.T) {
+	if err := DefaultConfig().Validate(); err != nil {
+		t.Errorf("Expected default config to be valid, got: %v", err)
+	}
+
+	cfg := DefaultConfig()
+	cfg.LLM.DefaultProvider = "anthropic"
+	cfg.LLM.Temperature = 3
+	cfg.Providers.Bedrock.AuthMethod = "static"
+	cfg.Security.RedactPatterns = []RedactPatternConfig{{Name: "broken", Pattern: "("}}
+	cfg.Git.CoAuthoredBy = "bazinga"
+	cfg.Tools.EnabledTools = []string{"read_file", "bash"}
+	cfg.Tools.DisabledTools = []string{"bash"}
+	cfg.Security.PermissionRules = []string{"allow edit_file internal/**", "approve bash"}
+	cfg.Security.TerminatorScope = "writes"
+	cfg.Project.MaxFiles = -1
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Expected validation errors for an invalid config")
+	}
+
+	for _, want := range []string{"llm.default_provider", "llm.temperature", "access_key_id", "security.redact_patterns[0]", "git.co_authored_by", "tools.disabled_tools[0]", "security.permission_rules[1]", "security.terminator_scope", "project.max_files"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected validation error to mention %s, got: %v", want, err)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	previous := DefaultConfig()
+	if changed := Diff(previous, DefaultConfig()); len(changed) != 0 {
+		t.Errorf("Expected no changes between equal configs, got %v", changed)
+	}
+
+	current := DefaultConfig()
+	current.LLM.Temperature = 0.2
+	current.LLM.Retry.MaxAttempts = 5
+	current.Providers.OpenAI.APIKey = "sk-new"
+	current.Tools.AllowedPaths = []string{"internal"}
+
+	changed := Diff(previous, current)
+	expected := []string{"llm.temperature", "llm.retry.max_attempts", "providers.openai.api_key", "tools.allowed_paths"}
+	if strings.Join(changed, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected %v, got %v", expected, changed)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"github.com/tildaslashalef/bazinga/internal/llm"
+	"regexp"
 	"strings"
 	"time"
 
@@ -14,28 +15,47 @@ import (
 // CommitGenerator generates AI-powered commit messages
 type CommitGenerator struct {
 	provider    llm.Provider
+	style       CommitStyle
 	maxTokens   int
 	temperature float64
 }
 
+// CommitStyle controls the format of generated commit messages
+type CommitStyle struct {
+	Conventional bool   // Follow Conventional Commits: type(scope): summary
+	CoAuthoredBy string // "Name <email>" added as a Co-authored-by trailer (empty = none)
+}
+
+const (
+	// maxSubjectLength is the longest subject line a generated message keeps; the rest
+	// moves to the body
+	maxSubjectLength = 72
+	// maxPromptDiff is how much of the diff is sent to the model
+	maxPromptDiff = 16000
+)
+
+// conventionalSubject matches a Conventional Commits subject line
+var conventionalSubject = regexp.MustCompile(`^[a-z]+(\([^()]+\))?!?: \S`)
+
 // NewCommitGenerator creates a new commit message generator
-func NewCommitGenerator(provider llm.Provider) *CommitGenerator {
+func NewCommitGenerator(provider llm.Provider, style CommitStyle) *CommitGenerator {
 	return &CommitGenerator{
 		provider:    provider,
-		maxTokens:   150, // Short commit messages
+		style:       style,
+		maxTokens:   400, // A subject line and a short body
 		temperature: 0.3, // More deterministic for consistency
 	}
 }
 
-// GenerateCommitMessage creates an AI-generated commit message based on diff
-func (cg *CommitGenerator) GenerateCommitMessage(ctx context.Context, repo *git.Repository) (string, error) {
-	// Get diff content
-	diffOutput, err := GetDiffOutput(repo)
+// GenerateCommitMessage creates an AI-generated commit message from the diff that will
+// be committed, with the repository status for context
+func (cg *CommitGenerator) GenerateCommitMessage(ctx context.Context, repo *git.Repository, diff string) (string, error) {
+	statusOutput, err := GetDiffOutput(repo)
 	if err != nil {
 		return "", fmt.Errorf("failed to get diff: %w", err)
 	}
 
-	if strings.Contains(diffOutput, "Working tree clean") {
+	if strings.Contains(statusOutput, "Working tree clean") {
 		return "", fmt.Errorf("no changes to commit")
 	}
 
@@ -49,31 +69,42 @@ func (cg *CommitGenerator) GenerateCommitMessage(ctx context.Context, repo *git.
 		return "", fmt.Errorf("no changes to commit")
 	}
 
+	if len(diff) > maxPromptDiff {
+		diff = strings.ToValidUTF8(diff[:maxPromptDiff], "") + fmt.Sprintf("\n... (diff truncated, %d more bytes)", len(diff)-maxPromptDiff)
+	}
+
+	format := `- First line: a summary of the change in the imperative mood, under 72 characters, without a trailing period`
+	if cg.style.Conventional {
+		format = `- Follow Conventional Commits. First line: type(scope): summary, where the scope is optional
+- Choose the type from what the diff does: feat (new behavior), fix (bug fix), refactor (restructuring without behavior change), perf, docs, test, build, ci, style or chore
+- Add ! after the type or scope for a breaking change
+- Keep the first line under 72 characters, in the imperative mood, without a trailing period`
+	}
+
 	// Create enhanced prompt for AI with better context
 	changesSummary := cg.analyzeChanges(repoStatus)
-	prompt := fmt.Sprintf(`Generate a concise git commit message for the following changes. Follow conventional commit format:
+	prompt := fmt.Sprintf(`Write a git commit message for the following changes.
 
 Rules:
-- Use format: type(scope): description
-- Types: feat, fix, docs, style, refactor, test, chore
-- Keep under 50 characters for the title
-- Be specific and clear about what changed
-- Focus on WHAT changed, not HOW
+%s
+- If the change needs explaining, add a blank line and a short body saying what changed and why, wrapped at 72 characters
+- Base the message on the diff; do not describe changes it does not show
+- Reply with the commit message only
 
 Changes Summary:
 %s
 
-Detailed Status:
+Diff:
 %s
 
-Commit message:`, changesSummary, diffOutput)
+Commit message:`, format, changesSummary, diff)
 
 	// Generate commit message using AI
 	req := &llm.GenerateRequest{
 		Messages: []llm.Message{
 			{
 				Role:    "system",
-				Content: "You are a git commit message generator. Generate concise, clear commit messages following conventional commit format.",
+				Content: "You are a git commit message generator. Generate concise, clear commit messages that describe the diff you are given.",
 			},
 			{
 				Role:    "user",
@@ -89,20 +120,61 @@ Commit message:`, changesSummary, diffOutput)
 		return "", fmt.Errorf("failed to generate commit message: %w", err)
 	}
 
-	// Clean up the response
-	message := strings.TrimSpace(response.Content)
-	message = strings.ReplaceAll(message, "\n", " ")
+	return FormatCommitMessage(response.Content, cg.style), nil
+}
 
-	// Remove any quotes if AI added them
-	message = strings.Trim(message, "\"'`")
+// FormatCommitMessage cleans up a generated commit message: quotes and code fences are
+// removed, a subject over 72 characters is cut at a word and the rest moved to the
+// body, and the Co-authored-by trailer is added. In conventional mode a subject
+// without a type gets "chore: ".
+func FormatCommitMessage(raw string, style CommitStyle) string {
+	message := strings.TrimSpace(raw)
+	if fenced, ok := strings.CutPrefix(message, "```"); ok {
+		// Drop the fence's language tag, if any
+		if tag, rest, found := strings.Cut(fenced, "\n"); found && !strings.Contains(strings.TrimSpace(tag), " ") {
+			fenced = rest
+		}
+		message = strings.TrimSuffix(strings.TrimSpace(fenced), "```")
+	}
+	message = strings.Trim(strings.TrimSpace(message), "\"'`")
+
+	subject, body, _ := strings.Cut(strings.TrimSpace(message), "\n")
+	subject = strings.TrimSuffix(strings.TrimSpace(subject), ".")
+	body = strings.TrimSpace(body)
+
+	if style.Conventional && !conventionalSubject.MatchString(subject) {
+		subject = "chore: " + subject
+	}
 
-	return message, nil
+	if len(subject) > maxSubjectLength {
+		cut := strings.LastIndex(subject[:maxSubjectLength], " ")
+		if cut <= 0 {
+			cut = maxSubjectLength
+		}
+		rest := strings.TrimSpace(subject[cut:])
+		subject = strings.TrimSpace(subject[:cut])
+		if body != "" {
+			body = rest + "\n\n" + body
+		} else {
+			body = rest
+		}
+	}
+
+	result := subject
+	if body != "" {
+		result += "\n\n" + body
+	}
+	if style.CoAuthoredBy != "" {
+		result += "\n\nCo-authored-by: " + style.CoAuthoredBy
+	}
+	return result
 }
 
-// CommitWithAI commits changes with an AI-generated message
-func (cg *CommitGenerator) CommitWithAI(ctx context.Context, repo *git.Repository, authorName, authorEmail string) (string, error) {
+// CommitWithAI commits all changes with an AI-generated message. diff is the change
+// being committed, for the model to describe.
+func (cg *CommitGenerator) CommitWithAI(ctx context.Context, repo *git.Repository, diff, authorName, authorEmail string) (string, error) {
 	// Generate commit message
-	message, err := cg.GenerateCommitMessage(ctx, repo)
+	message, err := cg.GenerateCommitMessage(ctx, repo, diff)
 	if err != nil {
 		return "", err
 	}
@@ -116,6 +188,30 @@ func (cg *CommitGenerator) CommitWithAI(ctx context.Context, repo *git.Repositor
 	return fmt.Sprintf("Committed with message: %s\nCommit: %s", message, commitHash), nil
 }
 
+// StageAll adds every change in the working tree to the index, as a commit does
+func StageAll(repo *git.Repository) error {
+	if repo == nil {
+		return fmt.Errorf("no git repository")
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("failed to get worktree: %w", err)
+	}
+
+	status, err := worktree.Status()
+	if err != nil {
+		return fmt.Errorf("failed to get status: %w", err)
+	}
+
+	for file := range status {
+		if _, err := worktree.Add(file); err != nil {
+			return fmt.Errorf("failed to add file %s: %w", file, err)
+		}
+	}
+	return nil
+}
+
 // CommitChanges commits all staged changes with the given message
 func CommitChanges(repo *git.Repository, message, authorName, authorEmail string) (string, error) {
 	if repo == nil {
@@ -137,12 +233,8 @@ func CommitChanges(repo *git.Repository, message, authorName, authorEmail string
 		return "", fmt.Errorf("nothing to commit, working tree clean")
 	}
 
-	// Stage all changes
-	for file := range status {
-		_, err = worktree.Add(file)
-		if err != nil {
-			return "", fmt.Errorf("failed to add file %s: %w", file, err)
-		}
+	if err := StageAll(repo); err != nil {
+		return "", err
 	}
 
 	// Create commit
@@ -0,0 +1,68 @@
+package git
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatCommitMessage(t *testing.T) {
+	tests := []struct {
+		name  string
+		raw   string
+		style CommitStyle
+		want  string
+	}{
+		{
+			name: "plain subject",
+			raw:  "Fix nil pointer in session resume.",
+			want: "Fix nil pointer in session resume",
+		},
+		{
+			name: "code fence with language tag",
+			raw:  "```text\nAdd /diff command\n\nShows the net change per file.\n```",
+			want: "Add /diff command\n\nShows the net change per file.",
+		},
+		{
+			name:  "conventional subject kept",
+			raw:   "feat(ui): add file picker",
+			style: CommitStyle{Conventional: true},
+			want:  "feat(ui): add file picker",
+		},
+		{
+			name:  "missing type gets chore",
+			raw:   "update dependencies",
+			style: CommitStyle{Conventional: true},
+			want:  "chore: update dependencies",
+		},
+		{
+			name:  "co-author trailer",
+			raw:   "fix: handle empty diff",
+			style: CommitStyle{Conventional: true, CoAuthoredBy: "bazinga <bazinga@example.com>"},
+			want:  "fix: handle empty diff\n\nCo-authored-by: bazinga <bazinga@example.com>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatCommitMessage(tt.raw, tt.style); got != tt.want {
+				t.Errorf("FormatCommitMessage(%q) = %q, want %q", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatCommitMessage_LongSubject(t *testing.T) {
+	raw := "Refactor the permission manager so that rules are evaluated before prompting the user\n\nKeeps prompts rare."
+	got := FormatCommitMessage(raw, CommitStyle{})
+
+	subject, body, _ := strings.Cut(got, "\n\n")
+	if len(subject) > maxSubjectLength {
+		t.Errorf("Expected subject of at most %d characters, got %d: %q", maxSubjectLength, len(subject), subject)
+	}
+	if strings.HasSuffix(subject, " ") || !strings.HasPrefix(raw, subject+" ") {
+		t.Errorf("Expected subject cut at a word boundary, got %q", subject)
+	}
+	if !strings.HasPrefix(body, "prompting the user") || !strings.HasSuffix(body, "Keeps prompts rare.") {
+		t.Errorf("Expected the rest of the subject moved into the body, got %q", body)
+	}
+}
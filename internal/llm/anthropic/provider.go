@@ -17,12 +17,14 @@ type Provider struct {
 	apiKey     string
 	baseURL    string
 	httpClient *http.Client
+	retry      llm.RetryConfig
 }
 
 // Config represents Anthropic-specific configuration
 type Config struct {
-	APIKey  string `yaml:"api_key"`
-	BaseURL string `yaml:"base_url"`
+	APIKey  string          `yaml:"api_key"`
+	BaseURL string          `yaml:"base_url"`
+	Retry   llm.RetryConfig `yaml:"-"` // Zero value uses llm.DefaultRetryConfig
 }
 
 // NewProvider creates a new Anthropic provider
@@ -49,6 +51,7 @@ func NewProviderWithConfig(cfg *Config) *Provider {
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		retry: cfg.Retry,
 	}
 }
 
@@ -59,6 +62,8 @@ func (p *Provider) Name() string {
 
 // GenerateResponse generates a response using Anthropic's API
 func (p *Provider) GenerateResponse(ctx context.Context, req *llm.GenerateRequest) (*llm.Response, error) {
+	req = llm.LimitOutputTokens(req, p.GetAvailableModels(), p.GetDefaultModel())
+
 	// Convert to Anthropic format
 	anthropicReq := convertToAnthropicRequest(req)
 
@@ -67,6 +72,25 @@ func (p *Provider) GenerateResponse(ctx context.Context, req *llm.GenerateReques
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
+	resp, err := llm.Retry(ctx, p.retry, "anthropic generate", func(ctx context.Context) (*http.Response, error) {
+		return p.postMessages(ctx, reqBody)
+	})
+	if err != nil {
+		return nil, llm.ClassifyError(p.Name(), err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var anthropicResp anthropicResponse
+	if err := json.NewDecoder(resp.Body).Decode(&anthropicResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return convertFromAnthropicResponse(&anthropicResp), nil
+}
+
+// postMessages sends a request body to the messages endpoint. A response other than
+// 200 is returned as an *llm.StatusError with its body closed.
+func (p *Provider) postMessages(ctx context.Context, reqBody []byte) (*http.Response, error) {
 	httpReq, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/v1/messages", bytes.NewReader(reqBody))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
@@ -80,23 +104,19 @@ func (p *Provider) GenerateResponse(ctx context.Context, req *llm.GenerateReques
 	if err != nil {
 		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
-	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
-
-	var anthropicResp anthropicResponse
-	if err := json.NewDecoder(resp.Body).Decode(&anthropicResp); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+		_ = resp.Body.Close()
+		return nil, llm.NewStatusError(resp, body)
 	}
-
-	return convertFromAnthropicResponse(&anthropicResp), nil
+	return resp, nil
 }
 
 // StreamResponse streams a response using Anthropic's API with real streaming
 func (p *Provider) StreamResponse(ctx context.Context, req *llm.GenerateRequest) (<-chan *llm.StreamChunk, error) {
+	req = llm.LimitOutputTokens(req, p.GetAvailableModels(), p.GetDefaultModel())
+
 	// Convert to Anthropic format with streaming enabled
 	anthropicReq := convertToAnthropicRequest(req)
 	anthropicReq.Stream = true
@@ -106,24 +126,12 @@ func (p *Provider) StreamResponse(ctx context.Context, req *llm.GenerateRequest)
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/v1/messages", bytes.NewReader(reqBody))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	httpReq.Header.Set("x-api-key", p.apiKey)
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("anthropic-version", "2023-06-01")
-
-	resp, err := p.httpClient.Do(httpReq)
+	// Only establishing the stream is retried; a stream that drops is resumed by the session
+	resp, err := llm.Retry(ctx, p.retry, "anthropic stream", func(ctx context.Context) (*http.Response, error) {
+		return p.postMessages(ctx, reqBody)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		resp.Body.Close()
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+		return nil, llm.ClassifyError(p.Name(), err)
 	}
 
 	// Create channel for streaming chunks
@@ -145,6 +153,15 @@ func (p *Provider) StreamResponse(ctx context.Context, req *llm.GenerateRequest)
 			return
 		}
 
+		// Reasoning comes before the answer, as the model produced it
+		if response.Thinking != "" {
+			select {
+			case streamChan <- &llm.StreamChunk{ID: response.ID, Type: "thinking", Content: response.Thinking}:
+			case <-ctx.Done():
+				return
+			}
+		}
+
 		// Send tool calls first (like Claude Code does)
 		for _, toolCall := range response.ToolCalls {
 			chunk := &llm.StreamChunk{
@@ -183,7 +200,7 @@ func (p *Provider) StreamResponse(ctx context.Context, req *llm.GenerateRequest)
 
 		// Send completion marker
 		select {
-		case streamChan <- &llm.StreamChunk{ID: response.ID, Type: "content_block_stop"}:
+		case streamChan <- &llm.StreamChunk{ID: response.ID, Type: "content_block_stop", Usage: response.TokenUsage()}:
 		case <-ctx.Done():
 			return
 		}
@@ -217,9 +234,9 @@ func (p *Provider) SupportsFunctionCalling() bool {
 // GetAvailableModels returns the available models for this provider
 func (p *Provider) GetAvailableModels() []llm.Model {
 	return []llm.Model{
-		{ID: "claude-3-opus-20240229", Name: "Claude 3 Opus", Provider: "anthropic"},
-		{ID: "claude-3-sonnet-20240229", Name: "Claude 3 Sonnet", Provider: "anthropic"},
-		{ID: "claude-3-haiku-20240307", Name: "Claude 3 Haiku", Provider: "anthropic"},
+		{ID: "claude-3-opus-20240229", Name: "Claude 3 Opus", Provider: "anthropic", MaxTokens: 200000, MaxOutputTokens: 4096},
+		{ID: "claude-3-sonnet-20240229", Name: "Claude 3 Sonnet", Provider: "anthropic", MaxTokens: 200000, MaxOutputTokens: 4096},
+		{ID: "claude-3-haiku-20240307", Name: "Claude 3 Haiku", Provider: "anthropic", MaxTokens: 200000, MaxOutputTokens: 4096},
 	}
 }
 
@@ -258,8 +275,36 @@ type anthropicRequest struct {
 }
 
 type anthropicMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role    string
+	Content string           // Plain text turns
+	Blocks  []anthropicBlock // Image, tool_use and tool_result turns, sent instead of Content
+}
+
+// anthropicBlock is a request content block
+type anthropicBlock struct {
+	Type      string           `json:"type"` // "text", "image", "tool_use" or "tool_result"
+	Text      string           `json:"text,omitempty"`
+	Source    *llm.ImageSource `json:"source,omitempty"`
+	ID        string           `json:"id,omitempty"`
+	Name      string           `json:"name,omitempty"`
+	Input     json.RawMessage  `json:"input,omitempty"` // Always set for tool_use, even when empty
+	ToolUseID string           `json:"tool_use_id,omitempty"`
+	Content   interface{}      `json:"content,omitempty"` // tool_result: string, or text and image blocks
+	IsError   bool             `json:"is_error,omitempty"`
+}
+
+// MarshalJSON sends content as a string for text turns and as blocks otherwise
+func (m anthropicMessage) MarshalJSON() ([]byte, error) {
+	if len(m.Blocks) > 0 {
+		return json.Marshal(struct {
+			Role    string           `json:"role"`
+			Content []anthropicBlock `json:"content"`
+		}{m.Role, m.Blocks})
+	}
+	return json.Marshal(struct {
+		Role    string `json:"role"`
+		Content string `json:"content"`
+	}{m.Role, m.Content})
 }
 
 type anthropicTool struct {
@@ -280,11 +325,12 @@ type anthropicResponse struct {
 }
 
 type anthropicContent struct {
-	Type  string                 `json:"type"`
-	Text  string                 `json:"text,omitempty"`
-	ID    string                 `json:"id,omitempty"`
-	Name  string                 `json:"name,omitempty"`
-	Input map[string]interface{} `json:"input,omitempty"`
+	Type     string                 `json:"type"`
+	Text     string                 `json:"text,omitempty"`
+	Thinking string                 `json:"thinking,omitempty"`
+	ID       string                 `json:"id,omitempty"`
+	Name     string                 `json:"name,omitempty"`
+	Input    map[string]interface{} `json:"input,omitempty"`
 }
 
 type anthropicUsage struct {
@@ -300,20 +346,10 @@ func convertToAnthropicRequest(req *llm.GenerateRequest) *anthropicRequest {
 		Temperature: req.Temperature,
 	}
 
-	// Convert messages and extract system message
-	var systemMessage string
-	for _, msg := range req.Messages {
-		if msg.Role == "system" {
-			// Extract system message content
-			if content, ok := msg.Content.(string); ok {
-				systemMessage = content
-			}
-		} else {
-			anthropicReq.Messages = append(anthropicReq.Messages, anthropicMessage{
-				Role:    msg.Role,
-				Content: fmt.Sprintf("%v", msg.Content), // Simple string conversion
-			})
-		}
+	// Extract the system prompt; mid-conversation system messages become user turns
+	systemMessage, conversation := llm.SplitSystemPrompt(req.Messages)
+	for _, msg := range llm.PairToolResults(conversation) {
+		anthropicReq.Messages = appendAnthropicMessage(anthropicReq.Messages, msg)
 	}
 
 	// Set system message if we found one
@@ -333,8 +369,83 @@ func convertToAnthropicRequest(req *llm.GenerateRequest) *anthropicRequest {
 	return anthropicReq
 }
 
+// appendAnthropicMessage converts a message and appends it. Assistant tool calls become
+// tool_use blocks, and tool results become tool_result blocks in a user turn, grouped
+// with the other results for the same assistant turn as the API requires.
+func appendAnthropicMessage(messages []anthropicMessage, msg llm.Message) []anthropicMessage {
+	switch {
+	case msg.Role == "tool":
+		block := anthropicBlock{
+			Type:      "tool_result",
+			ToolUseID: msg.ToolCallID,
+			IsError:   msg.IsError,
+		}
+		if blocks, ok := msg.Content.([]llm.ContentBlock); ok {
+			block.Content = convertAnthropicBlocks(blocks)
+		} else if content := llm.ContentText(msg.Content); content != "" {
+			block.Content = content
+		}
+		if n := len(messages); n > 0 && messages[n-1].Role == "user" && isToolResultTurn(messages[n-1]) {
+			messages[n-1].Blocks = append(messages[n-1].Blocks, block)
+			return messages
+		}
+		return append(messages, anthropicMessage{Role: "user", Blocks: []anthropicBlock{block}})
+
+	case msg.Role == "assistant" && len(msg.ToolCalls) > 0:
+		var blocks []anthropicBlock
+		if text, ok := msg.Content.(string); ok && strings.TrimSpace(text) != "" {
+			blocks = append(blocks, anthropicBlock{Type: "text", Text: text})
+		}
+		for _, call := range msg.ToolCalls {
+			input, err := json.Marshal(call.CallInput())
+			if err != nil {
+				input = []byte("{}")
+			}
+			blocks = append(blocks, anthropicBlock{
+				Type:  "tool_use",
+				ID:    call.ID,
+				Name:  call.CallName(),
+				Input: input,
+			})
+		}
+		return append(messages, anthropicMessage{Role: "assistant", Blocks: blocks})
+	}
+
+	if blocks, ok := msg.Content.([]llm.ContentBlock); ok {
+		return append(messages, anthropicMessage{Role: msg.Role, Blocks: convertAnthropicBlocks(blocks)})
+	}
+
+	return append(messages, anthropicMessage{
+		Role:    msg.Role,
+		Content: fmt.Sprintf("%v", msg.Content), // Simple string conversion
+	})
+}
+
+// convertAnthropicBlocks converts the text and image blocks of structured content
+func convertAnthropicBlocks(blocks []llm.ContentBlock) []anthropicBlock {
+	var converted []anthropicBlock
+	for _, block := range blocks {
+		switch block.Type {
+		case "text":
+			if block.Text != "" {
+				converted = append(converted, anthropicBlock{Type: "text", Text: block.Text})
+			}
+		case "image":
+			if block.Source != nil {
+				converted = append(converted, anthropicBlock{Type: "image", Source: block.Source})
+			}
+		}
+	}
+	return converted
+}
+
+// isToolResultTurn reports whether a user turn carries tool results
+func isToolResultTurn(msg anthropicMessage) bool {
+	return len(msg.Blocks) > 0 && msg.Blocks[0].Type == "tool_result"
+}
+
 func convertFromAnthropicResponse(resp *anthropicResponse) *llm.Response {
-	content := ""
+	content, thinking := "", ""
 	var toolCalls []llm.ToolCall
 
 	// Process all content blocks
@@ -345,6 +456,11 @@ func convertFromAnthropicResponse(resp *anthropicResponse) *llm.Response {
 				content += "\n"
 			}
 			content += block.Text
+		case "thinking":
+			if thinking != "" {
+				thinking += "\n"
+			}
+			thinking += block.Thinking
 		case "tool_use":
 			// Ensure input is not nil
 			input := block.Input
@@ -366,6 +482,7 @@ func convertFromAnthropicResponse(resp *anthropicResponse) *llm.Response {
 		ID:           resp.ID,
 		Model:        resp.Model,
 		Content:      content,
+		Thinking:     thinking,
 		StopReason:   resp.StopReason,
 		InputTokens:  resp.Usage.InputTokens,
 		OutputTokens: resp.Usage.OutputTokens,
@@ -552,3 +552,155 @@ func TestProvider_GenerateResponse_InvalidJSON(t *testing.T) {
 		t.Errorf("Expected JSON decode error message, got: %v", err)
 	}
 }
+
+func TestConvertToAnthropicRequest_MidConversationSystem(t *testing.T) {
+	req := &llm.GenerateRequest{
+		Messages: []llm.Message{
+			{Role: "system", Content: "You are a helpful assistant"},
+			{Role: "user", Content: "List the files"},
+			{Role: "assistant", Content: "Running list_files"},
+			{Role: "system", Content: "Tool results from 'list_files':\nmain.go"},
+			{Role: "user", Content: "Thanks"},
+		},
+	}
+
+	anthropicReq := convertToAnthropicRequest(req)
+
+	if anthropicReq.System != "You are a helpful assistant" {
+		t.Errorf("Expected system prompt to contain only the leading system message, got %q", anthropicReq.System)
+	}
+
+	if len(anthropicReq.Messages) != 4 {
+		t.Fatalf("Expected 4 messages, got %d", len(anthropicReq.Messages))
+	}
+
+	toolMsg := anthropicReq.Messages[2]
+	if toolMsg.Role != "user" {
+		t.Errorf("Expected mid-conversation system message to become a user turn, got %s", toolMsg.Role)
+	}
+
+	if !strings.Contains(toolMsg.Content, "main.go") {
+		t.Errorf("Expected tool result to be preserved, got %q", toolMsg.Content)
+	}
+}
+
+func TestConvertToAnthropicRequest_ToolResultRoundTrip(t *testing.T) {
+	call := llm.ToolCall{ID: "toolu_1", Name: "read_file", Input: map[string]interface{}{"file_path": "main.go"}}
+	failed := llm.ToolCall{ID: "toolu_2", Name: "list_files", Input: map[string]interface{}{}}
+	req := &llm.GenerateRequest{
+		Messages: []llm.Message{
+			{Role: "system", Content: "You are a helpful assistant"},
+			{Role: "user", Content: "Read main.go"},
+			{Role: "assistant", Content: "Reading it", ToolCalls: []llm.ToolCall{call, failed}},
+			llm.NewToolResultMessage(&call, "package main", false),
+			llm.NewToolResultMessage(&failed, "Error: permission denied", true),
+		},
+	}
+
+	data, err := json.Marshal(convertToAnthropicRequest(req))
+	if err != nil {
+		t.Fatalf("Failed to marshal request: %v", err)
+	}
+
+	var sent struct {
+		Messages []struct {
+			Role    string          `json:"role"`
+			Content json.RawMessage `json:"content"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal(data, &sent); err != nil {
+		t.Fatalf("Request is not valid JSON: %v", err)
+	}
+
+	if len(sent.Messages) != 3 {
+		t.Fatalf("Expected user, assistant and one tool result turn, got %d messages", len(sent.Messages))
+	}
+
+	var assistant []anthropicContent
+	if err := json.Unmarshal(sent.Messages[1].Content, &assistant); err != nil {
+		t.Fatalf("Expected assistant content blocks: %v", err)
+	}
+	if len(assistant) != 3 || assistant[0].Text != "Reading it" || assistant[1].Type != "tool_use" ||
+		assistant[1].ID != "toolu_1" || assistant[1].Input["file_path"] != "main.go" {
+		t.Errorf("Unexpected assistant blocks: %+v", assistant)
+	}
+	if !strings.Contains(string(sent.Messages[1].Content), `"input":{}`) {
+		t.Error("Expected tool_use with no arguments to send an empty input object")
+	}
+
+	var results []struct {
+		Type      string `json:"type"`
+		ToolUseID string `json:"tool_use_id"`
+		Content   string `json:"content"`
+		IsError   bool   `json:"is_error"`
+	}
+	if err := json.Unmarshal(sent.Messages[2].Content, &results); err != nil {
+		t.Fatalf("Expected tool_result blocks: %v", err)
+	}
+	if sent.Messages[2].Role != "user" || len(results) != 2 {
+		t.Fatalf("Expected both results in one user turn, got %s with %d blocks", sent.Messages[2].Role, len(results))
+	}
+	if results[0].Type != "tool_result" || results[0].ToolUseID != "toolu_1" || results[0].Content != "package main" || results[0].IsError {
+		t.Errorf("Unexpected tool result: %+v", results[0])
+	}
+	if results[1].ToolUseID != "toolu_2" || !results[1].IsError {
+		t.Errorf("Expected failed tool result to be marked as an error, got %+v", results[1])
+	}
+	if strings.Contains(string(data), "<tool_result") {
+		t.Error("Structured tool results should not use the text wrapper")
+	}
+}
+
+func TestConvertToAnthropicRequest_ImageToolResult(t *testing.T) {
+	call := llm.ToolCall{ID: "toolu_1", Name: "read_file", Input: map[string]interface{}{"file_path": "shot.png"}}
+	image := llm.ImageSource{Type: "base64", MediaType: "image/png", Data: "iVBORw0KGgo="}
+	req := &llm.GenerateRequest{
+		Messages: []llm.Message{
+			{Role: "user", Content: "Look at the screenshot"},
+			{Role: "assistant", ToolCalls: []llm.ToolCall{call}},
+			llm.NewToolResultMessage(&call, "Image: shot.png", false, image),
+		},
+	}
+
+	data, err := json.Marshal(convertToAnthropicRequest(req))
+	if err != nil {
+		t.Fatalf("Failed to marshal request: %v", err)
+	}
+
+	var sent struct {
+		Messages []struct {
+			Role    string          `json:"role"`
+			Content json.RawMessage `json:"content"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal(data, &sent); err != nil {
+		t.Fatalf("Request is not valid JSON: %v", err)
+	}
+	if len(sent.Messages) != 3 {
+		t.Fatalf("Expected user, assistant and tool result turns, got %s", data)
+	}
+
+	var results []struct {
+		Type      string `json:"type"`
+		ToolUseID string `json:"tool_use_id"`
+		Content   []struct {
+			Type   string           `json:"type"`
+			Text   string           `json:"text"`
+			Source *llm.ImageSource `json:"source"`
+		} `json:"content"`
+	}
+	if err := json.Unmarshal(sent.Messages[2].Content, &results); err != nil || len(results) != 1 {
+		t.Fatalf("Expected a single tool result block, got %s", sent.Messages[2].Content)
+	}
+
+	result := results[0]
+	if result.Type != "tool_result" || result.ToolUseID != "toolu_1" || len(result.Content) != 2 {
+		t.Fatalf("Unexpected tool result: %+v", result)
+	}
+	if result.Content[0].Type != "text" || result.Content[0].Text != "Image: shot.png" {
+		t.Errorf("Expected the text block first, got %+v", result.Content[0])
+	}
+	if result.Content[1].Type != "image" || result.Content[1].Source == nil || *result.Content[1].Source != image {
+		t.Errorf("Expected the image block, got %+v", result.Content[1])
+	}
+}
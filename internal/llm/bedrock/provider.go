@@ -20,6 +20,7 @@ type Provider struct {
 	region       string
 	defaultModel string
 	models       map[string]llm.Model
+	retry        llm.RetryConfig
 }
 
 // Config represents Bedrock-specific configuration
@@ -30,6 +31,8 @@ type Config struct {
 	SessionToken string `yaml:"session_token"`
 	Profile      string `yaml:"profile"`
 	AuthMethod   string `yaml:"auth_method"`
+
+	Retry llm.RetryConfig `yaml:"-"` // Zero value uses llm.DefaultRetryConfig
 }
 
 // Claude model IDs for Bedrock
@@ -86,34 +89,43 @@ func NewProvider(cfg *Config) (*Provider, error) {
 		return nil, fmt.Errorf("credential validation failed: %w", err)
 	}
 
-	// Create Bedrock Runtime client
-	client := bedrockruntime.NewFromConfig(awsCfg)
+	// Create Bedrock Runtime client. Throttling and server errors are retried by
+	// llm.Retry, so the SDK's own retries are turned off to avoid multiplying attempts.
+	client := bedrockruntime.NewFromConfig(awsCfg, func(o *bedrockruntime.Options) {
+		o.Retryer = aws.NopRetryer{}
+	})
 
 	// Define available models
 	models := map[string]llm.Model{
 		ModelClaudeSonnet: {
-			ID:              ModelClaudeSonnet,
-			Name:            "Claude 3 Sonnet",
-			Provider:        "bedrock",
-			MaxTokens:       200000,
-			SupportsTools:   true,
-			CostPer1KTokens: 0.003, // Approximate pricing
+			ID:                    ModelClaudeSonnet,
+			Name:                  "Claude 3 Sonnet",
+			Provider:              "bedrock",
+			MaxTokens:             200000,
+			MaxOutputTokens:       4096,
+			SupportsTools:         true,
+			CostPer1KTokens:       0.003, // Approximate pricing
+			OutputCostPer1KTokens: 0.015,
 		},
 		ModelClaudeOpus: {
-			ID:              ModelClaudeOpus,
-			Name:            "Claude 3 Opus",
-			Provider:        "bedrock",
-			MaxTokens:       200000,
-			SupportsTools:   true,
-			CostPer1KTokens: 0.015, // Approximate pricing
+			ID:                    ModelClaudeOpus,
+			Name:                  "Claude 3 Opus",
+			Provider:              "bedrock",
+			MaxTokens:             200000,
+			MaxOutputTokens:       4096,
+			SupportsTools:         true,
+			CostPer1KTokens:       0.015, // Approximate pricing
+			OutputCostPer1KTokens: 0.075,
 		},
 		ModelClaudeHaiku: {
-			ID:              ModelClaudeHaiku,
-			Name:            "Claude 3 Haiku",
-			Provider:        "bedrock",
-			MaxTokens:       200000,
-			SupportsTools:   true,
-			CostPer1KTokens: 0.00025, // Approximate pricing
+			ID:                    ModelClaudeHaiku,
+			Name:                  "Claude 3 Haiku",
+			Provider:              "bedrock",
+			MaxTokens:             200000,
+			MaxOutputTokens:       4096,
+			SupportsTools:         true,
+			CostPer1KTokens:       0.00025, // Approximate pricing
+			OutputCostPer1KTokens: 0.00125,
 		},
 	}
 
@@ -122,6 +134,7 @@ func NewProvider(cfg *Config) (*Provider, error) {
 		region:       cfg.Region,
 		defaultModel: ModelClaudeSonnet, // Default to Sonnet
 		models:       models,
+		retry:        cfg.Retry,
 	}, nil
 }
 
@@ -132,6 +145,8 @@ func (p *Provider) Name() string {
 
 // GenerateResponse generates a response from Claude via Bedrock
 func (p *Provider) GenerateResponse(ctx context.Context, req *llm.GenerateRequest) (*llm.Response, error) {
+	req = llm.LimitOutputTokens(req, p.GetAvailableModels(), p.GetDefaultModel())
+
 	start := time.Now()
 
 	// Use default model if not specified
@@ -147,13 +162,15 @@ func (p *Provider) GenerateResponse(ctx context.Context, req *llm.GenerateReques
 	}
 
 	// Make the API call
-	resp, err := p.client.InvokeModel(ctx, &bedrockruntime.InvokeModelInput{
-		ModelId:     aws.String(model),
-		ContentType: aws.String("application/json"),
-		Body:        bedrockReq,
+	resp, err := llm.Retry(ctx, p.retry, "bedrock generate", func(ctx context.Context) (*bedrockruntime.InvokeModelOutput, error) {
+		return p.client.InvokeModel(ctx, &bedrockruntime.InvokeModelInput{
+			ModelId:     aws.String(model),
+			ContentType: aws.String("application/json"),
+			Body:        bedrockReq,
+		})
 	})
 	if err != nil {
-		return nil, fmt.Errorf("bedrock invoke model failed: %w", err)
+		return nil, llm.ClassifyError(p.Name(), fmt.Errorf("bedrock invoke model failed: %w", err))
 	}
 
 	// Parse response
@@ -170,6 +187,8 @@ func (p *Provider) GenerateResponse(ctx context.Context, req *llm.GenerateReques
 
 // StreamResponse streams a response from Claude via Bedrock
 func (p *Provider) StreamResponse(ctx context.Context, req *llm.GenerateRequest) (<-chan *llm.StreamChunk, error) {
+	req = llm.LimitOutputTokens(req, p.GetAvailableModels(), p.GetDefaultModel())
+
 	loggy.Debug("Bedrock StreamResponse", "starting", "true")
 
 	// Use default model if not specified
@@ -201,14 +220,16 @@ func (p *Provider) StreamResponse(ctx context.Context, req *llm.GenerateRequest)
 	loggy.Debug("Bedrock StreamResponse", "invoking_model_stream", "true")
 
 	// Make streaming API call
-	resp, err := p.client.InvokeModelWithResponseStream(ctx, &bedrockruntime.InvokeModelWithResponseStreamInput{
-		ModelId:     aws.String(model),
-		ContentType: aws.String("application/json"),
-		Body:        bedrockReq,
+	resp, err := llm.Retry(ctx, p.retry, "bedrock stream", func(ctx context.Context) (*bedrockruntime.InvokeModelWithResponseStreamOutput, error) {
+		return p.client.InvokeModelWithResponseStream(ctx, &bedrockruntime.InvokeModelWithResponseStreamInput{
+			ModelId:     aws.String(model),
+			ContentType: aws.String("application/json"),
+			Body:        bedrockReq,
+		})
 	})
 	if err != nil {
 		loggy.Error("Bedrock StreamResponse", "invoke_model_stream_failed", err)
-		return nil, fmt.Errorf("bedrock invoke model stream failed: %w", err)
+		return nil, llm.ClassifyError(p.Name(), fmt.Errorf("bedrock invoke model stream failed: %w", err))
 	}
 
 	loggy.Debug("Bedrock StreamResponse", "api_call_successful", "true", "creating_channel", "true")
@@ -381,27 +402,23 @@ func (p *Provider) convertRequest(req *llm.GenerateRequest, modelID string) ([]b
 
 	// Convert messages
 	messages := make([]map[string]interface{}, 0, len(req.Messages))
-	var systemMessage string
 	var userContent string
 
-	// First pass: collect all messages and handle system messages
+	// Leading system messages go to Claude's system parameter; later ones
+	// (e.g. tool results) are kept in place as user turns
+	systemMessage, conversation := llm.SplitSystemPrompt(req.Messages)
+
+	// Claude conversations must open with a user turn. Leading assistant turns, left
+	// behind when older history is trimmed, are dropped before tool results are paired
+	// so the results of their calls are kept as text rather than as tool_result blocks
+	// pointing at a tool_use that is no longer sent.
+	conversation = dropLeadingAssistantTurns(conversation)
+
+	// First pass: collect all messages
 	var normalizedMessages []map[string]interface{}
 	var lastAddedRole string
 
-	for _, msg := range req.Messages {
-		// Handle system messages - store separately for Claude's system parameter
-		if msg.Role == "system" {
-			if content, ok := msg.Content.(string); ok {
-				// If we already have a system message, append to it
-				if systemMessage != "" {
-					systemMessage += "\n\n" + content
-				} else {
-					systemMessage = content
-				}
-			}
-			continue
-		}
-
+	for _, msg := range llm.PairToolResults(conversation) {
 		// Skip messages with empty roles
 		if msg.Role == "" {
 			// If there's content but no role, use it as generic user content
@@ -411,19 +428,7 @@ func (p *Provider) convertRequest(req *llm.GenerateRequest, modelID string) ([]b
 			continue
 		}
 
-		// Create Claude message
-		claudeMsg := map[string]interface{}{
-			"role":    msg.Role,
-			"content": p.convertContent(msg.Content),
-		}
-
-		// Handle tool messages - convert to assistant to maintain alternation
-		if msg.Role == "tool" {
-			// Convert tool messages to assistant messages to maintain alternation
-			claudeMsg["role"] = "assistant"
-		}
-
-		normalizedMessages = append(normalizedMessages, claudeMsg)
+		normalizedMessages = append(normalizedMessages, p.convertMessage(msg))
 	}
 
 	// Second pass: ensure proper alternation of user/assistant roles
@@ -431,32 +436,27 @@ func (p *Provider) convertRequest(req *llm.GenerateRequest, modelID string) ([]b
 		lastAddedRole = ""
 		loggy.Debug("Bedrock message processing", "total_normalized_messages", len(normalizedMessages))
 
-		for i, msg := range normalizedMessages {
+		for _, msg := range normalizedMessages {
 			role, _ := msg["role"].(string)
 
 			// First message must be from user
-			if i == 0 && role != "user" {
-				// If first message isn't from user, skip it
+			if len(messages) == 0 && role != "user" {
 				loggy.Debug("Bedrock message processing", "skipping_non_user_first_message", role)
 				continue
 			}
 
-			// Enforce alternation - only add if this message's role differs from the last added
+			// Enforce alternation - only add if this message's role differs from the last
+			// added. Same-role turns are merged, keeping tool results as separate blocks.
 			if lastAddedRole == "" || role != lastAddedRole {
 				messages = append(messages, msg)
 				lastAddedRole = role
 				loggy.Debug("Bedrock message processing", "added_message", role)
 			} else {
-				// If same role as previous, combine content if possible
+				// If same role as previous, combine content
 				if len(messages) > 0 {
 					lastMsg := messages[len(messages)-1]
-					if content, ok := lastMsg["content"].(string); ok {
-						if newContent, ok := msg["content"].(string); ok {
-							// Combine text content
-							lastMsg["content"] = content + "\n\n" + newContent
-							loggy.Debug("Bedrock message processing", "combined_consecutive_messages", role)
-						}
-					}
+					lastMsg["content"] = mergeContent(lastMsg["content"], msg["content"])
+					loggy.Debug("Bedrock message processing", "combined_consecutive_messages", role)
 				}
 			}
 		}
@@ -497,6 +497,89 @@ func (p *Provider) convertRequest(req *llm.GenerateRequest, modelID string) ([]b
 	return json.Marshal(bedrockReq)
 }
 
+// dropLeadingAssistantTurns removes assistant turns that come before the first user or
+// tool message
+func dropLeadingAssistantTurns(messages []llm.Message) []llm.Message {
+	for len(messages) > 0 && messages[0].Role == "assistant" {
+		loggy.Debug("Bedrock message processing", "dropping_leading_assistant_turn", len(messages[0].ToolCalls))
+		messages = messages[1:]
+	}
+	return messages
+}
+
+// convertMessage converts a message to Claude format. Tool results become tool_result
+// blocks in a user turn and assistant tool calls become tool_use blocks.
+func (p *Provider) convertMessage(msg llm.Message) map[string]interface{} {
+	switch {
+	case msg.Role == "tool":
+		// Text results stay a string; results with images keep their blocks
+		var content interface{} = llm.ContentText(msg.Content)
+		if blocks, ok := msg.Content.([]llm.ContentBlock); ok {
+			content = p.convertContent(blocks)
+		}
+		block := map[string]interface{}{
+			"type":        "tool_result",
+			"tool_use_id": msg.ToolCallID,
+			"content":     content,
+		}
+		if msg.IsError {
+			block["is_error"] = true
+		}
+		return map[string]interface{}{
+			"role":    "user",
+			"content": []map[string]interface{}{block},
+		}
+
+	case msg.Role == "assistant" && len(msg.ToolCalls) > 0:
+		var blocks []map[string]interface{}
+		if text, ok := msg.Content.(string); ok && strings.TrimSpace(text) != "" {
+			blocks = append(blocks, map[string]interface{}{"type": "text", "text": text})
+		}
+		for _, call := range msg.ToolCalls {
+			blocks = append(blocks, map[string]interface{}{
+				"type":  "tool_use",
+				"id":    call.ID,
+				"name":  call.CallName(),
+				"input": call.CallInput(),
+			})
+		}
+		return map[string]interface{}{
+			"role":    "assistant",
+			"content": blocks,
+		}
+	}
+
+	return map[string]interface{}{
+		"role":    msg.Role,
+		"content": p.convertContent(msg.Content),
+	}
+}
+
+// mergeContent combines the content of consecutive same-role turns. Text is joined;
+// if either side has content blocks, both are combined into one block list.
+func mergeContent(existing, next interface{}) interface{} {
+	if a, ok := existing.(string); ok {
+		if b, ok := next.(string); ok {
+			return a + "\n\n" + b
+		}
+	}
+	return append(contentBlocks(existing), contentBlocks(next)...)
+}
+
+// contentBlocks returns content as Claude content blocks
+func contentBlocks(content interface{}) []map[string]interface{} {
+	switch v := content.(type) {
+	case []map[string]interface{}:
+		return v
+	case string:
+		if v == "" {
+			return nil
+		}
+		return []map[string]interface{}{{"type": "text", "text": v}}
+	}
+	return nil
+}
+
 // convertContent converts message content to Claude format
 func (p *Provider) convertContent(content interface{}) interface{} {
 	switch v := content.(type) {
@@ -537,7 +620,10 @@ func (p *Provider) convertContentBlock(block llm.ContentBlock) map[string]interf
 			result["input"] = block.ToolUse.Input
 		}
 	case "tool_result":
-		result["tool_use_id"] = block.Content
+		result["tool_use_id"] = block.ToolUseID
+		if block.Content != nil {
+			result["content"] = block.Content
+		}
 		if block.IsError {
 			result["is_error"] = true
 		}
@@ -566,11 +652,12 @@ func (p *Provider) parseResponse(body []byte, model string) (*llm.Response, erro
 		Type    string `json:"type"`
 		Role    string `json:"role"`
 		Content []struct {
-			Type  string                 `json:"type"`
-			Text  string                 `json:"text,omitempty"`
-			ID    string                 `json:"id,omitempty"`
-			Name  string                 `json:"name,omitempty"`
-			Input map[string]interface{} `json:"input,omitempty"`
+			Type     string                 `json:"type"`
+			Text     string                 `json:"text,omitempty"`
+			Thinking string                 `json:"thinking,omitempty"`
+			ID       string                 `json:"id,omitempty"`
+			Name     string                 `json:"name,omitempty"`
+			Input    map[string]interface{} `json:"input,omitempty"`
 		} `json:"content"`
 		Model        string `json:"model"`
 		StopReason   string `json:"stop_reason"`
@@ -594,13 +681,15 @@ func (p *Provider) parseResponse(body []byte, model string) (*llm.Response, erro
 	}
 
 	// Extract content and tool calls
-	var contentParts []string
+	var contentParts, thinkingParts []string
 	var toolCalls []llm.ToolCall
 
 	for _, content := range bedrockResp.Content {
 		switch content.Type {
 		case "text":
 			contentParts = append(contentParts, content.Text)
+		case "thinking":
+			thinkingParts = append(thinkingParts, content.Thinking)
 		case "tool_use":
 			// Ensure input is not nil
 			input := content.Input
@@ -617,6 +706,7 @@ func (p *Provider) parseResponse(body []byte, model string) (*llm.Response, erro
 	}
 
 	response.Content = strings.Join(contentParts, "\n")
+	response.Thinking = strings.Join(thinkingParts, "\n")
 	response.ToolCalls = toolCalls
 
 	return response, nil
@@ -630,6 +720,7 @@ func (p *Provider) parseStreamChunk(data []byte) (*llm.StreamChunk, error) {
 		Delta struct {
 			Type        string `json:"type"`
 			Text        string `json:"text"`
+			Thinking    string `json:"thinking"`
 			PartialJSON string `json:"partial_json"`
 		} `json:"delta"`
 		ContentBlock struct {
@@ -638,6 +729,10 @@ func (p *Provider) parseStreamChunk(data []byte) (*llm.StreamChunk, error) {
 			Name  string                 `json:"name"`
 			Input map[string]interface{} `json:"input"`
 		} `json:"content_block"`
+		Message struct {
+			Usage llm.Usage `json:"usage"`
+		} `json:"message"`
+		Usage llm.Usage `json:"usage"`
 	}
 
 	if err := json.Unmarshal(data, &chunkData); err != nil {
@@ -668,6 +763,11 @@ func (p *Provider) parseStreamChunk(data []byte) (*llm.StreamChunk, error) {
 		if chunkData.Delta.Type == "text_delta" {
 			chunk.Content = chunkData.Delta.Text
 		}
+		// Extended thinking is streamed apart from the answer
+		if chunkData.Delta.Type == "thinking_delta" {
+			chunk.Type = "thinking"
+			chunk.Content = chunkData.Delta.Thinking
+		}
 		// Handle tool input deltas - accumulate JSON input
 		if chunkData.Delta.Type == "input_json_delta" {
 			chunk.ToolInputDelta = chunkData.Delta.PartialJSON
@@ -677,7 +777,17 @@ func (p *Provider) parseStreamChunk(data []byte) (*llm.StreamChunk, error) {
 		if chunk.ToolCall != nil && chunk.ToolCall.Input == nil {
 			chunk.ToolCall.Input = make(map[string]interface{})
 		}
-	case "message_start", "message_delta", "message_stop":
+	case "message_start":
+		// Input tokens are reported when the message starts...
+		if chunkData.Message.Usage.InputTokens > 0 {
+			chunk.Usage = &llm.Usage{InputTokens: chunkData.Message.Usage.InputTokens}
+		}
+	case "message_delta":
+		// ...and output tokens when it ends
+		if chunkData.Usage.OutputTokens > 0 {
+			chunk.Usage = &llm.Usage{OutputTokens: chunkData.Usage.OutputTokens}
+		}
+	case "message_stop":
 		// Message-level events
 	}
 
@@ -375,3 +375,254 @@ func TestNewProvider_Config(t *testing.T) {
 		t.Errorf("Expected region 'us-west-2', got %s", authCfg.Region)
 	}
 }
+
+func TestProvider_ConvertRequest_MidConversationSystem(t *testing.T) {
+	provider := createMockProvider()
+
+	req := &llm.GenerateRequest{
+		Messages: []llm.Message{
+			{Role: "system", Content: "You are a helpful assistant"},
+			{Role: "system", Content: "Project memory"},
+			{Role: "user", Content: "List the files"},
+			{Role: "assistant", Content: "Running list_files"},
+			{Role: "system", Content: "Tool results from 'list_files':\nmain.go"},
+		},
+		MaxTokens: 100,
+	}
+
+	bedrockReq, err := provider.convertRequest(req, ModelClaudeSonnet)
+	if err != nil {
+		t.Fatalf("convertRequest failed: %v", err)
+	}
+
+	var requestData struct {
+		System   string `json:"system"`
+		Messages []struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal(bedrockReq, &requestData); err != nil {
+		t.Fatalf("Converted request is not valid JSON: %v", err)
+	}
+
+	if requestData.System != "You are a helpful assistant\n\nProject memory" {
+		t.Errorf("Expected leading system messages to be joined, got %q", requestData.System)
+	}
+
+	if strings.Contains(requestData.System, "main.go") {
+		t.Error("Tool result should not be merged into the system prompt")
+	}
+
+	if len(requestData.Messages) != 3 {
+		t.Fatalf("Expected 3 messages, got %d", len(requestData.Messages))
+	}
+
+	last := requestData.Messages[2]
+	if last.Role != "user" || !strings.Contains(last.Content, "main.go") {
+		t.Errorf("Expected tool result as trailing user turn, got %s: %q", last.Role, last.Content)
+	}
+}
+
+func TestProvider_ConvertRequest_ToolResultRoundTrip(t *testing.T) {
+	provider := createMockProvider()
+
+	call := llm.ToolCall{ID: "toolu_1", Name: "read_file", Input: map[string]interface{}{"file_path": "main.go"}}
+	req := &llm.GenerateRequest{
+		Messages: []llm.Message{
+			{Role: "system", Content: "You are a helpful assistant"},
+			{Role: "user", Content: "Read main.go"},
+			{Role: "assistant", Content: "Reading it", ToolCalls: []llm.ToolCall{call}},
+			llm.NewToolResultMessage(&call, "package main", false),
+			{Role: "user", Content: "Now summarize it"},
+		},
+		MaxTokens: 100,
+	}
+
+	bedrockReq, err := provider.convertRequest(req, ModelClaudeSonnet)
+	if err != nil {
+		t.Fatalf("convertRequest failed: %v", err)
+	}
+
+	var requestData struct {
+		Messages []struct {
+			Role    string          `json:"role"`
+			Content json.RawMessage `json:"content"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal(bedrockReq, &requestData); err != nil {
+		t.Fatalf("Converted request is not valid JSON: %v", err)
+	}
+
+	if len(requestData.Messages) != 3 {
+		t.Fatalf("Expected user, assistant and user turns, got %d", len(requestData.Messages))
+	}
+
+	var assistant []map[string]interface{}
+	if err := json.Unmarshal(requestData.Messages[1].Content, &assistant); err != nil {
+		t.Fatalf("Expected assistant content blocks: %v", err)
+	}
+	if len(assistant) != 2 || assistant[1]["type"] != "tool_use" || assistant[1]["id"] != "toolu_1" || assistant[1]["name"] != "read_file" {
+		t.Errorf("Unexpected assistant blocks: %+v", assistant)
+	}
+
+	// The tool result and the next user message share one user turn, result first
+	var user []map[string]interface{}
+	if err := json.Unmarshal(requestData.Messages[2].Content, &user); err != nil {
+		t.Fatalf("Expected user content blocks: %v", err)
+	}
+	if requestData.Messages[2].Role != "user" || len(user) != 2 {
+		t.Fatalf("Expected a merged user turn with 2 blocks, got %s with %d", requestData.Messages[2].Role, len(user))
+	}
+	if user[0]["type"] != "tool_result" || user[0]["tool_use_id"] != "toolu_1" || user[0]["content"] != "package main" {
+		t.Errorf("Unexpected tool result block: %+v", user[0])
+	}
+	if user[1]["type"] != "text" || user[1]["text"] != "Now summarize it" {
+		t.Errorf("Expected the follow-up text after the tool result, got %+v", user[1])
+	}
+}
+
+// convertedMessages converts req and decodes the messages of the Bedrock request
+func convertedMessages(t *testing.T, req *llm.GenerateRequest) []struct {
+	Role    string          `json:"role"`
+	Content json.RawMessage `json:"content"`
+} {
+	t.Helper()
+
+	bedrockReq, err := createMockProvider().convertRequest(req, ModelClaudeSonnet)
+	if err != nil {
+		t.Fatalf("convertRequest failed: %v", err)
+	}
+
+	var requestData struct {
+		Messages []struct {
+			Role    string          `json:"role"`
+			Content json.RawMessage `json:"content"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal(bedrockReq, &requestData); err != nil {
+		t.Fatalf("Converted request is not valid JSON: %v", err)
+	}
+	return requestData.Messages
+}
+
+func TestProvider_ConvertRequest_ParallelToolResults(t *testing.T) {
+	first := llm.ToolCall{ID: "toolu_1", Name: "read_file", Input: map[string]interface{}{"file_path": "a.go"}}
+	second := llm.ToolCall{ID: "toolu_2", Name: "read_file", Input: map[string]interface{}{"file_path": "b.go"}}
+	messages := convertedMessages(t, &llm.GenerateRequest{
+		Messages: []llm.Message{
+			{Role: "user", Content: "Compare a.go and b.go"},
+			{Role: "assistant", Content: "", ToolCalls: []llm.ToolCall{first, second}},
+			llm.NewToolResultMessage(&first, "package a", false),
+			llm.NewToolResultMessage(&second, "open b.go: no such file", true),
+			{Role: "user", Content: "What did you find?"},
+			{Role: "assistant", Content: "Only a.go exists"},
+		},
+		MaxTokens: 100,
+	})
+
+	roles := make([]string, len(messages))
+	for i, msg := range messages {
+		roles[i] = msg.Role
+	}
+	if strings.Join(roles, ",") != "user,assistant,user,assistant" {
+		t.Fatalf("Expected strictly alternating turns, got %v", roles)
+	}
+
+	var user []map[string]interface{}
+	if err := json.Unmarshal(messages[2].Content, &user); err != nil {
+		t.Fatalf("Expected user content blocks: %v", err)
+	}
+	if len(user) != 3 {
+		t.Fatalf("Expected two tool results and the follow-up text, got %+v", user)
+	}
+	if user[0]["type"] != "tool_result" || user[0]["tool_use_id"] != "toolu_1" || user[0]["is_error"] != nil {
+		t.Errorf("Unexpected first tool result: %+v", user[0])
+	}
+	if user[1]["type"] != "tool_result" || user[1]["tool_use_id"] != "toolu_2" || user[1]["is_error"] != true {
+		t.Errorf("Unexpected second tool result: %+v", user[1])
+	}
+	if user[2]["type"] != "text" || user[2]["text"] != "What did you find?" {
+		t.Errorf("Expected the follow-up text last, got %+v", user[2])
+	}
+}
+
+func TestProvider_ConvertRequest_LeadingAssistantTurns(t *testing.T) {
+	call := llm.ToolCall{ID: "toolu_1", Name: "bash", Input: map[string]interface{}{"command": "go test ./..."}}
+
+	// History trimmed mid-turn can start with a tool call and its result
+	messages := convertedMessages(t, &llm.GenerateRequest{
+		Messages: []llm.Message{
+			{Role: "system", Content: "You are a helpful assistant"},
+			{Role: "assistant", Content: "Running the tests", ToolCalls: []llm.ToolCall{call}},
+			llm.NewToolResultMessage(&call, "ok  \tpkg\t0.1s", false),
+			{Role: "assistant", Content: "All tests pass"},
+			{Role: "user", Content: "Great, commit it"},
+		},
+		MaxTokens: 100,
+	})
+
+	if len(messages) != 3 || messages[0].Role != "user" || messages[1].Role != "assistant" || messages[2].Role != "user" {
+		t.Fatalf("Expected user, assistant and user turns, got %d messages", len(messages))
+	}
+
+	// A result whose tool call was dropped must not be sent as a tool_result block
+	var first string
+	if err := json.Unmarshal(messages[0].Content, &first); err != nil {
+		t.Fatalf("Expected the first user turn to be plain text, got %s", messages[0].Content)
+	}
+	if !strings.Contains(first, "ok  \tpkg") {
+		t.Errorf("Expected the orphaned result to be kept as text, got %q", first)
+	}
+	if strings.Contains(string(messages[1].Content), "tool_use") {
+		t.Errorf("Expected the dropped tool call not to be sent: %s", messages[1].Content)
+	}
+}
+
+func TestProvider_ParseStreamChunk_Usage(t *testing.T) {
+	provider := createMockProvider()
+
+	start, err := provider.parseStreamChunk([]byte(`{"type":"message_start","message":{"usage":{"input_tokens":1234,"output_tokens":1}}}`))
+	if err != nil {
+		t.Fatalf("parseStreamChunk failed: %v", err)
+	}
+	if start.Usage == nil || start.Usage.InputTokens != 1234 || start.Usage.OutputTokens != 0 {
+		t.Errorf("Expected input tokens from message_start, got %+v", start.Usage)
+	}
+
+	delta, err := provider.parseStreamChunk([]byte(`{"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":56}}`))
+	if err != nil {
+		t.Fatalf("parseStreamChunk failed: %v", err)
+	}
+	if delta.Usage == nil || delta.Usage.OutputTokens != 56 || delta.Usage.InputTokens != 0 {
+		t.Errorf("Expected output tokens from message_delta, got %+v", delta.Usage)
+	}
+
+	text, err := provider.parseStreamChunk([]byte(`{"type":"content_block_delta","delta":{"type":"text_delta","text":"hi"}}`))
+	if err != nil {
+		t.Fatalf("parseStreamChunk failed: %v", err)
+	}
+	if text.Usage != nil {
+		t.Errorf("Expected no usage on a text delta, got %+v", text.Usage)
+	}
+}
+
+func TestProvider_ParseStreamChunk_Thinking(t *testing.T) {
+	provider := createMockProvider()
+
+	chunk, err := provider.parseStreamChunk([]byte(`{"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"Check the caller first."}}`))
+	if err != nil {
+		t.Fatalf("parseStreamChunk failed: %v", err)
+	}
+	if chunk.Type != "thinking" || chunk.Content != "Check the caller first." {
+		t.Errorf("Expected a thinking chunk, got type %q content %q", chunk.Type, chunk.Content)
+	}
+
+	signature, err := provider.parseStreamChunk([]byte(`{"type":"content_block_delta","index":0,"delta":{"type":"signature_delta","signature":"abc"}}`))
+	if err != nil {
+		t.Fatalf("parseStreamChunk failed: %v", err)
+	}
+	if signature.Content != "" {
+		t.Errorf("Expected no content from a signature delta, got %q", signature.Content)
+	}
+}
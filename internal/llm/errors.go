@@ -0,0 +1,235 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrorKind classifies why a provider request failed
+type ErrorKind string
+
+const (
+	ErrorAuth           ErrorKind = "auth"            // Missing, invalid or expired credentials
+	ErrorRateLimit      ErrorKind = "rate_limit"      // Throttled or out of quota
+	ErrorContextLength  ErrorKind = "context_length"  // The request does not fit the model's context window
+	ErrorServer         ErrorKind = "server"          // The provider failed or is overloaded
+	ErrorNetwork        ErrorKind = "network"         // The provider could not be reached
+	ErrorInvalidRequest ErrorKind = "invalid_request" // The provider rejected the request, e.g. an unknown model
+	ErrorUnknown        ErrorKind = "unknown"
+)
+
+// Retryable reports whether a request that failed this way may succeed if sent again
+func (k ErrorKind) Retryable() bool {
+	switch k {
+	case ErrorRateLimit, ErrorServer, ErrorNetwork:
+		return true
+	}
+	return false
+}
+
+// ProviderError is a failed provider request with its cause classified. Its message is
+// the wrapped error's, so classifying an error does not change how it reads.
+type ProviderError struct {
+	Provider   string
+	Kind       ErrorKind
+	StatusCode int           // HTTP status, if the provider responded
+	Message    string        // The provider's own error message, or the error text
+	RetryAfter time.Duration // From the Retry-After header, if the server sent one
+	Err        error
+}
+
+func (e *ProviderError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ProviderError) Unwrap() error {
+	return e.Err
+}
+
+// Guidance returns what the user can do about the error, or "" when there is nothing
+// specific to suggest
+func (e *ProviderError) Guidance() string {
+	switch e.Kind {
+	case ErrorAuth:
+		return fmt.Sprintf("The %s API key or credentials were rejected. Check them in your config or environment; `bazinga doctor` shows what is missing.", e.Provider)
+	case ErrorRateLimit:
+		return "The provider is rate limiting requests. Wait a moment and /retry, or lower llm.rate_limit in your config."
+	case ErrorContextLength:
+		return "The conversation is too long for the model's context window. Run /compact to summarize it, then /retry."
+	case ErrorServer:
+		return "The provider is having problems. /retry in a moment."
+	case ErrorNetwork:
+		return fmt.Sprintf("Could not reach %s. Check your network connection and the provider's base URL.", e.Provider)
+	case ErrorInvalidRequest:
+		return "The provider rejected the request. Check the model name with /model and your provider settings."
+	}
+	return ""
+}
+
+// contextLengthPhrases appear in the errors providers return for requests that do not
+// fit the context window, usually with status 400
+var contextLengthPhrases = []string{
+	"prompt is too long",                   // Anthropic, Bedrock
+	"input is too long",                    // Bedrock
+	"context_length_exceeded",              // OpenAI
+	"maximum context length",               // OpenAI
+	"exceeds the maximum number of tokens", // Gemini
+	"context window",
+	"too many tokens",
+}
+
+// authPhrases appear in credential errors that are not sent with 401 or 403, such as
+// Gemini's 400 for an invalid key
+var authPhrases = []string{
+	"api key not valid",
+	"api_key_invalid",
+	"invalid api key",
+	"invalid_api_key",
+	"invalid x-api-key",
+	"incorrect api key",
+	"authentication_error",
+	"unauthenticated",
+	"security token included in the request is invalid",
+	"expiredtoken",
+}
+
+// rateLimitPhrases appear in throttling errors that are not sent with 429
+var rateLimitPhrases = []string{
+	"rate_limit",
+	"rate limit",
+	"resource_exhausted",
+	"throttl",
+	"insufficient_quota",
+}
+
+// ClassifyError wraps err from the named provider in a *ProviderError. Errors that are
+// already classified, and cancellations, are returned unchanged.
+func ClassifyError(provider string, err error) error {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return err
+	}
+
+	var providerErr *ProviderError
+	if errors.As(err, &providerErr) {
+		return err
+	}
+
+	classified := &ProviderError{
+		Provider: provider,
+		Message:  err.Error(),
+		Err:      err,
+	}
+	classified.Kind, classified.StatusCode = classify(err)
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		classified.RetryAfter = statusErr.RetryAfter
+		if message := errorBodyMessage(statusErr.Body); message != "" {
+			classified.Message = message
+		}
+	}
+	return classified
+}
+
+// ErrorKindOf returns the kind of a provider error, classifying it if needed
+func ErrorKindOf(err error) ErrorKind {
+	if err == nil {
+		return ErrorUnknown
+	}
+
+	var providerErr *ProviderError
+	if errors.As(err, &providerErr) {
+		return providerErr.Kind
+	}
+	kind, _ := classify(err)
+	return kind
+}
+
+// classify returns the kind of err and the HTTP status it carries, if any
+func classify(err error) (ErrorKind, int) {
+	text := strings.ToLower(err.Error())
+
+	var status interface{ HTTPStatusCode() int }
+	if errors.As(err, &status) {
+		code := status.HTTPStatusCode()
+		switch {
+		case code == http.StatusUnauthorized || code == http.StatusForbidden:
+			return ErrorAuth, code
+		case code == http.StatusTooManyRequests:
+			return ErrorRateLimit, code
+		case code == http.StatusRequestEntityTooLarge:
+			return ErrorContextLength, code
+		case code >= 500:
+			return ErrorServer, code
+		case code >= 400:
+			switch {
+			case containsAny(text, contextLengthPhrases):
+				return ErrorContextLength, code
+			case containsAny(text, authPhrases):
+				return ErrorAuth, code
+			case containsAny(text, rateLimitPhrases):
+				return ErrorRateLimit, code
+			}
+			return ErrorInvalidRequest, code
+		}
+	}
+
+	var netErr net.Error
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, context.DeadlineExceeded) ||
+		errors.As(err, &netErr) {
+		return ErrorNetwork, 0
+	}
+
+	switch {
+	case containsAny(text, contextLengthPhrases):
+		return ErrorContextLength, 0
+	case containsAny(text, authPhrases):
+		return ErrorAuth, 0
+	case containsAny(text, rateLimitPhrases):
+		return ErrorRateLimit, 0
+	}
+	return ErrorUnknown, 0
+}
+
+// errorBodyMessage returns the message of a JSON error body, in any of the shapes
+// providers use: {"error": {"message": ...}}, {"error": "..."} or {"message": ...}
+func errorBodyMessage(body string) string {
+	var payload struct {
+		Error   json.RawMessage `json:"error"`
+		Message string          `json:"message"`
+	}
+	if err := json.Unmarshal([]byte(body), &payload); err != nil {
+		return ""
+	}
+
+	var nested struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(payload.Error, &nested) == nil && nested.Message != "" {
+		return nested.Message
+	}
+	var message string
+	if json.Unmarshal(payload.Error, &message) == nil && message != "" {
+		return message
+	}
+	return payload.Message
+}
+
+// containsAny reports whether text contains any of the phrases
+func containsAny(text string, phrases []string) bool {
+	for _, phrase := range phrases {
+		if strings.Contains(text, phrase) {
+			return true
+		}
+	}
+	return false
+}
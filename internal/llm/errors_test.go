@@ -0,0 +1,136 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"syscall"
+	"testing"
+)
+
+// awsError mimics an AWS SDK response error, which exposes its status code
+type awsError struct {
+	status  int
+	message string
+}
+
+func (e *awsError) Error() string       { return e.message }
+func (e *awsError) HTTPStatusCode() int { return e.status }
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		kind    ErrorKind
+		message string
+	}{
+		{
+			"anthropic invalid key",
+			&StatusError{StatusCode: 401, Body: `{"type":"error","error":{"type":"authentication_error","message":"invalid x-api-key"}}`},
+			ErrorAuth, "invalid x-api-key",
+		},
+		{
+			"anthropic prompt too long",
+			&StatusError{StatusCode: 400, Body: `{"type":"error","error":{"type":"invalid_request_error","message":"prompt is too long: 210000 tokens > 200000 maximum"}}`},
+			ErrorContextLength, "prompt is too long: 210000 tokens > 200000 maximum",
+		},
+		{
+			"anthropic overloaded",
+			&StatusError{StatusCode: 529, Body: `{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`},
+			ErrorServer, "Overloaded",
+		},
+		{
+			"openai context length",
+			&StatusError{StatusCode: 400, Body: `{"error":{"message":"This model's maximum context length is 128000 tokens.","type":"invalid_request_error","code":"context_length_exceeded"}}`},
+			ErrorContextLength, "This model's maximum context length is 128000 tokens.",
+		},
+		{
+			"openai quota",
+			&StatusError{StatusCode: 429, Body: `{"error":{"message":"You exceeded your current quota","code":"insufficient_quota"}}`},
+			ErrorRateLimit, "You exceeded your current quota",
+		},
+		{
+			"gemini invalid key",
+			&StatusError{StatusCode: 400, Body: `{"error":{"code":400,"message":"API key not valid. Please pass a valid API key.","status":"INVALID_ARGUMENT"}}`},
+			ErrorAuth, "API key not valid. Please pass a valid API key.",
+		},
+		{
+			"gemini unknown model",
+			&StatusError{StatusCode: 404, Body: `{"error":{"code":404,"message":"models/gemini-9 is not found","status":"NOT_FOUND"}}`},
+			ErrorInvalidRequest, "models/gemini-9 is not found",
+		},
+		{
+			"ollama missing model",
+			&StatusError{StatusCode: 404, Body: `{"error":"model \"qwen9\" not found, try pulling it first"}`},
+			ErrorInvalidRequest, `model "qwen9" not found, try pulling it first`,
+		},
+		{
+			"bedrock input too long",
+			fmt.Errorf("bedrock invoke model failed: %w", &awsError{400, "ValidationException: Input is too long for requested model."}),
+			ErrorContextLength, "",
+		},
+		{
+			"bedrock access denied",
+			fmt.Errorf("bedrock invoke model failed: %w", &awsError{403, "AccessDeniedException: not authorized"}),
+			ErrorAuth, "",
+		},
+		{
+			"bedrock throttled without status",
+			errors.New("ThrottlingException: Too many requests, please wait before trying again."),
+			ErrorRateLimit, "",
+		},
+		{
+			"connection reset",
+			fmt.Errorf("failed to send request: %w", syscall.ECONNRESET),
+			ErrorNetwork, "",
+		},
+		{
+			"unrecognised",
+			errors.New("something odd"),
+			ErrorUnknown, "something odd",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ClassifyError("test", tt.err)
+
+			var providerErr *ProviderError
+			if !errors.As(err, &providerErr) {
+				t.Fatalf("Expected a *ProviderError, got %T", err)
+			}
+			if providerErr.Kind != tt.kind {
+				t.Errorf("Kind = %s, want %s", providerErr.Kind, tt.kind)
+			}
+			if tt.message != "" && providerErr.Message != tt.message {
+				t.Errorf("Message = %q, want %q", providerErr.Message, tt.message)
+			}
+			if err.Error() != tt.err.Error() {
+				t.Errorf("Expected the error text to be unchanged, got %q", err.Error())
+			}
+			if !errors.Is(err, tt.err) {
+				t.Error("Expected the original error to be wrapped")
+			}
+		})
+	}
+}
+
+func TestClassifyError_Passthrough(t *testing.T) {
+	if ClassifyError("test", nil) != nil {
+		t.Error("Expected nil for nil")
+	}
+	if err := ClassifyError("test", context.Canceled); err != context.Canceled {
+		t.Errorf("Expected cancellation to be returned as is, got %v", err)
+	}
+
+	classified := ClassifyError("anthropic", &StatusError{StatusCode: http.StatusUnauthorized})
+	if again := ClassifyError("openai", fmt.Errorf("wrapped: %w", classified)); ErrorKindOf(again) != ErrorAuth {
+		t.Errorf("Expected the first classification to be kept, got %s", ErrorKindOf(again))
+	}
+
+	var providerErr *ProviderError
+	if !errors.As(classified, &providerErr) || providerErr.Guidance() == "" {
+		t.Error("Expected guidance for an auth error")
+	}
+}
@@ -0,0 +1,561 @@
+package gemini
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/tildaslashalef/bazinga/internal/llm"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	defaultBaseURL = "https://generativelanguage.googleapis.com/v1beta"
+	defaultModel   = "gemini-1.5-pro"
+)
+
+// Provider implements the LLM provider interface for Google Gemini
+type Provider struct {
+	apiKey       string
+	baseURL      string
+	defaultModel string
+	httpClient   *http.Client
+	retry        llm.RetryConfig
+}
+
+// Config represents Gemini-specific configuration
+type Config struct {
+	APIKey  string          `yaml:"api_key"`
+	BaseURL string          `yaml:"base_url"`
+	Model   string          `yaml:"model"` // Default model to use
+	Retry   llm.RetryConfig `yaml:"-"`     // Zero value uses llm.DefaultRetryConfig
+}
+
+// NewProvider creates a new Gemini provider
+func NewProvider(apiKey string) *Provider {
+	return NewProviderWithConfig(&Config{
+		APIKey:  apiKey,
+		BaseURL: defaultBaseURL,
+	})
+}
+
+// NewProviderWithConfig creates a new Gemini provider with full configuration
+func NewProviderWithConfig(cfg *Config) *Provider {
+	if cfg.APIKey == "" {
+		cfg.APIKey = "dummy-key" // For testing without API key
+	}
+
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = defaultBaseURL
+	}
+
+	if cfg.Model == "" {
+		cfg.Model = defaultModel
+	}
+
+	return &Provider{
+		apiKey:       cfg.APIKey,
+		baseURL:      strings.TrimRight(cfg.BaseURL, "/"),
+		defaultModel: cfg.Model,
+		httpClient: &http.Client{
+			Timeout: 2 * time.Minute,
+		},
+		retry: cfg.Retry,
+	}
+}
+
+// Name returns the provider name
+func (p *Provider) Name() string {
+	return "gemini"
+}
+
+// GenerateResponse generates a response using Gemini's generateContent endpoint
+func (p *Provider) GenerateResponse(ctx context.Context, req *llm.GenerateRequest) (*llm.Response, error) {
+	req = llm.LimitOutputTokens(req, p.GetAvailableModels(), p.GetDefaultModel())
+
+	geminiReq := convertToGeminiRequest(req)
+
+	reqBody, err := json.Marshal(geminiReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	model := p.model(req)
+	startTime := time.Now()
+	resp, err := llm.Retry(ctx, p.retry, "gemini generate", func(ctx context.Context) (*http.Response, error) {
+		return p.post(ctx, model+":generateContent", reqBody)
+	})
+	if err != nil {
+		return nil, llm.ClassifyError(p.Name(), err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var geminiResp geminiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&geminiResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	response := convertFromGeminiResponse(&geminiResp, model)
+	response.ProcessingTimeMs = time.Since(startTime).Milliseconds()
+	return response, nil
+}
+
+// post sends a request body to a model method such as "gemini-1.5-pro:generateContent".
+// A response other than 200 is returned as an *llm.StatusError with its body closed.
+func (p *Provider) post(ctx context.Context, method string, reqBody []byte) (*http.Response, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/models/"+method, bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("x-goog-api-key", p.apiKey)
+
+	resp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		return nil, llm.NewStatusError(resp, body)
+	}
+	return resp, nil
+}
+
+// StreamResponse streams a response using Gemini's streamGenerateContent endpoint,
+// which sends one generateContent response per server-sent event
+func (p *Provider) StreamResponse(ctx context.Context, req *llm.GenerateRequest) (<-chan *llm.StreamChunk, error) {
+	req = llm.LimitOutputTokens(req, p.GetAvailableModels(), p.GetDefaultModel())
+
+	geminiReq := convertToGeminiRequest(req)
+
+	reqBody, err := json.Marshal(geminiReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	// Only establishing the stream is retried; a stream that drops is resumed by the session
+	model := p.model(req)
+	resp, err := llm.Retry(ctx, p.retry, "gemini stream", func(ctx context.Context) (*http.Response, error) {
+		return p.post(ctx, model+":streamGenerateContent?alt=sse", reqBody)
+	})
+	if err != nil {
+		return nil, llm.ClassifyError(p.Name(), err)
+	}
+
+	streamChan := make(chan *llm.StreamChunk, 10)
+
+	go func() {
+		defer close(streamChan)
+		defer func() { _ = resp.Body.Close() }()
+
+		send := func(chunk *llm.StreamChunk) bool {
+			select {
+			case streamChan <- chunk:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		id := fmt.Sprintf("gemini-stream-%d", time.Now().UnixNano())
+		calls := 0
+		var usage geminiUsage
+
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+		for scanner.Scan() {
+			data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+			if !ok {
+				continue
+			}
+
+			var streamResp geminiResponse
+			if err := json.Unmarshal([]byte(data), &streamResp); err != nil {
+				continue // Skip malformed events
+			}
+			// Each event carries the running totals, so the last one is final
+			if streamResp.UsageMetadata.PromptTokenCount > 0 || streamResp.UsageMetadata.CandidatesTokenCount > 0 {
+				usage = streamResp.UsageMetadata
+			}
+			if len(streamResp.Candidates) == 0 {
+				continue
+			}
+
+			for _, part := range streamResp.Candidates[0].Content.Parts {
+				chunk := &llm.StreamChunk{ID: id}
+				switch {
+				case part.FunctionCall != nil:
+					call := convertFunctionCall(part.FunctionCall, calls)
+					calls++
+					chunk.Type = "content_block_start"
+					chunk.ToolCall = &call
+				case part.Text != "" && part.Thought:
+					chunk.Type = "thinking"
+					chunk.Content = part.Text
+				case part.Text != "":
+					chunk.Type = "content_block_delta"
+					chunk.Content = part.Text
+				default:
+					continue
+				}
+				if !send(chunk) {
+					return
+				}
+			}
+		}
+
+		if err := scanner.Err(); err != nil {
+			send(&llm.StreamChunk{ID: id, Type: "error", Content: fmt.Sprintf("Streaming error: %v", err)})
+			return
+		}
+
+		stop := &llm.StreamChunk{ID: id, Type: "content_block_stop"}
+		if usage.PromptTokenCount > 0 || usage.CandidatesTokenCount > 0 {
+			stop.Usage = &llm.Usage{InputTokens: usage.PromptTokenCount, OutputTokens: usage.CandidatesTokenCount}
+		}
+		send(stop)
+	}()
+
+	return streamChan, nil
+}
+
+// model returns the model a request is sent to
+func (p *Provider) model(req *llm.GenerateRequest) string {
+	if req.Model != "" {
+		return req.Model
+	}
+	return p.defaultModel
+}
+
+// SupportsFunctionCalling returns whether this provider supports function calling
+func (p *Provider) SupportsFunctionCalling() bool {
+	return true
+}
+
+// GetAvailableModels returns the available models for this provider
+func (p *Provider) GetAvailableModels() []llm.Model {
+	return []llm.Model{
+		{ID: "gemini-1.5-pro", Name: "Gemini 1.5 Pro", Provider: "gemini", MaxTokens: 2097152, MaxOutputTokens: 8192, SupportsTools: true},
+		{ID: "gemini-1.5-flash", Name: "Gemini 1.5 Flash", Provider: "gemini", MaxTokens: 1048576, MaxOutputTokens: 8192, SupportsTools: true},
+	}
+}
+
+// GetDefaultModel returns the default model
+func (p *Provider) GetDefaultModel() string {
+	return p.defaultModel
+}
+
+// EstimateTokens provides a rough token estimate
+func (p *Provider) EstimateTokens(text string) int {
+	// Rough estimation: ~4 characters per token for English text
+	return len(text) / 4
+}
+
+// GetTokenLimit returns the token limit for the current model
+func (p *Provider) GetTokenLimit() int {
+	// Gemini 1.5 Flash has a 1M token context window, Pro has 2M
+	return 1000000
+}
+
+// Close cleans up resources
+func (p *Provider) Close() error {
+	// Nothing to clean up for HTTP client
+	return nil
+}
+
+// Gemini request/response types
+type geminiRequest struct {
+	Contents          []geminiContent         `json:"contents"`
+	SystemInstruction *geminiContent          `json:"systemInstruction,omitempty"`
+	Tools             []geminiTool            `json:"tools,omitempty"`
+	GenerationConfig  *geminiGenerationConfig `json:"generationConfig,omitempty"`
+}
+
+type geminiContent struct {
+	Role  string       `json:"role,omitempty"` // "user" or "model"; unset for the system instruction
+	Parts []geminiPart `json:"parts"`
+}
+
+// geminiPart holds exactly one of its fields
+type geminiPart struct {
+	Text             string                  `json:"text,omitempty"`
+	Thought          bool                    `json:"thought,omitempty"` // Response parts with the model's reasoning
+	InlineData       *geminiInlineData       `json:"inlineData,omitempty"`
+	FunctionCall     *geminiFunctionCall     `json:"functionCall,omitempty"`
+	FunctionResponse *geminiFunctionResponse `json:"functionResponse,omitempty"`
+}
+
+type geminiInlineData struct {
+	MimeType string `json:"mimeType"`
+	Data     string `json:"data"` // base64 encoded
+}
+
+type geminiFunctionCall struct {
+	ID   string                 `json:"id,omitempty"`
+	Name string                 `json:"name"`
+	Args map[string]interface{} `json:"args"`
+}
+
+type geminiFunctionResponse struct {
+	ID       string                 `json:"id,omitempty"`
+	Name     string                 `json:"name"`
+	Response map[string]interface{} `json:"response"` // {"content": ...} or {"error": ...}
+}
+
+type geminiTool struct {
+	FunctionDeclarations []geminiFunctionDeclaration `json:"functionDeclarations"`
+}
+
+type geminiFunctionDeclaration struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Parameters  map[string]interface{} `json:"parameters,omitempty"`
+}
+
+type geminiGenerationConfig struct {
+	Temperature     float64 `json:"temperature,omitempty"`
+	MaxOutputTokens int     `json:"maxOutputTokens,omitempty"`
+}
+
+type geminiResponse struct {
+	Candidates    []geminiCandidate `json:"candidates"`
+	UsageMetadata geminiUsage       `json:"usageMetadata"`
+	ModelVersion  string            `json:"modelVersion"`
+	ResponseID    string            `json:"responseId"`
+}
+
+type geminiCandidate struct {
+	Content      geminiContent `json:"content"`
+	FinishReason string        `json:"finishReason"`
+}
+
+type geminiUsage struct {
+	PromptTokenCount     int `json:"promptTokenCount"`
+	CandidatesTokenCount int `json:"candidatesTokenCount"`
+}
+
+// schemaKeys are the JSON Schema keywords Gemini's OpenAPI-based schema accepts; others,
+// such as additionalProperties in custom tool parameters, make the request fail
+var schemaKeys = map[string]bool{
+	"type": true, "description": true, "properties": true, "items": true,
+	"required": true, "enum": true, "format": true, "nullable": true,
+}
+
+// Conversion functions
+func convertToGeminiRequest(req *llm.GenerateRequest) *geminiRequest {
+	geminiReq := &geminiRequest{}
+
+	// Gemini takes the system prompt as a separate instruction; mid-conversation system
+	// messages become user turns
+	systemMessage, conversation := llm.SplitSystemPrompt(req.Messages)
+	if systemMessage != "" {
+		geminiReq.SystemInstruction = &geminiContent{Parts: []geminiPart{{Text: systemMessage}}}
+	}
+
+	for _, msg := range llm.PairToolResults(conversation) {
+		geminiReq.Contents = appendGeminiContent(geminiReq.Contents, msg)
+	}
+
+	if req.Temperature > 0 || req.MaxTokens > 0 {
+		geminiReq.GenerationConfig = &geminiGenerationConfig{
+			Temperature:     req.Temperature,
+			MaxOutputTokens: req.MaxTokens,
+		}
+	}
+
+	if len(req.Tools) > 0 {
+		declarations := make([]geminiFunctionDeclaration, len(req.Tools))
+		for i, tool := range req.Tools {
+			declarations[i] = geminiFunctionDeclaration{
+				Name:        tool.Name,
+				Description: tool.Description,
+				Parameters:  convertSchema(tool.InputSchema),
+			}
+		}
+		geminiReq.Tools = []geminiTool{{FunctionDeclarations: declarations}}
+	}
+
+	return geminiReq
+}
+
+// appendGeminiContent converts a message and appends it. Gemini names the assistant role
+// "model" and has no tool role: tool results become functionResponse parts of a user turn,
+// grouped with the other results for the same model turn.
+func appendGeminiContent(contents []geminiContent, msg llm.Message) []geminiContent {
+	switch msg.Role {
+	case "tool":
+		key := "content"
+		if msg.IsError {
+			key = "error"
+		}
+		parts := []geminiPart{{FunctionResponse: &geminiFunctionResponse{
+			ID:       geminiCallID(msg.ToolCallID),
+			Name:     msg.Name,
+			Response: map[string]interface{}{key: llm.ContentText(msg.Content)},
+		}}}
+		parts = append(parts, imageParts(msg.Content)...)
+
+		if n := len(contents); n > 0 && isFunctionResponseTurn(contents[n-1]) {
+			contents[n-1].Parts = append(contents[n-1].Parts, parts...)
+			return contents
+		}
+		return append(contents, geminiContent{Role: "user", Parts: parts})
+
+	case "assistant":
+		var parts []geminiPart
+		if text := llm.ContentText(msg.Content); strings.TrimSpace(text) != "" {
+			parts = append(parts, geminiPart{Text: text})
+		}
+		for _, call := range msg.ToolCalls {
+			parts = append(parts, geminiPart{FunctionCall: &geminiFunctionCall{
+				ID:   geminiCallID(call.ID),
+				Name: call.CallName(),
+				Args: call.CallInput(),
+			}})
+		}
+		if len(parts) == 0 {
+			return contents
+		}
+		return append(contents, geminiContent{Role: "model", Parts: parts})
+	}
+
+	var parts []geminiPart
+	if text := llm.ContentText(msg.Content); text != "" {
+		parts = append(parts, geminiPart{Text: text})
+	}
+	parts = append(parts, imageParts(msg.Content)...)
+	if len(parts) == 0 {
+		return contents
+	}
+	return append(contents, geminiContent{Role: "user", Parts: parts})
+}
+
+// imageParts converts the images of structured content to inline data parts
+func imageParts(content interface{}) []geminiPart {
+	var parts []geminiPart
+	for _, image := range llm.ContentImages(content) {
+		parts = append(parts, geminiPart{InlineData: &geminiInlineData{MimeType: image.MediaType, Data: image.Data}})
+	}
+	return parts
+}
+
+// isFunctionResponseTurn reports whether a user turn carries tool results
+func isFunctionResponseTurn(content geminiContent) bool {
+	return content.Role == "user" && len(content.Parts) > 0 && content.Parts[0].FunctionResponse != nil
+}
+
+// geminiCallID returns the call ID to send back to Gemini. IDs generated for calls that
+// came without one are left out, since Gemini matches those results by name and order.
+func geminiCallID(id string) string {
+	if strings.HasPrefix(id, generatedCallIDPrefix) {
+		return ""
+	}
+	return id
+}
+
+// generatedCallIDPrefix marks call IDs made up for function calls Gemini sent without one
+const generatedCallIDPrefix = "gemini-call-"
+
+// convertSchema copies a tool's JSON schema, keeping only the keywords Gemini accepts.
+// Objects without properties are sent without parameters, which Gemini requires.
+func convertSchema(schema map[string]interface{}) map[string]interface{} {
+	if props, ok := schema["properties"].(map[string]interface{}); schema["type"] == "object" && (!ok || len(props) == 0) {
+		return nil
+	}
+	return filterSchema(schema)
+}
+
+// filterSchema drops unsupported keywords from a schema and its nested schemas
+func filterSchema(schema map[string]interface{}) map[string]interface{} {
+	filtered := make(map[string]interface{}, len(schema))
+	for key, value := range schema {
+		if !schemaKeys[key] {
+			continue
+		}
+		switch key {
+		case "properties":
+			if props, ok := value.(map[string]interface{}); ok {
+				converted := make(map[string]interface{}, len(props))
+				for name, prop := range props {
+					if propSchema, ok := prop.(map[string]interface{}); ok {
+						converted[name] = filterSchema(propSchema)
+					}
+				}
+				value = converted
+			}
+		case "items":
+			if items, ok := value.(map[string]interface{}); ok {
+				value = filterSchema(items)
+			}
+		}
+		filtered[key] = value
+	}
+	return filtered
+}
+
+// convertFunctionCall converts a function call, giving it an ID when Gemini sent none.
+// index is the position of the call within the response.
+func convertFunctionCall(call *geminiFunctionCall, index int) llm.ToolCall {
+	id := call.ID
+	if id == "" {
+		id = fmt.Sprintf("%s%d-%d", generatedCallIDPrefix, time.Now().UnixNano(), index)
+	}
+
+	input := call.Args
+	if input == nil {
+		input = make(map[string]interface{})
+	}
+
+	return llm.ToolCall{
+		ID:    id,
+		Type:  "function",
+		Name:  call.Name,
+		Input: input,
+	}
+}
+
+func convertFromGeminiResponse(resp *geminiResponse, model string) *llm.Response {
+	response := &llm.Response{
+		ID:           resp.ResponseID,
+		Model:        model,
+		InputTokens:  resp.UsageMetadata.PromptTokenCount,
+		OutputTokens: resp.UsageMetadata.CandidatesTokenCount,
+		CreatedAt:    time.Now(),
+	}
+	if response.ID == "" {
+		response.ID = fmt.Sprintf("gemini-%d", time.Now().UnixNano())
+	}
+	if resp.ModelVersion != "" {
+		response.Model = resp.ModelVersion
+	}
+
+	if len(resp.Candidates) == 0 {
+		return response
+	}
+
+	candidate := resp.Candidates[0]
+	response.StopReason = strings.ToLower(candidate.FinishReason)
+
+	var texts, thoughts []string
+	for _, part := range candidate.Content.Parts {
+		switch {
+		case part.FunctionCall != nil:
+			response.ToolCalls = append(response.ToolCalls, convertFunctionCall(part.FunctionCall, len(response.ToolCalls)))
+		case part.Text != "" && part.Thought:
+			thoughts = append(thoughts, part.Text)
+		case part.Text != "":
+			texts = append(texts, part.Text)
+		}
+	}
+	response.Content = strings.Join(texts, "")
+	response.Thinking = strings.Join(thoughts, "")
+
+	return response
+}
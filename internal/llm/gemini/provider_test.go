@@ -0,0 +1,328 @@
+package gemini
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/tildaslashalef/bazinga/internal/llm"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewProvider(t *testing.T) {
+	provider := NewProvider("")
+
+	if provider.apiKey != "dummy-key" {
+		t.Errorf("Expected dummy-key, got %s", provider.apiKey)
+	}
+
+	if provider.baseURL != defaultBaseURL {
+		t.Errorf("Expected %s, got %s", defaultBaseURL, provider.baseURL)
+	}
+
+	if provider.GetDefaultModel() != "gemini-1.5-pro" {
+		t.Errorf("Expected gemini-1.5-pro, got %s", provider.GetDefaultModel())
+	}
+
+	if provider.Name() != "gemini" {
+		t.Errorf("Expected 'gemini', got %s", provider.Name())
+	}
+}
+
+func TestProvider_GetAvailableModels(t *testing.T) {
+	models := NewProvider("test-key").GetAvailableModels()
+
+	expected := []string{"gemini-1.5-pro", "gemini-1.5-flash"}
+	if len(models) != len(expected) {
+		t.Fatalf("Expected %d models, got %d", len(expected), len(models))
+	}
+	for i, id := range expected {
+		if models[i].ID != id {
+			t.Errorf("Expected model %s, got %s", id, models[i].ID)
+		}
+		if models[i].Provider != "gemini" {
+			t.Errorf("Expected provider gemini, got %s", models[i].Provider)
+		}
+	}
+}
+
+func TestProvider_EstimateTokens(t *testing.T) {
+	if got := NewProvider("test-key").EstimateTokens("12345678"); got != 2 {
+		t.Errorf("Expected 2 tokens, got %d", got)
+	}
+}
+
+func TestConvertToGeminiRequest(t *testing.T) {
+	call := llm.ToolCall{ID: "call_1", Name: "read_file", Input: map[string]interface{}{"file_path": "main.go"}}
+	req := &llm.GenerateRequest{
+		Messages: []llm.Message{
+			{Role: "system", Content: "You are helpful"},
+			{Role: "user", Content: "Read main.go"},
+			{Role: "assistant", Content: "Reading it.", ToolCalls: []llm.ToolCall{call}},
+			llm.NewToolResultMessage(&call, "package main", false),
+		},
+		MaxTokens:   1000,
+		Temperature: 0.5,
+		Tools: []llm.Tool{{
+			Name:        "read_file",
+			Description: "Read a file",
+			InputSchema: map[string]interface{}{
+				"type":                 "object",
+				"additionalProperties": false,
+				"properties": map[string]interface{}{
+					"file_path": map[string]interface{}{"type": "string", "description": "Path", "default": "x"},
+				},
+				"required": []string{"file_path"},
+			},
+		}},
+	}
+
+	geminiReq := convertToGeminiRequest(req)
+
+	if geminiReq.SystemInstruction == nil || geminiReq.SystemInstruction.Parts[0].Text != "You are helpful" {
+		t.Errorf("Expected system instruction, got %+v", geminiReq.SystemInstruction)
+	}
+
+	if len(geminiReq.Contents) != 3 {
+		t.Fatalf("Expected 3 contents, got %d", len(geminiReq.Contents))
+	}
+
+	roles := []string{"user", "model", "user"}
+	for i, role := range roles {
+		if geminiReq.Contents[i].Role != role {
+			t.Errorf("Expected content %d to have role %s, got %s", i, role, geminiReq.Contents[i].Role)
+		}
+	}
+
+	modelParts := geminiReq.Contents[1].Parts
+	if len(modelParts) != 2 || modelParts[0].Text != "Reading it." || modelParts[1].FunctionCall == nil {
+		t.Fatalf("Expected text and function call parts, got %+v", modelParts)
+	}
+	if modelParts[1].FunctionCall.Name != "read_file" || modelParts[1].FunctionCall.Args["file_path"] != "main.go" {
+		t.Errorf("Unexpected function call: %+v", modelParts[1].FunctionCall)
+	}
+
+	response := geminiReq.Contents[2].Parts[0].FunctionResponse
+	if response == nil || response.Name != "read_file" || response.ID != "call_1" {
+		t.Fatalf("Expected function response for read_file, got %+v", geminiReq.Contents[2].Parts[0])
+	}
+	if response.Response["content"] != "package main" {
+		t.Errorf("Expected result content, got %v", response.Response)
+	}
+
+	if geminiReq.GenerationConfig == nil || geminiReq.GenerationConfig.MaxOutputTokens != 1000 {
+		t.Errorf("Expected maxOutputTokens 1000, got %+v", geminiReq.GenerationConfig)
+	}
+
+	if len(geminiReq.Tools) != 1 || len(geminiReq.Tools[0].FunctionDeclarations) != 1 {
+		t.Fatalf("Expected one function declaration, got %+v", geminiReq.Tools)
+	}
+	params := geminiReq.Tools[0].FunctionDeclarations[0].Parameters
+	if _, ok := params["additionalProperties"]; ok {
+		t.Error("Expected additionalProperties to be dropped from the schema")
+	}
+	prop := params["properties"].(map[string]interface{})["file_path"].(map[string]interface{})
+	if _, ok := prop["default"]; ok || prop["type"] != "string" {
+		t.Errorf("Expected nested schema filtered to supported keys, got %v", prop)
+	}
+}
+
+func TestConvertToGeminiRequest_ErrorAndGroupedResults(t *testing.T) {
+	first := llm.ToolCall{ID: "gemini-call-1-0", Name: "bash"}
+	second := llm.ToolCall{ID: "gemini-call-1-1", Name: "grep"}
+	req := &llm.GenerateRequest{
+		Messages: []llm.Message{
+			{Role: "user", Content: "Do things"},
+			{Role: "assistant", ToolCalls: []llm.ToolCall{first, second}},
+			llm.NewToolResultMessage(&first, "command not found", true),
+			llm.NewToolResultMessage(&second, "No matches found", false),
+		},
+	}
+
+	contents := convertToGeminiRequest(req).Contents
+	if len(contents) != 3 {
+		t.Fatalf("Expected results grouped into one turn, got %d contents", len(contents))
+	}
+
+	parts := contents[2].Parts
+	if len(parts) != 2 {
+		t.Fatalf("Expected 2 function responses, got %d", len(parts))
+	}
+	if parts[0].FunctionResponse.Response["error"] != "command not found" {
+		t.Errorf("Expected error response, got %v", parts[0].FunctionResponse.Response)
+	}
+	if parts[0].FunctionResponse.ID != "" {
+		t.Errorf("Expected generated call ID to be left out, got %s", parts[0].FunctionResponse.ID)
+	}
+}
+
+func TestConvertToGeminiRequest_EmptySchema(t *testing.T) {
+	req := &llm.GenerateRequest{
+		Messages: []llm.Message{{Role: "user", Content: "hi"}},
+		Tools: []llm.Tool{{
+			Name:        "git_status",
+			InputSchema: map[string]interface{}{"type": "object", "properties": map[string]interface{}{}},
+		}},
+	}
+
+	declaration := convertToGeminiRequest(req).Tools[0].FunctionDeclarations[0]
+	if declaration.Parameters != nil {
+		t.Errorf("Expected no parameters for an empty object schema, got %v", declaration.Parameters)
+	}
+}
+
+func TestConvertFromGeminiResponse(t *testing.T) {
+	resp := &geminiResponse{
+		Candidates: []geminiCandidate{{
+			Content: geminiContent{Role: "model", Parts: []geminiPart{
+				{Text: "Let me check."},
+				{FunctionCall: &geminiFunctionCall{Name: "read_file", Args: map[string]interface{}{"file_path": "a.go"}}},
+			}},
+			FinishReason: "STOP",
+		}},
+		UsageMetadata: geminiUsage{PromptTokenCount: 12, CandidatesTokenCount: 7},
+	}
+
+	response := convertFromGeminiResponse(resp, "gemini-1.5-flash")
+
+	if response.Content != "Let me check." {
+		t.Errorf("Expected content, got %q", response.Content)
+	}
+	if response.Model != "gemini-1.5-flash" {
+		t.Errorf("Expected model gemini-1.5-flash, got %s", response.Model)
+	}
+	if response.InputTokens != 12 || response.OutputTokens != 7 {
+		t.Errorf("Expected 12/7 tokens, got %d/%d", response.InputTokens, response.OutputTokens)
+	}
+	if len(response.ToolCalls) != 1 {
+		t.Fatalf("Expected 1 tool call, got %d", len(response.ToolCalls))
+	}
+	call := response.ToolCalls[0]
+	if call.Name != "read_file" || call.Input["file_path"] != "a.go" {
+		t.Errorf("Unexpected tool call: %+v", call)
+	}
+	if !strings.HasPrefix(call.ID, generatedCallIDPrefix) {
+		t.Errorf("Expected generated call ID, got %s", call.ID)
+	}
+}
+
+func TestProvider_GenerateResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/models/gemini-1.5-flash:generateContent" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		if r.Header.Get("x-goog-api-key") != "test-key" {
+			t.Errorf("Expected API key header, got %q", r.Header.Get("x-goog-api-key"))
+		}
+
+		var req geminiRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+			return
+		}
+		if len(req.Contents) != 1 || req.Contents[0].Role != "user" {
+			t.Errorf("Unexpected contents: %+v", req.Contents)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"candidates":[{"content":{"role":"model","parts":[{"text":"Hello!"}]},"finishReason":"STOP"}],"usageMetadata":{"promptTokenCount":3,"candidatesTokenCount":2}}`))
+	}))
+	defer server.Close()
+
+	provider := NewProviderWithConfig(&Config{APIKey: "test-key", BaseURL: server.URL})
+	response, err := provider.GenerateResponse(context.Background(), &llm.GenerateRequest{
+		Model:    "gemini-1.5-flash",
+		Messages: []llm.Message{{Role: "user", Content: "Hi"}},
+	})
+	if err != nil {
+		t.Fatalf("GenerateResponse failed: %v", err)
+	}
+
+	if response.Content != "Hello!" {
+		t.Errorf("Expected 'Hello!', got %q", response.Content)
+	}
+	if response.StopReason != "stop" {
+		t.Errorf("Expected stop reason 'stop', got %q", response.StopReason)
+	}
+}
+
+func TestProvider_GenerateResponse_APIError(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":{"message":"API key not valid"}}`))
+	}))
+	defer server.Close()
+
+	provider := NewProviderWithConfig(&Config{APIKey: "bad", BaseURL: server.URL})
+	_, err := provider.GenerateResponse(context.Background(), &llm.GenerateRequest{
+		Messages: []llm.Message{{Role: "user", Content: "Hi"}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "400") {
+		t.Errorf("Expected 400 error, got %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("Expected no retries for a client error, got %d requests", requests)
+	}
+}
+
+func TestProvider_StreamResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/models/gemini-1.5-pro:streamGenerateContent" || r.URL.Query().Get("alt") != "sse" {
+			t.Errorf("Unexpected stream URL %s", r.URL.String())
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		events := []string{
+			`{"candidates":[{"content":{"role":"model","parts":[{"text":"Hel"}]}}]}`,
+			`{"candidates":[{"content":{"role":"model","parts":[{"text":"lo"}]}}]}`,
+			`{"candidates":[{"content":{"role":"model","parts":[{"functionCall":{"name":"list_files","args":{"directory":"."}}}]},"finishReason":"STOP"}]}`,
+		}
+		for _, event := range events {
+			_, _ = w.Write([]byte("data: " + event + "\r\n\r\n"))
+		}
+	}))
+	defer server.Close()
+
+	provider := NewProviderWithConfig(&Config{APIKey: "test-key", BaseURL: server.URL})
+	stream, err := provider.StreamResponse(context.Background(), &llm.GenerateRequest{
+		Messages: []llm.Message{{Role: "user", Content: "Hi"}},
+	})
+	if err != nil {
+		t.Fatalf("StreamResponse failed: %v", err)
+	}
+
+	var content strings.Builder
+	var toolCalls []*llm.ToolCall
+	var lastType string
+	timeout := time.After(5 * time.Second)
+	for done := false; !done; {
+		select {
+		case chunk, ok := <-stream:
+			if !ok {
+				done = true
+				break
+			}
+			content.WriteString(chunk.Content)
+			if chunk.ToolCall != nil {
+				toolCalls = append(toolCalls, chunk.ToolCall)
+			}
+			lastType = chunk.Type
+		case <-timeout:
+			t.Fatal("Timed out waiting for stream")
+		}
+	}
+
+	if content.String() != "Hello" {
+		t.Errorf("Expected streamed 'Hello', got %q", content.String())
+	}
+	if len(toolCalls) != 1 || toolCalls[0].Name != "list_files" || toolCalls[0].Input["directory"] != "." {
+		t.Errorf("Expected list_files tool call, got %+v", toolCalls)
+	}
+	if lastType != "content_block_stop" {
+		t.Errorf("Expected stream to end with content_block_stop, got %s", lastType)
+	}
+}
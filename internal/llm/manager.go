@@ -4,12 +4,15 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
 )
 
 // Manager manages multiple LLM providers
 type Manager struct {
 	providers       map[string]Provider
 	defaultProvider string
+	limiter         *RateLimiter // Shared by every provider (nil = unlimited)
+	onRateLimitWait func(wait time.Duration)
 	mu              sync.RWMutex
 }
 
@@ -39,6 +42,37 @@ func (m *Manager) RegisterProvider(name string, provider Provider) error {
 	return nil
 }
 
+// ReplaceProvider registers provider under name in place of any provider registered
+// there before, which is closed. A nil provider removes the name.
+func (m *Manager) ReplaceProvider(name string, provider Provider) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	previous, exists := m.providers[name]
+	if provider == nil {
+		delete(m.providers, name)
+		if m.defaultProvider == name {
+			m.defaultProvider = ""
+			for other := range m.providers {
+				m.defaultProvider = other
+				break
+			}
+		}
+	} else {
+		m.providers[name] = provider
+		if m.defaultProvider == "" {
+			m.defaultProvider = name
+		}
+	}
+
+	if exists {
+		if err := previous.Close(); err != nil {
+			return fmt.Errorf("failed to close provider %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
 // SetDefaultProvider sets the default provider
 func (m *Manager) SetDefaultProvider(name string) error {
 	m.mu.Lock()
@@ -52,7 +86,35 @@ func (m *Manager) SetDefaultProvider(name string) error {
 	return nil
 }
 
-// GetProvider returns a provider by name
+// SetRateLimit limits requests across all providers. Calls over the limit wait for it
+// rather than fail. A zero config removes the limit.
+func (m *Manager) SetRateLimit(cfg RateLimitConfig) {
+	limiter := NewRateLimiter(cfg)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if limiter != nil {
+		limiter.onWait = m.onRateLimitWait
+	}
+	m.limiter = limiter
+}
+
+// SetRateLimitCallback sets a function called when a request will wait on the rate
+// limit for more than a second, with how long it will wait
+func (m *Manager) SetRateLimitCallback(callback func(wait time.Duration)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.onRateLimitWait = callback
+	if m.limiter != nil {
+		m.limiter.mu.Lock()
+		m.limiter.onWait = callback
+		m.limiter.mu.Unlock()
+	}
+}
+
+// GetProvider returns a provider by name. Its requests are subject to the rate limit.
 func (m *Manager) GetProvider(name string) (Provider, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -66,6 +128,9 @@ func (m *Manager) GetProvider(name string) (Provider, error) {
 		return nil, fmt.Errorf("provider %s not found", name)
 	}
 
+	if m.limiter != nil {
+		return &rateLimitedProvider{Provider: provider, limiter: m.limiter}, nil
+	}
 	return provider, nil
 }
 
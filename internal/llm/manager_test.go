@@ -157,3 +157,37 @@ func TestManager_ListProviders(t *testing.T) {
 		t.Error("Not all registered providers were listed")
 	}
 }
+
+func TestManager_ReplaceProvider(t *testing.T) {
+	manager := NewManager()
+	if err := manager.RegisterProvider("test", &mockProvider{name: "old"}); err != nil {
+		t.Fatalf("RegisterProvider failed: %v", err)
+	}
+
+	if err := manager.ReplaceProvider("test", &mockProvider{name: "new"}); err != nil {
+		t.Fatalf("ReplaceProvider failed: %v", err)
+	}
+	provider, err := manager.GetProvider("test")
+	if err != nil || provider.Name() != "new" {
+		t.Errorf("Expected the replacement provider, got %v (%v)", provider, err)
+	}
+
+	// Replacing with nil removes the provider and its default
+	if err := manager.ReplaceProvider("test", nil); err != nil {
+		t.Fatalf("ReplaceProvider failed: %v", err)
+	}
+	if len(manager.ListProviders()) != 0 {
+		t.Errorf("Expected no providers, got %v", manager.ListProviders())
+	}
+	if _, err := manager.GetDefaultProvider(); err == nil {
+		t.Error("Expected no default provider after removal")
+	}
+
+	// A new name is added like RegisterProvider would
+	if err := manager.ReplaceProvider("added", &mockProvider{name: "added"}); err != nil {
+		t.Fatalf("ReplaceProvider failed: %v", err)
+	}
+	if provider, err := manager.GetDefaultProvider(); err != nil || provider.Name() != "added" {
+		t.Errorf("Expected the added provider as default, got %v (%v)", provider, err)
+	}
+}
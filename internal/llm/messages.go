@@ -0,0 +1,215 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// SystemNotePrefix marks system content that was moved into a user turn
+const SystemNotePrefix = "[system]\n"
+
+// SplitSystemPrompt separates the system prompt from the conversation for providers
+// that take it as a dedicated field (Anthropic, Bedrock). Leading system messages are
+// joined into the prompt. System messages that appear after the conversation has
+// started, such as legacy tool results, are converted to user turns so they stay in
+// place instead of being merged into (or overwriting) the system prompt.
+func SplitSystemPrompt(messages []Message) (string, []Message) {
+	var systemParts []string
+	conversation := make([]Message, 0, len(messages))
+
+	for _, msg := range messages {
+		if msg.Role != "system" {
+			conversation = append(conversation, msg)
+			continue
+		}
+
+		if len(conversation) == 0 {
+			if content, ok := msg.Content.(string); ok && content != "" {
+				systemParts = append(systemParts, content)
+			}
+			continue
+		}
+
+		converted := msg
+		converted.Role = "user"
+		if content, ok := msg.Content.(string); ok {
+			converted.Content = SystemNotePrefix + content
+		}
+		conversation = append(conversation, converted)
+	}
+
+	return strings.Join(systemParts, "\n\n"), conversation
+}
+
+// NewToolResultMessage builds the "tool" message that answers a tool call. Providers map
+// it to their native tool-result format, so the content is the bare result or error.
+// Images returned by the tool are added as image blocks after the text.
+func NewToolResultMessage(toolCall *ToolCall, content string, isError bool, images ...ImageSource) Message {
+	return Message{
+		Role:       "tool",
+		Content:    withImages(content, images),
+		Name:       toolCall.Name,
+		ToolCallID: toolCall.ID,
+		IsError:    isError,
+	}
+}
+
+// withImages returns text as plain content, or as a text block followed by image blocks
+// when there are images
+func withImages(text string, images []ImageSource) interface{} {
+	if len(images) == 0 {
+		return text
+	}
+
+	blocks := []ContentBlock{{Type: "text", Text: text}}
+	for i := range images {
+		blocks = append(blocks, ContentBlock{Type: "image", Source: &images[i]})
+	}
+	return blocks
+}
+
+// FlattenToolResult converts a tool result into a plain user turn, for tool results
+// whose call is no longer in the conversation or providers without structured results
+func FlattenToolResult(msg Message) Message {
+	errAttr := ""
+	if msg.IsError {
+		errAttr = ` error="true"`
+	}
+
+	text := fmt.Sprintf("<tool_result tool=\"%s\" tool_id=\"%s\"%s>\n%s\n</tool_result>",
+		msg.Name, msg.ToolCallID, errAttr, ContentText(msg.Content))
+	return Message{
+		Role:    "user",
+		Content: withImages(text, ContentImages(msg.Content)),
+	}
+}
+
+// PairToolResults makes tool calls and their results safe to send as structured
+// messages. A tool result is kept only if it directly follows (possibly after other
+// results) the assistant turn that made the call; otherwise it is flattened into a user
+// turn. Tool calls left without a result, e.g. after history pruning, are dropped from
+// their assistant turn, and the turn itself is dropped if nothing else remains.
+func PairToolResults(messages []Message) []Message {
+	result := make([]Message, 0, len(messages))
+	open := make(map[string]bool) // call IDs of the last assistant turn still awaiting a result
+	callsAt := -1                 // index in result of that assistant turn
+
+	closeCalls := func() {
+		if callsAt < 0 || len(open) == 0 {
+			open = make(map[string]bool)
+			callsAt = -1
+			return
+		}
+
+		msg := result[callsAt]
+		var answered []ToolCall
+		for _, call := range msg.ToolCalls {
+			if !open[call.ID] {
+				answered = append(answered, call)
+			}
+		}
+		msg.ToolCalls = answered
+		result[callsAt] = msg
+
+		if len(answered) == 0 && messageText(msg) == "" {
+			result = append(result[:callsAt], result[callsAt+1:]...)
+		}
+
+		open = make(map[string]bool)
+		callsAt = -1
+	}
+
+	for _, msg := range messages {
+		if msg.Role == "tool" {
+			if msg.ToolCallID != "" && open[msg.ToolCallID] {
+				delete(open, msg.ToolCallID)
+				result = append(result, msg)
+			} else {
+				result = append(result, FlattenToolResult(msg))
+			}
+			continue
+		}
+
+		closeCalls()
+		result = append(result, msg)
+
+		if msg.Role == "assistant" && len(msg.ToolCalls) > 0 {
+			callsAt = len(result) - 1
+			for _, call := range msg.ToolCalls {
+				open[call.ID] = true
+			}
+		}
+	}
+	closeCalls()
+
+	return result
+}
+
+// messageText returns the text of a message with string or text-block content
+func messageText(msg Message) string {
+	return strings.TrimSpace(ContentText(msg.Content))
+}
+
+// ContentText returns the text of string content, or the text blocks of structured
+// content joined by newlines
+func ContentText(content interface{}) string {
+	switch content := content.(type) {
+	case string:
+		return content
+	case []ContentBlock:
+		var parts []string
+		for _, block := range content {
+			if block.Type == "text" && block.Text != "" {
+				parts = append(parts, block.Text)
+			}
+		}
+		return strings.Join(parts, "\n")
+	}
+	return ""
+}
+
+// DisplayText returns a message's text as the user should see it: the whole result of
+// a tool call even after it was cut down in the history
+func DisplayText(msg Message) string {
+	if msg.FullContent != "" {
+		return msg.FullContent
+	}
+	return ContentText(msg.Content)
+}
+
+// ContentImages returns the images of structured content
+func ContentImages(content interface{}) []ImageSource {
+	blocks, ok := content.([]ContentBlock)
+	if !ok {
+		return nil
+	}
+
+	var images []ImageSource
+	for _, block := range blocks {
+		if block.Type == "image" && block.Source != nil {
+			images = append(images, *block.Source)
+		}
+	}
+	return images
+}
+
+// CallName returns the tool name, whichever of the two call shapes the provider used
+func (tc ToolCall) CallName() string {
+	if tc.Name == "" && tc.Function != nil {
+		return tc.Function.Name
+	}
+	return tc.Name
+}
+
+// CallInput returns the tool arguments as a map, parsing Function.Arguments if needed
+func (tc ToolCall) CallInput() map[string]interface{} {
+	if tc.Input != nil {
+		return tc.Input
+	}
+	input := make(map[string]interface{})
+	if tc.Function != nil && tc.Function.Arguments != "" {
+		_ = json.Unmarshal([]byte(tc.Function.Arguments), &input)
+	}
+	return input
+}
@@ -0,0 +1,91 @@
+package llm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSplitSystemPrompt(t *testing.T) {
+	messages := []Message{
+		{Role: "system", Content: "base prompt"},
+		{Role: "system", Content: "memory"},
+		{Role: "user", Content: "hello"},
+		{Role: "system", Content: "tool output"},
+	}
+
+	system, conversation := SplitSystemPrompt(messages)
+
+	if system != "base prompt\n\nmemory" {
+		t.Errorf("Expected joined system prompt, got %q", system)
+	}
+
+	if len(conversation) != 2 {
+		t.Fatalf("Expected 2 conversation messages, got %d", len(conversation))
+	}
+
+	if conversation[1].Role != "user" {
+		t.Errorf("Expected mid-conversation system message to become user, got %s", conversation[1].Role)
+	}
+
+	content, _ := conversation[1].Content.(string)
+	if !strings.HasPrefix(content, SystemNotePrefix) || !strings.HasSuffix(content, "tool output") {
+		t.Errorf("Expected prefixed system note, got %q", content)
+	}
+
+	// The input slice must not be modified
+	if messages[3].Role != "system" {
+		t.Error("SplitSystemPrompt should not modify its input")
+	}
+}
+
+func TestPairToolResults(t *testing.T) {
+	call := ToolCall{ID: "call_1", Name: "read_file", Input: map[string]interface{}{"file_path": "main.go"}}
+	unanswered := ToolCall{ID: "call_2", Name: "list_files"}
+
+	messages := []Message{
+		{Role: "user", Content: "read main.go"},
+		{Role: "assistant", Content: "Reading it", ToolCalls: []ToolCall{call, unanswered}},
+		NewToolResultMessage(&call, "package main", false),
+		{Role: "user", Content: "thanks"},
+		NewToolResultMessage(&ToolCall{ID: "call_9", Name: "grep"}, "no matches", true),
+	}
+
+	paired := PairToolResults(messages)
+
+	if len(paired) != 5 {
+		t.Fatalf("Expected 5 messages, got %d", len(paired))
+	}
+
+	if calls := paired[1].ToolCalls; len(calls) != 1 || calls[0].ID != "call_1" {
+		t.Errorf("Expected only the answered tool call to remain, got %+v", calls)
+	}
+
+	if paired[2].Role != "tool" || paired[2].ToolCallID != "call_1" {
+		t.Errorf("Expected paired tool result to stay structured, got %+v", paired[2])
+	}
+
+	orphan := paired[4]
+	content, _ := orphan.Content.(string)
+	if orphan.Role != "user" || !strings.Contains(content, `tool="grep"`) || !strings.Contains(content, `error="true"`) {
+		t.Errorf("Expected orphaned tool result to be flattened into a user turn, got %s: %q", orphan.Role, content)
+	}
+
+	// The input slice must not be modified
+	if len(messages[1].ToolCalls) != 2 {
+		t.Error("PairToolResults should not modify its input")
+	}
+}
+
+func TestPairToolResultsDropsEmptyAssistantTurn(t *testing.T) {
+	messages := []Message{
+		{Role: "user", Content: "list files"},
+		{Role: "assistant", Content: "", ToolCalls: []ToolCall{{ID: "call_1", Name: "list_files"}}},
+		{Role: "user", Content: "never mind"},
+	}
+
+	paired := PairToolResults(messages)
+
+	if len(paired) != 2 || paired[1].Content != "never mind" {
+		t.Errorf("Expected the unanswered, empty assistant turn to be dropped, got %+v", paired)
+	}
+}
@@ -18,12 +18,14 @@ type Provider struct {
 	baseURL      string
 	httpClient   *http.Client
 	defaultModel string
+	retry        llm.RetryConfig
 }
 
 // Config represents Ollama-specific configuration
 type Config struct {
-	BaseURL string `yaml:"base_url"`
-	Model   string `yaml:"model"` // Default model to use
+	BaseURL string          `yaml:"base_url"`
+	Model   string          `yaml:"model"` // Default model to use
+	Retry   llm.RetryConfig `yaml:"-"`     // Zero value uses llm.DefaultRetryConfig
 }
 
 // NewProvider creates a new Ollama provider with default configuration
@@ -50,6 +52,7 @@ func NewProviderWithConfig(cfg *Config) *Provider {
 			Timeout: 5 * time.Minute, // Ollama can be slow for large models
 		},
 		defaultModel: cfg.Model,
+		retry:        cfg.Retry,
 	}
 }
 
@@ -60,6 +63,8 @@ func (p *Provider) Name() string {
 
 // GenerateResponse generates a response using Ollama's API
 func (p *Provider) GenerateResponse(ctx context.Context, req *llm.GenerateRequest) (*llm.Response, error) {
+	req = llm.LimitOutputTokens(req, p.GetAvailableModels(), p.GetDefaultModel())
+
 	// Convert to Ollama format
 	ollamaReq := convertToOllamaRequest(req)
 
@@ -68,6 +73,26 @@ func (p *Provider) GenerateResponse(ctx context.Context, req *llm.GenerateReques
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
+	startTime := time.Now()
+	resp, err := llm.Retry(ctx, p.retry, "ollama generate", func(ctx context.Context) (*http.Response, error) {
+		return p.postChat(ctx, reqBody)
+	})
+	if err != nil {
+		return nil, llm.ClassifyError(p.Name(), err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var ollamaResp ollamaResponse
+	if err := json.NewDecoder(resp.Body).Decode(&ollamaResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return convertFromOllamaResponse(&ollamaResp, time.Since(startTime)), nil
+}
+
+// postChat sends a request body to the chat endpoint. A response other than 200 is
+// returned as an *llm.StatusError with its body closed.
+func (p *Provider) postChat(ctx context.Context, reqBody []byte) (*http.Response, error) {
 	httpReq, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/api/chat", bytes.NewReader(reqBody))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
@@ -75,28 +100,23 @@ func (p *Provider) GenerateResponse(ctx context.Context, req *llm.GenerateReques
 
 	httpReq.Header.Set("Content-Type", "application/json")
 
-	startTime := time.Now()
 	resp, err := p.httpClient.Do(httpReq)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
-	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
-
-	var ollamaResp ollamaResponse
-	if err := json.NewDecoder(resp.Body).Decode(&ollamaResp); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+		_ = resp.Body.Close()
+		return nil, llm.NewStatusError(resp, body)
 	}
-
-	return convertFromOllamaResponse(&ollamaResp, time.Since(startTime)), nil
+	return resp, nil
 }
 
 // StreamResponse streams a response using Ollama's API
 func (p *Provider) StreamResponse(ctx context.Context, req *llm.GenerateRequest) (<-chan *llm.StreamChunk, error) {
+	req = llm.LimitOutputTokens(req, p.GetAvailableModels(), p.GetDefaultModel())
+
 	// Convert to Ollama format with streaming enabled
 	ollamaReq := convertToOllamaRequest(req)
 	ollamaReq.Stream = true
@@ -106,22 +126,11 @@ func (p *Provider) StreamResponse(ctx context.Context, req *llm.GenerateRequest)
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/api/chat", bytes.NewReader(reqBody))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	resp, err := p.httpClient.Do(httpReq)
+	resp, err := llm.Retry(ctx, p.retry, "ollama stream", func(ctx context.Context) (*http.Response, error) {
+		return p.postChat(ctx, reqBody)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		_ = resp.Body.Close()
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+		return nil, llm.ClassifyError(p.Name(), err)
 	}
 
 	streamChan := make(chan *llm.StreamChunk, 10)
@@ -216,7 +225,10 @@ type ollamaRequest struct {
 type ollamaMessage struct {
 	Role      string           `json:"role"`
 	Content   string           `json:"content"`
+	Thinking  string           `json:"thinking,omitempty"` // Responses from thinking models: their reasoning
 	ToolCalls []ollamaToolCall `json:"tool_calls,omitempty"`
+	ToolName  string           `json:"tool_name,omitempty"` // Tool results: the tool that produced them
+	Images    []string         `json:"images,omitempty"`    // Base64 images for vision models
 }
 
 type ollamaOptions struct {
@@ -242,8 +254,31 @@ type ollamaToolCall struct {
 }
 
 type ollamaToolCallFunction struct {
-	Name      string `json:"name"`
-	Arguments string `json:"arguments"`
+	Name      string          `json:"name"`
+	Arguments ollamaArguments `json:"arguments"`
+}
+
+// ollamaArguments holds tool call arguments as a JSON string. Ollama sends and expects
+// them as an object, so they are (un)marshalled as raw JSON; a JSON string is also accepted.
+type ollamaArguments string
+
+// MarshalJSON writes the arguments as a JSON object
+func (a ollamaArguments) MarshalJSON() ([]byte, error) {
+	if a == "" || !json.Valid([]byte(a)) {
+		return []byte("{}"), nil
+	}
+	return []byte(a), nil
+}
+
+// UnmarshalJSON reads arguments sent as an object or as a JSON-encoded string
+func (a *ollamaArguments) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err == nil {
+		*a = ollamaArguments(str)
+		return nil
+	}
+	*a = ollamaArguments(data)
+	return nil
 }
 
 type ollamaResponse struct {
@@ -254,17 +289,20 @@ type ollamaResponse struct {
 }
 
 type ollamaStreamResponse struct {
-	Model     string        `json:"model"`
-	Message   ollamaMessage `json:"message"`
-	Done      bool          `json:"done"`
-	CreatedAt string        `json:"created_at"`
+	Model           string        `json:"model"`
+	Message         ollamaMessage `json:"message"`
+	Done            bool          `json:"done"`
+	CreatedAt       string        `json:"created_at"`
+	PromptEvalCount int           `json:"prompt_eval_count"` // Final chunk only
+	EvalCount       int           `json:"eval_count"`        // Final chunk only
 }
 
 // Conversion functions
 func convertToOllamaRequest(req *llm.GenerateRequest) *ollamaRequest {
+	messages := llm.PairToolResults(req.Messages)
 	ollamaReq := &ollamaRequest{
 		Model:    req.Model,
-		Messages: make([]ollamaMessage, len(req.Messages)),
+		Messages: make([]ollamaMessage, len(messages)),
 		Stream:   false,
 	}
 
@@ -273,11 +311,30 @@ func convertToOllamaRequest(req *llm.GenerateRequest) *ollamaRequest {
 		ollamaReq.Model = "qwen3:latest"
 	}
 
-	// Convert messages
-	for i, msg := range req.Messages {
+	// Convert messages. Ollama's chat API accepts system turns anywhere in the
+	// conversation, so mid-conversation system messages keep their role and position.
+	// Tool results use the native "tool" role, named after the tool that ran.
+	for i, msg := range messages {
 		ollamaReq.Messages[i] = ollamaMessage{
 			Role: msg.Role,
 		}
+		if msg.Role == "tool" {
+			ollamaReq.Messages[i].ToolName = msg.Name
+		}
+		for _, call := range msg.ToolCalls {
+			arguments, err := json.Marshal(call.CallInput())
+			if err != nil {
+				arguments = []byte("{}")
+			}
+			ollamaReq.Messages[i].ToolCalls = append(ollamaReq.Messages[i].ToolCalls, ollamaToolCall{
+				ID:   call.ID,
+				Type: "function",
+				Function: ollamaToolCallFunction{
+					Name:      call.CallName(),
+					Arguments: ollamaArguments(arguments),
+				},
+			})
+		}
 
 		// Convert content (handle both string and structured content)
 		if str, ok := msg.Content.(string); ok {
@@ -285,6 +342,9 @@ func convertToOllamaRequest(req *llm.GenerateRequest) *ollamaRequest {
 		} else {
 			// For structured content, extract text parts
 			ollamaReq.Messages[i].Content = extractTextFromContent(msg.Content)
+			for _, image := range llm.ContentImages(msg.Content) {
+				ollamaReq.Messages[i].Images = append(ollamaReq.Messages[i].Images, image.Data)
+			}
 		}
 	}
 
@@ -322,6 +382,7 @@ func convertFromOllamaResponse(resp *ollamaResponse, duration time.Duration) *ll
 		ID:               fmt.Sprintf("ollama-%d", time.Now().UnixNano()),
 		Model:            resp.Model,
 		Content:          resp.Message.Content,
+		Thinking:         resp.Message.Thinking,
 		StopReason:       "stop",
 		ProcessingTimeMs: duration.Milliseconds(),
 		CreatedAt:        time.Now(),
@@ -336,7 +397,7 @@ func convertFromOllamaResponse(resp *ollamaResponse, duration time.Duration) *ll
 				Type: tc.Type,
 				Function: &llm.Function{
 					Name:      tc.Function.Name,
-					Arguments: tc.Function.Arguments,
+					Arguments: string(tc.Function.Arguments),
 				},
 			}
 		}
@@ -350,7 +411,22 @@ func convertFromOllamaResponse(resp *ollamaResponse, duration time.Duration) *ll
 }
 
 func convertFromOllamaStreamResponse(resp *ollamaStreamResponse) *llm.StreamChunk {
-	if resp.Message.Content == "" && len(resp.Message.ToolCalls) == 0 {
+	var usage *llm.Usage
+	if resp.Done && (resp.PromptEvalCount > 0 || resp.EvalCount > 0) {
+		usage = &llm.Usage{InputTokens: resp.PromptEvalCount, OutputTokens: resp.EvalCount}
+	}
+
+	// Thinking models stream their reasoning before any content
+	if resp.Message.Thinking != "" && resp.Message.Content == "" && len(resp.Message.ToolCalls) == 0 {
+		return &llm.StreamChunk{
+			ID:      fmt.Sprintf("ollama-stream-%d", time.Now().UnixNano()),
+			Type:    "thinking",
+			Content: resp.Message.Thinking,
+			Usage:   usage,
+		}
+	}
+
+	if resp.Message.Content == "" && len(resp.Message.ToolCalls) == 0 && usage == nil {
 		return nil
 	}
 
@@ -358,6 +434,7 @@ func convertFromOllamaStreamResponse(resp *ollamaStreamResponse) *llm.StreamChun
 		ID:      fmt.Sprintf("ollama-stream-%d", time.Now().UnixNano()),
 		Type:    "content_block_delta",
 		Content: resp.Message.Content,
+		Usage:   usage,
 	}
 
 	// Handle tool calls in streaming
@@ -368,7 +445,7 @@ func convertFromOllamaStreamResponse(resp *ollamaStreamResponse) *llm.StreamChun
 			Type: resp.Message.ToolCalls[0].Type,
 			Function: &llm.Function{
 				Name:      resp.Message.ToolCalls[0].Function.Name,
-				Arguments: resp.Message.ToolCalls[0].Function.Arguments,
+				Arguments: string(resp.Message.ToolCalls[0].Function.Arguments),
 			},
 		}
 	}
@@ -1,6 +1,7 @@
 package openai
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -18,13 +19,15 @@ type Provider struct {
 	baseURL    string
 	orgID      string
 	httpClient *http.Client
+	retry      llm.RetryConfig
 }
 
 // Config represents OpenAI-specific configuration
 type Config struct {
-	APIKey  string `yaml:"api_key"`
-	BaseURL string `yaml:"base_url"`
-	OrgID   string `yaml:"org_id"`
+	APIKey  string          `yaml:"api_key"`
+	BaseURL string          `yaml:"base_url"`
+	OrgID   string          `yaml:"org_id"`
+	Retry   llm.RetryConfig `yaml:"-"` // Zero value uses llm.DefaultRetryConfig
 }
 
 // NewProvider creates a new OpenAI provider
@@ -52,6 +55,7 @@ func NewProviderWithConfig(cfg *Config) *Provider {
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		retry: cfg.Retry,
 	}
 }
 
@@ -62,6 +66,8 @@ func (p *Provider) Name() string {
 
 // GenerateResponse generates a response using OpenAI's API
 func (p *Provider) GenerateResponse(ctx context.Context, req *llm.GenerateRequest) (*llm.Response, error) {
+	req = llm.LimitOutputTokens(req, p.GetAvailableModels(), p.GetDefaultModel())
+
 	// Convert to OpenAI format
 	openAIReq := convertToOpenAIRequest(req)
 
@@ -70,6 +76,25 @@ func (p *Provider) GenerateResponse(ctx context.Context, req *llm.GenerateReques
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
+	resp, err := llm.Retry(ctx, p.retry, "openai generate", func(ctx context.Context) (*http.Response, error) {
+		return p.postChatCompletions(ctx, reqBody)
+	})
+	if err != nil {
+		return nil, llm.ClassifyError(p.Name(), err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var openAIResp openAIResponse
+	if err := json.NewDecoder(resp.Body).Decode(&openAIResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return convertFromOpenAIResponse(&openAIResp), nil
+}
+
+// postChatCompletions sends a request body to the chat completions endpoint. A response
+// other than 200 is returned as an *llm.StatusError with its body closed.
+func (p *Provider) postChatCompletions(ctx context.Context, reqBody []byte) (*http.Response, error) {
 	httpReq, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/chat/completions", bytes.NewReader(reqBody))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
@@ -86,65 +111,144 @@ func (p *Provider) GenerateResponse(ctx context.Context, req *llm.GenerateReques
 	if err != nil {
 		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
-	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
-
-	var openAIResp openAIResponse
-	if err := json.NewDecoder(resp.Body).Decode(&openAIResp); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+		_ = resp.Body.Close()
+		return nil, llm.NewStatusError(resp, body)
 	}
-
-	return convertFromOpenAIResponse(&openAIResp), nil
+	return resp, nil
 }
 
-// StreamResponse streams a response using OpenAI's API
+// StreamResponse streams a response using OpenAI's API. Text arrives as content
+// deltas; each tool call starts with its ID and name and its arguments follow as JSON
+// fragments, keyed by the index OpenAI gives each call of a response.
 func (p *Provider) StreamResponse(ctx context.Context, req *llm.GenerateRequest) (<-chan *llm.StreamChunk, error) {
-	// For MVP, use non-streaming and simulate streaming
-	response, err := p.GenerateResponse(ctx, req)
+	req = llm.LimitOutputTokens(req, p.GetAvailableModels(), p.GetDefaultModel())
+
+	openAIReq := convertToOpenAIRequest(req)
+	openAIReq.Stream = true
+	openAIReq.StreamOptions = &openAIStreamOptions{IncludeUsage: true}
+
+	reqBody, err := json.Marshal(openAIReq)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	// Create a channel and simulate streaming
-	streamChan := make(chan *llm.StreamChunk, 1)
+	// Only establishing the stream is retried; a stream that drops is resumed by the session
+	resp, err := llm.Retry(ctx, p.retry, "openai stream", func(ctx context.Context) (*http.Response, error) {
+		return p.postChatCompletions(ctx, reqBody)
+	})
+	if err != nil {
+		return nil, llm.ClassifyError(p.Name(), err)
+	}
+
+	streamChan := make(chan *llm.StreamChunk, 10)
+
 	go func() {
 		defer close(streamChan)
+		defer func() { _ = resp.Body.Close() }()
 
-		// Simulate streaming by sending the content in chunks
-		words := strings.Fields(response.Content)
-		for i, word := range words {
-			chunk := &llm.StreamChunk{
-				ID:      response.ID,
-				Content: word,
-			}
-			if i < len(words)-1 {
-				chunk.Content += " "
-			}
-
+		send := func(chunk *llm.StreamChunk) bool {
 			select {
 			case streamChan <- chunk:
-				time.Sleep(50 * time.Millisecond) // Simulate streaming delay
+				return true
 			case <-ctx.Done():
-				return
+				return false
 			}
 		}
 
-		// Send tool calls if any
-		for _, toolCall := range response.ToolCalls {
-			chunk := &llm.StreamChunk{
-				ID:       response.ID,
-				ToolCall: &toolCall,
+		var id string
+		var usage *llm.Usage
+		started := make(map[int]bool) // Tool call indexes whose start was sent
+		stopped := false
+
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+		for scanner.Scan() {
+			data, ok := strings.CutPrefix(scanner.Text(), "data:")
+			if !ok {
+				continue
 			}
-			select {
-			case streamChan <- chunk:
-			case <-ctx.Done():
-				return
+			data = strings.TrimSpace(data)
+			if data == "[DONE]" {
+				break
+			}
+
+			var event openAIStreamEvent
+			if err := json.Unmarshal([]byte(data), &event); err != nil {
+				continue // Skip malformed events
+			}
+			if event.ID != "" {
+				id = event.ID
+			}
+			// With include_usage the totals come in a last event without choices
+			if event.Usage != nil {
+				usage = &llm.Usage{InputTokens: event.Usage.PromptTokens, OutputTokens: event.Usage.CompletionTokens}
+			}
+			if len(event.Choices) == 0 {
+				continue
+			}
+
+			choice := event.Choices[0]
+			if choice.Delta.ReasoningContent != "" {
+				if !send(&llm.StreamChunk{ID: id, Type: "thinking", Content: choice.Delta.ReasoningContent}) {
+					return
+				}
+			}
+			if choice.Delta.Content != "" {
+				if !send(&llm.StreamChunk{ID: id, Type: "content_block_delta", Content: choice.Delta.Content}) {
+					return
+				}
+			}
+
+			for _, delta := range choice.Delta.ToolCalls {
+				if !started[delta.Index] {
+					started[delta.Index] = true
+					callID := delta.ID
+					if callID == "" {
+						callID = fmt.Sprintf("call_%d", delta.Index)
+					}
+					call := &llm.ToolCall{
+						ID:       callID,
+						Type:     "function",
+						Name:     delta.Function.Name,
+						Function: &llm.Function{Name: delta.Function.Name},
+					}
+					if !send(&llm.StreamChunk{ID: id, Type: "content_block_start", Index: delta.Index, ToolCall: call}) {
+						return
+					}
+				}
+				if delta.Function.Arguments != "" {
+					if !send(&llm.StreamChunk{ID: id, Type: "content_block_delta", Index: delta.Index, ToolInputDelta: delta.Function.Arguments}) {
+						return
+					}
+				}
+			}
+
+			// The finish reason closes every tool call of the response at once
+			if choice.FinishReason != "" && !stopped {
+				stopped = true
+				if !send(&llm.StreamChunk{ID: id, Type: "content_block_stop"}) {
+					return
+				}
 			}
 		}
+
+		if err := scanner.Err(); err != nil {
+			send(&llm.StreamChunk{ID: id, Type: "error", Content: fmt.Sprintf("Streaming error: %v", err)})
+			return
+		}
+
+		// A stream cut short of its finish reason still closes its tool calls
+		if !stopped && !send(&llm.StreamChunk{ID: id, Type: "content_block_stop"}) {
+			return
+		}
+
+		// Report the token counts last
+		if usage != nil {
+			send(&llm.StreamChunk{ID: id, Type: "message_stop", Usage: usage})
+		}
 	}()
 
 	return streamChan, nil
@@ -158,9 +262,9 @@ func (p *Provider) SupportsFunctionCalling() bool {
 // GetAvailableModels returns the available models for this provider
 func (p *Provider) GetAvailableModels() []llm.Model {
 	return []llm.Model{
-		{ID: "gpt-4", Name: "GPT-4", Provider: "openai"},
-		{ID: "gpt-4-turbo", Name: "GPT-4 Turbo", Provider: "openai"},
-		{ID: "gpt-3.5-turbo", Name: "GPT-3.5 Turbo", Provider: "openai"},
+		{ID: "gpt-4", Name: "GPT-4", Provider: "openai", MaxTokens: 8192, MaxOutputTokens: 8192},
+		{ID: "gpt-4-turbo", Name: "GPT-4 Turbo", Provider: "openai", MaxTokens: 128000, MaxOutputTokens: 4096},
+		{ID: "gpt-3.5-turbo", Name: "GPT-3.5 Turbo", Provider: "openai", MaxTokens: 16385, MaxOutputTokens: 4096},
 	}
 }
 
@@ -189,17 +293,60 @@ func (p *Provider) Close() error {
 
 // OpenAI request/response types
 type openAIRequest struct {
-	Model       string          `json:"model"`
-	Messages    []openAIMessage `json:"messages"`
-	MaxTokens   int             `json:"max_tokens,omitempty"`
-	Temperature float64         `json:"temperature,omitempty"`
-	Tools       []openAITool    `json:"tools,omitempty"`
-	Stream      bool            `json:"stream,omitempty"`
+	Model         string               `json:"model"`
+	Messages      []openAIMessage      `json:"messages"`
+	MaxTokens     int                  `json:"max_tokens,omitempty"`
+	Temperature   float64              `json:"temperature,omitempty"`
+	Tools         []openAITool         `json:"tools,omitempty"`
+	Stream        bool                 `json:"stream,omitempty"`
+	StreamOptions *openAIStreamOptions `json:"stream_options,omitempty"`
+}
+
+// openAIStreamOptions asks for the token counts at the end of a stream
+type openAIStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
 }
 
 type openAIMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role       string              `json:"role"`
+	Content    string              `json:"content"`
+	Parts      []openAIContentPart `json:"-"` // Sent as content instead of Content when set
+	ToolCalls  []openAIToolCall    `json:"tool_calls,omitempty"`
+	ToolCallID string              `json:"tool_call_id,omitempty"`
+}
+
+// openAIContentPart is a text or image part of a multi-part user message
+type openAIContentPart struct {
+	Type     string          `json:"type"` // "text" or "image_url"
+	Text     string          `json:"text,omitempty"`
+	ImageURL *openAIImageURL `json:"image_url,omitempty"`
+}
+
+type openAIImageURL struct {
+	URL string `json:"url"` // data URL for inline images
+}
+
+// MarshalJSON sends content parts in place of the text content when a message has them
+func (m openAIMessage) MarshalJSON() ([]byte, error) {
+	type message openAIMessage
+	if len(m.Parts) == 0 {
+		return json.Marshal(message(m))
+	}
+	return json.Marshal(struct {
+		message
+		Content []openAIContentPart `json:"content"`
+	}{message(m), m.Parts})
+}
+
+type openAIToolCall struct {
+	ID       string             `json:"id"`
+	Type     string             `json:"type"`
+	Function openAIFunctionCall `json:"function"`
+}
+
+type openAIFunctionCall struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"` // JSON string
 }
 
 type openAITool struct {
@@ -225,6 +372,32 @@ type openAIChoice struct {
 	FinishReason string        `json:"finish_reason"`
 }
 
+// openAIStreamEvent is one server-sent event of a streamed chat completion
+type openAIStreamEvent struct {
+	ID      string               `json:"id"`
+	Choices []openAIStreamChoice `json:"choices"`
+	Usage   *openAIUsage         `json:"usage"`
+}
+
+type openAIStreamChoice struct {
+	Delta        openAIStreamDelta `json:"delta"`
+	FinishReason string            `json:"finish_reason"`
+}
+
+type openAIStreamDelta struct {
+	Content          string                   `json:"content"`
+	ReasoningContent string                   `json:"reasoning_content"` // Reasoning streamed by OpenAI-compatible servers such as DeepSeek and vLLM
+	ToolCalls        []openAIToolCallFragment `json:"tool_calls"`
+}
+
+// openAIToolCallFragment is part of a streamed tool call. The first fragment of a
+// call carries its ID and name; later ones only add to its arguments.
+type openAIToolCallFragment struct {
+	Index    int                `json:"index"`
+	ID       string             `json:"id"`
+	Function openAIFunctionCall `json:"function"`
+}
+
 type openAIUsage struct {
 	PromptTokens     int `json:"prompt_tokens"`
 	CompletionTokens int `json:"completion_tokens"`
@@ -239,13 +412,58 @@ func convertToOpenAIRequest(req *llm.GenerateRequest) *openAIRequest {
 		Temperature: req.Temperature,
 	}
 
-	// Convert messages
-	for _, msg := range req.Messages {
-		openAIReq.Messages = append(openAIReq.Messages, openAIMessage{
+	// Convert messages. OpenAI accepts system turns anywhere in the conversation,
+	// so mid-conversation system messages keep their role and position. Tool results
+	// use the native "tool" role, answering the tool_calls of the assistant turn.
+	// Tool messages only take text, so images they return are sent in a user turn
+	// after the last result of the group.
+	var toolImages []openAIContentPart
+	flushToolImages := func() {
+		if len(toolImages) == 0 {
+			return
+		}
+		parts := append([]openAIContentPart{{Type: "text", Text: "Images returned by the tool calls above:"}}, toolImages...)
+		openAIReq.Messages = append(openAIReq.Messages, openAIMessage{Role: "user", Parts: parts})
+		toolImages = nil
+	}
+
+	for _, msg := range llm.PairToolResults(req.Messages) {
+		if msg.Role != "tool" {
+			flushToolImages()
+		}
+
+		openAIMsg := openAIMessage{
 			Role:    msg.Role,
 			Content: fmt.Sprintf("%v", msg.Content), // Simple string conversion
-		})
+		}
+		if blocks, ok := msg.Content.([]llm.ContentBlock); ok {
+			openAIMsg.Content = llm.ContentText(blocks)
+			if msg.Role == "tool" {
+				toolImages = append(toolImages, openAIImageParts(blocks)...)
+			} else if images := openAIImageParts(blocks); len(images) > 0 {
+				openAIMsg.Parts = append([]openAIContentPart{{Type: "text", Text: openAIMsg.Content}}, images...)
+			}
+		}
+		if msg.Role == "tool" {
+			openAIMsg.ToolCallID = msg.ToolCallID
+		}
+		for _, call := range msg.ToolCalls {
+			arguments, err := json.Marshal(call.CallInput())
+			if err != nil {
+				arguments = []byte("{}")
+			}
+			openAIMsg.ToolCalls = append(openAIMsg.ToolCalls, openAIToolCall{
+				ID:   call.ID,
+				Type: "function",
+				Function: openAIFunctionCall{
+					Name:      call.CallName(),
+					Arguments: string(arguments),
+				},
+			})
+		}
+		openAIReq.Messages = append(openAIReq.Messages, openAIMsg)
 	}
+	flushToolImages()
 
 	// Convert tools
 	for _, tool := range req.Tools {
@@ -262,6 +480,18 @@ func convertToOpenAIRequest(req *llm.GenerateRequest) *openAIRequest {
 	return openAIReq
 }
 
+// openAIImageParts converts the image blocks of structured content to data URL parts
+func openAIImageParts(blocks []llm.ContentBlock) []openAIContentPart {
+	var parts []openAIContentPart
+	for _, image := range llm.ContentImages(blocks) {
+		parts = append(parts, openAIContentPart{
+			Type:     "image_url",
+			ImageURL: &openAIImageURL{URL: "data:" + image.MediaType + ";base64," + image.Data},
+		})
+	}
+	return parts
+}
+
 func convertFromOpenAIResponse(resp *openAIResponse) *llm.Response {
 	if len(resp.Choices) == 0 {
 		return &llm.Response{
@@ -271,7 +501,7 @@ func convertFromOpenAIResponse(resp *openAIResponse) *llm.Response {
 	}
 
 	choice := resp.Choices[0]
-	return &llm.Response{
+	response := &llm.Response{
 		ID:           resp.ID,
 		Model:        resp.Model,
 		Content:      choice.Message.Content,
@@ -280,4 +510,20 @@ func convertFromOpenAIResponse(resp *openAIResponse) *llm.Response {
 		OutputTokens: resp.Usage.CompletionTokens,
 		CreatedAt:    time.Now(),
 	}
+
+	for _, tc := range choice.Message.ToolCalls {
+		call := llm.ToolCall{
+			ID:   tc.ID,
+			Type: "function",
+			Name: tc.Function.Name,
+			Function: &llm.Function{
+				Name:      tc.Function.Name,
+				Arguments: tc.Function.Arguments,
+			},
+		}
+		call.Input = call.CallInput()
+		response.ToolCalls = append(response.ToolCalls, call)
+	}
+
+	return response
 }
@@ -2,6 +2,7 @@ package llm
 
 import (
 	"context"
+	"github.com/tildaslashalef/bazinga/internal/loggy"
 	"time"
 )
 
@@ -51,6 +52,7 @@ type Response struct {
 	ID               string     `json:"id"`
 	Model            string     `json:"model"`
 	Content          string     `json:"content"`
+	Thinking         string     `json:"thinking,omitempty"` // Reasoning the model showed before its answer
 	ToolCalls        []ToolCall `json:"tool_calls,omitempty"`
 	StopReason       string     `json:"stop_reason"`
 	InputTokens      int        `json:"input_tokens"`
@@ -59,16 +61,32 @@ type Response struct {
 	CreatedAt        time.Time  `json:"created_at"`
 }
 
+// TokenUsage returns the token counts the API reported for the response, or nil if it
+// reported none
+func (r *Response) TokenUsage() *Usage {
+	if r.InputTokens == 0 && r.OutputTokens == 0 {
+		return nil
+	}
+	return &Usage{InputTokens: r.InputTokens, OutputTokens: r.OutputTokens}
+}
+
 // StreamChunk represents a chunk of streamed response
 type StreamChunk struct {
 	ID             string          `json:"id"`
-	Type           string          `json:"type"` // "content_block_start", "content_block_delta", "content_block_stop", "tool_completion"
+	Type           string          `json:"type"` // "content_block_start", "content_block_delta", "content_block_stop", "tool_completion", or "thinking" for reasoning that is not part of the answer
 	Index          int             `json:"index,omitempty"`
 	Delta          *Delta          `json:"delta,omitempty"`
 	Content        string          `json:"content,omitempty"`
 	ToolCall       *ToolCall       `json:"tool_call,omitempty"`
 	ToolInputDelta string          `json:"tool_input_delta,omitempty"`
 	ToolCompletion *ToolCompletion `json:"tool_completion,omitempty"`
+	Usage          *Usage          `json:"usage,omitempty"` // Token counts the API reported; may arrive split over several chunks
+}
+
+// Usage holds the token counts an API reported for one response
+type Usage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
 }
 
 // Delta represents incremental content in a stream
@@ -82,17 +100,24 @@ type Message struct {
 	Role       string      `json:"role"`    // "user", "assistant", "system", "tool"
 	Content    interface{} `json:"content"` // string or []ContentBlock
 	Name       string      `json:"name,omitempty"`
-	ToolCallID string      `json:"tool_call_id,omitempty"`
+	ToolCallID string      `json:"tool_call_id,omitempty"` // Tool results: the call this answers
+	ToolCalls  []ToolCall  `json:"tool_calls,omitempty"`   // Assistant turns: the tools it called
+	IsError    bool        `json:"is_error,omitempty"`     // Tool results: the tool failed
+
+	// Tool results cut down in the history: the whole result, for display only. It is
+	// saved with the session but never sent to the model.
+	FullContent string `json:"full_content,omitempty"`
 }
 
 // ContentBlock represents structured content
 type ContentBlock struct {
-	Type    string       `json:"type"` // "text", "image", "tool_use", "tool_result"
-	Text    string       `json:"text,omitempty"`
-	Source  *ImageSource `json:"source,omitempty"`
-	ToolUse *ToolUse     `json:"tool_use,omitempty"`
-	Content interface{}  `json:"content,omitempty"`
-	IsError bool         `json:"is_error,omitempty"`
+	Type      string       `json:"type"` // "text", "image", "tool_use", "tool_result"
+	Text      string       `json:"text,omitempty"`
+	Source    *ImageSource `json:"source,omitempty"`
+	ToolUse   *ToolUse     `json:"tool_use,omitempty"`
+	ToolUseID string       `json:"tool_use_id,omitempty"` // tool_result: the tool_use it answers
+	Content   interface{}  `json:"content,omitempty"`
+	IsError   bool         `json:"is_error,omitempty"`
 }
 
 // ImageSource represents image data
@@ -137,18 +162,55 @@ type ToolCompletion struct {
 	Args      map[string]interface{} `json:"args"`
 	Result    string                 `json:"result"`
 	Error     string                 `json:"error,omitempty"`
-	State     string                 `json:"state"`                // "complete" or "error"
+	State     string                 `json:"state"`                // "start", "output", "complete" or "error"; output carries one line in Result
 	TaskGroup string                 `json:"task_group,omitempty"` // Optional task group for UI organization
 }
 
 // Model represents an LLM model
 type Model struct {
-	ID              string  `json:"id"`
-	Name            string  `json:"name"`
-	Provider        string  `json:"provider"`
-	MaxTokens       int     `json:"max_tokens"`
-	SupportsTools   bool    `json:"supports_tools"`
-	CostPer1KTokens float64 `json:"cost_per_1k_tokens"`
+	ID                    string  `json:"id"`
+	Name                  string  `json:"name"`
+	Provider              string  `json:"provider"`
+	MaxTokens             int     `json:"max_tokens"`                  // Context window
+	MaxOutputTokens       int     `json:"max_output_tokens,omitempty"` // Most tokens one response may have (0 = unknown)
+	SupportsTools         bool    `json:"supports_tools"`
+	CostPer1KTokens       float64 `json:"cost_per_1k_tokens"` // Input price; also the output price unless OutputCostPer1KTokens is set
+	OutputCostPer1KTokens float64 `json:"output_cost_per_1k_tokens,omitempty"`
+}
+
+// defaultOutputTokens is the response length asked for when none is configured, if the
+// model allows that much
+const defaultOutputTokens = 8192
+
+// LimitOutputTokens fits the MaxTokens of a request to the model it is for, or to
+// defaultModel when it names none. A value above the model's maximum output is lowered
+// to it, and an unset one gets a default, so switching models does not cause "max_tokens
+// too large" errors. Requests for unknown models are returned unchanged.
+func LimitOutputTokens(req *GenerateRequest, models []Model, defaultModel string) *GenerateRequest {
+	modelID := req.Model
+	if modelID == "" {
+		modelID = defaultModel
+	}
+
+	var maxOutput int
+	for _, model := range models {
+		if model.ID == modelID {
+			maxOutput = model.MaxOutputTokens
+			break
+		}
+	}
+	if maxOutput <= 0 || (req.MaxTokens > 0 && req.MaxTokens <= maxOutput) {
+		return req
+	}
+
+	limited := *req
+	if req.MaxTokens <= 0 {
+		limited.MaxTokens = min(maxOutput, defaultOutputTokens)
+	} else {
+		loggy.Info("Lowering max_tokens to the model's maximum output", "model", modelID, "requested", req.MaxTokens, "max_output_tokens", maxOutput)
+		limited.MaxTokens = maxOutput
+	}
+	return &limited
 }
 
 // ProviderConfig represents provider configuration
@@ -0,0 +1,39 @@
+package llm
+
+import "testing"
+
+func TestLimitOutputTokens(t *testing.T) {
+	models := []Model{
+		{ID: "small", MaxOutputTokens: 4096},
+		{ID: "large", MaxOutputTokens: 64000},
+		{ID: "unknown"},
+	}
+
+	tests := []struct {
+		name      string
+		model     string
+		maxTokens int
+		want      int
+	}{
+		{"within the limit", "small", 2000, 2000},
+		{"above the limit", "small", 16000, 4096},
+		{"unset on a small model", "small", 0, 4096},
+		{"unset on a large model", "large", 0, defaultOutputTokens},
+		{"default model", "", 16000, 4096},
+		{"no known maximum", "unknown", 100000, 100000},
+		{"model not listed", "other", 100000, 100000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &GenerateRequest{Model: tt.model, MaxTokens: tt.maxTokens}
+			got := LimitOutputTokens(req, models, "small")
+			if got.MaxTokens != tt.want {
+				t.Errorf("MaxTokens = %d, want %d", got.MaxTokens, tt.want)
+			}
+			if req.MaxTokens != tt.maxTokens {
+				t.Error("Expected the original request to be left unchanged")
+			}
+		})
+	}
+}
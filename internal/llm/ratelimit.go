@@ -0,0 +1,157 @@
+package llm
+
+import (
+	"context"
+	"github.com/tildaslashalef/bazinga/internal/loggy"
+	"sync"
+	"time"
+)
+
+// RateLimitConfig limits how fast requests are sent, across all providers
+type RateLimitConfig struct {
+	RequestsPerMinute int // Requests allowed per minute (0 = unlimited)
+	TokensPerMinute   int // Estimated input tokens allowed per minute (0 = unlimited)
+}
+
+// rateLimitNoticeDelay is how long a call must wait before the wait callback is told
+const rateLimitNoticeDelay = time.Second
+
+// RateLimiter is a token bucket for requests and, optionally, one for input tokens.
+// Callers reserve their share up front and sleep until the buckets have refilled, so
+// waiting calls go out in the order they arrived.
+type RateLimiter struct {
+	mu       sync.Mutex
+	requests *bucket
+	tokens   *bucket
+	onWait   func(wait time.Duration)
+}
+
+// bucket holds up to a minute's allowance and refills continuously. Its level goes
+// negative while callers are waiting on it.
+type bucket struct {
+	capacity float64
+	level    float64
+	perSec   float64
+	updated  time.Time
+}
+
+// NewRateLimiter returns a limiter for cfg, or nil when cfg sets no limits
+func NewRateLimiter(cfg RateLimitConfig) *RateLimiter {
+	if cfg.RequestsPerMinute <= 0 && cfg.TokensPerMinute <= 0 {
+		return nil
+	}
+
+	now := time.Now()
+	return &RateLimiter{
+		requests: newBucket(cfg.RequestsPerMinute, now),
+		tokens:   newBucket(cfg.TokensPerMinute, now),
+	}
+}
+
+// newBucket returns a full bucket for perMinute, or nil when it is unlimited
+func newBucket(perMinute int, now time.Time) *bucket {
+	if perMinute <= 0 {
+		return nil
+	}
+	return &bucket{
+		capacity: float64(perMinute),
+		level:    float64(perMinute),
+		perSec:   float64(perMinute) / 60,
+		updated:  now,
+	}
+}
+
+// reserve takes n from the bucket and returns how long until the level is back to zero
+func (b *bucket) reserve(n float64, now time.Time) time.Duration {
+	if b == nil {
+		return 0
+	}
+
+	b.level = min(b.capacity, b.level+now.Sub(b.updated).Seconds()*b.perSec)
+	b.updated = now
+
+	// A request larger than the bucket would otherwise never go through
+	b.level -= min(n, b.capacity)
+	if b.level >= 0 {
+		return 0
+	}
+	return time.Duration(-b.level / b.perSec * float64(time.Second))
+}
+
+// refund returns n to the bucket, for a call that gave up waiting
+func (b *bucket) refund(n float64) {
+	if b != nil {
+		b.level = min(b.capacity, b.level+min(n, b.capacity))
+	}
+}
+
+// Wait blocks until a request estimated at tokens input tokens may be sent, or ctx is
+// done. A nil limiter never waits.
+func (l *RateLimiter) Wait(ctx context.Context, tokens int) error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	wait := max(l.requests.reserve(1, now), l.tokens.reserve(float64(tokens), now))
+	onWait := l.onWait
+	l.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+
+	loggy.Debug("Waiting on rate limit", "wait", wait, "tokens", tokens)
+	if wait > rateLimitNoticeDelay && onWait != nil {
+		onWait(wait)
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.requests.refund(1)
+		l.tokens.refund(float64(tokens))
+		l.mu.Unlock()
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// rateLimitedProvider acquires from the limiter before each call to the provider
+type rateLimitedProvider struct {
+	Provider
+	limiter *RateLimiter
+}
+
+// GenerateResponse waits on the rate limit, then generates the response
+func (p *rateLimitedProvider) GenerateResponse(ctx context.Context, req *GenerateRequest) (*Response, error) {
+	if err := p.limiter.Wait(ctx, p.estimateTokens(req)); err != nil {
+		return nil, err
+	}
+	return p.Provider.GenerateResponse(ctx, req)
+}
+
+// StreamResponse waits on the rate limit, then starts the stream
+func (p *rateLimitedProvider) StreamResponse(ctx context.Context, req *GenerateRequest) (<-chan *StreamChunk, error) {
+	if err := p.limiter.Wait(ctx, p.estimateTokens(req)); err != nil {
+		return nil, err
+	}
+	return p.Provider.StreamResponse(ctx, req)
+}
+
+// estimateTokens estimates the input tokens of a request from its message text
+func (p *rateLimitedProvider) estimateTokens(req *GenerateRequest) int {
+	if req == nil || p.limiter.tokens == nil {
+		return 0
+	}
+
+	tokens := 0
+	for _, msg := range req.Messages {
+		tokens += p.EstimateTokens(ContentText(msg.Content))
+	}
+	return tokens
+}
@@ -0,0 +1,94 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBucket_Reserve(t *testing.T) {
+	now := time.Now()
+	b := newBucket(60, now)
+
+	for i := 0; i < 60; i++ {
+		if wait := b.reserve(1, now); wait != 0 {
+			t.Fatalf("Request %d waited %v within the burst", i+1, wait)
+		}
+	}
+	if wait := b.reserve(1, now); wait != time.Second {
+		t.Errorf("Expected the 61st request to wait 1s, got %v", wait)
+	}
+	if wait := b.reserve(1, now); wait != 2*time.Second {
+		t.Errorf("Expected the next request to queue behind it, got %v", wait)
+	}
+
+	// Refilled for three seconds, two of which pay off the queue
+	if wait := b.reserve(1, now.Add(3*time.Second)); wait != 0 {
+		t.Errorf("Expected no wait after refilling, got %v", wait)
+	}
+
+	if wait := newBucket(10, now).reserve(100, now); wait != 0 {
+		t.Errorf("Expected a request larger than the bucket to be capped, got %v", wait)
+	}
+}
+
+func TestRateLimiter_Wait(t *testing.T) {
+	if NewRateLimiter(RateLimitConfig{}) != nil {
+		t.Error("Expected no limiter without limits")
+	}
+
+	limiter := NewRateLimiter(RateLimitConfig{TokensPerMinute: 60})
+	var notified time.Duration
+	limiter.onWait = func(wait time.Duration) { notified = wait }
+
+	if err := limiter.Wait(context.Background(), 60); err != nil {
+		t.Fatalf("Wait failed within the limit: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := limiter.Wait(ctx, 30); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected Wait to stop with the context, got %v", err)
+	}
+	if notified < 29*time.Second {
+		t.Errorf("Expected to be told about a ~30s wait, got %v", notified)
+	}
+	if level := limiter.tokens.level; level < -1 {
+		t.Errorf("Expected the cancelled reservation to be refunded, level is %v", level)
+	}
+}
+
+func TestManager_RateLimit(t *testing.T) {
+	manager := NewManager()
+	if err := manager.RegisterProvider("test", &mockProvider{name: "test"}); err != nil {
+		t.Fatalf("RegisterProvider failed: %v", err)
+	}
+
+	manager.SetRateLimit(RateLimitConfig{RequestsPerMinute: 1})
+	waited := make(chan time.Duration, 1)
+	manager.SetRateLimitCallback(func(wait time.Duration) { waited <- wait })
+
+	provider, err := manager.GetProvider("test")
+	if err != nil {
+		t.Fatalf("GetProvider failed: %v", err)
+	}
+	if _, err := provider.GenerateResponse(context.Background(), &GenerateRequest{}); err != nil {
+		t.Fatalf("First request failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-waited
+		cancel()
+	}()
+	if _, err := provider.StreamResponse(ctx, &GenerateRequest{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the second request to wait on the limit, got %v", err)
+	}
+
+	manager.SetRateLimit(RateLimitConfig{})
+	provider, _ = manager.GetProvider("test")
+	if _, ok := provider.(*rateLimitedProvider); ok {
+		t.Error("Expected no rate limiting after removing the limit")
+	}
+}
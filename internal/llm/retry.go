@@ -0,0 +1,160 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/tildaslashalef/bazinga/internal/loggy"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// RetryConfig controls how provider calls are retried on transient errors
+type RetryConfig struct {
+	MaxAttempts int           // Total attempts including the first; 1 disables retries
+	BaseDelay   time.Duration // Delay before the first retry, doubled for each one after
+	MaxDelay    time.Duration // Upper bound for a single delay, including Retry-After
+	Jitter      float64       // Fraction the delay is randomly varied by, e.g. 0.2 for ±20%
+}
+
+// DefaultRetryConfig returns the retry settings used when none are configured
+func DefaultRetryConfig() RetryConfig {
+	return RetryConfig{
+		MaxAttempts: 3,
+		BaseDelay:   time.Second,
+		MaxDelay:    30 * time.Second,
+		Jitter:      0.2,
+	}
+}
+
+// withDefaults fills unset fields from DefaultRetryConfig
+func (c RetryConfig) withDefaults() RetryConfig {
+	defaults := DefaultRetryConfig()
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = defaults.MaxAttempts
+	}
+	if c.BaseDelay <= 0 {
+		c.BaseDelay = defaults.BaseDelay
+	}
+	if c.MaxDelay <= 0 {
+		c.MaxDelay = defaults.MaxDelay
+	}
+	if c.Jitter < 0 {
+		c.Jitter = 0
+	}
+	return c
+}
+
+// delay returns how long to wait before the given retry (1 for the first retry)
+func (c RetryConfig) delay(retry int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		return min(retryAfter, c.MaxDelay)
+	}
+
+	delay := c.BaseDelay << (retry - 1)
+	if delay <= 0 || delay > c.MaxDelay {
+		delay = c.MaxDelay
+	}
+	if c.Jitter > 0 {
+		delay += time.Duration((rand.Float64()*2 - 1) * c.Jitter * float64(delay))
+	}
+	return delay
+}
+
+// StatusError is a non-200 response from a provider's HTTP API
+type StatusError struct {
+	StatusCode int
+	Body       string
+	RetryAfter time.Duration // From the Retry-After header, if the server sent one
+}
+
+// NewStatusError builds a StatusError from a failed response and its body
+func NewStatusError(resp *http.Response, body []byte) *StatusError {
+	return &StatusError{
+		StatusCode: resp.StatusCode,
+		Body:       string(body),
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("API request failed with status %d: %s", e.StatusCode, e.Body)
+}
+
+// HTTPStatusCode returns the response status, matching the AWS SDK's response errors
+func (e *StatusError) HTTPStatusCode() int {
+	return e.StatusCode
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds
+func parseRetryAfter(value string) time.Duration {
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// IsRetryable reports whether err is a transient failure worth retrying, judged by its
+// ErrorKind: throttling (429), server errors (5xx, including Anthropic's 529 overloaded)
+// and network failures. Client errors such as 400 and authentication failures are not.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	// A refused connection means the server is not there, which waiting rarely fixes
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return false
+	}
+	return ErrorKindOf(err).Retryable()
+}
+
+// Retry calls fn until it succeeds, fails with an error that is not retryable, or runs
+// out of attempts, waiting with exponential backoff between attempts. It stops early
+// when ctx is done. operation names the call in logs, e.g. "anthropic generate".
+func Retry[T any](ctx context.Context, cfg RetryConfig, operation string, fn func(ctx context.Context) (T, error)) (T, error) {
+	cfg = cfg.withDefaults()
+
+	for attempt := 1; ; attempt++ {
+		result, err := fn(ctx)
+		if err == nil {
+			if attempt > 1 {
+				loggy.Info("LLM request succeeded after retrying", "operation", operation, "attempts", attempt)
+			}
+			return result, nil
+		}
+
+		if attempt >= cfg.MaxAttempts || !IsRetryable(err) || ctx.Err() != nil {
+			if attempt > 1 {
+				loggy.Warn("LLM request failed after retrying", "operation", operation, "attempts", attempt, "error", err)
+			}
+			return result, err
+		}
+
+		var retryAfter time.Duration
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) {
+			retryAfter = statusErr.RetryAfter
+		}
+		delay := cfg.delay(attempt, retryAfter)
+
+		loggy.Warn("Retrying LLM request after transient error",
+			"operation", operation,
+			"attempt", attempt,
+			"max_attempts", cfg.MaxAttempts,
+			"delay", delay,
+			"error", err)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return result, err
+		case <-timer.C:
+		}
+	}
+}
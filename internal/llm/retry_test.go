@@ -0,0 +1,143 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// fastRetry keeps test retries from sleeping for real
+var fastRetry = RetryConfig{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+
+func TestRetry_SucceedsAfterTransientErrors(t *testing.T) {
+	attempts := 0
+	result, err := Retry(context.Background(), fastRetry, "test", func(ctx context.Context) (string, error) {
+		attempts++
+		switch attempts {
+		case 1:
+			return "", &StatusError{StatusCode: http.StatusTooManyRequests, Body: "rate limited"}
+		case 2:
+			return "", &StatusError{StatusCode: http.StatusServiceUnavailable, Body: "unavailable"}
+		}
+		return "ok", nil
+	})
+
+	if err != nil {
+		t.Fatalf("Expected success after retries, got %v", err)
+	}
+	if result != "ok" {
+		t.Errorf("Expected result ok, got %q", result)
+	}
+	if attempts != 3 {
+		t.Errorf("Expected 3 attempts, got %d", attempts)
+	}
+}
+
+func TestRetry_FailsFastOnClientErrors(t *testing.T) {
+	for _, code := range []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden} {
+		attempts := 0
+		_, err := Retry(context.Background(), fastRetry, "test", func(ctx context.Context) (string, error) {
+			attempts++
+			return "", &StatusError{StatusCode: code, Body: "bad"}
+		})
+
+		if err == nil {
+			t.Errorf("Expected error for status %d", code)
+		}
+		if attempts != 1 {
+			t.Errorf("Expected a single attempt for status %d, got %d", code, attempts)
+		}
+	}
+}
+
+func TestRetry_GivesUpAfterMaxAttempts(t *testing.T) {
+	attempts := 0
+	_, err := Retry(context.Background(), fastRetry, "test", func(ctx context.Context) (int, error) {
+		attempts++
+		return 0, &StatusError{StatusCode: http.StatusInternalServerError, Body: "boom"}
+	})
+
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusInternalServerError {
+		t.Errorf("Expected the last status error, got %v", err)
+	}
+	if attempts != fastRetry.MaxAttempts {
+		t.Errorf("Expected %d attempts, got %d", fastRetry.MaxAttempts, attempts)
+	}
+}
+
+func TestRetry_StopsWhenContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	attempts := 0
+	_, err := Retry(ctx, RetryConfig{MaxAttempts: 5, BaseDelay: time.Hour}, "test", func(ctx context.Context) (int, error) {
+		attempts++
+		cancel()
+		return 0, &StatusError{StatusCode: http.StatusTooManyRequests}
+	})
+
+	if err == nil {
+		t.Error("Expected error after cancellation")
+	}
+	if attempts != 1 {
+		t.Errorf("Expected no retries after cancellation, got %d attempts", attempts)
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"throttled", &StatusError{StatusCode: 429}, true},
+		{"server error", &StatusError{StatusCode: 500}, true},
+		{"overloaded", &StatusError{StatusCode: 529}, true},
+		{"bad request", &StatusError{StatusCode: 400}, false},
+		{"unauthorized", &StatusError{StatusCode: 401}, false},
+		{"wrapped", fmt.Errorf("failed: %w", &StatusError{StatusCode: 503}), true},
+		{"connection reset", fmt.Errorf("read: %w", syscall.ECONNRESET), true},
+		{"connection refused", fmt.Errorf("dial: %w", syscall.ECONNREFUSED), false},
+		{"cancelled", context.Canceled, false},
+	}
+
+	for _, tt := range tests {
+		if got := IsRetryable(tt.err); got != tt.want {
+			t.Errorf("IsRetryable(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	cfg := RetryConfig{BaseDelay: time.Second, MaxDelay: 5 * time.Second}.withDefaults()
+	cfg.Jitter = 0
+
+	if got := cfg.delay(1, 0); got != time.Second {
+		t.Errorf("Expected 1s for the first retry, got %v", got)
+	}
+	if got := cfg.delay(2, 0); got != 2*time.Second {
+		t.Errorf("Expected 2s for the second retry, got %v", got)
+	}
+	if got := cfg.delay(10, 0); got != 5*time.Second {
+		t.Errorf("Expected delay capped at 5s, got %v", got)
+	}
+	if got := cfg.delay(1, 3*time.Second); got != 3*time.Second {
+		t.Errorf("Expected Retry-After to be used, got %v", got)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	if got := parseRetryAfter("2"); got != 2*time.Second {
+		t.Errorf("Expected 2s, got %v", got)
+	}
+	if got := parseRetryAfter(""); got != 0 {
+		t.Errorf("Expected 0 for missing header, got %v", got)
+	}
+	if got := parseRetryAfter("Wed, 21 Oct 2015 07:28:00 GMT"); got != 0 {
+		t.Errorf("Expected 0 for date form, got %v", got)
+	}
+}
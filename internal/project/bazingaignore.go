@@ -0,0 +1,77 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// BazingaIgnoreFile holds gitignore-style patterns of paths hidden from the assistant,
+// such as secrets or large generated files, whether or not git ignores them
+const BazingaIgnoreFile = ".bazingaignore"
+
+// IgnoreMatcher matches paths against the patterns of a project's .bazingaignore. A nil
+// or empty matcher matches nothing.
+type IgnoreMatcher struct {
+	root    string
+	rules   []ignoreRule
+	modTime time.Time // When the file was last changed, to notice edits
+	size    int64
+}
+
+// LoadBazingaIgnore reads the .bazingaignore at the project root
+func LoadBazingaIgnore(rootPath string) *IgnoreMatcher {
+	matcher := &IgnoreMatcher{root: rootPath}
+
+	path := filepath.Join(rootPath, BazingaIgnoreFile)
+	info, err := os.Stat(path)
+	if err != nil {
+		return matcher // No .bazingaignore file
+	}
+	matcher.modTime, matcher.size = info.ModTime(), info.Size()
+
+	for _, pattern := range readGitIgnore(path, "") {
+		if rule, ok := parseIgnoreRule(pattern); ok {
+			matcher.rules = append(matcher.rules, rule)
+		}
+	}
+	return matcher
+}
+
+// Stale reports whether the .bazingaignore changed, appeared or went away since the
+// matcher was loaded
+func (m *IgnoreMatcher) Stale() bool {
+	info, err := os.Stat(filepath.Join(m.root, BazingaIgnoreFile))
+	if err != nil {
+		return !m.modTime.IsZero()
+	}
+	return !info.ModTime().Equal(m.modTime) || info.Size() != m.size
+}
+
+// Empty reports whether the matcher has no patterns
+func (m *IgnoreMatcher) Empty() bool {
+	return m == nil || len(m.rules) == 0
+}
+
+// Matches reports whether a path, absolute or relative to the root, is hidden by the
+// patterns. Paths outside the root never match.
+func (m *IgnoreMatcher) Matches(path string, isDir bool) bool {
+	if m.Empty() {
+		return false
+	}
+
+	if filepath.IsAbs(path) {
+		rel, err := filepath.Rel(m.root, path)
+		if err != nil {
+			return false
+		}
+		path = rel
+	}
+	path = filepath.ToSlash(filepath.Clean(path))
+	if path == "." || path == ".." || strings.HasPrefix(path, "../") {
+		return false
+	}
+
+	return ignoredByRules(strings.Split(path, "/"), isDir, m.rules)
+}
@@ -1,7 +1,6 @@
 package project
 
 import (
-	"bufio"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -18,6 +17,8 @@ const (
 	ProjectTypePython     ProjectType = "python"
 	ProjectTypeRust       ProjectType = "rust"
 	ProjectTypeJava       ProjectType = "java"
+	ProjectTypeCSharp     ProjectType = "csharp"
+	ProjectTypeRuby       ProjectType = "ruby"
 	ProjectTypeGeneric    ProjectType = "generic"
 )
 
@@ -30,8 +31,17 @@ type Project struct {
 	Directories []string          `json:"directories"`
 	Metadata    map[string]string `json:"metadata"`
 	GitIgnore   []string          `json:"gitignore_patterns"`
+
+	// FileLimitReached is set when the scan stopped at the file limit, so Files is partial
+	FileLimitReached bool `json:"file_limit_reached"`
 }
 
+// DefaultMaxDepth is how many directory levels below the root a scan descends
+const DefaultMaxDepth = 5
+
+// DefaultMaxFiles is how many files a scan lists before it stops
+const DefaultMaxFiles = 500
+
 // ProjectDetector handles project detection and scanning
 type ProjectDetector struct {
 	maxFiles      int
@@ -39,13 +49,33 @@ type ProjectDetector struct {
 	includeHidden bool
 }
 
+// DetectorConfig sets the limits of a project scan. Zero limits use the defaults.
+type DetectorConfig struct {
+	MaxFiles      int
+	MaxDepth      int
+	IncludeHidden bool
+}
+
 // NewDetector creates a new project detector
 func NewDetector() *ProjectDetector {
 	return &ProjectDetector{
-		maxFiles:      500,   // Reasonable limit for context
-		maxDepth:      5,     // Avoid deep recursion
-		includeHidden: false, // Skip hidden files by default
+		maxFiles:      DefaultMaxFiles, // Reasonable limit for context
+		maxDepth:      DefaultMaxDepth, // Avoid deep recursion
+		includeHidden: false,           // Skip hidden files by default
+	}
+}
+
+// NewDetectorWithConfig creates a project detector with the given scan limits
+func NewDetectorWithConfig(cfg DetectorConfig) *ProjectDetector {
+	d := NewDetector()
+	if cfg.MaxFiles > 0 {
+		d.maxFiles = cfg.MaxFiles
 	}
+	if cfg.MaxDepth > 0 {
+		d.maxDepth = cfg.MaxDepth
+	}
+	d.includeHidden = cfg.IncludeHidden
+	return d
 }
 
 // DetectProject analyzes the given directory and detects project type
@@ -84,6 +114,12 @@ func (d *ProjectDetector) DetectProject(rootPath string) (*Project, error) {
 	return project, nil
 }
 
+// DetectType returns the type of the project at rootPath from its key files, without
+// scanning the project
+func (d *ProjectDetector) DetectType(rootPath string) ProjectType {
+	return d.detectProjectType(rootPath)
+}
+
 // detectProjectType determines the project type based on key files
 func (d *ProjectDetector) detectProjectType(rootPath string) ProjectType {
 	// Check for specific project types in order of priority
@@ -119,6 +155,16 @@ func (d *ProjectDetector) detectProjectType(rootPath string) ProjectType {
 		}
 	}
 
+	// C# (a project or solution file)
+	if d.globExists(rootPath, "*.csproj") || d.globExists(rootPath, "*.sln") {
+		return ProjectTypeCSharp
+	}
+
+	// Ruby (Gemfile is definitive; Rails apps often have a package.json too)
+	if d.fileExists(rootPath, "Gemfile") {
+		return ProjectTypeRuby
+	}
+
 	// JavaScript (package.json without tsconfig.json)
 	if d.fileExists(rootPath, "package.json") {
 		return ProjectTypeJavaScript
@@ -134,11 +180,32 @@ func (d *ProjectDetector) fileExists(rootPath, filename string) bool {
 	return err == nil
 }
 
+// globExists checks if any file in the given directory matches a glob pattern
+func (d *ProjectDetector) globExists(rootPath, pattern string) bool {
+	matches, err := filepath.Glob(filepath.Join(rootPath, pattern))
+	return err == nil && len(matches) > 0
+}
+
 // scanProject scans the project directory for relevant files
 func (d *ProjectDetector) scanProject(project *Project) error {
+	return d.scanTree(project, project.Root)
+}
+
+// scanTree scans the directory tree at start, inside the project root, for relevant
+// files and directories the project doesn't list yet
+func (d *ProjectDetector) scanTree(project *Project, start string) error {
 	extensions := d.getRelevantExtensions(project.Type)
+	hidden := LoadBazingaIgnore(project.Root)
+
+	known := make(map[string]bool, len(project.Files)+len(project.Directories))
+	for _, path := range project.Files {
+		known[path] = true
+	}
+	for _, path := range project.Directories {
+		known[path] = true
+	}
 
-	return filepath.Walk(project.Root, func(path string, info os.FileInfo, err error) error {
+	return filepath.Walk(start, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return nil //nolint:nilerr // Skip files with errors
 		}
@@ -161,25 +228,30 @@ func (d *ProjectDetector) scanProject(project *Project) error {
 			return nil
 		}
 
-		// Check gitignore patterns
-		if d.shouldIgnore(relPath, project.GitIgnore) {
+		// Check gitignore patterns, and .bazingaignore so hidden files stay out of the prompt
+		if d.shouldIgnore(relPath, info.IsDir(), project.GitIgnore) || hidden.Matches(relPath, info.IsDir()) {
 			if info.IsDir() {
 				return filepath.SkipDir
 			}
 			return nil
 		}
 
+		if known[relPath] {
+			return nil
+		}
+
 		if info.IsDir() {
 			project.Directories = append(project.Directories, relPath)
 		} else {
 			// Check if file is relevant
 			if d.isRelevantFile(path, extensions) {
-				project.Files = append(project.Files, relPath)
-
 				// Stop if we've hit the file limit
 				if len(project.Files) >= d.maxFiles {
-					return fmt.Errorf("file limit reached")
+					project.FileLimitReached = true
+					return filepath.SkipAll
 				}
+
+				project.Files = append(project.Files, relPath)
 			}
 		}
 
@@ -201,7 +273,11 @@ func (d *ProjectDetector) getRelevantExtensions(projectType ProjectType) []strin
 	case ProjectTypeRust:
 		return []string{".rs", ".toml", ".md"}
 	case ProjectTypeJava:
-		return []string{".java", ".xml", ".properties", ".gradle", ".md"}
+		return []string{".java", ".xml", ".properties", ".gradle", ".kts", ".md"}
+	case ProjectTypeCSharp:
+		return []string{".cs", ".csproj", ".sln", ".props", ".json", ".md"}
+	case ProjectTypeRuby:
+		return []string{".rb", ".rake", ".gemspec", ".erb", ".ru", ".yml", ".md"}
 	default:
 		return []string{".md", ".txt", ".json", ".yaml", ".yml", ".toml"}
 	}
@@ -222,7 +298,7 @@ func (d *ProjectDetector) isRelevantFile(filePath string, extensions []string) b
 	fileName := strings.ToLower(filepath.Base(filePath))
 	specialFiles := []string{
 		"readme", "license", "changelog", "makefile", "dockerfile",
-		"gitignore", "gitattributes", "editorconfig",
+		"gitignore", "gitattributes", "editorconfig", "gemfile", "rakefile",
 	}
 
 	for _, special := range specialFiles {
@@ -234,90 +310,27 @@ func (d *ProjectDetector) isRelevantFile(filePath string, extensions []string) b
 	return false
 }
 
-// loadGitIgnore loads .gitignore patterns from the project root
-func (d *ProjectDetector) loadGitIgnore(rootPath string) []string {
-	gitignorePath := filepath.Join(rootPath, ".gitignore")
-
-	file, err := os.Open(gitignorePath)
-	if err != nil {
-		return []string{} // No .gitignore file
-	}
-	defer func() { _ = file.Close() }()
-
-	var patterns []string
-	scanner := bufio.NewScanner(file)
-
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		// Skip empty lines and comments
-		if line != "" && !strings.HasPrefix(line, "#") {
-			patterns = append(patterns, line)
-		}
-	}
-
-	return patterns
-}
-
-// shouldIgnore checks if a path should be ignored based on gitignore patterns
-func (d *ProjectDetector) shouldIgnore(relPath string, patterns []string) bool {
-	// Always ignore common directories
-	commonIgnores := []string{
-		"node_modules", ".git", ".svn", ".hg",
-		"vendor", "target", "build", "dist",
-		".vscode", ".idea", "__pycache__", ".pytest_cache",
-		".DS_Store", "Thumbs.db",
-	}
-
-	pathParts := strings.Split(relPath, string(filepath.Separator))
-
-	// Check common ignores
-	for _, part := range pathParts {
-		for _, ignore := range commonIgnores {
-			if part == ignore {
-				return true
-			}
-		}
-	}
-
-	// Check gitignore patterns (simplified matching)
-	for _, pattern := range patterns {
-		if d.matchesPattern(relPath, pattern) {
-			return true
-		}
-	}
-
-	return false
+// LoadGitIgnore loads the .gitignore patterns of a project root and its subdirectories
+func LoadGitIgnore(rootPath string) []string {
+	return (&ProjectDetector{}).loadGitIgnore(rootPath)
 }
 
-// matchesPattern provides basic gitignore pattern matching
-func (d *ProjectDetector) matchesPattern(path, pattern string) bool {
-	// Handle simple patterns (not full gitignore spec)
-	pattern = strings.TrimSpace(pattern)
-
-	// Directory patterns
-	if strings.HasSuffix(pattern, "/") {
-		pattern = strings.TrimSuffix(pattern, "/")
-		return strings.Contains(path, pattern)
-	}
-
-	// Wildcard patterns (basic)
-	if strings.Contains(pattern, "*") {
-		// Convert to simple regex-like matching
-		if strings.HasPrefix(pattern, "*.") {
-			ext := strings.TrimPrefix(pattern, "*")
-			return strings.HasSuffix(path, ext)
-		}
-	}
-
-	// Exact match or contains
-	return strings.Contains(path, pattern) || filepath.Base(path) == pattern
+// ShouldIgnore reports whether a path relative to the project root is in a common
+// ignored directory (node_modules, vendor, .git, ...) or is ignored by the .gitignore
+// patterns. isDir says whether the path is a directory, for patterns ending in "/".
+func ShouldIgnore(relPath string, isDir bool, patterns []string) bool {
+	return (&ProjectDetector{}).shouldIgnore(relPath, isDir, patterns)
 }
 
 // GetProjectSummary returns a human-readable summary of the project
 func (p *Project) GetProjectSummary() string {
 	summary := fmt.Sprintf("Project: %s (%s)\n", p.Name, p.Type)
 	summary += fmt.Sprintf("Root: %s\n", p.Root)
-	summary += fmt.Sprintf("Files: %d relevant files found\n", len(p.Files))
+	if p.FileLimitReached {
+		summary += fmt.Sprintf("Files: %d relevant files found (file limit reached, the list is partial)\n", len(p.Files))
+	} else {
+		summary += fmt.Sprintf("Files: %d relevant files found\n", len(p.Files))
+	}
 	summary += fmt.Sprintf("Directories: %d\n", len(p.Directories))
 
 	if len(p.GitIgnore) > 0 {
@@ -331,33 +344,11 @@ func (p *Project) GetProjectSummary() string {
 func (p *Project) GetMainFiles() []string {
 	var mainFiles []string
 
-	// Add project-specific important files first
-	switch p.Type {
-	case ProjectTypeGo:
-		priorities := []string{"main.go", "go.mod", "README.md", "Makefile"}
-		for _, priority := range priorities {
-			for _, file := range p.Files {
-				if strings.HasSuffix(file, priority) {
-					mainFiles = append(mainFiles, file)
-				}
-			}
-		}
-	case ProjectTypeJavaScript, ProjectTypeTypeScript:
-		priorities := []string{"package.json", "index.js", "index.ts", "src/index.js", "src/index.ts", "README.md"}
-		for _, priority := range priorities {
-			for _, file := range p.Files {
-				if strings.HasSuffix(file, priority) {
-					mainFiles = append(mainFiles, file)
-				}
-			}
-		}
-	case ProjectTypePython:
-		priorities := []string{"main.py", "__init__.py", "requirements.txt", "README.md"}
-		for _, priority := range priorities {
-			for _, file := range p.Files {
-				if strings.HasSuffix(file, priority) {
-					mainFiles = append(mainFiles, file)
-				}
+	// Add project-specific important files first, in priority order
+	for _, priority := range p.mainFilePriorities() {
+		for _, file := range p.Files {
+			if strings.HasSuffix(file, priority) && !contains(mainFiles, file) {
+				mainFiles = append(mainFiles, file)
 			}
 		}
 	}
@@ -370,14 +361,7 @@ func (p *Project) GetMainFiles() []string {
 				break
 			}
 			// Don't add duplicates
-			found := false
-			for _, existing := range mainFiles {
-				if existing == file {
-					found = true
-					break
-				}
-			}
-			if !found {
+			if !contains(mainFiles, file) {
 				mainFiles = append(mainFiles, file)
 			}
 		}
@@ -386,6 +370,29 @@ func (p *Project) GetMainFiles() []string {
 	return mainFiles
 }
 
+// mainFilePriorities returns the path suffixes of a project type's entry points and
+// manifests, most important first
+func (p *Project) mainFilePriorities() []string {
+	switch p.Type {
+	case ProjectTypeGo:
+		return []string{"main.go", "go.mod", "README.md", "Makefile"}
+	case ProjectTypeJavaScript, ProjectTypeTypeScript:
+		return []string{"package.json", "index.js", "index.ts", "src/index.js", "src/index.ts", "README.md"}
+	case ProjectTypePython:
+		return []string{"main.py", "__init__.py", "requirements.txt", "README.md"}
+	case ProjectTypeRust:
+		return []string{"Cargo.toml", "src/main.rs", "src/lib.rs", "README.md"}
+	case ProjectTypeJava:
+		return []string{"pom.xml", "build.gradle", "build.gradle.kts", "Application.java", "Main.java", "README.md"}
+	case ProjectTypeCSharp:
+		return []string{".sln", ".csproj", "Program.cs", "Startup.cs", "appsettings.json", "README.md"}
+	case ProjectTypeRuby:
+		return []string{"Gemfile", "config/routes.rb", "config/application.rb", "Rakefile", "README.md"}
+	default:
+		return nil
+	}
+}
+
 // GetRelevantFiles returns a broader set of relevant files for the project
 // This includes main files plus important source files, up to a reasonable limit
 func (p *Project) GetRelevantFiles(maxFiles int) []string {
@@ -399,98 +406,68 @@ func (p *Project) GetRelevantFiles(maxFiles int) []string {
 	mainFiles := p.GetMainFiles()
 	relevantFiles = append(relevantFiles, mainFiles...)
 
-	// Add source files by priority
+	// Add source files by priority: directories and entry points first, then any
+	// source file of the project's language
+	var priorities []string
+	matches := strings.Contains
 	switch p.Type {
 	case ProjectTypeGo:
-		// Add Go source files, prioritizing certain patterns
-		priorities := []string{
+		priorities = []string{
 			"cmd/", "internal/", "pkg/", // Common Go structure
 			".go", // All Go files
 		}
-		for _, priority := range priorities {
-			if len(relevantFiles) >= maxFiles {
-				break
-			}
-			for _, file := range p.Files {
-				if len(relevantFiles) >= maxFiles {
-					break
-				}
-				// Skip if already added
-				if contains(relevantFiles, file) {
-					continue
-				}
-
-				if strings.Contains(file, priority) {
-					relevantFiles = append(relevantFiles, file)
-				}
-			}
-		}
-
 	case ProjectTypeJavaScript, ProjectTypeTypeScript:
-		// Add JS/TS source files
-		priorities := []string{
+		priorities = []string{
 			"src/", "lib/", "app/", // Common JS/TS structure
 			"index.", "main.", "app.", // Entry points
 			".js", ".ts", ".jsx", ".tsx", // Source files
 		}
-		for _, priority := range priorities {
-			if len(relevantFiles) >= maxFiles {
-				break
-			}
-			for _, file := range p.Files {
-				if len(relevantFiles) >= maxFiles {
-					break
-				}
-				if contains(relevantFiles, file) {
-					continue
-				}
-				if strings.Contains(file, priority) {
-					relevantFiles = append(relevantFiles, file)
-				}
-			}
-		}
-
 	case ProjectTypePython:
-		// Add Python source files
-		priorities := []string{
+		priorities = []string{
 			"src/", "lib/", "app/", // Common Python structure
 			"__init__.py", "main.py", // Entry points
 			".py", // Python files
 		}
-		for _, priority := range priorities {
-			if len(relevantFiles) >= maxFiles {
-				break
-			}
-			for _, file := range p.Files {
-				if len(relevantFiles) >= maxFiles {
-					break
-				}
-				if contains(relevantFiles, file) {
-					continue
-				}
-				if strings.Contains(file, priority) {
-					relevantFiles = append(relevantFiles, file)
-				}
-			}
+	case ProjectTypeRust:
+		priorities = []string{
+			"src/bin/", "src/", "crates/", // Common Cargo structure
+			".rs", // Rust files
+		}
+	case ProjectTypeJava:
+		priorities = []string{
+			"src/main/java/", "src/main/resources/", // Maven/Gradle structure
+			".java", // Java files
+		}
+	case ProjectTypeCSharp:
+		priorities = []string{
+			"Controllers/", "Models/", "Services/", // Common ASP.NET structure
+			".cs", // C# files
+		}
+	case ProjectTypeRuby:
+		priorities = []string{
+			"app/", "lib/", "config/", // Common Rails and gem structure
+			".rb", // Ruby files
 		}
-
 	default:
 		// For generic projects, add files by extension priority
-		priorities := []string{".md", ".txt", ".json", ".yaml", ".yml"}
-		for _, priority := range priorities {
+		priorities = []string{".md", ".txt", ".json", ".yaml", ".yml"}
+		matches = strings.HasSuffix
+	}
+
+	for _, priority := range priorities {
+		if len(relevantFiles) >= maxFiles {
+			break
+		}
+		for _, file := range p.Files {
 			if len(relevantFiles) >= maxFiles {
 				break
 			}
-			for _, file := range p.Files {
-				if len(relevantFiles) >= maxFiles {
-					break
-				}
-				if contains(relevantFiles, file) {
-					continue
-				}
-				if strings.HasSuffix(file, priority) {
-					relevantFiles = append(relevantFiles, file)
-				}
+			// Skip if already added
+			if contains(relevantFiles, file) {
+				continue
+			}
+			if matches(file, priority) {
+				relevantFiles = append(relevantFiles, file)
 			}
 		}
 	}
@@ -3,6 +3,7 @@ package project
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -46,6 +47,21 @@ func TestDetectProjectType(t *testing.T) {
 			files:    []string{"Cargo.toml", "src/main.rs"},
 			expected: ProjectTypeRust,
 		},
+		{
+			name:     "C# project",
+			files:    []string{"App.csproj", "Program.cs"},
+			expected: ProjectTypeCSharp,
+		},
+		{
+			name:     "C# solution",
+			files:    []string{"App.sln", "src/App/App.csproj"},
+			expected: ProjectTypeCSharp,
+		},
+		{
+			name:     "Ruby project",
+			files:    []string{"Gemfile", "package.json", "config/routes.rb"},
+			expected: ProjectTypeRuby,
+		},
 		{
 			name:     "Generic project",
 			files:    []string{"README.md", "some-file.txt"},
@@ -168,6 +184,8 @@ func TestGetRelevantExtensions(t *testing.T) {
 		{ProjectTypePython, ".py"},
 		{ProjectTypeRust, ".rs"},
 		{ProjectTypeJava, ".java"},
+		{ProjectTypeCSharp, ".cs"},
+		{ProjectTypeRuby, ".rb"},
 	}
 
 	for _, tt := range tests {
@@ -237,7 +255,7 @@ func TestShouldIgnore(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.path, func(t *testing.T) {
-			result := detector.shouldIgnore(tt.path, patterns)
+			result := detector.shouldIgnore(tt.path, false, patterns)
 			if result != tt.expected {
 				t.Errorf("Expected %t for path %s, got %t", tt.expected, tt.path, result)
 			}
@@ -270,3 +288,96 @@ func TestProjectGetMainFiles(t *testing.T) {
 		}
 	}
 }
+
+func TestProjectGetMainFilesByType(t *testing.T) {
+	tests := []struct {
+		project  *Project
+		expected []string
+	}{
+		{
+			project: &Project{
+				Type:  ProjectTypeRust,
+				Files: []string{"src/util.rs", "README.md", "src/lib.rs", "Cargo.toml", "src/main.rs"},
+			},
+			expected: []string{"Cargo.toml", "src/main.rs", "src/lib.rs", "README.md"},
+		},
+		{
+			project: &Project{
+				Type:  ProjectTypeCSharp,
+				Files: []string{"Models/User.cs", "Program.cs", "App.csproj", "App.sln"},
+			},
+			expected: []string{"App.sln", "App.csproj", "Program.cs"},
+		},
+		{
+			project: &Project{
+				Type:  ProjectTypeRuby,
+				Files: []string{"app/models/user.rb", "config/routes.rb", "Gemfile", "lib/tasks/db.rake"},
+			},
+			expected: []string{"Gemfile", "config/routes.rb"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.project.Type), func(t *testing.T) {
+			mainFiles := tt.project.GetMainFiles()
+			if len(mainFiles) < len(tt.expected) {
+				t.Fatalf("Expected at least %v, got %v", tt.expected, mainFiles)
+			}
+			for i, expected := range tt.expected {
+				if mainFiles[i] != expected {
+					t.Errorf("Expected main files to start with %v, got %v", tt.expected, mainFiles)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestNewDetectorWithConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, path := range []string{"go.mod", "a.go", "b.go", "c.go", "pkg/deep/d.go", ".github/workflows/ci.yml", ".github/README.md"} {
+		fullPath := filepath.Join(tmpDir, path)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fullPath, []byte("x\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The defaults list everything but hidden files
+	project, err := NewDetectorWithConfig(DetectorConfig{}).DetectProject(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to detect project: %v", err)
+	}
+	if len(project.Files) != 5 || project.FileLimitReached {
+		t.Errorf("Expected 5 files and no limit, got %v (limit reached: %v)", project.Files, project.FileLimitReached)
+	}
+	if strings.Contains(project.GetProjectSummary(), "partial") {
+		t.Errorf("Expected a complete list, got summary:\n%s", project.GetProjectSummary())
+	}
+
+	// Hitting the file limit keeps the files found so far and says so
+	project, err = NewDetectorWithConfig(DetectorConfig{MaxFiles: 3}).DetectProject(tmpDir)
+	if err != nil {
+		t.Fatalf("Expected the file limit not to fail detection, got: %v", err)
+	}
+	if len(project.Files) != 3 || !project.FileLimitReached {
+		t.Errorf("Expected 3 files and the limit reached, got %v (limit reached: %v)", project.Files, project.FileLimitReached)
+	}
+	if !strings.Contains(project.GetProjectSummary(), "file limit reached, the list is partial") {
+		t.Errorf("Expected the summary to report the limit, got:\n%s", project.GetProjectSummary())
+	}
+
+	// Depth and hidden files
+	project, err = NewDetectorWithConfig(DetectorConfig{MaxDepth: 2, IncludeHidden: true}).DetectProject(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to detect project: %v", err)
+	}
+	if !contains(project.Files, filepath.Join(".github", "README.md")) {
+		t.Errorf("Expected hidden directories to be scanned, got %v", project.Files)
+	}
+	if contains(project.Files, filepath.Join("pkg", "deep", "d.go")) {
+		t.Errorf("Expected files below the depth limit to be skipped, got %v", project.Files)
+	}
+}
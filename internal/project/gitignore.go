@@ -0,0 +1,219 @@
+package project
+
+import (
+	"bufio"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// commonIgnores are directories and files that are always skipped, whatever the
+// project's .gitignore says
+var commonIgnores = []string{
+	"node_modules", ".git", ".svn", ".hg",
+	"vendor", "target", "build", "dist",
+	".vscode", ".idea", "__pycache__", ".pytest_cache",
+	".DS_Store", "Thumbs.db",
+}
+
+// ignoreRule is one parsed .gitignore line
+type ignoreRule struct {
+	segments []string // Slash-separated glob segments; "**" matches any number of directories
+	negate   bool     // "!pattern" re-includes what an earlier rule ignored
+	dirOnly  bool     // "pattern/" only matches directories
+	anchored bool     // A pattern with a slash matches from the root; one without matches any basename
+}
+
+// parseIgnoreRule parses a .gitignore pattern. ok is false for blank lines and comments.
+func parseIgnoreRule(pattern string) (ignoreRule, bool) {
+	pattern = trimIgnoreLine(pattern)
+	if pattern == "" || strings.HasPrefix(pattern, "#") {
+		return ignoreRule{}, false
+	}
+
+	var rule ignoreRule
+	if strings.HasPrefix(pattern, "!") {
+		rule.negate = true
+		pattern = pattern[1:]
+	}
+	if strings.HasSuffix(pattern, "/") {
+		rule.dirOnly = true
+		pattern = strings.TrimRight(pattern, "/")
+	}
+	if strings.Contains(pattern, "/") {
+		rule.anchored = true
+		pattern = strings.TrimPrefix(pattern, "/")
+	}
+	if pattern == "" {
+		return ignoreRule{}, false
+	}
+
+	rule.segments = strings.Split(pattern, "/")
+	return rule, true
+}
+
+// trimIgnoreLine drops a line ending and trailing spaces that aren't escaped
+func trimIgnoreLine(line string) string {
+	line = strings.TrimRight(line, "\r")
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, `\ `) {
+		line = line[:len(line)-1]
+	}
+	return line
+}
+
+// matches reports whether the rule matches a slash-separated path relative to the root
+func (r ignoreRule) matches(components []string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	if !r.anchored {
+		ok, _ := path.Match(r.segments[0], components[len(components)-1])
+		return ok
+	}
+	return matchIgnoreSegments(r.segments, components)
+}
+
+// matchIgnoreSegments matches glob segments against path components. A "**" matches
+// zero or more directories, except that a trailing "**" must match something, so
+// "logs/**" ignores what is inside logs but not logs itself.
+func matchIgnoreSegments(pattern, name []string) bool {
+	if len(pattern) == 0 {
+		return len(name) == 0
+	}
+
+	if pattern[0] == "**" {
+		if len(pattern) == 1 {
+			return len(name) > 0
+		}
+		for i := 0; i <= len(name); i++ {
+			if matchIgnoreSegments(pattern[1:], name[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(name) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], name[0]); !ok {
+		return false
+	}
+	return matchIgnoreSegments(pattern[1:], name[1:])
+}
+
+// ignoredByRules applies gitignore rules to a path. The last rule that matches wins,
+// and as in git, nothing inside an ignored directory can be re-included.
+func ignoredByRules(components []string, isDir bool, rules []ignoreRule) bool {
+	for i := range components {
+		prefixIsDir := isDir || i < len(components)-1
+
+		ignored := false
+		for _, rule := range rules {
+			if rule.matches(components[:i+1], prefixIsDir) {
+				ignored = !rule.negate
+			}
+		}
+
+		if ignored {
+			return true
+		}
+	}
+	return false
+}
+
+// loadGitIgnore loads the patterns of the root .gitignore and of the .gitignore files
+// in its subdirectories. Patterns from a nested file are rewritten relative to the
+// root so they only apply below their own directory, and they come after those of
+// its parents so they take precedence, as in git.
+func (d *ProjectDetector) loadGitIgnore(rootPath string) []string {
+	patterns := readGitIgnore(filepath.Join(rootPath, ".gitignore"), "")
+
+	_ = filepath.WalkDir(rootPath, func(walkPath string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.IsDir() {
+			return nil //nolint:nilerr // Skip unreadable entries
+		}
+
+		relPath, err := filepath.Rel(rootPath, walkPath)
+		if err != nil || relPath == "." {
+			return nil //nolint:nilerr
+		}
+		if len(strings.Split(relPath, string(filepath.Separator))) > DefaultMaxDepth || d.shouldIgnore(relPath, true, patterns) {
+			return filepath.SkipDir
+		}
+
+		patterns = append(patterns, readGitIgnore(filepath.Join(walkPath, ".gitignore"), filepath.ToSlash(relPath))...)
+		return nil
+	})
+
+	return patterns
+}
+
+// readGitIgnore reads the patterns of one .gitignore file, scoping them to dir, the
+// file's directory relative to the root ("" for the root itself)
+func readGitIgnore(gitignorePath, dir string) []string {
+	file, err := os.Open(gitignorePath)
+	if err != nil {
+		return nil // No .gitignore file
+	}
+	defer func() { _ = file.Close() }()
+
+	var patterns []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := trimIgnoreLine(scanner.Text())
+		// Skip empty lines and comments
+		if _, ok := parseIgnoreRule(line); !ok {
+			continue
+		}
+		patterns = append(patterns, scopeIgnorePattern(line, dir))
+	}
+
+	return patterns
+}
+
+// scopeIgnorePattern rewrites a pattern from the .gitignore in dir so that, read as a
+// root pattern, it matches the same paths
+func scopeIgnorePattern(pattern, dir string) string {
+	if dir == "" {
+		return pattern
+	}
+
+	negate := ""
+	if strings.HasPrefix(pattern, "!") {
+		negate, pattern = "!", pattern[1:]
+	}
+
+	body := strings.TrimRight(pattern, "/")
+	if strings.Contains(body, "/") {
+		// Anchored to the .gitignore's own directory
+		return negate + dir + "/" + strings.TrimPrefix(pattern, "/")
+	}
+	return negate + dir + "/**/" + pattern
+}
+
+// shouldIgnore checks if a path relative to the root should be ignored, either because
+// it is in a common ignored directory or because of the gitignore patterns
+func (d *ProjectDetector) shouldIgnore(relPath string, isDir bool, patterns []string) bool {
+	components := strings.Split(filepath.ToSlash(relPath), "/")
+
+	// Always ignore common directories
+	for _, part := range components {
+		for _, ignore := range commonIgnores {
+			if part == ignore {
+				return true
+			}
+		}
+	}
+
+	rules := make([]ignoreRule, 0, len(patterns))
+	for _, pattern := range patterns {
+		if rule, ok := parseIgnoreRule(pattern); ok {
+			rules = append(rules, rule)
+		}
+	}
+
+	return ignoredByRules(components, isDir, rules)
+}
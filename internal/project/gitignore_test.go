@@ -0,0 +1,179 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGitIgnorePatterns(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		path     string
+		isDir    bool
+		expected bool
+	}{
+		{"extension anywhere", []string{"*.log"}, "logs/app/debug.log", false, true},
+		{"extension no match", []string{"*.log"}, "logger.go", false, false},
+		{"basename at any depth", []string{"secrets.txt"}, "config/secrets.txt", false, true},
+		{"anchored at root", []string{"/coverage"}, "coverage", true, true},
+		{"anchored not nested", []string{"/coverage"}, "pkg/coverage", true, false},
+		{"middle slash anchors", []string{"docs/generated"}, "docs/generated/api.md", false, true},
+		{"middle slash not nested", []string{"docs/generated"}, "site/docs/generated", true, false},
+		{"dir only matches dir", []string{"out/"}, "out", true, true},
+		{"dir only skips file", []string{"out/"}, "out", false, false},
+		{"dir only ignores contents", []string{"out/"}, "out/bin/app", false, true},
+		{"negation re-includes", []string{"*.log", "!keep.log"}, "keep.log", false, false},
+		{"later pattern wins", []string{"!keep.log", "*.log"}, "keep.log", false, true},
+		{"negation inside ignored dir", []string{"tmp/", "!tmp/keep.txt"}, "tmp/keep.txt", false, true},
+		{"negation after contents glob", []string{"tmp/*", "!tmp/keep.txt"}, "tmp/keep.txt", false, false},
+		{"leading double star", []string{"**/fixtures"}, "a/b/fixtures/x.json", false, true},
+		{"middle double star", []string{"src/**/gen"}, "src/gen", true, true},
+		{"middle double star deep", []string{"src/**/gen"}, "src/a/b/gen/x.go", false, true},
+		{"trailing double star", []string{"cache/**"}, "cache/a/b", false, true},
+		{"trailing double star not dir itself", []string{"cache/**"}, "cache", true, false},
+		{"character class", []string{"*.py[co]"}, "mod/x.pyc", false, true},
+		{"question mark", []string{"file?.txt"}, "file1.txt", false, true},
+		{"escaped hash", []string{`\#notes`}, "#notes", false, true},
+		{"comment ignored", []string{"# *.go"}, "main.go", false, false},
+		{"trailing spaces trimmed", []string{"*.tmp   "}, "a.tmp", false, true},
+		{"common ignores always apply", []string{"!node_modules"}, "web/node_modules/x.js", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ShouldIgnore(filepath.FromSlash(tt.path), tt.isDir, tt.patterns)
+			if result != tt.expected {
+				t.Errorf("Expected %t for %s with %v, got %t", tt.expected, tt.path, tt.patterns, result)
+			}
+		})
+	}
+}
+
+func TestLoadGitIgnoreNested(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		".gitignore":             "*.log\n/scratch\n",
+		"web/.gitignore":         "# web build\n.cache/\n!important.log\n/local.env\n",
+		"web/app/.gitignore":     "*.snap\n",
+		"scratch/.gitignore":     "*.go\n", // Inside an ignored directory, so never read
+		"web/app/view.go":        "",
+		"web/app/view.snap":      "",
+		"web/local.env":          "",
+		"web/app/local.env":      "",
+		"web/important.log":      "",
+		"web/other.log":          "",
+		"api/.cache/data":        "",
+		"web/components/.cache/": "",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if strings.HasSuffix(name, "/") {
+			if err := os.MkdirAll(path, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	patterns := LoadGitIgnore(root)
+	if strings.Join(patterns, " ") != "*.log /scratch web/**/.cache/ !web/**/important.log web/local.env web/app/**/*.snap" {
+		t.Errorf("Unexpected patterns: %q", patterns)
+	}
+
+	tests := []struct {
+		path     string
+		isDir    bool
+		expected bool
+	}{
+		{"web/app/view.go", false, false},
+		{"web/app/view.snap", false, true},
+		{"web/local.env", false, true},
+		{"web/app/local.env", false, false},
+		{"web/important.log", false, false},
+		{"web/other.log", false, true},
+		{"api/.cache", true, false},
+		{"web/components/.cache", true, true},
+		{"scratch/main.go", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			result := ShouldIgnore(filepath.FromSlash(tt.path), tt.isDir, patterns)
+			if result != tt.expected {
+				t.Errorf("Expected %t for %s, got %t", tt.expected, tt.path, result)
+			}
+		})
+	}
+}
+
+func TestBazingaIgnore(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"secrets", "src", "gen"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{"secrets/key.pem", "src/main.go", "gen/api.pb.go", "main.go"} {
+		if err := os.WriteFile(filepath.Join(root, filepath.FromSlash(name)), []byte("package x\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if matcher := LoadBazingaIgnore(root); !matcher.Empty() || matcher.Matches("secrets/key.pem", false) {
+		t.Error("Without a .bazingaignore nothing should be hidden")
+	}
+
+	ignorePath := filepath.Join(root, BazingaIgnoreFile)
+	if err := os.WriteFile(ignorePath, []byte("# hidden\nsecrets/\n*.pb.go\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	matcher := LoadBazingaIgnore(root)
+
+	tests := []struct {
+		path     string
+		isDir    bool
+		expected bool
+	}{
+		{"secrets", true, true},
+		{"secrets/key.pem", false, true},
+		{filepath.Join(root, "secrets", "key.pem"), false, true},
+		{"gen/api.pb.go", false, true},
+		{"src/main.go", false, false},
+		{"main.go", false, false},
+		{"../other/secrets/key.pem", false, false},
+	}
+	for _, tt := range tests {
+		if got := matcher.Matches(tt.path, tt.isDir); got != tt.expected {
+			t.Errorf("Matches(%q) = %v, want %v", tt.path, got, tt.expected)
+		}
+	}
+
+	project, err := NewDetector().DetectProject(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range project.Files {
+		if strings.HasPrefix(filepath.ToSlash(file), "secrets/") || strings.HasSuffix(file, ".pb.go") {
+			t.Errorf("Hidden file %s should not be in the project files", file)
+		}
+	}
+
+	if matcher.Stale() {
+		t.Error("An unchanged .bazingaignore should not be stale")
+	}
+	if err := os.WriteFile(ignorePath, []byte("secrets/\n*.pb.go\nsrc/\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if !matcher.Stale() {
+		t.Error("An edited .bazingaignore should be stale")
+	}
+}
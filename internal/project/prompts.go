@@ -87,6 +87,10 @@ func (pb *PromptBuilder) getLanguageDescription() string {
 		return "Rust development, emphasizing memory safety, performance, and idiomatic Rust patterns"
 	case ProjectTypeJava:
 		return "Java development, following established patterns, enterprise practices, and modern Java features"
+	case ProjectTypeCSharp:
+		return "C# and .NET development, following .NET conventions, async patterns, and modern C# features"
+	case ProjectTypeRuby:
+		return "Ruby development, following Ruby idioms, Rails conventions, and the Ruby style guide"
 	default:
 		return "software development with a focus on clean, maintainable code"
 	}
@@ -159,6 +163,26 @@ func (pb *PromptBuilder) getLanguageGuidelines() string {
 - Follow SOLID principles
 - Use proper package organization`
 
+	case ProjectTypeCSharp:
+		return `- Follow .NET naming conventions (PascalCase for types and members, camelCase for locals)
+- Use async/await with Task for I/O-bound work
+- Enable nullable reference types and respect their warnings
+- Use dependency injection through the built-in container
+- Prefer LINQ for collection queries where it stays readable
+- Dispose resources with using declarations
+- Write unit tests with xUnit, NUnit, or MSTest
+- Use the dotnet CLI to build, test, and manage packages`
+
+	case ProjectTypeRuby:
+		return `- Follow the Ruby style guide (snake_case methods, CamelCase classes)
+- Write idiomatic Ruby using blocks and Enumerable methods
+- Follow Rails conventions over configuration where Rails is used
+- Keep controllers thin and move logic into models or service objects
+- Use Bundler for dependency management
+- Write tests with RSpec or Minitest
+- Use RuboCop for code quality
+- Handle errors with specific exception classes`
+
 	default:
 		return `- Write clean, readable, and maintainable code
 - Follow consistent naming and formatting conventions
@@ -232,6 +256,26 @@ func (pb *PromptBuilder) getBestPractices() string {
 - Use design patterns appropriately
 - Follow enterprise development practices`
 
+	case ProjectTypeCSharp:
+		return `- Use records and immutable types for data
+- Pass CancellationToken through async call chains
+- Configure through the options pattern and appsettings.json
+- Use structured logging with ILogger
+- Avoid blocking on async code with .Result or .Wait()
+- Keep projects in a solution focused and layered
+- Use Entity Framework migrations for schema changes
+- Treat compiler warnings as errors in CI`
+
+	case ProjectTypeRuby:
+		return `- Prefer small methods and single-purpose classes
+- Avoid N+1 queries with includes and eager loading
+- Use database migrations for schema changes
+- Keep secrets in credentials or environment variables
+- Use background jobs for slow work
+- Freeze string literals and constants where it helps
+- Pin gem versions in the Gemfile.lock
+- Use rake tasks for repeatable maintenance work`
+
 	default:
 		return `- Write self-documenting code with clear names
 - Implement comprehensive error handling
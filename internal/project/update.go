@@ -0,0 +1,120 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// UpdatePaths brings the project's files and directories up to date after the given
+// paths were created, deleted or moved, without walking the whole project again. Paths
+// are relative to the project root or absolute; paths outside the root are skipped.
+// A path that exists is added, with its parent directories and, for a directory, its
+// contents; a path that doesn't is removed, with everything under it.
+func (d *ProjectDetector) UpdatePaths(project *Project, paths ...string) {
+	hidden := LoadBazingaIgnore(project.Root)
+
+	// Clipping the lists makes the first append copy them rather than grow them in place,
+	// since prompts may be reading them
+	project.Files = slices.Clip(project.Files)
+	project.Directories = slices.Clip(project.Directories)
+
+	for _, path := range paths {
+		relPath, ok := project.relativePath(path)
+		if !ok {
+			continue
+		}
+
+		info, err := os.Stat(filepath.Join(project.Root, relPath))
+		if err != nil {
+			project.removePath(relPath)
+			continue
+		}
+		if d.excluded(project, relPath, info.IsDir(), hidden) {
+			continue
+		}
+
+		project.addParents(relPath)
+		if info.IsDir() {
+			_ = d.scanTree(project, filepath.Join(project.Root, relPath)) // Unreadable entries are skipped
+			continue
+		}
+
+		extensions := d.getRelevantExtensions(project.Type)
+		if !d.isRelevantFile(relPath, extensions) || contains(project.Files, relPath) {
+			continue
+		}
+		if len(project.Files) >= d.maxFiles {
+			project.FileLimitReached = true
+			continue
+		}
+		project.Files = append(project.Files, relPath)
+	}
+}
+
+// relativePath returns path relative to the project root, and false for the root itself
+// and paths outside it
+func (p *Project) relativePath(path string) (string, bool) {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(p.Root, path)
+	}
+	relPath, err := filepath.Rel(p.Root, path)
+	if err != nil || relPath == "." || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return relPath, true
+}
+
+// excluded reports whether a scan would leave out relPath: it is too deep, or it or one
+// of its parent directories is hidden or ignored
+func (d *ProjectDetector) excluded(project *Project, relPath string, isDir bool, hidden *IgnoreMatcher) bool {
+	components := strings.Split(relPath, string(filepath.Separator))
+	if len(components) > d.maxDepth {
+		return true
+	}
+
+	for i, name := range components {
+		path := filepath.Join(components[:i+1]...)
+		pathIsDir := isDir || i < len(components)-1
+
+		if !d.includeHidden && strings.HasPrefix(name, ".") {
+			return true
+		}
+		if d.shouldIgnore(path, pathIsDir, project.GitIgnore) || hidden.Matches(path, pathIsDir) {
+			return true
+		}
+	}
+	return false
+}
+
+// addParents adds the directories above relPath that the project doesn't list yet
+func (p *Project) addParents(relPath string) {
+	for dir := filepath.Dir(relPath); dir != "."; dir = filepath.Dir(dir) {
+		if !contains(p.Directories, dir) {
+			p.Directories = append(p.Directories, dir)
+		}
+	}
+}
+
+// removePath removes relPath and everything under it from the project. The lists are
+// copied rather than changed in place, since prompts may be reading them.
+func (p *Project) removePath(relPath string) {
+	under := func(path string) bool {
+		return path == relPath || strings.HasPrefix(path, relPath+string(filepath.Separator))
+	}
+
+	p.Files = withoutPaths(p.Files, under)
+	p.Directories = withoutPaths(p.Directories, under)
+}
+
+// withoutPaths returns the paths that don't match drop
+func withoutPaths(paths []string, drop func(string) bool) []string {
+	kept := make([]string, 0, len(paths))
+	for _, path := range paths {
+		if !drop(path) {
+			kept = append(kept, path)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,76 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestUpdatePaths(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeFile := func(path string) {
+		t.Helper()
+		fullPath := filepath.Join(tmpDir, path)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fullPath, []byte("package x\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeFile("go.mod")
+	writeFile("main.go")
+	writeFile("old/a.go")
+	writeFile("old/b.go")
+	if err := os.WriteFile(filepath.Join(tmpDir, ".gitignore"), []byte("build/\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	detector := NewDetector()
+	project, err := detector.DetectProject(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to detect project: %v", err)
+	}
+
+	// A new file in new directories, a moved directory, a deleted file and files a
+	// scan leaves out
+	writeFile("internal/api/handler.go")
+	if err := os.Rename(filepath.Join(tmpDir, "old"), filepath.Join(tmpDir, "pkg")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(tmpDir, "main.go")); err != nil {
+		t.Fatal(err)
+	}
+	writeFile("build/out.go")
+	writeFile("notes.bin")
+
+	detector.UpdatePaths(project,
+		"internal/api/handler.go",
+		"old", filepath.Join(tmpDir, "pkg"),
+		"main.go",
+		"build/out.go", "notes.bin", "../outside.go",
+	)
+
+	files := append([]string(nil), project.Files...)
+	sort.Strings(files)
+	wantFiles := []string{"go.mod", filepath.Join("internal", "api", "handler.go"), filepath.Join("pkg", "a.go"), filepath.Join("pkg", "b.go")}
+	if !reflect.DeepEqual(files, wantFiles) {
+		t.Errorf("Expected files %v, got %v", wantFiles, files)
+	}
+
+	dirs := append([]string(nil), project.Directories...)
+	sort.Strings(dirs)
+	wantDirs := []string{".", "internal", filepath.Join("internal", "api"), "pkg"}
+	if !reflect.DeepEqual(dirs, wantDirs) {
+		t.Errorf("Expected directories %v, got %v", wantDirs, dirs)
+	}
+
+	// Updating the same paths again changes nothing
+	detector.UpdatePaths(project, "internal/api/handler.go", "pkg")
+	if len(project.Files) != len(wantFiles) || len(project.Directories) != len(wantDirs) {
+		t.Errorf("Expected no duplicates, got files %v and directories %v", project.Files, project.Directories)
+	}
+}
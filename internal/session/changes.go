@@ -0,0 +1,193 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"github.com/tildaslashalef/bazinga/internal/tools"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// RecordedChange is a file change and the place in the history where it happened
+type RecordedChange struct {
+	tools.FileChange
+	HistoryIndex int // The history message that follows the change: the result of the tool that made it
+}
+
+// RecordFileChange remembers a file the assistant modified so /changes can show it, and
+// updates the project's file list when the change created, moved or deleted a file
+func (s *Session) RecordFileChange(change tools.FileChange) {
+	s.touchedMu.Lock()
+	defer s.touchedMu.Unlock()
+
+	s.fileChanges = append(s.fileChanges, RecordedChange{FileChange: change, HistoryIndex: len(s.History)})
+
+	if s.touchedFiles == nil {
+		s.touchedFiles = make(map[string]bool)
+	}
+
+	// Moves are reported as "source → destination"; both sides changed
+	for _, path := range strings.Split(change.FilePath, " → ") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		if filepath.IsAbs(path) {
+			if rel, err := filepath.Rel(s.RootPath, path); err == nil {
+				path = rel
+			}
+		}
+		s.touchedFiles[filepath.ToSlash(filepath.Clean(path))] = true
+	}
+
+	s.updateProjectPaths(change)
+}
+
+// FileChanges returns the file changes made this session, oldest first
+func (s *Session) FileChanges() []RecordedChange {
+	s.touchedMu.Lock()
+	defer s.touchedMu.Unlock()
+
+	return append([]RecordedChange(nil), s.fileChanges...)
+}
+
+// rebaseFileChanges updates the history positions of recorded changes after the messages
+// before keepFrom were replaced and the rest moved to start at newStart. Changes made in
+// the replaced messages get position -1.
+func (s *Session) rebaseFileChanges(keepFrom, newStart int) {
+	s.touchedMu.Lock()
+	defer s.touchedMu.Unlock()
+
+	for i := range s.fileChanges {
+		if s.fileChanges[i].HistoryIndex < keepFrom {
+			s.fileChanges[i].HistoryIndex = -1
+			continue
+		}
+		s.fileChanges[i].HistoryIndex += newStart - keepFrom
+	}
+}
+
+// TouchedFiles returns the files modified by the assistant this session, relative to the root
+func (s *Session) TouchedFiles() []string {
+	s.touchedMu.Lock()
+	defer s.touchedMu.Unlock()
+
+	files := make([]string, 0, len(s.touchedFiles))
+	for path := range s.touchedFiles {
+		files = append(files, path)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// GetChangesDiff returns the net git diff against HEAD for the files the assistant
+// touched this session. Untracked files are rendered as all-additions.
+func (s *Session) GetChangesDiff(ctx context.Context) (string, error) {
+	touched := s.TouchedFiles()
+	if len(touched) == 0 {
+		return "", nil
+	}
+
+	untracked, err := s.runGit(ctx, append([]string{"ls-files", "--others", "--exclude-standard", "--"}, touched...)...)
+	if err != nil {
+		return "", err
+	}
+	untrackedFiles := filterTouchedFiles(strings.Split(untracked, "\n"), touched)
+
+	var tracked []string
+	for _, path := range touched {
+		if !containsPath(untrackedFiles, path) {
+			tracked = append(tracked, path)
+		}
+	}
+
+	var result strings.Builder
+	if len(tracked) > 0 {
+		diff, err := s.runGit(ctx, append([]string{"diff", "--no-color", "HEAD", "--"}, tracked...)...)
+		if err != nil {
+			return "", err
+		}
+		result.WriteString(diff)
+	}
+
+	for _, path := range untrackedFiles {
+		content, err := os.ReadFile(filepath.Join(s.RootPath, path))
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		if result.Len() > 0 && !strings.HasSuffix(result.String(), "\n") {
+			result.WriteString("\n")
+		}
+		result.WriteString(formatUntrackedDiff(path, string(content)))
+	}
+
+	return result.String(), nil
+}
+
+// runGit runs a git command in the session root and returns its output
+func (s *Session) runGit(ctx context.Context, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = s.RootPath
+
+	output, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return "", fmt.Errorf("git %s failed: %s", args[0], strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("git %s failed: %w", args[0], err)
+	}
+	return string(output), nil
+}
+
+// filterTouchedFiles keeps only the paths that the assistant touched this session
+func filterTouchedFiles(paths []string, touched []string) []string {
+	var result []string
+	for _, path := range paths {
+		path = filepath.ToSlash(strings.TrimSpace(path))
+		if path == "" {
+			continue
+		}
+		if containsPath(touched, path) && !containsPath(result, path) {
+			result = append(result, path)
+		}
+	}
+	return result
+}
+
+// containsPath reports whether paths contains path
+func containsPath(paths []string, path string) bool {
+	for _, p := range paths {
+		if p == path {
+			return true
+		}
+	}
+	return false
+}
+
+// formatUntrackedDiff renders a new file as a unified diff of additions
+func formatUntrackedDiff(path, content string) string {
+	var result strings.Builder
+
+	lines := strings.Split(content, "\n")
+	if strings.HasSuffix(content, "\n") {
+		lines = lines[:len(lines)-1]
+	}
+
+	result.WriteString(fmt.Sprintf("diff --git a/%s b/%s\n", path, path))
+	result.WriteString("new file (untracked)\n")
+	result.WriteString("--- /dev/null\n")
+	result.WriteString(fmt.Sprintf("+++ b/%s\n", path))
+	if len(lines) == 0 || content == "" {
+		return result.String()
+	}
+
+	result.WriteString(fmt.Sprintf("@@ -0,0 +1,%d @@\n", len(lines)))
+	for _, line := range lines {
+		result.WriteString("+" + line + "\n")
+	}
+
+	return result.String()
+}
@@ -0,0 +1,93 @@
+package session
+
+import (
+	"context"
+	"github.com/tildaslashalef/bazinga/internal/llm"
+	"github.com/tildaslashalef/bazinga/internal/tools"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGetChangesDiffOnlyTouchedFiles tests that /changes is scoped to files the assistant edited
+func TestGetChangesDiffOnlyTouchedFiles(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	dir := t.TempDir()
+	runGit := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		output, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(output))
+	}
+	writeFile := func(name, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+
+	runGit("init", "-q")
+	writeFile("edited.txt", "one\n")
+	writeFile("user.txt", "one\n")
+	writeFile("reverted.txt", "one\n")
+	runGit("add", ".")
+	runGit("-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "initial")
+
+	writeFile("edited.txt", "one\ntwo\n")
+	writeFile("user.txt", "changed by the user\n")
+	writeFile("created.txt", "fresh\nfile\n")
+	writeFile("scratch.txt", "untracked, not ours\n")
+
+	session := &Session{RootPath: dir}
+	session.RecordFileChange(tools.FileChange{FilePath: "edited.txt", Operation: "edit"})
+	session.RecordFileChange(tools.FileChange{FilePath: "created.txt", Operation: "create"})
+	session.RecordFileChange(tools.FileChange{FilePath: "reverted.txt", Operation: "edit"})
+	session.RecordFileChange(tools.FileChange{FilePath: filepath.Join(dir, "edited.txt"), Operation: "write"})
+
+	assert.Equal(t, []string{"created.txt", "edited.txt", "reverted.txt"}, session.TouchedFiles())
+
+	diff, err := session.GetChangesDiff(context.Background())
+	require.NoError(t, err)
+
+	assert.Contains(t, diff, "b/edited.txt")
+	assert.Contains(t, diff, "+two")
+	assert.Contains(t, diff, "+++ b/created.txt")
+	assert.Contains(t, diff, "@@ -0,0 +1,2 @@\n+fresh\n+file\n")
+	assert.NotContains(t, diff, "user.txt", "files the assistant did not touch must be excluded")
+	assert.NotContains(t, diff, "scratch.txt", "untracked files the assistant did not create must be excluded")
+	assert.NotContains(t, diff, "reverted.txt", "files with no net change should not appear")
+}
+
+// TestRecordFileChangeMove tests that both sides of a move are recorded
+func TestRecordFileChangeMove(t *testing.T) {
+	session := &Session{RootPath: t.TempDir()}
+	session.RecordFileChange(tools.FileChange{FilePath: "old/a.go → new/a.go", Operation: "move"})
+
+	assert.Equal(t, []string{"new/a.go", "old/a.go"}, session.TouchedFiles())
+	assert.Equal(t, []string{"new/a.go"}, filterTouchedFiles([]string{"new/a.go", "other.go", ""}, session.TouchedFiles()))
+}
+
+// TestFileChangesFollowHistory tests that each change remembers the tool result it precedes,
+// also after compaction rewrote the history
+func TestFileChangesFollowHistory(t *testing.T) {
+	session := &Session{RootPath: t.TempDir()}
+	session.History = make([]llm.Message, 3)
+	session.RecordFileChange(tools.FileChange{FilePath: "a.go", Operation: "edit"})
+	session.History = append(session.History, make([]llm.Message, 4)...)
+	session.RecordFileChange(tools.FileChange{FilePath: "b.go", Operation: "edit"})
+
+	changes := session.FileChanges()
+	require.Len(t, changes, 2)
+	assert.Equal(t, 3, changes[0].HistoryIndex)
+	assert.Equal(t, 7, changes[1].HistoryIndex)
+
+	// Messages before 5 collapse into a single summary
+	session.rebaseFileChanges(5, 1)
+	changes = session.FileChanges()
+	assert.Equal(t, -1, changes[0].HistoryIndex, "a change in the collapsed messages has no position")
+	assert.Equal(t, 3, changes[1].HistoryIndex)
+}
@@ -0,0 +1,255 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/tildaslashalef/bazinga/internal/llm"
+	"github.com/tildaslashalef/bazinga/internal/loggy"
+	"strings"
+	"time"
+)
+
+const (
+	// compactKeepTurns is how many of the most recent user turns, with the replies and
+	// tool calls that follow them, compaction keeps verbatim
+	compactKeepTurns = 2
+
+	// compactSummaryTokens caps the length of the summary the model writes
+	compactSummaryTokens = 2048
+
+	// compactSummaryPrefix introduces the summary that replaces compacted history
+	compactSummaryPrefix = "Summary of the conversation so far (earlier messages were compacted):\n\n"
+
+	// autoCompactThreshold is the share of the context budget at which history is
+	// compacted automatically before a request
+	autoCompactThreshold = 0.9
+)
+
+// compactPrompt asks the model for a summary that can stand in for the history it covers
+const compactPrompt = `You are compacting the history of a coding session between a user and an AI coding assistant so the conversation can continue in a smaller context window. Write a concise summary that the assistant can rely on instead of the original messages. Include:
+- What the user asked for and any preferences or constraints they stated
+- Decisions made and the reasons for them
+- Files read, created or changed, with the key details of each change
+- Commands run and their important results, including errors and how they were resolved
+- Work still in progress or left to do
+
+Use short bullet points grouped under headings. Keep exact file paths, function names and error messages. Do not add anything that is not in the transcript.`
+
+// CompactResult describes what a compaction removed
+type CompactResult struct {
+	Collapsed    int // History messages replaced by the summary
+	Kept         int // Recent messages kept verbatim
+	TokensBefore int
+	TokensAfter  int
+}
+
+// Compact asks the model to summarize the conversation so far and replaces all but the
+// most recent turns of history with that summary. Earlier summaries are folded into
+// the new one.
+func (s *Session) Compact(ctx context.Context) (*CompactResult, error) {
+	keepFrom := compactSplit(s.History, compactKeepTurns)
+	if keepFrom < 2 {
+		return nil, fmt.Errorf("not enough history to compact")
+	}
+
+	provider := s.currentProvider()
+	if provider == nil {
+		return nil, fmt.Errorf("no provider available to summarize the conversation")
+	}
+
+	collapsed := s.History[:keepFrom]
+	transcript := compactTranscript(collapsed, s.compactTranscriptBudget(), s.EstimateTokens)
+
+	req := &llm.GenerateRequest{
+		Messages: []llm.Message{
+			{Role: "system", Content: compactPrompt},
+			{Role: "user", Content: "Summarize this transcript:\n\n" + transcript},
+		},
+		Model:       s.Model,
+		MaxTokens:   compactSummaryTokens,
+		Temperature: 0,
+	}
+	if s.config != nil && s.config.LLM.MaxTokens > 0 {
+		req.MaxTokens = min(req.MaxTokens, s.config.LLM.MaxTokens)
+	}
+
+	response, err := provider.GenerateResponse(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize the conversation: %w", err)
+	}
+	s.recordUsage(response.TokenUsage())
+
+	summary := strings.TrimSpace(response.Content)
+	if summary == "" {
+		return nil, fmt.Errorf("the model returned an empty summary")
+	}
+
+	result := &CompactResult{
+		Collapsed:    len(collapsed),
+		Kept:         len(s.History) - keepFrom,
+		TokensBefore: s.contextManager.EstimateMessagesTokens(s.History),
+	}
+
+	// A leading system message joins the system prompt, so the summary reads as
+	// background rather than as something the user just said
+	history := make([]llm.Message, 0, result.Kept+1)
+	history = append(history, llm.Message{Role: "system", Content: compactSummaryPrefix + summary})
+	history = append(history, s.History[keepFrom:]...)
+	s.History = history
+	s.rebaseFileChanges(keepFrom, 1)
+	s.UpdatedAt = time.Now()
+
+	result.TokensAfter = s.contextManager.EstimateMessagesTokens(s.History)
+
+	if err := s.Save(); err != nil {
+		loggy.Warn("Failed to save session after compaction", "session_id", s.ID, "error", err)
+	}
+
+	loggy.Info("Compacted conversation history",
+		"session_id", s.ID,
+		"collapsed", result.Collapsed,
+		"kept", result.Kept,
+		"tokens_before", result.TokensBefore,
+		"tokens_after", result.TokensAfter)
+
+	return result, nil
+}
+
+// recoverContextLength sends req once more after the provider rejected it as too long
+// for the model's context window. History is compacted first; when that is not
+// possible, the request is trimmed to half its estimated size. Trailing messages the
+// request adds after the history are sent again after the compacted history. It returns
+// the new stream and a note for the user saying what was done.
+func (s *Session) recoverContextLength(ctx context.Context, provider llm.Provider, req *llm.GenerateRequest, trailing ...llm.Message) (<-chan *llm.StreamChunk, string, error) {
+	retry := *req
+	var notice string
+
+	if result, err := s.Compact(ctx); err == nil {
+		retry.Messages, _ = s.contextManager.buildContext(s, s.History)
+		retry.Messages = append(retry.Messages, trailing...)
+		notice = fmt.Sprintf("The conversation was too long for the model's context window, so %d earlier messages were compacted into a summary and the request was sent again.", result.Collapsed)
+	} else {
+		loggy.Warn("Could not compact after a context length error; trimming instead", "error", err)
+		tokens := s.contextManager.EstimateMessagesTokens(req.Messages)
+		retry.Messages, _ = s.contextManager.fitToBudget(req.Messages, tokens/2)
+		notice = "The conversation was too long for the model's context window, so older messages and tool results were left out and the request was sent again."
+	}
+
+	loggy.Info("Retrying request after a context length error", "session_id", s.ID, "messages", len(retry.Messages))
+
+	stream, err := provider.StreamResponse(ctx, &retry)
+	if err != nil {
+		// Only one retry, so a model that rejects every size does not loop
+		return nil, "", fmt.Errorf("the request is still too long after reducing the context: %w", err)
+	}
+	return stream, notice, nil
+}
+
+// compactSplit returns the index of the first history message kept verbatim: the
+// start of the keepTurns-th most recent user turn. Splitting at a user turn keeps each
+// tool call together with its result.
+func compactSplit(history []llm.Message, keepTurns int) int {
+	latest, turns := -1, 0
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].Role != "user" {
+			continue
+		}
+		if latest == -1 {
+			latest = i
+		}
+		turns++
+		if turns == keepTurns {
+			return i
+		}
+	}
+
+	// With fewer turns than that, keep only the latest one
+	return max(latest, 0)
+}
+
+// compactTranscriptBudget returns how many tokens the transcript sent for summarizing
+// may use, leaving room for the prompt and the summary itself
+func (s *Session) compactTranscriptBudget() int {
+	budget := s.contextManager.contextBudget(s) - compactSummaryTokens - s.EstimateTokens(compactPrompt)
+	return max(budget, 1000)
+}
+
+// Per-message limits for the compaction transcript; tool output is the least useful
+// to keep word for word
+const (
+	transcriptMessageChars    = 4000
+	transcriptToolInputChars  = 300
+	transcriptToolResultChars = 600
+)
+
+// compactTranscript renders history as plain text for the model to summarize. Long
+// messages and tool results are shortened, and if the transcript is still over budget
+// its oldest lines are left out.
+func compactTranscript(messages []llm.Message, budget int, estimateTokens func(string) int) string {
+	var entries []string
+	for _, msg := range messages {
+		text := llm.ContentText(msg.Content)
+		switch msg.Role {
+		case "user":
+			entries = append(entries, "User: "+shortenText(text, transcriptMessageChars))
+		case "assistant":
+			entry := "Assistant: " + shortenText(text, transcriptMessageChars)
+			for _, call := range msg.ToolCalls {
+				input, _ := json.Marshal(call.Input)
+				entry += fmt.Sprintf("\n  → %s %s", call.Name, shortenText(string(input), transcriptToolInputChars))
+			}
+			entries = append(entries, entry)
+		case "tool":
+			label := "Result"
+			if msg.IsError {
+				label = "Error"
+			}
+			entries = append(entries, fmt.Sprintf("%s of %s: %s", label, msg.Name, shortenText(text, transcriptToolResultChars)))
+		default:
+			entries = append(entries, "Note: "+text)
+		}
+	}
+
+	total := 0
+	tokens := make([]int, len(entries))
+	for i, entry := range entries {
+		tokens[i] = estimateTokens(entry)
+		total += tokens[i]
+	}
+
+	omitted := 0
+	for omitted < len(entries)-1 && total > budget {
+		total -= tokens[omitted]
+		omitted++
+	}
+	entries = entries[omitted:]
+	if omitted > 0 {
+		entries = append([]string{fmt.Sprintf("[%d earlier messages omitted]", omitted)}, entries...)
+	}
+
+	return strings.Join(entries, "\n\n")
+}
+
+// shortenText cuts text to at most limit bytes, noting how much was left out
+func shortenText(text string, limit int) string {
+	text = strings.TrimSpace(text)
+	if len(text) <= limit {
+		return text
+	}
+	return strings.ToValidUTF8(text[:limit], "") + fmt.Sprintf(" … [%d more characters]", len(text)-limit)
+}
+
+// shouldAutoCompact reports whether history is close enough to the context budget
+// that it should be compacted before the next request
+func (cm *ContextManager) shouldAutoCompact(session *Session, history []llm.Message) bool {
+	if !cm.autoCompact || compactSplit(history, compactKeepTurns) < 2 {
+		return false
+	}
+
+	if cm.historyWindow > 0 && len(history) > cm.historyWindow {
+		history = history[len(history)-cm.historyWindow:]
+	}
+	tokens := cm.estimateMessageTokens(cm.buildEnhancedSystemMessage(session)) + cm.EstimateMessagesTokens(history)
+	return float64(tokens) >= autoCompactThreshold*float64(cm.contextBudget(session))
+}
@@ -0,0 +1,188 @@
+package session
+
+import (
+	"context"
+	"github.com/tildaslashalef/bazinga/internal/llm"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// compactTestHistory returns three user turns, the first with a tool call and its result
+func compactTestHistory(padding string) []llm.Message {
+	toolCall := llm.ToolCall{ID: "call_1", Name: "read_file", Input: map[string]interface{}{"file_path": "main.go"}}
+	return []llm.Message{
+		{Role: "user", Content: "Read main.go" + padding},
+		{Role: "assistant", Content: "Reading it", ToolCalls: []llm.ToolCall{toolCall}},
+		llm.NewToolResultMessage(&toolCall, "package main"+padding, false),
+		{Role: "assistant", Content: "It is a main package" + padding},
+		{Role: "user", Content: "Add a flag"},
+		{Role: "assistant", Content: "Added"},
+		{Role: "user", Content: "Now test it"},
+		{Role: "assistant", Content: "Tested"},
+	}
+}
+
+// TestCompactKeepsRecentTurns tests that compaction summarizes all but the latest turns
+func TestCompactKeepsRecentTurns(t *testing.T) {
+	manager, _ := setupTestSessionManager()
+	session, err := manager.CreateSession(context.Background(), &CreateOptions{Name: "Compact Test"})
+	require.NoError(t, err)
+
+	session.History = compactTestHistory(strings.Repeat(" padding", 50))
+
+	result, err := session.Compact(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, 4, result.Collapsed)
+	assert.Equal(t, 4, result.Kept)
+	assert.Less(t, result.TokensAfter, result.TokensBefore)
+
+	require.Len(t, session.History, 5)
+	assert.Equal(t, "system", session.History[0].Role)
+	assert.Equal(t, compactSummaryPrefix+"mock response", session.History[0].Content)
+	assert.Equal(t, "Add a flag", session.History[1].Content, "the last two user turns should be kept verbatim")
+
+	// Only the summary is older than the kept turns, so there is nothing to compact
+	_, err = session.Compact(context.Background())
+	assert.Error(t, err)
+
+	// Once another turn arrives, the previous summary is folded into the new one
+	session.History = append(session.History, llm.Message{Role: "user", Content: "Commit it"})
+	result, err = session.Compact(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Collapsed)
+	assert.Equal(t, "system", session.History[0].Role)
+	assert.Equal(t, "Now test it", session.History[1].Content)
+}
+
+// TestCompactTranscript tests that the transcript shortens tool output and drops the oldest entries over budget
+func TestCompactTranscript(t *testing.T) {
+	estimate := func(text string) int { return len(text) / 4 }
+	history := compactTestHistory(strings.Repeat("x", 2000))
+
+	transcript := compactTranscript(history, 100000, estimate)
+	assert.Contains(t, transcript, "User: Read main.go")
+	assert.Contains(t, transcript, `→ read_file {"file_path":"main.go"}`)
+	assert.Contains(t, transcript, "Result of read_file: package main")
+	assert.Contains(t, transcript, "more characters]", "long tool results should be shortened")
+
+	short := compactTranscript(history, 200, estimate)
+	assert.True(t, strings.HasPrefix(short, "[4 earlier messages omitted]"), short)
+	assert.Contains(t, short, "User: Now test it")
+}
+
+// TestAutoCompactNearLimit tests that building context near the budget compacts history first
+func TestAutoCompactNearLimit(t *testing.T) {
+	manager, _ := setupTestSessionManager()
+	session, err := manager.CreateSession(context.Background(), &CreateOptions{Name: "Auto Compact Test"})
+	require.NoError(t, err)
+
+	session.contextManager.SetAutoCompact(true)
+	session.History = compactTestHistory(strings.Repeat(" padding", 20))
+	_, err = session.contextManager.BuildOptimizedContext(context.Background(), session, session.History, "Now test it")
+	require.NoError(t, err)
+	assert.Len(t, session.History, 8, "history well under the budget should not be compacted")
+
+	session.contextManager.SetAutoCompact(false)
+	session.History = compactTestHistory(strings.Repeat(" padding", 1000))
+	_, err = session.contextManager.BuildOptimizedContext(context.Background(), session, session.History, "Now test it")
+	require.NoError(t, err)
+	assert.Len(t, session.History, 8, "history should be left alone when auto-compaction is off")
+
+	session.contextManager.SetAutoCompact(true)
+	messages, err := session.contextManager.BuildOptimizedContext(context.Background(), session, session.History, "Now test it")
+	require.NoError(t, err)
+
+	require.Len(t, session.History, 5)
+	assert.Equal(t, "system", session.History[0].Role)
+	assert.Equal(t, session.History[0], messages[1], "the request should use the compacted history")
+}
+
+// tooLongProvider rejects the first rejections stream requests as too long for the
+// context window
+type tooLongProvider struct {
+	mockProvider
+	rejections int
+	requests   int
+}
+
+func (p *tooLongProvider) StreamResponse(ctx context.Context, req *llm.GenerateRequest) (<-chan *llm.StreamChunk, error) {
+	p.requests++
+	if p.requests <= p.rejections {
+		return nil, llm.ClassifyError("toolong", &llm.StatusError{
+			StatusCode: 400,
+			Body:       `{"error":{"type":"invalid_request_error","message":"prompt is too long: 210000 tokens > 200000 maximum"}}`,
+		})
+	}
+	return p.mockProvider.StreamResponse(ctx, req)
+}
+
+func newTooLongSession(t *testing.T, rejections int) (*Session, *tooLongProvider) {
+	manager, llmManager := setupTestSessionManager()
+
+	provider := &tooLongProvider{mockProvider: mockProvider{name: "toolong"}, rejections: rejections}
+	require.NoError(t, llmManager.RegisterProvider("toolong", provider))
+
+	session, err := manager.CreateSession(context.Background(), &CreateOptions{Name: "Too Long"})
+	require.NoError(t, err)
+	require.NoError(t, session.SetProvider("toolong"))
+	session.History = compactTestHistory(strings.Repeat(" padding", 50))
+
+	return session, provider
+}
+
+// TestContextLengthRecovery tests that a request rejected as too long is compacted and sent once more
+func TestContextLengthRecovery(t *testing.T) {
+	session, provider := newTooLongSession(t, 1)
+
+	stream, err := session.ProcessMessageStream(context.Background(), "And document it")
+	require.NoError(t, err)
+
+	var notices []string
+	for chunk := range stream {
+		if chunk.Type == "notice" {
+			notices = append(notices, chunk.Content)
+		}
+	}
+
+	assert.Equal(t, 2, provider.requests)
+	require.Len(t, notices, 1)
+	assert.Contains(t, notices[0], "compacted")
+	assert.Equal(t, "system", session.History[0].Role, "history should start with the summary")
+}
+
+// TestContextLengthRecoveryRetriesOnce tests that a request still too long after compacting fails
+func TestContextLengthRecoveryRetriesOnce(t *testing.T) {
+	session, provider := newTooLongSession(t, 10)
+
+	_, err := session.ProcessMessageStream(context.Background(), "And document it")
+	require.Error(t, err)
+
+	assert.Equal(t, 2, provider.requests)
+	assert.Equal(t, llm.ErrorContextLength, llm.ErrorKindOf(err))
+}
+
+// TestContextLengthRecoveryFollowUp tests that a follow-up request after tool results is
+// compacted and sent once more like the first request of a turn
+func TestContextLengthRecoveryFollowUp(t *testing.T) {
+	session, provider := newTooLongSession(t, 1)
+
+	uiChan := make(chan *llm.StreamChunk, 100)
+	require.NoError(t, session.sendStreamingFollowUpRequest(context.Background(), uiChan))
+	close(uiChan)
+
+	var notices []string
+	for chunk := range uiChan {
+		if chunk.Type == "notice" {
+			notices = append(notices, chunk.Content)
+		}
+	}
+
+	assert.Equal(t, 2, provider.requests)
+	require.Len(t, notices, 1)
+	assert.Contains(t, notices[0], "compacted")
+	assert.Equal(t, "system", session.History[0].Role, "history should start with the summary")
+}
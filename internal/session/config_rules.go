@@ -0,0 +1,150 @@
+package session
+
+import (
+	"github.com/tildaslashalef/bazinga/internal/config"
+	"github.com/tildaslashalef/bazinga/internal/llm"
+	"github.com/tildaslashalef/bazinga/internal/loggy"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// shellOperator matches what chains or redirects commands in a shell command line
+var shellOperator = regexp.MustCompile("&&|\\|\\||[;|&<>`\\n]|\\$\\(")
+
+// configRule is a permission rule from security.permission_rules
+type configRule struct {
+	text       string
+	permission PermissionLevel
+	tool       string // Glob of tool names
+	pattern    string // Glob of file paths, or of the command for bash
+}
+
+// SetConfigRules replaces the rules from security.permission_rules. File patterns are
+// matched against paths relative to rootPath. Rules that don't parse are skipped;
+// config validation reports them.
+func (pm *PermissionManager) SetConfigRules(rules []string, rootPath string) {
+	var parsed []configRule
+	for _, text := range rules {
+		spec, err := config.ParsePermissionRule(text)
+		if err != nil {
+			loggy.Warn("Skipping invalid permission rule", "rule", text, "error", err)
+			continue
+		}
+		level, _ := parsePermissionLevel(spec.Action)
+		parsed = append(parsed, configRule{text: strings.TrimSpace(text), permission: level, tool: spec.Tool, pattern: spec.Pattern})
+	}
+
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	pm.configRules = parsed
+	pm.rootPath = rootPath
+}
+
+// ConfigRules returns the active rules from security.permission_rules, in the order
+// they were configured
+func (pm *PermissionManager) ConfigRules() []string {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+
+	rules := make([]string, 0, len(pm.configRules))
+	for _, rule := range pm.configRules {
+		rules = append(rules, rule.text)
+	}
+	return rules
+}
+
+// configPermission returns what the configured rules say about a tool call. Deny beats
+// prompt, which beats allow, whatever order the rules are in.
+func (pm *PermissionManager) configPermission(toolCall *llm.ToolCall) (PermissionLevel, bool) {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+
+	permission, found := PermissionAllow, false
+	for _, rule := range pm.configRules {
+		if !rule.matches(toolCall, pm.rootPath) {
+			continue
+		}
+		if !found || rule.permission < permission {
+			permission = rule.permission
+		}
+		found = true
+	}
+	return permission, found
+}
+
+// RequiresPrompt reports whether a tool call must be put to the user: a configured
+// prompt rule covers it, or it is escalated for special conditions. Remembered and batch
+// decisions don't answer such calls, and the UI asks even when the tool is low risk.
+func (pm *PermissionManager) RequiresPrompt(toolCall *llm.ToolCall) bool {
+	if toolCall == nil {
+		return false
+	}
+	if permission, ok := pm.configPermission(toolCall); ok && permission == PermissionPrompt {
+		return true
+	}
+	return pm.hasSpecialConditions(toolCall, &ToolPermissionRule{})
+}
+
+// matches reports whether a configured rule covers a tool call. An allow rule must
+// cover all of the call's files, and only covers single commands, so chaining another
+// command onto an allowed one still prompts; deny and prompt rules cover a call if they
+// match any of its files or commands.
+func (r configRule) matches(toolCall *llm.ToolCall, rootPath string) bool {
+	if ok, _ := path.Match(r.tool, toolCall.Name); !ok {
+		return false
+	}
+	if r.pattern == "" {
+		return true
+	}
+
+	if toolCall.Name == "bash" {
+		command, _ := toolCall.Input["command"].(string)
+		command = strings.TrimSpace(command)
+		if r.permission == PermissionAllow {
+			return !shellOperator.MatchString(command) && matchCommandPattern(r.pattern, command)
+		}
+		for _, part := range shellOperator.Split(command, -1) {
+			if matchCommandPattern(r.pattern, strings.TrimSpace(part)) {
+				return true
+			}
+		}
+		return false
+	}
+
+	paths := toolFilePaths(toolCall)
+	if len(paths) == 0 {
+		return false
+	}
+	for _, filePath := range paths {
+		if filepath.IsAbs(filePath) && rootPath != "" {
+			if rel, err := filepath.Rel(rootPath, filePath); err == nil && !strings.HasPrefix(rel, "..") {
+				filePath = rel
+			}
+		}
+		matched := matchFilePattern(r.pattern, filePath)
+		if matched && r.permission != PermissionAllow {
+			return true
+		}
+		if !matched && r.permission == PermissionAllow {
+			return false
+		}
+	}
+	return r.permission == PermissionAllow
+}
+
+// matchCommandPattern matches a command against a rule's pattern: with * or ? it is a
+// glob over the whole command, where * also matches spaces and slashes; otherwise the
+// leading words of the command
+func matchCommandPattern(pattern, command string) bool {
+	if !strings.ContainsAny(pattern, "*?") {
+		return command == pattern || strings.HasPrefix(command, pattern+" ")
+	}
+
+	expr := regexp.QuoteMeta(pattern)
+	expr = strings.ReplaceAll(expr, `\*`, ".*")
+	expr = strings.ReplaceAll(expr, `\?`, ".")
+	ok, _ := regexp.MatchString("^"+expr+"$", command)
+	return ok
+}
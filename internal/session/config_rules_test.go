@@ -0,0 +1,108 @@
+package session
+
+import (
+	"github.com/tildaslashalef/bazinga/internal/llm"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestConfigRules tests that rules from security.permission_rules are consulted before
+// the built-in rules, with deny taking precedence over allow
+func TestConfigRules(t *testing.T) {
+	pm := NewPermissionManager()
+	pm.SetConfigRules([]string{
+		"allow edit_file internal/**",
+		`allow bash "go test*"`,
+		"allow bash make",
+		"deny delete_file **",
+		"allow delete_file tmp/*",
+		"deny bash 'rm *'",
+		"prompt read_file *.sum",
+		"not a rule at all",
+	}, "/project")
+
+	assert.Equal(t, 7, len(pm.ConfigRules()), "invalid rules are skipped")
+
+	edit := func(path string) *llm.ToolCall {
+		return &llm.ToolCall{Name: "edit_file", Input: map[string]interface{}{"file_path": path}}
+	}
+	bash := func(command string) *llm.ToolCall {
+		return &llm.ToolCall{Name: "bash", Input: map[string]interface{}{"command": command}}
+	}
+
+	tests := []struct {
+		name string
+		call *llm.ToolCall
+		want PermissionLevel
+	}{
+		{"file under allowed tree", edit("internal/ui/model.go"), PermissionAllow},
+		{"absolute path under allowed tree", edit("/project/internal/a.go"), PermissionAllow},
+		{"file outside allowed tree", edit("cmd/main.go"), PermissionPrompt},
+		{"sensitive file still prompts", edit("internal/.env"), PermissionPrompt},
+		{"command glob", bash("go test ./internal/..."), PermissionAllow},
+		{"command prefix", bash("make build"), PermissionAllow},
+		{"command prefix is word bound", bash("makefile-lint"), PermissionPrompt},
+		{"chained command is not allowed", bash("go test ./... && git push"), PermissionPrompt},
+		{"denied command in a chain", bash("go test ./... ; rm -f go.sum"), PermissionDeny},
+		{"deny beats allow", &llm.ToolCall{Name: "delete_file", Input: map[string]interface{}{"file_path": "tmp/a.txt"}}, PermissionDeny},
+		{"prompt overrides the read-only default", &llm.ToolCall{Name: "read_file", Input: map[string]interface{}{"file_path": "go.sum"}}, PermissionPrompt},
+		{"uncovered calls keep the defaults", &llm.ToolCall{Name: "read_file", Input: map[string]interface{}{"file_path": "go.mod"}}, PermissionAllow},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, pm.getToolPermission(tt.call), tt.name)
+	}
+
+	assert.False(t, pm.CheckPermission(bash("rm -rf build")), "denied calls are refused without prompting")
+
+	pm.SetConfigRules(nil, "/project")
+	assert.Empty(t, pm.ConfigRules())
+	assert.Equal(t, PermissionPrompt, pm.getToolPermission(edit("internal/ui/model.go")), "replaced rules no longer apply")
+}
+
+// TestConfigPromptRuleForcesPrompt tests that a prompt rule on a low-risk tool asks the
+// user, through a callback that auto-approves low-risk calls as the UI's does, and that
+// remembered decisions don't answer it
+func TestConfigPromptRuleForcesPrompt(t *testing.T) {
+	pm := NewPermissionManager()
+	pm.SetConfigRules([]string{"prompt read_file *.sum"}, "/project")
+
+	prompts := 0
+	pm.SetPromptDecisionCallback(func(toolCall *llm.ToolCall) PermissionDecision {
+		if pm.GetToolRisk(toolCall) == "low" && !pm.RequiresPrompt(toolCall) {
+			return PermissionDecision{Approved: true}
+		}
+		prompts++
+		return PermissionDecision{Approved: false, Reason: "not now"}
+	})
+
+	goSum := &llm.ToolCall{Name: "read_file", Input: map[string]interface{}{"file_path": "go.sum"}}
+	goMod := &llm.ToolCall{Name: "read_file", Input: map[string]interface{}{"file_path": "go.mod"}}
+	assert.Equal(t, "low", pm.GetToolRisk(goSum))
+	assert.True(t, pm.RequiresPrompt(goSum))
+	assert.False(t, pm.RequiresPrompt(goMod))
+
+	decision := pm.CheckPermissionDecision(goSum)
+	assert.False(t, decision.Approved)
+	assert.Equal(t, "not now", decision.Reason)
+	assert.Equal(t, 1, prompts, "the prompt rule asks the user")
+
+	pm.RememberDecision(goSum, true)
+	assert.False(t, pm.CheckPermissionDecision(goSum).Approved)
+	assert.Equal(t, 2, prompts, "a remembered decision doesn't answer a prompt rule")
+
+	assert.True(t, pm.CheckPermissionDecision(goMod).Approved)
+	assert.Equal(t, 2, prompts, "calls the rule doesn't cover keep their defaults")
+}
+
+// TestMatchFilePatternDoubleStar tests ** in file patterns
+func TestMatchFilePatternDoubleStar(t *testing.T) {
+	assert.True(t, matchFilePattern("internal/**", "internal/a.go"))
+	assert.True(t, matchFilePattern("internal/**", "internal/ui/commands/help.go"))
+	assert.True(t, matchFilePattern("**/*_test.go", "internal/ui/model_test.go"))
+	assert.True(t, matchFilePattern("**/*_test.go", "main_test.go"))
+	assert.True(t, matchFilePattern("**", "anything/at/all"))
+	assert.False(t, matchFilePattern("internal/**/*.go", "cmd/main.go"))
+	assert.False(t, matchFilePattern("internal/*", "internal/ui/model.go"))
+}
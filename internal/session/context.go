@@ -1,9 +1,16 @@
 package session
 
 import (
+	"bytes"
+	"context"
+	"encoding/base64"
 	"fmt"
 	"github.com/tildaslashalef/bazinga/internal/llm"
 	"github.com/tildaslashalef/bazinga/internal/loggy"
+	"image"
+	_ "image/gif"  // Register GIF for image.DecodeConfig
+	_ "image/jpeg" // Register JPEG for image.DecodeConfig
+	_ "image/png"  // Register PNG for image.DecodeConfig
 	"path/filepath"
 	"strings"
 	"time"
@@ -13,6 +20,8 @@ import (
 type ContextManager struct {
 	maxTokens      int
 	targetTokens   int // Use 80% for safety margin
+	historyWindow  int // Most recent history messages to consider (0 = no limit)
+	autoCompact    bool
 	estimateTokens func(string) int
 }
 
@@ -25,6 +34,68 @@ func NewContextManager(maxTokens int, estimateTokens func(string) int) *ContextM
 	}
 }
 
+// SetMaxTokens sets the token budget the context is trimmed to
+func (cm *ContextManager) SetMaxTokens(maxTokens int) {
+	cm.maxTokens = maxTokens
+	cm.targetTokens = int(float64(maxTokens) * 0.8)
+}
+
+// SetHistoryWindow limits how many recent history messages are considered for context
+func (cm *ContextManager) SetHistoryWindow(messages int) {
+	if messages < 0 {
+		messages = 0
+	}
+	cm.historyWindow = messages
+}
+
+// SetAutoCompact sets whether history is summarized automatically when it nears the
+// context budget, instead of only being trimmed
+func (cm *ContextManager) SetAutoCompact(enabled bool) {
+	cm.autoCompact = enabled
+}
+
+// EstimateMessagesTokens estimates the total token count of a set of context messages
+func (cm *ContextManager) EstimateMessagesTokens(messages []llm.Message) int {
+	total := 0
+	for _, msg := range messages {
+		total += cm.estimateMessageTokens(msg)
+	}
+	return total
+}
+
+// maxImageTokens is what a large image costs; providers scale bigger images down
+const maxImageTokens = 1600
+
+// estimateMessageTokens estimates the token count of one message, counting images by
+// their dimensions rather than the length of their base64 data
+func (cm *ContextManager) estimateMessageTokens(msg llm.Message) int {
+	switch content := msg.Content.(type) {
+	case string:
+		return cm.estimateTokens(content)
+	case []llm.ContentBlock:
+		tokens := cm.estimateTokens(llm.ContentText(content))
+		for _, source := range llm.ContentImages(content) {
+			tokens += estimateImageTokens(source)
+		}
+		return tokens
+	}
+	return cm.estimateTokens(fmt.Sprintf("%v", msg.Content))
+}
+
+// estimateImageTokens approximates an image's tokens as width × height / 750, the rate
+// vision models bill at, or maxImageTokens if the image cannot be decoded
+func estimateImageTokens(source llm.ImageSource) int {
+	data, err := base64.StdEncoding.DecodeString(source.Data)
+	if err != nil {
+		return maxImageTokens
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return maxImageTokens
+	}
+	return max(1, min(maxImageTokens, config.Width*config.Height/750))
+}
+
 // FileContent represents a file with metadata for context inclusion
 type FileContent struct {
 	Path         string
@@ -55,8 +126,12 @@ type ConversationMessage struct {
 	HasToolCall bool
 }
 
-// BuildOptimizedContext builds an optimized context for the LLM request
+// BuildOptimizedContext builds the context for an LLM request: the system prompt and
+// as much recent history as fits the current model's context window. currentMessage
+// is the request being answered; callers add it to history first. With auto-compaction
+// enabled, session history that nears the budget is summarized first.
 func (cm *ContextManager) BuildOptimizedContext(
+	ctx context.Context,
 	session *Session,
 	history []llm.Message,
 	currentMessage string,
@@ -65,34 +140,152 @@ func (cm *ContextManager) BuildOptimizedContext(
 		return nil, fmt.Errorf("cannot build context with nil session")
 	}
 
-	// Calculate token budget
-	currentTokens := cm.estimateTokens(currentMessage)
-	systemMsg := cm.buildEnhancedSystemMessage(session)
-	messages := []llm.Message{systemMsg}
-
-	// Extract system content for token calculation
-	systemContent, ok := systemMsg.Content.(string)
-	if !ok {
-		systemContent = ""
-		loggy.Warn("BuildOptimizedContext: Failed to extract system message content")
+	if cm.shouldAutoCompact(session, history) {
+		if result, err := session.Compact(ctx); err != nil {
+			loggy.Warn("Automatic compaction failed; trimming history instead", "error", err)
+		} else {
+			loggy.Info("Compacted history automatically near the context limit",
+				"collapsed", result.Collapsed,
+				"tokens_saved", result.TokensBefore-result.TokensAfter)
+			history = session.History
+		}
 	}
-	currentTokens += cm.estimateTokens(systemContent)
 
-	// Add conversation history with intelligent pruning
-	historyMessages := cm.pruneConversationHistory(history, cm.targetTokens-currentTokens)
-	messages = append(messages, historyMessages...)
+	messages, tokens := cm.buildContext(session, history)
 
 	loggy.Debug("BuildOptimizedContext completed",
 		"total_messages", len(messages),
-		"system_tokens", cm.estimateTokens(systemContent),
-		"history_messages", len(historyMessages),
-		"target_tokens", cm.targetTokens,
-
-		"current_tokens", currentTokens)
+		"history_messages", len(messages)-1,
+		"current_message_tokens", cm.estimateTokens(currentMessage),
+		"context_tokens", tokens)
 
 	return messages, nil
 }
 
+// buildContext assembles the system prompt and windowed history, trimmed to the token
+// budget, and returns the messages with their estimated token count
+func (cm *ContextManager) buildContext(session *Session, history []llm.Message) ([]llm.Message, int) {
+	// Only consider the configured window of recent history
+	if cm.historyWindow > 0 && len(history) > cm.historyWindow {
+		history = history[len(history)-cm.historyWindow:]
+	}
+
+	messages := make([]llm.Message, 0, len(history)+1)
+	messages = append(messages, cm.buildEnhancedSystemMessage(session))
+	messages = append(messages, history...)
+
+	// Files the current message @mentions go with it, not into the history
+	if len(session.turnMentions) > 0 {
+		if i := latestUserTurn(messages); i > 0 {
+			messages[i].Content = withMentions(messages[i].Content, session.turnMentions)
+		}
+	}
+
+	return cm.fitToBudget(messages, cm.contextBudget(session))
+}
+
+// contextBudget returns how many tokens the context messages may use: the model's
+// context window less the response and tool definitions, with a margin for estimation
+// error. Without a known window it falls back to the configured budget.
+func (cm *ContextManager) contextBudget(session *Session) int {
+	limit := session.contextTokenLimit()
+	if limit <= 0 {
+		return cm.targetTokens
+	}
+
+	available := limit - cm.maxTokens - session.toolDefinitionTokens()
+	if available <= 0 {
+		return cm.targetTokens
+	}
+	return int(float64(available) * 0.9)
+}
+
+// trimmedToolResult replaces the content of tool results dropped to fit the budget
+const trimmedToolResult = "[%s result removed to fit the context window; run the tool again if it is still needed]"
+
+// fitToBudget trims messages until their estimate fits budget. The oldest tool results
+// are emptied first, then the oldest messages are dropped and summarized. The system
+// prompt (messages[0]) and the latest user turn are always kept, even when they alone
+// exceed the budget.
+func (cm *ContextManager) fitToBudget(messages []llm.Message, budget int) ([]llm.Message, int) {
+	tokens := make([]int, len(messages))
+	total := 0
+	for i, msg := range messages {
+		tokens[i] = cm.estimateMessageTokens(msg)
+		total += tokens[i]
+	}
+	if total <= budget {
+		return messages, total
+	}
+
+	before := total
+	latestUser := latestUserTurn(messages)
+
+	// Tool results are the bulk of long sessions and can be fetched again, so empty them
+	// oldest first; the message stays so its tool call remains answered
+	trimmedResults := 0
+	messages = append([]llm.Message(nil), messages...)
+	for i := 1; i < len(messages) && total > budget; i++ {
+		if messages[i].Role != "tool" {
+			continue
+		}
+		messages[i].Content = fmt.Sprintf(trimmedToolResult, messages[i].Name)
+		trimmed := cm.estimateMessageTokens(messages[i])
+		total += trimmed - tokens[i]
+		tokens[i] = trimmed
+		trimmedResults++
+	}
+
+	// Then drop whole messages, oldest first, up to the latest user turn
+	drop := 0
+	for i := 1; i < len(messages) && total > budget && i != latestUser; i++ {
+		total -= tokens[i]
+		drop++
+	}
+
+	if drop > 0 {
+		dropped := make([]ConversationMessage, drop)
+		for i, msg := range messages[1 : drop+1] {
+			dropped[i] = ConversationMessage{
+				Message:     msg,
+				HasToolCall: len(msg.ToolCalls) > 0 || cm.hasToolCall(llm.ContentText(msg.Content)),
+			}
+		}
+
+		kept := append([]llm.Message{messages[0]}, messages[drop+1:]...)
+		if summary := cm.createConversationSummary(dropped); summary != "" {
+			summaryMsg := llm.Message{
+				Role:    "user",
+				Content: fmt.Sprintf("Previous conversation summary: %s", summary),
+			}
+			if summaryTokens := cm.estimateMessageTokens(summaryMsg); total+summaryTokens <= budget {
+				kept = append([]llm.Message{messages[0], summaryMsg}, messages[drop+1:]...)
+				total += summaryTokens
+			}
+		}
+		messages = kept
+	}
+
+	loggy.Info("Trimmed context to fit the token budget",
+		"tokens_before", before,
+		"tokens_after", total,
+		"budget", budget,
+		"tool_results_trimmed", trimmedResults,
+		"messages_dropped", drop)
+
+	return messages, total
+}
+
+// latestUserTurn returns the index of the last message the user wrote, or -1
+func latestUserTurn(messages []llm.Message) int {
+	for i := len(messages) - 1; i > 0; i-- {
+		if messages[i].Role == "user" {
+			return i
+		}
+	}
+	return -1
+}
+
 func (cm *ContextManager) buildEnhancedSystemMessage(session *Session) llm.Message {
 	var content strings.Builder
 
@@ -112,6 +305,7 @@ CRITICAL: When users ask you to add, modify, create, or change code, you MUST us
 
 Available Tools:
 - read_file: Read file contents to understand current state
+- read_files: Read several files or line ranges in one call
 - edit_file: Replace specific text in a file (use this for modifications)
 - write_file: Create or completely overwrite a file
 - create_file: Create a new file (fails if file already exists)
@@ -126,6 +320,8 @@ Available Tools:
 	// Add session context if available
 	if session != nil {
 		cm.addSessionContext(&content, session)
+		// Last, so it applies to the default prompt and MEMORY.md templates alike
+		content.WriteString(responseLanguageDirective(session.GetResponseLanguage()))
 	}
 
 	return llm.Message{
@@ -134,6 +330,20 @@ Available Tools:
 	}
 }
 
+// responseLanguageDirective tells the model which language to answer in while
+// leaving code, identifiers and tool arguments untouched
+func responseLanguageDirective(language string) string {
+	if language == "" {
+		return ""
+	}
+	return fmt.Sprintf(`Response Language:
+- Respond to the user in %s.
+- Code, comments you write into files, identifiers, file paths, commands and tool arguments stay exactly as they would be in English; only your explanations are translated.
+- Read English source code and comments as they are; do not translate them when quoting.
+
+`, language)
+}
+
 // addSessionContext adds session-specific context to the system message
 func (cm *ContextManager) addSessionContext(content *strings.Builder, session *Session) {
 	// Add project context
@@ -199,110 +409,6 @@ func (cm *ContextManager) addSessionContext(content *strings.Builder, session *S
 	content.WriteString("Remember to use tools to read files before making changes, and always maintain the existing code structure and style.")
 }
 
-// pruneConversationHistory intelligently prunes conversation history to fit token budget
-func (cm *ContextManager) pruneConversationHistory(history []llm.Message, tokenBudget int) []llm.Message {
-	if len(history) == 0 {
-		return history
-	}
-
-	loggy.Debug("pruneConversationHistory starting",
-		"input_messages", len(history),
-		"token_budget", tokenBudget)
-
-	// Convert to conversation messages with metadata
-	convMessages := make([]ConversationMessage, len(history))
-	for i, msg := range history {
-		content, _ := msg.Content.(string)
-		convMessages[i] = ConversationMessage{
-			Message:     msg,
-			Timestamp:   time.Now().Add(-time.Duration(len(history)-i) * time.Minute),
-			TokenCount:  cm.estimateTokens(content),
-			Importance:  cm.calculateMessageImportance(msg, i, len(history)),
-			HasToolCall: cm.hasToolCall(content),
-		}
-	}
-
-	// Keep most recent messages first
-	var result []llm.Message
-	usedTokens := 0
-
-	// Add from end (most recent) first
-	for i := len(convMessages) - 1; i >= 0; i-- {
-		msg := convMessages[i]
-		if usedTokens+msg.TokenCount <= tokenBudget {
-			result = append([]llm.Message{msg.Message}, result...)
-			usedTokens += msg.TokenCount
-			loggy.Debug("pruneConversationHistory kept message",
-				"index", i,
-				"role", msg.Role,
-				"tokens", msg.TokenCount,
-				"total_used", usedTokens)
-		} else {
-			loggy.Debug("pruneConversationHistory excluded message",
-				"index", i,
-				"role", msg.Role,
-				"tokens", msg.TokenCount,
-				"would_exceed_budget", usedTokens+msg.TokenCount)
-			break
-		}
-	}
-
-	// Add summary if we have room and skipped important messages
-	if len(result) < len(history) && usedTokens < tokenBudget {
-		summary := cm.createConversationSummary(convMessages[:len(history)-len(result)])
-		if summary != "" {
-			summaryTokens := cm.estimateTokens(summary)
-			if usedTokens+summaryTokens <= tokenBudget {
-				summaryMsg := llm.Message{
-					Role:    "user",
-					Content: fmt.Sprintf("Previous conversation summary: %s", summary),
-				}
-				result = append([]llm.Message{summaryMsg}, result...)
-			}
-		}
-	}
-
-	loggy.Debug("pruneConversationHistory completed",
-		"input_messages", len(history),
-		"output_messages", len(result),
-		"used_tokens", usedTokens,
-		"budget", tokenBudget)
-
-	return result
-}
-
-// calculateMessageImportance scores how important a message is to keep
-func (cm *ContextManager) calculateMessageImportance(msg llm.Message, index, total int) float64 {
-	importance := 0.0
-	content, _ := msg.Content.(string)
-
-	// Recent messages are more important
-	recencyScore := float64(index) / float64(total)
-	importance += recencyScore * 0.4
-
-	// Assistant messages with tool calls are important
-	if msg.Role == "assistant" && cm.hasToolCall(content) {
-		importance += 0.3
-	}
-
-	// Error messages are important
-	if strings.Contains(strings.ToLower(content), "error") || strings.Contains(content, "❌") {
-		importance += 0.2
-	}
-
-	// Tool result messages are important
-	if strings.Contains(content, "<tool_result") {
-		importance += 0.3
-	}
-
-	// Longer messages might be more important
-	if len(content) > 200 {
-		importance += 0.1
-	}
-
-	return importance
-}
-
 // hasToolCall checks if a message contains tool call information
 func (cm *ContextManager) hasToolCall(content string) bool {
 	return strings.Contains(content, "✅ Tool") ||
@@ -320,7 +426,7 @@ func (cm *ContextManager) createConversationSummary(messages []ConversationMessa
 	var keyPoints []string
 
 	for _, msg := range messages {
-		content, _ := msg.Content.(string)
+		content := llm.ContentText(msg.Content)
 
 		// Extract key information
 		if msg.HasToolCall && msg.Role == "assistant" {
@@ -341,7 +447,7 @@ func (cm *ContextManager) createConversationSummary(messages []ConversationMessa
 		}
 
 		// Extract tool results
-		if strings.Contains(content, "<tool_result") {
+		if msg.Role == "tool" || strings.Contains(content, "<tool_result") {
 			keyPoints = append(keyPoints, "Tool execution results")
 		}
 	}
@@ -0,0 +1,161 @@
+package session
+
+import (
+	"context"
+	"github.com/tildaslashalef/bazinga/internal/llm"
+	"github.com/tildaslashalef/bazinga/internal/memory"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestEstimatePromptTokens tests that the estimate covers the whole prompt, not just the message
+func TestEstimatePromptTokens(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "session-estimate-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	manager, _ := setupTestSessionManager()
+	ctx := context.Background()
+	session, err := manager.CreateSession(ctx, &CreateOptions{Name: "Estimate Test"})
+	require.NoError(t, err)
+
+	message := "Explain the storage layer"
+	messageOnly := len(message) / 4
+
+	systemPrompt := session.contextManager.buildEnhancedSystemMessage(session).Content.(string)
+	withMessage := session.EstimatePromptTokens(message)
+	assert.Greater(t, withMessage, messageOnly+len(systemPrompt)/4-1, "estimate should include the system prompt")
+
+	// Adding a file to the session grows the estimate
+	testFilePath := filepath.Join(tmpDir, "a_rather_long_file_name_for_estimates.go")
+	require.NoError(t, os.WriteFile(testFilePath, []byte("package main\n"), 0o644))
+	require.NoError(t, session.AddFile(ctx, testFilePath))
+
+	withFile := session.EstimatePromptTokens(message)
+	assert.Greater(t, withFile, withMessage, "estimate should include loaded files")
+
+	// Estimating must not modify history
+	assert.Empty(t, session.History)
+}
+
+// TestHistoryWindow tests that only the configured window of history reaches the context
+func TestHistoryWindow(t *testing.T) {
+	cm := NewContextManager(100000, func(text string) int { return len(text) / 4 })
+	cm.SetHistoryWindow(2)
+
+	manager, _ := setupTestSessionManager()
+	session, err := manager.CreateSession(context.Background(), &CreateOptions{Name: "Window Test"})
+	require.NoError(t, err)
+
+	history := []llm.Message{
+		{Role: "user", Content: "first"},
+		{Role: "assistant", Content: "second"},
+		{Role: "user", Content: "third"},
+	}
+
+	messages, err := cm.BuildOptimizedContext(context.Background(), session, history, "third")
+	require.NoError(t, err)
+
+	// System prompt plus the two most recent messages
+	require.Len(t, messages, 3)
+	assert.Equal(t, "second", messages[1].Content)
+	assert.Equal(t, "third", messages[2].Content)
+}
+
+// TestResponseLanguageDirective tests that the configured response language reaches the system prompt
+func TestResponseLanguageDirective(t *testing.T) {
+	manager, _ := setupTestSessionManager()
+	manager.config.LLM.ResponseLanguage = "German"
+
+	session, err := manager.CreateSession(context.Background(), &CreateOptions{Name: "Language Test"})
+	require.NoError(t, err)
+	assert.Equal(t, "German", session.GetResponseLanguage())
+
+	prompt := session.contextManager.buildEnhancedSystemMessage(session).Content.(string)
+	assert.Contains(t, prompt, "Respond to the user in German.")
+
+	// A project system prompt keeps the directive
+	session.RootPath = t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(session.RootPath, ".bazinga"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(session.RootPath, projectSystemPromptFile), []byte("You are a specialized reviewer for this repository.\n"), 0o644))
+	prompt = session.contextManager.buildEnhancedSystemMessage(session).Content.(string)
+	assert.True(t, strings.HasPrefix(prompt, "You are a specialized reviewer"), "project prompt should be the base prompt")
+	assert.Contains(t, prompt, "Respond to the user in German.")
+
+	// /lang overrides the config and clearing removes the directive
+	session.SetResponseLanguage("ja")
+	prompt = session.contextManager.buildEnhancedSystemMessage(session).Content.(string)
+	assert.Contains(t, prompt, "Respond to the user in ja.")
+
+	session.SetResponseLanguage("")
+	prompt = session.contextManager.buildEnhancedSystemMessage(session).Content.(string)
+	assert.NotContains(t, prompt, "Response Language:")
+}
+
+// TestProjectSystemPrompt tests that .bazinga/system_prompt.md replaces the default base
+// prompt while memory and session sections are still appended
+func TestProjectSystemPrompt(t *testing.T) {
+	manager, _ := setupTestSessionManager()
+	session, err := manager.CreateSession(context.Background(), &CreateOptions{Name: "Prompt Test"})
+	require.NoError(t, err)
+
+	session.RootPath = t.TempDir()
+	session.memoryContent = &memory.MemoryContent{ProjectMemory: "You are a helper. Use tabs for indentation."}
+
+	// Without the file, MEMORY.md is project context under the default prompt
+	prompt := session.SystemPrompt()
+	assert.True(t, strings.HasPrefix(prompt, "You are Bazinga"))
+	assert.Contains(t, prompt, "## Project Context\nYou are a helper. Use tabs for indentation.")
+	assert.Empty(t, session.SystemPromptFile())
+
+	// An empty file is ignored
+	promptPath := filepath.Join(session.RootPath, projectSystemPromptFile)
+	require.NoError(t, os.MkdirAll(filepath.Dir(promptPath), 0o755))
+	require.NoError(t, os.WriteFile(promptPath, []byte("  \n"), 0o644))
+	assert.True(t, strings.HasPrefix(session.SystemPrompt(), "You are Bazinga"))
+
+	require.NoError(t, os.WriteFile(promptPath, []byte("# Reviewer\n\nOnly review, never edit.\n"), 0o644))
+	prompt = session.SystemPrompt()
+	assert.True(t, strings.HasPrefix(prompt, "# Reviewer\n\nOnly review, never edit.\n\n## Project Context"), "project prompt should be used verbatim")
+	assert.NotContains(t, prompt, "You are Bazinga")
+	assert.Equal(t, promptPath, session.SystemPromptFile())
+}
+
+// TestFitToBudget tests that tool results are trimmed before whole messages are dropped
+func TestFitToBudget(t *testing.T) {
+	cm := NewContextManager(100000, func(text string) int { return len(text) })
+	call := &llm.ToolCall{ID: "call_1", Name: "read_file"}
+	bigResult := strings.Repeat("x", 1000)
+
+	messages := []llm.Message{
+		{Role: "system", Content: "system prompt"},
+		{Role: "user", Content: "read the file"},
+		{Role: "assistant", ToolCalls: []llm.ToolCall{*call}},
+		llm.NewToolResultMessage(call, bigResult, false),
+		{Role: "assistant", Content: "done"},
+		{Role: "user", Content: "now summarize it"},
+	}
+
+	// Emptying the tool result is enough
+	fitted, tokens := cm.fitToBudget(messages, 300)
+	require.Len(t, fitted, len(messages))
+	assert.LessOrEqual(t, tokens, 300)
+	assert.Contains(t, fitted[3].Content, "read_file result removed")
+	assert.Equal(t, bigResult, messages[3].Content, "history must not be modified")
+
+	// A tighter budget drops the oldest messages but keeps the system prompt and latest user turn
+	fitted, _ = cm.fitToBudget(messages, 20)
+	assert.Equal(t, "system", fitted[0].Role)
+	assert.Equal(t, "now summarize it", fitted[len(fitted)-1].Content)
+	assert.Less(t, len(fitted), len(messages))
+
+	// Within budget, nothing changes
+	fitted, tokens = cm.fitToBudget(messages, 100000)
+	assert.Equal(t, messages, fitted)
+	assert.Equal(t, cm.EstimateMessagesTokens(messages), tokens)
+}
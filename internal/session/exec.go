@@ -3,9 +3,11 @@ package session
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/tildaslashalef/bazinga/internal/llm"
 	"github.com/tildaslashalef/bazinga/internal/loggy"
+	"github.com/tildaslashalef/bazinga/internal/tools"
 	"strings"
 	"time"
 )
@@ -13,30 +15,221 @@ import (
 // ToolCompletionNotifier is a function type for notifying about tool completion
 type ToolCompletionNotifier func(toolName string, args map[string]interface{}, result string, err error)
 
-// executeToolCallWithNotification executes a tool call and notifies about completion
-func (s *Session) executeToolCallWithNotification(ctx context.Context, toolCall *llm.ToolCall, notifier ToolCompletionNotifier) error {
+// ToolOutputNotifier is a function type for streaming output from a running tool
+type ToolOutputNotifier func(toolName string, args map[string]interface{}, line string)
+
+// newToolOutputNotifier returns a notifier that sends a running tool's output to the UI
+// channel. Lines are dropped once the channel is half full rather than waited on, which
+// keeps room for the start and completion notifications.
+func newToolOutputNotifier(uiChan chan<- *llm.StreamChunk, taskGroup string) ToolOutputNotifier {
+	return func(toolName string, args map[string]interface{}, line string) {
+		if uiChan == nil || len(uiChan) >= cap(uiChan)/2 {
+			return
+		}
+
+		outputChunk := &llm.StreamChunk{
+			Type: "tool_output",
+			ToolCompletion: &llm.ToolCompletion{
+				ToolName:  toolName,
+				Args:      args,
+				Result:    line,
+				State:     "output",
+				TaskGroup: taskGroup,
+			},
+		}
+
+		select {
+		case uiChan <- outputChunk:
+		default:
+		}
+	}
+}
+
+// maxParallelTools bounds how many read-only tool calls from one response run at once
+const maxParallelTools = 4
+
+// toolCallOutcome is what running a tool call produced, before it is recorded
+type toolCallOutcome struct {
+	result  string
+	err     error
+	message llm.Message // The tool result message for the history
+}
+
+// newToolCompletionNotifier returns a notifier that sends a finished tool's result, or
+// its error, to the UI channel
+func newToolCompletionNotifier(uiChan chan<- *llm.StreamChunk, taskGroup string) ToolCompletionNotifier {
+	return func(toolName string, args map[string]interface{}, result string, err error) {
+		if uiChan == nil {
+			return
+		}
+
+		completion := &llm.ToolCompletion{
+			ToolName:  toolName,
+			Args:      args,
+			Result:    result,
+			State:     "complete",
+			TaskGroup: taskGroup,
+		}
+		if err != nil {
+			completion.Error = err.Error()
+			completion.State = "error"
+		}
+
+		select {
+		case uiChan <- &llm.StreamChunk{Type: "tool_completion", ToolCompletion: completion}:
+			loggy.Debug("Sent tool completion to UI", "tool_name", toolName, "state", completion.State)
+		default:
+			loggy.Warn("UI channel blocked for tool completion", "tool_name", toolName)
+		}
+	}
+}
+
+// sendToolStart tells the UI a tool call is about to run
+func sendToolStart(uiChan chan<- *llm.StreamChunk, toolCall *llm.ToolCall, taskGroup string) {
+	if uiChan == nil {
+		return
+	}
+
+	startChunk := &llm.StreamChunk{
+		Type: "tool_start",
+		ToolCompletion: &llm.ToolCompletion{
+			ToolName:  toolCall.Name,
+			Args:      toolCall.Input,
+			State:     "start",
+			TaskGroup: taskGroup,
+		},
+	}
+
+	select {
+	case uiChan <- startChunk:
+		loggy.Debug("Sent tool start to UI", "tool_name", toolCall.Name, "args", toolCall.Input)
+	default:
+		loggy.Warn("UI channel blocked for tool start", "tool_name", toolCall.Name)
+	}
+}
+
+// executeToolCalls runs the tool calls from one response. Consecutive read-only calls run
+// concurrently, at most maxParallelTools at a time; any other call runs on its own once
+// the calls before it are done. UI notifications and history results keep the model's order.
+func (s *Session) executeToolCalls(ctx context.Context, toolCalls []llm.ToolCall, uiChan chan<- *llm.StreamChunk, taskGroup string) {
+	notifier := newToolCompletionNotifier(uiChan, taskGroup)
+
+	// Calls past the tool budget don't run, but still get a result so providers can pair them
+	var skipped []llm.ToolCall
+	if budget := s.toolBudget(); budget > 0 && s.turnToolCalls+len(toolCalls) > budget {
+		allowed := max(budget-s.turnToolCalls, 0)
+		toolCalls, skipped = toolCalls[:allowed], toolCalls[allowed:]
+		loggy.Warn("Tool budget reached, skipping tool calls", "budget", budget, "skipped", len(skipped))
+	}
+	s.turnToolCalls += len(toolCalls)
+	if s.permissionManager != nil {
+		s.permissionManager.BeginBatch(toolCalls)
+		defer s.permissionManager.EndBatch()
+	}
+	defer func() {
+		for i := range skipped {
+			err := fmt.Errorf("not run: reached the tool budget of %d calls for this request", s.toolBudget())
+			_ = s.recordToolOutcome(&skipped[i], toolCallOutcome{err: err, message: s.buildToolResultMessage(&skipped[i], "", err)}, nil)
+		}
+	}()
+
+	for i := 0; i < len(toolCalls); {
+		end := i + 1
+		if s.runsConcurrently(&toolCalls[i]) {
+			for end < len(toolCalls) && s.runsConcurrently(&toolCalls[end]) {
+				end++
+			}
+		}
+
+		if end-i > 1 {
+			s.executeToolCallsConcurrently(ctx, toolCalls[i:end], uiChan, taskGroup, notifier)
+			i = end
+			continue
+		}
+
+		toolCall := &toolCalls[i]
+		i = end
+		loggy.Debug("Executing tool call", "tool_name", toolCall.Name, "tool_input", toolCall.Input, "tool_id", toolCall.ID, "tool_type", toolCall.Type)
+
+		// Validate tool call before execution
+		if toolCall.Name == "" {
+			loggy.Error("Invalid tool call", "error", "empty tool name", "tool_call", toolCall)
+			continue
+		}
+
+		sendToolStart(uiChan, toolCall, taskGroup)
+		if err := s.executeToolCallWithNotification(ctx, toolCall, notifier, newToolOutputNotifier(uiChan, taskGroup)); err != nil {
+			loggy.Error("Tool execution failed", "error", err, "tool_name", toolCall.Name, "tool_input", toolCall.Input)
+		}
+	}
+}
+
+// runsConcurrently reports whether a tool call is read-only and runs without prompting,
+// so it can run alongside other such calls
+func (s *Session) runsConcurrently(toolCall *llm.ToolCall) bool {
+	if toolCall.Name == "" || s.permissionManager == nil || s.isPlannedCall(toolCall) {
+		return false
+	}
+	return s.permissionManager.IsReadOnly(toolCall)
+}
+
+// executeToolCallsConcurrently runs read-only tool calls on a bounded pool. Each call's
+// start and completion reach the UI in order, as soon as the calls before it are done.
+func (s *Session) executeToolCallsConcurrently(ctx context.Context, toolCalls []llm.ToolCall, uiChan chan<- *llm.StreamChunk, taskGroup string, notifier ToolCompletionNotifier) {
+	loggy.Debug("Executing read-only tool calls concurrently", "count", len(toolCalls), "workers", maxParallelTools)
+
+	outcomes := make([]chan toolCallOutcome, len(toolCalls))
+	workers := make(chan struct{}, maxParallelTools)
+	for i := range toolCalls {
+		outcomes[i] = make(chan toolCallOutcome, 1)
+		go func(i int) {
+			workers <- struct{}{}
+			defer func() { <-workers }()
+			outcomes[i] <- s.runToolCall(ctx, &toolCalls[i], nil)
+		}(i)
+	}
+
+	for i := range toolCalls {
+		toolCall := &toolCalls[i]
+		sendToolStart(uiChan, toolCall, taskGroup)
+		outcome := <-outcomes[i]
+		if err := s.recordToolOutcome(toolCall, outcome, notifier); err != nil {
+			loggy.Error("Tool execution failed", "error", err, "tool_name", toolCall.Name, "tool_input", toolCall.Input)
+		}
+	}
+}
+
+// executeToolCallWithNotification executes a tool call and notifies about completion.
+// Tools that run commands stream their output to outputNotifier while they run.
+func (s *Session) executeToolCallWithNotification(ctx context.Context, toolCall *llm.ToolCall, notifier ToolCompletionNotifier, outputNotifier ToolOutputNotifier) error {
 	if s.toolExecutor == nil {
 		return fmt.Errorf("tool executor not available")
 	}
 
-	loggy.Debug("executeToolCallWithNotification", "tool_name", toolCall.Name, "input", toolCall.Input, "id", toolCall.ID, "type", toolCall.Type)
+	return s.recordToolOutcome(toolCall, s.runToolCall(ctx, toolCall, outputNotifier), notifier)
+}
 
-	// Check permissions before executing the tool
-	if s.permissionManager != nil {
-		if !s.permissionManager.CheckPermission(toolCall) {
-			// Permission denied - log and return error
-			loggy.Warn("Tool execution denied by permission system", "tool_name", toolCall.Name, "risk", s.permissionManager.GetToolRisk(toolCall))
+// runToolCall checks a tool call's permission and runs it. It doesn't touch the history,
+// so read-only calls can run it concurrently.
+func (s *Session) runToolCall(ctx context.Context, toolCall *llm.ToolCall, outputNotifier ToolOutputNotifier) toolCallOutcome {
+	if s.toolExecutor == nil {
+		err := fmt.Errorf("tool executor not available")
+		return toolCallOutcome{err: err, message: s.buildToolResultMessage(toolCall, "", err)}
+	}
 
-			permissionErr := fmt.Errorf("permission denied for %s tool", toolCall.Name)
-			errorResultMsg := s.buildToolResultMessage(toolCall, "", permissionErr)
-			s.History = append(s.History, errorResultMsg)
+	loggy.Debug("runToolCall", "tool_name", toolCall.Name, "input", toolCall.Input, "id", toolCall.ID, "type", toolCall.Type)
 
-			// Notify UI about error if notifier is provided
-			if notifier != nil {
-				notifier(toolCall.Name, toolCall.Input, "", permissionErr)
-			}
+	// Check permissions before executing the tool. A planned change doesn't touch
+	// disk; it is approved as part of the batch with /apply.
+	if s.isPlannedCall(toolCall) {
+		loggy.Debug("Tool call planned, skipping permission check", "tool_name", toolCall.Name)
+	} else if s.permissionManager != nil {
+		if decision := s.permissionManager.CheckPermissionDecision(toolCall); !decision.Approved {
+			// Permission denied - log and return error
+			loggy.Warn("Tool execution denied by permission system", "tool_name", toolCall.Name, "risk", s.permissionManager.GetToolRisk(toolCall), "reason", decision.Reason)
 
-			return permissionErr
+			permissionErr := &PermissionDeniedError{Tool: toolCall.Name, Reason: decision.Reason}
+			return toolCallOutcome{err: permissionErr, message: s.buildToolResultMessage(toolCall, "", permissionErr)}
 		}
 
 		loggy.Debug("Tool execution permitted", "tool_name", toolCall.Name, "risk", s.permissionManager.GetToolRisk(toolCall))
@@ -44,35 +237,47 @@ func (s *Session) executeToolCallWithNotification(ctx context.Context, toolCall
 		loggy.Warn("Permission manager not available, executing tool without permission check", "tool_name", toolCall.Name)
 	}
 
-	result, err := s.toolExecutor.ExecuteTool(ctx, toolCall)
-	if err != nil {
-		loggy.Error("executeToolCallWithNotification failed", "tool_name", toolCall.Name, "error", err, "input", toolCall.Input)
+	if outputNotifier != nil {
+		ctx = tools.WithOutputFunc(ctx, func(line string) {
+			outputNotifier(toolCall.Name, toolCall.Input, line)
+		})
+	}
 
-		errorResultMsg := s.buildToolResultMessage(toolCall, "", err)
-		s.History = append(s.History, errorResultMsg)
+	output, err := s.toolExecutor.ExecuteToolResult(ctx, toolCall)
+	if err != nil {
+		loggy.Error("runToolCall failed", "tool_name", toolCall.Name, "error", err, "input", toolCall.Input)
+		return toolCallOutcome{err: err, message: s.buildToolResultMessage(toolCall, "", err)}
+	}
 
-		// Notify UI about error if notifier is provided
-		if notifier != nil {
-			notifier(toolCall.Name, toolCall.Input, "", err)
-		}
+	loggy.Debug("runToolCall success", "tool_name", toolCall.Name, "result_length", len(output.Text), "images", len(output.Images))
 
-		return err
+	return toolCallOutcome{
+		result:  output.Text,
+		message: s.buildToolResultMessage(toolCall, output.Text, nil, output.Images...),
 	}
+}
 
-	loggy.Debug("executeToolCallWithNotification success", "tool_name", toolCall.Name, "result_length", len(result))
+// recordToolOutcome adds a tool call's result to the history and notifies about completion
+func (s *Session) recordToolOutcome(toolCall *llm.ToolCall, outcome toolCallOutcome, notifier ToolCompletionNotifier) error {
+	if outcome.err == nil && s.isPlannedCall(toolCall) {
+		s.planToolCall(toolCall)
+	}
 
-	toolResultMsg := s.buildToolResultMessage(toolCall, result, nil)
-	s.History = append(s.History, toolResultMsg)
+	s.History = append(s.History, outcome.message)
 
-	// Notify UI about successful completion if notifier is provided
+	// Notify UI about the result if notifier is provided
 	if notifier != nil {
-		notifier(toolCall.Name, toolCall.Input, result, nil)
+		notifier(toolCall.Name, toolCall.Input, outcome.result, outcome.err)
+	}
+
+	if outcome.err != nil {
+		return outcome.err
 	}
 
 	// Log the tool execution and result to help debug tool flow issues
 	loggy.Info("Tool execution completed",
 		"tool_name", toolCall.Name,
-		"result_length", len(result),
+		"result_length", len(outcome.result),
 		"adding_to_history", "true")
 
 	// Update session timestamp
@@ -81,20 +286,35 @@ func (s *Session) executeToolCallWithNotification(ctx context.Context, toolCall
 	return nil
 }
 
-func (s *Session) buildToolResultMessage(toolCall *llm.ToolCall, result string, err error) llm.Message {
-	var content string
-	if err != nil {
-		content = fmt.Sprintf("<tool_result tool=\"%s\" tool_id=\"%s\" error=\"true\">\nError: %s\n</tool_result>",
-			toolCall.Name, toolCall.ID, err.Error())
-	} else {
-		content = fmt.Sprintf("<tool_result tool=\"%s\" tool_id=\"%s\">\n%s\n</tool_result>",
-			toolCall.Name, toolCall.ID, result)
+// PermissionDeniedError is returned for a tool call the user or the permission rules
+// refused. Reason is what the user typed when denying it, if anything.
+type PermissionDeniedError struct {
+	Tool   string
+	Reason string
+}
+
+func (e *PermissionDeniedError) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("permission denied for %s tool", e.Tool)
 	}
+	return fmt.Sprintf("permission denied for %s tool: %s", e.Tool, e.Reason)
+}
 
-	return llm.Message{
-		Role:    "user", // Use user role for tool results to maintain alternation
-		Content: content,
+// buildToolResultMessage records a tool result as a "tool" message answering toolCall;
+// each provider maps it to its native tool-result format. Images the tool returned are
+// kept as image blocks. A denial with a reason passes the user's words on, so the model
+// changes course instead of retrying the same call.
+func (s *Session) buildToolResultMessage(toolCall *llm.ToolCall, result string, err error, images ...llm.ImageSource) llm.Message {
+	var denied *PermissionDeniedError
+	if errors.As(err, &denied) && denied.Reason != "" {
+		return llm.NewToolResultMessage(toolCall, fmt.Sprintf(
+			"The user denied this %s call and said: %q\nDo not retry the same call; follow their instructions instead.",
+			denied.Tool, denied.Reason), true)
 	}
+	if err != nil {
+		return llm.NewToolResultMessage(toolCall, "Error: "+err.Error(), true)
+	}
+	return llm.NewToolResultMessage(toolCall, result, false, images...)
 }
 
 // ExecuteToolCall executes a tool call from the AI (legacy method for compatibility)
@@ -118,20 +338,17 @@ func (s *Session) ExecuteToolCall(ctx context.Context, toolCall *llm.ToolCall) e
 		loggy.Warn("Permission manager not available, executing tool without permission check", "tool_name", toolCall.Name)
 	}
 
-	result, err := s.toolExecutor.ExecuteTool(ctx, toolCall)
+	output, err := s.toolExecutor.ExecuteToolResult(ctx, toolCall)
 	if err != nil {
 		loggy.Error("ExecuteToolCall failed", "tool_name", toolCall.Name, "error", err, "input", toolCall.Input)
 		return err
 	}
 
+	result := output.Text
 	loggy.Debug("ExecuteToolCall success", "tool_name", toolCall.Name, "result_length", len(result))
 
 	// Add tool result to conversation history so AI can see it
-	// Use 'system' role since 'tool' role isn't supported by all providers (especially Bedrock)
-	toolResultMsg := llm.Message{
-		Role:    "system", // Using system instead of tool for compatibility
-		Content: fmt.Sprintf("Tool results from '%s':\n%s", toolCall.Name, result),
-	}
+	toolResultMsg := s.buildToolResultMessage(toolCall, result, nil, output.Images...)
 
 	// Log the tool execution and result to help debug tool flow issues
 	loggy.Info("Tool execution completed",
@@ -162,36 +379,27 @@ func (s *Session) getLastUserMessage() string {
 	return "analyze the information provided"
 }
 
-// countRecentToolCalls counts tool calls in recent conversation to prevent infinite loops
-func (s *Session) countRecentToolCalls() int {
-	count := 0
-	// Count tool calls in the last 10 messages
-	start := len(s.History) - 10
-	if start < 0 {
-		start = 0
-	}
-
-	for i := start; i < len(s.History); i++ {
-		msg := s.History[i]
-		// Check if message content contains tool use blocks
-		if msg.Role == "assistant" {
-			if contentBlocks, ok := msg.Content.([]llm.ContentBlock); ok {
-				for _, block := range contentBlocks {
-					if block.Type == "tool_use" {
-						count++
-					}
-				}
-			}
-		}
+// toolBudget returns how many tool calls one user message may trigger (0 = no limit)
+func (s *Session) toolBudget() int {
+	if s.config == nil {
+		return 0
 	}
-	return count
+	return s.config.Tools.MaxCallsPerRequest
+}
+
+// toolBudgetReached reports whether the current message has used its whole tool budget
+func (s *Session) toolBudgetReached() bool {
+	budget := s.toolBudget()
+	return budget > 0 && s.turnToolCalls >= budget
 }
 
+// toolBudgetInstruction replaces the follow-up instruction once the tool budget is used up
+const toolBudgetInstruction = "You have reached the limit of %d tool calls for this request and no tools are available. Summarize what you found and did so far, what is still unfinished, and what the user should do next. The original request was: %s"
+
 // sendStreamingFollowUpRequest re-invokes the LLM with streaming for UI updates
 func (s *Session) sendStreamingFollowUpRequest(ctx context.Context, uiChan chan<- *llm.StreamChunk) error {
-	// Count current tool invocation depth to prevent infinite loops
-	toolDepth := s.countRecentToolCalls()
-	maxToolDepth := 10 // Configurable limit
+	// Once the message has used its tool budget, the model answers without tools
+	budgetReached := s.toolBudgetReached()
 	loggy.Info("Sending streaming follow-up request to LLM", "provider", s.Provider, "model", s.Model)
 
 	// Find the original user request to provide proper context for follow-up
@@ -200,7 +408,21 @@ func (s *Session) sendStreamingFollowUpRequest(ctx context.Context, uiChan chan<
 	// Create a follow-up instruction that reminds the AI what to do with tool results
 	// For code review requests, be more explicit about reading multiple files
 	var followUpInstruction string
-	if isCodeReviewRequest(originalRequest) {
+	if budgetReached {
+		followUpInstruction = fmt.Sprintf(toolBudgetInstruction, s.toolBudget(), originalRequest)
+
+		if uiChan != nil {
+			noticeChunk := &llm.StreamChunk{
+				Type:    "content_block_delta",
+				Content: fmt.Sprintf("\n\nReached tool budget of %d, summarizing.\n\n", s.toolBudget()),
+			}
+			// Waited on rather than dropped: the user should see why the work stopped
+			select {
+			case uiChan <- noticeChunk:
+			case <-ctx.Done():
+			}
+		}
+	} else if isCodeReviewRequest(originalRequest) {
 		followUpInstruction = fmt.Sprintf("Continue reading relevant files to complete the comprehensive code review requested: %s. Read additional files as needed to provide thorough analysis of the codebase structure, patterns, and implementation details.", originalRequest)
 	} else {
 		followUpInstruction = fmt.Sprintf("Based on the tool results above, please complete the user's request: %s", originalRequest)
@@ -209,20 +431,25 @@ func (s *Session) sendStreamingFollowUpRequest(ctx context.Context, uiChan chan<
 	loggy.Debug("Follow-up instruction", "original_request", originalRequest, "instruction", followUpInstruction)
 
 	// Use intelligent context management for the follow-up request
-	messages, err := s.contextManager.BuildOptimizedContext(s, s.History, followUpInstruction)
+	messages, err := s.contextManager.BuildOptimizedContext(ctx, s, s.History, followUpInstruction)
 	if err != nil {
 		return fmt.Errorf("failed to build context for follow-up: %w", err)
 	}
+	var trailing []llm.Message
+	if budgetReached {
+		trailing = append(trailing, llm.Message{Role: "user", Content: followUpInstruction})
+	}
+	messages = append(messages, trailing...)
 
 	// Create LLM request with updated conversation history
-	// Re-enable tools for follow-up requests with depth control
+	// Re-enable tools for follow-up requests until the tool budget is used up
 	var tools []llm.Tool
-	if toolDepth < maxToolDepth {
+	if !budgetReached {
 		tools = s.toolExecutor.GetAvailableTools()
-		loggy.Debug("Tools enabled for follow-up", "tool_depth", toolDepth, "max_depth", maxToolDepth)
+		loggy.Debug("Tools enabled for follow-up", "tool_calls", s.turnToolCalls, "budget", s.toolBudget())
 	} else {
-		tools = []llm.Tool{} // Disable tools if we've reached max depth
-		loggy.Warn("Tools disabled due to depth limit", "tool_depth", toolDepth, "max_depth", maxToolDepth)
+		tools = []llm.Tool{} // Disable tools once the budget is used up
+		loggy.Warn("Tools disabled by tool budget", "tool_calls", s.turnToolCalls, "budget", s.toolBudget())
 	}
 
 	req := &llm.GenerateRequest{
@@ -247,6 +474,17 @@ func (s *Session) sendStreamingFollowUpRequest(ctx context.Context, uiChan chan<
 
 	// Stream the response to UI channel
 	reinvokeProviderChan, err := provider.StreamResponse(ctx, req)
+	if err != nil && llm.ErrorKindOf(err) == llm.ErrorContextLength {
+		// Tool results can push a follow-up past the context window the first request fit in
+		var notice string
+		reinvokeProviderChan, notice, err = s.recoverContextLength(ctx, provider, req, trailing...)
+		if err == nil && uiChan != nil {
+			select {
+			case uiChan <- &llm.StreamChunk{Type: "notice", Content: notice}:
+			case <-ctx.Done():
+			}
+		}
+	}
 	if err != nil {
 		return fmt.Errorf("failed to generate streaming follow-up response: %w", err)
 	}
@@ -282,10 +520,11 @@ func (s *Session) sendStreamingFollowUpRequest(ctx context.Context, uiChan chan<
 			loggy.Error("UI channel is nil, cannot send follow-up chunk", "chunk_count", chunkCount)
 		}
 
-		// Collect for history
-		if chunk.Content != "" {
+		// Collect for history, without the reasoning
+		if chunk.Content != "" && chunk.Type != "thinking" {
 			reinvokeResponse.WriteString(chunk.Content)
 		}
+		s.recordUsage(chunk.Usage)
 
 		// Handle tool calls - support streaming tool input accumulation
 		if chunk.ToolCall != nil {
@@ -347,99 +586,30 @@ func (s *Session) sendStreamingFollowUpRequest(ctx context.Context, uiChan chan<
 
 	loggy.Info("Finished processing follow-up stream", "total_chunks", chunkCount, "response_length", reinvokeResponse.Len(), "tool_calls", len(toolCalls))
 
-	// Execute tool calls if any (just like in ProcessMessageStream)
-	for _, toolCall := range toolCalls {
-		loggy.Debug("Executing follow-up tool call", "tool_name", toolCall.Name, "tool_input", toolCall.Input, "tool_id", toolCall.ID)
-
-		// Validate tool call before execution
-		if toolCall.Name == "" {
-			loggy.Error("Invalid follow-up tool call", "empty tool name", "tool_call", toolCall)
-			continue
-		}
-
-		// Create a notifier that sends tool completion to UI channel
-		notifier := func(toolName string, args map[string]interface{}, result string, err error) {
-			// Create a special chunk for tool completion notification
-			var completionChunk *llm.StreamChunk
-			if err != nil {
-				completionChunk = &llm.StreamChunk{
-					Type:    "tool_completion",
-					Content: "",
-					ToolCompletion: &llm.ToolCompletion{
-						ToolName: toolName,
-						Args:     args,
-						Result:   result,
-						Error:    err.Error(),
-						State:    "error",
-					},
-				}
-			} else {
-				completionChunk = &llm.StreamChunk{
-					Type:    "tool_completion",
-					Content: "",
-					ToolCompletion: &llm.ToolCompletion{
-						ToolName: toolName,
-						Args:     args,
-						Result:   result,
-						Error:    "",
-						State:    "complete",
-					},
-				}
-			}
-
-			// Send completion chunk to UI
-			if uiChan != nil {
-				select {
-				case uiChan <- completionChunk:
-					loggy.Debug("Sent follow-up tool completion to UI", "tool_name", toolName, "state", completionChunk.ToolCompletion.State)
-				default:
-					loggy.Warn("UI channel blocked for follow-up tool completion", "tool_name", toolName)
-				}
-			}
-		}
-
-		// Send tool start notification before execution
-		startChunk := &llm.StreamChunk{
-			Type:    "tool_start",
-			Content: "",
-			ToolCompletion: &llm.ToolCompletion{
-				ToolName: toolCall.Name,
-				Args:     toolCall.Input,
-				Result:   "",
-				Error:    "",
-				State:    "start",
-			},
-		}
-
-		if uiChan != nil {
-			select {
-			case uiChan <- startChunk:
-				loggy.Debug("Sent follow-up tool start to UI", "tool_name", toolCall.Name, "args", toolCall.Input)
-			default:
-				loggy.Warn("UI channel blocked for follow-up tool start", "tool_name", toolCall.Name)
-			}
-		}
-
-		// Execute tool with notification
-		if err := s.executeToolCallWithNotification(ctx, &toolCall, notifier); err != nil {
-			loggy.Error("Follow-up tool execution failed", "tool_name", toolCall.Name, "tool_input", toolCall.Input, "error", err)
-		}
+	// Record the tool calls before their results so providers can pair them
+	if len(toolCalls) > 0 {
+		s.History = append(s.History, llm.Message{
+			Role:      "assistant",
+			Content:   reinvokeResponse.String(),
+			ToolCalls: toolCalls,
+		})
 	}
 
+	// Execute tool calls if any (just like in ProcessMessageStream)
+	s.executeToolCalls(ctx, toolCalls, uiChan, "")
+
 	// Recursively handle follow-up requests if new tool calls were made
 	if len(toolCalls) > 0 {
 		loggy.Info("Follow-up request generated tool calls, sending recursive follow-up", "tool_count", len(toolCalls))
 
-		// Check recursion depth to prevent infinite loops
-		currentDepth := s.countRecentToolCalls()
-		if currentDepth < 10 { // Same limit as before
+		// The recursion ends once the tool budget is used up: that follow-up has no tools,
+		// and calls the model makes anyway aren't run
+		if !budgetReached {
 			if err := s.sendStreamingFollowUpRequest(ctx, uiChan); err != nil {
 				loggy.Error("Recursive follow-up request failed", "error", err)
 			}
-		} else {
-			loggy.Warn("Maximum follow-up recursion depth reached, stopping", "depth", currentDepth)
 		}
-		return nil // Don't add this response to history since we're doing another follow-up
+		return nil // Response was added with its tool calls above
 	}
 
 	// Handle empty follow-up response - send fallback
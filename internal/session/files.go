@@ -5,9 +5,11 @@ import (
 	"fmt"
 	"github.com/tildaslashalef/bazinga/internal/loggy"
 	"github.com/tildaslashalef/bazinga/internal/project"
+	"github.com/tildaslashalef/bazinga/internal/tools"
 	"os"
 	"path/filepath"
 	"slices"
+	"strings"
 	"time"
 
 	gitstatus "github.com/tildaslashalef/bazinga/internal/git"
@@ -79,12 +81,12 @@ func (s *Session) RemoveFile(ctx context.Context, filePath string) error {
 
 // ScanForMoreFiles scans the project for additional relevant files
 func (s *Session) ScanForMoreFiles(ctx context.Context) error {
-	if s.project == nil {
+	if s.GetProject() == nil {
 		return fmt.Errorf("no project detected")
 	}
 
 	// Re-scan the project to pick up any new files
-	detector := project.NewDetector()
+	detector := s.projectDetector()
 	updatedProject, err := detector.DetectProject(s.RootPath)
 	if err != nil {
 		return fmt.Errorf("failed to rescan project: %w", err)
@@ -111,8 +113,7 @@ func (s *Session) ScanForMoreFiles(ctx context.Context) error {
 		}
 	}
 
-	s.project = updatedProject
-	s.promptBuilder = project.NewPromptBuilder(updatedProject)
+	s.setProject(updatedProject)
 
 	loggy.Info("Scanned project and added new files", "count", newFilesAdded)
 	return nil
@@ -142,3 +143,75 @@ func (s *Session) GetAllFileStatuses() map[string]gitstatus.FileStatus {
 
 	return result
 }
+
+// projectDetector returns a project detector with the scan limits from the config
+func (s *Session) projectDetector() *project.ProjectDetector {
+	if s.config == nil {
+		return project.NewDetector()
+	}
+	return project.NewDetectorWithConfig(project.DetectorConfig{
+		MaxFiles:      s.config.Project.MaxFiles,
+		MaxDepth:      s.config.Project.MaxDepth,
+		IncludeHidden: s.config.Project.IncludeHidden,
+	})
+}
+
+// updateProjectPaths updates the project's files and directories for a change that may
+// have created, moved or deleted paths. Edits leave the lists as they are.
+func (s *Session) updateProjectPaths(change tools.FileChange) {
+	if change.Operation == "edit" || change.Operation == "multi_edit" {
+		return
+	}
+
+	var paths []string
+	for _, path := range strings.Split(change.FilePath, " → ") {
+		if path = strings.TrimSpace(path); path != "" {
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(s.RootPath, path)
+			}
+			paths = append(paths, path)
+		}
+	}
+
+	// The update is made on a copy, which then replaces the project readers may still hold
+	s.projectMu.Lock()
+	defer s.projectMu.Unlock()
+	if s.project == nil {
+		return
+	}
+	updated := *s.project
+	s.projectDetector().UpdatePaths(&updated, paths...)
+	s.project = &updated
+	s.promptBuilder = project.NewPromptBuilder(&updated)
+}
+
+// RescanProject detects the project again, replacing the file list kept up to date from
+// tool changes. It returns how many files were added and removed by the rescan.
+func (s *Session) RescanProject() (added, removed int, err error) {
+	updatedProject, err := s.projectDetector().DetectProject(s.RootPath)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to rescan project: %w", err)
+	}
+
+	previous := make(map[string]bool)
+	if proj := s.GetProject(); proj != nil {
+		for _, file := range proj.Files {
+			previous[file] = true
+		}
+	}
+	for _, file := range updatedProject.Files {
+		if previous[file] {
+			delete(previous, file)
+		} else {
+			added++
+		}
+	}
+
+	s.setProject(updatedProject)
+	if s.toolExecutor != nil {
+		s.toolExecutor.EnableSymbols(updatedProject.Type == project.ProjectTypeGo)
+	}
+
+	loggy.Info("Rescanned project", "files", len(updatedProject.Files), "added", added, "removed", len(previous))
+	return added, len(previous), nil
+}
@@ -3,6 +3,8 @@ package session
 import (
 	"context"
 	"github.com/tildaslashalef/bazinga/internal/git"
+	"github.com/tildaslashalef/bazinga/internal/project"
+	"github.com/tildaslashalef/bazinga/internal/tools"
 	"os"
 	"path/filepath"
 	"testing"
@@ -107,3 +109,38 @@ func TestScanForMoreFiles(t *testing.T) {
 	assert.Error(t, err, "ScanForMoreFiles should error without a project")
 	assert.Contains(t, err.Error(), "no project detected", "Error message should indicate no project was detected")
 }
+
+// TestFileChangesUpdateProject tests that created, moved and deleted files show up in the
+// project's file list without a rescan, and that /rescan picks up the rest
+func TestFileChangesUpdateProject(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "go.mod"), []byte("module x\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "old.go"), []byte("package x\n"), 0o644))
+
+	detected, err := project.NewDetector().DetectProject(root)
+	require.NoError(t, err)
+	session := &Session{RootPath: root, project: detected}
+	before := session.GetProject()
+	beforeFiles := append([]string(nil), before.Files...)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "api"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "api", "handler.go"), []byte("package api\n"), 0o644))
+	session.RecordFileChange(tools.FileChange{FilePath: "api/handler.go", Operation: "create"})
+	assert.Contains(t, session.GetProject().Files, filepath.Join("api", "handler.go"))
+	assert.Contains(t, session.GetProject().Directories, "api")
+	assert.Equal(t, beforeFiles, before.Files, "a project already handed out is not changed in place")
+
+	require.NoError(t, os.Rename(filepath.Join(root, "old.go"), filepath.Join(root, "new.go")))
+	session.RecordFileChange(tools.FileChange{FilePath: "old.go → new.go", Operation: "move"})
+	assert.ElementsMatch(t, []string{"go.mod", "new.go", filepath.Join("api", "handler.go")}, session.GetProject().Files)
+
+	// Changes made outside the tools need a rescan
+	require.NoError(t, os.Remove(filepath.Join(root, "new.go")))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "a.go"), []byte("package x\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "b.go"), []byte("package x\n"), 0o644))
+	added, removed, err := session.RescanProject()
+	require.NoError(t, err)
+	assert.Equal(t, 2, added)
+	assert.Equal(t, 1, removed)
+	assert.ElementsMatch(t, []string{"go.mod", "a.go", "b.go", filepath.Join("api", "handler.go")}, session.GetProject().Files)
+}
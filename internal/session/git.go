@@ -3,6 +3,7 @@ package session
 import (
 	"context"
 	"fmt"
+	"github.com/tildaslashalef/bazinga/internal/llm"
 	"github.com/tildaslashalef/bazinga/internal/loggy"
 	"strings"
 	"time"
@@ -50,6 +51,9 @@ func (s *Session) CommitChanges(ctx context.Context, message string) error {
 	if err := worktree.AddGlob("*"); err != nil {
 		return fmt.Errorf("failed to add changes: %w", err)
 	}
+	if err := s.runPreCommitChecks(ctx); err != nil {
+		return err
+	}
 
 	// Create commit
 	commit, err := worktree.Commit(message, &git.CommitOptions{
@@ -67,8 +71,10 @@ func (s *Session) CommitChanges(ctx context.Context, message string) error {
 	return nil
 }
 
-// CommitWithAI commits changes with an AI-generated commit message
-func (s *Session) CommitWithAI(ctx context.Context) (string, error) {
+// CommitWithAI commits all changes with an AI-generated commit message describing
+// their staged diff. The message follows Conventional Commits when conventional is set
+// or git.conventional_commits is on.
+func (s *Session) CommitWithAI(ctx context.Context, conventional bool) (string, error) {
 	if s.gitRepo == nil {
 		return "", fmt.Errorf("no git repository found")
 	}
@@ -79,21 +85,104 @@ func (s *Session) CommitWithAI(ctx context.Context) (string, error) {
 		return "", fmt.Errorf("failed to get provider for AI commit: %w", err)
 	}
 
+	// The commit stages everything, so stage first and describe the staged diff. If the
+	// checks, the message or the commit fail, the index is put back as it was.
+	index, err := s.runGit(ctx, "write-tree")
+	if err != nil {
+		return "", fmt.Errorf("failed to save the index: %w", err)
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			s.restoreIndex(strings.TrimSpace(index))
+		}
+	}()
+
+	if err := gitstatus.StageAll(s.gitRepo); err != nil {
+		return "", fmt.Errorf("failed to stage changes: %w", err)
+	}
+	if err := s.runPreCommitChecks(ctx); err != nil {
+		return "", err
+	}
+	diff, err := s.stagedDiff(ctx)
+	if err != nil {
+		return "", err
+	}
+
 	// Create commit generator
-	commitGen := gitstatus.NewCommitGenerator(provider)
+	style := gitstatus.CommitStyle{Conventional: conventional}
+	if s.config != nil {
+		style.Conventional = style.Conventional || s.config.Git.ConventionalCommits
+		style.CoAuthoredBy = s.config.Git.CoAuthoredBy
+	}
+	commitGen := gitstatus.NewCommitGenerator(provider, style)
 
 	// Generate and commit with AI message
 	authorName := s.getGitAuthorName()
 	authorEmail := s.getGitAuthorEmail()
 
-	result, err := commitGen.CommitWithAI(ctx, s.gitRepo, authorName, authorEmail)
+	result, err := commitGen.CommitWithAI(ctx, s.gitRepo, diff, authorName, authorEmail)
 	if err != nil {
 		return "", fmt.Errorf("AI commit failed: %w", err)
 	}
+	committed = true
 
 	return result, nil
 }
 
+// restoreIndex puts the index back to a tree saved with git write-tree, leaving the
+// working tree alone. It runs without the caller's context, which may be cancelled.
+func (s *Session) restoreIndex(tree string) {
+	if _, err := s.runGit(context.Background(), "read-tree", tree); err != nil {
+		loggy.Warn("Failed to restore the index after a failed commit", "tree", tree, "error", err)
+	}
+}
+
+// runPreCommitChecks runs the pre-commit checks before a commit made through go-git,
+// which skips the repository's hooks, and stages what they fixed. A failing check
+// aborts the commit with its output.
+func (s *Session) runPreCommitChecks(ctx context.Context) error {
+	if s.toolExecutor == nil || s.toolExecutor.SkipCommitHooks() {
+		return nil
+	}
+
+	output, err := s.toolExecutor.RunPreCommitChecks(ctx, true)
+	if err != nil {
+		return err
+	}
+	if output != "" {
+		loggy.Debug("Pre-commit checks passed", "output", output)
+	}
+
+	// Formatters run as hooks may have rewritten staged files
+	if err := gitstatus.StageAll(s.gitRepo); err != nil {
+		return fmt.Errorf("failed to stage changes from pre-commit checks: %w", err)
+	}
+	return nil
+}
+
+// stagedDiff returns the staged diff through the git_diff tool, so secrets in it are
+// redacted before it reaches the model like any other tool output
+func (s *Session) stagedDiff(ctx context.Context) (string, error) {
+	if s.toolExecutor == nil {
+		return s.runGit(ctx, "diff", "--cached", "--no-color")
+	}
+	if !s.toolExecutor.IsToolEnabled("git_diff") {
+		// The model can't call git_diff, but /commit still describes the diff
+		diff, err := s.runGit(ctx, "diff", "--cached", "--no-color")
+		return s.toolExecutor.RedactText(diff), err
+	}
+
+	diff, err := s.toolExecutor.ExecuteTool(ctx, &llm.ToolCall{
+		Name:  "git_diff",
+		Input: map[string]interface{}{"staged": true},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to read staged diff: %w", err)
+	}
+	return diff, nil
+}
+
 // GetBranchInfo returns current git branch information
 func (s *Session) GetBranchInfo() (string, error) {
 	if s.gitRepo == nil {
@@ -109,6 +198,27 @@ func (s *Session) GetBranchInfo() (string, error) {
 		branchInfo.Current, branchInfo.CommitHash, strings.Join(branchInfo.All, ", ")), nil
 }
 
+// GitState returns the current branch and whether the working tree has uncommitted
+// changes, including untracked files. A detached HEAD is reported as "(detached)".
+func (s *Session) GitState(ctx context.Context) (string, bool, error) {
+	output, err := s.runGit(ctx, "status", "--porcelain=v2", "--branch")
+	if err != nil {
+		return "", false, err
+	}
+
+	branch := ""
+	dirty := false
+	for _, line := range strings.Split(output, "\n") {
+		if strings.HasPrefix(line, "# branch.head ") {
+			branch = strings.TrimPrefix(line, "# branch.head ")
+		} else if line != "" && !strings.HasPrefix(line, "#") {
+			dirty = true
+		}
+	}
+
+	return branch, dirty, nil
+}
+
 // GetCommitHistory returns recent commit history
 func (s *Session) GetCommitHistory(limit int) (string, error) {
 	if s.gitRepo == nil {
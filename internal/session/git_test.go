@@ -0,0 +1,49 @@
+package session
+
+import (
+	"context"
+	"github.com/tildaslashalef/bazinga/internal/tools"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCommitWithAIRestoresIndex tests that a commit that fails after staging leaves the
+// index as it was
+func TestCommitWithAIRestoresIndex(t *testing.T) {
+	dir := t.TempDir()
+	runGit := func(args ...string) string {
+		output, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+		require.NoError(t, err, "git %v: %s", args, output)
+		return string(output)
+	}
+	runGit("init", "-q")
+	runGit("config", "user.name", "Test")
+	runGit("config", "user.email", "test@example.com")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), []byte("one\n"), 0o644))
+	runGit("add", "a.txt")
+	runGit("commit", "-q", "-m", "initial")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), []byte("two\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.txt"), []byte("new\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "c.txt"), []byte("staged\n"), 0o644))
+	runGit("add", "c.txt")
+	before := runGit("status", "--porcelain")
+
+	repo, err := git.PlainOpen(dir)
+	require.NoError(t, err)
+	toolExecutor := tools.NewToolExecutor(dir)
+	toolExecutor.SetCommitHooks("exit 1", false)
+	s := &Session{RootPath: dir, Provider: "openai", llmManager: newMockLLMManager(), gitRepo: repo, toolExecutor: toolExecutor}
+
+	_, err = s.CommitWithAI(context.Background(), false)
+	require.Error(t, err, "the failing pre-commit check aborts the commit")
+	assert.Equal(t, before, runGit("status", "--porcelain"), "the index is restored")
+	assert.Equal(t, "c.txt", strings.TrimSpace(runGit("diff", "--cached", "--name-only")))
+}
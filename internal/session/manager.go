@@ -2,6 +2,7 @@ package session
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/tildaslashalef/bazinga/internal/config"
 	"github.com/tildaslashalef/bazinga/internal/llm"
@@ -23,6 +24,10 @@ type Manager struct {
 	llmManager *llm.Manager
 	config     *config.Config
 	storage    *storage.Storage
+
+	// Set with SetConfigReloader to enable ReloadConfig
+	loadConfig  ConfigLoader
+	newProvider ProviderFactory
 }
 
 // NewManager creates a new session manager
@@ -33,11 +38,24 @@ func NewManager(llmManager *llm.Manager, cfg *config.Config) *Manager {
 		loggy.Warn("Could not initialize session storage", "error", err)
 	}
 
-	return &Manager{
+	m := &Manager{
 		llmManager: llmManager,
 		config:     cfg,
 		storage:    storage,
 	}
+	m.applyRateLimit()
+	return m
+}
+
+// applyRateLimit limits requests to all providers as configured
+func (m *Manager) applyRateLimit() {
+	if m.llmManager == nil || m.config == nil {
+		return
+	}
+	m.llmManager.SetRateLimit(llm.RateLimitConfig{
+		RequestsPerMinute: m.config.LLM.RateLimit.RequestsPerMinute,
+		TokensPerMinute:   m.config.LLM.RateLimit.TokensPerMinute,
+	})
 }
 
 // CreateSession creates a new coding session
@@ -66,35 +84,27 @@ func (m *Manager) CreateSession(ctx context.Context, opts *CreateOptions) (*Sess
 	// Initialize tool executor
 	toolExecutor := tools.NewToolExecutor(cwd)
 
-	// Initialize context manager
-	contextManager := NewContextManager(m.config.LLM.MaxTokens, func(text string) int {
-		// Simple token estimation: ~4 characters per token for English
-		return len(text) / 4
-	})
-
 	// Initialize memory system
 	logger := loggy.WithSource()
 	memorySystem := memory.NewMemorySystem(logger)
 
 	// Initialize permission manager and tool queue
-	permissionManager := NewPermissionManager()
+	permissionManager, toolQueue := m.newPermissionManager(cwd, toolExecutor)
 
-	// Create tool queue for async permission handling
-	// Note: UI channel will be set later when UI is initialized
-	toolQueue := NewToolQueue(nil)
-	permissionManager.SetToolQueue(toolQueue)
+	// Apply tool settings and register project commands from config as tools
+	m.configureToolExecutor(toolExecutor, permissionManager)
 
 	// Set provider from config, ensuring it has a valid value
 	provider := m.config.LLM.DefaultProvider
-	if provider == "" {
+	providers := m.llmManager.ListProviders()
+	if len(providers) == 0 {
+		// Start without a provider so non-LLM commands keep working
+		provider = ""
+		loggy.Warn("No providers available in session creation")
+	} else if provider == "" {
 		// Fallback to first available provider if no default is set
-		providers := m.llmManager.ListProviders()
-		if len(providers) > 0 {
-			provider = providers[0]
-			loggy.Info("No default provider set, using first available provider", "provider", provider)
-		} else {
-			loggy.Warn("No providers available in session creation")
-		}
+		provider = providers[0]
+		loggy.Info("No default provider set, using first available provider", "provider", provider)
 	}
 
 	session := &Session{
@@ -116,12 +126,16 @@ func (m *Manager) CreateSession(ctx context.Context, opts *CreateOptions) (*Sess
 		gitRepo:           gitRepo,
 		fileWatcher:       fileWatcher,
 		toolExecutor:      toolExecutor,
-		contextManager:    contextManager,
 		memorySystem:      memorySystem,
 		permissionManager: permissionManager,
 		toolQueue:         toolQueue,
+		responseLanguage:  m.config.LLM.ResponseLanguage,
 	}
 
+	// Initialize context manager
+	session.contextManager = m.newContextManager(session)
+	session.syncContextTokenLimit()
+
 	// Load memory content
 	if memContent, err := memorySystem.LoadMemory(ctx, cwd); err == nil {
 		session.memoryContent = memContent
@@ -133,14 +147,14 @@ func (m *Manager) CreateSession(ctx context.Context, opts *CreateOptions) (*Sess
 	}
 
 	// Detect project structure
-	detector := project.NewDetector()
+	detector := session.projectDetector()
 	detectedProject, err := detector.DetectProject(cwd)
 	if err != nil {
 		// Continue without project detection on error
 		loggy.Warn("Could not detect project structure", "error", err)
 	} else {
-		session.project = detectedProject
-		session.promptBuilder = project.NewPromptBuilder(detectedProject)
+		session.setProject(detectedProject)
+		toolExecutor.EnableSymbols(detectedProject.Type == project.ProjectTypeGo)
 
 		// Enhanced auto-detection - always load key files
 		if opts.AutoDetectFiles || len(opts.Files) == 0 {
@@ -215,6 +229,8 @@ func (m *Manager) LoadSession(ctx context.Context, sessionID string) (*Session,
 		manager:      m,
 		llmManager:   m.llmManager,
 		config:       m.config,
+
+		responseLanguage: m.config.LLM.ResponseLanguage,
 	}
 
 	// Try to open git repository
@@ -231,13 +247,23 @@ func (m *Manager) LoadSession(ctx context.Context, sessionID string) (*Session,
 		}
 	}
 
+	// Carry the session cost over from earlier runs
+	session.restoreUsage(serializable.Usage)
+
 	// Initialize tool executor
 	session.toolExecutor = tools.NewToolExecutor(session.RootPath)
 
+	// Initialize permission manager with the decisions remembered in earlier runs
+	session.permissionManager, session.toolQueue = m.newPermissionManager(session.RootPath, session.toolExecutor)
+	if restored := session.permissionManager.RestorePermissions(serializable.Permissions); restored > 0 {
+		loggy.Info("Restored remembered permission decisions", "session_id", session.ID, "count", restored)
+	}
+
+	m.configureToolExecutor(session.toolExecutor, session.permissionManager)
+
 	// Initialize context manager
-	session.contextManager = NewContextManager(m.config.LLM.MaxTokens, func(text string) int {
-		return len(text) / 4
-	})
+	session.contextManager = m.newContextManager(session)
+	session.syncContextTokenLimit()
 
 	// Initialize memory system
 	logger := loggy.WithSource()
@@ -254,10 +280,10 @@ func (m *Manager) LoadSession(ctx context.Context, sessionID string) (*Session,
 	}
 
 	// Detect project structure
-	detector := project.NewDetector()
+	detector := session.projectDetector()
 	if detectedProject, err := detector.DetectProject(session.RootPath); err == nil {
-		session.project = detectedProject
-		session.promptBuilder = project.NewPromptBuilder(detectedProject)
+		session.setProject(detectedProject)
+		session.toolExecutor.EnableSymbols(detectedProject.Type == project.ProjectTypeGo)
 	}
 
 	return session, nil
@@ -305,8 +331,96 @@ func (m *Manager) restoreHistory(historyMaps []map[string]interface{}) []llm.Mes
 			msg.Content = content
 		}
 
+		msg.Name, _ = msgMap["name"].(string)
+		msg.ToolCallID, _ = msgMap["tool_call_id"].(string)
+		msg.IsError, _ = msgMap["is_error"].(bool)
+		if calls, ok := msgMap["tool_calls"]; ok {
+			// Stored as JSON, so decode through it to get typed tool calls back
+			if data, err := json.Marshal(calls); err == nil {
+				if err := json.Unmarshal(data, &msg.ToolCalls); err != nil {
+					loggy.Warn("Failed to restore tool calls", "error", err)
+				}
+			}
+		}
+
 		history = append(history, msg)
 	}
 
 	return history
 }
+
+// newPermissionManager creates a session's permission manager, with the rules from
+// config and the session's custom tools, and the tool queue it uses for async permission
+// handling. The queue's UI channel is set later when the UI is initialized.
+func (m *Manager) newPermissionManager(rootPath string, toolExecutor *tools.ToolExecutor) (*PermissionManager, *ToolQueue) {
+	permissionManager := NewPermissionManager()
+	permissionManager.SetRememberTTL(time.Duration(m.config.Security.RememberTTLHours) * time.Hour)
+	permissionManager.SetConfigRules(m.config.Security.PermissionRules, rootPath)
+	permissionManager.SetToolArgs(toolExecutor.CustomToolArgs)
+
+	toolQueue := NewToolQueue(nil)
+	permissionManager.SetToolQueue(toolQueue)
+
+	return permissionManager, toolQueue
+}
+
+// newContextManager creates a context manager that estimates tokens with the session's
+// current provider
+func (m *Manager) newContextManager(session *Session) *ContextManager {
+	contextManager := NewContextManager(m.config.LLM.MaxTokens, session.EstimateTokens)
+	contextManager.SetHistoryWindow(m.config.LLM.HistoryWindow)
+	contextManager.SetAutoCompact(m.config.LLM.AutoCompact)
+	return contextManager
+}
+
+// configureToolExecutor applies tool settings from config and registers config-defined
+// tools with the executor and their risk with the permission manager. Invalid custom
+// tool definitions are logged and skipped as a whole.
+func (m *Manager) configureToolExecutor(toolExecutor *tools.ToolExecutor, permissionManager *PermissionManager) {
+	toolExecutor.SetLongLineLimits(m.config.Tools.LongLineThreshold, m.config.Tools.LongLinePreview)
+	toolExecutor.SetAllowedPaths(m.config.Tools.AllowedPaths)
+	toolExecutor.SetToolFilter(m.config.Tools.EnabledTools, m.config.Tools.DisabledTools)
+	toolExecutor.SetBashTimeout(time.Duration(m.config.Tools.BashTimeout) * time.Second)
+	toolExecutor.SetMaxReadBytes(m.config.Tools.MaxReadBytes)
+	toolExecutor.SetCommitHooks(m.config.Git.PreCommitCommand, m.config.Git.SkipHooks)
+	toolExecutor.SetWebFetchLimits(m.config.Tools.WebFetchMaxBytes, time.Duration(m.config.Tools.WebFetchTimeout)*time.Second)
+	toolExecutor.SetWebFetchCache(time.Duration(m.config.Tools.WebFetchCacheTTL)*time.Second, m.config.Tools.WebFetchCacheSize)
+
+	patterns := make([]tools.SecretPattern, 0, len(m.config.Security.RedactPatterns))
+	for _, pattern := range m.config.Security.RedactPatterns {
+		patterns = append(patterns, tools.SecretPattern{Name: pattern.Name, Pattern: pattern.Pattern})
+	}
+	if err := toolExecutor.SetRedaction(m.config.Security.RedactSecrets, patterns); err != nil {
+		// Keep the built-in patterns rather than sending secrets unredacted
+		loggy.Warn("Could not load secret patterns", "error", err)
+		_ = toolExecutor.SetRedaction(true, nil)
+	}
+
+	if len(m.config.Tools.Custom) == 0 {
+		// Drops tools an earlier configuration defined
+		_ = toolExecutor.RegisterCustomTools(nil)
+		return
+	}
+
+	defs := make([]tools.CustomTool, 0, len(m.config.Tools.Custom))
+	for _, custom := range m.config.Tools.Custom {
+		defs = append(defs, tools.CustomTool{
+			Name:        custom.Name,
+			Description: custom.Description,
+			Command:     custom.Command,
+			Parameters:  custom.Parameters,
+			Timeout:     time.Duration(custom.Timeout) * time.Second,
+		})
+	}
+
+	if err := toolExecutor.RegisterCustomTools(defs); err != nil {
+		loggy.Warn("Could not register custom tools", "error", err)
+		return
+	}
+
+	if permissionManager != nil {
+		for _, custom := range m.config.Tools.Custom {
+			permissionManager.RegisterToolRisk(custom.Name, custom.Risk)
+		}
+	}
+}
@@ -2,7 +2,9 @@ package session
 
 import (
 	"context"
+	"errors"
 	"github.com/tildaslashalef/bazinga/internal/config"
+	"github.com/tildaslashalef/bazinga/internal/llm"
 	"os"
 	"testing"
 
@@ -118,6 +120,57 @@ func TestLoadSession(t *testing.T) {
 	assert.NotNil(t, loadedSession.fileWatcher)
 }
 
+// TestLoadSessionKeepsToolPairing tests that tool calls and results survive a save and load
+func TestLoadSessionKeepsToolPairing(t *testing.T) {
+	manager, _ := setupTestSessionManager()
+	ctx := context.Background()
+
+	session, err := manager.CreateSession(ctx, &CreateOptions{Name: "Tool Pairing Session"})
+	require.NoError(t, err)
+
+	call := llm.ToolCall{ID: "toolu_1", Name: "read_file", Input: map[string]interface{}{"file_path": "main.go"}}
+	session.History = append(session.History,
+		llm.Message{Role: "assistant", Content: "Reading it", ToolCalls: []llm.ToolCall{call}},
+		session.buildToolResultMessage(&call, "", errors.New("permission denied")),
+	)
+	require.NoError(t, manager.SaveSession(session))
+
+	loaded, err := manager.LoadSession(ctx, session.ID)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, len(loaded.History), 2)
+
+	assistant := loaded.History[len(loaded.History)-2]
+	require.Len(t, assistant.ToolCalls, 1)
+	assert.Equal(t, "toolu_1", assistant.ToolCalls[0].ID)
+	assert.Equal(t, "main.go", assistant.ToolCalls[0].Input["file_path"])
+
+	result := loaded.History[len(loaded.History)-1]
+	assert.Equal(t, "tool", result.Role)
+	assert.Equal(t, "toolu_1", result.ToolCallID)
+	assert.Equal(t, "read_file", result.Name)
+	assert.True(t, result.IsError)
+	assert.Equal(t, "Error: permission denied", result.Content)
+}
+
+// TestLoadSessionRestoresPermissions tests that remembered permission decisions survive a save and load
+func TestLoadSessionRestoresPermissions(t *testing.T) {
+	manager, _ := setupTestSessionManager()
+	manager.config.Security.RememberTTLHours = 24
+	ctx := context.Background()
+
+	session, err := manager.CreateSession(ctx, &CreateOptions{Name: "Permission Session"})
+	require.NoError(t, err)
+
+	goTest := &llm.ToolCall{Name: "bash", Input: map[string]interface{}{"command": "go test ./..."}}
+	session.GetPermissionManager().RememberDecision(goTest, true)
+	require.NoError(t, manager.SaveSession(session))
+
+	loaded, err := manager.LoadSession(ctx, session.ID)
+	require.NoError(t, err)
+	require.NotNil(t, loaded.GetPermissionManager())
+	assert.True(t, loaded.GetPermissionManager().CheckPermission(goTest))
+}
+
 // TestFindSessionsByRootPath tests finding sessions by root path
 func TestFindSessionsByRootPath(t *testing.T) {
 	manager, _ := setupTestSessionManager()
@@ -0,0 +1,158 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"github.com/tildaslashalef/bazinga/internal/llm"
+	"github.com/tildaslashalef/bazinga/internal/loggy"
+	"github.com/tildaslashalef/bazinga/internal/tools"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// mentionPattern finds @path mentions: an @ at the start of the message or after
+// whitespace or an opening bracket or quote, so e-mail addresses are left alone
+var mentionPattern = regexp.MustCompile("(?:^|[\\s(\\[{'\"`])@([^\\s@]+)")
+
+// mentionTrailing are characters that end the sentence around a mention rather than
+// its path
+const mentionTrailing = ".,;:!?)]}'\"`"
+
+// FileMention is a file the user referenced in a message with @path
+type FileMention struct {
+	Token   string // The mention as written, without the @
+	Path    string // The file it resolved to, relative to the root
+	Content string // The file as read_file returns it
+}
+
+// parseMentions returns the paths @mentioned in message, in order and without
+// duplicates
+func parseMentions(message string) []string {
+	var tokens []string
+	seen := make(map[string]bool)
+	for _, match := range mentionPattern.FindAllStringSubmatch(message, -1) {
+		token := strings.TrimRight(match[1], mentionTrailing)
+		if token == "" || seen[token] {
+			continue
+		}
+		seen[token] = true
+		tokens = append(tokens, token)
+	}
+	return tokens
+}
+
+// resolveMention finds the file a mention refers to, relative to the root: the path
+// itself when it names a file, otherwise the closest project file. Mentions with a
+// slash match along the whole path, others only match file names.
+func (s *Session) resolveMention(token string) (string, bool) {
+	path := token
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(s.RootPath, path)
+	}
+	if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+		rel, err := filepath.Rel(s.RootPath, path)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			return path, true
+		}
+		return rel, true
+	}
+
+	proj := s.GetProject()
+	if proj == nil {
+		return "", false
+	}
+
+	query := strings.ToLower(filepath.ToSlash(token))
+	best, bestRank := "", 0
+	for _, file := range proj.Files {
+		rank := mentionRank(strings.ToLower(filepath.ToSlash(file)), query)
+		if rank == 0 {
+			continue
+		}
+		if best == "" || rank < bestRank || (rank == bestRank && len(file) < len(best)) {
+			best, bestRank = file, rank
+		}
+	}
+	return best, best != ""
+}
+
+// mentionRank scores how well a project file matches a mention, both lowercased with
+// forward slashes; lower is better and 0 is no match
+func mentionRank(file, query string) int {
+	base := file[strings.LastIndex(file, "/")+1:]
+	switch {
+	case strings.HasSuffix("/"+file, "/"+query):
+		return 1
+	case strings.HasPrefix(base, query):
+		return 2
+	case strings.Contains(query, "/") && tools.FuzzyMatch(file, query):
+		return 3
+	case !strings.Contains(query, "/") && tools.FuzzyMatch(base, query):
+		return 3
+	}
+	return 0
+}
+
+// loadMentions reads the files message @mentions. Mentions that match no file or
+// can't be read are returned as warnings rather than failing the message.
+func (s *Session) loadMentions(ctx context.Context, message string) ([]FileMention, []string) {
+	tokens := parseMentions(message)
+	if len(tokens) == 0 || s.toolExecutor == nil {
+		return nil, nil
+	}
+
+	var mentions []FileMention
+	var warnings []string
+	loaded := make(map[string]bool)
+	for _, token := range tokens {
+		path, ok := s.resolveMention(token)
+		if !ok {
+			warnings = append(warnings, fmt.Sprintf("No file matches @%s; the message was sent without it", token))
+			continue
+		}
+		if loaded[path] {
+			continue
+		}
+
+		abs := path
+		if !filepath.IsAbs(abs) {
+			abs = filepath.Join(s.RootPath, path)
+		}
+		// read_file applies the usual path restrictions, size limits and redaction
+		content, err := s.toolExecutor.ExecuteTool(ctx, &llm.ToolCall{
+			Name:  "read_file",
+			Input: map[string]interface{}{"file_path": abs},
+		})
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("Could not read @%s: %v", token, err))
+			continue
+		}
+
+		loaded[path] = true
+		mentions = append(mentions, FileMention{Token: token, Path: path, Content: content})
+	}
+
+	if len(mentions) > 0 || len(warnings) > 0 {
+		loggy.Debug("Resolved file mentions", "mentions", len(tokens), "loaded", len(mentions), "unresolved", len(warnings))
+	}
+	return mentions, warnings
+}
+
+// withMentions adds the mentioned files ahead of a user message's content
+func withMentions(content interface{}, mentions []FileMention) interface{} {
+	var files strings.Builder
+	files.WriteString("Files mentioned in this message:\n")
+	for _, mention := range mentions {
+		fmt.Fprintf(&files, "\n<file path=%q>\n%s\n</file>\n", mention.Path, strings.TrimRight(mention.Content, "\n"))
+	}
+
+	switch c := content.(type) {
+	case string:
+		return files.String() + "\n" + c
+	case []llm.ContentBlock:
+		return append([]llm.ContentBlock{{Type: "text", Text: files.String()}}, c...)
+	}
+	return content
+}
@@ -0,0 +1,90 @@
+package session
+
+import (
+	"context"
+	"github.com/tildaslashalef/bazinga/internal/llm"
+	"github.com/tildaslashalef/bazinga/internal/project"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParseMentions tests that @mentions are found after whitespace, without the
+// punctuation around them
+func TestParseMentions(t *testing.T) {
+	assert.Equal(t,
+		[]string{"main.go", "internal/ui/model.go"},
+		parseMentions("@main.go calls into (@internal/ui/model.go), see @main.go. Mail me at dev@example.com"))
+	assert.Empty(t, parseMentions("no mentions @ all"))
+}
+
+// TestResolveMention tests that mentions resolve to an existing path first, then to the
+// closest project file
+func TestResolveMention(t *testing.T) {
+	dir := t.TempDir()
+	for _, file := range []string{"main.go", "internal/ui/model.go", "internal/model/user.go"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, filepath.Dir(file)), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, file), []byte("package x\n"), 0o644))
+	}
+	session := &Session{RootPath: dir, project: &project.Project{
+		Files: []string{"main.go", "internal/model/user.go", "internal/ui/model.go"},
+	}}
+
+	tests := []struct {
+		token string
+		want  string
+	}{
+		{"internal/ui/model.go", "internal/ui/model.go"},
+		{"model.go", "internal/ui/model.go"},
+		{"ui/model.go", "internal/ui/model.go"},
+		{"usr", "internal/model/user.go"},
+		{"model/usr", "internal/model/user.go"},
+	}
+	for _, tt := range tests {
+		got, ok := session.resolveMention(tt.token)
+		assert.True(t, ok, tt.token)
+		assert.Equal(t, tt.want, got, tt.token)
+	}
+
+	_, ok := session.resolveMention("internal")
+	assert.False(t, ok, "directories are not files")
+	_, ok = session.resolveMention("nothing.rs")
+	assert.False(t, ok)
+}
+
+// TestMentionedFilesSentWithTurn tests that mentioned files go with their message only,
+// leaving the history as the user wrote it, and that unknown mentions become notices
+func TestMentionedFilesSentWithTurn(t *testing.T) {
+	session, recorder := newRecordingSession(t)
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("remember the milk"), 0o644))
+	session.RootPath = dir
+
+	stream, err := session.ProcessMessageStream(context.Background(), "Summarize @notes.txt and @missing.go")
+	require.NoError(t, err)
+	var notices []string
+	for chunk := range stream {
+		if chunk.Type == "notice" {
+			notices = append(notices, chunk.Content)
+		}
+	}
+
+	require.Len(t, notices, 1)
+	assert.Contains(t, notices[0], "@missing.go")
+
+	last := recorder.lastRequest.Messages[len(recorder.lastRequest.Messages)-1]
+	assert.Contains(t, llm.ContentText(last.Content), "remember the milk")
+	assert.Contains(t, llm.ContentText(last.Content), "Summarize @notes.txt")
+	assert.Equal(t, "Summarize @notes.txt and @missing.go", session.History[0].Content, "history keeps the message as written")
+
+	stream, err = session.ProcessMessageStream(context.Background(), "Thanks")
+	require.NoError(t, err)
+	drainStream(stream)
+
+	for _, msg := range recorder.lastRequest.Messages {
+		assert.NotContains(t, llm.ContentText(msg.Content), "remember the milk", "mentioned files are sent with their turn only")
+	}
+}
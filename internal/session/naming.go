@@ -0,0 +1,163 @@
+package session
+
+import (
+	"context"
+	"github.com/tildaslashalef/bazinga/internal/llm"
+	"github.com/tildaslashalef/bazinga/internal/loggy"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	// sessionNameMaxLen caps the length of generated session names
+	sessionNameMaxLen = 40
+	// sessionNameWords is how many words the offline name keeps from the first message
+	sessionNameWords = 5
+	// sessionNameTimeout bounds the naming request so a slow provider can't hold it up
+	sessionNameTimeout = 20 * time.Second
+	// sessionNameInputLimit is how much of the first message is sent for naming
+	sessionNameInputLimit = 2000
+)
+
+// sessionNamePrompt asks for a short slug naming the session
+const sessionNamePrompt = `Name this coding session after the user's first message. Reply with only a lowercase slug of 2 to 5 words joined by hyphens, like a git branch name (for example fix-login-redirect or add-csv-export). No quotes, no explanation.`
+
+// nameStopWords are left out of names made from the first message
+var nameStopWords = map[string]bool{
+	"a": true, "an": true, "the": true, "please": true, "can": true, "could": true, "would": true,
+	"you": true, "i": true, "me": true, "my": true, "we": true, "our": true, "to": true, "of": true,
+	"for": true, "in": true, "on": true, "at": true, "and": true, "or": true, "is": true, "are": true,
+	"it": true, "this": true, "that": true, "with": true, "what": true, "how": true, "why": true,
+	"does": true, "do": true, "be": true, "let": true, "lets": true, "s": true, "some": true,
+}
+
+// nameSource returns the first user message of a session that has no name yet, and
+// whether there is one to name it after
+func (s *Session) nameSource() (string, bool) {
+	if s.Name != "" {
+		return "", false
+	}
+	for _, msg := range s.History {
+		if msg.Role == "user" {
+			first := strings.TrimSpace(llm.ContentText(msg.Content))
+			return first, first != ""
+		}
+	}
+	return "", false
+}
+
+// ensureName gives an unnamed session a short name from its first message, so saved
+// sessions can be told apart. It asks the provider's cheapest model and falls back to
+// the message's leading words when that fails. A name already set is never replaced.
+// It runs after the answer is shown, while the next message may already be on its way,
+// so it is given the first message rather than reading the history.
+func (s *Session) ensureName(first string) {
+	ctx, cancel := context.WithTimeout(context.Background(), sessionNameTimeout)
+	defer cancel()
+
+	name, err := s.generateName(ctx, first)
+	if err != nil {
+		loggy.Debug("Naming the session locally", "session_id", s.ID, "error", err)
+	}
+	if name == "" {
+		name = nameFromMessage(first)
+	}
+	if name == "" {
+		return
+	}
+
+	s.nameMu.Lock()
+	defer s.nameMu.Unlock()
+	if s.Name != "" {
+		return // Named by the user while the name was being generated
+	}
+	s.Name = name
+	if err := s.Save(); err != nil {
+		loggy.Warn("Failed to save session name", "session_id", s.ID, "error", err)
+	}
+	loggy.Info("Named session", "session_id", s.ID, "name", name)
+}
+
+// generateName asks the cheapest model of the current provider to name the session
+func (s *Session) generateName(ctx context.Context, message string) (string, error) {
+	provider := s.currentProvider()
+	if provider == nil {
+		return "", nil
+	}
+
+	if len(message) > sessionNameInputLimit {
+		message = message[:sessionNameInputLimit]
+	}
+
+	model := s.cheapestModel(provider)
+	response, err := provider.GenerateResponse(ctx, &llm.GenerateRequest{
+		Messages: []llm.Message{
+			{Role: "system", Content: sessionNamePrompt},
+			{Role: "user", Content: message},
+		},
+		Model:       model,
+		MaxTokens:   20,
+		Temperature: 0,
+	})
+	if err != nil {
+		return "", err
+	}
+	s.recordModelUsage(model, response.TokenUsage())
+
+	return slugify(response.Content, 0), nil
+}
+
+// cheapestModel returns the provider's model with the lowest known price, or the
+// session's model when no prices are known
+func (s *Session) cheapestModel(provider llm.Provider) string {
+	cheapest, lowest := s.Model, 0.0
+	for _, model := range provider.GetAvailableModels() {
+		price := model.CostPer1KTokens + model.OutputCostPer1KTokens
+		if price > 0 && (lowest == 0 || price < lowest) {
+			cheapest, lowest = model.ID, price
+		}
+	}
+	return cheapest
+}
+
+// nameFromMessage makes a name from the leading words of a message, skipping filler
+func nameFromMessage(message string) string {
+	var words []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(message), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if nameStopWords[word] {
+			continue
+		}
+		words = append(words, word)
+		if len(words) == sessionNameWords {
+			break
+		}
+	}
+	return slugify(strings.Join(words, " "), sessionNameWords)
+}
+
+// slugify turns text into a lowercase, hyphenated slug of at most maxWords words (0 for
+// no limit) and sessionNameMaxLen characters, cut at a word boundary
+func slugify(text string, maxWords int) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return r > unicode.MaxASCII || (!unicode.IsLetter(r) && !unicode.IsDigit(r))
+	})
+	if maxWords > 0 && len(words) > maxWords {
+		words = words[:maxWords]
+	}
+
+	slug := ""
+	for _, word := range words {
+		next := word
+		if slug != "" {
+			next = slug + "-" + word
+		}
+		if len(next) > sessionNameMaxLen {
+			break
+		}
+		slug = next
+	}
+	return slug
+}
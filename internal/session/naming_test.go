@@ -0,0 +1,73 @@
+package session
+
+import (
+	"context"
+	"github.com/tildaslashalef/bazinga/internal/llm"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestEnsureName tests that an unnamed session is named once, from the model's reply
+func TestEnsureName(t *testing.T) {
+	manager, _ := setupTestSessionManager()
+	session, err := manager.CreateSession(context.Background(), &CreateOptions{})
+	require.NoError(t, err)
+	require.Empty(t, session.Name)
+
+	// Nothing to name it after yet
+	_, ok := session.nameSource()
+	assert.False(t, ok)
+
+	session.History = append(session.History, llm.Message{Role: "user", Content: "Why does the login redirect loop?"})
+	first, ok := session.nameSource()
+	require.True(t, ok)
+	assert.Equal(t, "Why does the login redirect loop?", first)
+
+	// Naming works from the message it was given, whatever the history holds by then
+	session.History = append(session.History, llm.Message{Role: "user", Content: "And the logout?"})
+	session.ensureName(first)
+	assert.Equal(t, "mock-response", session.Name)
+
+	// A name is never replaced
+	_, ok = session.nameSource()
+	assert.False(t, ok)
+	session.Name = "my name"
+	session.ensureName(first)
+	assert.Equal(t, "my name", session.Name)
+}
+
+// TestNameFromMessage tests the offline name made from the first message
+func TestNameFromMessage(t *testing.T) {
+	assert.Equal(t, "fix-login-redirect-loop", nameFromMessage("Can you please fix the login redirect loop?"))
+	assert.Equal(t, "add-csv-export-reports-page", nameFromMessage("Add CSV export to the reports page, with headers"))
+	assert.Empty(t, nameFromMessage("can you?"))
+
+	long := nameFromMessage(strings.Repeat("internationalization ", 5))
+	assert.LessOrEqual(t, len(long), sessionNameMaxLen)
+	assert.False(t, strings.HasSuffix(long, "-"))
+}
+
+// TestSlugify tests cleaning up the model's reply into a name
+func TestSlugify(t *testing.T) {
+	assert.Equal(t, "fix-login-redirect", slugify("`fix-login-redirect`\n", 0))
+	assert.Equal(t, "add-csv-export", slugify(`"Add CSV export"`, 0))
+	assert.Equal(t, "one-two", slugify("one two three", 2))
+}
+
+// TestCheapestModel tests that naming picks the lowest priced model
+func TestCheapestModel(t *testing.T) {
+	session := &Session{Model: "large"}
+
+	provider := &mockProvider{models: []llm.Model{
+		{ID: "large", CostPer1KTokens: 0.015, OutputCostPer1KTokens: 0.075},
+		{ID: "small", CostPer1KTokens: 0.00025, OutputCostPer1KTokens: 0.00125},
+		{ID: "unpriced"},
+	}}
+	assert.Equal(t, "small", session.cheapestModel(provider))
+
+	assert.Equal(t, "large", session.cheapestModel(&mockProvider{models: []llm.Model{{ID: "local"}}}),
+		"without prices the session's model is used")
+}
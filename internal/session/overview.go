@@ -0,0 +1,243 @@
+package session
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/tildaslashalef/bazinga/internal/llm"
+	"github.com/tildaslashalef/bazinga/internal/loggy"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxOverviewFiles caps how many files the overview prompt asks the model to read
+const maxOverviewFiles = 12
+
+// overviewMemorySection is the MEMORY.md heading the overview is saved under
+const overviewMemorySection = "## Codebase Overview"
+
+// overviewDirective is prepended to the list of files the model reads for /overview
+const overviewDirective = `Give me an overview of this codebase. Start by reading the files listed below (use read_files to read them together), then explore further only where they leave a gap. Reply with a structured summary using these sections:
+
+## Architecture
+## Entry Points
+## Key Packages
+## Notable Patterns
+
+Keep each section short and concrete, naming files and packages rather than describing them in general terms.`
+
+// codebaseOverview is a cached /overview reply and the state of the files it was built from
+type codebaseOverview struct {
+	fingerprint string
+	content     string
+}
+
+// pendingOverview tracks an /overview request until its reply completes
+type pendingOverview struct {
+	prompt      string
+	fingerprint string
+	save        bool
+}
+
+// OverviewFiles returns the files an overview is built from, relative to the root path:
+// the project's main files first, then source files from its key directories. Files that
+// no longer exist are left out.
+func (s *Session) OverviewFiles() []string {
+	proj := s.GetProject()
+	if proj == nil {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	var files []string
+	for _, file := range proj.GetRelevantFiles(maxOverviewFiles) {
+		if seen[file] {
+			continue
+		}
+		seen[file] = true
+
+		if info, err := os.Stat(filepath.Join(s.RootPath, file)); err != nil || info.IsDir() {
+			continue
+		}
+		files = append(files, file)
+		if len(files) >= maxOverviewFiles {
+			break
+		}
+	}
+	return files
+}
+
+// overviewFingerprint hashes the path, size and modification time of each file so a
+// cached overview can be reused until one of them changes
+func (s *Session) overviewFingerprint(files []string) string {
+	hash := sha256.New()
+	for _, file := range files {
+		fmt.Fprintf(hash, "%s\x00", file)
+		if info, err := os.Stat(filepath.Join(s.RootPath, file)); err == nil {
+			fmt.Fprintf(hash, "%d\x00%d\x00", info.Size(), info.ModTime().UnixNano())
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// CachedOverview returns the last overview if none of its files have changed since
+func (s *Session) CachedOverview() (string, bool) {
+	if s.overview == nil {
+		return "", false
+	}
+	if s.overviewFingerprint(s.OverviewFiles()) != s.overview.fingerprint {
+		return "", false
+	}
+	return s.overview.content, true
+}
+
+// PrepareOverview returns the prompt for a new overview and remembers it, so the reply
+// is cached (and saved to MEMORY.md when save is set) once the stream completes
+func (s *Session) PrepareOverview(save bool) (string, error) {
+	files := s.OverviewFiles()
+	if len(files) == 0 {
+		return "", fmt.Errorf("no project files found to build an overview from")
+	}
+
+	var prompt strings.Builder
+	prompt.WriteString(overviewDirective)
+	prompt.WriteString("\n\nFiles to read:\n")
+	for _, file := range files {
+		prompt.WriteString("- " + file + "\n")
+	}
+
+	s.pendingOverview = &pendingOverview{
+		prompt:      prompt.String(),
+		fingerprint: s.overviewFingerprint(files),
+		save:        save,
+	}
+	return s.pendingOverview.prompt, nil
+}
+
+// finishOverview caches the reply to a pending overview request once the stream for
+// message has completed
+func (s *Session) finishOverview(message string) {
+	pending := s.pendingOverview
+	if pending == nil || pending.prompt != message {
+		return
+	}
+	s.pendingOverview = nil
+
+	content := lastAssistantText(s.History)
+	if content == "" {
+		return
+	}
+
+	s.overview = &codebaseOverview{fingerprint: pending.fingerprint, content: content}
+	loggy.Debug("Cached codebase overview", "length", len(content))
+
+	if pending.save {
+		if err := s.SaveOverviewToMemory(context.Background()); err != nil {
+			loggy.Warn("Failed to save codebase overview to memory", "error", err)
+		}
+	}
+}
+
+// lastAssistantText returns the text of the most recent assistant message
+func lastAssistantText(history []llm.Message) string {
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].Role != "assistant" {
+			continue
+		}
+		if text, ok := history[i].Content.(string); ok && strings.TrimSpace(text) != "" {
+			return strings.TrimSpace(text)
+		}
+	}
+	return ""
+}
+
+// SaveOverviewToMemory writes the cached overview to the project MEMORY.md, replacing
+// an earlier overview section if there is one
+func (s *Session) SaveOverviewToMemory(ctx context.Context) error {
+	if s.overview == nil {
+		return fmt.Errorf("no overview has been generated yet")
+	}
+
+	_, path := s.GetMemoryFilePaths()
+	if path == "" {
+		path = filepath.Join(s.RootPath, "MEMORY.md")
+	}
+
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	updated := replaceMemorySection(string(existing), overviewMemorySection, demoteHeadings(s.overview.content))
+	if err := os.WriteFile(path, []byte(updated), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	if s.memorySystem != nil {
+		return s.ReloadMemory(ctx)
+	}
+	return nil
+}
+
+// demoteHeadings nests markdown headings below level 2 so they stay inside the section
+// they are saved under
+func demoteHeadings(content string) string {
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		if !strings.HasPrefix(line, "#") {
+			continue
+		}
+		for !strings.HasPrefix(line, "###") {
+			line = "#" + line
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// replaceMemorySection replaces the body of a "## " section in a markdown document, or
+// appends the section if the document does not have it
+func replaceMemorySection(doc, heading, body string) string {
+	section := heading + "\n\n" + strings.TrimSpace(body) + "\n"
+
+	lines := strings.Split(doc, "\n")
+	start := -1
+	for i, line := range lines {
+		if strings.TrimSpace(line) == heading {
+			start = i
+			break
+		}
+	}
+
+	if start < 0 {
+		doc = strings.TrimRight(doc, "\n")
+		if doc == "" {
+			return section
+		}
+		return doc + "\n\n" + section
+	}
+
+	// The section runs until the next heading of the same or a higher level
+	end := len(lines)
+	for i := start + 1; i < len(lines); i++ {
+		if strings.HasPrefix(lines[i], "# ") || strings.HasPrefix(lines[i], "## ") {
+			end = i
+			break
+		}
+	}
+
+	before := strings.TrimRight(strings.Join(lines[:start], "\n"), "\n")
+	after := strings.TrimLeft(strings.Join(lines[end:], "\n"), "\n")
+
+	var result strings.Builder
+	if before != "" {
+		result.WriteString(before + "\n\n")
+	}
+	result.WriteString(section)
+	if after != "" {
+		result.WriteString("\n" + after)
+	}
+	return result.String()
+}
@@ -0,0 +1,94 @@
+package session
+
+import (
+	"context"
+	"github.com/tildaslashalef/bazinga/internal/llm"
+	"github.com/tildaslashalef/bazinga/internal/project"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newOverviewSession(t *testing.T) *Session {
+	dir := t.TempDir()
+	files := map[string]string{
+		"main.go":              "package main\n\nfunc main() {}\n",
+		"go.mod":               "module example.com/demo\n\ngo 1.23\n",
+		"README.md":            "# Demo\n",
+		"internal/store/db.go": "package store\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+
+	proj, err := project.NewDetector().DetectProject(dir)
+	require.NoError(t, err)
+
+	return &Session{RootPath: dir, project: proj}
+}
+
+// TestOverviewUsesMainFiles tests that the overview prompt is built from the main project files
+func TestOverviewUsesMainFiles(t *testing.T) {
+	session := newOverviewSession(t)
+
+	files := session.OverviewFiles()
+	assert.Equal(t, []string{"main.go", "go.mod", "README.md"}, files[:3], "priority files come first")
+	assert.Contains(t, files, filepath.Join("internal", "store", "db.go"))
+
+	prompt, err := session.PrepareOverview(false)
+	require.NoError(t, err)
+	assert.Contains(t, prompt, "## Architecture")
+	for _, file := range files {
+		assert.Contains(t, prompt, "- "+file+"\n")
+	}
+
+	// Files deleted since the project was scanned are left out
+	require.NoError(t, os.Remove(filepath.Join(session.RootPath, "README.md")))
+	assert.NotContains(t, session.OverviewFiles(), "README.md")
+}
+
+// TestOverviewCacheInvalidatesOnChange tests that a cached overview is reused until a main file changes
+func TestOverviewCacheInvalidatesOnChange(t *testing.T) {
+	session := newOverviewSession(t)
+
+	prompt, err := session.PrepareOverview(false)
+	require.NoError(t, err)
+
+	// Replies to other messages are not cached
+	session.History = append(session.History, llm.Message{Role: "assistant", Content: "unrelated"})
+	session.finishOverview("something else")
+	_, ok := session.CachedOverview()
+	assert.False(t, ok)
+
+	session.History = append(session.History, llm.Message{Role: "assistant", Content: "## Architecture\nA CLI."})
+	session.finishOverview(prompt)
+
+	cached, ok := session.CachedOverview()
+	require.True(t, ok)
+	assert.Equal(t, "## Architecture\nA CLI.", cached)
+
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(filepath.Join(session.RootPath, "main.go"), later, later))
+	_, ok = session.CachedOverview()
+	assert.False(t, ok, "changing a main file should invalidate the overview")
+}
+
+// TestSaveOverviewToMemory tests that saving replaces an earlier overview section in MEMORY.md
+func TestSaveOverviewToMemory(t *testing.T) {
+	session := newOverviewSession(t)
+	memoryPath := filepath.Join(session.RootPath, "MEMORY.md")
+	require.NoError(t, os.WriteFile(memoryPath, []byte("# Project\n\n## Codebase Overview\n\nold\n\n## Notes\n\nkeep me\n"), 0o644))
+
+	session.overview = &codebaseOverview{content: "## Architecture\nA CLI."}
+	require.NoError(t, session.SaveOverviewToMemory(context.Background()))
+
+	data, err := os.ReadFile(memoryPath)
+	require.NoError(t, err)
+	assert.Equal(t, "# Project\n\n## Codebase Overview\n\n### Architecture\nA CLI.\n\n## Notes\n\nkeep me\n", string(data))
+}
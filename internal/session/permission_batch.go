@@ -0,0 +1,109 @@
+package session
+
+import (
+	"github.com/tildaslashalef/bazinga/internal/llm"
+	"time"
+)
+
+// permissionBatch is the set of tool calls from one response that may need a prompt,
+// and the decision the user made for all of them, if any
+type permissionBatch struct {
+	calls    []*llm.ToolCall
+	decision *PermissionDecision
+}
+
+// BeginBatch starts the batch of tool calls from one response. Read-only calls are left
+// out, since they never prompt.
+func (pm *PermissionManager) BeginBatch(toolCalls []llm.ToolCall) {
+	batch := &permissionBatch{}
+	for i := range toolCalls {
+		if !pm.IsReadOnly(&toolCalls[i]) {
+			batch.calls = append(batch.calls, &toolCalls[i])
+		}
+	}
+
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	pm.batch = batch
+}
+
+// EndBatch ends the current batch; a decision made for it no longer applies
+func (pm *PermissionManager) EndBatch() {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	pm.batch = nil
+}
+
+// BatchPosition returns where a tool call is in the current batch, counting from 1, and
+// how many calls in the batch may prompt. It returns 0, 0 for calls outside a batch.
+func (pm *PermissionManager) BatchPosition(toolCall *llm.ToolCall) (position, total int) {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+
+	if pm.batch == nil {
+		return 0, 0
+	}
+	for i, call := range pm.batch.calls {
+		if call == toolCall {
+			return i + 1, len(pm.batch.calls)
+		}
+	}
+	return 0, 0
+}
+
+// BatchRemaining returns the calls of the current batch after toolCall, which haven't
+// been prompted for yet
+func (pm *PermissionManager) BatchRemaining(toolCall *llm.ToolCall) []*llm.ToolCall {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+
+	if pm.batch == nil {
+		return nil
+	}
+	for i, call := range pm.batch.calls {
+		if call == toolCall {
+			return append([]*llm.ToolCall(nil), pm.batch.calls[i+1:]...)
+		}
+	}
+	return nil
+}
+
+// BatchApprovable reports whether approving a whole batch covers a tool call. High-risk
+// calls and calls that require a prompt are left out and still prompt.
+func (pm *PermissionManager) BatchApprovable(toolCall *llm.ToolCall) bool {
+	return !pm.RequiresPrompt(toolCall) && pm.GetToolRisk(toolCall) != "high"
+}
+
+// DecideBatch approves or denies the rest of the current batch, so its remaining calls
+// that would prompt don't. Deny rules still apply to an approved batch, and calls that
+// aren't BatchApprovable still prompt.
+func (pm *PermissionManager) DecideBatch(approved bool) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	if pm.batch != nil {
+		pm.batch.decision = &PermissionDecision{Approved: approved, Timestamp: time.Now()}
+	}
+}
+
+// batchDecision returns the decision made for the current batch, if toolCall is in it
+func (pm *PermissionManager) batchDecision(toolCall *llm.ToolCall) (PermissionDecision, bool) {
+	// Checked before locking, as the config rules take the lock too
+	approvable := pm.BatchApprovable(toolCall)
+
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+
+	if pm.batch == nil || pm.batch.decision == nil {
+		return PermissionDecision{}, false
+	}
+	if pm.batch.decision.Approved && !approvable {
+		return PermissionDecision{}, false
+	}
+	for _, call := range pm.batch.calls {
+		if call == toolCall {
+			return PermissionDecision{Approved: pm.batch.decision.Approved}, true
+		}
+	}
+	return PermissionDecision{}, false
+}
@@ -0,0 +1,81 @@
+package session
+
+import (
+	"github.com/tildaslashalef/bazinga/internal/llm"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestPermissionBatch tests that a decision for a batch covers its remaining calls that
+// would prompt, and ends with the batch
+func TestPermissionBatch(t *testing.T) {
+	pm := NewPermissionManager()
+	prompts := 0
+	pm.SetPromptCallback(func(*llm.ToolCall) bool {
+		prompts++
+		return false
+	})
+
+	calls := []llm.ToolCall{
+		{Name: "read_file", Input: map[string]interface{}{"file_path": "a.go"}},
+		{Name: "edit_file", Input: map[string]interface{}{"file_path": "a.go"}},
+		{Name: "write_file", Input: map[string]interface{}{"file_path": "b.go"}},
+		{Name: "edit_file", Input: map[string]interface{}{"file_path": "c.go"}},
+	}
+	pm.BeginBatch(calls)
+
+	position, total := pm.BatchPosition(&calls[2])
+	assert.Equal(t, 2, position)
+	assert.Equal(t, 3, total, "read-only calls are not counted")
+	position, total = pm.BatchPosition(&llm.ToolCall{Name: "edit_file"})
+	assert.Zero(t, position+total, "calls outside the batch have no position")
+
+	assert.False(t, pm.CheckPermission(&calls[1]))
+	assert.Equal(t, 1, prompts)
+
+	pm.DecideBatch(true)
+	assert.True(t, pm.CheckPermission(&calls[2]))
+	assert.True(t, pm.CheckPermission(&calls[3]))
+	assert.Equal(t, 1, prompts, "the rest of the batch is not prompted")
+	assert.False(t, pm.CheckPermission(&llm.ToolCall{Name: "edit_file", Input: map[string]interface{}{"file_path": "c.go"}}), "calls outside the batch still prompt")
+
+	pm.EndBatch()
+	assert.False(t, pm.CheckPermission(&calls[3]))
+	assert.Equal(t, 3, prompts, "the decision ends with the batch")
+}
+
+// TestPermissionBatchLeavesRiskyCalls tests that approving a batch doesn't cover its
+// high-risk or escalated calls, while denying it does
+func TestPermissionBatchLeavesRiskyCalls(t *testing.T) {
+	pm := NewPermissionManager()
+	prompts := 0
+	pm.SetPromptCallback(func(*llm.ToolCall) bool {
+		prompts++
+		return false
+	})
+
+	calls := []llm.ToolCall{
+		{Name: "edit_file", Input: map[string]interface{}{"file_path": "a.go"}},
+		{Name: "write_file", Input: map[string]interface{}{"file_path": "b.go"}},
+		{Name: "bash", Input: map[string]interface{}{"command": "make"}},
+		{Name: "write_file", Input: map[string]interface{}{"file_path": ".env"}},
+	}
+	pm.BeginBatch(calls)
+
+	remaining := pm.BatchRemaining(&calls[0])
+	assert.Equal(t, []*llm.ToolCall{&calls[1], &calls[2], &calls[3]}, remaining)
+	assert.True(t, pm.BatchApprovable(&calls[1]))
+	assert.False(t, pm.BatchApprovable(&calls[2]), "high-risk calls are left out")
+	assert.False(t, pm.BatchApprovable(&calls[3]), "escalated calls are left out")
+
+	pm.DecideBatch(true)
+	assert.True(t, pm.CheckPermission(&calls[1]))
+	assert.False(t, pm.CheckPermission(&calls[2]))
+	assert.False(t, pm.CheckPermission(&calls[3]))
+	assert.Equal(t, 2, prompts, "risky calls still prompt")
+
+	pm.DecideBatch(false)
+	assert.False(t, pm.CheckPermission(&calls[2]))
+	assert.Equal(t, 2, prompts, "a denied batch covers every call")
+}
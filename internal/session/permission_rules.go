@@ -0,0 +1,235 @@
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// permissionRuleSetVersion is the current version of exported permission rule sets
+const permissionRuleSetVersion = 1
+
+// PermissionRuleSet is a shareable snapshot of tool and session permission rules
+type PermissionRuleSet struct {
+	Version      int                `yaml:"version" json:"version"`
+	ToolRules    []ToolRuleEntry    `yaml:"tool_rules" json:"tool_rules"`
+	SessionRules []SessionRuleEntry `yaml:"session_rules,omitempty" json:"session_rules,omitempty"`
+}
+
+// ToolRuleEntry is the serialized form of a ToolPermissionRule
+type ToolRuleEntry struct {
+	Tool         string   `yaml:"tool" json:"tool"`
+	Permission   string   `yaml:"permission" json:"permission"` // "allow", "prompt" or "deny"
+	FilePatterns []string `yaml:"file_patterns,omitempty" json:"file_patterns,omitempty"`
+	Commands     []string `yaml:"commands,omitempty" json:"commands,omitempty"`
+}
+
+// SessionRuleEntry is the serialized form of a PermissionRule
+type SessionRuleEntry struct {
+	Tool     string `yaml:"tool" json:"tool"`
+	File     string `yaml:"file,omitempty" json:"file,omitempty"`
+	Command  string `yaml:"command,omitempty" json:"command,omitempty"`
+	Approved bool   `yaml:"approved" json:"approved"`
+	Reason   string `yaml:"reason,omitempty" json:"reason,omitempty"`
+}
+
+// String returns the config name of a permission level
+func (l PermissionLevel) String() string {
+	switch l {
+	case PermissionAllow:
+		return "allow"
+	case PermissionDeny:
+		return "deny"
+	default:
+		return "prompt"
+	}
+}
+
+// parsePermissionLevel parses a permission level name
+func parsePermissionLevel(name string) (PermissionLevel, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "allow":
+		return PermissionAllow, nil
+	case "prompt":
+		return PermissionPrompt, nil
+	case "deny":
+		return PermissionDeny, nil
+	}
+	return PermissionPrompt, fmt.Errorf("unknown permission %q (want allow, prompt or deny)", name)
+}
+
+// ExportRuleSet returns the active tool and session rules, sorted by tool name
+func (pm *PermissionManager) ExportRuleSet() PermissionRuleSet {
+	set := PermissionRuleSet{Version: permissionRuleSetVersion}
+
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+	for _, rule := range pm.toolRules {
+		set.ToolRules = append(set.ToolRules, ToolRuleEntry{
+			Tool:         rule.ToolName,
+			Permission:   rule.Permission.String(),
+			FilePatterns: rule.FilePatterns,
+			Commands:     rule.Commands,
+		})
+	}
+	sort.Slice(set.ToolRules, func(i, j int) bool {
+		return set.ToolRules[i].Tool < set.ToolRules[j].Tool
+	})
+
+	for _, rule := range pm.sessionRules {
+		set.SessionRules = append(set.SessionRules, SessionRuleEntry{
+			Tool:     rule.ToolPattern,
+			File:     rule.FilePattern,
+			Command:  rule.CommandPattern,
+			Approved: rule.Decision.Approved,
+			Reason:   rule.Decision.Reason,
+		})
+	}
+
+	return set
+}
+
+// ApplyRuleSet validates a rule set and applies it. Listed tools replace their current
+// rule, other tools keep theirs, and session rules are replaced. Nothing is applied if
+// validation fails. The returned notes describe rules that differ from the defaults.
+func (pm *PermissionManager) ApplyRuleSet(set PermissionRuleSet) ([]string, error) {
+	if set.Version > permissionRuleSetVersion {
+		return nil, fmt.Errorf("unsupported rule set version %d", set.Version)
+	}
+
+	var problems []error
+	toolRules := make(map[string]*ToolPermissionRule, len(set.ToolRules))
+	for i, entry := range set.ToolRules {
+		name := strings.TrimSpace(entry.Tool)
+		if name == "" {
+			problems = append(problems, fmt.Errorf("tool_rules[%d]: tool is required", i))
+			continue
+		}
+		if _, dup := toolRules[name]; dup {
+			problems = append(problems, fmt.Errorf("tool_rules[%d]: duplicate rule for %s", i, name))
+			continue
+		}
+		level, err := parsePermissionLevel(entry.Permission)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("tool_rules[%d] (%s): %w", i, name, err))
+			continue
+		}
+		toolRules[name] = &ToolPermissionRule{
+			ToolName:     name,
+			Permission:   level,
+			FilePatterns: entry.FilePatterns,
+			Commands:     entry.Commands,
+		}
+	}
+
+	sessionRules := make([]PermissionRule, 0, len(set.SessionRules))
+	for i, entry := range set.SessionRules {
+		if strings.TrimSpace(entry.Tool) == "" {
+			problems = append(problems, fmt.Errorf("session_rules[%d]: tool is required", i))
+			continue
+		}
+		sessionRules = append(sessionRules, PermissionRule{
+			ToolPattern:    entry.Tool,
+			FilePattern:    entry.File,
+			CommandPattern: entry.Command,
+			Decision: PermissionDecision{
+				Approved:  entry.Approved,
+				Reason:    entry.Reason,
+				Timestamp: time.Now(),
+			},
+			CreatedAt: time.Now(),
+		})
+	}
+
+	if len(problems) > 0 {
+		return nil, errors.Join(problems...)
+	}
+
+	// Tool calls run in parallel read the rules, so they are replaced under the lock
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	notes := pm.ruleConflicts(toolRules)
+	for name, rule := range toolRules {
+		pm.toolRules[name] = rule
+	}
+	pm.sessionRules = sessionRules
+
+	return notes, nil
+}
+
+// ruleConflicts lists imported rules that differ from the built-in defaults or name
+// tools this session does not know about. The caller holds pm.mu.
+func (pm *PermissionManager) ruleConflicts(rules map[string]*ToolPermissionRule) []string {
+	defaults := NewPermissionManager().toolRules
+
+	var notes []string
+	for name, rule := range rules {
+		if def, ok := defaults[name]; ok {
+			if def.Permission != rule.Permission {
+				notes = append(notes, fmt.Sprintf("%s: %s overrides the default %s", name, rule.Permission, def.Permission))
+			}
+			continue
+		}
+		if _, ok := pm.toolRules[name]; !ok {
+			notes = append(notes, fmt.Sprintf("%s: not a known tool, rule kept for when it is registered", name))
+		}
+	}
+	sort.Strings(notes)
+
+	return notes
+}
+
+// ExportRules writes the active rules to path, as JSON for .json files and YAML otherwise
+func (pm *PermissionManager) ExportRules(path string) error {
+	set := pm.ExportRuleSet()
+
+	var data []byte
+	var err error
+	if isJSONPath(path) {
+		data, err = json.MarshalIndent(set, "", "  ")
+		data = append(data, '\n')
+	} else {
+		data, err = yaml.Marshal(set)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode permission rules: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write permission rules: %w", err)
+	}
+	return nil
+}
+
+// ImportRules loads and applies a rule set written by ExportRules
+func (pm *PermissionManager) ImportRules(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read permission rules: %w", err)
+	}
+
+	var set PermissionRuleSet
+	if isJSONPath(path) {
+		err = json.Unmarshal(data, &set)
+	} else {
+		err = yaml.Unmarshal(data, &set)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse permission rules: %w", err)
+	}
+
+	return pm.ApplyRuleSet(set)
+}
+
+// isJSONPath reports whether a rules file should be read and written as JSON
+func isJSONPath(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".json")
+}
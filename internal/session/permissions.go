@@ -3,7 +3,14 @@ package session
 import (
 	"fmt"
 	"github.com/tildaslashalef/bazinga/internal/llm"
+	"github.com/tildaslashalef/bazinga/internal/storage"
+	"github.com/tildaslashalef/bazinga/internal/tools"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -50,19 +57,37 @@ type PermissionRule struct {
 type PermissionManager struct {
 	defaultPermission PermissionLevel
 	toolRules         map[string]*ToolPermissionRule
-	promptCallback    func(toolCall *llm.ToolCall) bool // Callback to prompt user
+	toolRisks         map[string]string                               // Risk levels for config-defined tools
+	promptCallback    func(toolCall *llm.ToolCall) PermissionDecision // Callback to prompt user
+
+	// Command a config-defined tool call runs, which its remembered decisions are keyed by
+	toolArgs func(name string, input map[string]interface{}) ([]string, bool)
 
 	// Async permission handling
-	toolQueue    *ToolQueue
+	toolQueue *ToolQueue
+
+	// Remembered decisions, guarded by mu since prompts are answered from the UI
+	mu           sync.RWMutex
 	patterns     map[string]PermissionDecision
 	sessionRules []PermissionRule
+	rememberTTL  time.Duration    // How long remembered decisions last; 0 = until the session ends
+	configRules  []configRule     // From security.permission_rules, consulted before the tool rules
+	rootPath     string           // Config rule file patterns are relative to it
+	batch        *permissionBatch // Tool calls of the response being run
 }
 
+// readOnlyTools are the built-in tools that never change files or repository state
+var readOnlyTools = []string{"read_file", "read_files", "list_files", "grep", "find", "fuzzy_search", "symbols", "ast_outline", "dependencies", "git_status", "git_diff", "git_log", "git_blame", "git_show", "todo_read"}
+
+// writeTools are the built-in tools that change files
+var writeTools = []string{"write_file", "create_file", "edit_file", "multi_edit_file", "apply_patch", "move_file", "copy_file", "delete_file", "create_dir", "delete_dir"}
+
 // NewPermissionManager creates a new permission manager with defaults
 func NewPermissionManager() *PermissionManager {
 	pm := &PermissionManager{
 		defaultPermission: PermissionPrompt,
 		toolRules:         make(map[string]*ToolPermissionRule),
+		toolRisks:         make(map[string]string),
 		patterns:          make(map[string]PermissionDecision),
 		sessionRules:      make([]PermissionRule, 0),
 	}
@@ -70,6 +95,12 @@ func NewPermissionManager() *PermissionManager {
 	return pm
 }
 
+// SetToolArgs sets how to find the command a config-defined tool call runs, so its
+// remembered approvals cover only calls running the same command
+func (pm *PermissionManager) SetToolArgs(toolArgs func(name string, input map[string]interface{}) ([]string, bool)) {
+	pm.toolArgs = toolArgs
+}
+
 // SetToolQueue sets the tool queue for async permission handling
 func (pm *PermissionManager) SetToolQueue(queue *ToolQueue) {
 	pm.toolQueue = queue
@@ -78,8 +109,7 @@ func (pm *PermissionManager) SetToolQueue(queue *ToolQueue) {
 // setDefaultRules configures default permission rules
 func (pm *PermissionManager) setDefaultRules() {
 	// Safe read-only operations - allow without prompting
-	safeTools := []string{"read_file", "list_files", "grep", "find", "fuzzy_search", "git_status", "git_diff", "git_log", "todo_read"}
-	for _, tool := range safeTools {
+	for _, tool := range readOnlyTools {
 		pm.toolRules[tool] = &ToolPermissionRule{
 			ToolName:   tool,
 			Permission: PermissionAllow,
@@ -87,7 +117,6 @@ func (pm *PermissionManager) setDefaultRules() {
 	}
 
 	// Write operations - always prompt
-	writeTools := []string{"write_file", "create_file", "edit_file", "multi_edit_file", "move_file", "copy_file", "delete_file", "create_dir", "delete_dir"}
 	for _, tool := range writeTools {
 		pm.toolRules[tool] = &ToolPermissionRule{
 			ToolName:   tool,
@@ -96,7 +125,7 @@ func (pm *PermissionManager) setDefaultRules() {
 	}
 
 	// Potentially dangerous operations - always prompt with extra caution
-	dangerousTools := []string{"bash", "git_add", "git_commit", "git_branch"}
+	dangerousTools := []string{"bash", "run_tests", "git_add", "git_commit", "git_branch", "git_stash", "git_stash_pop", "git_restore"}
 	for _, tool := range dangerousTools {
 		pm.toolRules[tool] = &ToolPermissionRule{
 			ToolName:   tool,
@@ -117,15 +146,53 @@ func (pm *PermissionManager) setDefaultRules() {
 	}
 }
 
+// RegisterToolRisk sets the risk level of a config-defined tool. Low-risk tools run
+// without prompting; anything else prompts like the built-in write and shell tools.
+func (pm *PermissionManager) RegisterToolRisk(toolName, risk string) {
+	switch risk {
+	case "low", "medium", "high":
+	default:
+		risk = "high"
+	}
+
+	permission := PermissionPrompt
+	if risk == "low" {
+		permission = PermissionAllow
+	}
+
+	// A config reload registers tools again while calls may be checking them
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	pm.toolRisks[toolName] = risk
+	pm.toolRules[toolName] = &ToolPermissionRule{
+		ToolName:   toolName,
+		Permission: permission,
+	}
+}
+
 // SetPromptCallback sets the callback function for user prompts
 func (pm *PermissionManager) SetPromptCallback(callback func(toolCall *llm.ToolCall) bool) {
+	pm.promptCallback = func(toolCall *llm.ToolCall) PermissionDecision {
+		return PermissionDecision{Approved: callback(toolCall), Timestamp: time.Now()}
+	}
+}
+
+// SetPromptDecisionCallback sets a callback for user prompts that can give a reason
+// with a denial, which is passed on to the model
+func (pm *PermissionManager) SetPromptDecisionCallback(callback func(toolCall *llm.ToolCall) PermissionDecision) {
 	pm.promptCallback = callback
 }
 
 // CheckPermission checks if a tool execution should be allowed
 func (pm *PermissionManager) CheckPermission(toolCall *llm.ToolCall) bool {
+	return pm.CheckPermissionDecision(toolCall).Approved
+}
+
+// CheckPermissionDecision checks if a tool execution should be allowed, with the
+// reason the user gave when they denied it
+func (pm *PermissionManager) CheckPermissionDecision(toolCall *llm.ToolCall) PermissionDecision {
 	if toolCall == nil {
-		return false
+		return PermissionDecision{}
 	}
 
 	// Get permission level for this tool
@@ -133,28 +200,55 @@ func (pm *PermissionManager) CheckPermission(toolCall *llm.ToolCall) bool {
 
 	switch permission {
 	case PermissionAllow:
-		return true
+		return PermissionDecision{Approved: true}
 	case PermissionDeny:
-		return false
+		return PermissionDecision{}
 	case PermissionPrompt:
+		// Calls escalated for special conditions or by a prompt rule always prompt,
+		// whatever was decided for similar calls
+		if !pm.RequiresPrompt(toolCall) {
+			// Decisions the user asked to remember apply to similar calls
+			if decision, ok := pm.RememberedDecision(toolCall); ok {
+				return PermissionDecision{Approved: decision.Approved}
+			}
+			// So do approvals and denials of a whole batch
+			if decision, ok := pm.batchDecision(toolCall); ok {
+				return decision
+			}
+		}
 		if pm.promptCallback != nil {
 			return pm.promptCallback(toolCall)
 		}
 		// If no prompt callback, default to deny for safety
-		return false
+		return PermissionDecision{}
 	default:
-		return false
+		return PermissionDecision{}
 	}
 }
 
 // getToolPermission determines the permission level for a specific tool call
 func (pm *PermissionManager) getToolPermission(toolCall *llm.ToolCall) PermissionLevel {
+	// Configured rules come first; an allow rule still prompts for special conditions
+	if permission, ok := pm.configPermission(toolCall); ok {
+		if permission == PermissionAllow && pm.hasSpecialConditions(toolCall, &ToolPermissionRule{}) {
+			return PermissionPrompt
+		}
+		return permission
+	}
+
 	// Check if we have a specific rule for this tool
-	if rule, exists := pm.toolRules[toolCall.Name]; exists {
+	pm.mu.RLock()
+	rule, exists := pm.toolRules[toolCall.Name]
+	pm.mu.RUnlock()
+	if exists {
 		// Check for special conditions
 		if pm.hasSpecialConditions(toolCall, rule) {
 			return PermissionPrompt // Escalate to prompt for special conditions
 		}
+		// Read-only tools that reach the network for this call ask first
+		if rule.Permission == PermissionAllow && usesNetwork(toolCall) {
+			return PermissionPrompt
+		}
 		return rule.Permission
 	}
 
@@ -165,7 +259,7 @@ func (pm *PermissionManager) getToolPermission(toolCall *llm.ToolCall) Permissio
 // hasSpecialConditions checks if the tool call has conditions that require special handling
 func (pm *PermissionManager) hasSpecialConditions(toolCall *llm.ToolCall, rule *ToolPermissionRule) bool {
 	// Check for dangerous file patterns
-	if filePath, ok := toolCall.Input["file_path"].(string); ok {
+	for _, filePath := range toolFilePaths(toolCall) {
 		dangerousPatterns := []string{
 			"/etc/", "/bin/", "/sbin/", "/usr/bin/", "/usr/sbin/",
 			".env", ".key", ".pem", ".p12", ".pfx",
@@ -213,6 +307,16 @@ func (pm *PermissionManager) hasSpecialConditions(toolCall *llm.ToolCall, rule *
 	return false
 }
 
+// usesNetwork reports whether a call of an otherwise read-only tool reaches the network,
+// as dependencies does when it checks for updates
+func usesNetwork(toolCall *llm.ToolCall) bool {
+	if toolCall.Name != "dependencies" {
+		return false
+	}
+	checkUpdates, _ := toolCall.Input["check_updates"].(bool)
+	return checkUpdates
+}
+
 // GetToolRisk returns a risk assessment for a tool call
 func (pm *PermissionManager) GetToolRisk(toolCall *llm.ToolCall) string {
 	if toolCall == nil {
@@ -224,21 +328,42 @@ func (pm *PermissionManager) GetToolRisk(toolCall *llm.ToolCall) string {
 		return "high"
 	}
 
+	// Config-defined tools carry their own risk
+	pm.mu.RLock()
+	risk, ok := pm.toolRisks[toolCall.Name]
+	pm.mu.RUnlock()
+	if ok {
+		return risk
+	}
+
+	if usesNetwork(toolCall) {
+		return "medium"
+	}
+
 	// Assess based on tool type
 	switch toolCall.Name {
-	case "read_file", "list_files", "grep", "find", "fuzzy_search", "git_status", "git_diff", "git_log", "todo_read":
+	case "read_file", "read_files", "list_files", "grep", "find", "fuzzy_search", "symbols", "ast_outline", "dependencies", "git_status", "git_diff", "git_log", "git_blame", "git_show", "todo_read":
 		return "low"
-	case "write_file", "create_file", "edit_file", "multi_edit_file", "todo_write":
+	case "write_file", "create_file", "edit_file", "multi_edit_file", "apply_patch", "todo_write":
 		return "medium"
-	case "move_file", "copy_file", "delete_file", "create_dir", "delete_dir", "git_add", "git_commit":
+	case "move_file", "copy_file", "delete_file", "create_dir", "delete_dir", "git_add", "git_commit", "git_stash", "run_tests":
 		return "medium"
-	case "bash", "git_branch", "web_fetch":
+	case "bash", "git_branch", "git_stash_pop", "git_restore", "web_fetch":
 		return "high"
 	default:
 		return "medium"
 	}
 }
 
+// IsReadOnly reports whether a tool call is low risk and runs without prompting. Such
+// calls don't depend on each other, so a batch of them can run concurrently.
+func (pm *PermissionManager) IsReadOnly(toolCall *llm.ToolCall) bool {
+	if toolCall == nil {
+		return false
+	}
+	return pm.GetToolRisk(toolCall) == "low" && pm.getToolPermission(toolCall) == PermissionAllow
+}
+
 // FormatPermissionPrompt creates a user-friendly permission prompt
 func (pm *PermissionManager) FormatPermissionPrompt(toolCall *llm.ToolCall) string {
 	if toolCall == nil {
@@ -279,6 +404,14 @@ func (pm *PermissionManager) FormatPermissionPrompt(toolCall *llm.ToolCall) stri
 	return prompt.String()
 }
 
+// DescribeAction returns a one-line description of what a tool call will do
+func (pm *PermissionManager) DescribeAction(toolCall *llm.ToolCall) string {
+	if toolCall == nil {
+		return ""
+	}
+	return pm.getActionDescription(toolCall)
+}
+
 // getActionDescription returns a human-readable description of what the tool will do
 func (pm *PermissionManager) getActionDescription(toolCall *llm.ToolCall) string {
 	switch toolCall.Name {
@@ -287,6 +420,11 @@ func (pm *PermissionManager) getActionDescription(toolCall *llm.ToolCall) string
 			return fmt.Sprintf("Read file '%s'", filePath)
 		}
 		return "Read a file"
+	case "read_files":
+		if files, ok := toolCall.Input["files"].([]interface{}); ok {
+			return fmt.Sprintf("Read %d files", len(files))
+		}
+		return "Read files"
 	case "write_file", "create_file":
 		if filePath, ok := toolCall.Input["file_path"].(string); ok {
 			return fmt.Sprintf("Write to file '%s'", filePath)
@@ -297,6 +435,13 @@ func (pm *PermissionManager) getActionDescription(toolCall *llm.ToolCall) string
 			return fmt.Sprintf("Edit file '%s'", filePath)
 		}
 		return "Edit a file"
+	case "apply_patch":
+		if patch, ok := toolCall.Input["patch"].(string); ok {
+			if files := tools.PatchFiles(patch); len(files) > 0 {
+				return fmt.Sprintf("Apply patch to %d file(s): %s", len(files), strings.Join(files, ", "))
+			}
+		}
+		return "Apply a patch"
 	case "delete_file":
 		if filePath, ok := toolCall.Input["file_path"].(string); ok {
 			return fmt.Sprintf("Delete file '%s'", filePath)
@@ -307,11 +452,37 @@ func (pm *PermissionManager) getActionDescription(toolCall *llm.ToolCall) string
 			return fmt.Sprintf("Run command '%s'", command)
 		}
 		return "Execute a shell command"
+	case "dependencies":
+		if usesNetwork(toolCall) {
+			return "List dependencies and check the network for newer versions"
+		}
+		return "List dependencies"
+	case "run_tests":
+		if target, ok := toolCall.Input["target"].(string); ok && target != "" {
+			return fmt.Sprintf("Run the tests in '%s'", target)
+		}
+		return "Run the project's tests"
 	case "git_commit":
 		if message, ok := toolCall.Input["message"].(string); ok {
 			return fmt.Sprintf("Git commit with message '%s'", message)
 		}
 		return "Create a git commit"
+	case "git_stash":
+		if includeUntracked, ok := toolCall.Input["include_untracked"].(bool); ok && includeUntracked {
+			return "Stash uncommitted changes, including untracked files"
+		}
+		return "Stash uncommitted changes"
+	case "git_stash_pop":
+		if stash, ok := toolCall.Input["stash"].(string); ok && stash != "" {
+			return fmt.Sprintf("Apply and drop %s", stash)
+		}
+		return "Apply and drop the latest stash"
+	case "git_restore":
+		paths := toolFilePaths(toolCall)
+		if staged, ok := toolCall.Input["staged"].(bool); ok && staged {
+			return fmt.Sprintf("Unstage %s", strings.Join(paths, ", "))
+		}
+		return fmt.Sprintf("Discard uncommitted changes to %s", strings.Join(paths, ", "))
 	case "web_fetch":
 		if url, ok := toolCall.Input["url"].(string); ok {
 			return fmt.Sprintf("Fetch data from '%s'", url)
@@ -349,19 +520,52 @@ func (pm *PermissionManager) getToolDetails(toolCall *llm.ToolCall) string {
 	return ""
 }
 
+// toolFilePaths returns the files a tool call names, including every file a patch changes
+func toolFilePaths(toolCall *llm.ToolCall) []string {
+	var paths []string
+	if filePath, ok := toolCall.Input["file_path"].(string); ok {
+		paths = append(paths, filePath)
+	}
+	switch toolCall.Name {
+	case "apply_patch":
+		if patch, ok := toolCall.Input["patch"].(string); ok {
+			paths = append(paths, tools.PatchFiles(patch)...)
+		}
+	case "git_restore":
+		switch v := toolCall.Input["paths"].(type) {
+		case string:
+			paths = append(paths, v)
+		case []interface{}:
+			for _, raw := range v {
+				if path, ok := raw.(string); ok {
+					paths = append(paths, path)
+				}
+			}
+		}
+	}
+	return paths
+}
+
 // getToolWarnings returns any warnings about the tool execution
 func (pm *PermissionManager) getToolWarnings(toolCall *llm.ToolCall) string {
 	warnings := []string{}
 
 	// Check for dangerous file operations
-	if filePath, ok := toolCall.Input["file_path"].(string); ok {
+	systemFiles, sensitiveFiles := false, false
+	for _, filePath := range toolFilePaths(toolCall) {
 		if strings.Contains(filePath, "/etc/") || strings.Contains(filePath, "/bin/") {
-			warnings = append(warnings, "Modifying system files")
+			systemFiles = true
 		}
 		if strings.Contains(strings.ToLower(filePath), ".env") || strings.Contains(strings.ToLower(filePath), ".key") {
-			warnings = append(warnings, "Accessing sensitive files")
+			sensitiveFiles = true
 		}
 	}
+	if systemFiles {
+		warnings = append(warnings, "Modifying system files")
+	}
+	if sensitiveFiles {
+		warnings = append(warnings, "Accessing sensitive files")
+	}
 
 	// Check for dangerous bash commands
 	if toolCall.Name == "bash" {
@@ -412,8 +616,8 @@ func (pm *PermissionManager) RequestPermissionAsync(toolCall *llm.ToolCall) <-ch
 		return responseChan
 	}
 
-	// Check if we have a cached decision for this tool pattern
-	if decision, exists := pm.matchesPattern(toolCall); exists {
+	// Check if we have a cached decision for this tool pattern, unless the call is escalated
+	if decision, exists := pm.RememberedDecision(toolCall); exists && !pm.RequiresPrompt(toolCall) {
 		responseChan := make(chan PermissionDecision, 1)
 		responseChan <- decision
 		close(responseChan)
@@ -441,8 +645,10 @@ func (pm *PermissionManager) RequestPermissionAsync(toolCall *llm.ToolCall) <-ch
 
 			// Cache the decision if it should be remembered
 			if decision.RememberChoice {
-				key := pm.generatePatternKey(toolCall)
+				key := pm.PermissionKey(toolCall)
+				pm.mu.Lock()
 				pm.patterns[key] = decision
+				pm.mu.Unlock()
 			}
 
 			decisionChan <- decision
@@ -464,27 +670,66 @@ func (pm *PermissionManager) RequestPermissionAsync(toolCall *llm.ToolCall) <-ch
 
 // matchesPattern checks if a tool call matches any cached permission patterns
 func (pm *PermissionManager) matchesPattern(toolCall *llm.ToolCall) (PermissionDecision, bool) {
-	key := pm.generatePatternKey(toolCall)
+	key := pm.PermissionKey(toolCall)
 	decision, exists := pm.patterns[key]
+	if exists && pm.expired(decision.Timestamp) {
+		return PermissionDecision{}, false
+	}
 	return decision, exists
 }
 
-// generatePatternKey generates a key for caching permission decisions
-func (pm *PermissionManager) generatePatternKey(toolCall *llm.ToolCall) string {
+// PermissionKey returns the key a decision on toolCall is remembered under: calls with
+// the same key are covered by one approval. The UI caches its decisions under the same
+// key, so both agree on what an approval covers. A nil manager gives the same keys,
+// except that config-defined tools are keyed by name.
+func (pm *PermissionManager) PermissionKey(toolCall *llm.ToolCall) string {
 	key := toolCall.Name
 
+	// Config-defined tools are keyed by the command their arguments make
+	if pm != nil && pm.toolArgs != nil {
+		if args, ok := pm.toolArgs(toolCall.Name, toolCall.Input); ok {
+			return fmt.Sprintf("%s:%q", key, args)
+		}
+	}
+
 	// Add file path if present
 	if filePath, ok := toolCall.Input["file_path"].(string); ok {
 		key += ":" + filePath
 	}
 
-	// Add command if present (for bash tools)
+	// Add command if present (for bash tools). The whole command is used, with its
+	// whitespace normalized, so approving one command doesn't approve another that
+	// merely starts with the same program.
 	if command, ok := toolCall.Input["command"].(string); ok {
-		// For commands, only use the first word to allow similar commands
-		parts := strings.Fields(command)
-		if len(parts) > 0 {
-			key += ":" + parts[0]
+		if normalized := strings.Join(strings.Fields(command), " "); normalized != "" {
+			key += ":" + normalized
+		}
+	}
+
+	switch toolCall.Name {
+	case "run_tests":
+		// Test runs are keyed by what they run, since the target picks the code executed
+		target, _ := toolCall.Input["target"].(string)
+		filter, _ := toolCall.Input["filter"].(string)
+		key += ":" + strings.TrimSpace(target) + ":" + strings.TrimSpace(filter)
+	case "apply_patch":
+		// Patches are keyed by the files they change, or the whole patch if it can't be read
+		patch, _ := toolCall.Input["patch"].(string)
+		if files := tools.PatchFiles(patch); len(files) > 0 {
+			key += ":" + strings.Join(files, ",")
+		} else {
+			key += ":" + patch
+		}
+	case "git_restore":
+		// Restores throw away changes, so they are keyed by the paths and the mode
+		staged, _ := toolCall.Input["staged"].(bool)
+		key += ":" + strings.Join(toolFilePaths(toolCall), ",") + ":" + strconv.FormatBool(staged)
+	case "git_stash_pop":
+		stash, _ := toolCall.Input["stash"].(string)
+		if stash = strings.TrimSpace(stash); stash == "" {
+			stash = "stash@{0}"
 		}
+		key += ":" + stash
 	}
 
 	return key
@@ -492,9 +737,219 @@ func (pm *PermissionManager) generatePatternKey(toolCall *llm.ToolCall) string {
 
 // AddSessionRule adds a permission rule for the current session
 func (pm *PermissionManager) AddSessionRule(rule PermissionRule) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
 	pm.sessionRules = append(pm.sessionRules, rule)
 }
 
+// SetRememberTTL sets how long remembered decisions and session rules stay in effect.
+// With no TTL they last until the session ends and are not saved with it.
+func (pm *PermissionManager) SetRememberTTL(ttl time.Duration) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	pm.rememberTTL = ttl
+}
+
+// RememberDecision remembers a decision for calls similar to toolCall, i.e. the same
+// tool on the same file or running the same command, so they are not prompted again
+func (pm *PermissionManager) RememberDecision(toolCall *llm.ToolCall, approved bool) {
+	if toolCall == nil {
+		return
+	}
+
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	pm.patterns[pm.PermissionKey(toolCall)] = PermissionDecision{
+		Approved:       approved,
+		RememberChoice: true,
+		ApplyToSimilar: true,
+		Reason:         "remembered user decision",
+		Timestamp:      time.Now(),
+	}
+}
+
+// RememberedDecision returns the unexpired remembered decision or session rule that
+// covers a tool call. Later session rules take precedence over earlier ones.
+func (pm *PermissionManager) RememberedDecision(toolCall *llm.ToolCall) (PermissionDecision, bool) {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+
+	if decision, ok := pm.matchesPattern(toolCall); ok {
+		return decision, true
+	}
+
+	for i := len(pm.sessionRules) - 1; i >= 0; i-- {
+		rule := pm.sessionRules[i]
+		if !pm.expired(rule.CreatedAt) && rule.matches(toolCall) {
+			return rule.Decision, true
+		}
+	}
+
+	return PermissionDecision{}, false
+}
+
+// expired reports whether a decision made at the given time has outlived the TTL
+func (pm *PermissionManager) expired(createdAt time.Time) bool {
+	return pm.rememberTTL > 0 && time.Since(createdAt) > pm.rememberTTL
+}
+
+// matches reports whether a session rule covers a tool call. The tool pattern is a
+// glob, the file pattern a glob or a directory ending in "/", and the command pattern
+// the leading words of the command. Empty patterns match anything.
+func (r PermissionRule) matches(toolCall *llm.ToolCall) bool {
+	if r.ToolPattern != "" {
+		if ok, _ := path.Match(r.ToolPattern, toolCall.Name); !ok {
+			return false
+		}
+	}
+
+	if r.FilePattern != "" {
+		filePath, _ := toolCall.Input["file_path"].(string)
+		if filePath == "" || !matchFilePattern(r.FilePattern, filePath) {
+			return false
+		}
+	}
+
+	if r.CommandPattern != "" {
+		command, _ := toolCall.Input["command"].(string)
+		command = strings.TrimSpace(command)
+		if command != r.CommandPattern && !strings.HasPrefix(command, r.CommandPattern+" ") {
+			return false
+		}
+	}
+
+	return true
+}
+
+// matchFilePattern matches a file path against a rule's file pattern. Globs without a
+// slash match the file name, so "*.go" covers Go files in any directory, and "**"
+// matches any number of directories, so "internal/**" covers everything under internal.
+func matchFilePattern(pattern, filePath string) bool {
+	filePath = filepath.ToSlash(filepath.Clean(filePath))
+
+	if strings.HasSuffix(pattern, "/") {
+		return strings.HasPrefix(filePath+"/", pattern)
+	}
+	if !strings.Contains(pattern, "/") {
+		filePath = path.Base(filePath)
+	}
+	return matchPathSegments(strings.Split(pattern, "/"), strings.Split(filePath, "/"))
+}
+
+// matchPathSegments matches path segments against glob segments, where a "**" segment
+// matches zero or more path segments
+func matchPathSegments(pattern, segments []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := len(segments); i >= 0; i-- {
+				if matchPathSegments(pattern[1:], segments[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(segments) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], segments[0]); !ok {
+			return false
+		}
+		pattern, segments = pattern[1:], segments[1:]
+	}
+	return len(segments) == 0
+}
+
+// SavedPermissions returns the unexpired remembered decisions and session rules for
+// saving with the session, or nil if there are none or no TTL is set
+func (pm *PermissionManager) SavedPermissions() *storage.SavedPermissions {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+
+	if pm.rememberTTL <= 0 {
+		return nil
+	}
+
+	saved := &storage.SavedPermissions{}
+	for key, decision := range pm.patterns {
+		if decision.RememberChoice && !pm.expired(decision.Timestamp) {
+			saved.Decisions = append(saved.Decisions, storage.SavedPermissionDecision{
+				Key:       key,
+				Approved:  decision.Approved,
+				Reason:    decision.Reason,
+				CreatedAt: decision.Timestamp,
+			})
+		}
+	}
+	sort.Slice(saved.Decisions, func(i, j int) bool {
+		return saved.Decisions[i].Key < saved.Decisions[j].Key
+	})
+
+	for _, rule := range pm.sessionRules {
+		if !pm.expired(rule.CreatedAt) {
+			saved.Rules = append(saved.Rules, storage.SavedPermissionRule{
+				Tool:      rule.ToolPattern,
+				File:      rule.FilePattern,
+				Command:   rule.CommandPattern,
+				Approved:  rule.Decision.Approved,
+				Reason:    rule.Decision.Reason,
+				CreatedAt: rule.CreatedAt,
+			})
+		}
+	}
+
+	if len(saved.Decisions) == 0 && len(saved.Rules) == 0 {
+		return nil
+	}
+	return saved
+}
+
+// RestorePermissions adds the decisions and rules saved with a session, keeping their
+// original times so they still expire on schedule. Expired entries, and all entries
+// when no TTL is set, are dropped. It returns the number restored.
+func (pm *PermissionManager) RestorePermissions(saved *storage.SavedPermissions) int {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	if saved == nil || pm.rememberTTL <= 0 {
+		return 0
+	}
+
+	restored := 0
+	for _, entry := range saved.Decisions {
+		if entry.Key == "" || pm.expired(entry.CreatedAt) {
+			continue
+		}
+		pm.patterns[entry.Key] = PermissionDecision{
+			Approved:       entry.Approved,
+			RememberChoice: true,
+			ApplyToSimilar: true,
+			Reason:         entry.Reason,
+			Timestamp:      entry.CreatedAt,
+		}
+		restored++
+	}
+
+	for _, entry := range saved.Rules {
+		if strings.TrimSpace(entry.Tool) == "" || pm.expired(entry.CreatedAt) {
+			continue
+		}
+		pm.sessionRules = append(pm.sessionRules, PermissionRule{
+			ToolPattern:    entry.Tool,
+			FilePattern:    entry.File,
+			CommandPattern: entry.Command,
+			Decision: PermissionDecision{
+				Approved:  entry.Approved,
+				Reason:    entry.Reason,
+				Timestamp: entry.CreatedAt,
+			},
+			CreatedAt: entry.CreatedAt,
+		})
+		restored++
+	}
+
+	return restored
+}
+
 // GetRiskReasons returns detailed reasons why a tool is considered risky
 func (pm *PermissionManager) GetRiskReasons(toolCall *llm.ToolCall) []string {
 	reasons := []string{}
@@ -521,6 +976,12 @@ func (pm *PermissionManager) GetRiskReasons(toolCall *llm.ToolCall) []string {
 		}
 	case "delete_file":
 		reasons = append(reasons, "File deletion")
+	case "run_tests":
+		reasons = append(reasons, "Executes the project's test code")
+	case "dependencies":
+		if usesNetwork(toolCall) {
+			reasons = append(reasons, "Network access to look up newer versions")
+		}
 	case "web_fetch":
 		reasons = append(reasons, "External network request")
 	}
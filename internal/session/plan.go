@@ -0,0 +1,118 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"github.com/tildaslashalef/bazinga/internal/llm"
+	"github.com/tildaslashalef/bazinga/internal/loggy"
+	"github.com/tildaslashalef/bazinga/internal/tools"
+)
+
+// PlanMode reports whether write tools are only previewed, not applied
+func (s *Session) PlanMode() bool {
+	s.planMu.Lock()
+	defer s.planMu.Unlock()
+	return s.planMode
+}
+
+// SetPlanMode turns plan mode on or off. In plan mode write_file, edit_file,
+// create_file, delete_file and move_file show their diff without touching disk and
+// are held in a pending batch until ApplyPlan. Turning it off keeps the batch.
+func (s *Session) SetPlanMode(enabled bool) {
+	s.planMu.Lock()
+	defer s.planMu.Unlock()
+
+	s.planMode = enabled
+	if s.toolExecutor != nil {
+		s.toolExecutor.SetDryRun(enabled)
+	}
+}
+
+// PendingPlan describes the changes waiting for ApplyPlan, in order
+func (s *Session) PendingPlan() []string {
+	s.planMu.Lock()
+	defer s.planMu.Unlock()
+
+	descriptions := make([]string, len(s.pendingPlan))
+	for i := range s.pendingPlan {
+		descriptions[i] = describePlannedCall(&s.pendingPlan[i])
+	}
+	return descriptions
+}
+
+// DiscardPlan drops the pending changes and returns how many there were
+func (s *Session) DiscardPlan() int {
+	s.planMu.Lock()
+	defer s.planMu.Unlock()
+
+	discarded := len(s.pendingPlan)
+	s.pendingPlan = nil
+	if s.toolExecutor != nil {
+		s.toolExecutor.SetDryRun(s.planMode)
+	}
+	return discarded
+}
+
+// ApplyPlan runs the pending changes for real, in the order they were planned. It
+// stops at the first change that fails, which stays pending with the ones after it,
+// and returns how many were applied. Running /apply is the approval, so the changes
+// are not put through the permission prompt again.
+func (s *Session) ApplyPlan(ctx context.Context) (int, error) {
+	s.planMu.Lock()
+	defer s.planMu.Unlock()
+
+	if s.toolExecutor == nil {
+		return 0, fmt.Errorf("tool executor not available")
+	}
+
+	// Apply against disk, then start a fresh plan from the new state
+	s.toolExecutor.SetDryRun(false)
+	defer s.toolExecutor.SetDryRun(s.planMode)
+
+	for i := range s.pendingPlan {
+		toolCall := &s.pendingPlan[i]
+		if _, err := s.toolExecutor.ExecuteTool(ctx, toolCall); err != nil {
+			loggy.Warn("Planned change failed to apply", "tool_name", toolCall.Name, "error", err)
+			s.pendingPlan = s.pendingPlan[i:]
+			return i, fmt.Errorf("%s: %w", describePlannedCall(toolCall), err)
+		}
+	}
+
+	applied := len(s.pendingPlan)
+	s.pendingPlan = nil
+	return applied, nil
+}
+
+// planToolCall adds a previewed tool call to the pending batch
+func (s *Session) planToolCall(toolCall *llm.ToolCall) {
+	s.planMu.Lock()
+	defer s.planMu.Unlock()
+	s.pendingPlan = append(s.pendingPlan, *toolCall)
+}
+
+// isPlannedCall reports whether a tool call is only previewed because plan mode is on
+func (s *Session) isPlannedCall(toolCall *llm.ToolCall) bool {
+	return s.PlanMode() && tools.IsPlannedTool(toolCall.Name)
+}
+
+// describePlannedCall returns a short description of a planned change, e.g. "edit main.go"
+func describePlannedCall(toolCall *llm.ToolCall) string {
+	filePath, _ := toolCall.Input["file_path"].(string)
+
+	switch toolCall.Name {
+	case "write_file":
+		return "write " + filePath
+	case "edit_file":
+		return "edit " + filePath
+	case "create_file":
+		return "create " + filePath
+	case "delete_file":
+		return "delete " + filePath
+	case "move_file":
+		source, _ := toolCall.Input["source_path"].(string)
+		dest, _ := toolCall.Input["dest_path"].(string)
+		return fmt.Sprintf("move %s → %s", source, dest)
+	default:
+		return toolCall.Name
+	}
+}
@@ -0,0 +1,80 @@
+package session
+
+import (
+	"context"
+	"github.com/tildaslashalef/bazinga/internal/llm"
+	"github.com/tildaslashalef/bazinga/internal/tools"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlanMode_ApplyPlan(t *testing.T) {
+	dir := t.TempDir()
+	mainPath := filepath.Join(dir, "main.go")
+	require.NoError(t, os.WriteFile(mainPath, []byte("package main\n"), 0o644))
+
+	s := &Session{RootPath: dir, toolExecutor: tools.NewToolExecutor(dir)}
+	s.SetPlanMode(true)
+
+	var applied []tools.FileChange
+	s.toolExecutor.SetFileChangeCallback(func(change tools.FileChange) {
+		applied = append(applied, change)
+	})
+
+	ctx := context.Background()
+	calls := []llm.ToolCall{
+		{ID: "1", Name: "write_file", Input: map[string]interface{}{"file_path": "main.go", "content": "package main\n\nfunc main() {}\n"}},
+		{ID: "2", Name: "create_file", Input: map[string]interface{}{"file_path": "util.go", "content": "package main\n"}},
+	}
+	for i := range calls {
+		require.NoError(t, s.executeToolCallWithNotification(ctx, &calls[i], nil, nil))
+	}
+
+	assert.Equal(t, []string{"write main.go", "create util.go"}, s.PendingPlan())
+	assert.Empty(t, applied, "planned changes should not be applied yet")
+	content, _ := os.ReadFile(mainPath)
+	assert.Equal(t, "package main\n", string(content))
+
+	count, err := s.ApplyPlan(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.Empty(t, s.PendingPlan())
+	assert.Len(t, applied, 2)
+	assert.True(t, s.PlanMode(), "applying should leave plan mode on")
+
+	content, _ = os.ReadFile(mainPath)
+	assert.Equal(t, "package main\n\nfunc main() {}\n", string(content))
+	assert.FileExists(t, filepath.Join(dir, "util.go"))
+}
+
+func TestPlanMode_ApplyPlanStopsAtFailure(t *testing.T) {
+	dir := t.TempDir()
+
+	s := &Session{RootPath: dir, toolExecutor: tools.NewToolExecutor(dir)}
+	s.SetPlanMode(true)
+
+	ctx := context.Background()
+	calls := []llm.ToolCall{
+		{ID: "1", Name: "create_file", Input: map[string]interface{}{"file_path": "a.go", "content": "package a\n"}},
+		{ID: "2", Name: "create_file", Input: map[string]interface{}{"file_path": "b.go", "content": "package b\n"}},
+	}
+	for i := range calls {
+		require.NoError(t, s.executeToolCallWithNotification(ctx, &calls[i], nil, nil))
+	}
+
+	// b.go appears on disk before the plan is applied
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.go"), []byte("package other\n"), 0o644))
+
+	count, err := s.ApplyPlan(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "create b.go")
+	assert.Equal(t, 1, count)
+	assert.Equal(t, []string{"create b.go"}, s.PendingPlan(), "the failed change should stay pending")
+
+	assert.Equal(t, 1, s.DiscardPlan())
+	assert.Empty(t, s.PendingPlan())
+}
@@ -2,31 +2,74 @@ package session
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 )
 
+// projectSystemPromptFile is the file in the project root that, when present, replaces
+// the default base system prompt
+const projectSystemPromptFile = ".bazinga/system_prompt.md"
+
 // buildBazingaPrompt creates a natural, helpful Bazinga system prompt
 func (s *Session) buildBazingaPrompt() string {
-	// Check if MEMORY.md contains a complete system prompt template
-	if s.memoryContent != nil && s.memoryContent.ProjectMemory != "" {
-		// If MEMORY.md starts with "You are" or contains system prompt patterns, use it as the main prompt
-		projectMemory := strings.TrimSpace(s.memoryContent.ProjectMemory)
-		if s.isSystemPromptTemplate(projectMemory) {
-			// Use MEMORY.md as the complete system prompt with minimal additions
-			prompt := projectMemory
-
-			// Add only essential context (files, user preferences)
-			additionalContext := s.buildAdditionalContext()
-			if additionalContext != "" {
-				prompt += "\n\n" + additionalContext
-			}
-
-			return prompt
+	// A project's own base prompt is used verbatim in place of the default
+	prompt := s.projectSystemPrompt()
+	if prompt == "" {
+		prompt = defaultSystemPrompt
+	}
+
+	// Add memory context with better organization and hierarchy
+	memorySection := s.buildMemorySection()
+	if memorySection != "" {
+		prompt += "\n\n" + memorySection
+	}
+
+	// The prompt mentions tools the configuration may have turned off
+	if s.toolExecutor != nil {
+		if _, disabled := s.toolExecutor.ToolNames(); len(disabled) > 0 {
+			prompt += "\n\n## Disabled Tools\n\nThese tools are turned off in this configuration and can't be called: " +
+				strings.Join(disabled, ", ") + ". Work with the tools you have, and tell the user when a task needs one of these."
 		}
 	}
 
-	// Bazinga system prompt
-	prompt := `You are Bazinga, an AI coding assistant that helps with software development. You're precise, thorough, and focused on understanding code deeply before making suggestions.
+	return prompt
+}
+
+// SystemPrompt returns the system prompt as the next request will send it: the base
+// prompt with memory, files, project structure and the response language added
+func (s *Session) SystemPrompt() string {
+	if s.contextManager == nil {
+		return s.buildBazingaPrompt()
+	}
+	prompt, _ := s.contextManager.buildEnhancedSystemMessage(s).Content.(string)
+	return prompt
+}
+
+// SystemPromptFile returns the path of the project's system prompt file, or "" when
+// the project has none and the default prompt is used
+func (s *Session) SystemPromptFile() string {
+	if s.projectSystemPrompt() == "" {
+		return ""
+	}
+	return filepath.Join(s.RootPath, projectSystemPromptFile)
+}
+
+// projectSystemPrompt returns the content of the project's system prompt file, or ""
+// when it is missing or empty
+func (s *Session) projectSystemPrompt() string {
+	content, err := os.ReadFile(filepath.Join(s.RootPath, projectSystemPromptFile))
+	if err != nil {
+		return ""
+	}
+	if strings.TrimSpace(string(content)) == "" {
+		return ""
+	}
+	return strings.TrimRight(string(content), " \t\r\n")
+}
+
+// defaultSystemPrompt is the base system prompt for projects without their own
+const defaultSystemPrompt = `You are Bazinga, an AI coding assistant that helps with software development. You're precise, thorough, and focused on understanding code deeply before making suggestions.
 
 ## Core Capabilities
 
@@ -65,7 +108,10 @@ You have access to comprehensive development tools:
 - "run make build" → Use bash tool with command "make build"
 - "build the project" → Use bash tool with appropriate build command
 - "install dependencies" → Use bash tool with npm install, go mod download, etc.
-- "run tests" → Use bash tool with test command
+- "run tests" → Use the run_tests tool; bash for projects it doesn't support
+- "what does this depend on" → Use the dependencies tool rather than reading the manifest
+- "why is this code like this" → Use git_blame on the lines in question, then git_log for context
+- "what did this look like before" → Use git_show with a ref and file_path
 - "find function X" → Use grep tool to search for function definitions
 - "where is file Y" → Use find or fuzzy_search tool
 
@@ -147,15 +193,6 @@ You have access to comprehensive development tools:
 
 You maintain context across the conversation and can reference previously read files. Focus on providing accurate, helpful assistance based on deep understanding of the codebase.`
 
-	// Add memory context with better organization and hierarchy
-	memorySection := s.buildMemorySection()
-	if memorySection != "" {
-		prompt += "\n\n" + memorySection
-	}
-
-	return prompt
-}
-
 // buildMemorySection creates a well-formatted memory section with proper hierarchy
 func (s *Session) buildMemorySection() string {
 	var sections []string
@@ -182,8 +219,8 @@ func (s *Session) buildMemorySection() string {
 	}
 
 	// Project Structure section
-	if s.project != nil {
-		projectSummary := s.project.GetProjectSummary()
+	if proj := s.GetProject(); proj != nil {
+		projectSummary := proj.GetProjectSummary()
 		if projectSummary != "No project detected" && projectSummary != "" {
 			sections = append(sections, "## Project Structure")
 			sections = append(sections, s.formatProjectStructure(projectSummary))
@@ -308,65 +345,3 @@ func (s *Session) formatImportedFiles() string {
 	}
 	return strings.Join(fileLines, "\n")
 }
-
-// isSystemPromptTemplate detects if content appears to be a complete system prompt
-func (s *Session) isSystemPromptTemplate(content string) bool {
-	content = strings.TrimSpace(content)
-	if content == "" {
-		return false
-	}
-
-	// Check for common system prompt patterns
-	patterns := []string{
-		"You are an expert",
-		"You are a",
-		"You are specialized",
-		"# TASK",
-		"# RESPONSE INSTRUCTIONS",
-		"```\nYou are", // Template blocks
-	}
-
-	contentLower := strings.ToLower(content)
-	for _, pattern := range patterns {
-		if strings.Contains(contentLower, strings.ToLower(pattern)) {
-			return true
-		}
-	}
-
-	// Check if it starts with a typical system prompt beginning
-	if strings.HasPrefix(contentLower, "you are") {
-		return true
-	}
-
-	return false
-}
-
-// buildAdditionalContext builds minimal context when using MEMORY.md as main prompt
-func (s *Session) buildAdditionalContext() string {
-	var sections []string
-
-	// User Preferences (always useful)
-	if s.memoryContent != nil && s.memoryContent.UserMemory != "" {
-		sections = append(sections, "## User Preferences")
-		sections = append(sections, s.formatMemoryContent(s.memoryContent.UserMemory))
-		sections = append(sections, "")
-	}
-
-	// Current Session Files (essential for tool usage)
-	if len(s.Files) > 0 {
-		sections = append(sections, "## Current Session Files")
-		sections = append(sections, s.formatSessionFiles())
-		sections = append(sections, "")
-	}
-
-	if len(sections) == 0 {
-		return ""
-	}
-
-	// Remove trailing empty line
-	if len(sections) > 0 && sections[len(sections)-1] == "" {
-		sections = sections[:len(sections)-1]
-	}
-
-	return strings.Join(sections, "\n")
-}
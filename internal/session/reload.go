@@ -0,0 +1,230 @@
+package session
+
+import (
+	"fmt"
+	"github.com/tildaslashalef/bazinga/internal/config"
+	"github.com/tildaslashalef/bazinga/internal/llm"
+	"github.com/tildaslashalef/bazinga/internal/loggy"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ConfigLoader reads the configuration file again
+type ConfigLoader func() (*config.Config, error)
+
+// ProviderFactory creates the named provider from a configuration. It returns a nil
+// provider when the provider is disabled or has no credentials.
+type ProviderFactory func(cfg *config.Config, name string) (llm.Provider, error)
+
+// ReloadResult describes what a configuration reload changed
+type ReloadResult struct {
+	Changed   []string // Settings that differ from the running configuration, as YAML keys
+	Providers []string // What happened to providers whose settings changed
+	Warnings  []string // Changes that could not be applied
+}
+
+// SetConfigReloader enables ReloadConfig with the functions that read the
+// configuration and create providers from it
+func (m *Manager) SetConfigReloader(load ConfigLoader, newProvider ProviderFactory) {
+	m.loadConfig = load
+	m.newProvider = newProvider
+}
+
+// ReloadConfig reads the configuration again and applies it to the running program and
+// to the session, keeping its history. A configuration that fails to load is rejected
+// and the running one stays in effect.
+func (m *Manager) ReloadConfig(session *Session) (*ReloadResult, error) {
+	if m.loadConfig == nil {
+		return nil, fmt.Errorf("configuration reload is not available")
+	}
+
+	cfg, err := m.loadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ReloadResult{Changed: config.Diff(m.config, cfg)}
+	if len(result.Changed) == 0 {
+		return result, nil
+	}
+
+	// Update in place: sessions and the UI share this config and read most settings
+	// from it when they need them
+	*m.config = *cfg
+
+	if changedUnder(result.Changed, "logging") {
+		if err := loggy.Reconfigure(&m.config.Logging); err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("logging: %v", err))
+		}
+	}
+
+	m.reloadProviders(result)
+	if changedUnder(result.Changed, "llm.rate_limit") {
+		m.applyRateLimit()
+	}
+	if changed(result.Changed, "llm.default_provider") {
+		_ = m.llmManager.SetDefaultProvider(m.config.LLM.DefaultProvider)
+	}
+	m.applyConfig(session, result)
+
+	loggy.Info("Configuration reloaded", "changed", result.Changed)
+	return result, nil
+}
+
+// reloadProviders re-creates the providers whose settings changed. Changed retry
+// settings apply to every provider.
+func (m *Manager) reloadProviders(result *ReloadResult) {
+	names := make(map[string]bool)
+	for _, key := range result.Changed {
+		if rest, ok := strings.CutPrefix(key, "providers."); ok {
+			name, _, _ := strings.Cut(rest, ".")
+			names[name] = true
+		}
+	}
+	if changedUnder(result.Changed, "llm.retry") {
+		for _, name := range m.llmManager.ListProviders() {
+			names[name] = true
+		}
+	}
+	if len(names) == 0 {
+		return
+	}
+	if m.newProvider == nil {
+		result.Warnings = append(result.Warnings, "providers: restart to apply provider changes")
+		return
+	}
+
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	for _, name := range sorted {
+		_, err := m.llmManager.GetProvider(name)
+		running := err == nil
+
+		provider, err := m.newProvider(m.config, name)
+		if err != nil {
+			// Keep what works rather than lose the provider to a typo
+			if running {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("%s: %v (keeping the running provider)", name, err))
+			} else {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("%s: %v", name, err))
+			}
+			continue
+		}
+		if provider == nil && !running {
+			continue // Neither enabled before nor now
+		}
+		if err := m.llmManager.ReplaceProvider(name, provider); err != nil {
+			loggy.Warn("Failed to close replaced provider", "provider", name, "error", err)
+		}
+
+		switch {
+		case provider == nil:
+			result.Providers = append(result.Providers, name+" removed")
+		case running:
+			result.Providers = append(result.Providers, name+" re-created")
+		default:
+			result.Providers = append(result.Providers, name+" added")
+		}
+	}
+}
+
+// applyConfig applies the settings a session copied when it was created
+func (m *Manager) applyConfig(session *Session, result *ReloadResult) {
+	if session == nil {
+		return
+	}
+
+	available := make(map[string]bool)
+	for _, name := range m.llmManager.ListProviders() {
+		available[name] = true
+	}
+
+	// Follow a changed default provider, and leave a provider that is gone
+	provider := session.Provider
+	if changed(result.Changed, "llm.default_provider") {
+		if available[m.config.LLM.DefaultProvider] {
+			provider = m.config.LLM.DefaultProvider
+		} else {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("llm.default_provider: %s is not available", m.config.LLM.DefaultProvider))
+		}
+	}
+	if !available[provider] {
+		provider = ""
+		if available[m.config.LLM.DefaultProvider] {
+			provider = m.config.LLM.DefaultProvider
+		} else if names := m.llmManager.ListProviders(); len(names) > 0 {
+			sort.Strings(names)
+			provider = names[0]
+		}
+	}
+	if provider != session.Provider {
+		if provider == "" {
+			session.Provider = ""
+		} else if err := session.SetProvider(provider); err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("provider: %v", err))
+		}
+	}
+
+	if changed(result.Changed, "llm.default_model") {
+		_ = session.SetModel(m.config.LLM.DefaultModel)
+	}
+	if changed(result.Changed, "llm.response_language") {
+		session.SetResponseLanguage(m.config.LLM.ResponseLanguage)
+	}
+
+	if session.contextManager != nil {
+		session.contextManager.SetMaxTokens(m.config.LLM.MaxTokens)
+		session.contextManager.SetHistoryWindow(m.config.LLM.HistoryWindow)
+		session.contextManager.SetAutoCompact(m.config.LLM.AutoCompact)
+	}
+
+	if session.permissionManager != nil {
+		session.permissionManager.SetRememberTTL(time.Duration(m.config.Security.RememberTTLHours) * time.Hour)
+		session.permissionManager.SetConfigRules(m.config.Security.PermissionRules, session.RootPath)
+	}
+	if session.toolExecutor != nil && changedUnder(result.Changed, "tools") {
+		m.configureToolExecutor(session.toolExecutor, session.permissionManager)
+	}
+	if session.toolExecutor != nil && changedUnder(result.Changed, "git") {
+		session.toolExecutor.SetCommitHooks(m.config.Git.PreCommitCommand, m.config.Git.SkipHooks)
+	}
+	if changedUnder(result.Changed, "project") {
+		if _, _, err := session.RescanProject(); err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("project: %v", err))
+		}
+	}
+	session.syncContextTokenLimit()
+}
+
+// changed reports whether key is one of the changed settings
+func changed(keys []string, key string) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// changedUnder reports whether any changed setting is in the section prefix
+func changedUnder(keys []string, prefix string) bool {
+	for _, k := range keys {
+		if k == prefix || strings.HasPrefix(k, prefix+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// ReloadConfig reads the configuration again and applies it to this session
+func (s *Session) ReloadConfig() (*ReloadResult, error) {
+	if s.manager == nil {
+		return nil, fmt.Errorf("configuration reload is not available")
+	}
+	return s.manager.ReloadConfig(s)
+}
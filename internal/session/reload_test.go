@@ -0,0 +1,98 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"github.com/tildaslashalef/bazinga/internal/config"
+	"github.com/tildaslashalef/bazinga/internal/llm"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestReloadConfig tests that a reload applies changed settings to the session and
+// re-creates providers whose settings changed, keeping the history
+func TestReloadConfig(t *testing.T) {
+	manager, llmManager := setupTestSessionManager()
+	session, err := manager.CreateSession(context.Background(), &CreateOptions{Name: "Reload Test"})
+	require.NoError(t, err)
+	session.History = append(session.History, llm.Message{Role: "user", Content: "keep me"})
+
+	next := *manager.config
+	next.LLM.DefaultModel = "gpt-4o"
+	next.LLM.Temperature = 0.3
+	next.Providers.OpenAI.APIKey = "sk-rotated"
+	next.Git.SkipHooks = true
+
+	var created []string
+	manager.SetConfigReloader(
+		func() (*config.Config, error) { reloaded := next; return &reloaded, nil },
+		func(cfg *config.Config, name string) (llm.Provider, error) {
+			created = append(created, name+":"+cfg.Providers.OpenAI.APIKey)
+			return &mockProvider{name: "rotated"}, nil
+		},
+	)
+
+	result, err := session.ReloadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"llm.default_model", "llm.temperature", "providers.openai.api_key", "git.skip_hooks"}, result.Changed)
+	assert.Equal(t, []string{"openai re-created"}, result.Providers)
+	assert.Equal(t, []string{"openai:sk-rotated"}, created)
+
+	assert.Equal(t, "gpt-4o", session.GetModel())
+	assert.Equal(t, 0.3, session.GetConfig().LLM.Temperature)
+	assert.True(t, session.toolExecutor.SkipCommitHooks(), "git settings apply to the tools")
+	provider, err := llmManager.GetProvider("openai")
+	require.NoError(t, err)
+	assert.Equal(t, "rotated", provider.Name())
+	assert.Equal(t, "keep me", session.History[len(session.History)-1].Content)
+
+	// Reloading the same configuration changes nothing
+	result, err = session.ReloadConfig()
+	require.NoError(t, err)
+	assert.Empty(t, result.Changed)
+}
+
+// TestReloadConfigRejectsInvalid tests that a config that fails to load leaves the
+// running one in effect
+func TestReloadConfigRejectsInvalid(t *testing.T) {
+	manager, _ := setupTestSessionManager()
+	session, err := manager.CreateSession(context.Background(), &CreateOptions{Name: "Reload Test"})
+	require.NoError(t, err)
+
+	_, err = session.ReloadConfig()
+	assert.ErrorContains(t, err, "not available")
+
+	manager.SetConfigReloader(func() (*config.Config, error) {
+		return nil, errors.New("yaml: line 3: did not find expected key")
+	}, nil)
+
+	_, err = session.ReloadConfig()
+	assert.ErrorContains(t, err, "did not find expected key")
+	assert.Equal(t, "gpt-4", session.GetConfig().LLM.DefaultModel)
+}
+
+// TestReloadConfigRemovedProvider tests that a session moves off a provider the new
+// configuration disables
+func TestReloadConfigRemovedProvider(t *testing.T) {
+	manager, llmManager := setupTestSessionManager()
+	session, err := manager.CreateSession(context.Background(), &CreateOptions{Name: "Reload Test"})
+	require.NoError(t, err)
+	require.NoError(t, session.SetProvider("openai"))
+	manager.config.Providers.OpenAI.Enabled = true
+
+	next := *manager.config
+	next.LLM.DefaultProvider = "anthropic"
+	next.Providers.OpenAI.Enabled = false
+	manager.SetConfigReloader(
+		func() (*config.Config, error) { reloaded := next; return &reloaded, nil },
+		func(cfg *config.Config, name string) (llm.Provider, error) { return nil, nil },
+	)
+
+	result, err := session.ReloadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"openai removed"}, result.Providers)
+	assert.Equal(t, "anthropic", session.GetProvider())
+	assert.NotContains(t, llmManager.ListProviders(), "openai")
+}
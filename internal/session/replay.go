@@ -0,0 +1,116 @@
+package session
+
+import (
+	"fmt"
+	"github.com/tildaslashalef/bazinga/internal/llm"
+	"github.com/tildaslashalef/bazinga/internal/storage"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// ReplayKind identifies what a replay step shows
+type ReplayKind string
+
+const (
+	ReplaySystem     ReplayKind = "system"
+	ReplayUser       ReplayKind = "user"
+	ReplayAssistant  ReplayKind = "assistant"
+	ReplayToolCall   ReplayKind = "tool_call"
+	ReplayToolResult ReplayKind = "tool_result"
+)
+
+// ReplayStep is one message or tool action of a saved conversation
+type ReplayStep struct {
+	Kind       ReplayKind
+	Content    string
+	ToolName   string
+	ToolCallID string
+	ToolInput  map[string]interface{} // Set for tool calls
+	IsError    bool                   // Set for tool results that reported an error
+}
+
+// legacyToolResultPattern matches tool results saved as user turns by older versions
+var legacyToolResultPattern = regexp.MustCompile(`(?s)^<tool_result tool="([^"]*)" tool_id="([^"]*)"( error="true")?>\n?(.*?)\n?</tool_result>$`)
+
+// IsReadOnlyTool reports whether a built-in tool only reads files or repository state,
+// so it is safe to run again when replaying a session
+func IsReadOnlyTool(name string) bool {
+	return slices.Contains(readOnlyTools, name)
+}
+
+// BuildReplay turns a conversation history into the sequence of steps shown by replay.
+// Assistant turns are split into their text and one step per tool call, and results
+// flattened into user turns are recognised as tool results again.
+func BuildReplay(history []llm.Message) []ReplayStep {
+	var steps []ReplayStep
+	callNames := make(map[string]string)
+
+	for _, msg := range history {
+		content := llm.ContentText(msg.Content)
+
+		switch msg.Role {
+		case "assistant":
+			if strings.TrimSpace(content) != "" {
+				steps = append(steps, ReplayStep{Kind: ReplayAssistant, Content: content})
+			}
+			for _, call := range msg.ToolCalls {
+				callNames[call.ID] = call.CallName()
+				steps = append(steps, ReplayStep{
+					Kind:       ReplayToolCall,
+					ToolName:   call.CallName(),
+					ToolCallID: call.ID,
+					ToolInput:  call.CallInput(),
+				})
+			}
+
+		case "tool":
+			name := msg.Name
+			if name == "" {
+				name = callNames[msg.ToolCallID]
+			}
+			steps = append(steps, ReplayStep{
+				Kind:       ReplayToolResult,
+				Content:    content,
+				ToolName:   name,
+				ToolCallID: msg.ToolCallID,
+				IsError:    msg.IsError,
+			})
+
+		case "user":
+			if match := legacyToolResultPattern.FindStringSubmatch(content); match != nil {
+				steps = append(steps, ReplayStep{
+					Kind:       ReplayToolResult,
+					Content:    match[4],
+					ToolName:   match[1],
+					ToolCallID: match[2],
+					IsError:    match[3] != "",
+				})
+				continue
+			}
+			steps = append(steps, ReplayStep{Kind: ReplayUser, Content: content})
+
+		case "system":
+			if strings.TrimSpace(content) != "" {
+				steps = append(steps, ReplayStep{Kind: ReplaySystem, Content: content})
+			}
+		}
+	}
+
+	return steps
+}
+
+// LoadReplay reads a saved session and returns it with its replay steps. The session
+// is not reopened, so no provider, file watcher or tool executor is needed.
+func (m *Manager) LoadReplay(sessionID string) (*storage.SerializableSession, []ReplayStep, error) {
+	if m.storage == nil {
+		return nil, nil, fmt.Errorf("session storage not available")
+	}
+
+	saved, err := m.storage.LoadSession(sessionID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load session: %w", err)
+	}
+
+	return saved, BuildReplay(m.restoreHistory(saved.History)), nil
+}
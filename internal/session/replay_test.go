@@ -0,0 +1,68 @@
+package session
+
+import (
+	"context"
+	"github.com/tildaslashalef/bazinga/internal/llm"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLoadReplayReconstructsSequence tests that replay rebuilds the conversation and
+// tool actions of a saved session in order
+func TestLoadReplayReconstructsSequence(t *testing.T) {
+	manager, _ := setupTestSessionManager()
+
+	session, err := manager.CreateSession(context.Background(), &CreateOptions{Name: "Replay Session"})
+	require.NoError(t, err)
+
+	read := llm.ToolCall{ID: "toolu_1", Name: "read_file", Input: map[string]interface{}{"file_path": "main.go"}}
+	write := llm.ToolCall{ID: "toolu_2", Name: "write_file", Input: map[string]interface{}{"file_path": "out.txt"}}
+	session.History = []llm.Message{
+		{Role: "system", Content: "You are a helpful assistant"},
+		{Role: "user", Content: "What does main.go do?"},
+		{Role: "assistant", Content: "Let me look.", ToolCalls: []llm.ToolCall{read}},
+		llm.NewToolResultMessage(&read, "package main", false),
+		{Role: "assistant", ToolCalls: []llm.ToolCall{write}},
+		llm.FlattenToolResult(llm.NewToolResultMessage(&write, "Error: permission denied", true)),
+		{Role: "assistant", Content: "It is the entry point."},
+	}
+	require.NoError(t, manager.SaveSession(session))
+
+	saved, steps, err := manager.LoadReplay(session.ID)
+	require.NoError(t, err)
+	assert.Equal(t, session.ID, saved.ID)
+
+	kinds := make([]ReplayKind, len(steps))
+	for i, step := range steps {
+		kinds[i] = step.Kind
+	}
+	assert.Equal(t, []ReplayKind{
+		ReplaySystem, ReplayUser, ReplayAssistant, ReplayToolCall, ReplayToolResult,
+		ReplayToolCall, ReplayToolResult, ReplayAssistant,
+	}, kinds)
+
+	assert.Equal(t, "What does main.go do?", steps[1].Content)
+	assert.Equal(t, "read_file", steps[3].ToolName)
+	assert.Equal(t, "main.go", steps[3].ToolInput["file_path"])
+	assert.Equal(t, "toolu_1", steps[4].ToolCallID)
+	assert.Equal(t, "package main", steps[4].Content)
+	assert.False(t, steps[4].IsError)
+
+	// A result flattened into a user turn is still shown as a tool result
+	assert.Equal(t, "write_file", steps[6].ToolName)
+	assert.Equal(t, "toolu_2", steps[6].ToolCallID)
+	assert.Equal(t, "Error: permission denied", steps[6].Content)
+	assert.True(t, steps[6].IsError)
+
+	assert.Equal(t, "It is the entry point.", steps[7].Content)
+}
+
+// TestIsReadOnlyTool tests which tools replay may run again
+func TestIsReadOnlyTool(t *testing.T) {
+	assert.True(t, IsReadOnlyTool("read_file"))
+	assert.True(t, IsReadOnlyTool("git_diff"))
+	assert.False(t, IsReadOnlyTool("write_file"))
+	assert.False(t, IsReadOnlyTool("bash"))
+}
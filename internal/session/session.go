@@ -1,16 +1,19 @@
 package session
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/tildaslashalef/bazinga/internal/config"
 	"github.com/tildaslashalef/bazinga/internal/llm"
 	"github.com/tildaslashalef/bazinga/internal/loggy"
 	"github.com/tildaslashalef/bazinga/internal/memory"
 	"github.com/tildaslashalef/bazinga/internal/project"
+	"github.com/tildaslashalef/bazinga/internal/storage"
 	"github.com/tildaslashalef/bazinga/internal/tools"
 	"github.com/tildaslashalef/bazinga/internal/watcher"
 	"math/rand"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-git/go-git/v5"
@@ -36,8 +39,6 @@ type Session struct {
 	llmManager        *llm.Manager
 	config            *config.Config
 	gitRepo           *git.Repository
-	project           *project.Project
-	promptBuilder     *project.PromptBuilder
 	fileWatcher       *watcher.FileWatcher
 	toolExecutor      *tools.ToolExecutor
 	contextManager    *ContextManager
@@ -45,6 +46,44 @@ type Session struct {
 	memoryContent     *memory.MemoryContent
 	permissionManager *PermissionManager
 	toolQueue         *ToolQueue
+
+	// Detected project, replaced by rescans and by tool changes while prompts and the UI
+	// read it; a project once set is never changed in place
+	projectMu     sync.RWMutex
+	project       *project.Project
+	promptBuilder *project.PromptBuilder
+
+	// Plan mode and the write tool calls it is holding back until /apply
+	planMu      sync.Mutex
+	planMode    bool
+	pendingPlan []llm.ToolCall
+
+	// Files the assistant modified this session, relative to RootPath, and each change
+	// in the order it was made
+	touchedMu    sync.Mutex
+	touchedFiles map[string]bool
+	fileChanges  []RecordedChange
+
+	// Held while a session is named in the background after its first answer, and while
+	// the next message is added to History, so the two don't write the session at once
+	nameMu sync.Mutex
+
+	// Tokens and cost per model, from the counts the API reported
+	usageMu sync.Mutex
+	usage   []ModelUsage
+
+	// Tool calls run since the last user message, checked against tools.max_calls_per_request
+	turnToolCalls int
+
+	// Files @mentioned in the current message, sent along with it until the next one
+	turnMentions []FileMention
+
+	// Language the assistant answers in, set from config or /lang (empty = no preference)
+	responseLanguage string
+
+	// Last /overview reply and the request waiting to produce the next one
+	overview        *codebaseOverview
+	pendingOverview *pendingOverview
 }
 
 // CreateOptions contains options for creating a new session
@@ -67,6 +106,11 @@ func (s *Session) GetProvider() string {
 	return s.Provider
 }
 
+// HasProvider reports whether any LLM provider is available to send requests to
+func (s *Session) HasProvider() bool {
+	return s.llmManager != nil && len(s.llmManager.ListProviders()) > 0
+}
+
 // GetModel returns the current model
 func (s *Session) GetModel() string {
 	return s.Model
@@ -76,6 +120,7 @@ func (s *Session) GetModel() string {
 func (s *Session) SetModel(model string) error {
 	s.Model = model
 	s.UpdatedAt = time.Now()
+	s.syncContextTokenLimit()
 	loggy.Debug("Set model", "model", model)
 	return nil
 }
@@ -100,6 +145,7 @@ func (s *Session) SetProvider(provider string) error {
 
 	s.Provider = provider
 	s.UpdatedAt = time.Now()
+	s.syncContextTokenLimit()
 	loggy.Debug("Session provider changed", "provider", provider)
 	return nil
 }
@@ -126,15 +172,26 @@ func (s *Session) AddSystemMessage(message string) error {
 
 // GetProject returns the detected project information
 func (s *Session) GetProject() *project.Project {
+	s.projectMu.RLock()
+	defer s.projectMu.RUnlock()
 	return s.project
 }
 
+// setProject replaces the detected project and the prompt builder made from it
+func (s *Session) setProject(detected *project.Project) {
+	s.projectMu.Lock()
+	defer s.projectMu.Unlock()
+	s.project = detected
+	s.promptBuilder = project.NewPromptBuilder(detected)
+}
+
 // GetProjectSummary returns a summary of the detected project
 func (s *Session) GetProjectSummary() string {
-	if s.project == nil {
+	proj := s.GetProject()
+	if proj == nil {
 		return "No project detected"
 	}
-	return s.project.GetProjectSummary()
+	return proj.GetProjectSummary()
 }
 
 // GetFileWatcher returns the file watcher for this session
@@ -152,17 +209,111 @@ func (s *Session) GetPermissionManager() *PermissionManager {
 	return s.permissionManager
 }
 
+// SetRateLimitCallback sets a function called when a request will wait on the rate
+// limit for more than a second
+func (s *Session) SetRateLimitCallback(callback func(wait time.Duration)) {
+	if s.llmManager != nil {
+		s.llmManager.SetRateLimitCallback(callback)
+	}
+}
+
+// GetConfig returns the configuration the session was created with
+func (s *Session) GetConfig() *config.Config {
+	return s.config
+}
+
+// GetResponseLanguage returns the language the assistant is asked to respond in
+func (s *Session) GetResponseLanguage() string {
+	return s.responseLanguage
+}
+
+// SetResponseLanguage sets the language the assistant responds in; empty clears it
+func (s *Session) SetResponseLanguage(language string) {
+	s.responseLanguage = strings.TrimSpace(language)
+	loggy.Debug("Set response language", "language", s.responseLanguage)
+}
+
 // GetToolQueue returns the tool queue for this session
 func (s *Session) GetToolQueue() *ToolQueue {
 	return s.toolQueue
 }
 
-// IsTerminatorMode returns whether terminator mode is enabled (bypasses all permissions)
+// IsTerminatorMode returns whether terminator mode is enabled, bypassing some or all
+// permission prompts; TerminatorScope says which
 func (s *Session) IsTerminatorMode() bool {
-	if s.config == nil {
-		return false
+	return s.TerminatorScope() != ""
+}
+
+// EstimatePromptTokens estimates the size of the prompt that would be sent for a
+// pending message: the system prompt (memory, project and session files), the
+// windowed history as trimmed to the context window, and the tool definitions. An
+// empty message estimates the current context as-is.
+func (s *Session) EstimatePromptTokens(pending string) int {
+	if s.contextManager == nil {
+		return 0
+	}
+
+	history := s.History
+	if pending != "" {
+		history = append(history[:len(history):len(history)], llm.Message{Role: "user", Content: pending})
+	}
+
+	_, total := s.contextManager.buildContext(s, history)
+	return total + s.toolDefinitionTokens()
+}
+
+// EstimateTokens estimates the token count of text with the current provider's
+// estimator, or about four characters per token when no provider is available
+func (s *Session) EstimateTokens(text string) int {
+	if provider := s.currentProvider(); provider != nil {
+		return provider.EstimateTokens(text)
+	}
+	return len(text) / 4
+}
+
+// contextTokenLimit returns the current model's context window, or 0 if unknown
+func (s *Session) contextTokenLimit() int {
+	if provider := s.currentProvider(); provider != nil {
+		return provider.GetTokenLimit()
 	}
-	return s.config.Security.Terminator
+	return 0
+}
+
+// syncContextTokenLimit tells the tool executor the current model's context window, so
+// read_file can keep large files from overflowing it
+func (s *Session) syncContextTokenLimit() {
+	if s.toolExecutor != nil {
+		s.toolExecutor.SetContextTokenLimit(s.contextTokenLimit())
+	}
+}
+
+// toolDefinitionTokens estimates the tokens the tool definitions add to a request
+func (s *Session) toolDefinitionTokens() int {
+	toolDefs, err := json.Marshal(s.getAvailableTools())
+	if err != nil {
+		return 0
+	}
+	return s.EstimateTokens(string(toolDefs))
+}
+
+// currentProvider returns the session's provider, or nil if it is not available
+func (s *Session) currentProvider() llm.Provider {
+	if s.llmManager == nil {
+		return nil
+	}
+	provider, err := s.llmManager.GetProvider(s.Provider)
+	if err != nil {
+		return nil
+	}
+	return provider
+}
+
+// EstimatePromptCost estimates the prompt tokens for a pending message and their input
+// cost in USD using the current model's pricing. The cost is 0 when pricing is unknown.
+func (s *Session) EstimatePromptCost(pending string) (int, float64) {
+	tokens := s.EstimatePromptTokens(pending)
+	inputRate, _ := s.modelRates()
+	return tokens, float64(tokens) / 1000 * inputRate
 }
 
 // Save saves the session to storage
@@ -181,6 +332,12 @@ func (s *Session) Close() error {
 		loggy.Error("Failed to auto-save session on close", "session_id", s.ID, "error", err)
 	}
 
+	if s.toolExecutor != nil {
+		if err := s.toolExecutor.Close(); err != nil {
+			loggy.Warn("Failed to stop language server", "session_id", s.ID, "error", err)
+		}
+	}
+
 	if s.fileWatcher != nil {
 		return s.fileWatcher.Close()
 	}
@@ -196,6 +353,14 @@ func (s *Session) GetDryRun() bool         { return s.DryRun }
 func (s *Session) GetNoAutoCommit() bool   { return s.NoAutoCommit }
 func (s *Session) GetCreatedAt() time.Time { return s.CreatedAt }
 func (s *Session) GetUpdatedAt() time.Time { return s.UpdatedAt }
+
+// GetPermissions returns the remembered permission decisions to save with the session
+func (s *Session) GetPermissions() *storage.SavedPermissions {
+	if s.permissionManager == nil {
+		return nil
+	}
+	return s.permissionManager.SavedPermissions()
+}
 func (s *Session) GetHistory() []map[string]interface{} {
 	// Convert llm.Message slice to map slice for storage
 	var history []map[string]interface{}
@@ -204,6 +369,24 @@ func (s *Session) GetHistory() []map[string]interface{} {
 			"role":    msg.Role,
 			"content": msg.Content,
 		}
+		// Image data would crowd out the rest of the saved history, so only the text
+		// (which describes the image) is kept
+		if blocks, ok := msg.Content.([]llm.ContentBlock); ok {
+			msgMap["content"] = llm.ContentText(blocks)
+		}
+		// Tool calls and results keep the fields that pair them
+		if msg.Name != "" {
+			msgMap["name"] = msg.Name
+		}
+		if msg.ToolCallID != "" {
+			msgMap["tool_call_id"] = msg.ToolCallID
+		}
+		if msg.IsError {
+			msgMap["is_error"] = true
+		}
+		if len(msg.ToolCalls) > 0 {
+			msgMap["tool_calls"] = msg.ToolCalls
+		}
 		history = append(history, msgMap)
 	}
 	return history
@@ -231,7 +414,7 @@ func (s *Session) getBazingaStyleFiles(project *project.Project) []string {
 	// Priority 1: Essential project files (always include)
 	essentialFiles := []string{
 		"README.md", "readme.md", "Readme.md",
-		"package.json", "go.mod", "Cargo.toml", "pyproject.toml", "requirements.txt",
+		"package.json", "go.mod", "Cargo.toml", "pyproject.toml", "requirements.txt", "Gemfile",
 		"Makefile", "makefile", "CMakeLists.txt",
 		"tsconfig.json", "webpack.config.js", "vite.config.js",
 		".gitignore", "LICENSE", "CHANGELOG.md",
@@ -307,6 +490,10 @@ func (s *Session) getProjectEntryPoints(project *project.Project) []string {
 		return []string{"main.rs", "lib.rs", "src/main.rs", "src/lib.rs"}
 	case "java":
 		return []string{"Main.java", "Application.java", "src/main/"}
+	case "csharp":
+		return []string{".sln", ".csproj", "Program.cs", "Startup.cs"}
+	case "ruby":
+		return []string{"config/routes.rb", "config/application.rb", "lib/"}
 	default:
 		return []string{"main", "index", "app"}
 	}
@@ -325,6 +512,10 @@ func (s *Session) getArchitecturalFiles(project *project.Project) []string {
 		return []string{"src/", "benches/", "examples/"}
 	case "java":
 		return []string{"src/main/java/", "src/main/resources/", "src/test/"}
+	case "csharp":
+		return []string{"Controllers/", "Models/", "Services/", "Data/"}
+	case "ruby":
+		return []string{"app/models/", "app/controllers/", "lib/", "config/"}
 	default:
 		return []string{"src/", "lib/", "config/"}
 	}
@@ -370,8 +561,12 @@ func (s *Session) getSourceExtensions(projectType project.ProjectType) []string
 		return []string{".rs"}
 	case "java":
 		return []string{".java"}
+	case "csharp":
+		return []string{".cs"}
+	case "ruby":
+		return []string{".rb", ".rake"}
 	default:
-		return []string{".js", ".ts", ".py", ".go", ".rs", ".java", ".cpp", ".c", ".h"}
+		return []string{".js", ".ts", ".py", ".go", ".rs", ".java", ".cs", ".rb", ".cpp", ".c", ".h"}
 	}
 }
 
@@ -88,6 +88,30 @@ func TestCreateSession(t *testing.T) {
 	assert.NotEmpty(t, session.ID)
 	assert.False(t, session.CreatedAt.IsZero())
 	assert.False(t, session.UpdatedAt.IsZero())
+	assert.True(t, session.HasProvider())
+}
+
+// TestCreateSessionWithoutProvider tests that a session still starts when no provider has credentials
+func TestCreateSessionWithoutProvider(t *testing.T) {
+	cfg := &config.Config{
+		LLM: config.LLMConfig{
+			DefaultProvider: "bedrock",
+			DefaultModel:    "gpt-4",
+		},
+	}
+	manager := NewManager(llm.NewManager(), cfg)
+
+	session, err := manager.CreateSession(context.Background(), &CreateOptions{Name: "No Provider Session"})
+	require.NoError(t, err)
+	require.NotNil(t, session)
+
+	assert.False(t, session.HasProvider())
+	assert.Empty(t, session.Provider, "an unregistered default provider should not be kept")
+	assert.Empty(t, session.GetAvailableProviders())
+
+	// Commands that don't need the model keep working
+	assert.NoError(t, session.AddSystemMessage("Test system message"))
+	assert.NotNil(t, session.GetToolExecutor())
 }
 
 // TestSetProvider tests setting the provider for a session
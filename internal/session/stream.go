@@ -17,15 +17,24 @@ func (s *Session) ProcessMessage(ctx context.Context, message string) (*llm.Resp
 		Role:    "user",
 		Content: message,
 	}
+	// Results of earlier tool calls are cut down before the history is sent again
+	s.truncateToolResults()
 	s.History = append(s.History, userMsg)
 
+	// Files the message @mentions are sent with this turn only
+	var warnings []string
+	s.turnMentions, warnings = s.loadMentions(ctx, message)
+	for _, warning := range warnings {
+		loggy.Warn("File mention not sent", "warning", warning)
+	}
+
 	// Auto-save session after adding user message
 	if err := s.Save(); err != nil {
 		loggy.Warn("Failed to auto-save session after user message", "session_id", s.ID, "error", err)
 	}
 
 	// Use intelligent context management
-	messages, err := s.contextManager.BuildOptimizedContext(s, s.History, message)
+	messages, err := s.contextManager.BuildOptimizedContext(ctx, s, s.History, message)
 	if err != nil {
 		return nil, fmt.Errorf("failed to build context: %w", err)
 	}
@@ -49,6 +58,7 @@ func (s *Session) ProcessMessage(ctx context.Context, message string) (*llm.Resp
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate response: %w", err)
 	}
+	s.recordUsage(response.TokenUsage())
 
 	// Add assistant response to history
 	assistantMsg := llm.Message{
@@ -61,24 +71,63 @@ func (s *Session) ProcessMessage(ctx context.Context, message string) (*llm.Resp
 	return response, nil
 }
 
+// thinkDirective is prepended to /think questions so the model reasons instead of acting
+const thinkDirective = `Think carefully about the following before anything is changed. You have no tools available for this reply: do not attempt to read, edit, or run anything. Reason through the problem, call out trade-offs and risks, and finish with a concrete, numbered list of proposed next steps.`
+
 // ProcessMessageStream processes a user message with streaming AI response
 func (s *Session) ProcessMessageStream(ctx context.Context, message string) (<-chan *llm.StreamChunk, error) {
-	loggy.Debug("Session ProcessMessageStream", "starting", "true", "message", message)
+	return s.processMessageStream(ctx, message, true)
+}
+
+// ProcessThinkStream asks the AI to reason about a question and propose next steps.
+// Tools are withheld from the request, so the reply is text only and the session
+// carries no extra state into the next message.
+func (s *Session) ProcessThinkStream(ctx context.Context, question string) (<-chan *llm.StreamChunk, error) {
+	return s.processMessageStream(ctx, thinkDirective+"\n\n"+question, false)
+}
+
+// DropUnansweredMessage removes the last user message if nothing answered it, so a
+// request that failed before the model replied can be sent again without repeating it
+func (s *Session) DropUnansweredMessage() bool {
+	n := len(s.History)
+	if n == 0 || s.History[n-1].Role != "user" {
+		return false
+	}
+	s.History = s.History[:n-1]
+	return true
+}
+
+// processMessageStream sends a message and fans the streaming response out to the UI,
+// executing any tool calls when withTools is set
+func (s *Session) processMessageStream(ctx context.Context, message string, withTools bool) (<-chan *llm.StreamChunk, error) {
+	loggy.Debug("Session ProcessMessageStream", "starting", "true", "message", message, "with_tools", withTools)
 
 	// Add user message to history
 	userMsg := llm.Message{
 		Role:    "user",
 		Content: message,
 	}
+	// Naming after the previous answer may still be saving the session
+	s.nameMu.Lock()
+	// Results of earlier tool calls are cut down before the history is sent again
+	s.truncateToolResults()
 	s.History = append(s.History, userMsg)
 
+	// A new message gets a fresh tool budget
+	s.turnToolCalls = 0
+
+	// Files the message @mentions are read now and sent with this turn only
+	var notices []string
+	s.turnMentions, notices = s.loadMentions(ctx, message)
+
 	// Auto-save session after adding user message
 	if err := s.Save(); err != nil {
 		loggy.Warn("Failed to auto-save session after user message", "session_id", s.ID, "error", err)
 	}
+	s.nameMu.Unlock()
 
 	// Use intelligent context management
-	messages, err := s.contextManager.BuildOptimizedContext(s, s.History, message)
+	messages, err := s.contextManager.BuildOptimizedContext(ctx, s, s.History, message)
 	if err != nil {
 		loggy.Error("Session ProcessMessageStream", "context_build_failed", err)
 		return nil, fmt.Errorf("failed to build context: %w", err)
@@ -92,7 +141,9 @@ func (s *Session) ProcessMessageStream(ctx context.Context, message string) (<-c
 		Model:       s.Model,
 		MaxTokens:   s.config.LLM.MaxTokens,
 		Temperature: s.config.LLM.Temperature,
-		Tools:       s.getAvailableTools(),
+	}
+	if withTools {
+		req.Tools = s.getAvailableTools()
 	}
 
 	loggy.Debug("Session ProcessMessageStream", "llm_request_created", "true", "model", s.Model, "provider", s.Provider)
@@ -130,6 +181,11 @@ func (s *Session) ProcessMessageStream(ctx context.Context, message string) (<-c
 	loggy.Debug("Session ProcessMessageStream", "calling_provider_stream", "true", "provider", s.Provider)
 
 	providerChan, err := provider.StreamResponse(ctx, req)
+	if err != nil && llm.ErrorKindOf(err) == llm.ErrorContextLength {
+		var notice string
+		providerChan, notice, err = s.recoverContextLength(ctx, provider, req)
+		notices = append(notices, notice)
+	}
 	if err != nil {
 		loggy.Error("Session ProcessMessageStream", "provider_stream_failed", err)
 		return nil, fmt.Errorf("failed to generate streaming response: %w", err)
@@ -140,6 +196,9 @@ func (s *Session) ProcessMessageStream(ctx context.Context, message string) (<-c
 		return nil, fmt.Errorf("provider returned nil channel")
 	}
 
+	// Continue from the partial text if the connection drops mid-response
+	providerChan = s.resumableStream(ctx, provider, req, providerChan)
+
 	loggy.Debug("Session ProcessMessageStream", "provider_stream_successful", "true", "creating_ui_channel", "true")
 
 	// Create a new channel for the UI
@@ -155,14 +214,24 @@ func (s *Session) ProcessMessageStream(ctx context.Context, message string) (<-c
 		var toolCalls []llm.ToolCall
 		var pendingToolCalls map[string]*llm.ToolCall // Track incomplete tool calls
 		var toolInputBuffers map[string]string        // Buffer for accumulating tool input JSON
+		var pendingOrder []string                     // Pending tool call IDs in the order they started
+		var toolIDsByIndex map[int]string             // Pending tool call IDs by stream index
+		var latestToolID string
 		chunkCount := 0
 		hasContent := false
 
 		pendingToolCalls = make(map[string]*llm.ToolCall)
 		toolInputBuffers = make(map[string]string)
+		toolIDsByIndex = make(map[int]string)
 
 		loggy.Debug("Session ProcessMessageStream", "fan_out_goroutine_starting", "true")
 
+		for _, notice := range notices {
+			if notice != "" {
+				uiChan <- &llm.StreamChunk{Type: "notice", Content: notice}
+			}
+		}
+
 		for chunk := range providerChan {
 			chunkCount++
 			loggy.Debug("Session ProcessMessageStream", "received_chunk_from_provider", "true", "chunk_count", chunkCount, "chunk_type", chunk.Type, "chunk_content", chunk.Content)
@@ -175,39 +244,47 @@ func (s *Session) ProcessMessageStream(ctx context.Context, message string) (<-c
 				loggy.Warn("Session ProcessMessageStream", "ui_channel_blocked", "chunk_count", chunkCount)
 			}
 
-			// Collect for session processing
-			if chunk.Content != "" {
+			// Collect for session processing; reasoning is shown but not kept
+			if chunk.Content != "" && chunk.Type != "thinking" {
 				fullResponse.WriteString(chunk.Content)
 				hasContent = true
 			}
+			s.recordUsage(chunk.Usage)
 
 			// Handle tool calls - support streaming tool input accumulation
 			if chunk.ToolCall != nil {
 				toolCall := *chunk.ToolCall
 				if toolCall.ID != "" {
 					// Store or update the tool call
+					if _, exists := pendingToolCalls[toolCall.ID]; !exists {
+						pendingOrder = append(pendingOrder, toolCall.ID)
+					}
 					pendingToolCalls[toolCall.ID] = &toolCall
+					toolIDsByIndex[chunk.Index] = toolCall.ID
+					latestToolID = toolCall.ID
 					loggy.Debug("Session ProcessMessageStream", "tool_call_started", toolCall.Name, "tool_id", toolCall.ID)
 				}
 			}
 
-			// Handle tool input deltas (for streaming providers like Bedrock)
+			// Handle tool input deltas (for streaming providers like Bedrock and OpenAI).
+			// Parallel calls interleave their deltas, so each goes to the call started at
+			// its index, or to the most recent call when the index is unknown.
 			if chunk.ToolInputDelta != "" && len(pendingToolCalls) > 0 {
-				// Find the most recent tool call to append input to
-				var latestToolID string
-				for id := range pendingToolCalls {
-					latestToolID = id // In practice, there's usually only one active tool call
+				toolID, ok := toolIDsByIndex[chunk.Index]
+				if !ok {
+					toolID = latestToolID
 				}
 
-				if latestToolID != "" {
-					toolInputBuffers[latestToolID] += chunk.ToolInputDelta
-					loggy.Debug("Session ProcessMessageStream", "tool_input_delta", "tool_id", latestToolID, "partial_json", chunk.ToolInputDelta)
+				if toolID != "" {
+					toolInputBuffers[toolID] += chunk.ToolInputDelta
+					loggy.Debug("Session ProcessMessageStream", "tool_input_delta", "tool_id", toolID, "partial_json", chunk.ToolInputDelta)
 				}
 			}
 
 			// Handle end of content blocks - finalize tool calls
 			if chunk.Type == "content_block_stop" && len(pendingToolCalls) > 0 {
-				for toolID, toolCall := range pendingToolCalls {
+				for _, toolID := range pendingOrder {
+					toolCall := pendingToolCalls[toolID]
 					// Parse accumulated JSON input if we have it
 					if jsonInput, exists := toolInputBuffers[toolID]; exists && jsonInput != "" {
 						var inputMap map[string]interface{}
@@ -236,6 +313,9 @@ func (s *Session) ProcessMessageStream(ctx context.Context, message string) (<-c
 				// Clear pending tool calls after processing
 				pendingToolCalls = make(map[string]*llm.ToolCall)
 				toolInputBuffers = make(map[string]string)
+				toolIDsByIndex = make(map[int]string)
+				pendingOrder = nil
+				latestToolID = ""
 			}
 		}
 
@@ -262,11 +342,13 @@ func (s *Session) ProcessMessageStream(ctx context.Context, message string) (<-c
 			hasContent = true
 		}
 
-		// Add final assistant response to history only if we have content
-		if hasContent {
+		// Add final assistant response to history if it has content or tool calls;
+		// the calls are recorded so providers can pair them with their results
+		if hasContent || len(toolCalls) > 0 {
 			assistantMsg := llm.Message{
-				Role:    "assistant",
-				Content: fullResponse.String(),
+				Role:      "assistant",
+				Content:   fullResponse.String(),
+				ToolCalls: toolCalls,
 			}
 			s.History = append(s.History, assistantMsg)
 		}
@@ -298,82 +380,7 @@ func (s *Session) ProcessMessageStream(ctx context.Context, message string) (<-c
 		}
 
 		// Execute tool calls if any
-		for _, toolCall := range toolCalls {
-			loggy.Debug("Session ProcessMessageStream", "executing_tool", toolCall.Name, "tool_input", toolCall.Input, "tool_id", toolCall.ID, "tool_type", toolCall.Type)
-
-			// Validate tool call before execution
-			if toolCall.Name == "" {
-				loggy.Error("Session ProcessMessageStream", "invalid_tool_call", "empty tool name", "tool_call", toolCall)
-				continue
-			}
-
-			// Create a notifier that sends tool completion to UI channel
-			notifier := func(toolName string, args map[string]interface{}, result string, err error) {
-				// Create a special chunk for tool completion notification
-				var completionChunk *llm.StreamChunk
-				if err != nil {
-					completionChunk = &llm.StreamChunk{
-						Type:    "tool_completion",
-						Content: "",
-						ToolCompletion: &llm.ToolCompletion{
-							ToolName:  toolName,
-							Args:      args,
-							Result:    result,
-							Error:     err.Error(),
-							State:     "error",
-							TaskGroup: taskGroup,
-						},
-					}
-				} else {
-					completionChunk = &llm.StreamChunk{
-						Type:    "tool_completion",
-						Content: "",
-						ToolCompletion: &llm.ToolCompletion{
-							ToolName:  toolName,
-							Args:      args,
-							Result:    result,
-							Error:     "",
-							State:     "complete",
-							TaskGroup: taskGroup,
-						},
-					}
-				}
-
-				// Send completion chunk to UI
-				select {
-				case uiChan <- completionChunk:
-					loggy.Debug("Sent tool completion to UI", "tool_name", toolName, "state", completionChunk.ToolCompletion.State)
-				default:
-					loggy.Warn("UI channel blocked for tool completion", "tool_name", toolName)
-				}
-			}
-
-			// Send tool start notification before execution
-			startChunk := &llm.StreamChunk{
-				Type:    "tool_start",
-				Content: "",
-				ToolCompletion: &llm.ToolCompletion{
-					ToolName:  toolCall.Name,
-					Args:      toolCall.Input,
-					Result:    "",
-					Error:     "",
-					State:     "start",
-					TaskGroup: taskGroup,
-				},
-			}
-
-			select {
-			case uiChan <- startChunk:
-				loggy.Debug("Sent tool start to UI", "tool_name", toolCall.Name, "args", toolCall.Input)
-			default:
-				loggy.Warn("UI channel blocked for tool start", "tool_name", toolCall.Name)
-			}
-
-			// Execute tool with notification
-			if err := s.executeToolCallWithNotification(ctx, &toolCall, notifier); err != nil {
-				loggy.Error("Session ProcessMessageStream", "tool_execution_failed", err, "tool_name", toolCall.Name, "tool_input", toolCall.Input)
-			}
-		}
+		s.executeToolCalls(ctx, toolCalls, uiChan, taskGroup)
 
 		// Re-invoke LLM after all tool calls are executed
 		if len(toolCalls) > 0 {
@@ -387,12 +394,22 @@ func (s *Session) ProcessMessageStream(ctx context.Context, message string) (<-c
 			loggy.Info("Completed streaming follow-up request")
 		}
 
+		s.finishOverview(message)
+
+		// The next message may change the history once the stream closes, so the
+		// message naming a new session is taken first
+		first, unnamed := s.nameSource()
+		s.UpdatedAt = time.Now()
+
 		// Close the UI channel after all processing is complete
 		loggy.Info("About to close UI channel", "uiChan_address", fmt.Sprintf("%p", uiChan))
 		close(uiChan)
 		loggy.Info("Closed UI channel")
 
-		s.UpdatedAt = time.Now()
+		// Name a new session after its first message once the answer is shown
+		if unnamed {
+			s.ensureName(first)
+		}
 		loggy.Debug("Session ProcessMessageStream", "fan_out_goroutine_complete", "true")
 	}()
 
@@ -400,6 +417,79 @@ func (s *Session) ProcessMessageStream(ctx context.Context, message string) (<-c
 	return uiChan, nil
 }
 
+// maxStreamResumes caps the continuation requests sent for one response after stream errors
+const maxStreamResumes = 2
+
+// streamContinuationPrompt asks the model to pick up an interrupted response
+const streamContinuationPrompt = "Your previous response was cut off by a connection error. Continue exactly from where you left off, without repeating anything you already wrote."
+
+// resumableStream forwards chunks from a provider stream. When the stream errors after
+// producing text and before any tool call, it sends a continuation request seeded with
+// the partial text instead of surfacing the error, up to maxStreamResumes times.
+func (s *Session) resumableStream(ctx context.Context, provider llm.Provider, req *llm.GenerateRequest, source <-chan *llm.StreamChunk) <-chan *llm.StreamChunk {
+	out := make(chan *llm.StreamChunk, 10)
+
+	go func() {
+		defer close(out)
+
+		var partial strings.Builder
+		resumes := 0
+
+		for {
+			var streamErr *llm.StreamChunk
+			sawToolCall := false
+
+			for chunk := range source {
+				if chunk.Type == "error" && streamErr == nil {
+					streamErr = chunk
+					continue
+				}
+				if chunk.ToolCall != nil || chunk.ToolInputDelta != "" {
+					sawToolCall = true
+				}
+				if chunk.Type != "thinking" {
+					partial.WriteString(chunk.Content)
+				}
+
+				select {
+				case out <- chunk:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			if streamErr == nil {
+				return
+			}
+
+			if partial.Len() == 0 || sawToolCall || resumes >= maxStreamResumes || ctx.Err() != nil {
+				loggy.Warn("Stream failed, not resuming", "error", streamErr.Content, "resumes", resumes, "partial_length", partial.Len(), "tool_call", sawToolCall)
+				out <- streamErr
+				return
+			}
+
+			resumes++
+			loggy.Warn("Stream interrupted, requesting continuation", "error", streamErr.Content, "attempt", resumes, "partial_length", partial.Len())
+
+			continuation := *req
+			continuation.Messages = append(append([]llm.Message{}, req.Messages...),
+				llm.Message{Role: "assistant", Content: partial.String()},
+				llm.Message{Role: "user", Content: streamContinuationPrompt},
+			)
+
+			next, err := provider.StreamResponse(ctx, &continuation)
+			if err != nil || next == nil {
+				loggy.Error("Stream continuation request failed", "error", err, "attempt", resumes)
+				out <- streamErr
+				return
+			}
+			source = next
+		}
+	}()
+
+	return out
+}
+
 // generateTaskName creates a descriptive task name based on the types of tools being executed
 func (s *Session) generateTaskName(toolCalls []llm.ToolCall) string {
 	if len(toolCalls) == 0 {
@@ -410,15 +500,15 @@ func (s *Session) generateTaskName(toolCalls []llm.ToolCall) string {
 	toolTypes := make(map[string]int)
 	for _, tool := range toolCalls {
 		switch tool.Name {
-		case "read_file":
+		case "read_file", "read_files":
 			toolTypes["read"]++
-		case "write_file", "create_file", "edit_file", "multi_edit_file":
+		case "write_file", "create_file", "edit_file", "multi_edit_file", "apply_patch":
 			toolTypes["edit"]++
-		case "bash":
+		case "bash", "run_tests":
 			toolTypes["run"]++
-		case "grep", "find", "fuzzy_search":
+		case "grep", "find", "fuzzy_search", "symbols", "ast_outline", "dependencies":
 			toolTypes["search"]++
-		case "git_status", "git_diff", "git_add", "git_commit", "git_log", "git_branch":
+		case "git_status", "git_diff", "git_add", "git_commit", "git_log", "git_blame", "git_show", "git_branch", "git_stash", "git_stash_pop", "git_restore":
 			toolTypes["git"]++
 		case "todo_read", "todo_write":
 			toolTypes["todo"]++
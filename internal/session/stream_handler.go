@@ -0,0 +1,132 @@
+package session
+
+import (
+	"context"
+	"github.com/tildaslashalef/bazinga/internal/llm"
+	"strings"
+)
+
+// StreamHandler receives the parts of a response stream from ProcessMessageStream as
+// they arrive. Any callback may be nil.
+type StreamHandler struct {
+	OnText       func(text string)                    // Assistant text, as it streams
+	OnThinking   func(text string)                    // Reasoning the model streams apart from its answer
+	OnTaskStart  func(taskName string)                // Start of a group of tool calls
+	OnToolStart  func(completion *llm.ToolCompletion) // A tool is about to run
+	OnToolOutput func(completion *llm.ToolCompletion) // A line of output from a running tool
+	OnToolEnd    func(completion *llm.ToolCompletion) // A tool finished; State is "complete" or "error"
+	OnError      func(message string)                 // The provider reported a stream error
+	OnNotice     func(message string)                 // The session changed something the user should know about
+	OnToolCall   func(toolCall *llm.ToolCall)         // The model started a tool call
+}
+
+// StreamResult summarizes a consumed response stream
+type StreamResult struct {
+	Text       string // All assistant text, including follow-ups after tool calls
+	ToolRuns   int    // Tools that finished running
+	ToolErrors int    // Tools that failed or were denied
+	Errors     []string
+}
+
+// Handle passes one chunk to the matching callbacks
+func (h StreamHandler) Handle(chunk *llm.StreamChunk) {
+	if chunk == nil {
+		return
+	}
+
+	if chunk.Type == "error" {
+		if h.OnError != nil {
+			h.OnError(chunk.Content)
+		}
+		return
+	}
+
+	if chunk.Type == "notice" {
+		if h.OnNotice != nil {
+			h.OnNotice(chunk.Content)
+		}
+		return
+	}
+
+	if chunk.Type == "thinking" {
+		if h.OnThinking != nil {
+			h.OnThinking(chunk.Content)
+		}
+		return
+	}
+
+	if chunk.Content != "" && h.OnText != nil {
+		h.OnText(chunk.Content)
+	}
+
+	if chunk.ToolCall != nil && h.OnToolCall != nil {
+		h.OnToolCall(chunk.ToolCall)
+	}
+
+	completion := chunk.ToolCompletion
+	if completion == nil {
+		return
+	}
+
+	switch completion.State {
+	case "task_start":
+		if h.OnTaskStart != nil {
+			taskName, _ := completion.Args["task_name"].(string)
+			h.OnTaskStart(taskName)
+		}
+	case "start":
+		if h.OnToolStart != nil {
+			h.OnToolStart(completion)
+		}
+	case "output":
+		if h.OnToolOutput != nil {
+			h.OnToolOutput(completion)
+		}
+	case "complete", "error":
+		if h.OnToolEnd != nil {
+			h.OnToolEnd(completion)
+		}
+	}
+}
+
+// Consume reads the stream until it closes or ctx is done, passing each chunk to the
+// handler, and returns a summary of what the stream contained
+func (h StreamHandler) Consume(ctx context.Context, stream <-chan *llm.StreamChunk) (*StreamResult, error) {
+	result := &StreamResult{}
+	var text strings.Builder
+
+	for {
+		select {
+		case <-ctx.Done():
+			result.Text = text.String()
+			return result, ctx.Err()
+		case chunk, ok := <-stream:
+			if !ok {
+				result.Text = text.String()
+				return result, nil
+			}
+
+			if chunk == nil {
+				continue
+			}
+			switch {
+			case chunk.Type == "error":
+				result.Errors = append(result.Errors, chunk.Content)
+			case chunk.Type == "notice", chunk.Type == "thinking":
+			case chunk.Content != "":
+				text.WriteString(chunk.Content)
+			}
+			if completion := chunk.ToolCompletion; completion != nil {
+				switch completion.State {
+				case "complete":
+					result.ToolRuns++
+				case "error":
+					result.ToolRuns++
+					result.ToolErrors++
+				}
+			}
+
+			h.Handle(chunk)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package session
+
+import (
+	"context"
+	"github.com/tildaslashalef/bazinga/internal/llm"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestStreamHandlerConsume tests that chunks reach the right callbacks and are summarized
+func TestStreamHandlerConsume(t *testing.T) {
+	stream := make(chan *llm.StreamChunk, 10)
+	stream <- &llm.StreamChunk{Type: "thinking", Content: "The user wants the tests run."}
+	stream <- &llm.StreamChunk{Type: "content_block_delta", Content: "Running the tests. "}
+	stream <- &llm.StreamChunk{Type: "content_block_start", ToolCall: &llm.ToolCall{ID: "call_1", Name: "bash"}}
+	stream <- &llm.StreamChunk{Type: "tool_start", ToolCompletion: &llm.ToolCompletion{ToolName: "bash", State: "start"}}
+	stream <- &llm.StreamChunk{Type: "tool_output", ToolCompletion: &llm.ToolCompletion{ToolName: "bash", Result: "ok", State: "output"}}
+	stream <- &llm.StreamChunk{Type: "tool_completion", ToolCompletion: &llm.ToolCompletion{ToolName: "bash", Result: "ok", State: "complete"}}
+	stream <- &llm.StreamChunk{Type: "tool_completion", ToolCompletion: &llm.ToolCompletion{ToolName: "edit_file", Error: "permission denied", State: "error"}}
+	stream <- &llm.StreamChunk{Type: "error", Content: "Streaming error: connection reset"}
+	stream <- &llm.StreamChunk{Type: "content_block_delta", Content: "All passed."}
+	close(stream)
+
+	var text, events []string
+	handler := StreamHandler{
+		OnText:       func(t string) { text = append(text, t) },
+		OnThinking:   func(t string) { events = append(events, "thinking") },
+		OnToolCall:   func(call *llm.ToolCall) { events = append(events, "call:"+call.Name) },
+		OnToolStart:  func(c *llm.ToolCompletion) { events = append(events, "start:"+c.ToolName) },
+		OnToolOutput: func(c *llm.ToolCompletion) { events = append(events, "output:"+c.Result) },
+		OnToolEnd:    func(c *llm.ToolCompletion) { events = append(events, c.State+":"+c.ToolName) },
+		OnError:      func(message string) { events = append(events, "error") },
+	}
+
+	result, err := handler.Consume(context.Background(), stream)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"Running the tests. ", "All passed."}, text, "error and thinking chunks should not be treated as text")
+	assert.Equal(t, []string{"thinking", "call:bash", "start:bash", "output:ok", "complete:bash", "error:edit_file", "error"}, events)
+	assert.Equal(t, "Running the tests. All passed.", result.Text)
+	assert.Equal(t, 2, result.ToolRuns)
+	assert.Equal(t, 1, result.ToolErrors)
+	assert.Equal(t, []string{"Streaming error: connection reset"}, result.Errors)
+}
+
+// TestStreamHandlerConsumeCancelled tests that consuming stops when the context is cancelled
+func TestStreamHandlerConsumeCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := StreamHandler{}.Consume(ctx, make(chan *llm.StreamChunk))
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,375 @@
+package session
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"github.com/tildaslashalef/bazinga/internal/llm"
+	"image"
+	pngimage "image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingProvider captures the last request it was asked to stream
+type recordingProvider struct {
+	mockProvider
+	lastRequest *llm.GenerateRequest
+}
+
+func (r *recordingProvider) StreamResponse(ctx context.Context, req *llm.GenerateRequest) (<-chan *llm.StreamChunk, error) {
+	r.lastRequest = req
+	return r.mockProvider.StreamResponse(ctx, req)
+}
+
+func newRecordingSession(t *testing.T) (*Session, *recordingProvider) {
+	manager, llmManager := setupTestSessionManager()
+
+	recorder := &recordingProvider{mockProvider: mockProvider{name: "recorder"}}
+	require.NoError(t, llmManager.RegisterProvider("recorder", recorder))
+
+	session, err := manager.CreateSession(context.Background(), &CreateOptions{Name: "Stream Session"})
+	require.NoError(t, err)
+	require.NoError(t, session.SetProvider("recorder"))
+
+	return session, recorder
+}
+
+func drainStream(stream <-chan *llm.StreamChunk) {
+	for range stream {
+	}
+}
+
+// TestProcessThinkStreamOmitsTools tests that /think requests are sent without tools
+func TestProcessThinkStreamOmitsTools(t *testing.T) {
+	session, recorder := newRecordingSession(t)
+
+	stream, err := session.ProcessThinkStream(context.Background(), "How should we split the storage layer?")
+	require.NoError(t, err)
+	drainStream(stream)
+
+	require.NotNil(t, recorder.lastRequest)
+	assert.Empty(t, recorder.lastRequest.Tools, "think requests must not offer tools")
+
+	last := recorder.lastRequest.Messages[len(recorder.lastRequest.Messages)-1]
+	assert.Contains(t, last.Content, "How should we split the storage layer?")
+	assert.Contains(t, last.Content, "no tools available")
+
+	// A regular message afterwards gets tools again
+	stream, err = session.ProcessMessageStream(context.Background(), "Go ahead")
+	require.NoError(t, err)
+	drainStream(stream)
+
+	assert.NotEmpty(t, recorder.lastRequest.Tools, "regular requests should still offer tools")
+}
+
+// flakyProvider replays a scripted stream per request, recording each request
+type flakyProvider struct {
+	mockProvider
+	scripts  [][]*llm.StreamChunk
+	requests []*llm.GenerateRequest
+}
+
+func (f *flakyProvider) StreamResponse(ctx context.Context, req *llm.GenerateRequest) (<-chan *llm.StreamChunk, error) {
+	script := f.scripts[len(f.requests)%len(f.scripts)]
+	f.requests = append(f.requests, req)
+
+	ch := make(chan *llm.StreamChunk, len(script))
+	for _, chunk := range script {
+		ch <- chunk
+	}
+	close(ch)
+	return ch, nil
+}
+
+func newFlakySession(t *testing.T, scripts ...[]*llm.StreamChunk) (*Session, *flakyProvider) {
+	manager, llmManager := setupTestSessionManager()
+
+	flaky := &flakyProvider{mockProvider: mockProvider{name: "flaky"}, scripts: scripts}
+	require.NoError(t, llmManager.RegisterProvider("flaky", flaky))
+
+	session, err := manager.CreateSession(context.Background(), &CreateOptions{Name: "Flaky Session"})
+	require.NoError(t, err)
+	require.NoError(t, session.SetProvider("flaky"))
+
+	return session, flaky
+}
+
+// TestProcessMessageStreamResumesAfterDrop tests that a mid-stream error is followed by a continuation request
+func TestProcessMessageStreamResumesAfterDrop(t *testing.T) {
+	session, flaky := newFlakySession(t,
+		[]*llm.StreamChunk{
+			{Type: "content_block_delta", Content: "The storage layer "},
+			{Type: "error", Content: "Stream error: connection reset by peer"},
+		},
+		[]*llm.StreamChunk{
+			{Type: "content_block_delta", Content: "keeps sessions as JSON."},
+		},
+	)
+
+	stream, err := session.ProcessMessageStream(context.Background(), "How are sessions stored?")
+	require.NoError(t, err)
+
+	var received strings.Builder
+	for chunk := range stream {
+		assert.NotEqual(t, "error", chunk.Type, "a resumed stream should not surface the error")
+		received.WriteString(chunk.Content)
+	}
+
+	assert.Equal(t, "The storage layer keeps sessions as JSON.", received.String())
+	require.Len(t, flaky.requests, 2)
+
+	// The continuation is seeded with the partial answer
+	continuation := flaky.requests[1].Messages
+	require.GreaterOrEqual(t, len(continuation), 2)
+	assert.Equal(t, llm.Message{Role: "assistant", Content: "The storage layer "}, continuation[len(continuation)-2])
+	assert.Equal(t, streamContinuationPrompt, continuation[len(continuation)-1].Content)
+
+	last := session.History[len(session.History)-1]
+	assert.Equal(t, "assistant", last.Role)
+	assert.Equal(t, "The storage layer keeps sessions as JSON.", last.Content)
+}
+
+// TestProcessMessageStreamResumeCap tests that a stream that keeps failing stops after the retry cap
+func TestProcessMessageStreamResumeCap(t *testing.T) {
+	session, flaky := newFlakySession(t, []*llm.StreamChunk{
+		{Type: "content_block_delta", Content: "partial "},
+		{Type: "error", Content: "Stream error: timeout"},
+	})
+
+	stream, err := session.ProcessMessageStream(context.Background(), "Explain the watcher")
+	require.NoError(t, err)
+
+	var errors int
+	for chunk := range stream {
+		if chunk.Type == "error" {
+			errors++
+		}
+	}
+
+	assert.Equal(t, 1, errors, "the error is surfaced once the cap is reached")
+	assert.Len(t, flaky.requests, maxStreamResumes+1)
+}
+
+// TestThinkingKeptOutOfHistory tests that reasoning reaches the UI but only the answer
+// is kept in the history
+func TestThinkingKeptOutOfHistory(t *testing.T) {
+	session, _ := newFlakySession(t, []*llm.StreamChunk{
+		{Type: "thinking", Content: "The watcher debounces events."},
+		{Type: "content_block_delta", Content: "It batches file events."},
+		{Type: "content_block_stop"},
+	})
+
+	stream, err := session.ProcessMessageStream(context.Background(), "Explain the watcher")
+	require.NoError(t, err)
+
+	var thinking []string
+	for chunk := range stream {
+		if chunk.Type == "thinking" {
+			thinking = append(thinking, chunk.Content)
+		}
+	}
+
+	assert.Equal(t, []string{"The watcher debounces events."}, thinking)
+	last := session.History[len(session.History)-1]
+	assert.Equal(t, "assistant", last.Role)
+	assert.Equal(t, "It batches file events.", llm.ContentText(last.Content))
+}
+
+// TestImageToolResultSentAsImageBlock tests that an image returned by a tool reaches the
+// next request as an image content block
+func TestImageToolResultSentAsImageBlock(t *testing.T) {
+	session, recorder := newRecordingSession(t)
+
+	var png bytes.Buffer
+	require.NoError(t, pngimage.Encode(&png, image.NewRGBA(image.Rect(0, 0, 4, 3))))
+	path := filepath.Join(t.TempDir(), "screenshot.png")
+	require.NoError(t, os.WriteFile(path, png.Bytes(), 0o644))
+
+	call := llm.ToolCall{ID: "toolu_img", Name: "read_file", Input: map[string]interface{}{"file_path": path}}
+	session.History = append(session.History, llm.Message{Role: "assistant", ToolCalls: []llm.ToolCall{call}})
+	require.NoError(t, session.executeToolCallWithNotification(context.Background(), &call, nil, nil))
+
+	stream, err := session.ProcessMessageStream(context.Background(), "What is in the screenshot?")
+	require.NoError(t, err)
+	drainStream(stream)
+
+	require.NotNil(t, recorder.lastRequest)
+	var result *llm.Message
+	for i, msg := range recorder.lastRequest.Messages {
+		if msg.Role == "tool" && msg.ToolCallID == "toolu_img" {
+			result = &recorder.lastRequest.Messages[i]
+		}
+	}
+	require.NotNil(t, result, "the tool result should be in the request")
+
+	images := llm.ContentImages(result.Content)
+	require.Len(t, images, 1)
+	assert.Equal(t, "image/png", images[0].MediaType)
+	assert.Equal(t, base64.StdEncoding.EncodeToString(png.Bytes()), images[0].Data)
+	assert.Contains(t, llm.ContentText(result.Content), "4×3")
+}
+
+// TestProcessMessageStreamParallelToolDeltas tests that interleaved argument fragments of
+// parallel tool calls are routed to their calls by stream index
+func TestProcessMessageStreamParallelToolDeltas(t *testing.T) {
+	session, flaky := newFlakySession(t,
+		[]*llm.StreamChunk{
+			{Type: "content_block_start", Index: 0, ToolCall: &llm.ToolCall{ID: "call_a", Name: "todo_read"}},
+			{Type: "content_block_start", Index: 1, ToolCall: &llm.ToolCall{ID: "call_b", Name: "list_files"}},
+			{Type: "content_block_delta", Index: 1, ToolInputDelta: `{"pa`},
+			{Type: "content_block_delta", Index: 0, ToolInputDelta: `{}`},
+			{Type: "content_block_delta", Index: 1, ToolInputDelta: `th": "."}`},
+			{Type: "content_block_stop"},
+		},
+		[]*llm.StreamChunk{
+			{Type: "content_block_delta", Content: "Done."},
+		},
+	)
+
+	stream, err := session.ProcessMessageStream(context.Background(), "List the files and todos")
+	require.NoError(t, err)
+	drainStream(stream)
+
+	require.GreaterOrEqual(t, len(flaky.requests), 1)
+	var calls []llm.ToolCall
+	for _, msg := range session.History {
+		if msg.Role == "assistant" && len(msg.ToolCalls) > 0 {
+			calls = msg.ToolCalls
+			break
+		}
+	}
+	require.Len(t, calls, 2)
+	assert.Equal(t, "call_a", calls[0].ID)
+	assert.Empty(t, calls[0].Input)
+	assert.Equal(t, "call_b", calls[1].ID)
+	assert.Equal(t, map[string]interface{}{"path": "."}, calls[1].Input)
+}
+
+// TestExecuteToolCallsKeepsOrder tests that concurrently run read-only calls report to
+// the UI and the history in the order the model made them
+func TestExecuteToolCallsKeepsOrder(t *testing.T) {
+	session, _ := newRecordingSession(t)
+
+	dir := t.TempDir()
+	var calls []llm.ToolCall
+	for _, name := range []string{"a", "b", "c", "d", "e", "f"} {
+		path := filepath.Join(dir, name+".txt")
+		require.NoError(t, os.WriteFile(path, []byte("contents of "+name), 0o644))
+		calls = append(calls, llm.ToolCall{ID: "call_" + name, Name: "read_file", Input: map[string]interface{}{"file_path": path}})
+	}
+	// A write in the middle splits the batch and is denied without a prompt callback
+	calls = append(calls[:3], append([]llm.ToolCall{{ID: "call_write", Name: "write_file", Input: map[string]interface{}{"file_path": filepath.Join(dir, "new.txt"), "content": "x"}}}, calls[3:]...)...)
+
+	uiChan := make(chan *llm.StreamChunk, 100)
+	session.executeToolCalls(context.Background(), calls, uiChan, "Reading files")
+	close(uiChan)
+
+	var results []llm.Message
+	for _, msg := range session.History {
+		if msg.Role == "tool" {
+			results = append(results, msg)
+		}
+	}
+	require.Len(t, results, len(calls))
+	for i, call := range calls {
+		assert.Equal(t, call.ID, results[i].ToolCallID)
+	}
+	assert.Contains(t, llm.ContentText(results[0].Content), "contents of a")
+	assert.Contains(t, llm.ContentText(results[3].Content), "permission denied")
+	assert.Contains(t, llm.ContentText(results[6].Content), "contents of f")
+
+	var states []string
+	for chunk := range uiChan {
+		require.NotNil(t, chunk.ToolCompletion)
+		assert.Equal(t, "Reading files", chunk.ToolCompletion.TaskGroup)
+		states = append(states, chunk.ToolCompletion.State)
+	}
+	require.Len(t, states, 2*len(calls))
+	for i := 0; i < len(states); i += 2 {
+		assert.Equal(t, "start", states[i])
+		assert.NotEqual(t, "start", states[i+1])
+	}
+}
+
+// TestDeniedToolCallPassesReason tests that the reason given when denying a tool call
+// reaches the model as the call's result
+func TestDeniedToolCallPassesReason(t *testing.T) {
+	session, _ := newRecordingSession(t)
+
+	call := llm.ToolCall{ID: "call_write", Name: "write_file", Input: map[string]interface{}{"file_path": filepath.Join(t.TempDir(), "prod.yaml"), "content": "x"}}
+	session.GetPermissionManager().SetPromptDecisionCallback(func(*llm.ToolCall) PermissionDecision {
+		return PermissionDecision{Reason: "use the staging config instead"}
+	})
+
+	outcome := session.runToolCall(context.Background(), &call, nil)
+	var denied *PermissionDeniedError
+	require.ErrorAs(t, outcome.err, &denied)
+	assert.Equal(t, "use the staging config instead", denied.Reason)
+	assert.True(t, outcome.message.IsError)
+	assert.Contains(t, llm.ContentText(outcome.message.Content), `said: "use the staging config instead"`)
+
+	// Without a reason the model gets the plain error
+	session.GetPermissionManager().SetPromptCallback(func(*llm.ToolCall) bool { return false })
+	outcome = session.runToolCall(context.Background(), &call, nil)
+	assert.Equal(t, "Error: permission denied for write_file tool", llm.ContentText(outcome.message.Content))
+}
+
+// TestToolBudgetStopsRecursion tests that a model that keeps calling tools is stopped at
+// the tool budget and asked to summarize, and that the next message gets a fresh budget
+func TestToolBudgetStopsRecursion(t *testing.T) {
+	session, flaky := newFlakySession(t, []*llm.StreamChunk{
+		{Type: "content_block_start", ToolCall: &llm.ToolCall{ID: "call_todo", Name: "todo_read"}},
+		{Type: "content_block_stop"},
+	})
+	session.config.Tools.MaxCallsPerRequest = 3
+
+	stream, err := session.ProcessMessageStream(context.Background(), "Keep checking the todos")
+	require.NoError(t, err)
+
+	var received strings.Builder
+	for chunk := range stream {
+		received.WriteString(chunk.Content)
+	}
+
+	assert.Contains(t, received.String(), "Reached tool budget of 3, summarizing.")
+	require.Len(t, flaky.requests, 4, "three rounds of tool calls, then one summary request")
+	assert.Empty(t, flaky.requests[3].Tools, "the summary request offers no tools")
+	assert.Contains(t, flaky.requests[3].Messages[len(flaky.requests[3].Messages)-1].Content, "limit of 3 tool calls")
+
+	var results []llm.Message
+	for _, msg := range session.History {
+		if msg.Role == "tool" {
+			results = append(results, msg)
+		}
+	}
+	require.Len(t, results, 4)
+	assert.Contains(t, llm.ContentText(results[3].Content), "reached the tool budget of 3")
+
+	stream, err = session.ProcessMessageStream(context.Background(), "Once more")
+	require.NoError(t, err)
+	drainStream(stream)
+
+	assert.Len(t, flaky.requests, 8, "a new message starts with a fresh budget")
+}
+
+// TestDropUnansweredMessage tests that only a trailing, unanswered user message is dropped
+func TestDropUnansweredMessage(t *testing.T) {
+	session, _ := newRecordingSession(t)
+	session.History = []llm.Message{
+		{Role: "user", Content: "first"},
+		{Role: "assistant", Content: "answer"},
+	}
+
+	assert.False(t, session.DropUnansweredMessage())
+	assert.Len(t, session.History, 2)
+
+	session.History = append(session.History, llm.Message{Role: "user", Content: "second"})
+	assert.True(t, session.DropUnansweredMessage())
+	assert.Equal(t, "answer", session.History[len(session.History)-1].Content)
+}
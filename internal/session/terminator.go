@@ -0,0 +1,315 @@
+package session
+
+import (
+	"github.com/tildaslashalef/bazinga/internal/config"
+	"github.com/tildaslashalef/bazinga/internal/llm"
+	"path"
+	"slices"
+	"strings"
+)
+
+// commandWrappers are programs that run the command that follows them, so the command
+// after them is checked instead. Each maps to its short options that take a value, such
+// as the user in sudo -u root.
+var commandWrappers = map[string]string{
+	"sudo":    "ugCDhpRTU",
+	"env":     "uCS",
+	"command": "",
+	"exec":    "a",
+	"nohup":   "",
+	"time":    "fo",
+	"xargs":   "IndLPsaE",
+	"nice":    "n",
+	"timeout": "sk",
+	"stdbuf":  "ioe",
+	"ionice":  "cnp",
+}
+
+// shells run the script passed with -c, which is checked like a command line of its own
+var shells = []string{"sh", "bash", "zsh", "dash", "ksh"}
+
+// TerminatorScope returns which prompts terminator mode bypasses: config.TerminatorAll,
+// config.TerminatorEdits or config.TerminatorSafe, or "" when it is off.
+// security.terminator_scope takes precedence over the older security.terminator switch.
+func (s *Session) TerminatorScope() string {
+	if s.config == nil {
+		return ""
+	}
+	if scope := s.config.Security.TerminatorScope; scope != "" {
+		return scope
+	}
+	if s.config.Security.Terminator {
+		return config.TerminatorAll
+	}
+	return ""
+}
+
+// TerminatorApproves reports whether terminator mode approves a tool call that would
+// otherwise prompt. Destructive calls, and calls the permission manager requires a
+// prompt for, still prompt unless the scope is "all".
+func (s *Session) TerminatorApproves(toolCall *llm.ToolCall) bool {
+	scope := s.TerminatorScope()
+	if scope == config.TerminatorAll {
+		return true
+	}
+	if scope != config.TerminatorEdits && scope != config.TerminatorSafe {
+		return false
+	}
+
+	// Fail closed: without a permission manager to check the call, ask
+	if s.permissionManager == nil || s.permissionManager.RequiresPrompt(toolCall) || isDestructive(toolCall) {
+		return false
+	}
+	if scope == config.TerminatorEdits {
+		return slices.Contains(writeTools, toolCall.Name)
+	}
+	return true
+}
+
+// isDestructive reports whether a tool call deletes a tree, rewrites remote history or
+// throws away uncommitted work
+func isDestructive(toolCall *llm.ToolCall) bool {
+	switch toolCall.Name {
+	case "bash":
+		command, _ := toolCall.Input["command"].(string)
+		return destructiveCommand(command)
+	case "delete_dir":
+		recursive, _ := toolCall.Input["recursive"].(bool)
+		return recursive
+	case "git_restore":
+		staged, _ := toolCall.Input["staged"].(bool)
+		return !staged
+	}
+	return false
+}
+
+// destructiveCommand reports whether any command in a shell command line loses work
+// beyond what a prompt could undo: recursive deletes, force pushes, hard resets and
+// cleaning untracked files. Every argument is checked, so flags may come in any order,
+// and scripts run through sh -c, eval or find -exec are checked too.
+func destructiveCommand(commandLine string) bool {
+	for _, args := range shellCommands(commandLine) {
+		if destructiveArgs(args) {
+			return true
+		}
+	}
+	return false
+}
+
+// destructiveArgs reports whether the arguments of one command, program first, lose work
+func destructiveArgs(args []string) bool {
+	args = unwrapCommand(args)
+	if len(args) == 0 {
+		return false
+	}
+
+	name := path.Base(args[0])
+	switch {
+	case name == "rm":
+		return hasFlag(args[1:], "rR", "--recursive")
+	case name == "git":
+		return destructiveGit(args[1:])
+	case name == "eval":
+		return destructiveCommand(strings.Join(args[1:], " "))
+	case name == "find":
+		return destructiveFind(args[1:])
+	case slices.Contains(shells, name):
+		// The script is the first operand after -c, which may be combined as in -ec;
+		// -o and -O take an option name
+		for i := 1; i < len(args) && strings.HasPrefix(args[i], "-") && args[i] != "--"; i++ {
+			switch {
+			case strings.HasPrefix(args[i], "--"):
+			case strings.Contains(args[i], "c"):
+				script := operands(args[i+1:])
+				return len(script) > 0 && destructiveCommand(script[0])
+			case args[i] == "-o" || args[i] == "-O":
+				i++
+			}
+		}
+	}
+	return false
+}
+
+// destructiveFind reports whether find arguments delete what they match, with -delete
+// or by running a destructive command with -exec
+func destructiveFind(args []string) bool {
+	for i, arg := range args {
+		switch arg {
+		case "-delete":
+			return true
+		case "-exec", "-execdir", "-ok", "-okdir":
+			command := args[i+1:]
+			if end := slices.IndexFunc(command, func(arg string) bool { return arg == ";" || arg == "+" }); end >= 0 {
+				command = command[:end]
+			}
+			if destructiveArgs(command) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// destructiveGit reports whether git arguments, after "git", force a push, reset hard
+// or clean untracked files
+func destructiveGit(args []string) bool {
+	// Skip git's own options to find the subcommand
+	for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+		if args[0] == "-C" || args[0] == "-c" {
+			args = args[min(len(args), 1):]
+		}
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		return false
+	}
+
+	subcommand, args := args[0], args[1:]
+	switch subcommand {
+	case "push":
+		if hasFlag(args, "f", "--force") || hasFlag(args, "", "--force-with-lease") || hasFlag(args, "", "--mirror") {
+			return true
+		}
+		// "+main" forces the update of that ref
+		for _, arg := range operands(args) {
+			if strings.HasPrefix(arg, "+") {
+				return true
+			}
+		}
+	case "reset":
+		return hasFlag(args, "", "--hard")
+	case "clean":
+		return hasFlag(args, "f", "--force")
+	}
+	return false
+}
+
+// hasFlag reports whether args, up to a "--", contain one of the short flags (alone or
+// combined with others, as in -rf) or the long flag, which may carry a value after "="
+func hasFlag(args []string, short, long string) bool {
+	for _, arg := range args {
+		switch {
+		case arg == "--":
+			return false
+		case strings.HasPrefix(arg, "--"):
+			if long != "" && (arg == long || strings.HasPrefix(arg, long+"=")) {
+				return true
+			}
+		case strings.HasPrefix(arg, "-"):
+			if strings.ContainsAny(arg[1:], short) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// operands returns the arguments that aren't flags; everything after "--" is an operand
+func operands(args []string) []string {
+	var result []string
+	for i, arg := range args {
+		if arg == "--" {
+			return append(result, args[i+1:]...)
+		}
+		if !strings.HasPrefix(arg, "-") {
+			result = append(result, arg)
+		}
+	}
+	return result
+}
+
+// unwrapCommand drops variable assignments and wrappers such as sudo from the front of a
+// command, leaving the program that actually runs and its arguments
+func unwrapCommand(args []string) []string {
+	for len(args) > 0 {
+		name := path.Base(args[0])
+		valueFlags, isWrapper := commandWrappers[name]
+		switch {
+		case strings.Contains(args[0], "=") && !strings.HasPrefix(args[0], "-"):
+			args = args[1:]
+		case isWrapper:
+			args = args[1:]
+			// Wrapper options, such as sudo -u root, come before the command
+			for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+				option := args[0]
+				args = args[1:]
+				if option == "--" {
+					break
+				}
+				if len(option) == 2 && strings.Contains(valueFlags, option[1:]) && len(args) > 0 {
+					args = args[1:]
+				}
+			}
+			// timeout takes the duration before the command
+			if name == "timeout" && len(args) > 0 {
+				args = args[1:]
+			}
+		default:
+			return args
+		}
+	}
+	return args
+}
+
+// shellCommands splits a shell command line into the arguments of each command in it,
+// separated by ;, &&, ||, |, & or newlines. Quotes group words, and subshell parentheses
+// and braces are dropped; it's meant for spotting programs and flags, not for running.
+func shellCommands(commandLine string) [][]string {
+	var commands [][]string
+	var args []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+
+	endWord := func() {
+		if inWord {
+			args = append(args, word.String())
+			word.Reset()
+			inWord = false
+		}
+	}
+	endCommand := func() {
+		endWord()
+		if len(args) > 0 {
+			commands = append(commands, args)
+			args = nil
+		}
+	}
+
+	runes := []rune(commandLine)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else if r == '\\' && quote == '"' && i+1 < len(runes) {
+				i++
+				word.WriteRune(runes[i])
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == '\\' && i+1 < len(runes):
+			i++
+			word.WriteRune(runes[i])
+			inWord = true
+		case r == ';' || r == '&' || r == '|' || r == '\n' || r == '(' || r == ')' || r == '`':
+			endCommand()
+		case r == ' ' || r == '\t' || r == '\r':
+			endWord()
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	endCommand()
+
+	// Braces grouping commands are words of their own
+	for i, args := range commands {
+		commands[i] = slices.DeleteFunc(args, func(arg string) bool { return arg == "{" || arg == "}" })
+	}
+	return commands
+}
@@ -0,0 +1,131 @@
+package session
+
+import (
+	"fmt"
+	"github.com/tildaslashalef/bazinga/internal/llm"
+	"github.com/tildaslashalef/bazinga/internal/loggy"
+	"regexp"
+	"strings"
+)
+
+// maxOutlineLines is how many lines the outline of a dropped read_file result keeps
+const maxOutlineLines = 60
+
+// outlineLine matches lines that start a declaration or section at the top level of a
+// file, for the outline kept in place of a file read in an earlier turn
+var outlineLine = regexp.MustCompile(`^(?:package|import|func|type|var|const|class|def|async def|interface|struct|enum|impl|trait|fn|pub|export|module|namespace|public|private|protected|#{1,3} )\b`)
+
+// truncateToolResults cuts the tool results of earlier turns down to the configured
+// size before a new message is sent. Each result went to the model whole in the turn
+// it ran; later requests carry the cut-down copy, and the whole result stays in
+// FullContent for display.
+func (s *Session) truncateToolResults() {
+	maxBytes := 0
+	if s.config != nil {
+		maxBytes = s.config.Tools.HistoryResultMaxBytes
+	}
+	if maxBytes <= 0 {
+		return
+	}
+
+	calls := make(map[string]llm.ToolCall)
+	truncated := 0
+	for i := range s.History {
+		msg := &s.History[i]
+		for _, call := range msg.ToolCalls {
+			calls[call.ID] = call
+		}
+		if msg.Role != "tool" || msg.FullContent != "" {
+			continue
+		}
+
+		// Results with images keep them; only plain text is cut down
+		text, ok := msg.Content.(string)
+		if !ok || len(text) <= maxBytes {
+			continue
+		}
+
+		var short string
+		if msg.Name == "read_file" && !msg.IsError {
+			short = fileReadOutline(text, calls[msg.ToolCallID])
+		}
+		if short == "" {
+			short = truncateResult(text, maxBytes)
+		}
+		msg.FullContent = text
+		msg.Content = short
+		truncated++
+	}
+
+	if truncated > 0 {
+		loggy.Debug("Truncated tool results in history", "results", truncated, "max_bytes", maxBytes)
+	}
+}
+
+// truncateResult keeps the first and last lines of a tool result within maxBytes, the
+// head getting two thirds, with a marker saying how much was left out
+func truncateResult(text string, maxBytes int) string {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+
+	headBudget := maxBytes * 2 / 3
+	head := 0
+	for size := 0; head < len(lines) && size+len(lines[head])+1 <= headBudget; head++ {
+		size += len(lines[head]) + 1
+	}
+
+	tail := len(lines)
+	for size := 0; tail > head && size+len(lines[tail-1])+1 <= maxBytes-headBudget; tail-- {
+		size += len(lines[tail-1]) + 1
+	}
+
+	if head == 0 && tail == len(lines) {
+		// A single enormous line: keep its start
+		return fmt.Sprintf("%s\n[truncated, %d lines total: only the first %d bytes are kept]", strings.ToValidUTF8(text[:maxBytes], ""), len(lines), maxBytes)
+	}
+
+	var result strings.Builder
+	result.WriteString(strings.Join(lines[:head], "\n"))
+	fmt.Fprintf(&result, "\n[truncated, %d lines total: lines %d-%d left out; run the tool again if you need them]\n", len(lines), head+1, tail)
+	result.WriteString(strings.Join(lines[tail:], "\n"))
+	return strings.TrimRight(result.String(), "\n")
+}
+
+// fileReadOutline replaces a whole-file read_file result with the file's outline, since
+// the model can read the parts it needs again. It returns "" for results it doesn't
+// recognize.
+func fileReadOutline(text string, call llm.ToolCall) string {
+	header, content, ok := strings.Cut(text, "\nContent:\n\n")
+	if !ok {
+		return ""
+	}
+
+	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+	var outline []string
+	for i, line := range lines {
+		if !outlineLine.MatchString(line) {
+			continue
+		}
+		if len(outline) == maxOutlineLines {
+			outline = append(outline, "  ...")
+			break
+		}
+		outline = append(outline, fmt.Sprintf("  %d: %s", i+1, strings.TrimRight(line, " {")))
+	}
+
+	path := ""
+	if input := call.CallInput(); input != nil {
+		path, _ = input["file_path"].(string)
+	}
+
+	var result strings.Builder
+	result.WriteString(header)
+	fmt.Fprintf(&result, "\n[truncated, %d lines total: the content was dropped from the history after it was read; call read_file", len(lines))
+	if path != "" {
+		fmt.Fprintf(&result, " on %s", path)
+	}
+	result.WriteString(" with offset and limit to read the parts you need again]")
+	if len(outline) > 0 {
+		result.WriteString("\nOutline:\n" + strings.Join(outline, "\n"))
+	}
+	return result.String()
+}
@@ -0,0 +1,72 @@
+package session
+
+import (
+	"fmt"
+	"github.com/tildaslashalef/bazinga/internal/config"
+	"github.com/tildaslashalef/bazinga/internal/llm"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTruncateToolResults tests that large tool results from earlier turns are cut down
+// in the history while the whole result is kept for display
+func TestTruncateToolResults(t *testing.T) {
+	var source, output []string
+	for i := 1; i <= 500; i++ {
+		source = append(source, fmt.Sprintf("\tx := compute(%d) // padding padding padding", i))
+		output = append(output, fmt.Sprintf("ok  \tpkg/%d\t0.01s", i))
+	}
+	source[0] = "package main"
+	source[9] = "func main() {"
+	source[199] = "type Server struct {"
+	fileResult := "File: main.go\nLines: 500\nContent:\n\n" + strings.Join(source, "\n")
+	bashResult := strings.Join(output, "\n")
+
+	readCall := llm.ToolCall{ID: "read", Name: "read_file", Input: map[string]interface{}{"file_path": "main.go"}}
+	bashCall := llm.ToolCall{ID: "bash", Name: "bash", Input: map[string]interface{}{"command": "go test ./..."}}
+
+	cfg := config.DefaultConfig()
+	cfg.Tools.HistoryResultMaxBytes = 2000
+	s := &Session{config: cfg, History: []llm.Message{
+		{Role: "user", Content: "run the tests"},
+		{Role: "assistant", ToolCalls: []llm.ToolCall{readCall, bashCall}},
+		llm.NewToolResultMessage(&readCall, fileResult, false),
+		llm.NewToolResultMessage(&bashCall, bashResult, false),
+		llm.NewToolResultMessage(&llm.ToolCall{ID: "small", Name: "git_status"}, "clean", false),
+	}}
+
+	s.truncateToolResults()
+
+	read := s.History[2]
+	assert.Equal(t, fileResult, read.FullContent)
+	assert.Equal(t, fileResult, llm.DisplayText(read))
+	readText := llm.ContentText(read.Content)
+	assert.Contains(t, readText, "[truncated, 500 lines total")
+	assert.Contains(t, readText, "call read_file on main.go with offset and limit")
+	assert.Contains(t, readText, "  1: package main\n  10: func main()\n  200: type Server struct")
+	assert.NotContains(t, readText, "compute(")
+
+	bash := s.History[3]
+	bashText := llm.ContentText(bash.Content)
+	require.Equal(t, bashResult, bash.FullContent)
+	assert.LessOrEqual(t, len(bashText), 2200)
+	assert.True(t, strings.HasPrefix(bashText, "ok  \tpkg/1\t"), "the head is kept")
+	assert.True(t, strings.HasSuffix(bashText, "pkg/500\t0.01s"), "the tail is kept")
+	assert.Contains(t, bashText, "[truncated, 500 lines total: lines")
+
+	assert.Equal(t, "clean", s.History[4].Content, "small results are kept whole")
+	assert.Empty(t, s.History[4].FullContent)
+
+	// Results already cut down are left alone
+	s.truncateToolResults()
+	assert.Equal(t, readText, llm.ContentText(s.History[2].Content))
+}
+
+// TestTruncateResultLongLine tests that a single enormous line keeps its start
+func TestTruncateResultLongLine(t *testing.T) {
+	result := truncateResult(strings.Repeat("é", 1000), 101)
+	assert.True(t, strings.HasPrefix(result, strings.Repeat("é", 50)+"\n[truncated, 1 lines total"), result[:120])
+}
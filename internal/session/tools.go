@@ -96,7 +96,7 @@ func (tq *ToolQueue) QueueTool(toolCall *llm.ToolCall, permissionManager *Permis
 		ToolCall:          toolCall,
 		State:             ToolPending,
 		RiskLevel:         riskLevel,
-		RiskReasons:       []string{}, // TODO: Extract detailed risk reasons
+		RiskReasons:       permissionManager.GetRiskReasons(toolCall),
 		AffectedResources: extractAffectedResources(toolCall),
 		CreatedAt:         time.Now(),
 		ResponseChan:      make(chan bool, 1), // Buffered channel for non-blocking send
@@ -290,9 +290,7 @@ func extractAffectedResources(toolCall *llm.ToolCall) []string {
 	resources := []string{}
 
 	// Extract file paths
-	if filePath, ok := toolCall.Input["file_path"].(string); ok {
-		resources = append(resources, filePath)
-	}
+	resources = append(resources, toolFilePaths(toolCall)...)
 
 	// Extract command details for bash
 	if toolCall.Name == "bash" {
@@ -326,14 +324,15 @@ func (s *Session) getAvailableTools() []llm.Tool {
 	tools := s.toolExecutor.GetAvailableTools()
 
 	// Enhance tool descriptions with memory and project context
-	if s.memoryContent != nil || s.project != nil {
+	proj := s.GetProject()
+	if s.memoryContent != nil || proj != nil {
 		for i := range tools {
 			switch tools[i].Name {
 			case "read_file", "write_file", "edit_file", "create_file":
 				// Add project context to file operations
-				if s.project != nil {
+				if proj != nil {
 					projectInfo := fmt.Sprintf("\n\nProject Context: %s project with %d files",
-						s.project.Type, len(s.project.Files))
+						proj.Type, len(proj.Files))
 					tools[i].Description += projectInfo
 				}
 
@@ -345,8 +344,8 @@ func (s *Session) getAvailableTools() []llm.Tool {
 
 			case "bash":
 				// Add project-specific context
-				if s.project != nil {
-					commandInfo := fmt.Sprintf("\n\nProject Type: %s", s.project.Type)
+				if proj != nil {
+					commandInfo := fmt.Sprintf("\n\nProject Type: %s", proj.Type)
 					tools[i].Description += commandInfo
 				}
 			}
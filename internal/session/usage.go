@@ -0,0 +1,128 @@
+package session
+
+import (
+	"github.com/tildaslashalef/bazinga/internal/llm"
+	"github.com/tildaslashalef/bazinga/internal/storage"
+)
+
+// ModelUsage is what a session spent on one model: the tokens the API reported and
+// their cost at the model's prices when they were spent
+type ModelUsage struct {
+	Provider     string
+	Model        string
+	InputTokens  int
+	OutputTokens int
+	Cost         float64 // USD; 0 when the model's pricing is unknown
+}
+
+// recordUsage adds the tokens a response reported to the model active now, so tokens
+// spent before a model switch stay with the model that spent them
+func (s *Session) recordUsage(usage *llm.Usage) {
+	s.recordModelUsage(s.Model, usage)
+}
+
+// recordModelUsage adds the tokens a response reported to model of the current
+// provider, for requests that don't use the session's model
+func (s *Session) recordModelUsage(model string, usage *llm.Usage) {
+	if usage == nil || (usage.InputTokens == 0 && usage.OutputTokens == 0) {
+		return
+	}
+
+	inputRate, outputRate := s.ratesFor(model)
+	cost := float64(usage.InputTokens)/1000*inputRate + float64(usage.OutputTokens)/1000*outputRate
+
+	s.usageMu.Lock()
+	defer s.usageMu.Unlock()
+
+	for i := range s.usage {
+		if s.usage[i].Provider == s.Provider && s.usage[i].Model == model {
+			s.usage[i].InputTokens += usage.InputTokens
+			s.usage[i].OutputTokens += usage.OutputTokens
+			s.usage[i].Cost += cost
+			return
+		}
+	}
+	s.usage = append(s.usage, ModelUsage{
+		Provider:     s.Provider,
+		Model:        model,
+		InputTokens:  usage.InputTokens,
+		OutputTokens: usage.OutputTokens,
+		Cost:         cost,
+	})
+}
+
+// GetUsageBreakdown returns the tokens and cost per model, in the order the models
+// were first used
+func (s *Session) GetUsageBreakdown() []ModelUsage {
+	s.usageMu.Lock()
+	defer s.usageMu.Unlock()
+	return append([]ModelUsage(nil), s.usage...)
+}
+
+// GetSessionCost returns the estimated cost in USD of all tokens spent this session
+func (s *Session) GetSessionCost() float64 {
+	total := 0.0
+	for _, usage := range s.GetUsageBreakdown() {
+		total += usage.Cost
+	}
+	return total
+}
+
+// GetSessionTokens returns the input and output tokens spent this session
+func (s *Session) GetSessionTokens() (int, int) {
+	input, output := 0, 0
+	for _, usage := range s.GetUsageBreakdown() {
+		input += usage.InputTokens
+		output += usage.OutputTokens
+	}
+	return input, output
+}
+
+// GetUsage returns the usage to save with the session
+func (s *Session) GetUsage() []storage.SavedModelUsage {
+	breakdown := s.GetUsageBreakdown()
+	if len(breakdown) == 0 {
+		return nil
+	}
+
+	saved := make([]storage.SavedModelUsage, len(breakdown))
+	for i, usage := range breakdown {
+		saved[i] = storage.SavedModelUsage(usage)
+	}
+	return saved
+}
+
+// restoreUsage loads the usage saved with the session
+func (s *Session) restoreUsage(saved []storage.SavedModelUsage) {
+	s.usageMu.Lock()
+	defer s.usageMu.Unlock()
+
+	s.usage = make([]ModelUsage, len(saved))
+	for i, usage := range saved {
+		s.usage[i] = ModelUsage(usage)
+	}
+}
+
+// modelRates returns the current model's input and output prices per 1K tokens, or 0
+// if unknown. Models with a single price use it for both.
+func (s *Session) modelRates() (float64, float64) {
+	return s.ratesFor(s.Model)
+}
+
+// ratesFor returns the prices per 1K tokens of a model of the current provider
+func (s *Session) ratesFor(modelID string) (float64, float64) {
+	provider := s.currentProvider()
+	if provider == nil {
+		return 0, 0
+	}
+	for _, model := range provider.GetAvailableModels() {
+		if model.ID == modelID {
+			output := model.OutputCostPer1KTokens
+			if output == 0 {
+				output = model.CostPer1KTokens
+			}
+			return model.CostPer1KTokens, output
+		}
+	}
+	return 0, 0
+}
@@ -0,0 +1,44 @@
+package session
+
+import (
+	"github.com/tildaslashalef/bazinga/internal/llm"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRecordUsageAttributesTokensToActiveModel tests that a model switch leaves earlier spend with the earlier model
+func TestRecordUsageAttributesTokensToActiveModel(t *testing.T) {
+	llmManager := llm.NewManager()
+	require.NoError(t, llmManager.RegisterProvider("bedrock", &mockProvider{name: "bedrock", models: []llm.Model{
+		{ID: "sonnet", CostPer1KTokens: 0.003, OutputCostPer1KTokens: 0.015},
+		{ID: "haiku", CostPer1KTokens: 0.001},
+	}}))
+
+	s := &Session{Provider: "bedrock", Model: "sonnet", llmManager: llmManager}
+	s.recordUsage(&llm.Usage{InputTokens: 1000, OutputTokens: 200})
+	s.recordUsage(&llm.Usage{InputTokens: 1000})
+	s.recordUsage(nil)
+
+	s.Model = "haiku"
+	s.recordUsage(&llm.Usage{InputTokens: 2000, OutputTokens: 1000})
+
+	breakdown := s.GetUsageBreakdown()
+	require.Len(t, breakdown, 2)
+	assert.Equal(t, "sonnet", breakdown[0].Model)
+	assert.Equal(t, 2000, breakdown[0].InputTokens)
+	assert.Equal(t, 200, breakdown[0].OutputTokens)
+	assert.InDelta(t, 0.006+0.003, breakdown[0].Cost, 1e-9, "output tokens use the output price")
+	assert.InDelta(t, 0.002+0.001, breakdown[1].Cost, 1e-9, "a single price covers input and output")
+
+	assert.InDelta(t, 0.012, s.GetSessionCost(), 1e-9)
+	input, output := s.GetSessionTokens()
+	assert.Equal(t, 4000, input)
+	assert.Equal(t, 1200, output)
+
+	// Saved usage restores into a new session
+	restored := &Session{}
+	restored.restoreUsage(s.GetUsage())
+	assert.Equal(t, breakdown, restored.GetUsageBreakdown())
+}
@@ -30,6 +30,8 @@ type SessionInterface interface {
 	GetCreatedAt() time.Time
 	GetUpdatedAt() time.Time
 	GetHistory() []map[string]interface{}
+	GetPermissions() *SavedPermissions
+	GetUsage() []SavedModelUsage
 }
 
 // Storage manages session persistence
@@ -85,6 +87,46 @@ type SerializableSession struct {
 
 	// History with smart truncation to prevent large files
 	History []map[string]interface{} `json:"history,omitempty"`
+
+	// Remembered permission decisions, dropped on load once they expire
+	Permissions *SavedPermissions `json:"permissions,omitempty"`
+
+	// Tokens and cost per model, so the session cost survives restarts
+	Usage []SavedModelUsage `json:"usage,omitempty"`
+}
+
+// SavedModelUsage is the tokens a session spent on one model and their cost in USD
+type SavedModelUsage struct {
+	Provider     string  `json:"provider"`
+	Model        string  `json:"model"`
+	InputTokens  int     `json:"input_tokens"`
+	OutputTokens int     `json:"output_tokens"`
+	Cost         float64 `json:"cost"`
+}
+
+// SavedPermissions holds the permission decisions a session remembers
+type SavedPermissions struct {
+	Decisions []SavedPermissionDecision `json:"decisions,omitempty"`
+	Rules     []SavedPermissionRule     `json:"rules,omitempty"`
+}
+
+// SavedPermissionDecision is a decision remembered for a tool call pattern,
+// e.g. "bash:go" for go commands
+type SavedPermissionDecision struct {
+	Key       string    `json:"key"`
+	Approved  bool      `json:"approved"`
+	Reason    string    `json:"reason,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SavedPermissionRule is a session permission rule
+type SavedPermissionRule struct {
+	Tool      string    `json:"tool"`
+	File      string    `json:"file,omitempty"`
+	Command   string    `json:"command,omitempty"`
+	Approved  bool      `json:"approved"`
+	Reason    string    `json:"reason,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
 // SaveSession saves a session to disk
@@ -103,6 +145,8 @@ func (s *Storage) SaveSession(sess SessionInterface) error {
 		CreatedAt:    sess.GetCreatedAt(),
 		UpdatedAt:    sess.GetUpdatedAt(),
 		History:      s.truncateHistory(sess.GetHistory()),
+		Permissions:  sess.GetPermissions(),
+		Usage:        sess.GetUsage(),
 	}
 
 	// Create session file path
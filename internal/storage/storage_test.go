@@ -22,6 +22,8 @@ type MockSession struct {
 	createdAt    time.Time
 	updatedAt    time.Time
 	history      []map[string]interface{}
+	permissions  *SavedPermissions
+	usage        []SavedModelUsage
 }
 
 func (m *MockSession) GetID() string                        { return m.id }
@@ -36,6 +38,8 @@ func (m *MockSession) GetNoAutoCommit() bool                { return m.noAutoCom
 func (m *MockSession) GetCreatedAt() time.Time              { return m.createdAt }
 func (m *MockSession) GetUpdatedAt() time.Time              { return m.updatedAt }
 func (m *MockSession) GetHistory() []map[string]interface{} { return m.history }
+func (m *MockSession) GetPermissions() *SavedPermissions    { return m.permissions }
+func (m *MockSession) GetUsage() []SavedModelUsage          { return m.usage }
 
 // setupTestStorage creates a test storage with temporary directory
 func setupTestStorage(t *testing.T) (*Storage, string) {
@@ -82,6 +86,10 @@ func TestSaveLoadSession(t *testing.T) {
 			{"role": "user", "content": "Hello"},
 			{"role": "assistant", "content": "Hi there!"},
 		},
+		permissions: &SavedPermissions{
+			Decisions: []SavedPermissionDecision{{Key: "bash:go", Approved: true, CreatedAt: now}},
+		},
+		usage: []SavedModelUsage{{Provider: "bedrock", Model: "claude", InputTokens: 1200, OutputTokens: 300, Cost: 0.0081}},
 	}
 
 	// Test saving
@@ -115,6 +123,12 @@ func TestSaveLoadSession(t *testing.T) {
 	if len(loaded.Tags) != len(mockSession.tags) {
 		t.Errorf("Expected %d tags, got %d", len(mockSession.tags), len(loaded.Tags))
 	}
+	if loaded.Permissions == nil || len(loaded.Permissions.Decisions) != 1 || loaded.Permissions.Decisions[0].Key != "bash:go" {
+		t.Errorf("Expected remembered permission decision to be saved, got %+v", loaded.Permissions)
+	}
+	if len(loaded.Usage) != 1 || loaded.Usage[0] != mockSession.usage[0] {
+		t.Errorf("Expected session usage to be saved, got %+v", loaded.Usage)
+	}
 }
 
 // TestListSessions tests listing saved sessions
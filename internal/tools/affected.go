@@ -0,0 +1,123 @@
+package tools
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// maxAffectedPaths caps how many paths AffectedPaths lists
+const maxAffectedPaths = 20
+
+// bashSeparator splits a command line into the commands chained in it
+var bashSeparator = regexp.MustCompile(`&&|\|\||[;|\n]`)
+
+// bashRedirect matches an output redirection and its target, e.g. "> out.txt"
+var bashRedirect = regexp.MustCompile(`\d?>>?\s*([^\s;&|<>]+)`)
+
+// bashFileCommands are the commands whose arguments name the files they change, with
+// how many leading non-flag arguments are not paths (the mode of chmod, the owner of chown)
+var bashFileCommands = map[string]int{
+	"rm": 0, "rmdir": 0, "mv": 0, "cp": 0, "touch": 0, "mkdir": 0, "ln": 0,
+	"truncate": 0, "tee": 0, "unlink": 0, "shred": 0, "chmod": 1, "chown": 1, "chgrp": 1,
+}
+
+// AffectedPaths returns the files and directories a tool call will touch, relative to
+// the root where possible: the paths it names, every file a patch or a read_files glob
+// covers, and the targets of file commands and redirections in a bash command. It is
+// for showing the user what a call is about to do, not for enforcing anything.
+func (te *ToolExecutor) AffectedPaths(toolName string, input map[string]interface{}) []string {
+	paths, dirs := toolPaths(toolName, input)
+	paths = append(paths, dirs...)
+
+	switch toolName {
+	case "read_files":
+		if pattern, ok := input["glob"].(string); ok && pattern != "" {
+			if entries, err := te.globFiles(pattern); err == nil {
+				for _, entry := range entries {
+					paths = append(paths, entry.path)
+				}
+			}
+		}
+	case "bash":
+		if command, ok := input["command"].(string); ok {
+			paths = append(paths, bashTargets(command)...)
+		}
+	}
+
+	seen := make(map[string]bool)
+	var affected []string
+	for _, path := range paths {
+		path = te.affectedPath(path)
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+		affected = append(affected, path)
+	}
+
+	if len(affected) > maxAffectedPaths {
+		affected = append(affected[:maxAffectedPaths], fmt.Sprintf("... %d more", len(affected)-maxAffectedPaths))
+	}
+	return affected
+}
+
+// affectedPath shows path relative to the root when it is inside it, and absolute
+// otherwise; paths starting with ~ or a variable are kept as written
+func (te *ToolExecutor) affectedPath(path string) string {
+	if strings.HasPrefix(path, "~") || strings.HasPrefix(path, "$") {
+		return path
+	}
+	path = te.absPath(path)
+	if rel, err := filepath.Rel(te.rootPath, path); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return rel
+	}
+	return path
+}
+
+// bashTargets returns the paths file commands such as rm and mv operate on in a
+// command line, and the files its output is redirected to. Quoting is only stripped,
+// not interpreted, and arguments with shell expansions are kept as written.
+func bashTargets(command string) []string {
+	var targets []string
+	for _, part := range bashSeparator.Split(command, -1) {
+		for _, match := range bashRedirect.FindAllStringSubmatch(part, -1) {
+			if target := strings.Trim(match[1], `"'`); target != "" && target != "/dev/null" {
+				targets = append(targets, target)
+			}
+		}
+		part = bashRedirect.ReplaceAllString(part, "")
+
+		words := strings.Fields(part)
+		for len(words) > 0 && (words[0] == "sudo" || strings.Contains(words[0], "=")) {
+			words = words[1:] // sudo and VAR=value prefixes
+		}
+		if len(words) == 0 {
+			continue
+		}
+		skip, ok := bashFileCommands[words[0]]
+		if !ok {
+			continue
+		}
+
+		flags := true
+		for _, word := range words[1:] {
+			if flags && word == "--" {
+				flags = false
+				continue
+			}
+			if flags && strings.HasPrefix(word, "-") {
+				continue
+			}
+			if skip > 0 {
+				skip--
+				continue
+			}
+			if word = strings.Trim(word, `"'`); word != "" {
+				targets = append(targets, word)
+			}
+		}
+	}
+	return targets
+}
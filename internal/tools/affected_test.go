@@ -0,0 +1,45 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestToolExecutor_AffectedPaths(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, name := range []string{"a.go", "b.go", "notes.md"} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte("x"), 0o644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+	te := NewToolExecutor(tempDir)
+
+	tests := []struct {
+		name  string
+		tool  string
+		input map[string]interface{}
+		want  []string
+	}{
+		{"file path", "edit_file", map[string]interface{}{"file_path": filepath.Join(tempDir, "a.go")}, []string{"a.go"}},
+		{"move", "move_file", map[string]interface{}{"source_path": "a.go", "dest_path": "old/a.go"}, []string{"a.go", filepath.Join("old", "a.go")}},
+		{"glob", "read_files", map[string]interface{}{"glob": "*.go"}, []string{"a.go", "b.go"}},
+		{"listed files", "read_files", map[string]interface{}{"files": []interface{}{
+			map[string]interface{}{"file_path": "a.go"},
+			map[string]interface{}{"file_path": "notes.md"},
+		}}, []string{"a.go", "notes.md"}},
+		{"rm and redirect", "bash", map[string]interface{}{"command": "rm -rf build dist && go test ./... > test.log 2>&1"}, []string{"build", "dist", "test.log"}},
+		{"chmod skips the mode", "bash", map[string]interface{}{"command": "sudo chmod -R 755 scripts"}, []string{"scripts"}},
+		{"outside the root", "bash", map[string]interface{}{"command": "mv ~/.bashrc /tmp/bashrc"}, []string{"~/.bashrc", "/tmp/bashrc"}},
+		{"read-only command", "bash", map[string]interface{}{"command": "ls -la | grep go"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := te.AffectedPaths(tt.tool, tt.input); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("AffectedPaths() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
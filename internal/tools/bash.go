@@ -1,7 +1,9 @@
 package tools
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"github.com/tildaslashalef/bazinga/internal/loggy"
 	"os"
@@ -12,6 +14,39 @@ import (
 	"time"
 )
 
+const (
+	// maxBashOutputBytes caps the output kept for a bash result; the start and end of
+	// longer output are kept and the middle dropped
+	maxBashOutputBytes = 256 * 1024
+	// maxOutputLineBytes caps a single streamed line, e.g. a progress bar that never ends one
+	maxOutputLineBytes = 4096
+	// defaultBashTimeout is how long a command may run when neither the call nor the
+	// config sets a timeout
+	defaultBashTimeout = 120 * time.Second
+	// maxBashTimeout caps the timeout a single call may ask for
+	maxBashTimeout = 10 * time.Minute
+	// processWaitDelay bounds how long to wait for output pipes after the command exits,
+	// in case a background process it started still holds them open
+	processWaitDelay = 2 * time.Second
+)
+
+// OutputFunc receives the output of a running command line by line
+type OutputFunc func(line string)
+
+type outputFuncKey struct{}
+
+// WithOutputFunc returns a context under which bash commands pass their output to fn
+// line by line as it is produced. The full output is still returned as the result.
+func WithOutputFunc(ctx context.Context, fn OutputFunc) context.Context {
+	return context.WithValue(ctx, outputFuncKey{}, fn)
+}
+
+// outputFuncFrom returns the output callback set with WithOutputFunc, if any
+func outputFuncFrom(ctx context.Context) OutputFunc {
+	fn, _ := ctx.Value(outputFuncKey{}).(OutputFunc)
+	return fn
+}
+
 // BashResult contains detailed information about command execution
 type BashResult struct {
 	Output     string
@@ -22,7 +57,7 @@ type BashResult struct {
 }
 
 // executeBash executes a bash command with enhanced features
-func (te *ToolExecutor) executeBash(input map[string]interface{}) (string, error) {
+func (te *ToolExecutor) executeBash(ctx context.Context, input map[string]interface{}) (string, error) {
 	command, ok := input["command"].(string)
 	if !ok {
 		return "", fmt.Errorf("command is required")
@@ -48,14 +83,7 @@ func (te *ToolExecutor) executeBash(input map[string]interface{}) (string, error
 		}
 	}
 
-	// Optional timeout override (default 30s)
-	timeout := 30 * time.Second
-	if timeoutSec, ok := input["timeout"].(float64); ok && timeoutSec > 0 {
-		timeout = time.Duration(timeoutSec) * time.Second
-		if timeout > 300*time.Second { // Max 5 minutes
-			timeout = 300 * time.Second
-		}
-	}
+	timeout := te.bashCallTimeout(input)
 
 	// Optional environment variables
 	env := os.Environ()
@@ -74,16 +102,28 @@ func (te *ToolExecutor) executeBash(input map[string]interface{}) (string, error
 
 	startTime := time.Now()
 
-	// Create command with context for better cancellation
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	// Cancelling the session's context or hitting the timeout kills the command and
+	// everything it started
+	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
 	cmd := exec.CommandContext(ctx, "bash", "-c", command)
 	cmd.Dir = workingDir
 	cmd.Env = env
+	cmd.WaitDelay = processWaitDelay
+	setProcessGroup(cmd)
 
-	// Capture both stdout and stderr separately for better debugging
-	output, err := cmd.CombinedOutput()
+	// A nil Stdin reads from the null device, so commands waiting for input fail
+	// at once instead of blocking until the timeout
+	cmd.Stdin = nil
+
+	// Capture combined stdout and stderr, streaming lines as they arrive
+	output := &commandOutput{max: maxBashOutputBytes, onLine: outputFuncFrom(ctx)}
+	cmd.Stdout = output
+	cmd.Stderr = output
+
+	err := cmd.Run()
+	output.Flush()
 	duration := time.Since(startTime)
 
 	// Get exit code
@@ -97,7 +137,7 @@ func (te *ToolExecutor) executeBash(input map[string]interface{}) (string, error
 	}
 
 	result := &BashResult{
-		Output:     strings.TrimSpace(string(output)),
+		Output:     strings.TrimSpace(output.String()),
 		ExitCode:   exitCode,
 		Duration:   duration,
 		Command:    command,
@@ -111,8 +151,15 @@ func (te *ToolExecutor) executeBash(input map[string]interface{}) (string, error
 				"command", command,
 				"timeout", timeout,
 				"duration", duration)
-			return "", fmt.Errorf("command timed out after %v\nCommand: %s\nOutput: %s",
-				timeout, command, result.Output)
+			return "", fmt.Errorf("command timed out after %gs and was killed\nCommand: %s\nOutput: %s",
+				timeout.Seconds(), command, result.Output)
+		}
+		if errors.Is(ctx.Err(), context.Canceled) {
+			loggy.Info("ToolExecutor executeBash cancelled",
+				"command", command,
+				"duration", duration)
+			return "", fmt.Errorf("command cancelled after %v\nCommand: %s\nOutput: %s",
+				duration, command, result.Output)
 		}
 
 		loggy.Error("ToolExecutor executeBash failed",
@@ -139,6 +186,115 @@ func (te *ToolExecutor) executeBash(input map[string]interface{}) (string, error
 	return response, nil
 }
 
+// bashCallTimeout returns the timeout for a bash call: timeout_seconds (or the older
+// timeout input) if given, capped at maxBashTimeout, otherwise the configured default
+func (te *ToolExecutor) bashCallTimeout(input map[string]interface{}) time.Duration {
+	timeout := te.bashTimeout
+	if timeout <= 0 {
+		timeout = defaultBashTimeout
+	}
+
+	for _, key := range []string{"timeout_seconds", "timeout"} {
+		if seconds, ok := input[key].(float64); ok && seconds > 0 {
+			timeout = min(time.Duration(seconds*float64(time.Second)), maxBashTimeout)
+			break
+		}
+	}
+
+	return timeout
+}
+
+// commandOutput collects a command's output, keeping the first and last halves of max
+// bytes, and passes complete lines to onLine as they arrive. Stdout and stderr share
+// one writer, so exec copies them from a single pipe and writes are not concurrent.
+type commandOutput struct {
+	max     int
+	head    []byte
+	tail    []byte
+	dropped int // Bytes dropped from the front of tail
+	line    []byte
+	onLine  OutputFunc
+}
+
+func (o *commandOutput) Write(p []byte) (int, error) {
+	o.capture(p)
+	if o.onLine != nil {
+		o.splitLines(p)
+	}
+	return len(p), nil
+}
+
+// capture fills head, then keeps the most recent output in tail. tail is compacted
+// only once it holds twice what is kept, so writes stay cheap.
+func (o *commandOutput) capture(p []byte) {
+	half := o.max / 2
+	if room := half - len(o.head); room > 0 {
+		n := min(room, len(p))
+		o.head = append(o.head, p[:n]...)
+		p = p[n:]
+	}
+
+	o.tail = append(o.tail, p...)
+	if len(o.tail) > 2*half {
+		drop := len(o.tail) - half
+		o.dropped += drop
+		o.tail = append(o.tail[:0], o.tail[drop:]...)
+	}
+}
+
+// splitLines passes each complete line to onLine, keeping a partial line for later
+func (o *commandOutput) splitLines(p []byte) {
+	for len(p) > 0 {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			o.line = append(o.line, p...)
+			if len(o.line) > maxOutputLineBytes {
+				o.emitLine()
+			}
+			return
+		}
+		o.line = append(o.line, p[:i]...)
+		o.emitLine()
+		p = p[i+1:]
+	}
+}
+
+// emitLine sends the buffered line. Progress bars redraw with carriage returns, so
+// only the text after the last one is sent.
+func (o *commandOutput) emitLine() {
+	line := strings.TrimRight(string(o.line), "\r")
+	o.line = o.line[:0]
+	if i := strings.LastIndexByte(line, '\r'); i >= 0 {
+		line = line[i+1:]
+	}
+	if len(line) > maxOutputLineBytes {
+		line = line[:maxOutputLineBytes]
+	}
+	o.onLine(strings.ToValidUTF8(line, ""))
+}
+
+// Flush sends a final line that did not end with a newline
+func (o *commandOutput) Flush() {
+	if o.onLine != nil && len(o.line) > 0 {
+		o.emitLine()
+	}
+}
+
+// String returns the captured output, noting how much was dropped from the middle
+func (o *commandOutput) String() string {
+	tail := o.tail
+	dropped := o.dropped
+	if excess := len(tail) - o.max/2; excess > 0 {
+		tail = tail[excess:]
+		dropped += excess
+	}
+
+	if dropped == 0 {
+		return string(o.head) + string(tail)
+	}
+	return strings.ToValidUTF8(fmt.Sprintf("%s\n... [%d bytes of output omitted] ...\n%s", o.head, dropped, tail), "")
+}
+
 // formatBashResponse formats the bash execution result
 func (te *ToolExecutor) formatBashResponse(result *BashResult) string {
 	var response strings.Builder
@@ -5,6 +5,7 @@ import (
 	"github.com/tildaslashalef/bazinga/internal/llm"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestToolExecutor_Bash(t *testing.T) {
@@ -214,3 +215,122 @@ func TestToolExecutor_Bash_Timeout(t *testing.T) {
 		t.Errorf("Expected timeout error, got: %v", err)
 	}
 }
+
+func TestToolExecutor_Bash_TimeoutSeconds(t *testing.T) {
+	te := NewToolExecutor(t.TempDir())
+
+	start := time.Now()
+	_, err := te.ExecuteTool(context.Background(), &llm.ToolCall{
+		Name:  "bash",
+		Input: map[string]interface{}{"command": "sleep 30 & sleep 30", "timeout_seconds": 0.5},
+	})
+	if err == nil || !strings.Contains(err.Error(), "command timed out after 0.5s") {
+		t.Errorf("Expected timeout error naming the timeout, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected the timeout to kill the command promptly, took %v", elapsed)
+	}
+}
+
+func TestToolExecutor_Bash_StdinIsEmpty(t *testing.T) {
+	te := NewToolExecutor(t.TempDir())
+
+	// A command waiting for input must see end of file instead of blocking
+	result, err := te.ExecuteTool(context.Background(), &llm.ToolCall{
+		Name:  "bash",
+		Input: map[string]interface{}{"command": "cat && echo done", "timeout_seconds": 5},
+	})
+	if err != nil {
+		t.Fatalf("bash failed: %v", err)
+	}
+	if !strings.Contains(result, "done") {
+		t.Errorf("Expected command to finish, got: %s", result)
+	}
+}
+
+func TestBashCallTimeout(t *testing.T) {
+	te := NewToolExecutor(t.TempDir())
+
+	tests := []struct {
+		name  string
+		input map[string]interface{}
+		want  time.Duration
+	}{
+		{"default", map[string]interface{}{}, defaultBashTimeout},
+		{"timeout_seconds", map[string]interface{}{"timeout_seconds": 1.5}, 1500 * time.Millisecond},
+		{"legacy timeout", map[string]interface{}{"timeout": 3.0}, 3 * time.Second},
+		{"capped", map[string]interface{}{"timeout_seconds": 100000.0}, maxBashTimeout},
+		{"ignores non-positive", map[string]interface{}{"timeout_seconds": -1.0}, defaultBashTimeout},
+	}
+
+	for _, tt := range tests {
+		if got := te.bashCallTimeout(tt.input); got != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+
+	te.SetBashTimeout(45 * time.Second)
+	if got := te.bashCallTimeout(map[string]interface{}{}); got != 45*time.Second {
+		t.Errorf("Expected configured default of 45s, got %v", got)
+	}
+}
+
+func TestToolExecutor_Bash_StreamsOutput(t *testing.T) {
+	te := NewToolExecutor(t.TempDir())
+
+	var lines []string
+	ctx := WithOutputFunc(context.Background(), func(line string) {
+		lines = append(lines, line)
+	})
+
+	result, err := te.ExecuteTool(ctx, &llm.ToolCall{
+		Name:  "bash",
+		Input: map[string]interface{}{"command": "printf 'one\\ntwo\\r\\nprogress 50%%\\rprogress 100%%\\nlast'"},
+	})
+	if err != nil {
+		t.Fatalf("bash failed: %v", err)
+	}
+
+	want := []string{"one", "two", "progress 100%", "last"}
+	if strings.Join(lines, "|") != strings.Join(want, "|") {
+		t.Errorf("Expected streamed lines %q, got %q", want, lines)
+	}
+	if !strings.Contains(result, "last") {
+		t.Errorf("Expected full output in the result, got: %s", result)
+	}
+}
+
+func TestToolExecutor_Bash_CancelKillsProcessGroup(t *testing.T) {
+	te := NewToolExecutor(t.TempDir())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(200*time.Millisecond, cancel)
+
+	// The background sleep keeps the output pipe open unless the whole group is killed
+	start := time.Now()
+	_, err := te.ExecuteTool(ctx, &llm.ToolCall{
+		Name:  "bash",
+		Input: map[string]interface{}{"command": "sleep 30 & sleep 30"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "command cancelled") {
+		t.Errorf("Expected cancellation error, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected cancellation to stop the command promptly, took %v", elapsed)
+	}
+}
+
+func TestCommandOutput_KeepsHeadAndTail(t *testing.T) {
+	output := &commandOutput{max: 10}
+	for i := 0; i < 10; i++ {
+		_, _ = output.Write([]byte("0123456789"))
+	}
+
+	got := output.String()
+	if !strings.HasPrefix(got, "01234\n") || !strings.HasSuffix(got, "\n56789") {
+		t.Errorf("Expected first and last 5 bytes kept, got %q", got)
+	}
+	if !strings.Contains(got, "[90 bytes of output omitted]") {
+		t.Errorf("Expected omitted byte count, got %q", got)
+	}
+}
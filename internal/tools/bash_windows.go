@@ -0,0 +1,9 @@
+//go:build windows
+
+package tools
+
+import "os/exec"
+
+// setProcessGroup leaves cmd unchanged on Windows, where cancelling it kills only the
+// command itself
+func setProcessGroup(cmd *exec.Cmd) {}
@@ -0,0 +1,53 @@
+package tools
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+)
+
+const (
+	// binarySniffBytes is how much of the start of a file is sampled to tell binary from text
+	binarySniffBytes = 8000
+	// maxInvalidUTF8Ratio is the share of invalid UTF-8 sequences above which a NUL-free
+	// sample is still treated as binary, e.g. Latin-1 text stays text but compressed data does not
+	maxInvalidUTF8Ratio = 0.3
+)
+
+// errBinaryFile is returned by search helpers for files that are not text
+var errBinaryFile = errors.New("binary file")
+
+// isBinary reports whether content looks like a binary file. The start of the content
+// is sampled: any NUL byte, or a high share of invalid UTF-8, marks it as binary.
+func isBinary(content []byte) bool {
+	sample := content[:min(len(content), binarySniffBytes)]
+	if bytes.IndexByte(sample, 0) != -1 {
+		return true
+	}
+
+	invalid, runes := 0, 0
+	for len(sample) > 0 {
+		// A rune cut off by the end of the sample is not invalid
+		if len(sample) < utf8.UTFMax && !utf8.FullRune(sample) && len(content) > binarySniffBytes {
+			break
+		}
+		r, size := utf8.DecodeRune(sample)
+		if r == utf8.RuneError && size == 1 {
+			invalid++
+		}
+		runes++
+		sample = sample[size:]
+	}
+
+	return runes > 0 && float64(invalid)/float64(runes) > maxInvalidUTF8Ratio
+}
+
+// binaryFileNote is what read_file returns instead of the contents of a binary file
+func binaryFileNote(displayPath string, size int) string {
+	sizeText := fmt.Sprintf("%d bytes", size)
+	if size >= 1024 {
+		sizeText += " (" + formatByteSize(size) + ")"
+	}
+	return fmt.Sprintf("%s is a binary file, %s; its contents are not shown. Use bash with `file` or `xxd | head` to inspect it.", displayPath, sizeText)
+}
@@ -0,0 +1,32 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIsBinary(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    bool
+	}{
+		{"empty", "", false},
+		{"ascii", "package main\n\nfunc main() {}\n", false},
+		{"utf-8", "héllo wörld — ✓ 日本語\n", false},
+		{"nul byte", "ELF\x00\x01\x02", true},
+		{"png header", "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR", true},
+		{"mostly invalid utf-8", strings.Repeat("\xff\xfe\xc3", 20), true},
+		{"occasional latin-1 byte", "caf\xe9 au lait, na\xefve r\xe9sum\xe9 of the project\n", false},
+		{"nul after the sample", strings.Repeat("a", binarySniffBytes) + "\x00", false},
+		{"rune cut by the sample", strings.Repeat("a", binarySniffBytes-1) + "é", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isBinary([]byte(tt.content)); got != tt.want {
+				t.Errorf("isBinary(%q) = %v, want %v", tt.content[:min(len(tt.content), 40)], got, tt.want)
+			}
+		})
+	}
+}
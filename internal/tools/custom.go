@@ -0,0 +1,333 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"github.com/tildaslashalef/bazinga/internal/llm"
+	"github.com/tildaslashalef/bazinga/internal/loggy"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// CustomTool is a project-specific command exposed to the model as a named tool.
+// Command is a template such as "./scripts/deploy.sh {{env}} --tag={{tag}}". It is
+// split into arguments once, at registration, and placeholders are substituted into
+// individual arguments, so values are never interpreted by a shell.
+type CustomTool struct {
+	Name        string
+	Description string
+	Command     string
+	Parameters  map[string]interface{} // JSON schema for the tool input
+	Timeout     time.Duration
+
+	argv []string
+}
+
+// placeholderPattern matches {{name}} placeholders in custom tool commands
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// RegisterCustomTools validates and registers config-defined tools
+func (te *ToolExecutor) RegisterCustomTools(defs []CustomTool) error {
+	builtins := make(map[string]bool)
+	for _, tool := range te.builtinTools() {
+		builtins[tool.Name] = true
+	}
+
+	customTools := make(map[string]*CustomTool)
+	for i := range defs {
+		def := defs[i]
+		if def.Name == "" {
+			return fmt.Errorf("custom tool %d: name is required", i)
+		}
+		if builtins[def.Name] {
+			return fmt.Errorf("custom tool %s: conflicts with a built-in tool", def.Name)
+		}
+		if _, exists := customTools[def.Name]; exists {
+			return fmt.Errorf("custom tool %s: defined more than once", def.Name)
+		}
+
+		argv, err := splitCommandTemplate(def.Command)
+		if err != nil {
+			return fmt.Errorf("custom tool %s: %w", def.Name, err)
+		}
+		if len(argv) == 0 {
+			return fmt.Errorf("custom tool %s: command is required", def.Name)
+		}
+		if placeholderPattern.MatchString(argv[0]) {
+			return fmt.Errorf("custom tool %s: the program name cannot be a placeholder", def.Name)
+		}
+
+		if def.Parameters == nil {
+			def.Parameters = map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+			}
+		}
+		if def.Timeout <= 0 {
+			def.Timeout = 60 * time.Second
+		}
+		def.argv = argv
+
+		customTools[def.Name] = &def
+	}
+
+	te.customTools = customTools
+	return nil
+}
+
+// customToolDefinitions returns the llm tool schemas for registered custom tools
+func (te *ToolExecutor) customToolDefinitions() []llm.Tool {
+	names := make([]string, 0, len(te.customTools))
+	for name := range te.customTools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var defs []llm.Tool
+	for _, name := range names {
+		tool := te.customTools[name]
+		description := tool.Description
+		if description == "" {
+			description = fmt.Sprintf("Run the project command: %s", tool.Command)
+		}
+		defs = append(defs, llm.Tool{
+			Name:        tool.Name,
+			Description: description,
+			InputSchema: tool.Parameters,
+		})
+	}
+	return defs
+}
+
+// executeCustomTool runs a custom tool with its arguments substituted into the command
+func (te *ToolExecutor) executeCustomTool(ctx context.Context, tool *CustomTool, input map[string]interface{}) (string, error) {
+	args, err := tool.renderArgs(input)
+	if err != nil {
+		return "", err
+	}
+
+	loggy.Debug("ToolExecutor executeCustomTool",
+		"tool", tool.Name,
+		"args", args,
+		"timeout", tool.Timeout)
+
+	startTime := time.Now()
+
+	ctx, cancel := context.WithTimeout(ctx, tool.Timeout)
+	defer cancel()
+
+	// Hitting the timeout kills the command and everything it started
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Dir = te.rootPath
+	cmd.WaitDelay = processWaitDelay
+	setProcessGroup(cmd)
+
+	output, err := cmd.CombinedOutput()
+	duration := time.Since(startTime)
+
+	exitCode := 0
+	if err != nil {
+		if exitError, ok := err.(*exec.ExitError); ok {
+			if status, ok := exitError.Sys().(syscall.WaitStatus); ok {
+				exitCode = status.ExitStatus()
+			}
+		}
+	}
+
+	result := &BashResult{
+		Output:     strings.TrimSpace(string(output)),
+		ExitCode:   exitCode,
+		Duration:   duration,
+		Command:    strings.Join(args, " "),
+		WorkingDir: te.rootPath,
+	}
+
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return "", fmt.Errorf("%s timed out after %v\nOutput: %s", tool.Name, tool.Timeout, result.Output)
+		}
+
+		loggy.Error("ToolExecutor executeCustomTool failed",
+			"tool", tool.Name,
+			"exit_code", exitCode,
+			"error", err)
+
+		return "", fmt.Errorf("%s failed with exit code %d\nCommand: %s\nOutput:\n%s",
+			tool.Name, exitCode, result.Command, result.Output)
+	}
+
+	return te.formatBashResponse(result), nil
+}
+
+// CustomToolArgs returns the arguments a call to the custom tool name runs, with its
+// input substituted into the command. It returns false for other tools and for input
+// the tool would reject.
+func (te *ToolExecutor) CustomToolArgs(name string, input map[string]interface{}) ([]string, bool) {
+	tool, ok := te.customTools[name]
+	if !ok {
+		return nil, false
+	}
+	args, err := tool.renderArgs(input)
+	return args, err == nil
+}
+
+// renderArgs validates input against the tool schema and builds the argument list.
+// Arguments that reference an omitted optional parameter are dropped.
+func (tool *CustomTool) renderArgs(input map[string]interface{}) ([]string, error) {
+	properties, _ := tool.Parameters["properties"].(map[string]interface{})
+
+	for _, name := range schemaRequired(tool.Parameters) {
+		if _, ok := input[name]; !ok {
+			return nil, fmt.Errorf("%s is required", name)
+		}
+	}
+
+	values := make(map[string]string, len(input))
+	for name, raw := range input {
+		prop, known := properties[name].(map[string]interface{})
+		if !known {
+			return nil, fmt.Errorf("unknown argument: %s", name)
+		}
+
+		value, err := formatArgValue(name, raw, prop)
+		if err != nil {
+			return nil, err
+		}
+		values[name] = value
+	}
+
+	var args []string
+	for _, token := range tool.argv {
+		missing := false
+		rendered := placeholderPattern.ReplaceAllStringFunc(token, func(match string) string {
+			name := placeholderPattern.FindStringSubmatch(match)[1]
+			value, ok := values[name]
+			if !ok {
+				missing = true
+			}
+			return value
+		})
+		if missing {
+			continue
+		}
+		args = append(args, rendered)
+	}
+
+	return args, nil
+}
+
+// formatArgValue checks a single argument against its schema and converts it to a string
+func formatArgValue(name string, raw interface{}, prop map[string]interface{}) (string, error) {
+	var value string
+
+	switch v := raw.(type) {
+	case string:
+		// Keep values from being read as flags by the target command
+		if strings.HasPrefix(v, "-") {
+			return "", fmt.Errorf("argument %s must not start with '-'", name)
+		}
+		value = v
+	case float64:
+		value = strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		value = strconv.FormatBool(v)
+	default:
+		return "", fmt.Errorf("argument %s must be a string, number or boolean", name)
+	}
+
+	switch prop["type"] {
+	case "string":
+		if _, ok := raw.(string); !ok {
+			return "", fmt.Errorf("argument %s must be a string", name)
+		}
+	case "number":
+		if _, ok := raw.(float64); !ok {
+			return "", fmt.Errorf("argument %s must be a number", name)
+		}
+	case "integer":
+		if f, ok := raw.(float64); !ok || f != float64(int64(f)) {
+			return "", fmt.Errorf("argument %s must be an integer", name)
+		}
+	case "boolean":
+		if _, ok := raw.(bool); !ok {
+			return "", fmt.Errorf("argument %s must be a boolean", name)
+		}
+	}
+
+	if enum, ok := prop["enum"].([]interface{}); ok && len(enum) > 0 {
+		allowed := false
+		for _, option := range enum {
+			if fmt.Sprintf("%v", option) == value {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return "", fmt.Errorf("argument %s must be one of %v", name, enum)
+		}
+	}
+
+	return value, nil
+}
+
+// schemaRequired returns the required property names from a JSON schema
+func schemaRequired(schema map[string]interface{}) []string {
+	switch required := schema["required"].(type) {
+	case []string:
+		return required
+	case []interface{}:
+		var names []string
+		for _, name := range required {
+			if s, ok := name.(string); ok {
+				names = append(names, s)
+			}
+		}
+		return names
+	}
+	return nil
+}
+
+// splitCommandTemplate splits a command into arguments, honouring single and double quotes
+func splitCommandTemplate(command string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inToken := false
+	var quote rune
+
+	for _, r := range command {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inToken = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inToken {
+				args = append(args, current.String())
+				current.Reset()
+				inToken = false
+			}
+		default:
+			current.WriteRune(r)
+			inToken = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in command")
+	}
+	if inToken {
+		args = append(args, current.String())
+	}
+
+	return args, nil
+}
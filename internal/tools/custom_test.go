@@ -0,0 +1,160 @@
+package tools
+
+import (
+	"context"
+	"github.com/tildaslashalef/bazinga/internal/llm"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newGreetTool() CustomTool {
+	return CustomTool{
+		Name:        "greet",
+		Description: "Print a greeting",
+		Command:     "echo 'hello:' {{name}} --times={{times}}",
+		Parameters: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"name":  map[string]interface{}{"type": "string"},
+				"times": map[string]interface{}{"type": "integer"},
+			},
+			"required": []interface{}{"name"},
+		},
+	}
+}
+
+func TestToolExecutor_CustomTool(t *testing.T) {
+	tempDir := t.TempDir()
+	te := NewToolExecutor(tempDir)
+
+	if err := te.RegisterCustomTools([]CustomTool{newGreetTool()}); err != nil {
+		t.Fatalf("RegisterCustomTools failed: %v", err)
+	}
+
+	found := false
+	for _, tool := range te.GetAvailableTools() {
+		if tool.Name == "greet" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatal("Expected custom tool in available tools")
+	}
+
+	result, err := te.ExecuteTool(context.Background(), &llm.ToolCall{
+		Name:  "greet",
+		Input: map[string]interface{}{"name": "world", "times": float64(2)},
+	})
+	if err != nil {
+		t.Fatalf("custom tool failed: %v", err)
+	}
+	if !strings.Contains(result, "hello: world --times=2") {
+		t.Errorf("Expected substituted arguments, got: %s", result)
+	}
+
+	// Omitted optional arguments drop their whole argument
+	result, err = te.ExecuteTool(context.Background(), &llm.ToolCall{
+		Name:  "greet",
+		Input: map[string]interface{}{"name": "world"},
+	})
+	if err != nil {
+		t.Fatalf("custom tool failed: %v", err)
+	}
+	if strings.Contains(result, "--times") {
+		t.Errorf("Expected optional argument to be dropped, got: %s", result)
+	}
+}
+
+func TestToolExecutor_CustomToolNoShellInjection(t *testing.T) {
+	tempDir := t.TempDir()
+	te := NewToolExecutor(tempDir)
+
+	if err := te.RegisterCustomTools([]CustomTool{newGreetTool()}); err != nil {
+		t.Fatalf("RegisterCustomTools failed: %v", err)
+	}
+
+	marker := filepath.Join(tempDir, "pwned")
+	payloads := []string{
+		"x; touch " + marker,
+		"$(touch " + marker + ")",
+		"`touch " + marker + "`",
+		"x && touch " + marker,
+	}
+
+	for _, payload := range payloads {
+		result, err := te.ExecuteTool(context.Background(), &llm.ToolCall{
+			Name:  "greet",
+			Input: map[string]interface{}{"name": payload},
+		})
+		if err != nil {
+			t.Fatalf("custom tool failed for %q: %v", payload, err)
+		}
+		if !strings.Contains(result, payload) {
+			t.Errorf("Expected payload to be passed literally, got: %s", result)
+		}
+	}
+
+	if _, err := os.Stat(marker); err == nil {
+		t.Fatal("Argument was interpreted by a shell")
+	}
+}
+
+func TestToolExecutor_CustomToolValidation(t *testing.T) {
+	te := NewToolExecutor(t.TempDir())
+
+	if err := te.RegisterCustomTools([]CustomTool{newGreetTool()}); err != nil {
+		t.Fatalf("RegisterCustomTools failed: %v", err)
+	}
+
+	invalidInputs := []map[string]interface{}{
+		{},                                      // missing required
+		{"name": float64(1)},                    // wrong type
+		{"name": "x", "times": float64(1.5)},    // not an integer
+		{"name": "--help"},                      // flag injection
+		{"name": "x", "extra": "not in schema"}, // unknown argument
+	}
+
+	for _, input := range invalidInputs {
+		_, err := te.ExecuteTool(context.Background(), &llm.ToolCall{Name: "greet", Input: input})
+		if err == nil {
+			t.Errorf("Expected error for input %v", input)
+		}
+	}
+
+	// Built-in tool names cannot be overridden
+	err := te.RegisterCustomTools([]CustomTool{{Name: "bash", Command: "echo hi"}})
+	if err == nil {
+		t.Error("Expected error when overriding a built-in tool")
+	}
+
+	// The program itself cannot come from an argument
+	err = te.RegisterCustomTools([]CustomTool{{Name: "run", Command: "{{program}} --version"}})
+	if err == nil {
+		t.Error("Expected error when the program name is a placeholder")
+	}
+}
+
+func TestToolExecutor_CustomToolTimeoutKillsChildren(t *testing.T) {
+	te := NewToolExecutor(t.TempDir())
+
+	if err := te.RegisterCustomTools([]CustomTool{{
+		Name:    "serve",
+		Command: "sh -c 'sleep 30 & sleep 30'",
+		Timeout: 500 * time.Millisecond,
+	}}); err != nil {
+		t.Fatalf("RegisterCustomTools failed: %v", err)
+	}
+
+	start := time.Now()
+	_, err := te.ExecuteTool(context.Background(), &llm.ToolCall{Name: "serve", Input: map[string]interface{}{}})
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("Expected timeout error, got: %v", err)
+	}
+	// A background child still holding the output would keep the call waiting
+	if elapsed := time.Since(start); elapsed > processWaitDelay {
+		t.Errorf("Expected the timeout to kill the command and its children promptly, took %v", elapsed)
+	}
+}
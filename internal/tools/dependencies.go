@@ -0,0 +1,588 @@
+package tools
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/tildaslashalef/bazinga/internal/llm"
+	"github.com/tildaslashalef/bazinga/internal/loggy"
+	"github.com/tildaslashalef/bazinga/internal/project"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"golang.org/x/mod/modfile"
+)
+
+// updateCheckTimeout is how long looking up newer versions may take
+const updateCheckTimeout = 60 * time.Second
+
+// requirementName splits a requirements.txt or PEP 508 line into the package name, with
+// any extras, and the rest: version specifiers and environment markers
+var requirementName = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9._-]*(?:\[[^\]]*\])?)\s*(.*)$`)
+
+// dependency is a package a manifest depends on
+type dependency struct {
+	name    string
+	version string // Version or constraint as written in the manifest
+	latest  string // Newer version found by an update check
+}
+
+// dependencyGroup is a named list of dependencies, such as "dependencies" or
+// "dev-dependencies"
+type dependencyGroup struct {
+	name string
+	deps []dependency
+}
+
+// manifestDependencies is what a manifest declares
+type manifestDependencies struct {
+	manifest string   // File name, relative to the directory
+	header   []string // Module name, language version and such
+	groups   []dependencyGroup
+	notes    []string
+	checked  bool // Newer versions were looked up
+}
+
+// dependenciesTool describes the dependencies tool
+func dependenciesTool() llm.Tool {
+	return llm.Tool{
+		Name:        "dependencies",
+		Description: "List a project's direct dependencies with their versions, read from its manifest: go.mod, package.json, requirements.txt or pyproject.toml, or Cargo.toml, depending on the project type. Far more reliable than reading or grepping the manifest. Set check_updates to also look up newer versions (Go and npm only; uses the network).",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"dir_path": map[string]interface{}{
+					"type":        "string",
+					"description": "Directory containing the manifest, for a module or package below the root (optional, default: project root)",
+				},
+				"check_updates": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Flag dependencies with newer versions, using go list -m -u or npm outdated; needs network access (optional, default: false)",
+				},
+			},
+		},
+	}
+}
+
+// listDependencies returns the direct dependencies declared by the manifest of the
+// project at dir_path
+func (te *ToolExecutor) listDependencies(ctx context.Context, input map[string]interface{}) (string, error) {
+	dir := te.rootPath
+	if dirPath, ok := input["dir_path"].(string); ok && dirPath != "" {
+		dir = te.absPath(dirPath)
+	}
+	checkUpdates, _ := input["check_updates"].(bool)
+
+	projectType := project.NewDetector().DetectType(dir)
+	loggy.Debug("ToolExecutor listDependencies", "dir", dir, "project_type", projectType, "check_updates", checkUpdates)
+
+	var deps *manifestDependencies
+	var err error
+	switch projectType {
+	case project.ProjectTypeGo:
+		deps, err = goModDependencies(dir)
+	case project.ProjectTypeJavaScript, project.ProjectTypeTypeScript:
+		deps, err = packageJSONDependencies(dir)
+	case project.ProjectTypePython:
+		deps, err = pythonDependencies(dir)
+	case project.ProjectTypeRust:
+		deps, err = cargoDependencies(dir)
+	default:
+		return fmt.Sprintf("No recognized manifest in %s (a %s project); dependencies can be listed for Go, JavaScript/TypeScript, Python and Rust projects", te.displayPath(dir), projectType), nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if checkUpdates {
+		ctx, cancel := context.WithTimeout(ctx, updateCheckTimeout)
+		defer cancel()
+
+		switch projectType {
+		case project.ProjectTypeGo:
+			err = checkGoUpdates(ctx, dir, deps)
+		case project.ProjectTypeJavaScript, project.ProjectTypeTypeScript:
+			err = checkNPMUpdates(ctx, dir, deps)
+		default:
+			deps.notes = append(deps.notes, fmt.Sprintf("Update check is not supported for %s projects", projectType))
+		}
+		if err != nil {
+			deps.notes = append(deps.notes, "Update check failed: "+err.Error())
+		}
+	}
+
+	return formatDependencies(te.displayPath(filepath.Join(dir, deps.manifest)), deps), nil
+}
+
+// formatDependencies lists the dependencies of a manifest, one per line, by group
+func formatDependencies(manifest string, deps *manifestDependencies) string {
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Manifest: %s\n", manifest))
+	for _, line := range deps.header {
+		result.WriteString(line + "\n")
+	}
+
+	total, outdated := 0, 0
+	for _, group := range deps.groups {
+		if len(group.deps) == 0 {
+			continue
+		}
+		total += len(group.deps)
+
+		width := 0
+		for _, dep := range group.deps {
+			width = max(width, len(dep.name))
+		}
+		result.WriteString(fmt.Sprintf("\n%s (%d):\n", group.name, len(group.deps)))
+		for _, dep := range group.deps {
+			line := fmt.Sprintf("  %-*s  %s", width, dep.name, dep.version)
+			if dep.latest != "" {
+				line += fmt.Sprintf("  (latest %s)", dep.latest)
+				outdated++
+			}
+			result.WriteString(strings.TrimRight(line, " ") + "\n")
+		}
+	}
+	if total == 0 {
+		result.WriteString("\nNo dependencies declared\n")
+	}
+
+	if deps.checked && total > 0 {
+		if outdated == 0 {
+			result.WriteString("\nAll dependencies are up to date\n")
+		} else {
+			result.WriteString(fmt.Sprintf("\n%d of %d dependencies have newer versions\n", outdated, total))
+		}
+	}
+	if len(deps.notes) > 0 {
+		result.WriteString("\n" + strings.Join(deps.notes, "\n") + "\n")
+	}
+	return strings.TrimRight(result.String(), "\n")
+}
+
+// goModDependencies reads the requirements of go.mod. Indirect requirements are only
+// counted, and replacements are shown after the version they replace.
+func goModDependencies(dir string) (*manifestDependencies, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read go.mod: %w", err)
+	}
+	file, err := modfile.Parse("go.mod", data, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse go.mod: %w", err)
+	}
+
+	deps := &manifestDependencies{manifest: "go.mod"}
+	if file.Module != nil {
+		deps.header = append(deps.header, "Module: "+file.Module.Mod.Path)
+	}
+	if file.Go != nil {
+		deps.header = append(deps.header, "Go: "+file.Go.Version)
+	}
+
+	replaced := make(map[string]string)
+	for _, replace := range file.Replace {
+		target := replace.New.Path
+		if replace.New.Version != "" {
+			target += " " + replace.New.Version
+		}
+		replaced[replace.Old.Path] = target
+	}
+
+	direct := dependencyGroup{name: "Dependencies"}
+	indirect := 0
+	for _, require := range file.Require {
+		if require.Indirect {
+			indirect++
+			continue
+		}
+		version := require.Mod.Version
+		if target, ok := replaced[require.Mod.Path]; ok {
+			version += " => " + target
+		}
+		direct.deps = append(direct.deps, dependency{name: require.Mod.Path, version: version})
+	}
+	deps.groups = append(deps.groups, direct)
+	if indirect > 0 {
+		deps.notes = append(deps.notes, fmt.Sprintf("%d indirect dependencies not listed", indirect))
+	}
+	return deps, nil
+}
+
+// packageJSONDependencies reads the dependency sections of package.json
+func packageJSONDependencies(dir string) (*manifestDependencies, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read package.json: %w", err)
+	}
+	var manifest struct {
+		Name                 string            `json:"name"`
+		Version              string            `json:"version"`
+		Dependencies         map[string]string `json:"dependencies"`
+		DevDependencies      map[string]string `json:"devDependencies"`
+		PeerDependencies     map[string]string `json:"peerDependencies"`
+		OptionalDependencies map[string]string `json:"optionalDependencies"`
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse package.json: %w", err)
+	}
+
+	deps := &manifestDependencies{manifest: "package.json"}
+	if manifest.Name != "" {
+		deps.header = append(deps.header, strings.TrimSpace("Package: "+manifest.Name+" "+manifest.Version))
+	}
+	deps.groups = []dependencyGroup{
+		{name: "Dependencies", deps: dependencyMap(manifest.Dependencies)},
+		{name: "Dev dependencies", deps: dependencyMap(manifest.DevDependencies)},
+		{name: "Peer dependencies", deps: dependencyMap(manifest.PeerDependencies)},
+		{name: "Optional dependencies", deps: dependencyMap(manifest.OptionalDependencies)},
+	}
+	return deps, nil
+}
+
+// pythonDependencies reads pyproject.toml, or requirements.txt when there is no
+// pyproject.toml or it declares no dependencies
+func pythonDependencies(dir string) (*manifestDependencies, error) {
+	if _, err := os.Stat(filepath.Join(dir, "pyproject.toml")); err == nil {
+		deps, err := pyprojectDependencies(dir)
+		if err != nil {
+			return nil, err
+		}
+		if hasDependencies(deps) {
+			return deps, nil
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "requirements.txt")); err == nil {
+		return requirementsDependencies(dir)
+	}
+	return &manifestDependencies{manifest: "pyproject.toml", notes: []string{"No pyproject.toml dependencies or requirements.txt found"}}, nil
+}
+
+// pyprojectDependencies reads the PEP 621 dependencies of pyproject.toml, its optional
+// dependencies and dependency groups, and Poetry's dependency tables
+func pyprojectDependencies(dir string) (*manifestDependencies, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "pyproject.toml"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pyproject.toml: %w", err)
+	}
+	var manifest struct {
+		Project struct {
+			Name                 string              `toml:"name"`
+			Version              string              `toml:"version"`
+			RequiresPython       string              `toml:"requires-python"`
+			Dependencies         []string            `toml:"dependencies"`
+			OptionalDependencies map[string][]string `toml:"optional-dependencies"`
+		} `toml:"project"`
+		DependencyGroups map[string][]interface{} `toml:"dependency-groups"`
+		Tool             struct {
+			Poetry struct {
+				Dependencies    map[string]interface{} `toml:"dependencies"`
+				DevDependencies map[string]interface{} `toml:"dev-dependencies"`
+				Group           map[string]struct {
+					Dependencies map[string]interface{} `toml:"dependencies"`
+				} `toml:"group"`
+			} `toml:"poetry"`
+		} `toml:"tool"`
+	}
+	if err := toml.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse pyproject.toml: %w", err)
+	}
+
+	deps := &manifestDependencies{manifest: "pyproject.toml"}
+	if manifest.Project.Name != "" {
+		deps.header = append(deps.header, strings.TrimSpace("Package: "+manifest.Project.Name+" "+manifest.Project.Version))
+	}
+	if manifest.Project.RequiresPython != "" {
+		deps.header = append(deps.header, "Python: "+manifest.Project.RequiresPython)
+	}
+
+	deps.groups = append(deps.groups, dependencyGroup{name: "Dependencies", deps: requirementList(manifest.Project.Dependencies)})
+	for _, name := range sortedKeys(manifest.Project.OptionalDependencies) {
+		deps.groups = append(deps.groups, dependencyGroup{name: fmt.Sprintf("Optional dependencies [%s]", name), deps: requirementList(manifest.Project.OptionalDependencies[name])})
+	}
+	for _, name := range sortedKeys(manifest.DependencyGroups) {
+		// Groups may include other groups as tables; only requirement strings are listed
+		var requirements []string
+		for _, entry := range manifest.DependencyGroups[name] {
+			if requirement, ok := entry.(string); ok {
+				requirements = append(requirements, requirement)
+			}
+		}
+		deps.groups = append(deps.groups, dependencyGroup{name: fmt.Sprintf("Dependency group [%s]", name), deps: requirementList(requirements)})
+	}
+
+	poetry := manifest.Tool.Poetry
+	if python, ok := poetry.Dependencies["python"]; ok && manifest.Project.RequiresPython == "" {
+		deps.header = append(deps.header, "Python: "+tableVersion(python))
+	}
+	delete(poetry.Dependencies, "python")
+	deps.groups = append(deps.groups,
+		dependencyGroup{name: "Poetry dependencies", deps: dependencyTable(poetry.Dependencies)},
+		dependencyGroup{name: "Poetry dev dependencies", deps: dependencyTable(poetry.DevDependencies)},
+	)
+	for _, name := range sortedKeys(poetry.Group) {
+		deps.groups = append(deps.groups, dependencyGroup{name: fmt.Sprintf("Poetry group [%s]", name), deps: dependencyTable(poetry.Group[name].Dependencies)})
+	}
+	return deps, nil
+}
+
+// requirementsDependencies reads requirements.txt, skipping options such as -r and -e
+func requirementsDependencies(dir string) (*manifestDependencies, error) {
+	file, err := os.Open(filepath.Join(dir, "requirements.txt"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read requirements.txt: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	var requirements []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), " #")
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "-") {
+			continue
+		}
+		requirements = append(requirements, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read requirements.txt: %w", err)
+	}
+
+	return &manifestDependencies{
+		manifest: "requirements.txt",
+		groups:   []dependencyGroup{{name: "Dependencies", deps: requirementList(requirements)}},
+	}, nil
+}
+
+// cargoDependencies reads the dependency tables of Cargo.toml
+func cargoDependencies(dir string) (*manifestDependencies, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "Cargo.toml"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Cargo.toml: %w", err)
+	}
+	var manifest struct {
+		Package struct {
+			Name    string      `toml:"name"`
+			Version interface{} `toml:"version"`
+			Edition interface{} `toml:"edition"`
+		} `toml:"package"`
+		Dependencies      map[string]interface{} `toml:"dependencies"`
+		DevDependencies   map[string]interface{} `toml:"dev-dependencies"`
+		BuildDependencies map[string]interface{} `toml:"build-dependencies"`
+		Workspace         struct {
+			Members      []string               `toml:"members"`
+			Dependencies map[string]interface{} `toml:"dependencies"`
+		} `toml:"workspace"`
+	}
+	if err := toml.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse Cargo.toml: %w", err)
+	}
+
+	deps := &manifestDependencies{manifest: "Cargo.toml"}
+	if manifest.Package.Name != "" {
+		deps.header = append(deps.header, strings.TrimSpace("Package: "+manifest.Package.Name+" "+tableVersion(manifest.Package.Version)))
+	}
+	if edition := tableVersion(manifest.Package.Edition); edition != "" {
+		deps.header = append(deps.header, "Edition: "+edition)
+	}
+	if len(manifest.Workspace.Members) > 0 {
+		deps.header = append(deps.header, "Workspace members: "+strings.Join(manifest.Workspace.Members, ", "))
+	}
+	deps.groups = []dependencyGroup{
+		{name: "Dependencies", deps: dependencyTable(manifest.Dependencies)},
+		{name: "Dev dependencies", deps: dependencyTable(manifest.DevDependencies)},
+		{name: "Build dependencies", deps: dependencyTable(manifest.BuildDependencies)},
+		{name: "Workspace dependencies", deps: dependencyTable(manifest.Workspace.Dependencies)},
+	}
+	return deps, nil
+}
+
+// checkGoUpdates looks up newer versions of the direct dependencies with go list -m -u
+func checkGoUpdates(ctx context.Context, dir string, deps *manifestDependencies) error {
+	var modules []string
+	for _, dep := range deps.groups[0].deps {
+		modules = append(modules, dep.name)
+	}
+	if len(modules) == 0 {
+		deps.checked = true
+		return nil
+	}
+
+	cmd := exec.CommandContext(ctx, "go", append([]string{"list", "-m", "-u", "-json"}, modules...)...)
+	cmd.Dir = dir
+	cmd.WaitDelay = processWaitDelay
+	setProcessGroup(cmd)
+	output, err := cmd.Output()
+	if err != nil {
+		return commandError(ctx, "go list -m -u", err)
+	}
+
+	latest := make(map[string]string)
+	decoder := json.NewDecoder(strings.NewReader(string(output)))
+	for {
+		var module struct {
+			Path   string
+			Update *struct{ Version string }
+		}
+		if err := decoder.Decode(&module); err == io.EOF {
+			break
+		} else if err != nil {
+			return fmt.Errorf("failed to parse go list output: %w", err)
+		}
+		if module.Update != nil {
+			latest[module.Path] = module.Update.Version
+		}
+	}
+	setLatest(deps, latest)
+	return nil
+}
+
+// checkNPMUpdates looks up newer versions with npm outdated, which exits with status 1
+// when anything is outdated
+func checkNPMUpdates(ctx context.Context, dir string, deps *manifestDependencies) error {
+	cmd := exec.CommandContext(ctx, "npm", "outdated", "--json")
+	cmd.Dir = dir
+	cmd.WaitDelay = processWaitDelay
+	setProcessGroup(cmd)
+	output, err := cmd.Output()
+	var exitErr *exec.ExitError
+	if err != nil && !(errors.As(err, &exitErr) && exitErr.ExitCode() == 1 && len(output) > 0) {
+		return commandError(ctx, "npm outdated", err)
+	}
+	if strings.TrimSpace(string(output)) == "" {
+		deps.checked = true
+		return nil
+	}
+
+	var outdated map[string]struct {
+		Latest string `json:"latest"`
+	}
+	if err := json.Unmarshal(output, &outdated); err != nil {
+		return fmt.Errorf("failed to parse npm outdated output: %w", err)
+	}
+	latest := make(map[string]string)
+	for name, info := range outdated {
+		latest[name] = info.Latest
+	}
+	setLatest(deps, latest)
+	return nil
+}
+
+// commandError describes why an update check command failed, with its stderr
+func commandError(ctx context.Context, command string, err error) error {
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("%s timed out after %v", command, updateCheckTimeout)
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+		return fmt.Errorf("%s: %s", command, strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	return fmt.Errorf("%s: %w", command, err)
+}
+
+// setLatest records newer versions found by an update check
+func setLatest(deps *manifestDependencies, latest map[string]string) {
+	deps.checked = true
+	for i := range deps.groups {
+		for j := range deps.groups[i].deps {
+			dep := &deps.groups[i].deps[j]
+			if version, ok := latest[dep.name]; ok && version != "" && version != dep.version {
+				dep.latest = version
+			}
+		}
+	}
+}
+
+// hasDependencies reports whether a manifest declares any dependencies
+func hasDependencies(deps *manifestDependencies) bool {
+	for _, group := range deps.groups {
+		if len(group.deps) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// dependencyMap lists name-to-version dependencies, sorted by name
+func dependencyMap(entries map[string]string) []dependency {
+	var deps []dependency
+	for _, name := range sortedKeys(entries) {
+		deps = append(deps, dependency{name: name, version: entries[name]})
+	}
+	return deps
+}
+
+// dependencyTable lists TOML dependency tables, where a dependency is a version string
+// or a table with a version, path or git source, sorted by name
+func dependencyTable(entries map[string]interface{}) []dependency {
+	var deps []dependency
+	for _, name := range sortedKeys(entries) {
+		deps = append(deps, dependency{name: name, version: tableVersion(entries[name])})
+	}
+	return deps
+}
+
+// tableVersion describes a TOML dependency value: its version, or where it comes from
+func tableVersion(value interface{}) string {
+	switch value := value.(type) {
+	case string:
+		return value
+	case map[string]interface{}:
+		var parts []string
+		if version, ok := value["version"].(string); ok {
+			parts = append(parts, version)
+		}
+		for _, source := range []string{"path", "git"} {
+			if location, ok := value[source].(string); ok {
+				parts = append(parts, source+" "+location)
+			}
+		}
+		if workspace, ok := value["workspace"].(bool); ok && workspace {
+			parts = append(parts, "(workspace)")
+		}
+		if len(parts) == 0 {
+			return "*"
+		}
+		return strings.Join(parts, " ")
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// requirementList lists PEP 508 requirements such as "requests>=2.31; python_version>'3.8'"
+func requirementList(requirements []string) []dependency {
+	var deps []dependency
+	for _, requirement := range requirements {
+		match := requirementName.FindStringSubmatch(strings.TrimSpace(requirement))
+		if match == nil {
+			continue
+		}
+		version := strings.TrimSpace(match[2])
+		if version == "" {
+			version = "*"
+		}
+		deps = append(deps, dependency{name: match[1], version: version})
+	}
+	return deps
+}
+
+// sortedKeys returns the keys of a map in order
+func sortedKeys[V any](entries map[string]V) []string {
+	keys := make([]string, 0, len(entries))
+	for key := range entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,171 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestToolExecutor_ListDependencies(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		want    []string
+		notWant []string
+	}{
+		{
+			name: "go.mod",
+			files: map[string]string{"go.mod": `module example.com/app
+
+go 1.22
+
+require (
+	github.com/spf13/cobra v1.8.0
+	golang.org/x/text v0.14.0 // indirect
+)
+
+require github.com/local/lib v0.1.0
+
+replace github.com/local/lib => ../lib
+`},
+			want: []string{
+				"Manifest: go.mod", "Module: example.com/app", "Go: 1.22",
+				"Dependencies (2):", "github.com/spf13/cobra  v1.8.0", "github.com/local/lib    v0.1.0 => ../lib",
+				"1 indirect dependencies not listed",
+			},
+			notWant: []string{"golang.org/x/text"},
+		},
+		{
+			name: "package.json",
+			files: map[string]string{
+				"package.json":  `{"name": "web", "version": "1.0.0", "dependencies": {"react": "^18.2.0"}, "devDependencies": {"vitest": "^1.0.0", "eslint": "^8.0.0"}}`,
+				"tsconfig.json": "{}",
+			},
+			want: []string{"Package: web 1.0.0", "Dependencies (1):\n  react  ^18.2.0", "Dev dependencies (2):\n  eslint  ^8.0.0\n  vitest  ^1.0.0"},
+		},
+		{
+			name: "pyproject.toml",
+			files: map[string]string{
+				"pyproject.toml": `[project]
+name = "svc"
+requires-python = ">=3.10"
+dependencies = ["requests>=2.31", "uvicorn[standard]==0.27.0; sys_platform != 'win32'", "click"]
+
+[project.optional-dependencies]
+test = ["pytest>=8"]
+`,
+				"requirements.txt": "flask==3.0\n",
+			},
+			want: []string{
+				"Python: >=3.10", "requests           >=2.31", "uvicorn[standard]  ==0.27.0; sys_platform != 'win32'", "click              *",
+				"Optional dependencies [test] (1):\n  pytest  >=8",
+			},
+			notWant: []string{"flask"},
+		},
+		{
+			name: "poetry",
+			files: map[string]string{"pyproject.toml": `[tool.poetry.dependencies]
+python = "^3.11"
+django = "^5.0"
+
+[tool.poetry.group.dev.dependencies]
+ruff = { version = "^0.3", optional = true }
+`},
+			want: []string{"Python: ^3.11", "Poetry dependencies (1):\n  django  ^5.0", "Poetry group [dev] (1):\n  ruff  ^0.3"},
+		},
+		{
+			name:  "requirements.txt",
+			files: map[string]string{"requirements.txt": "# pinned\n-r base.txt\nDjango==5.0.1  # web\nnumpy\n-e ./local\n"},
+			want:  []string{"Manifest: requirements.txt", "Django  ==5.0.1", "numpy   *"},
+		},
+		{
+			name: "Cargo.toml",
+			files: map[string]string{"Cargo.toml": `[package]
+name = "cli"
+version = "0.2.0"
+edition = "2021"
+
+[dependencies]
+serde = { version = "1.0", features = ["derive"] }
+anyhow = "1"
+util = { path = "../util" }
+
+[dev-dependencies]
+insta = "1.34"
+`},
+			want: []string{"Package: cli 0.2.0", "Edition: 2021", "anyhow  1", "serde   1.0", "util    path ../util", "Dev dependencies (1):\n  insta  1.34"},
+		},
+		{
+			name:  "generic",
+			files: map[string]string{"README.md": "# nothing"},
+			want:  []string{"No recognized manifest", "generic project"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			for name, content := range tt.files {
+				if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0o644); err != nil {
+					t.Fatalf("Failed to create %s: %v", name, err)
+				}
+			}
+
+			te := NewToolExecutor(tempDir)
+			result, err := te.listDependencies(context.Background(), map[string]interface{}{})
+			if err != nil {
+				t.Fatalf("dependencies failed: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(result, want) {
+					t.Errorf("Expected %q in result:\n%s", want, result)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(result, notWant) {
+					t.Errorf("Expected no %q in result:\n%s", notWant, result)
+				}
+			}
+		})
+	}
+}
+
+func TestToolExecutor_ListDependenciesSubdirectory(t *testing.T) {
+	tempDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tempDir, "web"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "web", "package.json"), []byte(`{"dependencies": {"vue": "^3.4.0"}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	te := NewToolExecutor(tempDir)
+	result, err := te.listDependencies(context.Background(), map[string]interface{}{"dir_path": "web"})
+	if err != nil {
+		t.Fatalf("dependencies failed: %v", err)
+	}
+	if !strings.Contains(result, "Manifest: web/package.json") || !strings.Contains(result, "vue  ^3.4.0") {
+		t.Errorf("Expected the subdirectory's manifest, got:\n%s", result)
+	}
+}
+
+func TestFormatDependenciesUpdates(t *testing.T) {
+	deps := &manifestDependencies{
+		manifest: "go.mod",
+		notes:    []string{"3 indirect dependencies not listed"},
+		groups: []dependencyGroup{{name: "Dependencies", deps: []dependency{
+			{name: "github.com/a/a", version: "v1.0.0"},
+			{name: "github.com/b/b", version: "v2.1.0"},
+		}}},
+	}
+	setLatest(deps, map[string]string{"github.com/a/a": "v1.2.0", "github.com/b/b": "v2.1.0"})
+
+	result := formatDependencies("go.mod", deps)
+	for _, want := range []string{"github.com/a/a  v1.0.0  (latest v1.2.0)", "github.com/b/b  v2.1.0\n", "1 of 2 dependencies have newer versions"} {
+		if !strings.Contains(result, want) {
+			t.Errorf("Expected %q in result:\n%s", want, result)
+		}
+	}
+}
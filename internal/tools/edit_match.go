@@ -0,0 +1,220 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// nearMatchThreshold is the similarity, after normalizing whitespace, a region
+	// needs before edit_file offers it as the text old_text was meant to match
+	nearMatchThreshold = 0.85
+	// minFuzzyEditBytes is the shortest old_text compared by edit distance; shorter
+	// text only matches when it differs in whitespace alone
+	minFuzzyEditBytes = 20
+	// maxFuzzyEditBytes is the longest old_text compared by edit distance
+	maxFuzzyEditBytes = 4096
+)
+
+// editCandidate is the region of a file that old_text most likely meant
+type editCandidate struct {
+	start, end int     // Byte offsets of the region in the file
+	line       int     // 1-based line the region starts on
+	similarity float64 // 1 when only whitespace differs
+}
+
+// text returns the exact text of the region
+func (c *editCandidate) text(content string) string {
+	return content[c.start:c.end]
+}
+
+// describe reports the region so the model can retry with its exact text
+func (c *editCandidate) describe(content string) string {
+	how := "only whitespace differs"
+	if c.similarity < 1 {
+		how = fmt.Sprintf("%.0f%% similar", c.similarity*100)
+	}
+	return fmt.Sprintf("Closest match at line %d (%s):\n%s", c.line, how, c.text(content))
+}
+
+// findEditCandidate looks for the region old_text was meant to match when it is not in
+// content exactly: first comparing lines with whitespace normalized, then by edit
+// distance. It returns nil and an explanation unless exactly one region is a close
+// match, so an edit is never applied to a guess between several.
+func findEditCandidate(content, oldText string) (*editCandidate, string) {
+	want := trimBlankLines(strings.Split(oldText, "\n"))
+	if len(want) == 0 {
+		return nil, "old_text is empty or only whitespace"
+	}
+	wantNorm := strings.Join(normalizeEditLines(want), "\n")
+	wantCounts := byteCounts(wantNorm)
+
+	lines := strings.Split(content, "\n")
+	norm := normalizeEditLines(lines)
+	n := len(want)
+
+	// Edit distance is only worth it for text long enough to tell regions apart
+	limit := 0
+	if len(wantNorm) >= minFuzzyEditBytes && len(wantNorm) <= maxFuzzyEditBytes {
+		limit = int(float64(len(wantNorm)) * (1 - nearMatchThreshold))
+	}
+
+	type match struct {
+		index      int
+		similarity float64
+	}
+	var matches []match
+	for i := 0; i+n <= len(lines); i++ {
+		window := strings.Join(norm[i:i+n], "\n")
+		if window == wantNorm {
+			matches = append(matches, match{i, 1})
+			continue
+		}
+		if limit == 0 || strings.TrimSpace(window) == "" {
+			continue
+		}
+		// Differing byte counts bound the distance from below and rule out most windows
+		if countDistance(byteCounts(window), wantCounts) > limit {
+			continue
+		}
+		if distance := boundedLevenshtein(window, wantNorm, limit); distance <= limit {
+			similarity := 1 - float64(distance)/float64(max(len(window), len(wantNorm)))
+			if similarity >= nearMatchThreshold {
+				matches = append(matches, match{i, similarity})
+			}
+		}
+	}
+
+	if len(matches) == 0 {
+		return nil, describeEditMiss(content, content, oldText)
+	}
+
+	best := matches[0]
+	for _, m := range matches[1:] {
+		if m.similarity > best.similarity {
+			best = m
+		}
+	}
+
+	// Windows overlapping the best one are the same region shifted; any other close
+	// match makes the choice a guess
+	var others []int
+	for _, m := range matches {
+		if m.index != best.index && (m.index <= best.index-n || m.index >= best.index+n) {
+			others = append(others, m.index+1)
+		}
+	}
+	if len(others) > 0 {
+		return nil, fmt.Sprintf("old_text nearly matches %d places (lines %s); re-read the file and include more surrounding context",
+			len(others)+1, formatLineNumbers(append([]int{best.index + 1}, others...)))
+	}
+
+	start := 0
+	for _, line := range lines[:best.index] {
+		start += len(line) + 1
+	}
+	end := start
+	for _, line := range lines[best.index : best.index+n] {
+		end += len(line) + 1
+	}
+	// Keep the last line's newline only if old_text ended with one
+	if end > len(content) || !strings.HasSuffix(oldText, "\n") {
+		end--
+	}
+
+	return &editCandidate{start: start, end: end, line: best.index + 1, similarity: best.similarity}, ""
+}
+
+// trimBlankLines drops leading and trailing blank lines
+func trimBlankLines(lines []string) []string {
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// normalizeEditLines collapses each line's runs of whitespace to a single space and
+// trims it
+func normalizeEditLines(lines []string) []string {
+	norm := make([]string, len(lines))
+	for i, line := range lines {
+		norm[i] = strings.Join(strings.Fields(line), " ")
+	}
+	return norm
+}
+
+// byteCounts counts each byte value in s
+func byteCounts(s string) [256]int {
+	var counts [256]int
+	for i := 0; i < len(s); i++ {
+		counts[s[i]]++
+	}
+	return counts
+}
+
+// countDistance is a lower bound on the edit distance between two strings with the
+// given byte counts
+func countDistance(a, b [256]int) int {
+	surplus, deficit := 0, 0
+	for i := range a {
+		if d := a[i] - b[i]; d > 0 {
+			surplus += d
+		} else {
+			deficit -= d
+		}
+	}
+	return max(surplus, deficit)
+}
+
+// boundedLevenshtein returns the edit distance between a and b, or limit+1 as soon as
+// it is known to exceed limit. Only the diagonal band within limit is computed.
+func boundedLevenshtein(a, b string, limit int) int {
+	if abs(len(a)-len(b)) > limit {
+		return limit + 1
+	}
+
+	over := limit + 1
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = min(j, over)
+	}
+
+	for i := 1; i <= len(a); i++ {
+		lo, hi := max(1, i-limit), min(len(b), i+limit)
+		curr[0] = min(i, over)
+		if lo > 1 {
+			curr[lo-1] = over
+		}
+
+		rowMin := curr[0]
+		for j := lo; j <= hi; j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost, over)
+			rowMin = min(rowMin, curr[j])
+		}
+		if hi < len(b) {
+			curr[hi+1] = over
+		}
+		if rowMin > limit {
+			return over
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
+
+// abs returns the absolute value of n
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
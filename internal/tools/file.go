@@ -1,10 +1,14 @@
 package tools
 
 import (
+	"bytes"
 	"fmt"
 	"github.com/tildaslashalef/bazinga/internal/loggy"
+	"github.com/tildaslashalef/bazinga/internal/project"
 	"os"
+	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
 )
 
@@ -16,6 +20,50 @@ type FileChange struct {
 	Operation string // "edit", "create", "write"
 }
 
+const (
+	// defaultLongLineThreshold is the line length above which a file is treated as minified
+	defaultLongLineThreshold = 5000
+	// defaultLongLinePreview is how many bytes of a minified file read_file returns
+	defaultLongLinePreview = 2000
+
+	// maxReadFilesCount is the most files a single read_files call may request
+	maxReadFilesCount = 20
+	// maxReadFilesBytes caps the combined content returned by read_files
+	maxReadFilesBytes = 200 * 1024
+
+	// defaultMaxReadBytes caps the content read_file returns when no limit is configured
+	defaultMaxReadBytes = 256 * 1024
+	// minReadBytes is the smallest read limit the model's context window can scale down to
+	minReadBytes = 8 * 1024
+	// readContextShare is the share of the model's context window one read may fill
+	readContextShare = 0.25
+	// bytesPerToken converts a token limit to bytes of source text, roughly
+	bytesPerToken = 4
+)
+
+// readLimit returns how many bytes of content read_file returns in one call: the
+// configured maximum, lowered to a share of the model's context window when it is known
+func (te *ToolExecutor) readLimit() int {
+	limit := te.maxReadBytes
+	if limit <= 0 {
+		limit = defaultMaxReadBytes
+	}
+	if te.contextTokens > 0 {
+		limit = min(limit, max(int(float64(te.contextTokens)*readContextShare)*bytesPerToken, minReadBytes))
+	}
+	return limit
+}
+
+// headOfFile returns the whole lines of content that fit in limit bytes, and how many lines that is
+func headOfFile(content []byte, limit int) (string, int) {
+	head := content[:min(len(content), limit)]
+	if cut := bytes.LastIndexByte(head, '\n'); cut > 0 && len(head) < len(content) {
+		head = head[:cut]
+	}
+	head = bytes.ToValidUTF8(head, nil)
+	return string(head), bytes.Count(head, []byte("\n")) + 1
+}
+
 // readFile reads the contents of a file
 func (te *ToolExecutor) readFile(input map[string]interface{}) (string, error) {
 	loggy.Debug("ToolExecutor readFile", "input", input, "input_length", len(input))
@@ -25,6 +73,18 @@ func (te *ToolExecutor) readFile(input map[string]interface{}) (string, error) {
 		return "", fmt.Errorf("file_path is required")
 	}
 
+	// Optional 1-based start line and number of lines to return
+	offset, limit := 0, 0
+	if v, ok := input["offset"].(float64); ok {
+		offset = int(v)
+	}
+	if v, ok := input["limit"].(float64); ok {
+		limit = int(v)
+	}
+	if offset < 0 || limit < 0 {
+		return "", fmt.Errorf("offset and limit must be positive")
+	}
+
 	// Resolve relative path
 	if !filepath.IsAbs(filePath) {
 		filePath = filepath.Join(te.rootPath, filePath)
@@ -54,10 +114,335 @@ func (te *ToolExecutor) readFile(input map[string]interface{}) (string, error) {
 
 	loggy.Info("ToolExecutor readFile success", "path", filePath, "size", len(content), "lines", lines)
 
+	if isBinary(content) {
+		return binaryFileNote(displayPath, len(content)), nil
+	}
+
+	if offset > 0 || limit > 0 {
+		return te.formatFileRange(displayPath, content, offset, limit)
+	}
+
+	// Don't dump minified or generated files with enormous lines into the context
+	if longest := longestLineLength(content); longest > te.longLineThreshold {
+		loggy.Info("ToolExecutor readFile truncated long-line file", "path", filePath, "longest_line", longest)
+		return te.formatLongLineSummary(displayPath, content, lines, longest), nil
+	}
+
+	// Files too large for the model's context are returned from the start
+	if maxBytes := te.readLimit(); len(content) > maxBytes {
+		head, shown := headOfFile(content, maxBytes)
+		loggy.Info("ToolExecutor readFile truncated large file", "path", filePath, "size", len(content), "limit", maxBytes)
+		return fmt.Sprintf("File: %s\nLines: %d\nShowing: lines 1-%d of %d\nFile is %s, showing first %s — use offset/limit to read more\nContent:\n\n%s",
+			displayPath, lines, shown, lines, formatByteSize(len(content)), formatByteSize(len(head)), head), nil
+	}
+
 	// Return content with line count for display
 	return fmt.Sprintf("File: %s\nLines: %d\nContent:\n\n%s", displayPath, lines, string(content)), nil
 }
 
+// formatFileRange returns limit lines of a file starting at the 1-based offset, with
+// the file's total line count so the model knows how much it has not seen
+func (te *ToolExecutor) formatFileRange(displayPath string, content []byte, offset, limit int) (string, error) {
+	lines := strings.Split(string(content), "\n")
+	if strings.HasSuffix(string(content), "\n") {
+		lines = lines[:len(lines)-1]
+	}
+	total := len(lines)
+
+	start := max(offset, 1)
+	if start > total {
+		return "", fmt.Errorf("offset %d is past the end of %s, which has %d lines", start, displayPath, total)
+	}
+	end := total
+	if limit > 0 && start+limit-1 < total {
+		end = start + limit - 1
+	}
+
+	selected := strings.Join(lines[start-1:end], "\n")
+	if longest := longestLineLength([]byte(selected)); longest > te.longLineThreshold {
+		return te.formatLongLineSummary(displayPath, []byte(selected), end-start+1, longest), nil
+	}
+
+	// A range can still be too large for the model's context
+	if maxBytes := te.readLimit(); len(selected) > maxBytes {
+		head, shown := headOfFile([]byte(selected), maxBytes)
+		return fmt.Sprintf("File: %s\nLines: %d\nShowing: lines %d-%d of %d\nRange is %s, showing first %s — use a smaller limit or a later offset to read more\nContent:\n\n%s",
+			displayPath, total, start, start+shown-1, total, formatByteSize(len(selected)), formatByteSize(len(head)), head), nil
+	}
+
+	return fmt.Sprintf("File: %s\nLines: %d\nShowing: lines %d-%d of %d\nContent:\n\n%s", displayPath, total, start, end, total, selected), nil
+}
+
+// readFileRange is one entry of a read_files request
+type readFileRange struct {
+	path      string
+	startLine int // 1-based, inclusive; 0 = from the start
+	endLine   int // 1-based, inclusive; 0 = to the end
+}
+
+// readFiles reads several files, or line ranges of them, in one call. Files are given
+// as a list, or as a glob pattern whose matches are read until the byte budget runs out.
+func (te *ToolExecutor) readFiles(input map[string]interface{}) (string, error) {
+	budget := maxReadFilesBytes
+	if v, ok := input["max_bytes"].(float64); ok && v > 0 {
+		budget = min(int(v), maxReadFilesBytes)
+	}
+
+	pattern, isGlob := input["glob"].(string)
+	var entries []readFileRange
+	var err error
+	if isGlob {
+		if _, ok := input["files"]; ok {
+			return "", fmt.Errorf("pass either files or glob, not both")
+		}
+		entries, err = te.globFiles(pattern)
+	} else {
+		entries, err = parseReadFileRanges(input)
+	}
+	if err != nil {
+		return "", err
+	}
+	if len(entries) == 0 {
+		return fmt.Sprintf("No files match %s", pattern), nil
+	}
+
+	loggy.Debug("ToolExecutor readFiles", "count", len(entries), "glob", pattern)
+
+	var result strings.Builder
+	remaining := budget
+
+	for i, entry := range entries {
+		if i > 0 {
+			result.WriteString("\n\n")
+		}
+
+		filePath := entry.path
+		if !filepath.IsAbs(filePath) {
+			filePath = filepath.Join(te.rootPath, filePath)
+		}
+		displayPath := entry.path
+		if relPath, err := filepath.Rel(te.rootPath, filePath); err == nil && !strings.HasPrefix(relPath, "..") {
+			displayPath = relPath
+		}
+
+		if remaining <= 0 && isGlob {
+			result.WriteString(fmt.Sprintf("... %d more matching files not read: output limit of %d bytes reached; narrow the glob or read them separately", len(entries)-i, budget))
+			break
+		}
+		if remaining <= 0 {
+			result.WriteString(fmt.Sprintf("==> %s <==\nSkipped: output limit of %d bytes reached; read it separately", displayPath, budget))
+			continue
+		}
+
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			result.WriteString(fmt.Sprintf("==> %s <==\nError: %v", displayPath, err))
+			continue
+		}
+		if isBinary(content) {
+			result.WriteString(fmt.Sprintf("==> %s <==\nSkipped: binary file (%s)", displayPath, formatByteSize(len(content))))
+			continue
+		}
+
+		lines := strings.Split(string(content), "\n")
+		if strings.HasSuffix(string(content), "\n") {
+			lines = lines[:len(lines)-1]
+		}
+		total := len(lines)
+
+		start, end := entry.startLine, entry.endLine
+		if start <= 0 {
+			start = 1
+		}
+		if end <= 0 || end > total {
+			end = total
+		}
+		if total > 0 && start > total {
+			result.WriteString(fmt.Sprintf("==> %s <==\nError: start_line %d is past the end of the file (%d lines)", displayPath, start, total))
+			continue
+		}
+
+		selected := ""
+		if total > 0 {
+			selected = strings.Join(lines[start-1:end], "\n")
+		}
+
+		if longest := longestLineLength([]byte(selected)); longest > te.longLineThreshold {
+			result.WriteString(fmt.Sprintf("==> %s <==\n", displayPath))
+			summary := te.formatLongLineSummary(displayPath, []byte(selected), end-start+1, longest)
+			result.WriteString(summary)
+			remaining -= len(summary)
+			continue
+		}
+
+		if total == 0 {
+			result.WriteString(fmt.Sprintf("==> %s (empty) <==", displayPath))
+			continue
+		}
+		result.WriteString(fmt.Sprintf("==> %s (lines %d-%d of %d) <==\n", displayPath, start, end, total))
+
+		if len(selected) > remaining {
+			result.WriteString(selected[:remaining])
+			result.WriteString(fmt.Sprintf("\n... [truncated: output limit of %d bytes reached]", budget))
+			remaining = 0
+			continue
+		}
+		result.WriteString(selected)
+		remaining -= len(selected)
+	}
+
+	return result.String(), nil
+}
+
+// parseReadFileRanges validates the files argument of read_files
+func parseReadFileRanges(input map[string]interface{}) ([]readFileRange, error) {
+	rawFiles, ok := input["files"].([]interface{})
+	if !ok || len(rawFiles) == 0 {
+		return nil, fmt.Errorf("files is required and must be a non-empty array")
+	}
+	if len(rawFiles) > maxReadFilesCount {
+		return nil, fmt.Errorf("too many files: %d requested, at most %d per call", len(rawFiles), maxReadFilesCount)
+	}
+
+	entries := make([]readFileRange, 0, len(rawFiles))
+	for i, raw := range rawFiles {
+		item, ok := raw.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("files[%d] must be an object", i)
+		}
+
+		path, ok := item["file_path"].(string)
+		if !ok || path == "" {
+			return nil, fmt.Errorf("files[%d].file_path is required", i)
+		}
+
+		entry := readFileRange{path: path}
+		if v, ok := item["start_line"].(float64); ok {
+			entry.startLine = int(v)
+		}
+		if v, ok := item["end_line"].(float64); ok {
+			entry.endLine = int(v)
+		}
+		if entry.startLine < 0 || entry.endLine < 0 {
+			return nil, fmt.Errorf("files[%d]: line numbers must be positive", i)
+		}
+		if entry.endLine > 0 && entry.startLine > entry.endLine {
+			return nil, fmt.Errorf("files[%d]: start_line %d is after end_line %d", i, entry.startLine, entry.endLine)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// globFiles returns the files under the root path matching a glob pattern, such as
+// "internal/llm/**/*.go", in lexical order. Files ignored by .gitignore or in common
+// ignored directories such as node_modules are left out.
+func (te *ToolExecutor) globFiles(pattern string) ([]readFileRange, error) {
+	pattern = strings.TrimPrefix(filepath.ToSlash(strings.TrimSpace(pattern)), "./")
+	if pattern == "" {
+		return nil, fmt.Errorf("glob must not be empty")
+	}
+	if _, err := path.Match(strings.ReplaceAll(pattern, "**", "*"), ""); err != nil {
+		return nil, fmt.Errorf("invalid glob %q: %w", pattern, err)
+	}
+
+	ignorePatterns := project.LoadGitIgnore(te.rootPath)
+
+	var entries []readFileRange
+	err := filepath.Walk(te.rootPath, func(walkPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil //nolint:nilerr // Skip unreadable entries
+		}
+		if skip, err := te.skipDisallowed(walkPath, info); skip {
+			return err
+		}
+
+		relPath, err := filepath.Rel(te.rootPath, walkPath)
+		if err != nil || relPath == "." {
+			return nil //nolint:nilerr
+		}
+		if project.ShouldIgnore(relPath, info.IsDir(), ignorePatterns) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !info.IsDir() && matchGlob(pattern, filepath.ToSlash(relPath)) {
+			entries = append(entries, readFileRange{path: relPath})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to match glob: %w", err)
+	}
+
+	return entries, nil
+}
+
+// matchGlob matches a slash-separated path against a glob pattern in which "**" matches
+// any number of directories and the other segments use path.Match syntax
+func matchGlob(pattern, name string) bool {
+	return matchGlobSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchGlobSegments(pattern, name []string) bool {
+	if len(pattern) == 0 {
+		return len(name) == 0
+	}
+
+	if pattern[0] == "**" {
+		for i := 0; i <= len(name); i++ {
+			if matchGlobSegments(pattern[1:], name[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(name) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], name[0]); !ok {
+		return false
+	}
+	return matchGlobSegments(pattern[1:], name[1:])
+}
+
+// longestLineLength returns the length in bytes of the longest line in content
+func longestLineLength(content []byte) int {
+	longest := 0
+	current := 0
+	for _, b := range content {
+		if b == '\n' {
+			current = 0
+			continue
+		}
+		current++
+		if current > longest {
+			longest = current
+		}
+	}
+	return longest
+}
+
+// formatLongLineSummary describes a file with pathologically long lines and previews its start
+func (te *ToolExecutor) formatLongLineSummary(displayPath string, content []byte, lines, longest int) string {
+	preview := content
+	if len(preview) > te.longLinePreview {
+		preview = preview[:te.longLinePreview]
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("File: %s\nLines: %d\nSize: %d bytes\n", displayPath, lines, len(content)))
+	result.WriteString(fmt.Sprintf("Note: the longest line is %d characters, so this file is likely minified or generated. ", longest))
+	result.WriteString(fmt.Sprintf("Only the first %d bytes are shown; use grep to search it instead of reading it whole.\n", len(preview)))
+	result.WriteString(fmt.Sprintf("Preview:\n\n%s\n... [truncated %d bytes]", strings.ToValidUTF8(string(preview), ""), len(content)-len(preview)))
+	return result.String()
+}
+
 // writeFile writes content to a file
 func (te *ToolExecutor) writeFile(input map[string]interface{}) (string, error) {
 	filePath, ok := input["file_path"].(string)
@@ -77,10 +462,15 @@ func (te *ToolExecutor) writeFile(input map[string]interface{}) (string, error)
 
 	// Capture before state for diff
 	var beforeContent string
-	if existingContent, err := os.ReadFile(filePath); err == nil {
+	if existingContent, err := te.readForChange(filePath); err == nil {
 		beforeContent = string(existingContent)
 	}
 
+	if te.dryRun {
+		change := FileChange{FilePath: te.displayPath(filePath), Before: beforeContent, After: content, Operation: "write"}
+		return te.planChange(change, map[string]*string{filePath: &content}), nil
+	}
+
 	// Ensure directory exists
 	dir := filepath.Dir(filePath)
 	if err := os.MkdirAll(dir, 0o755); err != nil {
@@ -108,7 +498,7 @@ func (te *ToolExecutor) writeFile(input map[string]interface{}) (string, error)
 		})
 	}
 
-	return fmt.Sprintf("File %s written successfully (%d bytes)", filePath, len(content)), nil
+	return fmt.Sprintf("File %s written successfully (%d bytes)", filePath, len(content)) + syntaxWarning(filePath, content), nil
 }
 
 // createFile creates a new file with content
@@ -129,10 +519,15 @@ func (te *ToolExecutor) createFile(input map[string]interface{}) (string, error)
 	}
 
 	// Check if file already exists
-	if _, err := os.Stat(filePath); err == nil {
+	if _, err := te.statForChange(filePath); err == nil {
 		return "", fmt.Errorf("file %s already exists", filePath)
 	}
 
+	if te.dryRun {
+		change := FileChange{FilePath: te.displayPath(filePath), After: content, Operation: "create"}
+		return te.planChange(change, map[string]*string{filePath: &content}), nil
+	}
+
 	// Ensure directory exists
 	dir := filepath.Dir(filePath)
 	if err := os.MkdirAll(dir, 0o755); err != nil {
@@ -160,7 +555,7 @@ func (te *ToolExecutor) createFile(input map[string]interface{}) (string, error)
 		})
 	}
 
-	return fmt.Sprintf("File %s created successfully (%d bytes)", filePath, len(content)), nil
+	return fmt.Sprintf("File %s created successfully (%d bytes)", filePath, len(content)) + syntaxWarning(filePath, content), nil
 }
 
 // editFile edits a file by replacing text
@@ -186,20 +581,55 @@ func (te *ToolExecutor) editFile(input map[string]interface{}) (string, error) {
 	}
 
 	// Read current content
-	content, err := os.ReadFile(filePath)
+	content, err := te.readForChange(filePath)
 	if err != nil {
 		return "", fmt.Errorf("failed to read file %s: %w", filePath, err)
 	}
 
 	contentStr := string(content)
 
-	// Check if old text exists
-	if !strings.Contains(contentStr, oldText) {
-		return "", fmt.Errorf("old text not found in file %s", filePath)
+	// Check if old text exists, and that it names a single spot unless the caller
+	// asked for several
+	lines := matchLines(contentStr, oldText)
+	replaceAll, _ := input["replace_all"].(bool)
+	expected, hasExpected := input["expected_occurrences"].(float64)
+
+	// On a miss, find the region old_text most likely meant. It is only reported
+	// unless fuzzy is set, and never used for a multi-location replace.
+	var candidate *editCandidate
+	if len(lines) == 0 {
+		match, reason := findEditCandidate(contentStr, oldText)
+		if match == nil {
+			return "", fmt.Errorf("old text not found in file %s\n%s", filePath, reason)
+		}
+		if fuzzy, _ := input["fuzzy"].(bool); !fuzzy || replaceAll || hasExpected {
+			return "", fmt.Errorf("old text not found in file %s\n%s\nRetry with this exact text as old_text, or set fuzzy to apply the edit to it", filePath, match.describe(contentStr))
+		}
+		candidate = match
+	}
+
+	var newContentStr string
+	if candidate != nil {
+		newContentStr = contentStr[:candidate.start] + newText + contentStr[candidate.end:]
+	} else {
+		if hasExpected {
+			if int(expected) != len(lines) {
+				return "", fmt.Errorf("expected %d occurrences of old text in %s but found %d (lines %s)", int(expected), filePath, len(lines), formatLineNumbers(lines))
+			}
+			replaceAll = true
+		}
+		if len(lines) > 1 && !replaceAll {
+			return "", fmt.Errorf("old text matches %d locations in %s (lines %s); include more surrounding context to pick one, or set replace_all to change them all", len(lines), filePath, formatLineNumbers(lines))
+		}
+
+		// Replace text
+		newContentStr = strings.Replace(contentStr, oldText, newText, len(lines))
 	}
 
-	// Replace text
-	newContentStr := strings.Replace(contentStr, oldText, newText, 1)
+	if te.dryRun {
+		change := FileChange{FilePath: te.displayPath(filePath), Before: contentStr, After: newContentStr, Operation: "edit"}
+		return te.planChange(change, map[string]*string{filePath: &newContentStr}), nil
+	}
 
 	// Write back to file
 	err = os.WriteFile(filePath, []byte(newContentStr), 0o644)
@@ -223,7 +653,38 @@ func (te *ToolExecutor) editFile(input map[string]interface{}) (string, error) {
 		})
 	}
 
-	return fmt.Sprintf("File %s edited successfully", filePath), nil
+	if candidate != nil {
+		return fmt.Sprintf("File %s edited successfully (old text matched approximately at line %d)", filePath, candidate.line) + syntaxWarning(filePath, newContentStr), nil
+	}
+	if len(lines) > 1 {
+		return fmt.Sprintf("File %s edited successfully (%d occurrences replaced)", filePath, len(lines)) + syntaxWarning(filePath, newContentStr), nil
+	}
+	return fmt.Sprintf("File %s edited successfully", filePath) + syntaxWarning(filePath, newContentStr), nil
+}
+
+// matchLines returns the 1-based line each non-overlapping occurrence of text starts on
+func matchLines(content, text string) []int {
+	var lines []int
+	line, pos := 1, 0
+	for {
+		idx := strings.Index(content[pos:], text)
+		if idx == -1 || text == "" {
+			return lines
+		}
+		line += strings.Count(content[pos:pos+idx], "\n")
+		lines = append(lines, line)
+		line += strings.Count(text, "\n")
+		pos += idx + len(text)
+	}
+}
+
+// formatLineNumbers joins line numbers for an error message
+func formatLineNumbers(lines []int) string {
+	parts := make([]string, len(lines))
+	for i, line := range lines {
+		parts[i] = strconv.Itoa(line)
+	}
+	return strings.Join(parts, ", ")
 }
 
 // multiEditFile performs multiple edits on a file in sequence
@@ -258,11 +719,18 @@ func (te *ToolExecutor) multiEditFile(input map[string]interface{}) (string, err
 		return "", fmt.Errorf("failed to read file %s: %w", filePath, err)
 	}
 
+	// Atomic by default: if any edit fails, nothing is written
+	atomic := true
+	if v, ok := input["atomic"].(bool); ok {
+		atomic = v
+	}
+
 	contentStr := string(originalContent)
 	currentContent := contentStr
 
-	// Apply each edit in sequence
+	// Apply each edit in sequence; the file is only written once all edits are processed
 	editCount := 0
+	var failures []string
 	for i, editInterface := range edits {
 		edit, ok := editInterface.(map[string]interface{})
 		if !ok {
@@ -281,7 +749,13 @@ func (te *ToolExecutor) multiEditFile(input map[string]interface{}) (string, err
 
 		// Check if old text exists in current content
 		if !strings.Contains(currentContent, oldText) {
-			return "", fmt.Errorf("edit %d: old text not found in file", i+1)
+			failure := fmt.Sprintf("edit %d of %d: old text not found in file\n%s",
+				i+1, len(edits), describeEditMiss(contentStr, currentContent, oldText))
+			if atomic {
+				return "", fmt.Errorf("%s\nNo edits were applied; %s is unchanged on disk", failure, filePath)
+			}
+			failures = append(failures, failure)
+			continue
 		}
 
 		// Apply the replacement
@@ -289,6 +763,12 @@ func (te *ToolExecutor) multiEditFile(input map[string]interface{}) (string, err
 		editCount++
 	}
 
+	var failureReport string
+	if len(failures) > 0 {
+		failureReport = fmt.Sprintf("\n%d of %d edits failed and were skipped:\n%s",
+			len(failures), len(edits), strings.Join(failures, "\n"))
+	}
+
 	// Write back to file if anything changed
 	if currentContent != contentStr {
 		err = os.WriteFile(filePath, []byte(currentContent), 0o644)
@@ -312,10 +792,81 @@ func (te *ToolExecutor) multiEditFile(input map[string]interface{}) (string, err
 			})
 		}
 
-		return fmt.Sprintf("File %s: applied %d edits successfully", filePath, editCount), nil
+		return fmt.Sprintf("File %s: applied %d edits successfully", filePath, editCount) + failureReport, nil
+	}
+
+	if len(failures) == len(edits) {
+		return "", fmt.Errorf("no edits could be applied to %s:\n%s", filePath, strings.Join(failures, "\n"))
+	}
+
+	return fmt.Sprintf("File %s: no changes needed", filePath) + failureReport, nil
+}
+
+// editMissContext is the number of lines shown either side of the closest match
+const editMissContext = 2
+
+// describeEditMiss explains why old text was not found: either an earlier edit in the
+// same sequence changed it, or the closest line in the current content is shown so the
+// model can see what differs
+func describeEditMiss(original, current, oldText string) string {
+	if original != current && strings.Contains(original, oldText) {
+		return "The text exists in the original file but an earlier edit in this sequence changed it"
+	}
+
+	firstLine := ""
+	for _, line := range strings.Split(oldText, "\n") {
+		if strings.TrimSpace(line) != "" {
+			firstLine = strings.TrimSpace(line)
+			break
+		}
+	}
+	if firstLine == "" {
+		return "old_text is empty or only whitespace"
+	}
+
+	lines := strings.Split(current, "\n")
+	best, bestScore := -1, 0
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+		score := commonPrefixLength(trimmed, firstLine)
+		if strings.Contains(trimmed, firstLine) {
+			score = len(firstLine) + 1
+		}
+		if score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+
+	// Require a meaningful overlap before calling something the closest match
+	if best < 0 || bestScore < min(len(firstLine), 8) {
+		return fmt.Sprintf("No line in the file resembles %q; re-read the file before editing", firstLine)
+	}
+
+	var snippet strings.Builder
+	fmt.Fprintf(&snippet, "Closest match in the current content (line %d):\n", best+1)
+	start := max(best-editMissContext, 0)
+	end := min(best+editMissContext, len(lines)-1)
+	for i := start; i <= end; i++ {
+		marker := " "
+		if i == best {
+			marker = ">"
+		}
+		fmt.Fprintf(&snippet, "%s %4d | %s\n", marker, i+1, lines[i])
 	}
 
-	return fmt.Sprintf("File %s: no changes needed", filePath), nil
+	return strings.TrimRight(snippet.String(), "\n")
+}
+
+// commonPrefixLength returns the number of leading bytes a and b share
+func commonPrefixLength(a, b string) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return n
 }
 
 // moveFile moves or renames a file
@@ -339,16 +890,29 @@ func (te *ToolExecutor) moveFile(input map[string]interface{}) (string, error) {
 	}
 
 	// Check if source exists
-	if _, err := os.Stat(sourcePath); os.IsNotExist(err) {
+	if _, err := te.statForChange(sourcePath); os.IsNotExist(err) {
 		return "", fmt.Errorf("source file %s does not exist", sourcePath)
 	}
 
 	// Read source content for diff tracking before moving
 	var beforeContent string
-	if content, err := os.ReadFile(sourcePath); err == nil {
+	if content, err := te.readForChange(sourcePath); err == nil {
 		beforeContent = string(content)
 	}
 
+	if te.dryRun {
+		if _, err := te.statForChange(destPath); err == nil {
+			return "", fmt.Errorf("destination file %s already exists", destPath)
+		}
+		change := FileChange{
+			FilePath:  fmt.Sprintf("%s → %s", te.displayPath(sourcePath), te.displayPath(destPath)),
+			Before:    beforeContent,
+			After:     beforeContent,
+			Operation: "move",
+		}
+		return te.planChange(change, map[string]*string{sourcePath: nil, destPath: &beforeContent}), nil
+	}
+
 	// Ensure destination directory exists
 	destDir := filepath.Dir(destPath)
 	if err := os.MkdirAll(destDir, 0o755); err != nil {
@@ -473,7 +1037,7 @@ func (te *ToolExecutor) deleteFile(input map[string]interface{}) (string, error)
 	}
 
 	// Check if file exists and get info
-	fileInfo, err := os.Stat(filePath)
+	fileInfo, err := te.statForChange(filePath)
 	if os.IsNotExist(err) {
 		return "", fmt.Errorf("file %s does not exist", filePath)
 	}
@@ -483,10 +1047,15 @@ func (te *ToolExecutor) deleteFile(input map[string]interface{}) (string, error)
 
 	// Read content before deletion for diff tracking
 	var beforeContent string
-	if content, err := os.ReadFile(filePath); err == nil {
+	if content, err := te.readForChange(filePath); err == nil {
 		beforeContent = string(content)
 	}
 
+	if te.dryRun {
+		change := FileChange{FilePath: te.displayPath(filePath), Before: beforeContent, Operation: "delete"}
+		return te.planChange(change, map[string]*string{filePath: nil}), nil
+	}
+
 	// Delete the file
 	err = os.Remove(filePath)
 	if err != nil {
@@ -617,6 +1186,13 @@ func (te *ToolExecutor) listFiles(input map[string]interface{}) (string, error)
 
 	for _, entry := range entries {
 		name := entry.Name()
+		entryPath := filepath.Join(directory, name)
+		if !te.isAllowedPath(entryPath) && !te.isAllowedAncestor(entryPath) {
+			continue
+		}
+		if te.isIgnored(entryPath, entry.IsDir()) {
+			continue
+		}
 		if entry.IsDir() {
 			dirs = append(dirs, name+"/")
 		} else {
@@ -2,6 +2,7 @@ package tools
 
 import (
 	"context"
+	"fmt"
 	"github.com/tildaslashalef/bazinga/internal/llm"
 	"os"
 	"path/filepath"
@@ -59,6 +60,184 @@ func TestToolExecutor_ReadFile(t *testing.T) {
 	}
 }
 
+func TestToolExecutor_ReadFileRange(t *testing.T) {
+	tempDir := t.TempDir()
+	var content strings.Builder
+	for i := 1; i <= 10; i++ {
+		content.WriteString(fmt.Sprintf("line %d\n", i))
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "ten.txt"), []byte(content.String()), 0o644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	te := NewToolExecutor(tempDir)
+
+	result, err := te.readFile(map[string]interface{}{"file_path": "ten.txt", "offset": float64(4), "limit": float64(3)})
+	if err != nil {
+		t.Fatalf("readFile failed: %v", err)
+	}
+	if !strings.Contains(result, "Lines: 10\nShowing: lines 4-6 of 10") {
+		t.Errorf("Expected total and range headers, got: %s", result)
+	}
+	if !strings.HasSuffix(result, "line 4\nline 5\nline 6") || strings.Contains(result, "line 7") {
+		t.Errorf("Expected only lines 4-6, got: %s", result)
+	}
+
+	// A limit past the end stops at the last line
+	result, err = te.readFile(map[string]interface{}{"file_path": "ten.txt", "offset": float64(9), "limit": float64(50)})
+	if err != nil {
+		t.Fatalf("readFile failed: %v", err)
+	}
+	if !strings.Contains(result, "Showing: lines 9-10 of 10") {
+		t.Errorf("Expected lines 9-10, got: %s", result)
+	}
+
+	// Limit alone reads from the start
+	result, err = te.readFile(map[string]interface{}{"file_path": "ten.txt", "limit": float64(2)})
+	if err != nil {
+		t.Fatalf("readFile failed: %v", err)
+	}
+	if !strings.HasSuffix(result, "line 1\nline 2") {
+		t.Errorf("Expected the first 2 lines, got: %s", result)
+	}
+
+	_, err = te.readFile(map[string]interface{}{"file_path": "ten.txt", "offset": float64(11)})
+	if err == nil || !strings.Contains(err.Error(), "past the end") {
+		t.Errorf("Expected past-the-end error, got %v", err)
+	}
+}
+
+func TestToolExecutor_ReadFileLongLine(t *testing.T) {
+	tempDir := t.TempDir()
+	testFile := filepath.Join(tempDir, "bundle.min.js")
+	testContent := strings.Repeat("var a=1;", 25000) // 200KB on a single line
+
+	err := os.WriteFile(testFile, []byte(testContent), 0o644)
+	if err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	te := NewToolExecutor(tempDir)
+
+	result, err := te.readFile(map[string]interface{}{"file_path": "bundle.min.js"})
+	if err != nil {
+		t.Fatalf("readFile failed: %v", err)
+	}
+
+	if len(result) > 5000 {
+		t.Errorf("Expected a truncated summary, got %d bytes", len(result))
+	}
+
+	if !strings.Contains(result, "likely minified or generated") {
+		t.Errorf("Expected minified file warning, got: %s", result)
+	}
+
+	if !strings.Contains(result, "Size: 200000 bytes") {
+		t.Errorf("Expected size-based summary, got: %s", result)
+	}
+
+	if !strings.Contains(result, "[truncated 198000 bytes]") {
+		t.Errorf("Expected truncation marker, got: %s", result)
+	}
+
+	// Raising the threshold returns the full content again
+	te.SetLongLineLimits(300000, 0)
+	result, err = te.readFile(map[string]interface{}{"file_path": "bundle.min.js"})
+	if err != nil {
+		t.Fatalf("readFile failed: %v", err)
+	}
+
+	if !strings.Contains(result, testContent) {
+		t.Error("Expected full content when under the configured threshold")
+	}
+}
+
+func TestToolExecutor_ReadFileSizeGuard(t *testing.T) {
+	tempDir := t.TempDir()
+	line := strings.Repeat("x", 99) + "\n"
+	testContent := strings.Repeat(line, 3000) // 300KB in 3000 lines
+
+	if err := os.WriteFile(filepath.Join(tempDir, "large.txt"), []byte(testContent), 0o644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	te := NewToolExecutor(tempDir)
+	te.SetMaxReadBytes(64 * 1024)
+
+	result, err := te.readFile(map[string]interface{}{"file_path": "large.txt"})
+	if err != nil {
+		t.Fatalf("readFile failed: %v", err)
+	}
+	if !strings.Contains(result, "File is 293.0 KB, showing first 64.0 KB — use offset/limit to read more") {
+		t.Errorf("Expected a truncation notice, got: %s", result[:min(len(result), 300)])
+	}
+	if !strings.Contains(result, "Showing: lines 1-655 of 3001") {
+		t.Errorf("Expected the shown line range, got: %s", result[:min(len(result), 300)])
+	}
+	if len(result) > 64*1024+300 {
+		t.Errorf("Expected about 64KB of content, got %d bytes", len(result))
+	}
+
+	// A small context window lowers the limit further
+	te.SetContextTokenLimit(32000)
+	result, err = te.readFile(map[string]interface{}{"file_path": "large.txt"})
+	if err != nil {
+		t.Fatalf("readFile failed: %v", err)
+	}
+	if !strings.Contains(result, "showing first 31.2 KB") {
+		t.Errorf("Expected the limit to scale with the context window, got: %s", result[:min(len(result), 300)])
+	}
+
+	// Large ranges are capped too
+	result, err = te.readFile(map[string]interface{}{"file_path": "large.txt", "offset": float64(100), "limit": float64(2000)})
+	if err != nil {
+		t.Fatalf("readFile failed: %v", err)
+	}
+	if !strings.Contains(result, "Showing: lines 100-419 of 3000") || !strings.Contains(result, "use a smaller limit or a later offset") {
+		t.Errorf("Expected a capped range, got: %s", result[:min(len(result), 300)])
+	}
+
+	// Ranges under the limit are returned whole
+	result, err = te.readFile(map[string]interface{}{"file_path": "large.txt", "offset": float64(100), "limit": float64(10)})
+	if err != nil {
+		t.Fatalf("readFile failed: %v", err)
+	}
+	if !strings.Contains(result, "Showing: lines 100-109 of 3000") {
+		t.Errorf("Expected the requested range, got: %s", result[:min(len(result), 300)])
+	}
+}
+
+func TestToolExecutor_ReadFileBinary(t *testing.T) {
+	tempDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tempDir, "app.bin"), []byte("ELF\x00\x01\x02binary"), 0o644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "notes.txt"), []byte("plain text\n"), 0o644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	te := NewToolExecutor(tempDir)
+
+	result, err := te.readFile(map[string]interface{}{"file_path": "app.bin"})
+	if err != nil {
+		t.Fatalf("readFile failed: %v", err)
+	}
+	if result != binaryFileNote("app.bin", 12) || strings.Contains(result, "ELF") {
+		t.Errorf("Expected a binary file note instead of the contents, got %q", result)
+	}
+
+	result, err = te.readFiles(map[string]interface{}{"files": []interface{}{
+		map[string]interface{}{"file_path": "app.bin"},
+		map[string]interface{}{"file_path": "notes.txt"},
+	}})
+	if err != nil {
+		t.Fatalf("readFiles failed: %v", err)
+	}
+	if !strings.Contains(result, "==> app.bin <==\nSkipped: binary file") || !strings.Contains(result, "plain text") {
+		t.Errorf("Expected the binary file to be skipped and the text file read, got: %s", result)
+	}
+}
+
 func TestToolExecutor_WriteFile(t *testing.T) {
 	tempDir := t.TempDir()
 	te := NewToolExecutor(tempDir)
@@ -196,6 +375,66 @@ func TestToolExecutor_EditFile(t *testing.T) {
 	}
 }
 
+func TestToolExecutor_EditFileMultipleMatches(t *testing.T) {
+	tempDir := t.TempDir()
+	testFile := filepath.Join(tempDir, "dup.txt")
+	originalContent := "x := 1\nfoo()\ny := 2\nfoo()\n"
+
+	if err := os.WriteFile(testFile, []byte(originalContent), 0o644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	te := NewToolExecutor(tempDir)
+
+	// An ambiguous edit is refused and names the matching lines
+	_, err := te.editFile(map[string]interface{}{
+		"file_path": "dup.txt",
+		"old_text":  "foo()",
+		"new_text":  "bar()",
+	})
+	if err == nil {
+		t.Fatal("Expected error when old_text matches several locations")
+	}
+	if !strings.Contains(err.Error(), "matches 2 locations") || !strings.Contains(err.Error(), "lines 2, 4") {
+		t.Errorf("Expected match count and line numbers in error, got: %v", err)
+	}
+
+	content, _ := os.ReadFile(testFile)
+	if string(content) != originalContent {
+		t.Errorf("Expected file to be unchanged, got: %q", content)
+	}
+
+	// A wrong expected count is refused too
+	_, err = te.editFile(map[string]interface{}{
+		"file_path":            "dup.txt",
+		"old_text":             "foo()",
+		"new_text":             "bar()",
+		"expected_occurrences": float64(3),
+	})
+	if err == nil || !strings.Contains(err.Error(), "expected 3 occurrences") {
+		t.Errorf("Expected occurrence mismatch error, got: %v", err)
+	}
+
+	// replace_all changes every occurrence
+	result, err := te.editFile(map[string]interface{}{
+		"file_path":   "dup.txt",
+		"old_text":    "foo()",
+		"new_text":    "bar()",
+		"replace_all": true,
+	})
+	if err != nil {
+		t.Fatalf("editFile failed: %v", err)
+	}
+	if !strings.Contains(result, "2 occurrences replaced") {
+		t.Errorf("Expected replacement count, got: %s", result)
+	}
+
+	content, _ = os.ReadFile(testFile)
+	if string(content) != "x := 1\nbar()\ny := 2\nbar()\n" {
+		t.Errorf("Expected every occurrence replaced, got: %q", content)
+	}
+}
+
 func TestToolExecutor_DeleteFile(t *testing.T) {
 	tempDir := t.TempDir()
 	testFile := filepath.Join(tempDir, "delete_test.txt")
@@ -232,3 +471,314 @@ func TestToolExecutor_DeleteFile(t *testing.T) {
 		t.Error("Expected error when deleting nonexistent file")
 	}
 }
+
+func TestToolExecutor_ReadFiles(t *testing.T) {
+	tempDir := t.TempDir()
+
+	writeLines := func(name string, count int) {
+		var lines []string
+		for i := 1; i <= count; i++ {
+			lines = append(lines, fmt.Sprintf("%s line %d", name, i))
+		}
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+	writeLines("a.go", 10)
+	writeLines("b.go", 5)
+	writeLines("c.go", 8)
+
+	te := NewToolExecutor(tempDir)
+
+	result, err := te.ExecuteTool(context.Background(), &llm.ToolCall{
+		Name: "read_files",
+		Input: map[string]interface{}{
+			"files": []interface{}{
+				map[string]interface{}{"file_path": "a.go", "start_line": float64(3), "end_line": float64(4)},
+				map[string]interface{}{"file_path": "b.go"},
+				map[string]interface{}{"file_path": "c.go", "start_line": float64(7)},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("read_files failed: %v", err)
+	}
+
+	expected := strings.Join([]string{
+		"==> a.go (lines 3-4 of 10) <==",
+		"a.go line 3",
+		"a.go line 4",
+		"",
+		"==> b.go (lines 1-5 of 5) <==",
+		"b.go line 1",
+		"b.go line 2",
+		"b.go line 3",
+		"b.go line 4",
+		"b.go line 5",
+		"",
+		"==> c.go (lines 7-8 of 8) <==",
+		"c.go line 7",
+		"c.go line 8",
+	}, "\n")
+	if result != expected {
+		t.Errorf("Unexpected read_files output.\nExpected:\n%s\nGot:\n%s", expected, result)
+	}
+
+	// A missing file is reported inline without failing the other reads
+	result, err = te.readFiles(map[string]interface{}{
+		"files": []interface{}{
+			map[string]interface{}{"file_path": "missing.go"},
+			map[string]interface{}{"file_path": "b.go", "end_line": float64(1)},
+		},
+	})
+	if err != nil {
+		t.Fatalf("read_files failed: %v", err)
+	}
+	if !strings.Contains(result, "==> missing.go <==\nError:") || !strings.Contains(result, "b.go line 1") {
+		t.Errorf("Expected per-file error and remaining content, got: %s", result)
+	}
+
+	// Too many files is rejected up front
+	var tooMany []interface{}
+	for i := 0; i <= maxReadFilesCount; i++ {
+		tooMany = append(tooMany, map[string]interface{}{"file_path": "a.go"})
+	}
+	if _, err := te.readFiles(map[string]interface{}{"files": tooMany}); err == nil {
+		t.Error("Expected error when requesting too many files")
+	}
+}
+
+func TestToolExecutor_ReadFilesGlob(t *testing.T) {
+	tempDir := t.TempDir()
+	files := map[string]string{
+		"internal/llm/manager.go":         "package llm\n",
+		"internal/llm/openai/provider.go": "package openai\n\nfunc x() {}\n",
+		"internal/llm/notes.md":           "notes\n",
+		"node_modules/dep/index.go":       "package dep\n",
+		"generated/out.go":                "package generated\n",
+	}
+	for name, content := range files {
+		fullPath := filepath.Join(tempDir, name)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0o755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, ".gitignore"), []byte("generated/\n"), 0o644); err != nil {
+		t.Fatalf("Failed to create .gitignore: %v", err)
+	}
+
+	te := NewToolExecutor(tempDir)
+
+	result, err := te.readFiles(map[string]interface{}{"glob": "internal/llm/**/*.go"})
+	if err != nil {
+		t.Fatalf("readFiles failed: %v", err)
+	}
+	if !strings.Contains(result, "==> internal/llm/manager.go (lines 1-1 of 1) <==") {
+		t.Errorf("Expected manager.go with its line count, got: %s", result)
+	}
+	if !strings.Contains(result, "==> internal/llm/openai/provider.go (lines 1-3 of 3) <==") {
+		t.Errorf("Expected nested provider.go, got: %s", result)
+	}
+	if strings.Contains(result, "notes.md") {
+		t.Errorf("Expected non-matching files to be left out, got: %s", result)
+	}
+
+	// Ignored directories are never read, even when the glob matches them
+	result, err = te.readFiles(map[string]interface{}{"glob": "**/*.go"})
+	if err != nil {
+		t.Fatalf("readFiles failed: %v", err)
+	}
+	if strings.Contains(result, "node_modules") || strings.Contains(result, "generated") {
+		t.Errorf("Expected ignored files to be skipped, got: %s", result)
+	}
+
+	// The byte budget stops reading and notes the files left out
+	result, err = te.readFiles(map[string]interface{}{"glob": "**/*.go", "max_bytes": float64(5)})
+	if err != nil {
+		t.Fatalf("readFiles failed: %v", err)
+	}
+	if !strings.Contains(result, "1 more matching files not read") {
+		t.Errorf("Expected budget note, got: %s", result)
+	}
+
+	result, err = te.readFiles(map[string]interface{}{"glob": "*.rs"})
+	if err != nil || result != "No files match *.rs" {
+		t.Errorf("Expected no-match message, got %q (%v)", result, err)
+	}
+
+	if _, err := te.readFiles(map[string]interface{}{"glob": "*.go", "files": []interface{}{}}); err == nil {
+		t.Error("Expected error when both files and glob are given")
+	}
+}
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "cmd/main.go", false},
+		{"**/*.go", "main.go", true},
+		{"**/*.go", "a/b/c.go", true},
+		{"internal/**", "internal/llm/x.go", true},
+		{"internal/*/x.go", "internal/llm/x.go", true},
+		{"internal/*/x.go", "internal/llm/sub/x.go", false},
+	}
+
+	for _, tt := range tests {
+		if got := matchGlob(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("matchGlob(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestToolExecutor_MultiEditFileFailure(t *testing.T) {
+	tempDir := t.TempDir()
+	testFile := filepath.Join(tempDir, "server.go")
+	original := "package server\n\nfunc Start(port int) error {\n\treturn listen(port)\n}\n\nfunc Stop() {}\n"
+	if err := os.WriteFile(testFile, []byte(original), 0o644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	te := NewToolExecutor(tempDir)
+	var changes int
+	te.SetFileChangeCallback(func(FileChange) { changes++ })
+
+	edits := []interface{}{
+		map[string]interface{}{"old_text": "package server", "new_text": "package httpserver"},
+		map[string]interface{}{"old_text": "func Start(port string) error {", "new_text": "func Start(addr string) error {"},
+		map[string]interface{}{"old_text": "func Stop() {}", "new_text": "func Stop() error { return nil }"},
+	}
+
+	// Atomic (default): the second edit fails and nothing is written
+	_, err := te.multiEditFile(map[string]interface{}{"file_path": "server.go", "edits": edits})
+	if err == nil {
+		t.Fatal("Expected multi_edit to fail when an edit does not match")
+	}
+	for _, want := range []string{"edit 2 of 3", "line 3", "func Start(port int) error {", "unchanged on disk"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to contain %q, got: %v", want, err)
+		}
+	}
+
+	content, _ := os.ReadFile(testFile)
+	if string(content) != original {
+		t.Errorf("Expected file on disk to be unchanged after a failed atomic multi_edit, got: %s", content)
+	}
+	if changes != 0 {
+		t.Errorf("Expected no file change notifications, got %d", changes)
+	}
+
+	// Non-atomic: matching edits are applied and the failure is reported
+	result, err := te.multiEditFile(map[string]interface{}{"file_path": "server.go", "edits": edits, "atomic": false})
+	if err != nil {
+		t.Fatalf("Expected non-atomic multi_edit to succeed, got: %v", err)
+	}
+	if !strings.Contains(result, "applied 2 edits") || !strings.Contains(result, "1 of 3 edits failed") {
+		t.Errorf("Expected result to report applied and failed edits, got: %s", result)
+	}
+
+	content, _ = os.ReadFile(testFile)
+	if !strings.Contains(string(content), "package httpserver") || !strings.Contains(string(content), "func Stop() error") {
+		t.Errorf("Expected matching edits to be written, got: %s", content)
+	}
+	if !strings.Contains(string(content), "func Start(port int) error {") {
+		t.Errorf("Expected the failed edit to leave its text alone, got: %s", content)
+	}
+}
+
+func TestDescribeEditMiss(t *testing.T) {
+	original := "a := 1\nb := 2\n"
+	current := "a := 10\nb := 2\n"
+
+	if got := describeEditMiss(original, current, "a := 1\n"); !strings.Contains(got, "earlier edit in this sequence") {
+		t.Errorf("Expected an earlier-edit explanation, got: %s", got)
+	}
+	if got := describeEditMiss(current, current, "completely different"); !strings.Contains(got, "No line in the file resembles") {
+		t.Errorf("Expected a no-match explanation, got: %s", got)
+	}
+}
+
+func TestToolExecutor_EditFileNearMatch(t *testing.T) {
+	tempDir := t.TempDir()
+	testFile := filepath.Join(tempDir, "server.go")
+	original := "package server\n\nfunc Start(port int) error {\n\tif port == 0 {\n\t\treturn errNoPort\n\t}\n\treturn listen(port)\n}\n"
+	if err := os.WriteFile(testFile, []byte(original), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	te := NewToolExecutor(tempDir)
+
+	// Indented with spaces instead of tabs
+	oldText := "    if port == 0 {\n        return errNoPort\n    }\n"
+	newText := "\tif port <= 0 {\n\t\treturn errNoPort\n\t}\n"
+
+	_, err := te.editFile(map[string]interface{}{"file_path": "server.go", "old_text": oldText, "new_text": newText})
+	if err == nil {
+		t.Fatal("Expected an inexact match to fail without fuzzy")
+	}
+	if !strings.Contains(err.Error(), "Closest match at line 4 (only whitespace differs):\n\tif port == 0 {\n\t\treturn errNoPort\n\t}\n") {
+		t.Errorf("Expected the near match's line and exact text, got: %v", err)
+	}
+	if content, _ := os.ReadFile(testFile); string(content) != original {
+		t.Error("Expected the file to be unchanged")
+	}
+
+	result, err := te.editFile(map[string]interface{}{"file_path": "server.go", "old_text": oldText, "new_text": newText, "fuzzy": true})
+	if err != nil {
+		t.Fatalf("Expected the fuzzy edit to apply: %v", err)
+	}
+	if !strings.Contains(result, "matched approximately at line 4") {
+		t.Errorf("Unexpected result: %s", result)
+	}
+	want := strings.Replace(original, "port == 0", "port <= 0", 1)
+	if content, _ := os.ReadFile(testFile); string(content) != want {
+		t.Errorf("Expected only the matched region to change, got:\n%s", content)
+	}
+
+	// A small typo is found by edit distance
+	_, err = te.editFile(map[string]interface{}{"file_path": "server.go", "old_text": "\treturn listne(port)\n}", "new_text": "\treturn serve(port)\n}"})
+	if err == nil || !strings.Contains(err.Error(), "Closest match at line 7 (") || !strings.Contains(err.Error(), "% similar):\n\treturn listen(port)\n}") {
+		t.Errorf("Expected a similar-text match, got: %v", err)
+	}
+}
+
+func TestFindEditCandidateAmbiguous(t *testing.T) {
+	content := "func a() {\n\treturn nil\n}\n\nfunc b() {\n\treturn nil\n}\n"
+
+	candidate, reason := findEditCandidate(content, "  return nil\n")
+	if candidate != nil {
+		t.Fatalf("Expected no candidate for text that nearly matches twice, got line %d", candidate.line)
+	}
+	if !strings.Contains(reason, "nearly matches 2 places (lines 2, 6)") {
+		t.Errorf("Unexpected reason: %s", reason)
+	}
+
+	if candidate, _ := findEditCandidate(content, "completely unrelated text that matches nothing"); candidate != nil {
+		t.Errorf("Expected no candidate for unrelated text, got line %d", candidate.line)
+	}
+}
+
+func TestBoundedLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b  string
+		limit int
+		want  int
+	}{
+		{"kitten", "sitting", 5, 3},
+		{"", "abc", 5, 3},
+		{"same", "same", 0, 0},
+		{"kitten", "sitting", 2, 3}, // Over the limit reports limit+1
+		{"abcdef", "abc", 1, 2},
+	}
+
+	for _, tt := range tests {
+		if got := boundedLevenshtein(tt.a, tt.b, tt.limit); got != tt.want {
+			t.Errorf("boundedLevenshtein(%q, %q, %d) = %d, want %d", tt.a, tt.b, tt.limit, got, tt.want)
+		}
+	}
+}
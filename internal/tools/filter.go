@@ -0,0 +1,82 @@
+package tools
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SetToolFilter limits the tools offered to the model and executed. When enabled is
+// non-empty only the tools it names are available; the tools disabled names never are.
+func (te *ToolExecutor) SetToolFilter(enabled, disabled []string) {
+	te.enabledTools = toolNameSet(enabled)
+	te.disabledTools = toolNameSet(disabled)
+}
+
+// toolNameSet returns the trimmed, non-empty names as a set, or nil when there are none
+func toolNameSet(names []string) map[string]bool {
+	var set map[string]bool
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if set == nil {
+			set = make(map[string]bool)
+		}
+		set[name] = true
+	}
+	return set
+}
+
+// IsToolEnabled reports whether the tool filter lets the model use a tool
+func (te *ToolExecutor) IsToolEnabled(name string) bool {
+	if te.disabledTools[name] {
+		return false
+	}
+	return len(te.enabledTools) == 0 || te.enabledTools[name]
+}
+
+// checkToolEnabled refuses tools the configuration turned off, in case the model calls
+// one it was not offered
+func (te *ToolExecutor) checkToolEnabled(name string) error {
+	if te.IsToolEnabled(name) {
+		return nil
+	}
+	return fmt.Errorf("the %s tool is disabled in this configuration", name)
+}
+
+// ToolNames returns the names of the tools the model is offered and of those the tool
+// filter turned off, each sorted
+func (te *ToolExecutor) ToolNames() (active, disabled []string) {
+	for _, tool := range te.allTools() {
+		if te.IsToolEnabled(tool.Name) {
+			active = append(active, tool.Name)
+		} else {
+			disabled = append(disabled, tool.Name)
+		}
+	}
+	sort.Strings(active)
+	sort.Strings(disabled)
+	return active, disabled
+}
+
+// UnknownFilterNames returns names in the tool filter that match no tool, sorted, so
+// typos in the configuration can be pointed out
+func (te *ToolExecutor) UnknownFilterNames() []string {
+	known := make(map[string]bool)
+	for _, tool := range te.allTools() {
+		known[tool.Name] = true
+	}
+
+	var unknown []string
+	for _, set := range []map[string]bool{te.enabledTools, te.disabledTools} {
+		for name := range set {
+			if !known[name] {
+				unknown = append(unknown, name)
+			}
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
@@ -0,0 +1,53 @@
+package tools
+
+import (
+	"context"
+	"github.com/tildaslashalef/bazinga/internal/llm"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestToolFilter(t *testing.T) {
+	te := NewToolExecutor(t.TempDir())
+	all := len(te.GetAvailableTools())
+
+	te.SetToolFilter(nil, []string{"bash", " delete_file ", "", "not_a_tool"})
+	offered := te.GetAvailableTools()
+	if len(offered) != all-2 {
+		t.Errorf("Expected %d tools offered, got %d", all-2, len(offered))
+	}
+	for _, tool := range offered {
+		if tool.Name == "bash" || tool.Name == "delete_file" {
+			t.Errorf("Expected %s not to be offered", tool.Name)
+		}
+	}
+
+	_, disabled := te.ToolNames()
+	if !reflect.DeepEqual(disabled, []string{"bash", "delete_file"}) {
+		t.Errorf("Expected bash and delete_file disabled, got %v", disabled)
+	}
+	if unknown := te.UnknownFilterNames(); !reflect.DeepEqual(unknown, []string{"not_a_tool"}) {
+		t.Errorf("Expected not_a_tool reported as unknown, got %v", unknown)
+	}
+
+	_, err := te.ExecuteTool(context.Background(), &llm.ToolCall{Name: "bash", Input: map[string]interface{}{"command": "echo hi"}})
+	if err == nil || !strings.Contains(err.Error(), "disabled") {
+		t.Errorf("Expected a disabled tool to be refused, got %v", err)
+	}
+
+	// An allowlist offers only the tools it names, less any that are disabled
+	te.SetToolFilter([]string{"read_file", "grep", "bash"}, []string{"bash"})
+	active, _ := te.ToolNames()
+	if !reflect.DeepEqual(active, []string{"grep", "read_file"}) {
+		t.Errorf("Expected only grep and read_file active, got %v", active)
+	}
+	if _, err := te.ExecuteToolResult(context.Background(), &llm.ToolCall{Name: "write_file", Input: map[string]interface{}{"file_path": "a.txt", "content": "x"}}); err == nil {
+		t.Error("Expected a tool outside the allowlist to be refused")
+	}
+
+	te.SetToolFilter(nil, nil)
+	if len(te.GetAvailableTools()) != all {
+		t.Error("Expected clearing the filter to offer every tool again")
+	}
+}
@@ -1,9 +1,14 @@
 package tools
 
 import (
+	"context"
 	"fmt"
 	"github.com/tildaslashalef/bazinga/internal/loggy"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -11,11 +16,19 @@ import (
 // For testing - allows us to mock exec.Command
 var execCommand = exec.Command
 
-// gitStatus shows the current git status
+// gitStatus shows the current git status as a structured summary, or the full
+// `git status` output when raw is set
 func (te *ToolExecutor) gitStatus(input map[string]interface{}) (string, error) {
 	loggy.Debug("ToolExecutor gitStatus")
 
-	cmd := execCommand("git", "status", "--porcelain", "-b")
+	raw, _ := input["raw"].(bool)
+
+	args := []string{"status", "--porcelain=v2", "--branch"}
+	if raw {
+		args = []string{"status"}
+	}
+
+	cmd := execCommand("git", args...)
 	cmd.Dir = te.rootPath
 
 	output, err := cmd.CombinedOutput()
@@ -23,56 +36,188 @@ func (te *ToolExecutor) gitStatus(input map[string]interface{}) (string, error)
 		return "", fmt.Errorf("git status failed: %w\nOutput: %s", err, string(output))
 	}
 
-	result := strings.TrimSpace(string(output))
-	if result == "" {
-		return "Working tree clean", nil
-	}
-
-	// Parse the output for better formatting
-	lines := strings.Split(result, "\n")
-	var formattedLines []string
-
-	for _, line := range lines {
-		if strings.HasPrefix(line, "##") {
-			// Branch information
-			branch := strings.TrimPrefix(line, "## ")
-			formattedLines = append(formattedLines, "Branch: "+branch)
-		} else if len(line) >= 3 {
-			// File status
-			status := line[:2]
-			file := strings.TrimSpace(line[3:])
-
-			var statusDesc string
-			switch status {
-			case "M ":
-				statusDesc = "Modified"
-			case " M":
-				statusDesc = "Modified (unstaged)"
-			case "A ":
-				statusDesc = "Added"
-			case " A":
-				statusDesc = "Added (unstaged)"
-			case "D ":
-				statusDesc = "Deleted"
-			case " D":
-				statusDesc = "Deleted (unstaged)"
-			case "??":
-				statusDesc = "Untracked"
-			case "R ":
-				statusDesc = "Renamed"
-			case "C ":
-				statusDesc = "Copied"
-			case "MM":
-				statusDesc = "Modified (staged and unstaged)"
-			default:
-				statusDesc = status
+	if raw {
+		return strings.TrimSpace(string(output)), nil
+	}
+
+	return parseGitStatusV2(string(output)).String(), nil
+}
+
+// gitStatusEntry is one changed path in a git status summary
+type gitStatusEntry struct {
+	Status string // "modified", "added", "deleted", ...
+	Path   string
+	From   string // Original path for renames and copies
+}
+
+// gitStatusSummary is the parsed form of `git status --porcelain=v2 --branch`
+type gitStatusSummary struct {
+	Branch     string
+	Upstream   string
+	Ahead      int
+	Behind     int
+	Staged     []gitStatusEntry
+	Modified   []gitStatusEntry
+	Renamed    []gitStatusEntry
+	Untracked  []gitStatusEntry
+	Conflicted []gitStatusEntry
+}
+
+// parseGitStatusV2 parses porcelain v2 status output. Unknown lines are ignored.
+func parseGitStatusV2(output string) gitStatusSummary {
+	var summary gitStatusSummary
+
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		if line == "" {
+			continue
+		}
+
+		switch line[0] {
+		case '#':
+			parseGitStatusHeader(&summary, line)
+		case '1':
+			// 1 <XY> <sub> <mH> <mI> <mW> <hH> <hI> <path>
+			fields := strings.SplitN(line, " ", 9)
+			if len(fields) < 9 {
+				continue
+			}
+			summary.addChange(fields[1], gitStatusEntry{Path: fields[8]})
+		case '2':
+			// 2 <XY> <sub> <mH> <mI> <mW> <hH> <hI> <X><score> <path>\t<origPath>
+			fields := strings.SplitN(line, " ", 10)
+			if len(fields) < 10 {
+				continue
+			}
+			path, from, _ := strings.Cut(fields[9], "\t")
+			status := "renamed"
+			if strings.HasPrefix(fields[8], "C") {
+				status = "copied"
+			}
+			summary.Renamed = append(summary.Renamed, gitStatusEntry{Status: status, Path: path, From: from})
+			if xy := fields[1]; len(xy) == 2 && xy[1] != '.' {
+				summary.Modified = append(summary.Modified, gitStatusEntry{Status: gitStatusCode(xy[1]), Path: path})
 			}
+		case 'u':
+			// u <XY> <sub> <m1> <m2> <m3> <mW> <h1> <h2> <h3> <path>
+			fields := strings.SplitN(line, " ", 11)
+			if len(fields) < 11 {
+				continue
+			}
+			summary.Conflicted = append(summary.Conflicted, gitStatusEntry{Status: "conflict " + fields[1], Path: fields[10]})
+		case '?':
+			summary.Untracked = append(summary.Untracked, gitStatusEntry{Status: "untracked", Path: strings.TrimPrefix(line, "? ")})
+		}
+	}
+
+	return summary
+}
+
+// parseGitStatusHeader reads the "# branch.*" header lines
+func parseGitStatusHeader(summary *gitStatusSummary, line string) {
+	fields := strings.Fields(line)
+	if len(fields) < 3 {
+		return
+	}
+
+	switch fields[1] {
+	case "branch.head":
+		summary.Branch = fields[2]
+	case "branch.upstream":
+		summary.Upstream = fields[2]
+	case "branch.ab":
+		if len(fields) >= 4 {
+			summary.Ahead, _ = strconv.Atoi(strings.TrimPrefix(fields[2], "+"))
+			summary.Behind, _ = strconv.Atoi(strings.TrimPrefix(fields[3], "-"))
+		}
+	}
+}
+
+// addChange files an ordinary change under staged and/or modified from its XY code
+func (s *gitStatusSummary) addChange(xy string, entry gitStatusEntry) {
+	if len(xy) != 2 {
+		return
+	}
+	if xy[0] != '.' {
+		staged := entry
+		staged.Status = gitStatusCode(xy[0])
+		s.Staged = append(s.Staged, staged)
+	}
+	if xy[1] != '.' {
+		modified := entry
+		modified.Status = gitStatusCode(xy[1])
+		s.Modified = append(s.Modified, modified)
+	}
+}
+
+// gitStatusCode describes a single porcelain status letter
+func gitStatusCode(code byte) string {
+	switch code {
+	case 'M':
+		return "modified"
+	case 'T':
+		return "type changed"
+	case 'A':
+		return "added"
+	case 'D':
+		return "deleted"
+	case 'R':
+		return "renamed"
+	case 'C':
+		return "copied"
+	default:
+		return string(code)
+	}
+}
 
-			formattedLines = append(formattedLines, fmt.Sprintf("  %s: %s", statusDesc, file))
+// String formats the summary as labelled sections with counts
+func (s gitStatusSummary) String() string {
+	var b strings.Builder
+
+	branch := s.Branch
+	if branch == "" {
+		branch = "(unknown)"
+	}
+	b.WriteString("Branch: " + branch)
+	if s.Upstream != "" {
+		fmt.Fprintf(&b, " (upstream %s, ahead %d, behind %d)", s.Upstream, s.Ahead, s.Behind)
+	}
+	b.WriteString("\n")
+
+	sections := []struct {
+		title   string
+		entries []gitStatusEntry
+	}{
+		{"Staged", s.Staged},
+		{"Modified (unstaged)", s.Modified},
+		{"Renamed", s.Renamed},
+		{"Untracked", s.Untracked},
+		{"Conflicted", s.Conflicted},
+	}
+
+	clean := true
+	for _, section := range sections {
+		if len(section.entries) == 0 {
+			continue
+		}
+		clean = false
+		fmt.Fprintf(&b, "%s (%d):\n", section.title, len(section.entries))
+		for _, entry := range section.entries {
+			switch {
+			case entry.From != "":
+				fmt.Fprintf(&b, "  %s: %s → %s\n", entry.Status, entry.From, entry.Path)
+			case entry.Status == "untracked":
+				fmt.Fprintf(&b, "  %s\n", entry.Path)
+			default:
+				fmt.Fprintf(&b, "  %s: %s\n", entry.Status, entry.Path)
+			}
 		}
 	}
 
-	return strings.Join(formattedLines, "\n"), nil
+	if clean {
+		b.WriteString("Working tree clean\n")
+	}
+
+	return strings.TrimRight(b.String(), "\n")
 }
 
 // gitDiff shows the git diff
@@ -121,27 +266,9 @@ func (te *ToolExecutor) gitDiff(input map[string]interface{}) (string, error) {
 func (te *ToolExecutor) gitAdd(input map[string]interface{}) (string, error) {
 	loggy.Debug("ToolExecutor gitAdd")
 
-	pathsInterface, ok := input["paths"]
-	if !ok {
-		return "", fmt.Errorf("paths array is required")
-	}
-
-	var paths []string
-	switch v := pathsInterface.(type) {
-	case string:
-		paths = []string{v}
-	case []interface{}:
-		for _, pathInterface := range v {
-			if path, ok := pathInterface.(string); ok {
-				paths = append(paths, path)
-			}
-		}
-	default:
-		return "", fmt.Errorf("paths must be a string or array of strings")
-	}
-
-	if len(paths) == 0 {
-		return "", fmt.Errorf("at least one path is required")
+	paths, err := gitPathsInput(input)
+	if err != nil {
+		return "", err
 	}
 
 	// Build git add command
@@ -172,7 +299,15 @@ func (te *ToolExecutor) gitCommit(input map[string]interface{}) (string, error)
 		return "", fmt.Errorf("commit message cannot be empty")
 	}
 
-	cmd := execCommand("git", "commit", "-m", message)
+	args := []string{"commit", "-m", message}
+	if te.skipCommitHooks {
+		args = append(args, "--no-verify")
+	} else if _, err := te.RunPreCommitChecks(context.Background(), false); err != nil {
+		// git runs the repository's own hook as part of the commit
+		return "", err
+	}
+
+	cmd := execCommand("git", args...)
 	cmd.Dir = te.rootPath
 
 	// Set timeout
@@ -190,6 +325,9 @@ func (te *ToolExecutor) gitCommit(input map[string]interface{}) (string, error)
 	select {
 	case err := <-done:
 		if err != nil {
+			if !te.skipCommitHooks && te.preCommitHook() != "" {
+				return "", fmt.Errorf("git commit failed, possibly rejected by the pre-commit hook; fix the problems its output reports, stage the fixes and commit again: %w\nOutput: %s", err, string(output))
+			}
 			return "", fmt.Errorf("git commit failed: %w\nOutput: %s", err, string(output))
 		}
 
@@ -265,3 +403,173 @@ func (te *ToolExecutor) gitBranch(input map[string]interface{}) (string, error)
 	result := strings.TrimSpace(string(output))
 	return result, nil
 }
+
+// stashRefPattern matches the stash references git_stash_pop accepts
+var stashRefPattern = regexp.MustCompile(`^stash@\{\d+\}$`)
+
+// gitStash stashes uncommitted changes and reports the stash ref it created
+func (te *ToolExecutor) gitStash(input map[string]interface{}) (string, error) {
+	loggy.Debug("ToolExecutor gitStash")
+
+	args := []string{"stash", "push"}
+	if includeUntracked, ok := input["include_untracked"].(bool); ok && includeUntracked {
+		args = append(args, "--include-untracked")
+	}
+	if message, ok := input["message"].(string); ok && strings.TrimSpace(message) != "" {
+		args = append(args, "--message", message)
+	}
+
+	cmd := execCommand("git", args...)
+	cmd.Dir = te.rootPath
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git stash failed: %w\nOutput: %s", err, string(output))
+	}
+	if strings.Contains(string(output), "No local changes to save") {
+		return "No local changes to stash", nil
+	}
+
+	// The new stash is always stash@{0}
+	cmd = execCommand("git", "stash", "list", "--max-count=1", "--format=%gd (%h): %gs")
+	cmd.Dir = te.rootPath
+	listOutput, err := cmd.CombinedOutput()
+	if err != nil {
+		return "Stashed changes as stash@{0}", nil //nolint:nilerr // The stash was created; only its description is missing
+	}
+
+	return fmt.Sprintf("Stashed changes as %s\nRestore them with git_stash_pop", strings.TrimSpace(string(listOutput))), nil
+}
+
+// gitStashPop applies a stash to the working tree and drops it
+func (te *ToolExecutor) gitStashPop(input map[string]interface{}) (string, error) {
+	loggy.Debug("ToolExecutor gitStashPop")
+
+	ref := "stash@{0}"
+	if stash, ok := input["stash"].(string); ok && stash != "" {
+		ref = stash
+	}
+	if !stashRefPattern.MatchString(ref) {
+		return "", fmt.Errorf("stash must look like stash@{N}, got %q", ref)
+	}
+
+	cmd := execCommand("git", "stash", "pop", ref)
+	cmd.Dir = te.rootPath
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		// On conflicts git applies what it can and keeps the stash
+		return "", fmt.Errorf("git stash pop failed: %w\nOutput: %s", err, string(output))
+	}
+
+	result := strings.TrimSpace(string(output))
+	return fmt.Sprintf("Popped %s\n%s", ref, result), nil
+}
+
+// gitRestore discards working-tree changes to paths, or unstages them when staged is set
+func (te *ToolExecutor) gitRestore(input map[string]interface{}) (string, error) {
+	loggy.Debug("ToolExecutor gitRestore")
+
+	paths, err := gitPathsInput(input)
+	if err != nil {
+		return "", err
+	}
+
+	staged, _ := input["staged"].(bool)
+
+	// Remember the files' contents so the discarded changes can be shown and undone
+	var before map[string]string
+	if !staged {
+		before = te.readRegularFiles(paths)
+	}
+
+	args := []string{"restore"}
+	if staged {
+		args = append(args, "--staged")
+	}
+	args = append(args, "--")
+	args = append(args, paths...)
+
+	cmd := execCommand("git", args...)
+	cmd.Dir = te.rootPath
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git restore failed: %w\nOutput: %s", err, string(output))
+	}
+
+	if staged {
+		return fmt.Sprintf("Unstaged %d path(s); working-tree changes are kept", len(paths)), nil
+	}
+
+	changed := 0
+	for path, content := range before {
+		after, err := os.ReadFile(filepath.Join(te.rootPath, path))
+		if err != nil || string(after) == content {
+			continue
+		}
+		changed++
+		if te.fileChangeCallback != nil {
+			te.fileChangeCallback(FileChange{
+				FilePath:  path,
+				Before:    content,
+				After:     string(after),
+				Operation: "edit",
+			})
+		}
+	}
+
+	return fmt.Sprintf("Restored %d path(s) to their committed state (%d file(s) changed)", len(paths), changed), nil
+}
+
+// gitPathsInput reads the paths argument of git tools, given as a string or an array
+func gitPathsInput(input map[string]interface{}) ([]string, error) {
+	pathsInterface, ok := input["paths"]
+	if !ok {
+		return nil, fmt.Errorf("paths array is required")
+	}
+
+	var paths []string
+	switch v := pathsInterface.(type) {
+	case string:
+		paths = []string{v}
+	case []interface{}:
+		for _, pathInterface := range v {
+			if path, ok := pathInterface.(string); ok {
+				paths = append(paths, path)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("paths must be a string or array of strings")
+	}
+
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("at least one path is required")
+	}
+	return paths, nil
+}
+
+// readRegularFiles returns the contents of the paths that are regular files, keyed by
+// path relative to the project root
+func (te *ToolExecutor) readRegularFiles(paths []string) map[string]string {
+	contents := make(map[string]string)
+	for _, path := range paths {
+		fullPath := path
+		if !filepath.IsAbs(fullPath) {
+			fullPath = filepath.Join(te.rootPath, path)
+		}
+		relPath, err := filepath.Rel(te.rootPath, fullPath)
+		if err != nil {
+			continue
+		}
+
+		info, err := os.Stat(fullPath)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		if content, err := os.ReadFile(fullPath); err == nil {
+			contents[relPath] = string(content)
+		}
+	}
+	return contents
+}
@@ -0,0 +1,219 @@
+package tools
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/tildaslashalef/bazinga/internal/loggy"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxBlameLines caps how many lines one git_blame call covers
+const maxBlameLines = 200
+
+// blameCommit is the commit a blamed line was last changed in
+type blameCommit struct {
+	hash    string
+	author  string
+	date    string
+	summary string
+}
+
+// uncommitted reports whether the commit is the all-zero one git blame reports for lines
+// changed in the working tree
+func (c *blameCommit) uncommitted() bool {
+	return strings.Trim(c.hash, "0") == ""
+}
+
+// blameLine is one line of a blamed range
+type blameLine struct {
+	number  int
+	commit  *blameCommit
+	content string
+}
+
+// gitBlame shows the commit, author and date that last changed each line in a range of a
+// file, with the summaries of those commits
+func (te *ToolExecutor) gitBlame(input map[string]interface{}) (string, error) {
+	loggy.Debug("ToolExecutor gitBlame")
+
+	filePath, ok := input["file_path"].(string)
+	if !ok || filePath == "" {
+		return "", fmt.Errorf("file_path is required")
+	}
+	absPath := te.absPath(filePath)
+	displayPath := te.displayPath(absPath)
+
+	content, err := os.ReadFile(absPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file %s: %w", displayPath, err)
+	}
+	if isBinary(content) {
+		return binaryFileNote(displayPath, len(content)), nil
+	}
+	total := bytes.Count(content, []byte("\n"))
+	if len(content) > 0 && !bytes.HasSuffix(content, []byte("\n")) {
+		total++
+	}
+	if total == 0 {
+		return fmt.Sprintf("%s is empty", displayPath), nil
+	}
+
+	start, end, note, err := blameRange(input, total)
+	if err != nil {
+		return "", err
+	}
+
+	cmd := execCommand("git", "blame", "--porcelain", "-L", fmt.Sprintf("%d,%d", start, end), "--", absPath)
+	cmd.Dir = te.rootPath
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git blame failed: %w\nOutput: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	lines, commits := parseBlamePorcelain(string(output))
+	if len(lines) == 0 {
+		return fmt.Sprintf("No blame information for %s lines %d-%d", displayPath, start, end), nil
+	}
+
+	result := formatBlame(displayPath, start, end, total, lines, commits)
+	if note != "" {
+		result += "\n" + note
+	}
+	return result, nil
+}
+
+// blameRange returns the lines to blame from the start_line and end_line inputs, clamped
+// to the file and capped at maxBlameLines, with a note when the range was cut
+func blameRange(input map[string]interface{}, total int) (start, end int, note string, err error) {
+	start, end = 1, total
+	if value, ok := input["start_line"].(float64); ok {
+		start = int(value)
+	}
+	if value, ok := input["end_line"].(float64); ok {
+		end = int(value)
+	}
+
+	if start < 1 {
+		start = 1
+	}
+	if start > total {
+		return 0, 0, "", fmt.Errorf("start_line %d is past the end of the file (%d lines)", start, total)
+	}
+	if end > total {
+		end = total
+	}
+	if end < start {
+		return 0, 0, "", fmt.Errorf("end_line %d is before start_line %d", end, start)
+	}
+
+	if end-start+1 > maxBlameLines {
+		end = start + maxBlameLines - 1
+		note = fmt.Sprintf("... [showing %d lines, the most one call covers; blame from start_line %d for more]", maxBlameLines, end+1)
+	}
+	return start, end, note, nil
+}
+
+// parseBlamePorcelain parses the output of git blame --porcelain into its lines and the
+// commits they came from, in the order the commits first appear
+func parseBlamePorcelain(output string) ([]blameLine, []*blameCommit) {
+	var lines []blameLine
+	var order []*blameCommit
+	commits := make(map[string]*blameCommit)
+
+	var current *blameCommit
+	var lineNumber int
+	for _, line := range strings.Split(output, "\n") {
+		if strings.HasPrefix(line, "\t") {
+			if current != nil {
+				lines = append(lines, blameLine{number: lineNumber, commit: current, content: line[1:]})
+			}
+			continue
+		}
+
+		key, value, _ := strings.Cut(line, " ")
+		if (len(key) == 40 || len(key) == 64) && isHex(key) {
+			// "<hash> <original line> <final line> [<lines in group>]" starts each line
+			fields := strings.Fields(value)
+			if len(fields) < 2 {
+				continue
+			}
+			lineNumber, _ = strconv.Atoi(fields[1])
+			if current = commits[key]; current == nil {
+				current = &blameCommit{hash: key}
+				commits[key] = current
+				order = append(order, current)
+			}
+			continue
+		}
+		if current == nil {
+			continue
+		}
+
+		switch key {
+		case "author":
+			current.author = value
+		case "author-time":
+			if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+				current.date = time.Unix(seconds, 0).UTC().Format("2006-01-02")
+			}
+		case "summary":
+			current.summary = value
+		}
+	}
+	return lines, order
+}
+
+// isHex reports whether s is made of lowercase hexadecimal digits
+func isHex(s string) bool {
+	for _, r := range s {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// formatBlame renders blamed lines as "line  hash  date  author | content", followed by
+// the summary of each commit
+func formatBlame(displayPath string, start, end, total int, lines []blameLine, commits []*blameCommit) string {
+	authorWidth := 0
+	for _, commit := range commits {
+		authorWidth = max(authorWidth, len([]rune(blameAuthor(commit))))
+	}
+	numberWidth := len(strconv.Itoa(end))
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Blame for %s lines %d-%d (of %d):\n", displayPath, start, end, total))
+	for _, line := range lines {
+		author := blameAuthor(line.commit)
+		result.WriteString(fmt.Sprintf("%*d  %s  %-10s  %s%s | %s\n",
+			numberWidth, line.number, line.commit.hash[:8], line.commit.date,
+			author, strings.Repeat(" ", authorWidth-len([]rune(author))), line.content))
+	}
+
+	result.WriteString("\nCommits:\n")
+	for _, commit := range commits {
+		if commit.uncommitted() {
+			result.WriteString(fmt.Sprintf("  %s  not committed yet (working tree changes)\n", commit.hash[:8]))
+			continue
+		}
+		result.WriteString(fmt.Sprintf("  %s  %s  %s: %s\n", commit.hash[:8], commit.date, blameAuthor(commit), commit.summary))
+	}
+	return strings.TrimRight(result.String(), "\n")
+}
+
+// blameAuthor returns the author shown for a commit, cut to 20 characters
+func blameAuthor(commit *blameCommit) string {
+	author := commit.author
+	if commit.uncommitted() {
+		author = "uncommitted"
+	}
+	if runes := []rune(author); len(runes) > 20 {
+		author = string(runes[:19]) + "…"
+	}
+	return author
+}
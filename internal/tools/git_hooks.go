@@ -0,0 +1,126 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"github.com/tildaslashalef/bazinga/internal/loggy"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// preCommitTimeout is how long the pre-commit checks may run before the commit is
+// abandoned
+const preCommitTimeout = 5 * time.Minute
+
+// PreCommitError is a pre-commit check that failed, with its output so the model can
+// fix what it reports and commit again
+type PreCommitError struct {
+	Check  string // The configured command, or "pre-commit hook"
+	Output string
+}
+
+func (e *PreCommitError) Error() string {
+	return fmt.Sprintf("commit aborted: %s failed. Fix the problems it reports, stage the fixes and commit again.\nOutput:\n%s", e.Check, e.Output)
+}
+
+// SetCommitHooks configures the checks run before commits: command runs ahead of the
+// repository's own pre-commit hook, and skip turns both off
+func (te *ToolExecutor) SetCommitHooks(command string, skip bool) {
+	te.preCommitCommand = strings.TrimSpace(command)
+	te.skipCommitHooks = skip
+}
+
+// SkipCommitHooks reports whether commits skip the pre-commit checks
+func (te *ToolExecutor) SkipCommitHooks() bool {
+	return te.skipCommitHooks
+}
+
+// RunPreCommitChecks runs the configured pre-commit command and, with includeHook, the
+// repository's pre-commit hook, for commits made without the git CLI, which would run
+// the hook itself. It returns the checks' output; a failing check returns a
+// *PreCommitError.
+func (te *ToolExecutor) RunPreCommitChecks(ctx context.Context, includeHook bool) (string, error) {
+	if te.skipCommitHooks {
+		return "", nil
+	}
+
+	var outputs []string
+	if te.preCommitCommand != "" {
+		output, err := te.runPreCommitCheck(ctx, "bash", "-c", te.preCommitCommand)
+		if err != nil {
+			return "", &PreCommitError{Check: te.preCommitCommand, Output: output}
+		}
+		outputs = append(outputs, output)
+	}
+
+	if includeHook {
+		if hook := te.preCommitHook(); hook != "" {
+			name, args := hook, []string(nil)
+			if runtime.GOOS == "windows" {
+				// Hooks are shell scripts; git for Windows runs them with its sh
+				name, args = "sh", []string{hook}
+			}
+			output, err := te.runPreCommitCheck(ctx, name, args...)
+			if err != nil {
+				return "", &PreCommitError{Check: "pre-commit hook", Output: output}
+			}
+			outputs = append(outputs, output)
+		}
+	}
+
+	return strings.TrimSpace(strings.Join(outputs, "\n")), nil
+}
+
+// runPreCommitCheck runs one check from the root, returning its combined output
+func (te *ToolExecutor) runPreCommitCheck(ctx context.Context, name string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, preCommitTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = te.rootPath
+	cmd.WaitDelay = processWaitDelay
+	setProcessGroup(cmd)
+
+	output := &commandOutput{max: maxBashOutputBytes}
+	cmd.Stdout = output
+	cmd.Stderr = output
+
+	start := time.Now()
+	err := cmd.Run()
+	result := strings.TrimSpace(output.String())
+	if ctx.Err() == context.DeadlineExceeded {
+		result = strings.TrimSpace(fmt.Sprintf("%s\n(timed out after %v)", result, preCommitTimeout))
+	}
+
+	loggy.Debug("ToolExecutor pre-commit check", "command", strings.Join(cmd.Args, " "), "duration", time.Since(start), "error", err)
+	return result, err
+}
+
+// preCommitHook returns the path of the repository's pre-commit hook, honouring
+// core.hooksPath, or "" when there is no runnable hook
+func (te *ToolExecutor) preCommitHook() string {
+	cmd := execCommand("git", "rev-parse", "--git-path", "hooks/pre-commit")
+	cmd.Dir = te.rootPath
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+
+	hook := strings.TrimSpace(string(output))
+	if !filepath.IsAbs(hook) {
+		hook = filepath.Join(te.rootPath, hook)
+	}
+
+	info, err := os.Stat(hook)
+	if err != nil || !info.Mode().IsRegular() {
+		return ""
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0o111 == 0 {
+		return "" // git ignores hooks that aren't executable
+	}
+	return hook
+}
@@ -0,0 +1,139 @@
+package tools
+
+import (
+	"fmt"
+	"github.com/tildaslashalef/bazinga/internal/loggy"
+	"github.com/tildaslashalef/bazinga/internal/project"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// gitShow shows a commit's message and diff, or a file's content at a revision
+func (te *ToolExecutor) gitShow(input map[string]interface{}) (string, error) {
+	loggy.Debug("ToolExecutor gitShow")
+
+	ref, ok := input["ref"].(string)
+	ref = strings.TrimSpace(ref)
+	if !ok || ref == "" {
+		return "", fmt.Errorf("ref is required")
+	}
+	if strings.HasPrefix(ref, "-") {
+		return "", fmt.Errorf("invalid ref %q: refs can't start with '-'", ref)
+	}
+
+	// Resolve the ref first, so a bad one gets a clear error rather than git's usage text
+	cmd := execCommand("git", "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	cmd.Dir = te.rootPath
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("unknown revision %q: not a commit, branch or tag in this repository", ref)
+	}
+	hash := strings.TrimSpace(string(output))
+
+	if filePath, ok := input["file_path"].(string); ok && filePath != "" {
+		return te.gitShowFile(ref, hash, filePath)
+	}
+
+	// Files hidden by .bazingaignore are left out of the stat and the diff
+	ignored, err := te.ignoredCommitPaths(hash)
+	if err != nil {
+		return "", err
+	}
+	args := []string{"show", "--no-color", "--no-ext-diff", "--no-renames", "--stat", "--patch", hash}
+	if len(ignored) > 0 {
+		args = append(args, "--")
+		for _, path := range ignored {
+			args = append(args, ":(top,literal,exclude)"+path)
+		}
+	}
+
+	cmd = execCommand("git", args...)
+	cmd.Dir = te.rootPath
+	output, err = cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git show failed: %w\nOutput: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	result := te.truncateGitOutput(strings.TrimSpace(string(output)), "pass file_path to see one file at this revision")
+	if len(ignored) > 0 {
+		result += fmt.Sprintf("\n\n(%d changed file(s) hidden by %s left out)", len(ignored), project.BazingaIgnoreFile)
+	}
+	return result, nil
+}
+
+// ignoredCommitPaths returns the files a commit changed that .bazingaignore hides, as
+// paths from the top of the repository
+func (te *ToolExecutor) ignoredCommitPaths(hash string) ([]string, error) {
+	if te.ignore.Load().Empty() {
+		return nil, nil
+	}
+
+	// The project may be a subdirectory of the repository; git names files from the top
+	cmd := execCommand("git", "rev-parse", "--show-prefix")
+	cmd.Dir = te.rootPath
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git rev-parse failed: %w", err)
+	}
+	prefix := strings.TrimSpace(string(output))
+
+	cmd = execCommand("git", "show", "--no-renames", "--name-only", "--format=", "-z", hash)
+	cmd.Dir = te.rootPath
+	output, err = cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git show failed: %w", err)
+	}
+
+	var ignored []string
+	for _, path := range strings.Split(string(output), "\x00") {
+		if path == "" || !strings.HasPrefix(path, prefix) {
+			continue
+		}
+		if te.isIgnored(filepath.FromSlash(strings.TrimPrefix(path, prefix)), false) && !slices.Contains(ignored, path) {
+			ignored = append(ignored, path)
+		}
+	}
+	return ignored, nil
+}
+
+// gitShowFile returns a file's content at the commit hash that ref resolved to
+func (te *ToolExecutor) gitShowFile(ref, hash, filePath string) (string, error) {
+	displayPath := te.displayPath(te.absPath(filePath))
+	if displayPath == ".." || strings.HasPrefix(displayPath, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside the project", displayPath)
+	}
+
+	// "./" makes the path relative to the project root rather than the repository's
+	cmd := execCommand("git", "show", "--no-color", hash+":./"+filepath.ToSlash(displayPath))
+	cmd.Dir = te.rootPath
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%s does not exist at %s", displayPath, ref)
+	}
+
+	shortHash := hash[:min(len(hash), 8)]
+	if isBinary(output) {
+		return binaryFileNote(fmt.Sprintf("%s at %s (%s)", displayPath, ref, shortHash), len(output)), nil
+	}
+
+	lines := strings.Count(string(output), "\n")
+	if len(output) > 0 && !strings.HasSuffix(string(output), "\n") {
+		lines++
+	}
+	content := te.truncateGitOutput(string(output), "git_show without file_path shows just what the commit changed")
+	return fmt.Sprintf("File: %s at %s (%s)\nLines: %d\nContent:\n\n%s", displayPath, ref, shortHash, lines, content), nil
+}
+
+// truncateGitOutput cuts git output to the read limit, at a line boundary, with a note
+// saying how to see less at a time
+func (te *ToolExecutor) truncateGitOutput(output, hint string) string {
+	maxBytes := te.readLimit()
+	if len(output) <= maxBytes {
+		return output
+	}
+
+	head, _ := headOfFile([]byte(output), maxBytes)
+	return fmt.Sprintf("%s\n... [truncated: output limit of %s reached, %s in total; %s]",
+		head, formatByteSize(maxBytes), formatByteSize(len(output)), hint)
+}
@@ -1,10 +1,15 @@
 package tools
 
 import (
+	"context"
+	"errors"
 	"github.com/tildaslashalef/bazinga/internal/loggy"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
 	"testing"
 )
 
@@ -39,23 +44,23 @@ func TestGitStatus(t *testing.T) {
 	}{
 		{
 			name:           "clean_repo",
-			mockOutput:     "", // Empty output results in "Working tree clean"
+			mockOutput:     "# branch.oid 4b825dc642cb6eb9a060e54bf8d69288fbee4904\n# branch.head master",
 			mockError:      false,
-			expectedResult: "Working tree clean",
+			expectedResult: "Branch: master\nWorking tree clean",
 			expectError:    false,
 		},
 		{
 			name:           "branch_only",
-			mockOutput:     "## master...origin/master",
+			mockOutput:     "# branch.head master\n# branch.upstream origin/master\n# branch.ab +0 -0",
 			mockError:      false,
-			expectedResult: "Branch: master...origin/master",
+			expectedResult: "Branch: master (upstream origin/master, ahead 0, behind 0)\nWorking tree clean",
 			expectError:    false,
 		},
 		{
 			name:           "modified_files",
-			mockOutput:     "## master...origin/master\nM  file1.go\n D file2.go\n?? file3.go",
+			mockOutput:     "# branch.head master\n1 M. N... 100644 100644 100644 abc abc file1.go\n1 .D N... 100644 100644 000000 abc abc file2.go\n? file3.go",
 			mockError:      false,
-			expectedResult: "Branch: master...origin/master\n  Modified: file1.go\n  Deleted (unstaged): file2.go\n  Untracked: file3.go",
+			expectedResult: "Branch: master\nStaged (1):\n  modified: file1.go\nModified (unstaged) (1):\n  deleted: file2.go\nUntracked (1):\n  file3.go",
 			expectError:    false,
 		},
 		{
@@ -125,6 +130,74 @@ exit 0
 	}
 }
 
+// TestParseGitStatusV2 tests parsing representative porcelain v2 output into a summary
+func TestParseGitStatusV2(t *testing.T) {
+	output := strings.Join([]string{
+		"# branch.oid 1f2e3d4c5b6a79881726354453627181909a8b7c",
+		"# branch.head feature/status",
+		"# branch.upstream origin/feature/status",
+		"# branch.ab +2 -1",
+		"1 M. N... 100644 100644 100644 3f1a2b 3f1a2b internal/tools/git.go",
+		"1 .M N... 100644 100644 100644 9c8d7e 9c8d7e README.md",
+		"1 AM N... 000000 100644 100644 000000 5a6b7c internal/tools/status.go",
+		"2 R. N... 100644 100644 100644 7e6f5a 7e6f5a R100 docs/new name.md\tdocs/old.md",
+		"u UU N... 100644 100644 100644 100644 a1 b2 c3 go.mod",
+		"? notes/todo.txt",
+		"? scratch.go",
+	}, "\n")
+
+	summary := parseGitStatusV2(output)
+
+	if summary.Branch != "feature/status" || summary.Upstream != "origin/feature/status" {
+		t.Errorf("unexpected branch %q upstream %q", summary.Branch, summary.Upstream)
+	}
+	if summary.Ahead != 2 || summary.Behind != 1 {
+		t.Errorf("expected ahead 2 behind 1, got ahead %d behind %d", summary.Ahead, summary.Behind)
+	}
+
+	expectedStaged := []gitStatusEntry{
+		{Status: "modified", Path: "internal/tools/git.go"},
+		{Status: "added", Path: "internal/tools/status.go"},
+	}
+	if !reflect.DeepEqual(summary.Staged, expectedStaged) {
+		t.Errorf("staged = %+v, want %+v", summary.Staged, expectedStaged)
+	}
+
+	expectedModified := []gitStatusEntry{
+		{Status: "modified", Path: "README.md"},
+		{Status: "modified", Path: "internal/tools/status.go"},
+	}
+	if !reflect.DeepEqual(summary.Modified, expectedModified) {
+		t.Errorf("modified = %+v, want %+v", summary.Modified, expectedModified)
+	}
+
+	expectedRenamed := []gitStatusEntry{{Status: "renamed", Path: "docs/new name.md", From: "docs/old.md"}}
+	if !reflect.DeepEqual(summary.Renamed, expectedRenamed) {
+		t.Errorf("renamed = %+v, want %+v", summary.Renamed, expectedRenamed)
+	}
+
+	if len(summary.Untracked) != 2 || summary.Untracked[0].Path != "notes/todo.txt" {
+		t.Errorf("unexpected untracked entries: %+v", summary.Untracked)
+	}
+	if len(summary.Conflicted) != 1 || summary.Conflicted[0].Path != "go.mod" {
+		t.Errorf("unexpected conflicted entries: %+v", summary.Conflicted)
+	}
+
+	formatted := summary.String()
+	for _, want := range []string{
+		"Branch: feature/status (upstream origin/feature/status, ahead 2, behind 1)",
+		"Staged (2):",
+		"Modified (unstaged) (2):",
+		"Renamed (1):\n  renamed: docs/old.md → docs/new name.md",
+		"Untracked (2):\n  notes/todo.txt\n  scratch.go",
+		"Conflicted (1):\n  conflict UU: go.mod",
+	} {
+		if !strings.Contains(formatted, want) {
+			t.Errorf("formatted summary missing %q:\n%s", want, formatted)
+		}
+	}
+}
+
 // TestGitDiff tests the gitDiff function
 func TestGitDiff(t *testing.T) {
 	// Create a temp directory to use for tests
@@ -255,3 +328,176 @@ func TestHelperProcess(t *testing.T) {
 	}
 	os.Exit(0)
 }
+
+// newGitRepo creates a repository with one committed file and returns its path
+func newGitRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"config", "user.email", "test@example.com"},
+		{"config", "user.name", "Test"},
+		{"config", "commit.gpgsign", "false"},
+	} {
+		if output, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, output)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{{"add", "main.go"}, {"commit", "-q", "-m", "initial"}} {
+		if output, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, output)
+		}
+	}
+	return dir
+}
+
+// TestGitStashAndPop tests stashing changes, reporting the ref, and popping them back
+func TestGitStashAndPop(t *testing.T) {
+	dir := newGitRepo(t)
+	te := NewToolExecutor(dir)
+
+	result, err := te.gitStash(map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("gitStash failed: %v", err)
+	}
+	if result != "No local changes to stash" {
+		t.Errorf("Expected nothing to stash in a clean tree, got %q", result)
+	}
+
+	mainPath := filepath.Join(dir, "main.go")
+	if err := os.WriteFile(mainPath, []byte("package main\n\nfunc main() {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "new.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err = te.gitStash(map[string]interface{}{"message": "try parser", "include_untracked": true})
+	if err != nil {
+		t.Fatalf("gitStash failed: %v", err)
+	}
+	if !strings.HasPrefix(result, "Stashed changes as stash@{0} (") || !strings.Contains(result, "try parser") {
+		t.Errorf("Expected the stash ref and message, got %q", result)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "new.go")); !os.IsNotExist(err) {
+		t.Error("Expected the untracked file to be stashed")
+	}
+
+	if _, err := te.gitStashPop(map[string]interface{}{"stash": "HEAD; rm -rf /"}); err == nil {
+		t.Error("Expected an invalid stash ref to be rejected")
+	}
+
+	result, err = te.gitStashPop(map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("gitStashPop failed: %v", err)
+	}
+	if !strings.HasPrefix(result, "Popped stash@{0}") {
+		t.Errorf("Unexpected pop result %q", result)
+	}
+	content, _ := os.ReadFile(mainPath)
+	if !strings.Contains(string(content), "func main") {
+		t.Error("Expected the stashed change to be back")
+	}
+}
+
+// TestGitRestore tests discarding and unstaging changes to a path
+func TestGitRestore(t *testing.T) {
+	dir := newGitRepo(t)
+	te := NewToolExecutor(dir)
+
+	var changes []FileChange
+	te.SetFileChangeCallback(func(change FileChange) {
+		changes = append(changes, change)
+	})
+
+	mainPath := filepath.Join(dir, "main.go")
+	if err := os.WriteFile(mainPath, []byte("package broken\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Unstaging leaves the working tree alone
+	if _, err := te.gitAdd(map[string]interface{}{"paths": []interface{}{"main.go"}}); err != nil {
+		t.Fatalf("gitAdd failed: %v", err)
+	}
+	result, err := te.gitRestore(map[string]interface{}{"paths": []interface{}{"main.go"}, "staged": true})
+	if err != nil {
+		t.Fatalf("gitRestore staged failed: %v", err)
+	}
+	if !strings.HasPrefix(result, "Unstaged 1 path(s)") {
+		t.Errorf("Unexpected result %q", result)
+	}
+	if content, _ := os.ReadFile(mainPath); string(content) != "package broken\n" {
+		t.Error("Expected unstaging to keep the working-tree change")
+	}
+
+	result, err = te.gitRestore(map[string]interface{}{"paths": []interface{}{"main.go"}})
+	if err != nil {
+		t.Fatalf("gitRestore failed: %v", err)
+	}
+	if result != "Restored 1 path(s) to their committed state (1 file(s) changed)" {
+		t.Errorf("Unexpected result %q", result)
+	}
+	if content, _ := os.ReadFile(mainPath); string(content) != "package main\n" {
+		t.Errorf("Expected the committed content back, got %q", content)
+	}
+	if len(changes) != 1 || changes[0].FilePath != "main.go" || changes[0].Before != "package broken\n" || changes[0].After != "package main\n" {
+		t.Errorf("Expected the discarded change to be reported, got %+v", changes)
+	}
+
+	if _, err := te.gitRestore(map[string]interface{}{}); err == nil {
+		t.Error("Expected an error without paths")
+	}
+}
+
+// TestGitCommitPreCommitChecks tests that failing pre-commit checks abort commits with
+// their output, and that skipping hooks bypasses them
+func TestGitCommitPreCommitChecks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook scripts need a POSIX shell")
+	}
+	dir := newGitRepo(t)
+	te := NewToolExecutor(dir)
+
+	hook := filepath.Join(dir, ".git", "hooks", "pre-commit")
+	if err := os.MkdirAll(filepath.Dir(hook), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(hook, []byte("#!/bin/sh\necho 'lint: main.go is not formatted'\nexit 1\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := te.gitAdd(map[string]interface{}{"paths": []interface{}{"main.go"}}); err != nil {
+		t.Fatalf("gitAdd failed: %v", err)
+	}
+
+	_, err := te.RunPreCommitChecks(context.Background(), true)
+	var preCommitErr *PreCommitError
+	if !errors.As(err, &preCommitErr) || !strings.Contains(preCommitErr.Output, "not formatted") {
+		t.Errorf("Expected the failing hook's output, got %v", err)
+	}
+
+	_, err = te.gitCommit(map[string]interface{}{"message": "format"})
+	if err == nil || !strings.Contains(err.Error(), "pre-commit hook") || !strings.Contains(err.Error(), "not formatted") {
+		t.Errorf("Expected the hook to abort the commit with its output, got %v", err)
+	}
+
+	te.SetCommitHooks("echo 'vet: unused variable' && exit 2", false)
+	_, err = te.gitCommit(map[string]interface{}{"message": "format"})
+	if !errors.As(err, &preCommitErr) || !strings.Contains(preCommitErr.Output, "unused variable") {
+		t.Errorf("Expected the configured command to abort the commit, got %v", err)
+	}
+
+	te.SetCommitHooks("exit 1", true)
+	if _, err := te.gitCommit(map[string]interface{}{"message": "format"}); err != nil {
+		t.Errorf("Expected skipped hooks to let the commit through, got %v", err)
+	}
+}
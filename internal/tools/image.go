@@ -0,0 +1,124 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"github.com/tildaslashalef/bazinga/internal/llm"
+	"github.com/tildaslashalef/bazinga/internal/loggy"
+	"image"
+	_ "image/gif"  // Register GIF for image.DecodeConfig
+	_ "image/jpeg" // Register JPEG for image.DecodeConfig
+	_ "image/png"  // Register PNG for image.DecodeConfig
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxImageBytes is the largest image a tool may return; providers reject bigger ones
+const maxImageBytes = 5 * 1024 * 1024
+
+// imageMediaTypes maps the image extensions read_file returns as images to media types
+var imageMediaTypes = map[string]string{
+	".png":  "image/png",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".gif":  "image/gif",
+	".webp": "image/webp",
+}
+
+// ToolResult is the output of a tool call: its text, plus any images it returned
+type ToolResult struct {
+	Text   string
+	Images []llm.ImageSource
+}
+
+// ExecuteToolResult executes a tool call and returns its text and images. Tools that
+// only produce text return a result without images.
+func (te *ToolExecutor) ExecuteToolResult(ctx context.Context, toolCall *llm.ToolCall) (*ToolResult, error) {
+	if toolCall.Name == "read_file" {
+		if filePath, ok := toolCall.Input["file_path"].(string); ok && isImagePath(filePath) {
+			if err := te.checkToolEnabled(toolCall.Name); err != nil {
+				return nil, err
+			}
+			if err := te.checkToolPaths(toolCall.Name, toolCall.Input); err != nil {
+				return nil, err
+			}
+			return te.readImage(filePath)
+		}
+	}
+
+	text, err := te.ExecuteTool(ctx, toolCall)
+	if err != nil {
+		return nil, err
+	}
+	return &ToolResult{Text: text}, nil
+}
+
+// isImagePath reports whether read_file returns the file as an image
+func isImagePath(path string) bool {
+	_, ok := imageMediaTypes[strings.ToLower(filepath.Ext(path))]
+	return ok
+}
+
+// readImage reads an image file and returns it with a text placeholder describing it
+func (te *ToolExecutor) readImage(filePath string) (*ToolResult, error) {
+	displayPath := filePath
+	filePath = te.absPath(filePath)
+	if relPath, err := filepath.Rel(te.rootPath, filePath); err == nil && !strings.HasPrefix(relPath, "..") {
+		displayPath = relPath
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %s: %w", filePath, err)
+	}
+	if len(data) > maxImageBytes {
+		return nil, fmt.Errorf("image %s is %s, larger than the %s limit", displayPath, formatByteSize(len(data)), formatByteSize(maxImageBytes))
+	}
+
+	// Trust the content over the extension, e.g. for a JPEG saved as .png
+	mediaType := imageMediaTypes[strings.ToLower(filepath.Ext(filePath))]
+	if detected := http.DetectContentType(data); strings.HasPrefix(detected, "image/") {
+		mediaType = detected
+	}
+
+	loggy.Info("ToolExecutor readImage success", "path", filePath, "size", len(data), "media_type", mediaType)
+
+	source := llm.ImageSource{
+		Type:      "base64",
+		MediaType: mediaType,
+		Data:      base64.StdEncoding.EncodeToString(data),
+	}
+	return &ToolResult{
+		Text:   fmt.Sprintf("Image: %s\n%s", displayPath, DescribeImage(source)),
+		Images: []llm.ImageSource{source},
+	}, nil
+}
+
+// DescribeImage returns a short placeholder for an image, e.g. "[image/png 800×600, 12.3 KB]".
+// The dimensions are left out for formats that cannot be decoded.
+func DescribeImage(source llm.ImageSource) string {
+	data, err := base64.StdEncoding.DecodeString(source.Data)
+	if err != nil {
+		return fmt.Sprintf("[%s image]", source.MediaType)
+	}
+
+	if config, _, err := image.DecodeConfig(bytes.NewReader(data)); err == nil {
+		return fmt.Sprintf("[%s %d×%d, %s]", source.MediaType, config.Width, config.Height, formatByteSize(len(data)))
+	}
+	return fmt.Sprintf("[%s, %s]", source.MediaType, formatByteSize(len(data)))
+}
+
+// formatByteSize formats a byte count for messages, e.g. "2.1 MB"
+func formatByteSize(size int) string {
+	switch {
+	case size >= 1024*1024:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1024*1024))
+	case size >= 1024:
+		return fmt.Sprintf("%.1f KB", float64(size)/1024)
+	}
+	return fmt.Sprintf("%d bytes", size)
+}
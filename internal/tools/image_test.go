@@ -0,0 +1,66 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"github.com/tildaslashalef/bazinga/internal/llm"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExecuteToolResult_ReadImage(t *testing.T) {
+	tempDir := t.TempDir()
+	var data bytes.Buffer
+	if err := png.Encode(&data, image.NewRGBA(image.Rect(0, 0, 8, 6))); err != nil {
+		t.Fatalf("Failed to encode PNG: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "shot.png"), data.Bytes(), 0o644); err != nil {
+		t.Fatalf("Failed to write image: %v", err)
+	}
+
+	te := NewToolExecutor(tempDir)
+	call := &llm.ToolCall{Name: "read_file", Input: map[string]interface{}{"file_path": "shot.png"}}
+
+	result, err := te.ExecuteToolResult(context.Background(), call)
+	if err != nil {
+		t.Fatalf("ExecuteToolResult failed: %v", err)
+	}
+	if len(result.Images) != 1 || result.Images[0].MediaType != "image/png" || result.Images[0].Type != "base64" {
+		t.Fatalf("Expected one base64 PNG image, got %+v", result.Images)
+	}
+	if !strings.Contains(result.Text, "shot.png") || !strings.Contains(result.Text, "[image/png 8×6,") {
+		t.Errorf("Expected a placeholder with the dimensions, got %q", result.Text)
+	}
+
+	// Text-only callers get the placeholder rather than the binary content
+	text, err := te.ExecuteTool(context.Background(), call)
+	if err != nil {
+		t.Fatalf("ExecuteTool failed: %v", err)
+	}
+	if text != result.Text {
+		t.Errorf("Expected ExecuteTool to return the placeholder, got %q", text)
+	}
+}
+
+func TestExecuteToolResult_TextTool(t *testing.T) {
+	tempDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tempDir, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	te := NewToolExecutor(tempDir)
+	result, err := te.ExecuteToolResult(context.Background(), &llm.ToolCall{
+		Name:  "read_file",
+		Input: map[string]interface{}{"file_path": "main.go"},
+	})
+	if err != nil {
+		t.Fatalf("ExecuteToolResult failed: %v", err)
+	}
+	if len(result.Images) != 0 || !strings.Contains(result.Text, "package main") {
+		t.Errorf("Expected a text-only result, got %+v", result)
+	}
+}
@@ -0,0 +1,428 @@
+// Package lsp is a minimal Language Server Protocol client for asking gopls where Go
+// symbols are defined and referenced.
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf16"
+)
+
+// ErrNotInstalled is returned by Start when gopls is not on the PATH
+var ErrNotInstalled = errors.New("gopls is not installed")
+
+// shutdownTimeout is how long Close waits for gopls to exit before killing it
+const shutdownTimeout = 5 * time.Second
+
+// Location is a place in a file that a query returned
+type Location struct {
+	Path   string // Absolute file path
+	Line   int    // 1-based
+	Column int    // 1-based, in bytes
+	Text   string // The source line, trimmed
+}
+
+// Client talks to one language server for one workspace
+type Client struct {
+	root   string
+	conn   *conn
+	closer io.Closer
+	cmd    *exec.Cmd // nil when connected to a stream rather than a process
+
+	mu       sync.Mutex           // Serializes queries, which each sync the workspace first
+	modTimes map[string]time.Time // Go files as the server was last told about them
+}
+
+// Start runs gopls for the workspace at root and initializes it
+func Start(ctx context.Context, root string) (*Client, error) {
+	path, err := exec.LookPath("gopls")
+	if err != nil {
+		return nil, ErrNotInstalled
+	}
+
+	// gopls outlives the call that starts it, so it isn't tied to ctx
+	cmd := exec.Command(path, "serve") //nolint:gosec // Fixed binary found on PATH
+	cmd.Dir = root
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start gopls: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start gopls: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start gopls: %w", err)
+	}
+
+	client, err := NewClient(ctx, root, stdout, stdin)
+	if err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return nil, err
+	}
+	client.cmd = cmd
+	return client, nil
+}
+
+// NewClient initializes a language server that reads from w and writes to r. Closing
+// the client closes w.
+func NewClient(ctx context.Context, root string, r io.Reader, w io.WriteCloser) (*Client, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve workspace: %w", err)
+	}
+
+	c := &Client{root: absRoot, closer: w}
+	c.conn = newConn(r, w, c.handleRequest)
+
+	params := map[string]interface{}{
+		"processId": os.Getpid(),
+		"rootUri":   pathToURI(absRoot),
+		"workspaceFolders": []map[string]string{
+			{"uri": pathToURI(absRoot), "name": filepath.Base(absRoot)},
+		},
+		"capabilities": map[string]interface{}{
+			"textDocument": map[string]interface{}{
+				"definition": map[string]interface{}{"linkSupport": true},
+				"references": map[string]interface{}{},
+			},
+			"workspace": map[string]interface{}{
+				"workspaceFolders":      true,
+				"configuration":         true,
+				"didChangeWatchedFiles": map[string]interface{}{"dynamicRegistration": false},
+			},
+		},
+	}
+	if err := c.conn.call(ctx, "initialize", params, nil); err != nil {
+		_ = w.Close()
+		return nil, fmt.Errorf("failed to initialize language server: %w", err)
+	}
+	if err := c.conn.notify("initialized", map[string]interface{}{}); err != nil {
+		_ = w.Close()
+		return nil, fmt.Errorf("failed to initialize language server: %w", err)
+	}
+
+	c.modTimes = c.scanGoFiles()
+	return c, nil
+}
+
+// Definition returns where the symbol at a 1-based line and byte column is declared
+func (c *Client) Definition(ctx context.Context, path string, line, column int) ([]Location, error) {
+	return c.query(ctx, "textDocument/definition", path, line, column, nil)
+}
+
+// References returns every use of the symbol at a 1-based line and byte column,
+// including its declaration
+func (c *Client) References(ctx context.Context, path string, line, column int) ([]Location, error) {
+	return c.query(ctx, "textDocument/references", path, line, column, map[string]interface{}{
+		"includeDeclaration": true,
+	})
+}
+
+// Alive reports whether the server is still connected
+func (c *Client) Alive() bool {
+	select {
+	case <-c.conn.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// Close shuts the server down, killing it if it doesn't exit in time
+func (c *Client) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if c.Alive() {
+		if err := c.conn.call(ctx, "shutdown", nil, nil); err == nil {
+			_ = c.conn.notify("exit", nil)
+		}
+	}
+	err := c.closer.Close()
+
+	if c.cmd != nil {
+		exited := make(chan struct{})
+		go func() {
+			_ = c.cmd.Wait()
+			close(exited)
+		}()
+		select {
+		case <-exited:
+		case <-ctx.Done():
+			_ = c.cmd.Process.Kill()
+			<-exited
+		}
+	}
+	return err
+}
+
+// query sends a position request for a file and returns the locations it answers with
+func (c *Client) query(ctx context.Context, method, path string, line, column int, extra map[string]interface{}) ([]Location, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(absPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	pos, err := toPosition(content, line, column)
+	if err != nil {
+		return nil, err
+	}
+
+	c.syncFiles()
+
+	// Open the file with its current content so the answer matches what is on disk
+	uri := pathToURI(absPath)
+	if err := c.conn.notify("textDocument/didOpen", map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": uri, "languageId": "go", "version": 1, "text": string(content)},
+	}); err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = c.conn.notify("textDocument/didClose", map[string]interface{}{
+			"textDocument": map[string]string{"uri": uri},
+		})
+	}()
+
+	params := map[string]interface{}{
+		"textDocument": map[string]string{"uri": uri},
+		"position":     pos,
+	}
+	if extra != nil {
+		params["context"] = extra
+	}
+
+	var raw json.RawMessage
+	if err := c.conn.call(ctx, method, params, &raw); err != nil {
+		return nil, err
+	}
+	return resolveLocations(raw)
+}
+
+// handleRequest answers the requests gopls sends the client
+func (c *Client) handleRequest(method string, params json.RawMessage) (interface{}, error) {
+	switch method {
+	case "workspace/configuration":
+		// No settings: one null per item asked for means gopls uses its defaults
+		var request struct {
+			Items []json.RawMessage `json:"items"`
+		}
+		_ = json.Unmarshal(params, &request)
+		return make([]interface{}, len(request.Items)), nil
+	case "workspace/workspaceFolders":
+		return []map[string]string{{"uri": pathToURI(c.root), "name": filepath.Base(c.root)}}, nil
+	default:
+		// Progress, capability registration and message requests need only an answer
+		return nil, nil
+	}
+}
+
+// syncFiles tells the server about Go files changed on disk since the last query,
+// since it only watches files the client reports
+func (c *Client) syncFiles() {
+	current := c.scanGoFiles()
+
+	type fileEvent struct {
+		URI  string `json:"uri"`
+		Type int    `json:"type"` // 1 created, 2 changed, 3 deleted
+	}
+	var events []fileEvent
+	for path, modTime := range current {
+		if previous, ok := c.modTimes[path]; !ok {
+			events = append(events, fileEvent{pathToURI(path), 1})
+		} else if !previous.Equal(modTime) {
+			events = append(events, fileEvent{pathToURI(path), 2})
+		}
+	}
+	for path := range c.modTimes {
+		if _, ok := current[path]; !ok {
+			events = append(events, fileEvent{pathToURI(path), 3})
+		}
+	}
+
+	c.modTimes = current
+	if len(events) > 0 {
+		_ = c.conn.notify("workspace/didChangeWatchedFiles", map[string]interface{}{"changes": events})
+	}
+}
+
+// scanGoFiles returns the modification time of each Go source and module file in the
+// workspace, skipping hidden and vendor directories
+func (c *Client) scanGoFiles() map[string]time.Time {
+	files := make(map[string]time.Time)
+	_ = filepath.WalkDir(c.root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil //nolint:nilerr // Skip unreadable entries
+		}
+		name := entry.Name()
+		if entry.IsDir() {
+			if path != c.root && (strings.HasPrefix(name, ".") || name == "vendor" || name == "node_modules") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasSuffix(name, ".go") || name == "go.mod" || name == "go.sum" || name == "go.work" {
+			if info, err := entry.Info(); err == nil {
+				files[path] = info.ModTime()
+			}
+		}
+		return nil
+	})
+	return files
+}
+
+// position is a zero-based LSP position; character counts UTF-16 code units
+type position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// lspRange is the span of a location
+type lspRange struct {
+	Start position `json:"start"`
+	End   position `json:"end"`
+}
+
+// lspLocation is either a Location or a LocationLink; definition answers with
+// either kind, alone or in a list
+type lspLocation struct {
+	URI                  string    `json:"uri"`
+	Range                lspRange  `json:"range"`
+	TargetURI            string    `json:"targetUri"`
+	TargetSelectionRange *lspRange `json:"targetSelectionRange"`
+}
+
+// resolveLocations converts a query result to file locations with their source lines
+func resolveLocations(raw json.RawMessage) ([]Location, error) {
+	trimmed := strings.TrimSpace(string(raw))
+	if trimmed == "" || trimmed == "null" {
+		return nil, nil
+	}
+
+	var found []lspLocation
+	if strings.HasPrefix(trimmed, "[") {
+		if err := json.Unmarshal(raw, &found); err != nil {
+			return nil, fmt.Errorf("invalid locations: %w", err)
+		}
+	} else {
+		var single lspLocation
+		if err := json.Unmarshal(raw, &single); err != nil {
+			return nil, fmt.Errorf("invalid location: %w", err)
+		}
+		found = []lspLocation{single}
+	}
+
+	contents := make(map[string][]byte)
+	seen := make(map[Location]bool)
+	var locations []Location
+	for _, loc := range found {
+		uri, start := loc.URI, loc.Range.Start
+		if loc.TargetURI != "" {
+			uri = loc.TargetURI
+			if loc.TargetSelectionRange != nil {
+				start = loc.TargetSelectionRange.Start
+			}
+		}
+
+		path, err := uriToPath(uri)
+		if err != nil {
+			continue
+		}
+		content, ok := contents[path]
+		if !ok {
+			content, _ = os.ReadFile(path)
+			contents[path] = content
+		}
+
+		location := fromPosition(content, start)
+		location.Path = path
+		if !seen[location] {
+			seen[location] = true
+			locations = append(locations, location)
+		}
+	}
+	return locations, nil
+}
+
+// toPosition converts a 1-based line and byte column in content to an LSP position
+func toPosition(content []byte, line, column int) (position, error) {
+	lines := strings.Split(string(content), "\n")
+	if line < 1 || line > len(lines) {
+		return position{}, fmt.Errorf("line %d is outside the file (1-%d)", line, len(lines))
+	}
+	text := lines[line-1]
+	if column < 1 || column > len(text)+1 {
+		return position{}, fmt.Errorf("column %d is outside line %d (1-%d)", column, line, len(text)+1)
+	}
+
+	character := 0
+	for _, r := range text[:column-1] {
+		character += utf16.RuneLen(r)
+	}
+	return position{Line: line - 1, Character: character}, nil
+}
+
+// fromPosition converts an LSP position in content to a 1-based line and byte column
+func fromPosition(content []byte, pos position) Location {
+	location := Location{Line: pos.Line + 1, Column: pos.Character + 1}
+
+	lines := strings.Split(string(content), "\n")
+	if pos.Line < 0 || pos.Line >= len(lines) {
+		return location
+	}
+	text := lines[pos.Line]
+	location.Text = strings.TrimSpace(text)
+
+	units := 0
+	for offset, r := range text {
+		if units >= pos.Character {
+			location.Column = offset + 1
+			return location
+		}
+		units += utf16.RuneLen(r)
+	}
+	location.Column = len(text) + 1
+	return location
+}
+
+// pathToURI returns the file:// URI of an absolute path
+func pathToURI(path string) string {
+	path = filepath.ToSlash(path)
+	if !strings.HasPrefix(path, "/") {
+		// Windows paths start with a drive letter
+		path = "/" + path
+	}
+	return (&url.URL{Scheme: "file", Path: path}).String()
+}
+
+// uriToPath returns the path of a file:// URI
+func uriToPath(uri string) (string, error) {
+	parsed, err := url.Parse(uri)
+	if err != nil || parsed.Scheme != "file" {
+		return "", fmt.Errorf("not a file URI: %s", uri)
+	}
+	path := parsed.Path
+	if runtime.GOOS == "windows" {
+		path = strings.TrimPrefix(path, "/")
+	}
+	return filepath.FromSlash(path), nil
+}
@@ -0,0 +1,146 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// startFakeServer connects a client to an in-process server that answers with handle
+func startFakeServer(t *testing.T, root string, handle handler) *Client {
+	t.Helper()
+
+	clientReader, serverWriter := io.Pipe()
+	serverReader, clientWriter := io.Pipe()
+	newConn(serverReader, serverWriter, handle)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client, err := NewClient(ctx, root, clientReader, clientWriter)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = client.Close()
+		_ = serverWriter.Close()
+	})
+	return client
+}
+
+func TestClientQueries(t *testing.T) {
+	root := t.TempDir()
+	source := "package main\n\n// héllo 🙂\nfunc greet() string { return \"hi\" }\n\nfunc main() { _ = greet() }\n"
+	path := filepath.Join(root, "main.go")
+	if err := os.WriteFile(path, []byte(source), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	uri := pathToURI(path)
+
+	var asked position
+	client := startFakeServer(t, root, func(method string, params json.RawMessage) (interface{}, error) {
+		switch method {
+		case "initialize":
+			return map[string]interface{}{"capabilities": map[string]interface{}{}}, nil
+		case "textDocument/definition":
+			var request struct {
+				Position position `json:"position"`
+			}
+			_ = json.Unmarshal(params, &request)
+			asked = request.Position
+			// A LocationLink pointing at the name in the declaration
+			return []map[string]interface{}{{
+				"targetUri":            uri,
+				"targetRange":          lspRange{Start: position{3, 0}, End: position{3, 36}},
+				"targetSelectionRange": lspRange{Start: position{3, 5}, End: position{3, 10}},
+			}}, nil
+		case "textDocument/references":
+			return []lspLocation{
+				{URI: uri, Range: lspRange{Start: position{3, 5}}},
+				{URI: uri, Range: lspRange{Start: position{5, 18}}},
+				{URI: uri, Range: lspRange{Start: position{5, 18}}},
+			}, nil
+		case "textDocument/hover":
+			return nil, fmt.Errorf("unsupported")
+		}
+		return nil, nil
+	})
+
+	ctx := context.Background()
+	definition, err := client.Definition(ctx, path, 6, 19)
+	if err != nil {
+		t.Fatalf("Definition failed: %v", err)
+	}
+	if asked != (position{5, 18}) {
+		t.Errorf("Expected the query at 5:18, got %+v", asked)
+	}
+	want := Location{Path: path, Line: 4, Column: 6, Text: `func greet() string { return "hi" }`}
+	if len(definition) != 1 || definition[0] != want {
+		t.Errorf("Expected %+v, got %+v", want, definition)
+	}
+
+	references, err := client.References(ctx, path, 4, 6)
+	if err != nil {
+		t.Fatalf("References failed: %v", err)
+	}
+	if len(references) != 2 || references[1].Line != 6 || references[1].Column != 19 {
+		t.Errorf("Expected two distinct references, got %+v", references)
+	}
+
+	if _, err := client.Definition(ctx, path, 40, 1); err == nil {
+		t.Error("Expected an error for a line outside the file")
+	}
+
+	if _, err := client.query(ctx, "textDocument/hover", path, 1, 1, nil); err == nil {
+		t.Error("Expected the server's error to be returned")
+	}
+
+	if !client.Alive() {
+		t.Error("Expected the client to be connected")
+	}
+}
+
+func TestPositionConversion(t *testing.T) {
+	content := []byte("first\n// héllo 🙂 x\n")
+
+	// "// héllo 🙂 " is 15 bytes but 12 UTF-16 units ("é" is one unit, the emoji two)
+	pos, err := toPosition(content, 2, 16)
+	if err != nil {
+		t.Fatalf("toPosition failed: %v", err)
+	}
+	if pos != (position{Line: 1, Character: 12}) {
+		t.Errorf("Expected 1:12, got %+v", pos)
+	}
+
+	location := fromPosition(content, pos)
+	if location.Line != 2 || location.Column != 16 || location.Text != "// héllo 🙂 x" {
+		t.Errorf("Expected to convert back to 2:16, got %+v", location)
+	}
+
+	if _, err := toPosition(content, 1, 10); err == nil {
+		t.Error("Expected an error for a column past the end of the line")
+	}
+}
+
+func TestURIConversion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dir with space", "a.go")
+	got, err := uriToPath(pathToURI(path))
+	if err != nil || got != path {
+		t.Errorf("Expected %s to round-trip, got %s (%v)", path, got, err)
+	}
+	if _, err := uriToPath("https://example.com/a.go"); err == nil {
+		t.Error("Expected an error for a non-file URI")
+	}
+}
+
+func TestStartWithoutGopls(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	if _, err := Start(context.Background(), t.TempDir()); err != ErrNotInstalled {
+		t.Errorf("Expected ErrNotInstalled, got %v", err)
+	}
+}
@@ -0,0 +1,217 @@
+package lsp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// handler answers a request the other side sends; its result is sent back as the reply
+type handler func(method string, params json.RawMessage) (interface{}, error)
+
+// message is any JSON-RPC message read from the stream
+type message struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Method string          `json:"method,omitempty"`
+	Params json.RawMessage `json:"params,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *responseError  `json:"error,omitempty"`
+}
+
+// request is an outgoing request or notification (no ID)
+type request struct {
+	JSONRPC string      `json:"jsonrpc"`
+	ID      *int64      `json:"id,omitempty"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
+// response is an outgoing reply; result is always present, as null when empty
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result"`
+	Error   *responseError  `json:"error,omitempty"`
+}
+
+// responseError is the error of a failed request
+type responseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *responseError) Error() string {
+	return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+}
+
+// conn is a JSON-RPC 2.0 connection framed with Content-Length headers, as LSP uses
+type conn struct {
+	writer  io.Writer
+	writeMu sync.Mutex
+
+	handle handler
+
+	mu      sync.Mutex
+	nextID  int64
+	pending map[int64]chan *message
+	done    chan struct{}
+	err     error // Why the read loop stopped, set before done is closed
+}
+
+// newConn starts reading messages from r; requests from the other side go to handle
+func newConn(r io.Reader, w io.Writer, handle handler) *conn {
+	c := &conn{
+		writer:  w,
+		handle:  handle,
+		pending: make(map[int64]chan *message),
+		done:    make(chan struct{}),
+	}
+	go c.readLoop(bufio.NewReader(r))
+	return c
+}
+
+// call sends a request and decodes its result into result, which may be nil
+func (c *conn) call(ctx context.Context, method string, params, result interface{}) error {
+	c.mu.Lock()
+	c.nextID++
+	id := c.nextID
+	reply := make(chan *message, 1)
+	c.pending[id] = reply
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+	}()
+
+	if err := c.write(request{JSONRPC: "2.0", ID: &id, Method: method, Params: params}); err != nil {
+		return err
+	}
+
+	select {
+	case msg := <-reply:
+		if msg.Error != nil {
+			return fmt.Errorf("%s: %w", method, msg.Error)
+		}
+		if result == nil || len(msg.Result) == 0 {
+			return nil
+		}
+		if err := json.Unmarshal(msg.Result, result); err != nil {
+			return fmt.Errorf("%s: invalid result: %w", method, err)
+		}
+		return nil
+	case <-c.done:
+		return fmt.Errorf("%s: connection closed: %w", method, c.err)
+	case <-ctx.Done():
+		// Tell the server to stop working on it; the reply is dropped when it comes
+		_ = c.notify("$/cancelRequest", map[string]int64{"id": id})
+		return ctx.Err()
+	}
+}
+
+// notify sends a notification, which has no reply
+func (c *conn) notify(method string, params interface{}) error {
+	return c.write(request{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+// write sends one framed message
+func (c *conn) write(msg interface{}) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if _, err := fmt.Fprintf(c.writer, "Content-Length: %d\r\n\r\n", len(body)); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	if _, err := c.writer.Write(body); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	return nil
+}
+
+// readLoop delivers replies to their callers and answers requests until the stream ends
+func (c *conn) readLoop(r *bufio.Reader) {
+	for {
+		msg, err := readMessage(r)
+		if err != nil {
+			c.err = err
+			close(c.done)
+			return
+		}
+
+		switch {
+		case msg.Method != "" && len(msg.ID) > 0:
+			go c.reply(msg)
+		case msg.Method != "":
+			// Notifications such as diagnostics and log messages aren't needed
+		default:
+			id, err := strconv.ParseInt(string(msg.ID), 10, 64)
+			if err != nil {
+				continue
+			}
+			c.mu.Lock()
+			reply, ok := c.pending[id]
+			c.mu.Unlock()
+			if ok {
+				reply <- msg
+			}
+		}
+	}
+}
+
+// reply answers a request from the other side
+func (c *conn) reply(msg *message) {
+	resp := response{JSONRPC: "2.0", ID: msg.ID}
+	if c.handle == nil {
+		resp.Error = &responseError{Code: -32601, Message: "method not found: " + msg.Method}
+	} else if result, err := c.handle(msg.Method, msg.Params); err != nil {
+		resp.Error = &responseError{Code: -32603, Message: err.Error()}
+	} else {
+		resp.Result = result
+	}
+	_ = c.write(resp)
+}
+
+// readMessage reads one framed message
+func readMessage(r *bufio.Reader) (*message, error) {
+	length := -1
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		}
+		if name, value, ok := strings.Cut(line, ":"); ok && strings.EqualFold(strings.TrimSpace(name), "Content-Length") {
+			if length, err = strconv.Atoi(strings.TrimSpace(value)); err != nil {
+				return nil, fmt.Errorf("invalid Content-Length %q", value)
+			}
+		}
+	}
+	if length < 0 {
+		return nil, fmt.Errorf("message without Content-Length")
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+
+	var msg message
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return nil, fmt.Errorf("invalid message: %w", err)
+	}
+	return &msg, nil
+}
@@ -0,0 +1,282 @@
+package tools
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/tildaslashalef/bazinga/internal/llm"
+	"github.com/tildaslashalef/bazinga/internal/loggy"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	// maxOutlineEntries caps how many symbols an outline lists
+	maxOutlineEntries = 400
+
+	// maxOutlineNames caps how many names a const or var group lists
+	maxOutlineNames = 8
+
+	// maxOutlineSignature caps the length of a signature or type in the outline
+	maxOutlineSignature = 160
+)
+
+// heuristicOutlineLine matches lines that declare a class, function, type or similar in
+// languages other than Go, at any indentation so methods are included
+var heuristicOutlineLine = regexp.MustCompile(`^\s*(?:(?:export|default|public|private|protected|internal|static|abstract|final|sealed|async|override|virtual|extern|unsafe|pub(?:\([a-z]+\))?|open|data)\s+)*(?:class|def|function|func|fn|interface|struct|enum|impl|trait|type|module|namespace|object|record|protocol|extension|mod)(?:\s+[\w$<]|<)`)
+
+// markdownHeading matches a markdown heading
+var markdownHeading = regexp.MustCompile(`^#{1,6} \S`)
+
+// astOutlineTool describes the ast_outline tool
+func astOutlineTool() llm.Tool {
+	return llm.Tool{
+		Name:        "ast_outline",
+		Description: "Get the structure of a file without reading it whole: for Go the package, imports, types, functions and methods with their signatures and line ranges; for other languages the lines that declare classes, functions and types, found heuristically. Use it before reading a large file, then read_file with offset and limit for the parts you need.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"file_path": map[string]interface{}{
+					"type":        "string",
+					"description": "The path of the file to outline",
+				},
+			},
+			"required": []string{"file_path"},
+		},
+	}
+}
+
+// astOutline returns the outline of a file
+func (te *ToolExecutor) astOutline(input map[string]interface{}) (string, error) {
+	filePath, ok := input["file_path"].(string)
+	if !ok || filePath == "" {
+		return "", fmt.Errorf("file_path is required")
+	}
+	filePath = te.absPath(filePath)
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file %s: %w", filePath, err)
+	}
+	displayPath := te.displayPath(filePath)
+	if isBinary(content) {
+		return binaryFileNote(displayPath, len(content)), nil
+	}
+
+	lines := bytes.Count(content, []byte("\n"))
+	if len(content) > 0 && !bytes.HasSuffix(content, []byte("\n")) {
+		lines++
+	}
+
+	var entries []string
+	kind := "heuristic"
+	if strings.EqualFold(filepath.Ext(filePath), ".go") {
+		if entries, err = goOutline(content); err == nil {
+			kind = "Go"
+		} else {
+			loggy.Debug("ToolExecutor astOutline could not parse Go file, using heuristic outline", "path", filePath, "error", err)
+		}
+	}
+	if kind == "heuristic" {
+		entries = heuristicOutline(content, filepath.Ext(filePath))
+	}
+
+	loggy.Debug("ToolExecutor astOutline", "path", filePath, "kind", kind, "entries", len(entries))
+
+	var result strings.Builder
+	fmt.Fprintf(&result, "File: %s\nLines: %d\n", displayPath, lines)
+	if len(entries) == 0 {
+		result.WriteString("No symbols found; use read_file to see the content")
+		return result.String(), nil
+	}
+	if len(entries) > maxOutlineEntries {
+		entries = append(entries[:maxOutlineEntries], fmt.Sprintf("... %d more", len(entries)-maxOutlineEntries))
+	}
+	if kind == "Go" {
+		result.WriteString("Outline (Go, line ranges):\n")
+	} else {
+		result.WriteString("Outline (heuristic, start lines):\n")
+	}
+	result.WriteString(strings.Join(entries, "\n"))
+	return result.String(), nil
+}
+
+// goOutline lists the declarations of a Go file in source order, methods nested under
+// their receiver's type when it is declared in the same file
+func goOutline(content []byte) ([]string, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", content, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+
+	lineRange := func(node ast.Node) string {
+		start, end := fset.Position(node.Pos()).Line, fset.Position(node.End()).Line
+		if start == end {
+			return fmt.Sprintf("%d", start)
+		}
+		return fmt.Sprintf("%d-%d", start, end)
+	}
+	entry := func(node ast.Node, indent, text string) string {
+		return fmt.Sprintf("%-9s %s%s", lineRange(node), indent, text)
+	}
+
+	// Methods are listed after their type; those whose type is elsewhere stay in place
+	declaredTypes := make(map[string]bool)
+	for _, decl := range file.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.TYPE {
+			for _, spec := range gen.Specs {
+				declaredTypes[spec.(*ast.TypeSpec).Name.Name] = true
+			}
+		}
+	}
+	methods := make(map[string][]string)
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv != nil {
+			if recv := receiverType(fn); declaredTypes[recv] {
+				methods[recv] = append(methods[recv], entry(fn, "  ", funcSignature(fset, fn)))
+			}
+		}
+	}
+
+	entries := []string{entry(file.Name, "", "package "+file.Name.Name)}
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if decl.Recv != nil && declaredTypes[receiverType(decl)] {
+				continue
+			}
+			entries = append(entries, entry(decl, "", funcSignature(fset, decl)))
+
+		case *ast.GenDecl:
+			switch decl.Tok {
+			case token.IMPORT:
+				var paths []string
+				for _, spec := range decl.Specs {
+					paths = append(paths, strings.Trim(spec.(*ast.ImportSpec).Path.Value, "\"`"))
+				}
+				entries = append(entries, entry(decl, "", "import "+strings.Join(paths, ", ")))
+
+			case token.TYPE:
+				for _, spec := range decl.Specs {
+					spec := spec.(*ast.TypeSpec)
+					entries = append(entries, entry(spec, "", typeSummary(fset, spec)))
+					entries = append(entries, methods[spec.Name.Name]...)
+				}
+
+			case token.CONST, token.VAR:
+				var names []string
+				for _, spec := range decl.Specs {
+					for _, name := range spec.(*ast.ValueSpec).Names {
+						names = append(names, name.Name)
+					}
+				}
+				text := decl.Tok.String() + " " + strings.Join(names, ", ")
+				if len(names) > maxOutlineNames {
+					text = fmt.Sprintf("%s %s, ... (%d names)", decl.Tok, strings.Join(names[:maxOutlineNames], ", "), len(names))
+				}
+				entries = append(entries, entry(decl, "", text))
+			}
+		}
+	}
+	return entries, nil
+}
+
+// receiverType returns the name of a method's receiver type, without pointer or type
+// parameters
+func receiverType(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return ""
+	}
+	expr := fn.Recv.List[0].Type
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	switch t := expr.(type) {
+	case *ast.IndexExpr:
+		expr = t.X
+	case *ast.IndexListExpr:
+		expr = t.X
+	}
+	if ident, ok := expr.(*ast.Ident); ok {
+		return ident.Name
+	}
+	return ""
+}
+
+// funcSignature renders a function's declaration without its body
+func funcSignature(fset *token.FileSet, fn *ast.FuncDecl) string {
+	return shortenSignature(printNode(fset, &ast.FuncDecl{Recv: fn.Recv, Name: fn.Name, Type: fn.Type}))
+}
+
+// typeSummary renders a type declaration, naming the kind of struct and interface
+// types rather than listing their fields
+func typeSummary(fset *token.FileSet, spec *ast.TypeSpec) string {
+	text := "type " + spec.Name.Name
+	if spec.TypeParams != nil {
+		var params []string
+		for _, field := range spec.TypeParams.List {
+			var names []string
+			for _, name := range field.Names {
+				names = append(names, name.Name)
+			}
+			params = append(params, strings.Join(names, ", ")+" "+printNode(fset, field.Type))
+		}
+		text += "[" + strings.Join(params, ", ") + "]"
+	}
+	if spec.Assign.IsValid() {
+		text += " ="
+	}
+
+	switch spec.Type.(type) {
+	case *ast.StructType:
+		return text + " struct"
+	case *ast.InterfaceType:
+		return text + " interface"
+	default:
+		return shortenSignature(text + " " + printNode(fset, spec.Type))
+	}
+}
+
+// printNode renders a syntax node on one line
+func printNode(fset *token.FileSet, node ast.Node) string {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, fset, node); err != nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(buf.String()), " ")
+}
+
+// shortenSignature cuts an overly long signature down
+func shortenSignature(text string) string {
+	if len(text) <= maxOutlineSignature {
+		return text
+	}
+	return strings.ToValidUTF8(text[:maxOutlineSignature], "") + "..."
+}
+
+// heuristicOutline lists the lines that look like declarations, or headings in
+// markdown, keeping their indentation so nesting shows
+func heuristicOutline(content []byte, ext string) []string {
+	pattern := heuristicOutlineLine
+	if ext = strings.ToLower(ext); ext == ".md" || ext == ".markdown" {
+		pattern = markdownHeading
+	}
+
+	var entries []string
+	for i, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimRight(strings.ReplaceAll(line, "\t", "    "), " \r")
+		if !pattern.MatchString(line) {
+			continue
+		}
+		trimmed := strings.TrimLeft(line, " ")
+		indent := strings.Repeat(" ", (len(line)-len(trimmed))/2)
+		entries = append(entries, fmt.Sprintf("%-9d %s%s", i+1, indent, shortenSignature(strings.TrimRight(trimmed, " {:"))))
+	}
+	return entries
+}
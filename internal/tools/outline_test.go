@@ -0,0 +1,123 @@
+package tools
+
+import (
+	"context"
+	"github.com/tildaslashalef/bazinga/internal/llm"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestToolExecutor_ASTOutlineGo(t *testing.T) {
+	tempDir := t.TempDir()
+	source := `package store
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	DefaultLimit = 10
+	maxLimit     = 100
+)
+
+// Store keeps items
+type Store[K comparable, V any] struct {
+	items map[K]V
+}
+
+func (s *Store[K, V]) Get(ctx context.Context, key K) (V, bool) {
+	v, ok := s.items[key]
+	return v, ok
+}
+
+type Handler func(string) error
+
+func New[K comparable, V any]() *Store[K, V] {
+	return &Store[K, V]{items: make(map[K]V)}
+}
+
+func (s *Store[K, V]) String() string {
+	return fmt.Sprint(len(s.items))
+}
+`
+	if err := os.WriteFile(filepath.Join(tempDir, "store.go"), []byte(source), 0o644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	te := NewToolExecutor(tempDir)
+	result, err := te.ExecuteTool(context.Background(), &llm.ToolCall{
+		Name:  "ast_outline",
+		Input: map[string]interface{}{"file_path": "store.go"},
+	})
+	if err != nil {
+		t.Fatalf("ast_outline failed: %v", err)
+	}
+
+	want := []string{
+		"File: store.go\nLines: 31\nOutline (Go, line ranges):",
+		"1         package store",
+		"3-6       import context, fmt",
+		"8-11      const DefaultLimit, maxLimit",
+		"14-16     type Store[K comparable, V any] struct",
+		"18-21       func (s *Store[K, V]) Get(ctx context.Context, key K) (V, bool)",
+		"29-31       func (s *Store[K, V]) String() string",
+		"23        type Handler func(string) error",
+		"25-27     func New[K comparable, V any]() *Store[K, V]",
+	}
+	if result != strings.Join(want, "\n") {
+		t.Errorf("Unexpected outline:\n%s\nwant:\n%s", result, strings.Join(want, "\n"))
+	}
+}
+
+func TestToolExecutor_ASTOutlineHeuristic(t *testing.T) {
+	tempDir := t.TempDir()
+	source := `import os
+
+class Loader:
+    def __init__(self, path):
+        self.path = path
+
+    async def load(self):
+        return open(self.path).read()
+
+def main():
+    type(Loader)
+`
+	if err := os.WriteFile(filepath.Join(tempDir, "loader.py"), []byte(source), 0o644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	te := NewToolExecutor(tempDir)
+	result, err := te.astOutline(map[string]interface{}{"file_path": "loader.py"})
+	if err != nil {
+		t.Fatalf("ast_outline failed: %v", err)
+	}
+
+	want := "File: loader.py\nLines: 11\nOutline (heuristic, start lines):\n" +
+		"3         class Loader\n" +
+		"4           def __init__(self, path)\n" +
+		"7           async def load(self)\n" +
+		"10        def main()"
+	if result != want {
+		t.Errorf("Unexpected outline:\n%s\nwant:\n%s", result, want)
+	}
+}
+
+func TestToolExecutor_ASTOutlineInvalidGo(t *testing.T) {
+	tempDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tempDir, "broken.go"), []byte("package broken\n\nfunc Half(\n"), 0o644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	te := NewToolExecutor(tempDir)
+	result, err := te.astOutline(map[string]interface{}{"file_path": "broken.go"})
+	if err != nil {
+		t.Fatalf("ast_outline failed: %v", err)
+	}
+	if !strings.Contains(result, "Outline (heuristic, start lines):\n3         func Half(") {
+		t.Errorf("Expected the heuristic outline for a file that doesn't parse, got:\n%s", result)
+	}
+}
@@ -0,0 +1,438 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxPatchFuzz is how many context lines at each end of a hunk may be ignored when
+// the full context no longer matches, as GNU patch does with its fuzz factor
+const maxPatchFuzz = 2
+
+// devNull marks the missing side of a created or deleted file in a unified diff
+const devNull = "/dev/null"
+
+var hunkHeaderPattern = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// filePatch is the part of a unified diff that changes one file
+type filePatch struct {
+	oldPath string // devNull for a new file
+	newPath string // devNull for a deleted file
+	hunks   []*patchHunk
+}
+
+// path returns the file the patch applies to
+func (fp *filePatch) path() string {
+	if fp.newPath == devNull {
+		return fp.oldPath
+	}
+	return fp.newPath
+}
+
+// patchHunk is one @@ section of a file patch
+type patchHunk struct {
+	header   string
+	oldStart int      // 1-based line the hunk starts at in the original file
+	lines    []string // Hunk body, each line prefixed with ' ', '-' or '+'
+}
+
+// oldLines returns the lines the hunk expects to find: its context and removed lines
+func (h *patchHunk) oldLines() []string {
+	var lines []string
+	for _, line := range h.lines {
+		if line[0] != '+' {
+			lines = append(lines, line[1:])
+		}
+	}
+	return lines
+}
+
+// text returns the hunk as it appeared in the patch, for reporting a rejection
+func (h *patchHunk) text() string {
+	return h.header + "\n" + strings.Join(h.lines, "\n")
+}
+
+// parsePatch splits a unified diff into per-file patches. Line counts in hunk headers
+// are ignored because models often get them wrong; a hunk runs until the next header.
+func parsePatch(patch string) ([]*filePatch, error) {
+	var files []*filePatch
+	var current *filePatch
+	var hunk *patchHunk
+
+	lines := strings.Split(strings.ReplaceAll(patch, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		switch {
+		case strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ "):
+			current = &filePatch{
+				oldPath: patchPath(line[4:], "a/"),
+				newPath: patchPath(lines[i+1][4:], "b/"),
+			}
+			files = append(files, current)
+			hunk = nil
+			i++
+
+		case strings.HasPrefix(line, "@@"):
+			if current == nil {
+				return nil, fmt.Errorf("hunk %q comes before any --- / +++ file header", line)
+			}
+			match := hunkHeaderPattern.FindStringSubmatch(line)
+			if match == nil {
+				return nil, fmt.Errorf("malformed hunk header %q; expected @@ -start,count +start,count @@", line)
+			}
+			oldStart, _ := strconv.Atoi(match[1])
+			hunk = &patchHunk{header: line, oldStart: oldStart}
+			current.hunks = append(current.hunks, hunk)
+
+		case hunk != nil && line != "" && strings.ContainsRune(" -+", rune(line[0])):
+			hunk.lines = append(hunk.lines, line)
+
+		case hunk != nil && line == "":
+			// Editors and models often strip the space from blank context lines
+			hunk.lines = append(hunk.lines, " ")
+
+		case strings.HasPrefix(line, `\`):
+			// "\ No newline at end of file"
+
+		default:
+			// diff --git, index and mode lines, or text around the diff
+			hunk = nil
+		}
+	}
+
+	for _, fp := range files {
+		// Blank lines after the last hunk are padding, not context
+		for _, h := range fp.hunks {
+			for len(h.lines) > 0 && h.lines[len(h.lines)-1] == " " {
+				h.lines = h.lines[:len(h.lines)-1]
+			}
+		}
+		if fp.path() == devNull || fp.path() == "" {
+			return nil, fmt.Errorf("patch has a file header without a file path")
+		}
+		if len(fp.hunks) == 0 {
+			return nil, fmt.Errorf("patch for %s has no hunks", fp.path())
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no file patches found; expected a unified diff with --- / +++ headers and @@ hunks")
+	}
+
+	return files, nil
+}
+
+// patchPath cleans a path from a --- or +++ line, dropping any timestamp and the
+// a/ or b/ prefix git adds
+func patchPath(raw, prefix string) string {
+	path, _, _ := strings.Cut(raw, "\t")
+	path = strings.TrimSpace(path)
+	if path == devNull {
+		return path
+	}
+	return strings.TrimPrefix(path, prefix)
+}
+
+// PatchFiles returns the files a unified diff changes, for permission prompts. A
+// patch that cannot be parsed yields no files.
+func PatchFiles(patch string) []string {
+	files, err := parsePatch(patch)
+	if err != nil {
+		return nil
+	}
+
+	paths := make([]string, len(files))
+	for i, fp := range files {
+		paths[i] = fp.path()
+	}
+	return paths
+}
+
+// hunkResult records how one hunk was applied
+type hunkResult struct {
+	applied bool
+	line    int // 1-based line the hunk was applied at
+	fuzz    int // Context lines ignored at each end
+	loose   bool
+	reason  string
+}
+
+// applyHunks applies a file patch's hunks to content in order. Hunks that cannot be
+// placed are skipped and reported; the rest are still applied.
+func applyHunks(content string, hunks []*patchHunk) (string, []hunkResult) {
+	trailingNewline := content == "" || strings.HasSuffix(content, "\n")
+	var lines []string
+	if content != "" {
+		lines = strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	}
+
+	results := make([]hunkResult, len(hunks))
+	cursor := 0 // Hunks apply in order, so each starts after the previous one
+	offset := 0 // How far applied hunks have shifted the original line numbers
+
+	for i, h := range hunks {
+		expected := max(0, h.oldStart-1+offset)
+		if len(h.oldLines()) == 0 {
+			// Pure insertion: the header line is the only anchor. A start of 0 means
+			// the hunk goes at the top, otherwise it follows the given line.
+			at := min(max(h.oldStart+offset, cursor), len(lines))
+			var written int
+			lines, written = spliceHunk(lines, at, h.lines)
+			results[i] = hunkResult{applied: true, line: at + 1}
+			offset += written
+			cursor = at + written
+			continue
+		}
+
+		found := false
+		for fuzz := 0; fuzz <= maxPatchFuzz && !found; fuzz++ {
+			body, trimmedTop, ok := trimContext(h.lines, fuzz)
+			if !ok {
+				break
+			}
+			for _, loose := range []bool{false, true} {
+				at, ok := findHunk(lines, oldLinesOf(body), cursor, expected+trimmedTop, loose)
+				if !ok {
+					continue
+				}
+				var written int
+				lines, written = spliceHunk(lines, at, body)
+				results[i] = hunkResult{applied: true, line: at + 1, fuzz: fuzz, loose: loose}
+				offset += at - (expected + trimmedTop) + written - len(oldLinesOf(body))
+				cursor = at + written
+				found = true
+				break
+			}
+		}
+		if !found {
+			results[i] = hunkResult{reason: fmt.Sprintf("its context was not found at or after line %d", cursor+1)}
+		}
+	}
+
+	if len(lines) == 0 {
+		return "", results
+	}
+	result := strings.Join(lines, "\n")
+	if trailingNewline {
+		result += "\n"
+	}
+	return result, results
+}
+
+// trimContext drops up to fuzz context lines from each end of a hunk body. It fails
+// once that would leave no context to anchor the hunk.
+func trimContext(body []string, fuzz int) ([]string, int, bool) {
+	top := 0
+	for top < fuzz && top < len(body) && body[top][0] == ' ' {
+		top++
+	}
+	bottom := len(body)
+	for len(body)-bottom < fuzz && bottom > top && body[bottom-1][0] == ' ' {
+		bottom--
+	}
+	if fuzz > 0 && top == 0 && bottom == len(body) {
+		return nil, 0, false // Nothing left to trim
+	}
+	if len(oldLinesOf(body[top:bottom])) == 0 {
+		return nil, 0, false
+	}
+	return body[top:bottom], top, true
+}
+
+// oldLinesOf returns the context and removed lines of a hunk body
+func oldLinesOf(body []string) []string {
+	return (&patchHunk{lines: body}).oldLines()
+}
+
+// findHunk finds where old appears in lines at or after from, choosing the match
+// closest to the expected line. A loose match ignores differences in whitespace.
+func findHunk(lines, old []string, from, expected int, loose bool) (int, bool) {
+	best, bestDistance := -1, 0
+	for at := from; at+len(old) <= len(lines); at++ {
+		if !linesMatch(lines[at:at+len(old)], old, loose) {
+			continue
+		}
+		distance := at - expected
+		if distance < 0 {
+			distance = -distance
+		}
+		if best == -1 || distance < bestDistance {
+			best, bestDistance = at, distance
+		}
+	}
+	return best, best != -1
+}
+
+// linesMatch compares file lines with hunk lines
+func linesMatch(lines, old []string, loose bool) bool {
+	for i := range old {
+		if lines[i] == old[i] {
+			continue
+		}
+		if !loose || strings.Join(strings.Fields(lines[i]), " ") != strings.Join(strings.Fields(old[i]), " ") {
+			return false
+		}
+	}
+	return true
+}
+
+// spliceHunk applies a hunk body at line at, keeping the file's own context lines so
+// a loose match does not rewrite their whitespace. It returns the new lines and how
+// many lines the hunk wrote in place of the ones it matched.
+func spliceHunk(lines []string, at int, body []string) ([]string, int) {
+	result := make([]string, 0, len(lines)+len(body))
+	result = append(result, lines[:at]...)
+
+	pos := at
+	for _, line := range body {
+		switch line[0] {
+		case ' ':
+			result = append(result, lines[pos])
+			pos++
+		case '-':
+			pos++
+		case '+':
+			result = append(result, line[1:])
+		}
+	}
+
+	written := len(result) - at
+	return append(result, lines[pos:]...), written
+}
+
+// applyPatch applies a unified diff to one or more files
+func (te *ToolExecutor) applyPatch(input map[string]interface{}) (string, error) {
+	patch, ok := input["patch"].(string)
+	if !ok || strings.TrimSpace(patch) == "" {
+		return "", fmt.Errorf("patch is required")
+	}
+
+	files, err := parsePatch(patch)
+	if err != nil {
+		return "", fmt.Errorf("invalid patch: %w", err)
+	}
+
+	var report strings.Builder
+	appliedFiles, appliedHunks, totalHunks := 0, 0, 0
+	for _, fp := range files {
+		totalHunks += len(fp.hunks)
+		summary, applied, err := te.applyFilePatch(fp)
+		if err != nil {
+			fmt.Fprintf(&report, "✗ %s: %v\n", fp.path(), err)
+			continue
+		}
+		report.WriteString(summary)
+		appliedHunks += applied
+		if applied > 0 {
+			appliedFiles++
+		}
+	}
+
+	if appliedHunks == 0 {
+		return "", fmt.Errorf("no hunks could be applied; nothing was changed\n%s", strings.TrimRight(report.String(), "\n"))
+	}
+
+	header := fmt.Sprintf("Applied %d of %d hunks to %d file(s)\n", appliedHunks, totalHunks, appliedFiles)
+	return header + strings.TrimRight(report.String(), "\n"), nil
+}
+
+// applyFilePatch applies one file's hunks, writes the result and reports which hunks
+// applied and which were rejected
+func (te *ToolExecutor) applyFilePatch(fp *filePatch) (string, int, error) {
+	filePath := te.absPath(fp.path())
+	displayPath := fp.path()
+
+	before := ""
+	content, err := os.ReadFile(filePath)
+	switch {
+	case err == nil:
+		before = string(content)
+		if fp.oldPath == devNull && before != "" {
+			return "", 0, fmt.Errorf("the patch creates this file but it already exists")
+		}
+	case errors.Is(err, fs.ErrNotExist) && fp.oldPath == devNull:
+		// New file
+	default:
+		return "", 0, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	after, results := applyHunks(before, fp.hunks)
+
+	applied := 0
+	var rejected []string
+	var notes []string
+	for i, result := range results {
+		if !result.applied {
+			rejected = append(rejected, fmt.Sprintf("--- rejected hunk %d: %s ---\n%s", i+1, result.reason, fp.hunks[i].text()))
+			continue
+		}
+		applied++
+		if result.fuzz > 0 || result.loose {
+			var how []string
+			if result.fuzz > 0 {
+				how = append(how, fmt.Sprintf("fuzz %d", result.fuzz))
+			}
+			if result.loose {
+				how = append(how, "ignoring whitespace")
+			}
+			notes = append(notes, fmt.Sprintf("hunk %d at line %d (%s)", i+1, result.line, strings.Join(how, ", ")))
+		}
+	}
+
+	if applied == 0 {
+		return "", 0, fmt.Errorf("all %d hunks rejected\n%s", len(fp.hunks), strings.Join(rejected, "\n"))
+	}
+
+	operation := "edit"
+	switch {
+	case fp.newPath == devNull && after == "":
+		operation = "delete"
+		if err := os.Remove(filePath); err != nil {
+			return "", 0, fmt.Errorf("failed to delete file: %w", err)
+		}
+	default:
+		if fp.oldPath == devNull {
+			operation = "create"
+			if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+				return "", 0, fmt.Errorf("failed to create directory: %w", err)
+			}
+		}
+		mode := fs.FileMode(0o644)
+		if info, err := os.Stat(filePath); err == nil {
+			mode = info.Mode().Perm()
+		}
+		if err := os.WriteFile(filePath, []byte(after), mode); err != nil {
+			return "", 0, fmt.Errorf("failed to write file: %w", err)
+		}
+	}
+
+	if te.fileChangeCallback != nil {
+		te.fileChangeCallback(FileChange{
+			FilePath:  displayPath,
+			Before:    before,
+			After:     after,
+			Operation: operation,
+		})
+	}
+
+	var summary strings.Builder
+	fmt.Fprintf(&summary, "✓ %s: %d of %d hunks applied", displayPath, applied, len(fp.hunks))
+	if operation != "edit" {
+		fmt.Fprintf(&summary, " (%sd)", strings.TrimSuffix(operation, "e"))
+	}
+	summary.WriteString("\n")
+	if len(notes) > 0 {
+		fmt.Fprintf(&summary, "  applied loosely: %s\n", strings.Join(notes, "; "))
+	}
+	for _, r := range rejected {
+		summary.WriteString(r + "\n")
+	}
+
+	return summary.String(), applied, nil
+}
@@ -0,0 +1,211 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writePatchFixture(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to create %s: %v", name, err)
+	}
+}
+
+func readPatchFixture(t *testing.T, dir, name string) string {
+	t.Helper()
+	content, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", name, err)
+	}
+	return string(content)
+}
+
+func TestToolExecutor_ApplyPatch_MultipleFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	writePatchFixture(t, tempDir, "a.go", "package a\n\nfunc A() int {\n\treturn 1\n}\n")
+	writePatchFixture(t, tempDir, "b.go", "package b\n\nfunc B() {}\n")
+
+	var changes []FileChange
+	te := NewToolExecutor(tempDir)
+	te.SetFileChangeCallback(func(change FileChange) { changes = append(changes, change) })
+
+	patch := `diff --git a/a.go b/a.go
+index 1111111..2222222 100644
+--- a/a.go
++++ b/a.go
+@@ -3,3 +3,3 @@
+ func A() int {
+-	return 1
++	return 2
+ }
+--- a/b.go
++++ b/b.go
+@@ -1,3 +1,5 @@
+ package b
+
++// B does nothing
++
+ func B() {}
+`
+	result, err := te.applyPatch(map[string]interface{}{"patch": patch})
+	if err != nil {
+		t.Fatalf("applyPatch failed: %v", err)
+	}
+
+	if !strings.Contains(result, "Applied 2 of 2 hunks to 2 file(s)") {
+		t.Errorf("Expected summary of applied hunks, got: %s", result)
+	}
+	if got := readPatchFixture(t, tempDir, "a.go"); got != "package a\n\nfunc A() int {\n\treturn 2\n}\n" {
+		t.Errorf("Unexpected a.go content: %q", got)
+	}
+	if got := readPatchFixture(t, tempDir, "b.go"); got != "package b\n\n// B does nothing\n\nfunc B() {}\n" {
+		t.Errorf("Unexpected b.go content: %q", got)
+	}
+	if len(changes) != 2 || changes[0].FilePath != "a.go" || changes[0].Operation != "edit" {
+		t.Errorf("Expected an edit file change per file, got: %+v", changes)
+	}
+}
+
+func TestToolExecutor_ApplyPatch_FuzzyContext(t *testing.T) {
+	tempDir := t.TempDir()
+	// Two lines were added at the top and the context is indented differently
+	writePatchFixture(t, tempDir, "main.py", "import os\nimport sys\n\ndef main():\n    name = 'x'\n    print(name)\n    return 0\n")
+
+	patch := `--- main.py
++++ main.py
+@@ -1,4 +1,4 @@
+ def main():
+-  name = 'x'
++  name = 'y'
+   print(name)
+   return 0
+`
+	te := NewToolExecutor(tempDir)
+	result, err := te.applyPatch(map[string]interface{}{"patch": patch})
+	if err != nil {
+		t.Fatalf("applyPatch failed: %v", err)
+	}
+
+	if !strings.Contains(result, "ignoring whitespace") {
+		t.Errorf("Expected a note about the loose match, got: %s", result)
+	}
+	want := "import os\nimport sys\n\ndef main():\n  name = 'y'\n    print(name)\n    return 0\n"
+	if got := readPatchFixture(t, tempDir, "main.py"); got != want {
+		t.Errorf("Unexpected content:\n got: %q\nwant: %q", got, want)
+	}
+}
+
+func TestToolExecutor_ApplyPatch_ContextFuzz(t *testing.T) {
+	tempDir := t.TempDir()
+	writePatchFixture(t, tempDir, "list.txt", "one\ntwo\nthree\nfour\nfive\n")
+
+	// The first context line no longer matches the file
+	patch := `--- a/list.txt
++++ b/list.txt
+@@ -1,4 +1,4 @@
+ zero
+ two
+-three
++THREE
+ four
+`
+	te := NewToolExecutor(tempDir)
+	result, err := te.applyPatch(map[string]interface{}{"patch": patch})
+	if err != nil {
+		t.Fatalf("applyPatch failed: %v", err)
+	}
+
+	if !strings.Contains(result, "fuzz 1") {
+		t.Errorf("Expected the hunk to apply with fuzz, got: %s", result)
+	}
+	if got := readPatchFixture(t, tempDir, "list.txt"); got != "one\ntwo\nTHREE\nfour\nfive\n" {
+		t.Errorf("Unexpected content: %q", got)
+	}
+}
+
+func TestToolExecutor_ApplyPatch_RejectedHunks(t *testing.T) {
+	tempDir := t.TempDir()
+	writePatchFixture(t, tempDir, "list.txt", "one\ntwo\nthree\n")
+
+	patch := `--- a/list.txt
++++ b/list.txt
+@@ -1,2 +1,2 @@
+-one
++ONE
+ two
+@@ -10,2 +10,2 @@
+ ten
+-eleven
++ELEVEN
+`
+	te := NewToolExecutor(tempDir)
+	result, err := te.applyPatch(map[string]interface{}{"patch": patch})
+	if err != nil {
+		t.Fatalf("applyPatch failed: %v", err)
+	}
+
+	if !strings.Contains(result, "1 of 2 hunks applied") {
+		t.Errorf("Expected partial application, got: %s", result)
+	}
+	if !strings.Contains(result, "rejected hunk 2") || !strings.Contains(result, "+ELEVEN") {
+		t.Errorf("Expected the rejected hunk in the result, got: %s", result)
+	}
+	if got := readPatchFixture(t, tempDir, "list.txt"); got != "ONE\ntwo\nthree\n" {
+		t.Errorf("Unexpected content: %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "list.txt.rej")); !os.IsNotExist(err) {
+		t.Error("Expected no reject file to be written")
+	}
+
+	// A patch where nothing applies is an error and leaves the file alone
+	_, err = te.applyPatch(map[string]interface{}{"patch": "--- a/list.txt\n+++ b/list.txt\n@@ -1 +1 @@\n-missing\n+found\n"})
+	if err == nil {
+		t.Error("Expected error when no hunks apply")
+	}
+}
+
+func TestToolExecutor_ApplyPatch_CreateAndDelete(t *testing.T) {
+	tempDir := t.TempDir()
+	writePatchFixture(t, tempDir, "old.txt", "bye\n")
+
+	patch := `--- /dev/null
++++ b/pkg/new.txt
+@@ -0,0 +1,2 @@
++hello
++world
+--- a/old.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-bye
+`
+	var operations []string
+	te := NewToolExecutor(tempDir)
+	te.SetFileChangeCallback(func(change FileChange) { operations = append(operations, change.Operation) })
+
+	if _, err := te.applyPatch(map[string]interface{}{"patch": patch}); err != nil {
+		t.Fatalf("applyPatch failed: %v", err)
+	}
+
+	if got := readPatchFixture(t, tempDir, "pkg/new.txt"); got != "hello\nworld\n" {
+		t.Errorf("Unexpected new file content: %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "old.txt")); !os.IsNotExist(err) {
+		t.Error("Expected old.txt to be deleted")
+	}
+	if strings.Join(operations, ",") != "create,delete" {
+		t.Errorf("Expected create and delete file changes, got: %v", operations)
+	}
+}
+
+func TestPatchFiles(t *testing.T) {
+	patch := "--- a/x.go\t2024-01-01\n+++ b/x.go\n@@ -1 +1 @@\n-a\n+b\n--- /dev/null\n+++ b/y.go\n@@ -0,0 +1 @@\n+c\n"
+	if got := strings.Join(PatchFiles(patch), ","); got != "x.go,y.go" {
+		t.Errorf("Expected x.go,y.go, got %q", got)
+	}
+	if files := PatchFiles("not a patch"); len(files) != 0 {
+		t.Errorf("Expected no files for an invalid patch, got %v", files)
+	}
+}
@@ -0,0 +1,213 @@
+package tools
+
+import (
+	"fmt"
+	"github.com/tildaslashalef/bazinga/internal/project"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SetAllowedPaths confines file and search tools to the given subtrees. Relative paths
+// are resolved against the root path; an empty list allows the whole root.
+func (te *ToolExecutor) SetAllowedPaths(paths []string) {
+	te.allowedPaths = nil
+	for _, path := range paths {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		te.allowedPaths = append(te.allowedPaths, te.absPath(path))
+	}
+}
+
+// absPath resolves a tool path against the root path
+func (te *ToolExecutor) absPath(path string) string {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(te.rootPath, path)
+	}
+	return filepath.Clean(path)
+}
+
+// isAllowedPath reports whether path lies inside one of the allowed subtrees
+func (te *ToolExecutor) isAllowedPath(path string) bool {
+	if len(te.allowedPaths) == 0 {
+		return true
+	}
+
+	path = te.absPath(path)
+	for _, allowed := range te.allowedPaths {
+		if path == allowed || strings.HasPrefix(path, allowed+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// isAllowedAncestor reports whether path is a directory above an allowed subtree, so
+// walks and listings may pass through it without touching anything else
+func (te *ToolExecutor) isAllowedAncestor(path string) bool {
+	path = te.absPath(path)
+	for _, allowed := range te.allowedPaths {
+		if strings.HasPrefix(allowed, path+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkAllowedPath returns an error naming the allowed subtrees if path is outside them
+func (te *ToolExecutor) checkAllowedPath(path string) error {
+	if te.isAllowedPath(path) {
+		return nil
+	}
+	return fmt.Errorf("%s is outside the allowed paths (%s); tools may only operate in those directories",
+		path, strings.Join(te.allowedPathNames(), ", "))
+}
+
+// allowedPathNames returns the allowed subtrees relative to the root path for messages
+func (te *ToolExecutor) allowedPathNames() []string {
+	names := make([]string, 0, len(te.allowedPaths))
+	for _, allowed := range te.allowedPaths {
+		if rel, err := filepath.Rel(te.rootPath, allowed); err == nil && !strings.HasPrefix(rel, "..") {
+			names = append(names, filepath.ToSlash(rel)+"/")
+		} else {
+			names = append(names, allowed)
+		}
+	}
+	return names
+}
+
+// checkToolPaths rejects a file or search tool call whose path arguments fall outside
+// the allowed subtrees or are hidden by .bazingaignore. Directory arguments of listing
+// tools may also name an ancestor of an allowed subtree; their walks are scoped by
+// skipDisallowed.
+func (te *ToolExecutor) checkToolPaths(toolName string, input map[string]interface{}) error {
+	te.refreshIgnore()
+
+	paths, dirs := toolPaths(toolName, input)
+	for _, dir := range dirs {
+		if !te.isAllowedAncestor(dir) {
+			paths = append(paths, dir)
+		}
+	}
+
+	for _, path := range paths {
+		if err := te.checkAllowedPath(path); err != nil {
+			return err
+		}
+		if err := te.checkIgnoredPath(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// toolPaths returns the paths a tool call operates on, and separately the directories
+// a listing tool walks
+func toolPaths(toolName string, input map[string]interface{}) (paths, dirs []string) {
+	for _, key := range []string{"file_path", "source_path", "dest_path", "dir_path"} {
+		if path, ok := input[key].(string); ok && path != "" {
+			paths = append(paths, path)
+		}
+	}
+
+	switch toolName {
+	case "read_files":
+		if files, ok := input["files"].([]interface{}); ok {
+			for _, raw := range files {
+				if item, ok := raw.(map[string]interface{}); ok {
+					if path, ok := item["file_path"].(string); ok && path != "" {
+						paths = append(paths, path)
+					}
+				}
+			}
+		}
+	case "git_restore":
+		if restorePaths, err := gitPathsInput(input); err == nil {
+			paths = append(paths, restorePaths...)
+		}
+	case "grep":
+		if files, ok := input["files"].([]interface{}); ok {
+			for _, raw := range files {
+				if path, ok := raw.(string); ok && path != "" {
+					paths = append(paths, path)
+				}
+			}
+		}
+	case "apply_patch":
+		if patch, ok := input["patch"].(string); ok {
+			paths = append(paths, PatchFiles(patch)...)
+		}
+	case "list_files", "find":
+		for _, key := range []string{"directory", "path"} {
+			if dir, ok := input[key].(string); ok && dir != "" {
+				dirs = append(dirs, dir)
+			}
+		}
+	}
+	return paths, dirs
+}
+
+// refreshIgnore reloads .bazingaignore when it changed, so edits apply to the next tool call
+func (te *ToolExecutor) refreshIgnore() {
+	if current := te.ignore.Load(); current == nil || current.Stale() {
+		te.ignore.Store(project.LoadBazingaIgnore(te.rootPath))
+	}
+}
+
+// isIgnored reports whether .bazingaignore hides path from the tools
+func (te *ToolExecutor) isIgnored(path string, isDir bool) bool {
+	return te.ignore.Load().Matches(te.absPath(path), isDir)
+}
+
+// checkIgnoredPath returns an error if .bazingaignore hides path or a directory above it
+func (te *ToolExecutor) checkIgnoredPath(path string) error {
+	isDir := false
+	if info, err := os.Stat(te.absPath(path)); err == nil {
+		isDir = info.IsDir()
+	}
+	if te.isIgnored(path, isDir) {
+		return fmt.Errorf("%s is blocked by %s", path, project.BazingaIgnoreFile)
+	}
+	return nil
+}
+
+// skipDisallowed is used by directory walks to stay within the allowed subtrees and out
+// of paths hidden by .bazingaignore. It reports whether path should be left out of the
+// results and, for directories outside every allowed subtree or hidden, returns
+// filepath.SkipDir so the walk does not descend.
+func (te *ToolExecutor) skipDisallowed(path string, info os.FileInfo) (bool, error) {
+	if te.isIgnored(path, info.IsDir()) {
+		if info.IsDir() {
+			return true, filepath.SkipDir
+		}
+		return true, nil
+	}
+	if te.isAllowedPath(path) {
+		return false, nil
+	}
+	if info.IsDir() {
+		if te.isAllowedAncestor(path) {
+			return true, nil
+		}
+		return true, filepath.SkipDir
+	}
+	return true, nil
+}
+
+// allowedSearchRoots returns the allowed subtrees relative to the root path, or "." when
+// tools are not confined; used as search arguments for external tools such as rg
+func (te *ToolExecutor) allowedSearchRoots() []string {
+	if len(te.allowedPaths) == 0 {
+		return []string{"."}
+	}
+
+	var roots []string
+	for _, allowed := range te.allowedPaths {
+		if rel, err := filepath.Rel(te.rootPath, allowed); err == nil {
+			roots = append(roots, rel)
+		}
+	}
+	return roots
+}
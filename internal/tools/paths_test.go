@@ -0,0 +1,167 @@
+package tools
+
+import (
+	"context"
+	"github.com/tildaslashalef/bazinga/internal/llm"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newAllowedPathsExecutor(t *testing.T) (*ToolExecutor, string) {
+	tempDir := t.TempDir()
+	for _, dir := range []string{"internal/tools", "cmd"} {
+		if err := os.MkdirAll(filepath.Join(tempDir, dir), 0o755); err != nil {
+			t.Fatalf("Failed to create %s: %v", dir, err)
+		}
+	}
+	files := map[string]string{
+		"internal/tools/a.go": "package tools\n\nconst name = \"old\"\n",
+		"cmd/main.go":         "package main\n\nconst name = \"old\"\n",
+		"go.mod":              "module example\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	te := NewToolExecutor(tempDir)
+	te.SetAllowedPaths([]string{"internal/"})
+	return te, tempDir
+}
+
+func TestAllowedPaths_EditOutsideRejected(t *testing.T) {
+	te, tempDir := newAllowedPathsExecutor(t)
+	ctx := context.Background()
+
+	edit := func(path string) (string, error) {
+		return te.ExecuteTool(ctx, &llm.ToolCall{
+			Name: "edit_file",
+			Input: map[string]interface{}{
+				"file_path": path,
+				"old_text":  `"old"`,
+				"new_text":  `"new"`,
+			},
+		})
+	}
+
+	for _, path := range []string{"cmd/main.go", "internal/../cmd/main.go", filepath.Join(tempDir, "cmd", "main.go")} {
+		_, err := edit(path)
+		if err == nil || !strings.Contains(err.Error(), "outside the allowed paths (internal/)") {
+			t.Errorf("Expected %s to be rejected as outside the allowed paths, got: %v", path, err)
+		}
+	}
+
+	content, _ := os.ReadFile(filepath.Join(tempDir, "cmd", "main.go"))
+	if strings.Contains(string(content), `"new"`) {
+		t.Error("Rejected edit must not modify the file")
+	}
+
+	if _, err := edit("internal/tools/a.go"); err != nil {
+		t.Fatalf("Expected edit inside the allowed paths to succeed: %v", err)
+	}
+	content, _ = os.ReadFile(filepath.Join(tempDir, "internal", "tools", "a.go"))
+	if !strings.Contains(string(content), `"new"`) {
+		t.Errorf("Expected allowed edit to be applied, got: %s", content)
+	}
+}
+
+func TestAllowedPaths_WalksAreScoped(t *testing.T) {
+	te, _ := newAllowedPathsExecutor(t)
+	ctx := context.Background()
+
+	found, err := te.ExecuteTool(ctx, &llm.ToolCall{Name: "find", Input: map[string]interface{}{"name": "*.go"}})
+	if err != nil {
+		t.Fatalf("find failed: %v", err)
+	}
+	if !strings.Contains(found, filepath.Join("internal", "tools", "a.go")) || strings.Contains(found, "main.go") {
+		t.Errorf("Expected find to only see the allowed subtree, got: %s", found)
+	}
+
+	listing, err := te.ExecuteTool(ctx, &llm.ToolCall{Name: "list_files", Input: map[string]interface{}{}})
+	if err != nil {
+		t.Fatalf("list_files failed: %v", err)
+	}
+	if !strings.Contains(listing, "internal/") || strings.Contains(listing, "cmd/") || strings.Contains(listing, "go.mod") {
+		t.Errorf("Expected the root listing to show only the path to the allowed subtree, got: %s", listing)
+	}
+
+	if _, err := te.ExecuteTool(ctx, &llm.ToolCall{Name: "list_files", Input: map[string]interface{}{"directory": "cmd"}}); err == nil {
+		t.Error("Expected listing a directory outside the allowed paths to fail")
+	}
+
+	matches, err := te.nativeGrepSearch(map[string]interface{}{"pattern": "const name"})
+	if err != nil {
+		t.Fatalf("grep failed: %v", err)
+	}
+	if strings.Contains(matches, "main.go") || !strings.Contains(matches, "a.go") {
+		t.Errorf("Expected grep to only search the allowed subtree, got: %s", matches)
+	}
+}
+
+func TestBazingaIgnore_HidesPaths(t *testing.T) {
+	tempDir := t.TempDir()
+	files := map[string]string{
+		"secrets/prod.env": "TOKEN=hunter2\n",
+		"src/main.go":      "package main\n\n// TOKEN is read from the environment\n",
+		".bazingaignore":   "secrets/\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(tempDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	te := NewToolExecutor(tempDir)
+	ctx := context.Background()
+	run := func(name string, input map[string]interface{}) (string, error) {
+		return te.ExecuteTool(ctx, &llm.ToolCall{Name: name, Input: input})
+	}
+
+	for _, input := range []map[string]interface{}{
+		{"file_path": "secrets/prod.env"},
+		{"file_path": filepath.Join(tempDir, "secrets", "prod.env")},
+	} {
+		if _, err := run("read_file", input); err == nil || !strings.Contains(err.Error(), "blocked by .bazingaignore") {
+			t.Errorf("Expected read_file %v to be blocked, got: %v", input["file_path"], err)
+		}
+	}
+	if _, err := run("list_files", map[string]interface{}{"directory": "secrets"}); err == nil {
+		t.Error("Expected listing a hidden directory to be blocked")
+	}
+
+	listing, err := run("list_files", map[string]interface{}{})
+	if err != nil || strings.Contains(listing, "secrets") {
+		t.Errorf("Hidden directory should be left out of listings, got: %s (%v)", listing, err)
+	}
+	found, err := run("find", map[string]interface{}{"name": "*.env"})
+	if err != nil || strings.Contains(found, "prod.env") {
+		t.Errorf("Hidden files should not be found, got: %s (%v)", found, err)
+	}
+	matches, err := run("grep", map[string]interface{}{"pattern": "TOKEN", "extensions": []interface{}{".env", ".go"}})
+	if err != nil || strings.Contains(matches, "hunter2") || !strings.Contains(matches, "main.go") {
+		t.Errorf("grep should skip hidden files only, got: %s (%v)", matches, err)
+	}
+	fuzzy, err := run("fuzzy_search", map[string]interface{}{"query": "prod"})
+	if err != nil || strings.Contains(fuzzy, "prod.env") {
+		t.Errorf("Hidden files should not be fuzzy matched, got: %s (%v)", fuzzy, err)
+	}
+	globbed, err := run("read_files", map[string]interface{}{"glob": "**/*"})
+	if err != nil || strings.Contains(globbed, "hunter2") {
+		t.Errorf("Hidden files should not be globbed, got: %s (%v)", globbed, err)
+	}
+
+	// Edits to .bazingaignore apply to the next call
+	if err := os.WriteFile(filepath.Join(tempDir, ".bazingaignore"), []byte(""), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if content, err := run("read_file", map[string]interface{}{"file_path": "secrets/prod.env"}); err != nil || !strings.Contains(content, "hunter2") {
+		t.Errorf("Expected the file to be readable once unhidden, got: %s (%v)", content, err)
+	}
+}
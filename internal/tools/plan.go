@@ -0,0 +1,123 @@
+package tools
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// plannedTools are the write tools that only preview their change in dry-run mode
+var plannedTools = map[string]bool{
+	"write_file":  true,
+	"edit_file":   true,
+	"create_file": true,
+	"delete_file": true,
+	"move_file":   true,
+}
+
+// unplannableTools write files but can't preview their change, so they are refused
+// in dry-run mode rather than run for real
+var unplannableTools = map[string]string{
+	"multi_edit_file": "edit_file",
+	"apply_patch":     "edit_file",
+	"copy_file":       "create_file",
+}
+
+// IsPlannedTool reports whether a tool only previews its change in dry-run mode
+func IsPlannedTool(name string) bool {
+	return plannedTools[name]
+}
+
+// SetDryRun turns dry-run mode on or off. In dry-run mode the planned tools report
+// their change to the plan callback instead of touching disk. Later planned changes
+// see the earlier ones, so two edits to one file preview as they would apply.
+// Switching the mode forgets the changes planned so far.
+func (te *ToolExecutor) SetDryRun(enabled bool) {
+	te.dryRun = enabled
+	te.planned = nil
+}
+
+// DryRun reports whether dry-run mode is on
+func (te *ToolExecutor) DryRun() bool {
+	return te.dryRun
+}
+
+// SetPlanCallback sets the callback for changes previewed in dry-run mode
+func (te *ToolExecutor) SetPlanCallback(callback func(FileChange)) {
+	te.planCallback = callback
+}
+
+// checkDryRun refuses write tools whose change can't be previewed in dry-run mode
+func (te *ToolExecutor) checkDryRun(toolName string) error {
+	if !te.dryRun {
+		return nil
+	}
+	if alternative, ok := unplannableTools[toolName]; ok {
+		return fmt.Errorf("plan mode is on and %s can't preview its change; use %s instead", toolName, alternative)
+	}
+	return nil
+}
+
+// readForChange reads a file as a planned tool sees it: in dry-run mode, with the
+// changes planned so far applied
+func (te *ToolExecutor) readForChange(path string) ([]byte, error) {
+	if content, ok := te.planned[path]; ok {
+		if content == nil {
+			return nil, &fs.PathError{Op: "open", Path: path, Err: fs.ErrNotExist}
+		}
+		return []byte(*content), nil
+	}
+	return os.ReadFile(path)
+}
+
+// statForChange is os.Stat as a planned tool sees it. Files created by the plan
+// report as regular files.
+func (te *ToolExecutor) statForChange(path string) (fs.FileInfo, error) {
+	if content, ok := te.planned[path]; ok {
+		if content == nil {
+			return nil, &fs.PathError{Op: "stat", Path: path, Err: fs.ErrNotExist}
+		}
+		return plannedFileInfo{name: filepath.Base(path), size: int64(len(*content))}, nil
+	}
+	return os.Stat(path)
+}
+
+// planChange records a change in dry-run mode instead of writing it. contents maps
+// each absolute path the change touches to its new content, nil for removed.
+func (te *ToolExecutor) planChange(change FileChange, contents map[string]*string) string {
+	if te.planned == nil {
+		te.planned = make(map[string]*string)
+	}
+	for path, content := range contents {
+		te.planned[path] = content
+	}
+
+	if te.planCallback != nil {
+		te.planCallback(change)
+	}
+
+	return fmt.Sprintf("Plan mode: %s %s was planned but not applied. The user reviews the plan and runs /apply to write it; continue as if it were done.", change.Operation, change.FilePath)
+}
+
+// displayPath returns path relative to the root for display, if it is inside it
+func (te *ToolExecutor) displayPath(path string) string {
+	if relPath, err := filepath.Rel(te.rootPath, path); err == nil {
+		return relPath
+	}
+	return path
+}
+
+// plannedFileInfo describes a file that exists only in the plan
+type plannedFileInfo struct {
+	name string
+	size int64
+}
+
+func (i plannedFileInfo) Name() string       { return i.name }
+func (i plannedFileInfo) Size() int64        { return i.size }
+func (i plannedFileInfo) Mode() fs.FileMode  { return 0o644 }
+func (i plannedFileInfo) ModTime() time.Time { return time.Time{} }
+func (i plannedFileInfo) IsDir() bool        { return false }
+func (i plannedFileInfo) Sys() interface{}   { return nil }
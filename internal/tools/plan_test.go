@@ -0,0 +1,99 @@
+package tools
+
+import (
+	"context"
+	"github.com/tildaslashalef/bazinga/internal/llm"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestToolExecutor_DryRunPlansWithoutWriting(t *testing.T) {
+	tempDir := t.TempDir()
+	mainPath := filepath.Join(tempDir, "main.go")
+	if err := os.WriteFile(mainPath, []byte("package main\n\nfunc a() {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	te := NewToolExecutor(tempDir)
+	te.SetDryRun(true)
+
+	var planned []FileChange
+	te.SetPlanCallback(func(change FileChange) {
+		planned = append(planned, change)
+	})
+	te.SetFileChangeCallback(func(change FileChange) {
+		t.Errorf("Expected no applied change in dry-run mode, got %+v", change)
+	})
+
+	calls := []llm.ToolCall{
+		{Name: "edit_file", Input: map[string]interface{}{"file_path": "main.go", "old_text": "func a() {}", "new_text": "func b() {}"}},
+		// Sees the first edit, which only exists in the plan
+		{Name: "edit_file", Input: map[string]interface{}{"file_path": "main.go", "old_text": "func b() {}", "new_text": "func c() {}"}},
+		{Name: "create_file", Input: map[string]interface{}{"file_path": "util.go", "content": "package main\n"}},
+		{Name: "move_file", Input: map[string]interface{}{"source_path": "util.go", "dest_path": "helpers.go"}},
+		{Name: "delete_file", Input: map[string]interface{}{"file_path": "main.go"}},
+	}
+	for _, call := range calls {
+		result, err := te.ExecuteTool(context.Background(), &call)
+		if err != nil {
+			t.Fatalf("%s failed: %v", call.Name, err)
+		}
+		if !strings.HasPrefix(result, "Plan mode: ") {
+			t.Errorf("Expected a plan mode result for %s, got %q", call.Name, result)
+		}
+	}
+
+	if len(planned) != len(calls) {
+		t.Fatalf("Expected %d planned changes, got %d", len(calls), len(planned))
+	}
+	if planned[1].Before != "package main\n\nfunc b() {}\n" || planned[1].After != "package main\n\nfunc c() {}\n" {
+		t.Errorf("Expected the second edit to build on the first, got %+v", planned[1])
+	}
+	if planned[3].FilePath != "util.go → helpers.go" || planned[3].After != "package main\n" {
+		t.Errorf("Unexpected planned move %+v", planned[3])
+	}
+	if planned[4].Before != "package main\n\nfunc c() {}\n" {
+		t.Errorf("Expected the delete to show the planned content, got %q", planned[4].Before)
+	}
+
+	// Nothing reached disk
+	if content, _ := os.ReadFile(mainPath); string(content) != "package main\n\nfunc a() {}\n" {
+		t.Errorf("Expected main.go to be untouched, got %q", content)
+	}
+	for _, name := range []string{"util.go", "helpers.go"} {
+		if _, err := os.Stat(filepath.Join(tempDir, name)); !os.IsNotExist(err) {
+			t.Errorf("Expected %s not to exist", name)
+		}
+	}
+
+	// The plan knows main.go is gone
+	if _, err := te.ExecuteTool(context.Background(), &llm.ToolCall{Name: "delete_file", Input: map[string]interface{}{"file_path": "main.go"}}); err == nil {
+		t.Error("Expected deleting a file the plan already deleted to fail")
+	}
+
+	// Leaving dry-run mode forgets the plan
+	te.SetDryRun(false)
+	if _, err := te.readForChange(mainPath); err != nil {
+		t.Errorf("Expected main.go to be readable after dry-run mode ends: %v", err)
+	}
+}
+
+func TestToolExecutor_DryRunRefusesUnplannableTools(t *testing.T) {
+	tempDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tempDir, "a.txt"), []byte("a\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	te := NewToolExecutor(tempDir)
+	te.SetDryRun(true)
+
+	_, err := te.ExecuteTool(context.Background(), &llm.ToolCall{Name: "copy_file", Input: map[string]interface{}{"source_path": "a.txt", "dest_path": "b.txt"}})
+	if err == nil || !strings.Contains(err.Error(), "use create_file instead") {
+		t.Errorf("Expected copy_file to be refused in dry-run mode, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "b.txt")); !os.IsNotExist(err) {
+		t.Error("Expected b.txt not to be written")
+	}
+}
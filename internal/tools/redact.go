@@ -0,0 +1,208 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"github.com/tildaslashalef/bazinga/internal/loggy"
+	"math"
+	"regexp"
+	"strings"
+)
+
+// unredactedTools are the tools whose output is only bazinga's own report of what it
+// did, never file contents, command output or web pages, and so is not scanned for
+// secrets. Every other tool's output is, including custom tools.
+var unredactedTools = map[string]bool{
+	"create_dir":  true,
+	"delete_dir":  true,
+	"delete_file": true,
+	"move_file":   true,
+	"copy_file":   true,
+	"todo_read":   true,
+	"todo_write":  true,
+}
+
+// SecretPattern is a named regular expression matching a secret. When the expression
+// has a capture group, only the first group is redacted, so "API_KEY=" stays readable.
+type SecretPattern struct {
+	Name    string
+	Pattern string
+}
+
+// builtinSecretPatterns are always redacted when redaction is on; the private key comes
+// first so its body is replaced as a whole
+var builtinSecretPatterns = []SecretPattern{
+	{"private_key", `-----BEGIN (?:[A-Z0-9]+ )*PRIVATE KEY-----[\s\S]*?(?:-----END (?:[A-Z0-9]+ )*PRIVATE KEY-----|\z)`},
+	{"aws_key", `\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`},
+	{"aws_secret", `(?i)aws_?secret_?access_?key["']?\s*[:=]\s*["']?([A-Za-z0-9/+=]{40})`},
+	{"github_token", `\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})`},
+	{"slack_token", `\bxox[abprs]-[A-Za-z0-9-]{10,}`},
+	{"google_api_key", `\bAIza[0-9A-Za-z_\-]{35}`},
+	{"api_key", `\bsk-(?:ant-|proj-)?[A-Za-z0-9_\-]{20,}`},
+	{"bearer_token", `(?i)\bbearer\s+([A-Za-z0-9\-._~+/]{20,}=*)`},
+}
+
+// envSecretPattern matches .env-style assignments to secret-sounding names. Only values
+// that look random are redacted, so placeholders like "changeme" and references like
+// ${API_KEY} stay visible.
+var envSecretPattern = SecretPattern{
+	"env_secret",
+	`(?im)^[ \t]*(?:export[ \t]+)?[A-Za-z0-9_.-]*(?:secret|token|passw(?:or)?d|pwd|api_?key|private_?key|access_?key|credential)[A-Za-z0-9_.-]*[ \t]*[=:][ \t]*["']?([^\s"'$<{][^\s"']{7,})`,
+}
+
+// envSecretMinEntropy is the bits per character above which an assigned value is
+// treated as a secret
+const envSecretMinEntropy = 3.0
+
+// redactedPrefix starts every replacement, so a redacted value is never matched again
+const redactedPrefix = "[REDACTED:"
+
+// defaultRedactor redacts the built-in patterns, for executors not configured otherwise
+var defaultRedactor, _ = NewRedactor(nil)
+
+// secretPattern is a compiled SecretPattern
+type secretPattern struct {
+	name       string
+	re         *regexp.Regexp
+	minEntropy float64 // Matches with less entropy are left alone (0 = redact all)
+}
+
+// Redactor replaces secrets in tool output with [REDACTED:<name>] markers
+type Redactor struct {
+	patterns []secretPattern
+}
+
+// NewRedactor returns a redactor for the built-in secret patterns plus extra ones
+func NewRedactor(extra []SecretPattern) (*Redactor, error) {
+	r := &Redactor{}
+	for _, pattern := range builtinSecretPatterns {
+		r.patterns = append(r.patterns, secretPattern{name: pattern.Name, re: regexp.MustCompile(pattern.Pattern)})
+	}
+	for _, pattern := range extra {
+		re, err := regexp.Compile(pattern.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid secret pattern %q: %w", pattern.Name, err)
+		}
+		r.patterns = append(r.patterns, secretPattern{name: pattern.Name, re: re})
+	}
+	r.patterns = append(r.patterns, secretPattern{
+		name:       envSecretPattern.Name,
+		re:         regexp.MustCompile(envSecretPattern.Pattern),
+		minEntropy: envSecretMinEntropy,
+	})
+	return r, nil
+}
+
+// Redact returns text with every secret replaced, and how many were replaced
+func (r *Redactor) Redact(text string) (string, int) {
+	if r == nil {
+		return text, 0
+	}
+
+	total := 0
+	for _, pattern := range r.patterns {
+		var count int
+		text, count = pattern.redact(text)
+		total += count
+	}
+	return text, total
+}
+
+// redact replaces the matches of one pattern
+func (p secretPattern) redact(text string) (string, int) {
+	matches := p.re.FindAllStringSubmatchIndex(text, -1)
+	if len(matches) == 0 {
+		return text, 0
+	}
+
+	var out strings.Builder
+	count, last := 0, 0
+	for _, match := range matches {
+		start, end := match[0], match[1]
+		if len(match) >= 4 && match[2] >= 0 {
+			start, end = match[2], match[3]
+		}
+
+		secret := text[start:end]
+		if strings.HasPrefix(secret, redactedPrefix) || (p.minEntropy > 0 && shannonEntropy(secret) < p.minEntropy) {
+			continue
+		}
+
+		out.WriteString(text[last:start])
+		out.WriteString(redactedPrefix + p.name + "]")
+		last = end
+		count++
+	}
+	out.WriteString(text[last:])
+	return out.String(), count
+}
+
+// shannonEntropy returns the bits of entropy per character of s
+func shannonEntropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+
+	counts := make(map[rune]int)
+	total := 0
+	for _, r := range s {
+		counts[r]++
+		total++
+	}
+
+	entropy := 0.0
+	for _, n := range counts {
+		p := float64(n) / float64(total)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// SetRedaction turns secret redaction of tool output on or off and adds patterns to
+// the built-in ones. Redaction is on by default.
+func (te *ToolExecutor) SetRedaction(enabled bool, extra []SecretPattern) error {
+	if !enabled {
+		te.redactor = nil
+		return nil
+	}
+
+	redactor, err := NewRedactor(extra)
+	if err != nil {
+		return err
+	}
+	te.redactor = redactor
+	return nil
+}
+
+// RedactText removes secrets from text bazinga sends the model on its own, outside a
+// tool call
+func (te *ToolExecutor) RedactText(text string) string {
+	if te.redactor == nil {
+		return text
+	}
+	text, _ = te.redactor.Redact(text)
+	return text
+}
+
+// redactOutput removes secrets from the output or error of a tool call. How many were
+// removed is only logged locally.
+func (te *ToolExecutor) redactOutput(toolName, output string, err error) (string, error) {
+	if te.redactor == nil || unredactedTools[toolName] {
+		return output, err
+	}
+
+	output, count := te.redactor.Redact(output)
+	if err != nil {
+		// Failed commands report their output in the error
+		message, errCount := te.redactor.Redact(err.Error())
+		if errCount > 0 {
+			err = errors.New(message)
+			count += errCount
+		}
+	}
+
+	if count > 0 {
+		loggy.Info("Redacted secrets from tool output", "tool", toolName, "redactions", count)
+	}
+	return output, err
+}
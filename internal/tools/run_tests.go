@@ -0,0 +1,562 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/tildaslashalef/bazinga/internal/llm"
+	"github.com/tildaslashalef/bazinga/internal/loggy"
+	"github.com/tildaslashalef/bazinga/internal/project"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultTestTimeout is how long a test run may take when the call sets no timeout;
+	// test suites often outlast the bash default
+	defaultTestTimeout = 5 * time.Minute
+
+	// maxTestFailures caps how many failing tests a summary details
+	maxTestFailures = 20
+
+	// maxFailureLines caps the output kept for each failing test
+	maxFailureLines = 20
+)
+
+// testRun is the parsed result of a test run
+type testRun struct {
+	passed   int
+	failed   int
+	skipped  int
+	failures []testFailure
+}
+
+// testFailure is a failing test and the output explaining why
+type testFailure struct {
+	name   string
+	output string
+}
+
+// testCommand is how a project's tests are run and their output read
+type testCommand struct {
+	name string
+	args []string
+
+	// parse reads the captured output; Go tests are parsed line by line instead
+	parse func(output string) (*testRun, bool)
+}
+
+// runTestsTool describes the run_tests tool
+func runTestsTool() llm.Tool {
+	return llm.Tool{
+		Name:        "run_tests",
+		Description: "Run the project's tests and get a structured summary: how many passed, failed and were skipped, and each failing test with its error output. Detects the project type and runs go test, npm test or pytest. Prefer this over bash for running tests; the raw output is returned when it can't be parsed.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"target": map[string]interface{}{
+					"type":        "string",
+					"description": "What to test: a Go package pattern such as ./internal/... (default ./...), or a test file or directory for npm test and pytest (optional)",
+				},
+				"filter": map[string]interface{}{
+					"type":        "string",
+					"description": "Only run tests whose name matches: go test -run, jest/vitest -t, pytest -k (optional)",
+				},
+				"timeout_seconds": map[string]interface{}{
+					"type":        "number",
+					"description": "Seconds before the run is killed (optional, default 300, max 600)",
+				},
+			},
+		},
+	}
+}
+
+// runTests runs the project's tests, streaming their output, and summarizes the result
+func (te *ToolExecutor) runTests(ctx context.Context, input map[string]interface{}) (string, error) {
+	target, _ := input["target"].(string)
+	filter, _ := input["filter"].(string)
+
+	projectType := project.NewDetector().DetectType(te.rootPath)
+	command, err := testCommandFor(projectType, strings.TrimSpace(target), strings.TrimSpace(filter))
+	if err != nil {
+		return "", err
+	}
+	commandLine := strings.Join(append([]string{command.name}, command.args...), " ")
+
+	timeout := te.bashCallTimeout(input)
+	if _, ok := input["timeout_seconds"].(float64); !ok {
+		timeout = max(timeout, defaultTestTimeout)
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, command.name, command.args...)
+	cmd.Dir = te.rootPath
+	cmd.WaitDelay = processWaitDelay
+	setProcessGroup(cmd)
+
+	// go test -json is parsed as it streams, so its output is never cut short, and
+	// only the test output inside each event is shown
+	onLine := outputFuncFrom(ctx)
+	var events *goTestEvents
+	if command.parse == nil {
+		events = newGoTestEvents(onLine)
+		onLine = events.line
+	}
+	output := &commandOutput{max: maxBashOutputBytes, onLine: onLine}
+	cmd.Stdout = output
+	cmd.Stderr = output
+
+	loggy.Debug("ToolExecutor runTests", "command", commandLine, "project_type", projectType, "timeout", timeout)
+
+	start := time.Now()
+	err = cmd.Run()
+	output.Flush()
+	duration := time.Since(start).Round(10 * time.Millisecond)
+	raw := strings.TrimSpace(output.String())
+
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		return "", fmt.Errorf("tests timed out after %gs and were killed\nCommand: %s\nOutput:\n%s", timeout.Seconds(), commandLine, raw)
+	case errors.Is(ctx.Err(), context.Canceled):
+		return "", fmt.Errorf("tests cancelled after %v\nCommand: %s\nOutput:\n%s", duration, commandLine, raw)
+	}
+
+	var run *testRun
+	var parsed bool
+	if events != nil {
+		run, parsed = events.result()
+	} else {
+		run, parsed = command.parse(raw)
+	}
+
+	// A failing exit with no failing test means something else went wrong, e.g. the
+	// test command itself; the raw output explains it better
+	if parsed && (err == nil || run.failed > 0) {
+		loggy.Info("ToolExecutor runTests finished", "command", commandLine, "passed", run.passed, "failed", run.failed, "skipped", run.skipped, "duration", duration)
+		return formatTestRun(commandLine, run, duration), nil
+	}
+
+	loggy.Info("ToolExecutor runTests output not parsed", "command", commandLine, "error", err, "duration", duration)
+	if err != nil {
+		return "", fmt.Errorf("tests failed and the output could not be summarized\nCommand: %s\nDuration: %v\nOutput:\n%s", commandLine, duration, raw)
+	}
+	return fmt.Sprintf("Command: %s\nResult: exited successfully; the output could not be summarized\nDuration: %v\nOutput:\n%s", commandLine, duration, raw), nil
+}
+
+// testCommandFor returns the command running the tests of a project type. The target
+// and filter can't start with "-", so they can't be read as options of the test runner,
+// such as go test -exec or pytest -p, that run other programs.
+func testCommandFor(projectType project.ProjectType, target, filter string) (*testCommand, error) {
+	if strings.HasPrefix(target, "-") {
+		return nil, fmt.Errorf("invalid target %q: targets can't start with '-'", target)
+	}
+	if strings.HasPrefix(filter, "-") {
+		return nil, fmt.Errorf("invalid filter %q: filters can't start with '-'", filter)
+	}
+
+	switch projectType {
+	case project.ProjectTypeGo:
+		if target == "" {
+			target = "./..."
+		}
+		args := []string{"test", "-json"}
+		if filter != "" {
+			args = append(args, "-run", filter)
+		}
+		return &testCommand{name: "go", args: append(args, target)}, nil
+
+	case project.ProjectTypeJavaScript, project.ProjectTypeTypeScript:
+		// Arguments after -- go to the test runner; jest and vitest both take -t
+		args := []string{"test"}
+		var extra []string
+		if target != "" {
+			extra = append(extra, target)
+		}
+		if filter != "" {
+			extra = append(extra, "-t", filter)
+		}
+		if len(extra) > 0 {
+			args = append(append(args, "--"), extra...)
+		}
+		return &testCommand{name: "npm", args: args, parse: parseJSTestOutput}, nil
+
+	case project.ProjectTypePython:
+		name, args := "pytest", []string{}
+		if _, err := exec.LookPath("pytest"); err != nil {
+			name, args = "python3", []string{"-m", "pytest"}
+		}
+		args = append(args, "-q", "-rfE", "--tb=short")
+		if filter != "" {
+			args = append(args, "-k", filter)
+		}
+		if target != "" {
+			args = append(args, target)
+		}
+		return &testCommand{name: name, args: args, parse: parsePytestOutput}, nil
+
+	default:
+		return nil, fmt.Errorf("run_tests supports Go, JavaScript/TypeScript and Python projects; this is a %s project, so run its tests with bash", projectType)
+	}
+}
+
+// formatTestRun renders the summary returned to the model
+func formatTestRun(commandLine string, run *testRun, duration time.Duration) string {
+	var result strings.Builder
+	status := "PASSED"
+	if run.failed > 0 {
+		status = "FAILED"
+	}
+	fmt.Fprintf(&result, "Command: %s\n", commandLine)
+	fmt.Fprintf(&result, "Result: %s (%d passed, %d failed, %d skipped, %d total) in %v",
+		status, run.passed, run.failed, run.skipped, run.passed+run.failed+run.skipped, duration)
+
+	if len(run.failures) > 0 {
+		result.WriteString("\nFailures:")
+	}
+	for i, failure := range run.failures {
+		if i == maxTestFailures {
+			fmt.Fprintf(&result, "\n... %d more failing tests", len(run.failures)-maxTestFailures)
+			break
+		}
+		fmt.Fprintf(&result, "\n--- %s", failure.name)
+		if failure.output != "" {
+			result.WriteString("\n" + indentLines(failure.output, "    "))
+		}
+	}
+	return result.String()
+}
+
+// failureSnippet keeps the last maxFailureLines non-empty lines of a failure, where the
+// error usually is, without the indentation they share
+func failureSnippet(lines []string) string {
+	var kept []string
+	indent := -1
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		kept = append(kept, line)
+		if n := len(line) - len(strings.TrimLeft(line, " \t")); indent < 0 || n < indent {
+			indent = n
+		}
+	}
+	for i := range kept {
+		kept[i] = kept[i][indent:]
+	}
+	if len(kept) > maxFailureLines {
+		kept = append([]string{"..."}, kept[len(kept)-maxFailureLines:]...)
+	}
+	return strings.Join(kept, "\n")
+}
+
+// indentLines prefixes every line of text
+func indentLines(text, prefix string) string {
+	return prefix + strings.ReplaceAll(text, "\n", "\n"+prefix)
+}
+
+// goTestEvent is a line of go test -json output
+type goTestEvent struct {
+	Action      string
+	Package     string
+	Test        string
+	Output      string
+	ImportPath  string // Set on build output
+	FailedBuild string // Set when a package failed to build: the ImportPath of its build output
+}
+
+// goTestEvents collects go test -json events as they stream, passing the test output
+// they carry on to onLine
+type goTestEvents struct {
+	onLine   OutputFunc
+	parsed   bool
+	results  map[string]string   // Final action of each test, by package and name
+	order    []string            // Tests in the order they finished
+	output   map[string][]string // Output of each test, and of each package under its name
+	plain    []string            // Lines that aren't events, such as build errors
+	pkgFails []goTestEvent       // Package failures
+}
+
+func newGoTestEvents(onLine OutputFunc) *goTestEvents {
+	return &goTestEvents{onLine: onLine, results: make(map[string]string), output: make(map[string][]string)}
+}
+
+// line reads one line of output
+func (e *goTestEvents) line(line string) {
+	var event goTestEvent
+	if !strings.HasPrefix(line, "{") || json.Unmarshal([]byte(line), &event) != nil || event.Action == "" {
+		e.plain = append(e.plain, line)
+		if e.onLine != nil {
+			e.onLine(line)
+		}
+		return
+	}
+	e.parsed = true
+
+	key := event.Package
+	if event.ImportPath != "" {
+		key = event.ImportPath
+	}
+	if event.Test != "" {
+		key += " " + event.Test
+	}
+	switch event.Action {
+	case "output", "build-output":
+		output := strings.TrimRight(event.Output, "\n")
+		e.output[key] = append(e.output[key], output)
+		if e.onLine != nil {
+			e.onLine(output)
+		}
+	case "pass", "fail", "skip":
+		if event.Test == "" {
+			if event.Action == "fail" {
+				e.pkgFails = append(e.pkgFails, event)
+			}
+			return
+		}
+		if _, seen := e.results[key]; !seen {
+			e.order = append(e.order, key)
+		}
+		e.results[key] = event.Action
+	}
+}
+
+// result summarizes the run. Top-level tests are counted; the failures listed are the
+// innermost failing subtests, which name what actually broke.
+func (e *goTestEvents) result() (*testRun, bool) {
+	if !e.parsed {
+		return nil, false
+	}
+
+	run := &testRun{}
+	failedPackages := make(map[string]bool)
+	for _, key := range e.order {
+		action := e.results[key]
+		pkg, name, _ := strings.Cut(key, " ")
+		if !strings.Contains(name, "/") {
+			switch action {
+			case "pass":
+				run.passed++
+			case "fail":
+				run.failed++
+			case "skip":
+				run.skipped++
+			}
+		}
+		if action != "fail" || e.hasFailedSubtest(key) {
+			continue
+		}
+		failedPackages[pkg] = true
+		run.failures = append(run.failures, testFailure{name: key, output: failureSnippet(goTestOutput(e.output[key]))})
+	}
+
+	// A package can fail without a failing test: it didn't build, or TestMain failed
+	for _, event := range e.pkgFails {
+		if failedPackages[event.Package] {
+			continue
+		}
+		lines := goTestOutput(e.output[event.Package])
+		if event.FailedBuild != "" {
+			lines = append(e.output[event.FailedBuild], lines...)
+		}
+		if len(lines) == 0 {
+			lines = e.plain // Build errors of go versions before 1.24
+		}
+		run.failed++
+		run.failures = append(run.failures, testFailure{name: event.Package + " (package failed)", output: failureSnippet(lines)})
+	}
+	return run, true
+}
+
+// hasFailedSubtest reports whether a failed test failed because of a subtest
+func (e *goTestEvents) hasFailedSubtest(key string) bool {
+	for other, action := range e.results {
+		if action == "fail" && strings.HasPrefix(other, key+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// goTestOutput drops the progress lines go test prints around each test's own output
+func goTestOutput(lines []string) []string {
+	var kept []string
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "=== ") || strings.HasPrefix(trimmed, "--- ") ||
+			trimmed == "FAIL" || trimmed == "PASS" || strings.HasPrefix(trimmed, "FAIL\t") || strings.HasPrefix(trimmed, "ok  \t") {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return kept
+}
+
+var (
+	// pytestSummary matches pytest's closing line, e.g. "2 failed, 10 passed in 0.52s"
+	pytestSummary = regexp.MustCompile(`\b\d+ (?:passed|failed|errors?|skipped)\b.* in [\d.]+s`)
+
+	// pytestCount matches one count in pytest's closing line
+	pytestCount = regexp.MustCompile(`(\d+) (passed|failed|errors?|skipped|xfailed|xpassed)`)
+
+	// pytestFailure matches a line of the -rfE short summary
+	pytestFailure = regexp.MustCompile(`^(?:FAILED|ERROR) (\S+)(?: - (.*))?$`)
+
+	// pytestSection matches the header of a failure's traceback
+	pytestSection = regexp.MustCompile(`^_{3,} (.+?) _{3,}$`)
+)
+
+// parsePytestOutput summarizes pytest -q -rfE --tb=short output
+func parsePytestOutput(output string) (*testRun, bool) {
+	lines := strings.Split(output, "\n")
+
+	run := &testRun{}
+	found := false
+	for i := len(lines) - 1; i >= 0 && !found; i-- {
+		if !pytestSummary.MatchString(lines[i]) {
+			continue
+		}
+		found = true
+		for _, match := range pytestCount.FindAllStringSubmatch(lines[i], -1) {
+			n, _ := strconv.Atoi(match[1])
+			switch match[2] {
+			case "passed", "xfailed", "xpassed":
+				run.passed += n
+			case "failed", "error", "errors":
+				run.failed += n
+			case "skipped":
+				run.skipped += n
+			}
+		}
+	}
+	if !found {
+		return nil, false
+	}
+
+	// Tracebacks come in sections headed by the test name, e.g. "TestA.test_b"
+	sections := make(map[string][]string)
+	current := ""
+	for _, line := range lines {
+		if match := pytestSection.FindStringSubmatch(line); match != nil {
+			current = strings.TrimPrefix(match[1], "ERROR at setup of ")
+			continue
+		}
+		if strings.HasPrefix(line, "===") {
+			current = ""
+			continue
+		}
+		if current != "" {
+			sections[current] = append(sections[current], line)
+		}
+	}
+
+	for _, line := range lines {
+		match := pytestFailure.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			continue
+		}
+		nodeID := match[1]
+		section := nodeID
+		if _, name, ok := strings.Cut(nodeID, "::"); ok {
+			section = strings.ReplaceAll(name, "::", ".")
+		}
+		snippet := failureSnippet(sections[section])
+		if snippet == "" {
+			snippet = match[2]
+		}
+		run.failures = append(run.failures, testFailure{name: nodeID, output: snippet})
+	}
+	return run, true
+}
+
+var (
+	// jestSummary matches jest's totals, e.g. "Tests:       1 failed, 5 passed, 6 total"
+	jestSummary = regexp.MustCompile(`^Tests:\s+(.*\d+ total)`)
+
+	// vitestSummary matches vitest's totals, e.g. "Tests  1 failed | 5 passed (6)"
+	vitestSummary = regexp.MustCompile(`^Tests\s+(.*)\(\d+\)`)
+
+	// jsCount matches one count in a jest or vitest total
+	jsCount = regexp.MustCompile(`(\d+) (passed|failed|skipped|todo|pending)`)
+
+	// jestFailure matches the heading of a jest failure, e.g. "● Suite › does a thing"
+	jestFailure = regexp.MustCompile(`^● (.+)$`)
+
+	// vitestFailure matches the heading of a vitest failure, e.g. "FAIL  a.test.ts > suite > case"
+	vitestFailure = regexp.MustCompile(`^FAIL\s+(\S.* > .+)$`)
+
+	// ansiEscape matches terminal color codes, which test runners may print even when
+	// their output isn't a terminal
+	ansiEscape = regexp.MustCompile("\x1b\\[[0-9;]*m")
+)
+
+// parseJSTestOutput summarizes the output of npm test when it runs jest or vitest
+func parseJSTestOutput(output string) (*testRun, bool) {
+	lines := strings.Split(ansiEscape.ReplaceAllString(output, ""), "\n")
+
+	run := &testRun{}
+	found := false
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		counts := jestSummary.FindStringSubmatch(trimmed)
+		if counts == nil {
+			counts = vitestSummary.FindStringSubmatch(trimmed)
+		}
+		if counts == nil {
+			continue
+		}
+		found = true
+		for _, match := range jsCount.FindAllStringSubmatch(counts[1], -1) {
+			n, _ := strconv.Atoi(match[1])
+			switch match[2] {
+			case "passed":
+				run.passed += n
+			case "failed":
+				run.failed += n
+			default:
+				run.skipped += n
+			}
+		}
+	}
+	if !found {
+		return nil, false
+	}
+
+	// Each failure is a heading followed by its error, up to the next heading
+	var current *testFailure
+	var body []string
+	finish := func() {
+		if current != nil {
+			current.output = failureSnippet(body)
+			run.failures = append(run.failures, *current)
+		}
+		current, body = nil, nil
+	}
+	seen := make(map[string]bool)
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		match := jestFailure.FindStringSubmatch(trimmed)
+		if match == nil {
+			match = vitestFailure.FindStringSubmatch(trimmed)
+		}
+		switch {
+		case match != nil:
+			finish()
+			if !seen[match[1]] {
+				seen[match[1]] = true
+				current = &testFailure{name: match[1]}
+			}
+		case strings.HasPrefix(trimmed, "Test Suites:") || strings.HasPrefix(trimmed, "Test Files") || strings.HasPrefix(trimmed, "PASS ") || strings.HasPrefix(trimmed, "FAIL "):
+			finish()
+		default:
+			body = append(body, line)
+		}
+	}
+	finish()
+	return run, true
+}
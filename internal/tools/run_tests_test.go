@@ -0,0 +1,215 @@
+package tools
+
+import (
+	"context"
+	"github.com/tildaslashalef/bazinga/internal/project"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestToolExecutor_RunTestsGo(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go not available")
+	}
+
+	tempDir := t.TempDir()
+	files := map[string]string{
+		"go.mod":  "module example.com/calc\n\ngo 1.21\n",
+		"calc.go": "package calc\n\nfunc Add(a, b int) int { return a + b }\n",
+		"calc_test.go": `package calc
+
+import "testing"
+
+func TestAdd(t *testing.T) {
+	if Add(1, 2) != 3 {
+		t.Fatal("wrong sum")
+	}
+}
+
+func TestTable(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {})
+	t.Run("broken", func(t *testing.T) {
+		t.Errorf("Add(2, 2) = %d, want 5", Add(2, 2))
+	})
+}
+
+func TestSkipped(t *testing.T) {
+	t.Skip("not yet")
+}
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+
+	var streamed []string
+	ctx := WithOutputFunc(context.Background(), func(line string) {
+		streamed = append(streamed, line)
+	})
+
+	te := NewToolExecutor(tempDir)
+	result, err := te.runTests(ctx, map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("run_tests failed: %v", err)
+	}
+
+	for _, want := range []string{
+		"Command: go test -json ./...",
+		"Result: FAILED (1 passed, 1 failed, 1 skipped, 3 total)",
+		"--- example.com/calc TestTable/broken\n    calc_test.go:14: Add(2, 2) = 4, want 5",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("Expected %q in result:\n%s", want, result)
+		}
+	}
+	if strings.Contains(result, "--- example.com/calc TestTable\n") {
+		t.Errorf("Expected only the failing subtest to be listed:\n%s", result)
+	}
+
+	if len(streamed) == 0 || strings.HasPrefix(streamed[0], "{") {
+		t.Errorf("Expected the test output to stream without the JSON events, got %q", streamed)
+	}
+}
+
+func TestToolExecutor_RunTestsGoBuildFailure(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go not available")
+	}
+
+	tempDir := t.TempDir()
+	files := map[string]string{
+		"go.mod":        "module example.com/broken\n\ngo 1.21\n",
+		"broken.go":     "package broken\n\nfunc Value() int { return \"text\" }\n",
+		"value_test.go": "package broken\n\nimport \"testing\"\n\nfunc TestValue(t *testing.T) { Value() }\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+
+	te := NewToolExecutor(tempDir)
+	result, err := te.runTests(context.Background(), map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("run_tests failed: %v", err)
+	}
+	if !strings.Contains(result, "Result: FAILED") || !strings.Contains(result, "example.com/broken (package failed)") || !strings.Contains(result, "broken.go:3") {
+		t.Errorf("Expected the build failure in the summary, got:\n%s", result)
+	}
+}
+
+func TestToolExecutor_RunTestsUnsupportedProject(t *testing.T) {
+	te := NewToolExecutor(t.TempDir())
+	if _, err := te.runTests(context.Background(), map[string]interface{}{}); err == nil || !strings.Contains(err.Error(), "generic project") {
+		t.Errorf("Expected an error naming the project type, got %v", err)
+	}
+}
+
+func TestTestCommandForRejectsOptions(t *testing.T) {
+	for _, projectType := range []project.ProjectType{project.ProjectTypeGo, project.ProjectTypeJavaScript, project.ProjectTypePython} {
+		if _, err := testCommandFor(projectType, "-exec=/bin/echo INJECTED", ""); err == nil {
+			t.Errorf("Expected an option as the %s target to be rejected", projectType)
+		}
+		if _, err := testCommandFor(projectType, "", "-p evil"); err == nil {
+			t.Errorf("Expected an option as the %s filter to be rejected", projectType)
+		}
+		if _, err := testCommandFor(projectType, "./internal/...", "TestParse"); err != nil {
+			t.Errorf("Unexpected error for the %s project: %v", projectType, err)
+		}
+	}
+}
+
+func TestParsePytestOutput(t *testing.T) {
+	output := `..F.s
+=================================== FAILURES ===================================
+____________________________ TestMath.test_divide _____________________________
+tests/test_math.py:12: in test_divide
+    assert divide(4, 2) == 3
+E   assert 2.0 == 3
+=========================== short test summary info ============================
+FAILED tests/test_math.py::TestMath::test_divide - assert 2.0 == 3
+1 failed, 3 passed, 1 skipped in 0.05s`
+
+	run, ok := parsePytestOutput(output)
+	if !ok {
+		t.Fatal("Expected the output to parse")
+	}
+	if run.passed != 3 || run.failed != 1 || run.skipped != 1 {
+		t.Errorf("Unexpected counts: %+v", run)
+	}
+	if len(run.failures) != 1 || run.failures[0].name != "tests/test_math.py::TestMath::test_divide" {
+		t.Fatalf("Unexpected failures: %+v", run.failures)
+	}
+	if !strings.Contains(run.failures[0].output, "E   assert 2.0 == 3") {
+		t.Errorf("Expected the traceback in the failure, got %q", run.failures[0].output)
+	}
+
+	if _, ok := parsePytestOutput("ERROR: file or directory not found: tests/missing.py"); ok {
+		t.Error("Expected output without a summary not to parse")
+	}
+}
+
+func TestParseJSTestOutput(t *testing.T) {
+	tests := []struct {
+		name        string
+		output      string
+		passed      int
+		failed      int
+		skipped     int
+		failureName string
+		failureText string
+	}{
+		{
+			name: "jest",
+			output: `FAIL src/sum.test.js
+  ● sum › adds numbers
+
+    expect(received).toBe(expected) // Object.is equality
+
+    Expected: 4
+    Received: 5
+
+PASS src/other.test.js
+
+Test Suites: 1 failed, 1 passed, 2 total
+Tests:       1 failed, 1 skipped, 4 passed, 6 total`,
+			passed: 4, failed: 1, skipped: 1,
+			failureName: "sum › adds numbers",
+			failureText: "Received: 5",
+		},
+		{
+			name: "vitest",
+			output: "\x1b[31m FAIL \x1b[39m src/sum.test.ts > sum > adds numbers\n" +
+				"AssertionError: expected 5 to be 4\n" +
+				" ❯ src/sum.test.ts:5:20\n\n" +
+				" Test Files  1 failed (1)\n" +
+				"      Tests  1 failed | 2 passed (3)",
+			passed: 2, failed: 1,
+			failureName: "src/sum.test.ts > sum > adds numbers",
+			failureText: "AssertionError: expected 5 to be 4",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run, ok := parseJSTestOutput(tt.output)
+			if !ok {
+				t.Fatal("Expected the output to parse")
+			}
+			if run.passed != tt.passed || run.failed != tt.failed || run.skipped != tt.skipped {
+				t.Errorf("Unexpected counts: %+v", run)
+			}
+			if len(run.failures) != 1 || run.failures[0].name != tt.failureName {
+				t.Fatalf("Unexpected failures: %+v", run.failures)
+			}
+			if !strings.Contains(run.failures[0].output, tt.failureText) {
+				t.Errorf("Expected %q in the failure, got %q", tt.failureText, run.failures[0].output)
+			}
+		})
+	}
+}
@@ -3,12 +3,15 @@ package tools
 import (
 	"fmt"
 	"github.com/tildaslashalef/bazinga/internal/loggy"
+	"github.com/tildaslashalef/bazinga/internal/project"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // Default file extensions for searching
@@ -22,6 +25,26 @@ var defaultSearchExtensions = []string{
 	".R", ".m", ".swift", ".dart", ".lua", ".perl", ".pl", ".vim", ".emacs",
 }
 
+// defaultGrepMaxResults is how many matches grep returns when max_results is not set
+const defaultGrepMaxResults = 200
+
+// ripgrepLinePrefix finds the line number of a ripgrep output line, which is wrapped in
+// colons for a match ("path:12:") and dashes for a context line ("path-12-")
+var ripgrepLinePrefix = regexp.MustCompile(`^.*?(:\d+:|-\d+-)`)
+
+// grepMaxResults returns the max_results input, or the default when it is not set
+func grepMaxResults(input map[string]interface{}) int {
+	if maxResults, ok := input["max_results"].(float64); ok && maxResults > 0 {
+		return int(maxResults)
+	}
+	return defaultGrepMaxResults
+}
+
+// grepTruncationFooter notes how many matches were left out of a capped grep result
+func grepTruncationFooter(truncated int) string {
+	return fmt.Sprintf("... (%d more matches truncated, refine your pattern)", truncated)
+}
+
 // grepFiles searches for patterns in files using ripgrep if available, fallback to native search
 func (te *ToolExecutor) grepFiles(input map[string]interface{}) (string, error) {
 	_, ok := input["pattern"].(string)
@@ -47,7 +70,8 @@ func (te *ToolExecutor) ripgrepSearch(input map[string]interface{}) (string, err
 		return "", fmt.Errorf("ripgrep not available")
 	}
 
-	args := []string{"--line-number", "--no-heading", "--color=never"}
+	maxResults := grepMaxResults(input)
+	args := []string{"--line-number", "--no-heading", "--color=never", "--with-filename", "--max-count", strconv.Itoa(maxResults)}
 
 	// Handle context lines
 	if contextLines, ok := input["context"].(float64); ok && contextLines > 0 {
@@ -86,8 +110,14 @@ func (te *ToolExecutor) ripgrepSearch(input map[string]interface{}) (string, err
 		}
 	}
 
-	// Add pattern and search path
-	args = append(args, pattern, ".")
+	// Files hidden by .bazingaignore are skipped like gitignored ones
+	if !te.ignore.Load().Empty() {
+		args = append(args, "--ignore-file", filepath.Join(te.rootPath, project.BazingaIgnoreFile))
+	}
+
+	// Add pattern and search paths
+	args = append(args, pattern)
+	args = append(args, te.allowedSearchRoots()...)
 
 	cmd := exec.Command("rg", args...)
 	cmd.Dir = te.rootPath
@@ -103,11 +133,54 @@ func (te *ToolExecutor) ripgrepSearch(input map[string]interface{}) (string, err
 		return "No matches found", nil
 	}
 
-	// Format ripgrep output
-	lines := strings.Split(result, "\n")
-	matchCount := len(lines)
+	return formatRipgrepOutput(strings.Split(result, "\n"), maxResults, hasContext(input)), nil
+}
+
+// isRipgrepMatch reports whether a line of ripgrep output is a match rather than a
+// context line or group separator
+func isRipgrepMatch(line string) bool {
+	prefix := ripgrepLinePrefix.FindStringSubmatch(line)
+	return prefix != nil && strings.HasPrefix(prefix[1], ":")
+}
 
-	return fmt.Sprintf("Found %d matches:\n%s", matchCount, result), nil
+// hasContext reports whether grep was asked for context lines around matches
+func hasContext(input map[string]interface{}) bool {
+	contextLines, ok := input["context"].(float64)
+	return ok && contextLines > 0
+}
+
+// formatRipgrepOutput keeps the lines of the first maxResults matches of ripgrep output.
+// Without context every line is a match; with it, context lines and separators are kept
+// with their match. --max-count bounds matches per file, so the total is bounded here.
+func formatRipgrepOutput(lines []string, maxResults int, withContext bool) string {
+	matches := 0
+	kept := len(lines)
+	for i, line := range lines {
+		if withContext && !isRipgrepMatch(line) {
+			continue
+		}
+		matches++
+		if matches == maxResults+1 {
+			kept = i
+		}
+	}
+
+	if matches <= maxResults {
+		return fmt.Sprintf("Found %d matches:\n%s", matches, strings.Join(lines, "\n"))
+	}
+
+	// Drop the separator and leading context of the first match that is left out
+	shown := lines[:kept]
+	for j := len(shown) - 1; withContext && j >= 0; j-- {
+		if shown[j] == "--" {
+			shown = shown[:j]
+			break
+		}
+		if isRipgrepMatch(shown[j]) {
+			break
+		}
+	}
+	return fmt.Sprintf("Found %d+ matches:\n%s\n%s", matches, strings.Join(shown, "\n"), grepTruncationFooter(matches-maxResults))
 }
 
 // nativeGrepSearch provides fallback search functionality
@@ -137,6 +210,9 @@ func (te *ToolExecutor) nativeGrepSearch(input map[string]interface{}) (string,
 		recursive = rec
 	}
 
+	// Scanning stops once more than maxResults matches are found
+	maxResults := grepMaxResults(input)
+
 	// Get context lines
 	contextLines := 0
 	if context, ok := input["context"].(float64); ok {
@@ -163,16 +239,32 @@ func (te *ToolExecutor) nativeGrepSearch(input map[string]interface{}) (string,
 	var results []SearchResult
 
 	if len(searchFiles) == 0 {
-		// Search all files in directory
+		// Search all files in directory, pruning dependencies, build output and
+		// ignored paths the way ripgrep would
+		ignorePatterns := project.LoadGitIgnore(te.rootPath)
 		err := filepath.Walk(te.rootPath, func(path string, info os.FileInfo, err error) error {
 			if err != nil {
 				return nil //nolint:nilerr // Skip errors
 			}
 
+			if skip, err := te.skipDisallowed(path, info); skip {
+				return err
+			}
+
 			if info.IsDir() && !recursive && path != te.rootPath {
 				return filepath.SkipDir
 			}
 
+			if relPath, err := filepath.Rel(te.rootPath, path); err == nil && relPath != "." {
+				depth := len(strings.Split(relPath, string(filepath.Separator)))
+				if depth > project.DefaultMaxDepth || project.ShouldIgnore(relPath, info.IsDir(), ignorePatterns) {
+					if info.IsDir() {
+						return filepath.SkipDir
+					}
+					return nil
+				}
+			}
+
 			if !info.IsDir() && te.shouldSearchFile(path, allowedExtensions) {
 				matches, err := te.searchInFileWithContext(path, regex, contextLines)
 				if err == nil && len(matches) > 0 {
@@ -186,6 +278,9 @@ func (te *ToolExecutor) nativeGrepSearch(input map[string]interface{}) (string,
 						})
 					}
 				}
+				if len(results) > maxResults {
+					return filepath.SkipAll
+				}
 			}
 			return nil
 		})
@@ -214,6 +309,9 @@ func (te *ToolExecutor) nativeGrepSearch(input map[string]interface{}) (string,
 					Context: match.Context,
 				})
 			}
+			if len(results) > maxResults {
+				break
+			}
 		}
 	}
 
@@ -221,7 +319,7 @@ func (te *ToolExecutor) nativeGrepSearch(input map[string]interface{}) (string,
 		return "No matches found", nil
 	}
 
-	return te.formatSearchResults(results), nil
+	return te.formatSearchResults(results, maxResults), nil
 }
 
 // shouldSearchFile checks if a file should be searched based on extensions
@@ -260,13 +358,22 @@ func (te *ToolExecutor) findFiles(input map[string]interface{}) (string, error)
 		fileType = typ
 	}
 
-	var results []string
+	filter, err := parseFindFilter(input)
+	if err != nil {
+		return "", err
+	}
+
+	var results []foundFile
 
-	err := filepath.Walk(searchPath, func(path string, info os.FileInfo, err error) error {
+	err = filepath.Walk(searchPath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return nil //nolint:nilerr // Skip errors
 		}
 
+		if skip, err := te.skipDisallowed(path, info); skip {
+			return err
+		}
+
 		// Filter by type
 		if fileType != "" {
 			if fileType == "file" && info.IsDir() {
@@ -285,12 +392,15 @@ func (te *ToolExecutor) findFiles(input map[string]interface{}) (string, error)
 			}
 		}
 
+		if !filter.matches(info) {
+			return nil
+		}
+
 		relPath, _ := filepath.Rel(te.rootPath, path)
 		if info.IsDir() {
-			results = append(results, relPath+"/")
-		} else {
-			results = append(results, relPath)
+			relPath += "/"
 		}
+		results = append(results, foundFile{path: relPath, size: info.Size(), modTime: info.ModTime(), dir: info.IsDir()})
 
 		return nil
 	})
@@ -302,7 +412,173 @@ func (te *ToolExecutor) findFiles(input map[string]interface{}) (string, error)
 		return "No files found matching criteria", nil
 	}
 
-	return fmt.Sprintf("Found %d files:\n%s", len(results), strings.Join(results, "\n")), nil
+	filter.sort(results)
+
+	lines := make([]string, len(results))
+	for i, result := range results {
+		lines[i] = result.path
+		if filter.details() {
+			lines[i] = result.describe()
+		}
+	}
+
+	return fmt.Sprintf("Found %d files:\n%s", len(results), strings.Join(lines, "\n")), nil
+}
+
+// findFilter holds the find tool's time, size and ordering options
+type findFilter struct {
+	modifiedWithin time.Duration // Only entries modified this recently (0 = any time)
+	minSize        int64         // Only files at least this large (0 = no minimum)
+	maxSize        int64         // Only files at most this large (0 = no maximum)
+	sortBy         string        // "name", "modified" (newest first) or "size" (largest first)
+	now            time.Time
+}
+
+// foundFile is one entry the find tool matched
+type foundFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+	dir     bool
+}
+
+// describe returns the entry with its size and modification time
+func (f foundFile) describe() string {
+	size := "-"
+	if !f.dir {
+		size = formatByteSize(int(f.size))
+	}
+	return fmt.Sprintf("%s  %s  %s", f.path, size, f.modTime.Format("2006-01-02 15:04"))
+}
+
+// parseFindFilter reads modified_within, min_size, max_size and sort from the find input
+func parseFindFilter(input map[string]interface{}) (*findFilter, error) {
+	filter := &findFilter{sortBy: "name", now: time.Now()}
+
+	if within, ok := input["modified_within"].(string); ok && within != "" {
+		duration, err := parseAge(within)
+		if err != nil {
+			return nil, fmt.Errorf("invalid modified_within %q: use a duration like 30m, 2h or 3d", within)
+		}
+		filter.modifiedWithin = duration
+	}
+
+	var err error
+	if filter.minSize, err = sizeInput(input, "min_size"); err != nil {
+		return nil, err
+	}
+	if filter.maxSize, err = sizeInput(input, "max_size"); err != nil {
+		return nil, err
+	}
+	if filter.maxSize > 0 && filter.minSize > filter.maxSize {
+		return nil, fmt.Errorf("min_size is larger than max_size")
+	}
+
+	if sortBy, ok := input["sort"].(string); ok && sortBy != "" {
+		switch sortBy {
+		case "name", "modified", "size":
+			filter.sortBy = sortBy
+		default:
+			return nil, fmt.Errorf("unknown sort %q: use name, modified or size", sortBy)
+		}
+	}
+
+	return filter, nil
+}
+
+// matches reports whether an entry passes the time and size filters. Size filters
+// only match files.
+func (f *findFilter) matches(info os.FileInfo) bool {
+	if f.modifiedWithin > 0 && f.now.Sub(info.ModTime()) > f.modifiedWithin {
+		return false
+	}
+	if f.minSize > 0 || f.maxSize > 0 {
+		if info.IsDir() {
+			return false
+		}
+		if info.Size() < f.minSize || (f.maxSize > 0 && info.Size() > f.maxSize) {
+			return false
+		}
+	}
+	return true
+}
+
+// details reports whether results should list their size and modification time
+func (f *findFilter) details() bool {
+	return f.modifiedWithin > 0 || f.minSize > 0 || f.maxSize > 0 || f.sortBy != "name"
+}
+
+// sort orders results by the requested key; name order is the walk order
+func (f *findFilter) sort(results []foundFile) {
+	switch f.sortBy {
+	case "modified":
+		sort.SliceStable(results, func(i, j int) bool { return results[i].modTime.After(results[j].modTime) })
+	case "size":
+		sort.SliceStable(results, func(i, j int) bool { return results[i].size > results[j].size })
+	}
+}
+
+// parseAge parses a duration like "90s", "2h" or "3d"; Go durations have no day unit
+func parseAge(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.ParseFloat(days, 64)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid number of days %q", days)
+		}
+		return time.Duration(n * float64(24*time.Hour)), nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration < 0 {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	return duration, nil
+}
+
+// sizeInput reads a size given as a byte count or a string like "500KB" or "1.5MB"
+func sizeInput(input map[string]interface{}, key string) (int64, error) {
+	switch value := input[key].(type) {
+	case nil:
+		return 0, nil
+	case float64:
+		if value < 0 {
+			return 0, fmt.Errorf("%s must not be negative", key)
+		}
+		return int64(value), nil
+	case string:
+		size, err := parseByteSize(value)
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s %q: use bytes or a size like 500KB or 1MB", key, value)
+		}
+		return size, nil
+	default:
+		return 0, fmt.Errorf("%s must be a number of bytes or a size like 1MB", key)
+	}
+}
+
+// byteSizeUnits are the suffixes parseByteSize accepts, longest first
+var byteSizeUnits = []struct {
+	suffix string
+	bytes  float64
+}{
+	{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10},
+	{"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10}, {"B", 1},
+}
+
+// parseByteSize parses a size like "1MB", "500 KB" or "2048" into bytes
+func parseByteSize(value string) (int64, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	multiplier := 1.0
+	for _, unit := range byteSizeUnits {
+		if number, ok := strings.CutSuffix(value, unit.suffix); ok {
+			value, multiplier = strings.TrimSpace(number), unit.bytes
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", value)
+	}
+	return int64(n * multiplier), nil
 }
 
 // SearchMatch represents a search match with context
@@ -326,6 +602,9 @@ func (te *ToolExecutor) searchInFileWithContext(filePath string, regex *regexp.R
 	if err != nil {
 		return nil, err
 	}
+	if isBinary(content) {
+		return nil, errBinaryFile
+	}
 
 	lines := strings.Split(string(content), "\n")
 	var matches []SearchMatch
@@ -362,14 +641,22 @@ func (te *ToolExecutor) searchInFileWithContext(filePath string, regex *regexp.R
 	return matches, nil
 }
 
-// formatSearchResults formats search results for display
-func (te *ToolExecutor) formatSearchResults(results []SearchResult) string {
+// formatSearchResults formats search results for display, showing at most maxResults.
+// The count of a truncated result is a lower bound since scanning stopped at the cap.
+func (te *ToolExecutor) formatSearchResults(results []SearchResult, maxResults int) string {
 	if len(results) == 0 {
 		return "No matches found"
 	}
 
 	var output []string
-	output = append(output, fmt.Sprintf("Found %d matches:", len(results)))
+	truncated := 0
+	if len(results) > maxResults {
+		truncated = len(results) - maxResults
+		output = append(output, fmt.Sprintf("Found %d+ matches:", len(results)))
+		results = results[:maxResults]
+	} else {
+		output = append(output, fmt.Sprintf("Found %d matches:", len(results)))
+	}
 
 	currentFile := ""
 	for _, result := range results {
@@ -392,6 +679,10 @@ func (te *ToolExecutor) formatSearchResults(results []SearchResult) string {
 		}
 	}
 
+	if truncated > 0 {
+		output = append(output, "", grepTruncationFooter(truncated))
+	}
+
 	return strings.Join(output, "\n")
 }
 
@@ -418,7 +709,7 @@ func (te *ToolExecutor) fzfSearch(query string) (string, error) {
 	}
 
 	// Use find to get all files, pipe to fzf
-	findCmd := exec.Command("find", ".", "-type", "f")
+	findCmd := exec.Command("find", append(te.allowedSearchRoots(), "-type", "f")...)
 	findCmd.Dir = te.rootPath
 
 	fzfCmd := exec.Command("fzf", "--filter", query, "--no-sort")
@@ -439,13 +730,17 @@ func (te *ToolExecutor) fzfSearch(query string) (string, error) {
 
 	_ = findCmd.Wait()
 
-	result := strings.TrimSpace(string(output))
-	if result == "" {
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if line != "" && !te.isIgnored(line, false) {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) == 0 {
 		return "No files found matching query", nil
 	}
 
-	lines := strings.Split(result, "\n")
-	return fmt.Sprintf("Found %d files:\n%s", len(lines), result), nil
+	return fmt.Sprintf("Found %d files:\n%s", len(lines), strings.Join(lines, "\n")), nil
 }
 
 // nativeFuzzySearch provides fallback fuzzy search
@@ -458,6 +753,10 @@ func (te *ToolExecutor) nativeFuzzySearch(query string) (string, error) {
 			return nil //nolint:nilerr
 		}
 
+		if skip, err := te.skipDisallowed(path, info); skip {
+			return err
+		}
+
 		if !info.IsDir() {
 			relPath, _ := filepath.Rel(te.rootPath, path)
 			fileName := strings.ToLower(filepath.Base(relPath))
@@ -484,6 +783,12 @@ func (te *ToolExecutor) nativeFuzzySearch(query string) (string, error) {
 
 // fuzzyMatch checks if all characters in query appear in target in order
 func (te *ToolExecutor) fuzzyMatch(target, query string) bool {
+	return FuzzyMatch(target, query)
+}
+
+// FuzzyMatch reports whether all characters of query appear in target in order, as
+// fuzzy_search matches file names. Callers lowercase both for a case-insensitive match.
+func FuzzyMatch(target, query string) bool {
 	if query == "" {
 		return true
 	}
@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestToolExecutor_Grep(t *testing.T) {
@@ -69,6 +70,134 @@ Line 4: Hello again`
 	}
 }
 
+func TestToolExecutor_GrepMaxResults(t *testing.T) {
+	tempDir := t.TempDir()
+	var content strings.Builder
+	for i := 0; i < 50; i++ {
+		content.WriteString("match here\n")
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "many.txt"), []byte(content.String()), 0o644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	te := NewToolExecutor(tempDir)
+
+	result, err := te.nativeGrepSearch(map[string]interface{}{"pattern": "match", "max_results": float64(10)})
+	if err != nil {
+		t.Fatalf("grep failed: %v", err)
+	}
+
+	if !strings.HasPrefix(result, "Found 50+ matches:") {
+		t.Errorf("Expected lower-bound count header, got: %s", result)
+	}
+	if got := strings.Count(result, "match here"); got != 10 {
+		t.Errorf("Expected 10 matches shown, got %d", got)
+	}
+	if !strings.HasSuffix(result, "... (40 more matches truncated, refine your pattern)") {
+		t.Errorf("Expected truncation footer, got: %s", result)
+	}
+
+	// Results under the cap are not truncated
+	result, err = te.nativeGrepSearch(map[string]interface{}{"pattern": "match"})
+	if err != nil {
+		t.Fatalf("grep failed: %v", err)
+	}
+	if !strings.HasPrefix(result, "Found 50 matches:") || strings.Contains(result, "truncated") {
+		t.Errorf("Expected all 50 matches without truncation, got: %s", result)
+	}
+}
+
+func TestToolExecutor_NativeGrepSkipsIgnoredPaths(t *testing.T) {
+	tempDir := t.TempDir()
+	files := map[string]string{
+		"main.go":                   "needle",
+		"node_modules/dep/index.js": "needle",
+		"vendor/lib/lib.go":         "needle",
+		"generated/out.go":          "needle",
+		"a/b/c/d/shallow.go":        "needle",
+		"a/b/c/d/e/f/too_deep.go":   "needle",
+		".gitignore":                "generated/\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(tempDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	te := NewToolExecutor(tempDir)
+	result, err := te.nativeGrepSearch(map[string]interface{}{"pattern": "needle"})
+	if err != nil {
+		t.Fatalf("grep failed: %v", err)
+	}
+
+	for _, want := range []string{"main.go", "shallow.go"} {
+		if !strings.Contains(result, want) {
+			t.Errorf("Expected %s in results, got: %s", want, result)
+		}
+	}
+	for _, unwanted := range []string{"node_modules", "vendor", "generated", "too_deep.go"} {
+		if strings.Contains(result, unwanted) {
+			t.Errorf("Expected %s to be skipped, got: %s", unwanted, result)
+		}
+	}
+}
+
+func TestToolExecutor_NativeGrepSkipsBinaryFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	files := map[string]string{
+		"main.go":  "needle",
+		"blob.txt": "needle\x00\x01\x02",
+		"data.js":  "needle" + strings.Repeat("\xff\xfe", 50),
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	te := NewToolExecutor(tempDir)
+	for _, input := range []map[string]interface{}{
+		{"pattern": "needle"},
+		{"pattern": "needle", "files": []interface{}{"main.go", "blob.txt", "data.js"}},
+	} {
+		result, err := te.nativeGrepSearch(input)
+		if err != nil {
+			t.Fatalf("grep failed: %v", err)
+		}
+		if !strings.Contains(result, "main.go") {
+			t.Errorf("Expected main.go in results, got: %s", result)
+		}
+		if strings.Contains(result, "blob.txt") || strings.Contains(result, "data.js") {
+			t.Errorf("Expected binary files to be skipped, got: %s", result)
+		}
+	}
+}
+
+func TestFormatRipgrepOutput(t *testing.T) {
+	lines := []string{"a.go:1:one", "a.go:2:two", "b.go:5:three"}
+
+	result := formatRipgrepOutput(lines, 2, false)
+	if result != "Found 3+ matches:\na.go:1:one\na.go:2:two\n... (1 more matches truncated, refine your pattern)" {
+		t.Errorf("Unexpected truncated output: %q", result)
+	}
+
+	result = formatRipgrepOutput(lines, 5, false)
+	if !strings.HasPrefix(result, "Found 3 matches:") || strings.Contains(result, "truncated") {
+		t.Errorf("Expected untruncated output, got: %q", result)
+	}
+
+	// Context lines are not counted, and the next group's separator and context are dropped
+	withContext := []string{"a.go-1-before", "a.go:2:one", "a.go-3-after", "--", "b.go-9-before", "b.go:10:two"}
+	result = formatRipgrepOutput(withContext, 1, true)
+	if result != "Found 2+ matches:\na.go-1-before\na.go:2:one\na.go-3-after\n... (1 more matches truncated, refine your pattern)" {
+		t.Errorf("Unexpected truncated context output: %q", result)
+	}
+}
+
 func TestToolExecutor_Find(t *testing.T) {
 	tempDir := t.TempDir()
 
@@ -144,6 +273,81 @@ func TestToolExecutor_Find(t *testing.T) {
 	}
 }
 
+func TestToolExecutor_FindByTimeAndSize(t *testing.T) {
+	tempDir := t.TempDir()
+
+	files := map[string]int{"small.txt": 10, "medium.txt": 2048, "large.bin": 3 * 1024 * 1024}
+	for name, size := range files {
+		if err := os.WriteFile(filepath.Join(tempDir, name), make([]byte, size), 0o644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+	}
+	old := time.Now().Add(-48 * time.Hour)
+	if err := os.Chtimes(filepath.Join(tempDir, "large.bin"), old, old); err != nil {
+		t.Fatalf("Failed to age large.bin: %v", err)
+	}
+
+	te := NewToolExecutor(tempDir)
+	find := func(input map[string]interface{}) string {
+		t.Helper()
+		result, err := te.findFiles(input)
+		if err != nil {
+			t.Fatalf("find failed: %v", err)
+		}
+		return result
+	}
+
+	result := find(map[string]interface{}{"min_size": "1MB"})
+	if !strings.Contains(result, "large.bin  3.0 MB  ") || strings.Contains(result, "medium.txt") {
+		t.Errorf("Expected only large.bin with its size, got: %s", result)
+	}
+
+	result = find(map[string]interface{}{"min_size": float64(100), "max_size": "1MB"})
+	if !strings.HasPrefix(result, "Found 1 files:\nmedium.txt  2.0 KB") {
+		t.Errorf("Expected only medium.txt, got: %s", result)
+	}
+
+	result = find(map[string]interface{}{"type": "file", "modified_within": "1d"})
+	if strings.Contains(result, "large.bin") || !strings.Contains(result, "small.txt") {
+		t.Errorf("Expected only recently modified files, got: %s", result)
+	}
+
+	result = find(map[string]interface{}{"type": "file", "sort": "size"})
+	if !strings.HasPrefix(result, "Found 3 files:\nlarge.bin") || strings.Index(result, "medium.txt") > strings.Index(result, "small.txt") {
+		t.Errorf("Expected files largest first, got: %s", result)
+	}
+
+	result = find(map[string]interface{}{"type": "file", "sort": "modified"})
+	if !strings.HasSuffix(strings.SplitN(result, "\n", 5)[3], old.Format("2006-01-02 15:04")) {
+		t.Errorf("Expected the oldest file last, got: %s", result)
+	}
+
+	// Without the new options the listing is unchanged
+	if result := find(map[string]interface{}{"name": "small.txt"}); result != "Found 1 files:\nsmall.txt" {
+		t.Errorf("Expected a plain listing, got: %s", result)
+	}
+
+	for _, input := range []map[string]interface{}{
+		{"modified_within": "soon"},
+		{"min_size": "lots"},
+		{"min_size": "2MB", "max_size": "1MB"},
+		{"sort": "color"},
+	} {
+		if _, err := te.findFiles(input); err == nil {
+			t.Errorf("Expected an error for %v", input)
+		}
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+	tests := map[string]int64{"2048": 2048, "500KB": 500 * 1024, "1.5 mb": 3 * 512 * 1024, "1G": 1 << 30, "12B": 12}
+	for value, want := range tests {
+		if got, err := parseByteSize(value); err != nil || got != want {
+			t.Errorf("parseByteSize(%q) = %d, %v; want %d", value, got, err, want)
+		}
+	}
+}
+
 func TestToolExecutor_ListFiles(t *testing.T) {
 	tempDir := t.TempDir()
 
@@ -0,0 +1,201 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/tildaslashalef/bazinga/internal/llm"
+	"github.com/tildaslashalef/bazinga/internal/loggy"
+	"github.com/tildaslashalef/bazinga/internal/tools/lsp"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// symbolsTimeout bounds a symbols query, which may wait for gopls to load the workspace
+const symbolsTimeout = 60 * time.Second
+
+// symbolsTool describes the symbols tool, offered only in Go projects
+func symbolsTool() llm.Tool {
+	return llm.Tool{
+		Name:        "symbols",
+		Description: "Find where a Go symbol is defined or every place it is referenced, using gopls. Prefer this over grep for navigating Go code: it follows the type checker, so it ignores comments, strings and other symbols with the same name. Give the file and either the symbol name (its first use outside a comment, on line if set) or its line and column.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"query": map[string]interface{}{
+					"type":        "string",
+					"enum":        []string{"definition", "references"},
+					"description": "definition finds where the symbol is declared; references finds every use of it",
+				},
+				"file_path": map[string]interface{}{
+					"type":        "string",
+					"description": "Go file containing an occurrence of the symbol",
+				},
+				"symbol": map[string]interface{}{
+					"type":        "string",
+					"description": "Name of the symbol, e.g. NewClient (optional when line and column are given)",
+				},
+				"line": map[string]interface{}{
+					"type":        "integer",
+					"description": "1-based line of the occurrence (optional)",
+				},
+				"column": map[string]interface{}{
+					"type":        "integer",
+					"description": "1-based byte column of the occurrence (optional when symbol is given)",
+				},
+			},
+			"required": []string{"query", "file_path"},
+		},
+	}
+}
+
+// EnableSymbols offers the symbols tool, which needs gopls, for Go projects
+func (te *ToolExecutor) EnableSymbols(enabled bool) {
+	te.symbolsMu.Lock()
+	defer te.symbolsMu.Unlock()
+	te.symbolsEnabled = enabled
+}
+
+// Close stops the language server the symbols tool started, if any
+func (te *ToolExecutor) Close() error {
+	te.symbolsMu.Lock()
+	defer te.symbolsMu.Unlock()
+
+	if te.symbolsClient == nil {
+		return nil
+	}
+	err := te.symbolsClient.Close()
+	te.symbolsClient = nil
+	return err
+}
+
+// findSymbol answers a definition or references query with file:line locations
+func (te *ToolExecutor) findSymbol(ctx context.Context, input map[string]interface{}) (string, error) {
+	te.symbolsMu.Lock()
+	defer te.symbolsMu.Unlock()
+
+	if !te.symbolsEnabled {
+		return "", fmt.Errorf("symbols is only available in Go projects; use grep instead")
+	}
+
+	query, _ := input["query"].(string)
+	if query != "definition" && query != "references" {
+		return "", fmt.Errorf("query must be definition or references")
+	}
+	filePath, ok := input["file_path"].(string)
+	if !ok || filePath == "" {
+		return "", fmt.Errorf("file_path is required")
+	}
+	filePath = te.absPath(filePath)
+
+	line, column, name, err := symbolPosition(filePath, input)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, symbolsTimeout)
+	defer cancel()
+
+	client, err := te.startSymbols(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	var locations []lsp.Location
+	if query == "definition" {
+		locations, err = client.Definition(ctx, filePath, line, column)
+	} else {
+		locations, err = client.References(ctx, filePath, line, column)
+	}
+	if err != nil {
+		if !client.Alive() {
+			// gopls exited; start a fresh one next time
+			_ = client.Close()
+			te.symbolsClient = nil
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			return "", fmt.Errorf("gopls did not answer within %s", symbolsTimeout)
+		}
+		return "", fmt.Errorf("failed to find %s: %w", query, err)
+	}
+
+	if len(locations) == 0 {
+		return fmt.Sprintf("No %s found for %s", query, name), nil
+	}
+
+	lines := make([]string, len(locations))
+	for i, location := range locations {
+		lines[i] = fmt.Sprintf("%s:%d:%d: %s", te.displayPath(location.Path), location.Line, location.Column, location.Text)
+	}
+	if query == "definition" {
+		return fmt.Sprintf("Definition of %s:\n%s", name, strings.Join(lines, "\n")), nil
+	}
+	return fmt.Sprintf("Found %d references to %s:\n%s", len(locations), name, strings.Join(lines, "\n")), nil
+}
+
+// startSymbols returns the running gopls client, starting it on first use
+func (te *ToolExecutor) startSymbols(ctx context.Context) (*lsp.Client, error) {
+	if te.symbolsClient != nil {
+		return te.symbolsClient, nil
+	}
+
+	client, err := lsp.Start(ctx, te.rootPath)
+	if errors.Is(err, lsp.ErrNotInstalled) {
+		return nil, fmt.Errorf("symbols needs gopls, which is not installed; install it with `go install golang.org/x/tools/gopls@latest` or use grep instead")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	loggy.Info("Started gopls for the symbols tool", "root", te.rootPath)
+	te.symbolsClient = client
+	return client, nil
+}
+
+// symbolPosition returns the 1-based line and byte column a symbols query is about,
+// and a name for the symbol to report it by
+func symbolPosition(filePath string, input map[string]interface{}) (int, int, string, error) {
+	symbol, _ := input["symbol"].(string)
+	line, column := 0, 0
+	if v, ok := input["line"].(float64); ok {
+		line = int(v)
+	}
+	if v, ok := input["column"].(float64); ok {
+		column = int(v)
+	}
+
+	if line > 0 && column > 0 {
+		name := symbol
+		if name == "" {
+			name = fmt.Sprintf("the symbol at line %d, column %d", line, column)
+		}
+		return line, column, name, nil
+	}
+	if symbol == "" {
+		return 0, 0, "", fmt.Errorf("give the symbol name, or its line and column")
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return 0, 0, "", fmt.Errorf("failed to read file: %w", err)
+	}
+
+	// The first whole-word occurrence outside a line comment, on the given line if set
+	word := regexp.MustCompile(`\b` + regexp.QuoteMeta(symbol) + `\b`)
+	for i, text := range strings.Split(string(content), "\n") {
+		if line > 0 && i+1 != line {
+			continue
+		}
+		for _, match := range word.FindAllStringIndex(text, -1) {
+			if !strings.Contains(text[:match[0]], "//") {
+				return i + 1, match[0] + 1, symbol, nil
+			}
+		}
+	}
+	if line > 0 {
+		return 0, 0, "", fmt.Errorf("%s does not appear on line %d of %s", symbol, line, filePath)
+	}
+	return 0, 0, "", fmt.Errorf("%s does not appear in %s", symbol, filePath)
+}
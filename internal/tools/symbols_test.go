@@ -0,0 +1,92 @@
+package tools
+
+import (
+	"context"
+	"github.com/tildaslashalef/bazinga/internal/llm"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSymbolsToolOnlyInGoProjects(t *testing.T) {
+	te := NewToolExecutor(t.TempDir())
+
+	hasSymbols := func() bool {
+		for _, tool := range te.GetAvailableTools() {
+			if tool.Name == "symbols" {
+				return true
+			}
+		}
+		return false
+	}
+
+	if hasSymbols() {
+		t.Error("Expected symbols to be hidden until enabled")
+	}
+	_, err := te.ExecuteTool(context.Background(), &llm.ToolCall{
+		Name:  "symbols",
+		Input: map[string]interface{}{"query": "definition", "file_path": "main.go", "symbol": "main"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "only available in Go projects") {
+		t.Errorf("Expected symbols to be refused outside Go projects, got %v", err)
+	}
+
+	te.EnableSymbols(true)
+	if !hasSymbols() {
+		t.Error("Expected symbols once enabled")
+	}
+}
+
+func TestSymbolsWithoutGopls(t *testing.T) {
+	tempDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tempDir, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	t.Setenv("PATH", t.TempDir())
+
+	te := NewToolExecutor(tempDir)
+	te.EnableSymbols(true)
+	defer func() { _ = te.Close() }()
+
+	_, err := te.findSymbol(context.Background(), map[string]interface{}{
+		"query": "references", "file_path": "main.go", "symbol": "main",
+	})
+	if err == nil || !strings.Contains(err.Error(), "gopls, which is not installed") {
+		t.Errorf("Expected a message that gopls is missing, got %v", err)
+	}
+}
+
+func TestSymbolPosition(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.go")
+	source := "package a\n\n// NewClient makes a client\nfunc NewClient() *Client { return &Client{} }\n"
+	if err := os.WriteFile(path, []byte(source), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	tests := []struct {
+		input             map[string]interface{}
+		wantLine, wantCol int
+		wantErrContains   string
+	}{
+		{input: map[string]interface{}{"symbol": "NewClient"}, wantLine: 4, wantCol: 6},
+		{input: map[string]interface{}{"symbol": "NewClient", "line": float64(3)}, wantErrContains: "does not appear on line 3"},
+		{input: map[string]interface{}{"symbol": "Client"}, wantLine: 4, wantCol: 19},
+		{input: map[string]interface{}{"line": float64(4), "column": float64(6)}, wantLine: 4, wantCol: 6},
+		{input: map[string]interface{}{"symbol": "Missing"}, wantErrContains: "does not appear"},
+		{input: map[string]interface{}{"line": float64(4)}, wantErrContains: "give the symbol name"},
+	}
+
+	for _, tt := range tests {
+		line, column, _, err := symbolPosition(path, tt.input)
+		if tt.wantErrContains != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErrContains) {
+				t.Errorf("symbolPosition(%v): expected error containing %q, got %v", tt.input, tt.wantErrContains, err)
+			}
+			continue
+		}
+		if err != nil || line != tt.wantLine || column != tt.wantCol {
+			t.Errorf("symbolPosition(%v) = %d:%d, %v; want %d:%d", tt.input, line, column, err, tt.wantLine, tt.wantCol)
+		}
+	}
+}
@@ -271,6 +271,40 @@ func (tm *TodoManager) Write(todosData string) error {
 	return tm.save()
 }
 
+// Items returns the todo items in list order
+func (tm *TodoManager) Items() ([]TodoItem, error) {
+	if err := tm.load(); err != nil {
+		return nil, err
+	}
+	return append([]TodoItem(nil), tm.items...), nil
+}
+
+// SetStatus changes the status of one todo item, keeping the rest of the list
+func (tm *TodoManager) SetStatus(id, status string) error {
+	if !isValidStatus(status) {
+		return fmt.Errorf("invalid status: %s (must be pending, in_progress, completed, or canceled)", status)
+	}
+	if err := tm.load(); err != nil {
+		return err
+	}
+
+	for i := range tm.items {
+		item := &tm.items[i]
+		if item.ID != id {
+			continue
+		}
+
+		now := time.Now()
+		if status == "completed" && item.Status != "completed" {
+			item.CompletedAt = &now
+		}
+		item.Status = status
+		item.UpdatedAt = now
+		return tm.save()
+	}
+	return fmt.Errorf("no todo with id %s", id)
+}
+
 // getStats returns statistics about the todos
 func (tm *TodoManager) getStats() map[string]int {
 	stats := map[string]int{
@@ -343,3 +343,43 @@ func TestToolExecutor_TodoEdgeCases(t *testing.T) {
 func timePtr(t time.Time) *time.Time {
 	return &t
 }
+
+func TestToolExecutor_SetTodoStatus(t *testing.T) {
+	tempDir, _, cleanup := setupTodoTest(t)
+	defer cleanup()
+
+	te := NewToolExecutor(tempDir)
+	_, err := te.ExecuteTool(context.Background(), &llm.ToolCall{
+		Name: "todo_write",
+		Input: map[string]interface{}{
+			"todos": `[{"id": "b", "content": "Second"}, {"id": "a", "content": "First", "status": "in_progress"}]`,
+		},
+	})
+	if err != nil {
+		t.Fatalf("todo_write failed: %v", err)
+	}
+
+	if err := te.SetTodoStatus("b", "completed"); err != nil {
+		t.Fatalf("SetTodoStatus failed: %v", err)
+	}
+	if err := te.SetTodoStatus("missing", "completed"); err == nil {
+		t.Error("Expected an error for an unknown todo")
+	}
+	if err := te.SetTodoStatus("a", "done"); err == nil {
+		t.Error("Expected an error for an invalid status")
+	}
+
+	items, err := te.Todos()
+	if err != nil {
+		t.Fatalf("Todos failed: %v", err)
+	}
+	if len(items) != 2 || items[0].ID != "b" || items[1].ID != "a" {
+		t.Fatalf("Expected the todos in list order, got %+v", items)
+	}
+	if items[0].Status != "completed" || items[0].CompletedAt == nil {
+		t.Errorf("Expected the first todo completed with a timestamp, got %+v", items[0])
+	}
+	if items[1].Status != "in_progress" {
+		t.Errorf("Expected the other todo unchanged, got %q", items[1].Status)
+	}
+}
@@ -6,6 +6,11 @@ import (
 	"fmt"
 	"github.com/tildaslashalef/bazinga/internal/llm"
 	"github.com/tildaslashalef/bazinga/internal/loggy"
+	"github.com/tildaslashalef/bazinga/internal/project"
+	"github.com/tildaslashalef/bazinga/internal/tools/lsp"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // ToolExecutor handles execution of tools
@@ -14,29 +19,123 @@ type ToolExecutor struct {
 	todoManager        *TodoManager
 	webFetcher         *WebFetcher
 	fileChangeCallback func(FileChange)
+	customTools        map[string]*CustomTool
+	longLineThreshold  int
+	longLinePreview    int
+	allowedPaths       []string                              // Absolute subtrees file and search tools are confined to (empty = whole root)
+	ignore             atomic.Pointer[project.IgnoreMatcher] // Paths hidden by .bazingaignore, reloaded when it changes
+	redactor           *Redactor                             // Replaces secrets in tool output (nil = off)
+	bashTimeout        time.Duration                         // Default bash timeout (0 = defaultBashTimeout)
+	maxReadBytes       int                                   // Most bytes read_file returns (0 = defaultMaxReadBytes)
+	contextTokens      int                                   // Active model's context window in tokens (0 = unknown)
+	enabledTools       map[string]bool                       // Only these tools are available (nil = all)
+	disabledTools      map[string]bool                       // Tools never offered or run
+	preCommitCommand   string                                // Check run before commits, ahead of the repo's pre-commit hook
+	skipCommitHooks    bool                                  // Commit without running pre-commit checks
+
+	// Dry-run (plan) mode: planned tools report changes to planCallback instead of
+	// writing them, and planned holds their content by absolute path (nil = removed)
+	dryRun       bool
+	planCallback func(FileChange)
+	planned      map[string]*string
+
+	// The symbols tool, offered in Go projects; gopls starts on its first query
+	symbolsMu      sync.Mutex
+	symbolsEnabled bool
+	symbolsClient  *lsp.Client
 }
 
 // NewToolExecutor creates a new tool executor
 func NewToolExecutor(rootPath string) *ToolExecutor {
 	return &ToolExecutor{
-		rootPath:    rootPath,
-		todoManager: NewTodoManager(rootPath),
-		webFetcher:  NewWebFetcher(),
+		rootPath:          rootPath,
+		todoManager:       NewTodoManager(rootPath),
+		webFetcher:        NewWebFetcher(),
+		longLineThreshold: defaultLongLineThreshold,
+		longLinePreview:   defaultLongLinePreview,
+		redactor:          defaultRedactor,
+	}
+}
+
+// SetLongLineLimits configures when read_file treats a file as minified and how much of it to preview
+func (te *ToolExecutor) SetLongLineLimits(threshold, preview int) {
+	if threshold > 0 {
+		te.longLineThreshold = threshold
+	}
+	if preview > 0 {
+		te.longLinePreview = preview
 	}
 }
 
+// SetMaxReadBytes sets the most bytes of a file read_file returns in one call
+func (te *ToolExecutor) SetMaxReadBytes(maxBytes int) {
+	if maxBytes > 0 {
+		te.maxReadBytes = maxBytes
+	}
+}
+
+// SetContextTokenLimit tells the executor the active model's context window so large
+// reads can be scaled down to fit it; 0 means unknown
+func (te *ToolExecutor) SetContextTokenLimit(tokens int) {
+	te.contextTokens = max(tokens, 0)
+}
+
+// SetBashTimeout sets how long bash commands may run when a call sets no timeout
+func (te *ToolExecutor) SetBashTimeout(timeout time.Duration) {
+	if timeout > 0 {
+		te.bashTimeout = timeout
+	}
+}
+
+// SetWebFetchLimits sets the largest page web_fetch downloads and how long it may take
+func (te *ToolExecutor) SetWebFetchLimits(maxBytes int, timeout time.Duration) {
+	te.webFetcher.SetLimits(int64(maxBytes), timeout)
+}
+
+// SetWebFetchCache sets how long web_fetch reuses a page and how many pages it keeps;
+// a ttl of zero turns caching off
+func (te *ToolExecutor) SetWebFetchCache(ttl time.Duration, maxEntries int) {
+	te.webFetcher.SetCache(ttl, maxEntries)
+}
+
 // SetFileChangeCallback sets the callback for file changes
 func (te *ToolExecutor) SetFileChangeCallback(callback func(FileChange)) {
 	te.fileChangeCallback = callback
 }
 
-// GetAvailableTools returns all available tools for the session
+// GetAvailableTools returns the tools offered to the model: every tool the tool filter
+// leaves enabled
 func (te *ToolExecutor) GetAvailableTools() []llm.Tool {
+	all := te.allTools()
+	tools := make([]llm.Tool, 0, len(all))
+	for _, tool := range all {
+		if te.IsToolEnabled(tool.Name) {
+			tools = append(tools, tool)
+		}
+	}
+	return tools
+}
+
+// allTools returns every tool the executor provides, enabled or not
+func (te *ToolExecutor) allTools() []llm.Tool {
+	tools := te.builtinTools()
+
+	te.symbolsMu.Lock()
+	if te.symbolsEnabled {
+		tools = append(tools, symbolsTool())
+	}
+	te.symbolsMu.Unlock()
+
+	return append(tools, te.customToolDefinitions()...)
+}
+
+// builtinTools returns the tools implemented by the executor itself
+func (te *ToolExecutor) builtinTools() []llm.Tool {
 	return []llm.Tool{
 		// File operations
 		{
 			Name:        "read_file",
-			Description: "Read the contents of a file",
+			Description: "Read the contents of a file. For large files, pass offset and limit to read only a section; the total line count is always reported. Files too large for the context window are cut off after their first part. Binary files are refused. PNG, JPEG, GIF and WebP images are returned as images you can see.",
 			InputSchema: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -44,10 +143,57 @@ func (te *ToolExecutor) GetAvailableTools() []llm.Tool {
 						"type":        "string",
 						"description": "The path to the file to read",
 					},
+					"offset": map[string]interface{}{
+						"type":        "number",
+						"description": "1-based line to start reading from (optional, for large files)",
+					},
+					"limit": map[string]interface{}{
+						"type":        "number",
+						"description": "Number of lines to read (optional, defaults to the rest of the file)",
+					},
 				},
 				"required": []string{"file_path"},
 			},
 		},
+		{
+			Name:        "read_files",
+			Description: "Read several files, or line ranges of them, in one call. Pass either a list of files or a glob such as 'internal/llm/**/*.go' to load a whole package. Prefer this over repeated read_file calls when tracing code across files. Each file is returned under a '==> path (lines a-b of n) <==' header.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"files": map[string]interface{}{
+						"type":        "array",
+						"description": fmt.Sprintf("Files to read (at most %d)", maxReadFilesCount),
+						"items": map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"file_path": map[string]interface{}{
+									"type":        "string",
+									"description": "The path to the file to read",
+								},
+								"start_line": map[string]interface{}{
+									"type":        "integer",
+									"description": "First line to read, 1-based (default: 1)",
+								},
+								"end_line": map[string]interface{}{
+									"type":        "integer",
+									"description": "Last line to read, inclusive (default: end of file)",
+								},
+							},
+							"required": []string{"file_path"},
+						},
+					},
+					"glob": map[string]interface{}{
+						"type":        "string",
+						"description": "Glob pattern of files to read instead of files, e.g. 'internal/llm/**/*.go'; gitignored files and directories such as node_modules are skipped",
+					},
+					"max_bytes": map[string]interface{}{
+						"type":        "integer",
+						"description": fmt.Sprintf("Combined content budget in bytes (default and maximum: %d)", maxReadFilesBytes),
+					},
+				},
+			},
+		},
 		{
 			Name:        "write_file",
 			Description: "Write content to a file (creates or overwrites)",
@@ -68,7 +214,7 @@ func (te *ToolExecutor) GetAvailableTools() []llm.Tool {
 		},
 		{
 			Name:        "edit_file",
-			Description: "Edit a file by replacing specific text",
+			Description: "Edit a file by replacing specific text. old_text must match exactly one location unless replace_all or expected_occurrences is set. When it is not found, the closest matching region is reported with its line number and exact text so you can retry",
 			InputSchema: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -84,6 +230,18 @@ func (te *ToolExecutor) GetAvailableTools() []llm.Tool {
 						"type":        "string",
 						"description": "The new text to replace with",
 					},
+					"replace_all": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Replace every occurrence of old_text. Without it, old_text must match exactly one location",
+					},
+					"expected_occurrences": map[string]interface{}{
+						"type":        "integer",
+						"description": "The number of occurrences of old_text to replace; the edit fails if the file has a different number",
+					},
+					"fuzzy": map[string]interface{}{
+						"type":        "boolean",
+						"description": "If old_text is not found exactly but one region matches it closely (e.g. only indentation differs), edit that region instead of failing",
+					},
 				},
 				"required": []string{"file_path", "old_text", "new_text"},
 			},
@@ -134,10 +292,28 @@ func (te *ToolExecutor) GetAvailableTools() []llm.Tool {
 							"required": []string{"old_text", "new_text"},
 						},
 					},
+					"atomic": map[string]interface{}{
+						"type":        "boolean",
+						"description": "If any edit fails, apply none of them (default: true). Set to false to apply the edits that match and report the rest",
+					},
 				},
 				"required": []string{"file_path", "edits"},
 			},
 		},
+		{
+			Name:        "apply_patch",
+			Description: "Apply a unified diff (--- / +++ file headers and @@ hunks) to one or more files. Hunks are placed with fuzzy context matching; hunks that don't apply are rejected and reported while the rest are applied. Use /dev/null as the old path to create a file or as the new path to delete one",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"patch": map[string]interface{}{
+						"type":        "string",
+						"description": "The unified diff to apply, with paths relative to the project root",
+					},
+				},
+				"required": []string{"patch"},
+			},
+		},
 		{
 			Name:        "move_file",
 			Description: "Move or rename a file",
@@ -244,10 +420,15 @@ func (te *ToolExecutor) GetAvailableTools() []llm.Tool {
 						"type":        "string",
 						"description": "The bash command to execute",
 					},
+					"timeout_seconds": map[string]interface{}{
+						"type":        "number",
+						"description": "Seconds before the command and everything it started are killed (default: 120, max: 600). Raise it for long builds or test suites; servers that never exit should not be run.",
+					},
 				},
 				"required": []string{"command"},
 			},
 		},
+		runTestsTool(),
 		// Search operations
 		{
 			Name:        "grep",
@@ -281,13 +462,17 @@ func (te *ToolExecutor) GetAvailableTools() []llm.Tool {
 						"items":       map[string]interface{}{"type": "string"},
 						"description": "File extensions to search (e.g. ['.go', '.js']) - defaults to common code files",
 					},
+					"max_results": map[string]interface{}{
+						"type":        "number",
+						"description": "Maximum number of matches to return (default: 200); refine the pattern if results are truncated",
+					},
 				},
 				"required": []string{"pattern"},
 			},
 		},
 		{
 			Name:        "find",
-			Description: "Find files and directories by name, extension, or path patterns. Use this tool when you need to locate specific files or discover the project structure.",
+			Description: "Find files and directories by name, extension, or path patterns, optionally filtered by modification time and size. Use this tool when you need to locate specific files, discover the project structure, or find recently changed or unusually large files.",
 			InputSchema: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -303,6 +488,23 @@ func (te *ToolExecutor) GetAvailableTools() []llm.Tool {
 						"type":        "string",
 						"description": "Directory to search in (optional)",
 					},
+					"modified_within": map[string]interface{}{
+						"type":        "string",
+						"description": "Only entries modified within this duration, e.g. 30m, 2h or 3d (optional)",
+					},
+					"min_size": map[string]interface{}{
+						"type":        "string",
+						"description": "Only files at least this large, e.g. 500KB, 1MB or a byte count (optional)",
+					},
+					"max_size": map[string]interface{}{
+						"type":        "string",
+						"description": "Only files at most this large, e.g. 500KB, 1MB or a byte count (optional)",
+					},
+					"sort": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"name", "modified", "size"},
+						"description": "Order of results: name (default), modified (newest first) or size (largest first). Any time, size or sort option lists each entry's size and modification time.",
+					},
 				},
 			},
 		},
@@ -320,6 +522,8 @@ func (te *ToolExecutor) GetAvailableTools() []llm.Tool {
 				"required": []string{"query"},
 			},
 		},
+		astOutlineTool(),
+		dependenciesTool(),
 		// Todo management
 		{
 			Name:        "todo_read",
@@ -346,10 +550,15 @@ func (te *ToolExecutor) GetAvailableTools() []llm.Tool {
 		// Git operations
 		{
 			Name:        "git_status",
-			Description: "Show the current git status",
+			Description: "Show the current git status as a summary of staged, modified, renamed, untracked and conflicted files with counts",
 			InputSchema: map[string]interface{}{
-				"type":       "object",
-				"properties": map[string]interface{}{},
+				"type": "object",
+				"properties": map[string]interface{}{
+					"raw": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Return the full `git status` output instead of the summary (default: false)",
+					},
+				},
 			},
 		},
 		{
@@ -386,7 +595,7 @@ func (te *ToolExecutor) GetAvailableTools() []llm.Tool {
 		},
 		{
 			Name:        "git_commit",
-			Description: "Create a git commit",
+			Description: "Create a git commit. The repository's pre-commit checks run first; if they fail the commit is aborted and their output returned, so fix the problems, stage the fixes and commit again",
 			InputSchema: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -415,6 +624,46 @@ func (te *ToolExecutor) GetAvailableTools() []llm.Tool {
 				},
 			},
 		},
+		{
+			Name:        "git_blame",
+			Description: fmt.Sprintf("Show who last changed each line of a file and when: commit hash, author, date and the commit summaries. Covers at most %d lines per call, so pass the range you are investigating", maxBlameLines),
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"file_path": map[string]interface{}{
+						"type":        "string",
+						"description": "The file to blame",
+					},
+					"start_line": map[string]interface{}{
+						"type":        "number",
+						"description": "First line to blame, counting from 1 (default: 1)",
+					},
+					"end_line": map[string]interface{}{
+						"type":        "number",
+						"description": fmt.Sprintf("Last line to blame, inclusive (default: the end of the file, up to %d lines from start_line)", maxBlameLines),
+					},
+				},
+				"required": []string{"file_path"},
+			},
+		},
+		{
+			Name:        "git_show",
+			Description: "Show a past commit's message and diff, or with file_path a file's content at that revision, e.g. to see what a function looked like before a change",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"ref": map[string]interface{}{
+						"type":        "string",
+						"description": "Commit hash, branch, tag or relative ref such as HEAD~3",
+					},
+					"file_path": map[string]interface{}{
+						"type":        "string",
+						"description": "Return this file's content at the revision instead of the commit diff (optional)",
+					},
+				},
+				"required": []string{"ref"},
+			},
+		},
 		{
 			Name:        "git_branch",
 			Description: "List, create, or switch git branches",
@@ -432,10 +681,59 @@ func (te *ToolExecutor) GetAvailableTools() []llm.Tool {
 				},
 			},
 		},
+		{
+			Name:        "git_stash",
+			Description: "Stash uncommitted changes to set them aside, e.g. before switching branches or trying an experiment. Reports the stash ref created.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"message": map[string]interface{}{
+						"type":        "string",
+						"description": "Description of the stashed changes (optional)",
+					},
+					"include_untracked": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Also stash untracked files (default: false)",
+					},
+				},
+			},
+		},
+		{
+			Name:        "git_stash_pop",
+			Description: "Apply a stash to the working tree and drop it. If it conflicts, the stash is kept.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"stash": map[string]interface{}{
+						"type":        "string",
+						"description": "Stash to pop, e.g. stash@{1} (default: the latest, stash@{0})",
+					},
+				},
+			},
+		},
+		{
+			Name:        "git_restore",
+			Description: "Discard working-tree changes to paths, restoring their committed state, or unstage them with staged=true",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"paths": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "File or directory paths to restore",
+					},
+					"staged": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Unstage the paths instead, keeping working-tree changes (default: false)",
+					},
+				},
+				"required": []string{"paths"},
+			},
+		},
 		// Web operations
 		{
 			Name:        "web_fetch",
-			Description: "Fetch content from a URL",
+			Description: "Fetch content from a URL. By default HTML pages are returned as Markdown with their headings, links, lists and code blocks, without scripts, navigation and other boilerplate",
 			InputSchema: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -443,6 +741,11 @@ func (te *ToolExecutor) GetAvailableTools() []llm.Tool {
 						"type":        "string",
 						"description": "The URL to fetch content from",
 					},
+					"mode": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{FetchReadable, FetchRaw},
+						"description": "readable (default) extracts the page's main content as Markdown; raw returns the response body unchanged, e.g. to inspect markup",
+					},
 				},
 				"required": []string{"url"},
 			},
@@ -450,20 +753,49 @@ func (te *ToolExecutor) GetAvailableTools() []llm.Tool {
 	}
 }
 
-// ExecuteTool executes a tool call
+// ExecuteTool executes a tool call. Secrets in its output are redacted, except for the
+// few tools that only report what they did.
 func (te *ToolExecutor) ExecuteTool(ctx context.Context, toolCall *llm.ToolCall) (string, error) {
+	output, err := te.executeTool(ctx, toolCall)
+	return te.redactOutput(toolCall.Name, output, err)
+}
+
+// executeTool runs a tool call
+func (te *ToolExecutor) executeTool(ctx context.Context, toolCall *llm.ToolCall) (string, error) {
 	loggy.Debug("ToolExecutor ExecuteTool", "tool_name", toolCall.Name, "input", toolCall.Input, "id", toolCall.ID)
 
+	if err := te.checkToolEnabled(toolCall.Name); err != nil {
+		return "", err
+	}
+	if err := te.checkToolPaths(toolCall.Name, toolCall.Input); err != nil {
+		return "", err
+	}
+	if err := te.checkDryRun(toolCall.Name); err != nil {
+		return "", err
+	}
+
 	switch toolCall.Name {
 	// File operations
 	case "read_file":
+		if filePath, ok := toolCall.Input["file_path"].(string); ok && isImagePath(filePath) {
+			// Only the placeholder here; ExecuteToolResult also returns the image
+			result, err := te.readImage(filePath)
+			if err != nil {
+				return "", err
+			}
+			return result.Text, nil
+		}
 		return te.readFile(toolCall.Input)
+	case "read_files":
+		return te.readFiles(toolCall.Input)
 	case "write_file":
 		return te.writeFile(toolCall.Input)
 	case "edit_file":
 		return te.editFile(toolCall.Input)
 	case "multi_edit_file":
 		return te.multiEditFile(toolCall.Input)
+	case "apply_patch":
+		return te.applyPatch(toolCall.Input)
 	case "list_files":
 		return te.listFiles(toolCall.Input)
 	case "create_file":
@@ -481,7 +813,9 @@ func (te *ToolExecutor) ExecuteTool(ctx context.Context, toolCall *llm.ToolCall)
 
 	// System operations
 	case "bash":
-		return te.executeBash(toolCall.Input)
+		return te.executeBash(ctx, toolCall.Input)
+	case "run_tests":
+		return te.runTests(ctx, toolCall.Input)
 
 	// Search operations
 	case "grep":
@@ -490,6 +824,12 @@ func (te *ToolExecutor) ExecuteTool(ctx context.Context, toolCall *llm.ToolCall)
 		return te.findFiles(toolCall.Input)
 	case "fuzzy_search":
 		return te.fuzzySearch(toolCall.Input)
+	case "symbols":
+		return te.findSymbol(ctx, toolCall.Input)
+	case "ast_outline":
+		return te.astOutline(toolCall.Input)
+	case "dependencies":
+		return te.listDependencies(ctx, toolCall.Input)
 
 	// Todo management
 	case "todo_read":
@@ -508,14 +848,27 @@ func (te *ToolExecutor) ExecuteTool(ctx context.Context, toolCall *llm.ToolCall)
 		return te.gitCommit(toolCall.Input)
 	case "git_log":
 		return te.gitLog(toolCall.Input)
+	case "git_blame":
+		return te.gitBlame(toolCall.Input)
+	case "git_show":
+		return te.gitShow(toolCall.Input)
 	case "git_branch":
 		return te.gitBranch(toolCall.Input)
+	case "git_stash":
+		return te.gitStash(toolCall.Input)
+	case "git_stash_pop":
+		return te.gitStashPop(toolCall.Input)
+	case "git_restore":
+		return te.gitRestore(toolCall.Input)
 
 	// Web operations
 	case "web_fetch":
 		return te.webFetch(ctx, toolCall.Input)
 
 	default:
+		if custom, ok := te.customTools[toolCall.Name]; ok {
+			return te.executeCustomTool(ctx, custom, toolCall.Input)
+		}
 		return "", fmt.Errorf("unknown tool: %s", toolCall.Name)
 	}
 }
@@ -539,6 +892,16 @@ func (te *ToolExecutor) todoWrite(input map[string]interface{}) (string, error)
 	return "Todo list updated successfully", nil
 }
 
+// Todos returns the session's todo items in list order
+func (te *ToolExecutor) Todos() ([]TodoItem, error) {
+	return te.todoManager.Items()
+}
+
+// SetTodoStatus changes the status of one todo item
+func (te *ToolExecutor) SetTodoStatus(id, status string) error {
+	return te.todoManager.SetStatus(id, status)
+}
+
 // webFetch fetches content from a URL
 func (te *ToolExecutor) webFetch(ctx context.Context, input map[string]interface{}) (string, error) {
 	url, ok := input["url"].(string)
@@ -546,12 +909,21 @@ func (te *ToolExecutor) webFetch(ctx context.Context, input map[string]interface
 		return "", fmt.Errorf("url field is required")
 	}
 
-	content, err := te.webFetcher.Fetch(ctx, url)
+	mode, _ := input["mode"].(string)
+	page, err := te.webFetcher.FetchPage(ctx, url, mode)
 	if err != nil {
 		return "", fmt.Errorf("failed to fetch URL: %w", err)
 	}
 
-	return fmt.Sprintf("Content from %s:\n\n%s", url, content), nil
+	// The header reports the extracted size, which the UI shows when the fetch completes
+	size := formatByteSize(len(page.Content))
+	if page.Mode == FetchReadable && len(page.Content) != page.Downloaded {
+		size += " extracted from " + formatByteSize(page.Downloaded)
+	}
+	if page.Cached {
+		size += ", cached"
+	}
+	return fmt.Sprintf("Content from %s (%s, %s):\n\n%s", url, page.Mode, size, page.Content), nil
 }
 
 // ToolCallFromJSON parses a tool call from JSON
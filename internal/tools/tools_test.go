@@ -20,15 +20,15 @@ func TestToolExecutor(t *testing.T) {
 
 	// Test GetAvailableTools
 	tools := executor.GetAvailableTools()
-	if len(tools) != 24 {
-		t.Errorf("Expected 24 tools, got %d", len(tools))
+	if len(tools) != 34 {
+		t.Errorf("Expected 34 tools, got %d", len(tools))
 	}
 
 	expectedTools := []string{
-		"read_file", "write_file", "edit_file", "create_file", "multi_edit_file",
-		"move_file", "copy_file", "delete_file", "create_dir", "delete_dir", "list_files",
-		"bash", "grep", "find", "fuzzy_search", "todo_read", "todo_write",
-		"git_status", "git_diff", "git_add", "git_commit", "git_log", "git_branch",
+		"read_file", "read_files", "write_file", "edit_file", "create_file", "multi_edit_file",
+		"apply_patch", "move_file", "copy_file", "delete_file", "create_dir", "delete_dir", "list_files",
+		"bash", "run_tests", "grep", "find", "fuzzy_search", "ast_outline", "dependencies", "todo_read", "todo_write",
+		"git_status", "git_diff", "git_add", "git_commit", "git_log", "git_blame", "git_show", "git_branch", "git_stash", "git_stash_pop", "git_restore",
 		"web_fetch",
 	}
 
@@ -0,0 +1,97 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// syntaxWarning checks that content written to filePath still parses, for the file
+// types we can check cheaply. It returns a warning to append to the tool result, or
+// "" when the content parses or the type isn't checked. Writes are never failed over
+// this; the warning lets the model fix the file in its next turn.
+func syntaxWarning(filePath, content string) string {
+	if strings.TrimSpace(content) == "" {
+		return ""
+	}
+
+	var err error
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".go":
+		err = validateGo(filePath, content)
+	case ".json":
+		err = validateJSON(content)
+	case ".yaml", ".yml":
+		err = validateYAML(content)
+	default:
+		return ""
+	}
+	if err == nil {
+		return ""
+	}
+
+	return fmt.Sprintf("\nWarning: %s no longer parses: %v", filepath.Base(filePath), err)
+}
+
+// validateGo parses Go source, reporting the first syntax error with its line and column
+func validateGo(filePath, content string) error {
+	_, err := parser.ParseFile(token.NewFileSet(), filepath.Base(filePath), content, parser.AllErrors)
+	var list scanner.ErrorList
+	if errors.As(err, &list) && len(list) > 0 {
+		first := list[0]
+		if len(list) > 1 {
+			return fmt.Errorf("line %d, column %d: %s (and %d more errors)", first.Pos.Line, first.Pos.Column, first.Msg, len(list)-1)
+		}
+		return fmt.Errorf("line %d, column %d: %s", first.Pos.Line, first.Pos.Column, first.Msg)
+	}
+	return err
+}
+
+// validateJSON decodes JSON, translating the byte offset of a syntax error into a line
+// and column
+func validateJSON(content string) error {
+	var value interface{}
+	err := json.Unmarshal([]byte(content), &value)
+
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		// Offset counts the bytes read, including the one that failed
+		line, column := offsetPosition(content, syntaxErr.Offset-1)
+		return fmt.Errorf("line %d, column %d: %s", line, column, syntaxErr.Error())
+	}
+	return err
+}
+
+// validateYAML decodes every document in a YAML stream. yaml.v3 errors already name
+// the line.
+func validateYAML(content string) error {
+	decoder := yaml.NewDecoder(bytes.NewReader([]byte(content)))
+	for {
+		var value interface{}
+		err := decoder.Decode(&value)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// offsetPosition converts a byte offset into a 1-based line and column
+func offsetPosition(content string, offset int64) (int, int) {
+	offset = min(max(offset, 0), int64(len(content)))
+	before := content[:offset]
+	line := strings.Count(before, "\n") + 1
+	column := int(offset) - strings.LastIndex(before, "\n")
+	return line, column
+}
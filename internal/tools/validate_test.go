@@ -0,0 +1,54 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSyntaxWarning(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		content string
+		want    string // Substring of the warning, or "" for none
+	}{
+		{"valid go", "main.go", "package main\n\nfunc main() {}\n", ""},
+		{"broken go", "main.go", "package main\n\nfunc main() {\n", "line 3, column 15"},
+		{"valid json", "config.json", `{"a": [1, 2]}`, ""},
+		{"broken json", "config.json", "{\n  \"a\": 1,\n}", "line 3, column 1"},
+		{"valid yaml", "ci.yml", "a: 1\n---\nb: [2, 3]\n", ""},
+		{"broken yaml", "ci.yaml", "a: 1\nb: [2, 3\n", "yaml:"},
+		{"unchecked type", "notes.txt", "{not json", ""},
+		{"empty file", "empty.json", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := syntaxWarning(tt.path, tt.content)
+			if tt.want == "" {
+				if got != "" {
+					t.Errorf("Expected no warning, got: %s", got)
+				}
+				return
+			}
+			if !strings.Contains(got, "no longer parses") || !strings.Contains(got, tt.want) {
+				t.Errorf("Expected warning containing %q, got: %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestToolExecutor_WriteFileSyntaxWarning(t *testing.T) {
+	te := NewToolExecutor(t.TempDir())
+
+	result, err := te.writeFile(map[string]interface{}{
+		"file_path": "bad.json",
+		"content":   `{"a": }`,
+	})
+	if err != nil {
+		t.Fatalf("writeFile should not fail on invalid content: %v", err)
+	}
+	if !strings.Contains(result, "written successfully") || !strings.Contains(result, "Warning: bad.json no longer parses") {
+		t.Errorf("Expected success with a syntax warning, got: %s", result)
+	}
+}
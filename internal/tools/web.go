@@ -2,6 +2,7 @@ package tools
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/tildaslashalef/bazinga/internal/loggy"
 	"io"
@@ -13,12 +14,29 @@ import (
 	"github.com/PuerkitoBio/goquery"
 )
 
+// Fetch modes for web_fetch
+const (
+	FetchReadable = "readable" // HTML converted to Markdown, without scripts, navigation and boilerplate
+	FetchRaw      = "raw"      // The response body as served
+)
+
+// FetchedPage is the content of a fetched URL
+type FetchedPage struct {
+	URL         string
+	Content     string
+	Mode        string
+	ContentType string
+	Downloaded  int  // Bytes of the response body
+	Cached      bool // Served from the cache rather than the network
+}
+
 // WebFetcher handles web content fetching
 type WebFetcher struct {
 	client    *http.Client
 	maxSize   int64 // Maximum response size in bytes
 	timeout   time.Duration
 	userAgent string
+	cache     *webCache
 }
 
 // NewWebFetcher creates a new web fetcher
@@ -37,15 +55,52 @@ func NewWebFetcher() *WebFetcher {
 		maxSize:   10 * 1024 * 1024, // 10MB max
 		timeout:   30 * time.Second,
 		userAgent: "Bazinga/1.0 AI Assistant",
+		cache:     newWebCache(defaultWebCacheTTL, defaultWebCacheEntries),
 	}
 }
 
-// Fetch retrieves content from a URL
+// SetLimits sets the largest response the fetcher downloads and how long a fetch may
+// take; zero values keep the current limits
+func (wf *WebFetcher) SetLimits(maxBytes int64, timeout time.Duration) {
+	if maxBytes > 0 {
+		wf.maxSize = maxBytes
+	}
+	if timeout > 0 {
+		wf.timeout = timeout
+		wf.client.Timeout = timeout
+	}
+}
+
+// SetCache sets how long fetched pages are reused and how many are kept; a ttl of zero
+// turns caching off
+func (wf *WebFetcher) SetCache(ttl time.Duration, maxEntries int) {
+	wf.cache = newWebCache(ttl, maxEntries)
+}
+
+// Fetch retrieves a URL as readable text
 func (wf *WebFetcher) Fetch(ctx context.Context, targetURL string) (string, error) {
+	page, err := wf.FetchPage(ctx, targetURL, FetchReadable)
+	if err != nil {
+		return "", err
+	}
+	return page.Content, nil
+}
+
+// FetchPage retrieves a URL. In readable mode HTML is converted to Markdown; other text
+// content and raw mode return the body as served.
+func (wf *WebFetcher) FetchPage(ctx context.Context, targetURL, mode string) (*FetchedPage, error) {
+	switch mode {
+	case "":
+		mode = FetchReadable
+	case FetchReadable, FetchRaw:
+	default:
+		return nil, fmt.Errorf("unknown mode %q (use %s or %s)", mode, FetchReadable, FetchRaw)
+	}
+
 	// Validate and normalize URL
 	parsedURL, err := url.Parse(targetURL)
 	if err != nil {
-		return "", fmt.Errorf("invalid URL: %w", err)
+		return nil, fmt.Errorf("invalid URL: %w", err)
 	}
 
 	// Ensure we have a scheme
@@ -56,15 +111,57 @@ func (wf *WebFetcher) Fetch(ctx context.Context, targetURL string) (string, erro
 
 	// Only allow HTTP and HTTPS
 	if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
-		return "", fmt.Errorf("unsupported URL scheme: %s (only http and https are allowed)", parsedURL.Scheme)
+		return nil, fmt.Errorf("unsupported URL scheme: %s (only http and https are allowed)", parsedURL.Scheme)
+	}
+
+	response, cached := wf.cache.get(targetURL)
+	if cached {
+		loggy.Debug("WebFetcher: using cached response", "url", targetURL, "mode", mode)
+	} else {
+		response, err = wf.download(ctx, targetURL)
+		if err != nil {
+			return nil, err
+		}
+		wf.cache.put(targetURL, response)
 	}
 
+	page := &FetchedPage{
+		URL:         targetURL,
+		Content:     string(response.body),
+		Mode:        mode,
+		ContentType: response.contentType,
+		Downloaded:  len(response.body),
+		Cached:      cached,
+	}
+
+	// Readable mode converts HTML to Markdown; other text is already readable
+	if mode == FetchReadable && strings.Contains(response.contentType, "html") {
+		page.Content = wf.cleanHTML(page.Content, response.finalURL)
+	}
+
+	loggy.Info("WebFetcher: successfully fetched content",
+		"url", targetURL,
+		"mode", mode,
+		"cached", cached,
+		"downloaded", page.Downloaded,
+		"size", len(page.Content),
+		"content_type", response.contentType)
+
+	return page, nil
+}
+
+// download requests a URL and reads its body within the size and time limits
+func (wf *WebFetcher) download(ctx context.Context, targetURL string) (*webResponse, error) {
 	loggy.Debug("WebFetcher: fetching URL", "url", targetURL)
 
+	// The timeout covers reading the body too, so a slow page can't hang the tool
+	fetchCtx, cancel := context.WithTimeout(ctx, wf.timeout)
+	defer cancel()
+
 	// Create request with context
-	req, err := http.NewRequestWithContext(ctx, "GET", targetURL, nil)
+	req, err := http.NewRequestWithContext(fetchCtx, "GET", targetURL, nil)
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	// Set user agent and accept headers
@@ -75,46 +172,53 @@ func (wf *WebFetcher) Fetch(ctx context.Context, targetURL string) (string, erro
 	// Make the request
 	resp, err := wf.client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to fetch URL: %w", err)
+		return nil, wf.fetchError("failed to fetch URL", ctx, err)
 	}
 	defer resp.Body.Close()
 
 	// Check status code
 	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
-		return "", fmt.Errorf("HTTP error %d: %s", resp.StatusCode, resp.Status)
+		return nil, fmt.Errorf("HTTP error %d: %s", resp.StatusCode, resp.Status)
 	}
 
 	// Check content type
 	contentType := resp.Header.Get("Content-Type")
 	if !isTextContent(contentType) {
-		return "", fmt.Errorf("unsupported content type: %s (only text content is supported)", contentType)
+		return nil, fmt.Errorf("unsupported content type: %s (only text content is supported)", contentType)
+	}
+
+	// Refuse a body that announces it is too large before reading any of it
+	if resp.ContentLength >= wf.maxSize {
+		return nil, fmt.Errorf("response too large (%d bytes, limit is %d bytes)", resp.ContentLength, wf.maxSize)
 	}
 
 	// Read response with size limit
 	limitedReader := io.LimitReader(resp.Body, wf.maxSize)
 	content, err := io.ReadAll(limitedReader)
 	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
+		return nil, wf.fetchError("failed to read response", ctx, err)
 	}
 
 	// Check if we hit the size limit
 	if int64(len(content)) >= wf.maxSize {
-		return "", fmt.Errorf("response too large (exceeded %d bytes)", wf.maxSize)
+		return nil, fmt.Errorf("response too large (exceeded %d bytes)", wf.maxSize)
 	}
 
-	contentStr := string(content)
+	return &webResponse{
+		body:        content,
+		contentType: contentType,
+		finalURL:    resp.Request.URL,
+		noStore:     hasCacheDirective(resp.Header.Get("Cache-Control"), "no-store"),
+	}, nil
+}
 
-	// Basic content cleaning for HTML
-	if strings.Contains(contentType, "html") {
-		contentStr = wf.cleanHTML(contentStr)
+// fetchError wraps an error from fetching, naming the timeout when it was the
+// fetcher's own deadline rather than the caller's that ran out
+func (wf *WebFetcher) fetchError(action string, ctx context.Context, err error) error {
+	if ctx.Err() == nil && errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("%s: timed out after %s: %w", action, wf.timeout, err)
 	}
-
-	loggy.Info("WebFetcher: successfully fetched content",
-		"url", targetURL,
-		"size", len(contentStr),
-		"content_type", contentType)
-
-	return contentStr, nil
+	return fmt.Errorf("%s: %w", action, err)
 }
 
 // isTextContent checks if the content type is text-based
@@ -137,8 +241,9 @@ func isTextContent(contentType string) bool {
 	return false
 }
 
-// cleanHTML performs HTML cleaning to extract readable text using goquery
-func (wf *WebFetcher) cleanHTML(html string) string {
+// cleanHTML extracts the readable part of a page as Markdown, keeping headings, links,
+// lists and code blocks. Relative links are resolved against base when it is set.
+func (wf *WebFetcher) cleanHTML(html string, base *url.URL) string {
 	// Parse HTML with goquery
 	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
 	if err != nil {
@@ -146,12 +251,8 @@ func (wf *WebFetcher) cleanHTML(html string) string {
 		return wf.basicCleanHTML(html)
 	}
 
-	// Remove script, style, and other non-content elements
-	doc.Find("script, style, noscript, nav, header, footer, aside").Remove()
-
-	// Remove comments (goquery handles this automatically)
-
-	// Extract text content from main content areas
+	// Extract content from main content areas; scripts, styles and navigation inside
+	// them are skipped while rendering
 	var textParts []string
 
 	// Try to find main content areas first
@@ -164,7 +265,7 @@ func (wf *WebFetcher) cleanHTML(html string) string {
 	for _, selector := range mainSelectors {
 		if selection := doc.Find(selector); selection.Length() > 0 {
 			selection.Each(func(i int, s *goquery.Selection) {
-				if text := strings.TrimSpace(s.Text()); text != "" {
+				if text := renderMarkdown(s, base); text != "" {
 					textParts = append(textParts, text)
 					foundMainContent = true
 				}
@@ -181,39 +282,31 @@ func (wf *WebFetcher) cleanHTML(html string) string {
 			// Remove navigation, sidebar, and other non-content elements
 			s.Find("nav, .nav, .navigation, .sidebar, .menu, .header, .footer, .ads, .advertisement").Remove()
 
-			if text := strings.TrimSpace(s.Text()); text != "" {
+			if text := renderMarkdown(s, base); text != "" {
 				textParts = append(textParts, text)
 			}
 		})
 	}
 
-	// Join and clean up the text
-	fullText := strings.Join(textParts, "\n\n")
-
-	// Clean up excessive whitespace
-	lines := strings.Split(fullText, "\n")
-	var cleanLines []string
-
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		// Skip empty lines and very short lines that are likely navigation/UI elements
-		if len(line) > 2 {
-			cleanLines = append(cleanLines, line)
-		}
-	}
-
-	// Remove duplicate consecutive lines
+	// Drop repeated lines, such as a title shown twice, outside code blocks
 	var finalLines []string
 	var lastLine string
+	inCode := false
 
-	for _, line := range cleanLines {
-		if line != lastLine {
-			finalLines = append(finalLines, line)
+	for _, line := range strings.Split(strings.Join(textParts, "\n\n"), "\n") {
+		if strings.HasPrefix(line, "```") {
+			inCode = !inCode
+		}
+		if !inCode && line != "" && line == lastLine {
+			continue
+		}
+		if line != "" {
 			lastLine = line
 		}
+		finalLines = append(finalLines, line)
 	}
 
-	return strings.Join(finalLines, "\n")
+	return tidyMarkdown(strings.Join(finalLines, "\n"))
 }
 
 // basicCleanHTML is a fallback method using the original manual parsing
@@ -0,0 +1,114 @@
+package tools
+
+import (
+	"container/list"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultWebCacheTTL is how long a fetched page is reused when no TTL is configured
+	defaultWebCacheTTL = 15 * time.Minute
+	// defaultWebCacheEntries is how many fetched pages are kept when no cap is configured
+	defaultWebCacheEntries = 100
+)
+
+// webResponse is a downloaded response body, kept in the cache before any mode
+// conversion so raw and readable fetches of a URL share it
+type webResponse struct {
+	body        []byte
+	contentType string
+	finalURL    *url.URL // URL after redirects, which relative links resolve against
+	noStore     bool     // The server sent Cache-Control: no-store
+}
+
+// webCache keeps recent responses by URL for a TTL, evicting the least recently used
+// entry once it holds maxEntries. A nil cache or a zero TTL caches nothing.
+type webCache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	entries    map[string]*list.Element
+	order      *list.List // Most recently used at the front
+	now        func() time.Time
+}
+
+// webCacheEntry is one cached response and when it was fetched
+type webCacheEntry struct {
+	url       string
+	response  *webResponse
+	fetchedAt time.Time
+}
+
+func newWebCache(ttl time.Duration, maxEntries int) *webCache {
+	if maxEntries <= 0 {
+		maxEntries = defaultWebCacheEntries
+	}
+	return &webCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+		now:        time.Now,
+	}
+}
+
+// get returns the cached response for a URL if it is still fresh
+func (c *webCache) get(url string) (*webResponse, bool) {
+	if c == nil || c.ttl <= 0 {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[url]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*webCacheEntry)
+	if c.now().Sub(entry.fetchedAt) > c.ttl {
+		c.order.Remove(element)
+		delete(c.entries, url)
+		return nil, false
+	}
+
+	c.order.MoveToFront(element)
+	return entry.response, true
+}
+
+// put caches a response unless the server asked for it not to be stored
+func (c *webCache) put(url string, response *webResponse) {
+	if c == nil || c.ttl <= 0 || response.noStore {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[url]; ok {
+		element.Value = &webCacheEntry{url: url, response: response, fetchedAt: c.now()}
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[url] = c.order.PushFront(&webCacheEntry{url: url, response: response, fetchedAt: c.now()})
+	for c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*webCacheEntry).url)
+	}
+}
+
+// hasCacheDirective reports whether a Cache-Control header contains a directive
+func hasCacheDirective(header, directive string) bool {
+	for _, part := range strings.Split(header, ",") {
+		name, _, _ := strings.Cut(strings.TrimSpace(part), "=")
+		if strings.EqualFold(name, directive) {
+			return true
+		}
+	}
+	return false
+}
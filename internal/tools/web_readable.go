@@ -0,0 +1,282 @@
+package tools
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// skippedElements never contain readable page content
+var skippedElements = map[string]bool{
+	"script": true, "style": true, "noscript": true, "template": true, "svg": true,
+	"iframe": true, "nav": true, "header": true, "footer": true, "aside": true,
+	"form": true, "button": true, "select": true, "head": true,
+}
+
+// blockElements start on a line of their own
+var blockElements = map[string]bool{
+	"p": true, "div": true, "section": true, "article": true, "main": true,
+	"table": true, "tr": true, "ul": true, "ol": true, "dl": true, "dt": true, "dd": true,
+	"figure": true, "figcaption": true, "details": true, "summary": true, "body": true,
+}
+
+// extraBlankLines matches runs of blank lines to collapse into one
+var extraBlankLines = regexp.MustCompile(`\n{3,}`)
+
+// markdownRenderer converts HTML to Markdown that keeps headings, paragraphs, lists,
+// links, emphasis and code blocks
+type markdownRenderer struct {
+	base      *url.URL // Page URL that relative links are resolved against (nil = leave as is)
+	out       strings.Builder
+	listDepth int
+}
+
+// renderMarkdown returns the Markdown for a selection of HTML
+func renderMarkdown(selection *goquery.Selection, base *url.URL) string {
+	r := &markdownRenderer{base: base}
+	for _, node := range selection.Nodes {
+		r.render(node)
+		r.block()
+	}
+	return tidyMarkdown(r.out.String())
+}
+
+// render writes a node and its children
+func (r *markdownRenderer) render(node *html.Node) {
+	switch node.Type {
+	case html.TextNode:
+		r.text(node.Data)
+		return
+	case html.ElementNode:
+	default:
+		r.children(node)
+		return
+	}
+
+	tag := node.Data
+	if skippedElements[tag] {
+		return
+	}
+
+	switch tag {
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		r.block()
+		r.out.WriteString(strings.Repeat("#", int(tag[1]-'0')) + " " + r.inline(node))
+		r.block()
+	case "br":
+		r.out.WriteString("\n")
+	case "hr":
+		r.block()
+		r.out.WriteString("---")
+		r.block()
+	case "pre":
+		r.block()
+		r.out.WriteString("```" + codeLanguage(node) + "\n")
+		r.out.WriteString(strings.TrimRight(textContent(node), "\n"))
+		r.out.WriteString("\n```")
+		r.block()
+	case "code":
+		if code := textContent(node); code != "" {
+			r.out.WriteString("`" + code + "`")
+		}
+	case "a":
+		r.link(node)
+	case "strong", "b":
+		r.wrap(node, "**")
+	case "em", "i":
+		r.wrap(node, "*")
+	case "img":
+		// Images can't be read as text; their alt text stands in for them
+		if alt := strings.TrimSpace(attr(node, "alt")); alt != "" {
+			r.out.WriteString("[image: " + alt + "]")
+		}
+	case "ul", "ol":
+		r.block()
+		r.listDepth++
+		number := 0
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode || child.Data != "li" {
+				r.render(child)
+				continue
+			}
+			marker := "- "
+			if tag == "ol" {
+				number++
+				marker = strconv.Itoa(number) + ". "
+			}
+			r.line()
+			r.out.WriteString(strings.Repeat("  ", r.listDepth-1) + marker)
+			r.children(child)
+		}
+		r.listDepth--
+		r.block()
+	case "blockquote":
+		r.block()
+		sub := &markdownRenderer{base: r.base}
+		sub.children(node)
+		for _, line := range strings.Split(tidyMarkdown(sub.out.String()), "\n") {
+			r.out.WriteString("> " + line + "\n")
+		}
+		r.block()
+	case "td", "th":
+		for prev := node.PrevSibling; prev != nil; prev = prev.PrevSibling {
+			if prev.Type == html.ElementNode {
+				r.out.WriteString(" | ")
+				break
+			}
+		}
+		r.children(node)
+	default:
+		if blockElements[tag] {
+			r.block()
+			r.children(node)
+			r.block()
+			return
+		}
+		r.children(node)
+	}
+}
+
+// children renders each child of node
+func (r *markdownRenderer) children(node *html.Node) {
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		r.render(child)
+	}
+}
+
+// text writes a text node with its whitespace collapsed
+func (r *markdownRenderer) text(data string) {
+	collapsed := strings.Join(strings.Fields(data), " ")
+	if collapsed == "" {
+		if data != "" && !r.atLineStart() {
+			r.out.WriteString(" ")
+		}
+		return
+	}
+	if startsWithSpace(data) && !r.atLineStart() {
+		r.out.WriteString(" ")
+	}
+	r.out.WriteString(collapsed)
+	if endsWithSpace(data) {
+		r.out.WriteString(" ")
+	}
+}
+
+// inline renders node's children on their own and returns them as one line
+func (r *markdownRenderer) inline(node *html.Node) string {
+	sub := &markdownRenderer{base: r.base}
+	sub.children(node)
+	return strings.Join(strings.Fields(sub.out.String()), " ")
+}
+
+// wrap renders node's children between marker, e.g. **bold**
+func (r *markdownRenderer) wrap(node *html.Node, marker string) {
+	if text := r.inline(node); text != "" {
+		r.out.WriteString(marker + text + marker)
+	}
+}
+
+// link renders an anchor as [text](href). Fragment and script links keep only their text.
+func (r *markdownRenderer) link(node *html.Node) {
+	text := r.inline(node)
+	href := strings.TrimSpace(attr(node, "href"))
+	if href == "" || strings.HasPrefix(href, "#") || strings.HasPrefix(strings.ToLower(href), "javascript:") {
+		r.out.WriteString(text)
+		return
+	}
+	if r.base != nil {
+		if ref, err := url.Parse(href); err == nil {
+			href = r.base.ResolveReference(ref).String()
+		}
+	}
+	if text == "" {
+		text = href
+	}
+	r.out.WriteString("[" + text + "](" + href + ")")
+}
+
+// block ends the current paragraph
+func (r *markdownRenderer) block() {
+	if r.out.Len() > 0 {
+		r.out.WriteString("\n\n")
+	}
+}
+
+// line ends the current line
+func (r *markdownRenderer) line() {
+	if s := r.out.String(); s != "" && !strings.HasSuffix(s, "\n") {
+		r.out.WriteString("\n")
+	}
+}
+
+// atLineStart reports whether nothing has been written on the current line
+func (r *markdownRenderer) atLineStart() bool {
+	s := r.out.String()
+	return s == "" || strings.HasSuffix(s, "\n") || strings.HasSuffix(s, " ")
+}
+
+// tidyMarkdown trims trailing spaces and collapses runs of blank lines
+func tidyMarkdown(markdown string) string {
+	lines := strings.Split(markdown, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.TrimSpace(extraBlankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n"))
+}
+
+// codeLanguage returns the language of a <pre> block from a "language-go" style class
+// on it or its <code>
+func codeLanguage(pre *html.Node) string {
+	nodes := []*html.Node{pre}
+	if child := pre.FirstChild; child != nil && child.Type == html.ElementNode && child.Data == "code" {
+		nodes = append(nodes, child)
+	}
+	for _, node := range nodes {
+		for _, class := range strings.Fields(attr(node, "class")) {
+			for _, prefix := range []string{"language-", "lang-"} {
+				if strings.HasPrefix(class, prefix) {
+					return strings.TrimPrefix(class, prefix)
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// textContent returns the text inside node exactly as written
+func textContent(node *html.Node) string {
+	var text strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			text.WriteString(n.Data)
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(node)
+	return text.String()
+}
+
+// attr returns the value of an attribute of node
+func attr(node *html.Node, name string) string {
+	for _, a := range node.Attr {
+		if a.Key == name {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func startsWithSpace(s string) bool {
+	return s != "" && strings.TrimLeft(s, " \t\r\n") != s
+}
+
+func endsWithSpace(s string) bool {
+	return s != "" && strings.TrimRight(s, " \t\r\n") != s
+}
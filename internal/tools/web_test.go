@@ -2,8 +2,10 @@ package tools
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
 	"time"
@@ -322,7 +324,7 @@ func TestWebFetcher_CleanHTML(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := wf.cleanHTML(tt.input)
+			result := wf.cleanHTML(tt.input, nil)
 
 			for _, expected := range tt.contains {
 				if !strings.Contains(result, expected) {
@@ -435,3 +437,186 @@ func TestWebFetcher_Fetch_RedirectLimit(t *testing.T) {
 		t.Errorf("Expected 'too many redirects' error, got: %v", err)
 	}
 }
+
+func TestWebFetcher_CleanHTMLMarkdown(t *testing.T) {
+	wf := NewWebFetcher()
+	base, _ := url.Parse("https://example.com/docs/guide")
+
+	input := `
+		<html>
+			<body>
+				<nav><a href="/">Home</a></nav>
+				<main>
+					<h1>Getting   started</h1>
+					<p>Install the <code>bazinga</code> CLI, then read the <a href="config">configuration guide</a>.</p>
+					<ul>
+						<li>Fast</li>
+						<li>Small <strong>and</strong> safe</li>
+					</ul>
+					<pre><code class="language-go">func main() {
+	fmt.Println("hi")
+}</code></pre>
+					<script>track()</script>
+				</main>
+			</body>
+		</html>
+	`
+
+	want := "# Getting started\n\n" +
+		"Install the `bazinga` CLI, then read the [configuration guide](https://example.com/docs/config).\n\n" +
+		"- Fast\n- Small **and** safe\n\n" +
+		"```go\nfunc main() {\n\tfmt.Println(\"hi\")\n}\n```"
+	if got := wf.cleanHTML(input, base); got != want {
+		t.Errorf("Unexpected Markdown:\n%s\n\nwant:\n%s", got, want)
+	}
+}
+
+func TestWebFetcher_FetchPageModes(t *testing.T) {
+	page := "<html><body><main><h2>Title</h2><p>Body text</p></main><script>x()</script></body></html>"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(page))
+	}))
+	defer server.Close()
+
+	wf := NewWebFetcher()
+	ctx := context.Background()
+
+	readable, err := wf.FetchPage(ctx, server.URL, "")
+	if err != nil {
+		t.Fatalf("FetchPage failed: %v", err)
+	}
+	if readable.Mode != FetchReadable || readable.Content != "## Title\n\nBody text" {
+		t.Errorf("Unexpected readable page: %+v", readable)
+	}
+	if readable.Downloaded != len(page) {
+		t.Errorf("Expected %d downloaded bytes, got %d", len(page), readable.Downloaded)
+	}
+
+	raw, err := wf.FetchPage(ctx, server.URL, FetchRaw)
+	if err != nil {
+		t.Fatalf("FetchPage failed: %v", err)
+	}
+	if raw.Content != page {
+		t.Errorf("Expected the raw body, got %q", raw.Content)
+	}
+
+	if _, err := wf.FetchPage(ctx, server.URL, "pdf"); err == nil || !strings.Contains(err.Error(), "unknown mode") {
+		t.Errorf("Expected an unknown mode error, got %v", err)
+	}
+
+	// The executor reports the mode and sizes in its header
+	te := NewToolExecutor(t.TempDir())
+	result, err := te.webFetch(ctx, map[string]interface{}{"url": server.URL})
+	if err != nil {
+		t.Fatalf("webFetch failed: %v", err)
+	}
+	wantHeader := fmt.Sprintf("Content from %s (readable, 19 bytes extracted from %d bytes):\n\n", server.URL, len(page))
+	if !strings.HasPrefix(result, wantHeader) {
+		t.Errorf("Expected header %q, got %q", wantHeader, result)
+	}
+}
+
+func TestWebFetcher_Limits(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(200 * time.Millisecond)
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte(strings.Repeat("a", 2048)))
+	}))
+	defer server.Close()
+
+	wf := NewWebFetcher()
+	wf.SetLimits(1024, 50*time.Millisecond)
+
+	_, err := wf.Fetch(context.Background(), server.URL)
+	if err == nil || !strings.Contains(err.Error(), "response too large") {
+		t.Errorf("Expected the size cap to apply, got %v", err)
+	}
+
+	_, err = wf.Fetch(context.Background(), server.URL+"/slow")
+	if err == nil || !strings.Contains(err.Error(), "timed out after 50ms") {
+		t.Errorf("Expected the fetch to time out, got %v", err)
+	}
+}
+
+func TestWebFetcher_Cache(t *testing.T) {
+	requests := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests[r.URL.Path]++
+		if r.URL.Path == "/private" {
+			w.Header().Set("Cache-Control", "private, no-store")
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte("page " + r.URL.Path))
+	}))
+	defer server.Close()
+
+	wf := NewWebFetcher()
+	ctx := context.Background()
+
+	first, err := wf.FetchPage(ctx, server.URL+"/a", "")
+	if err != nil {
+		t.Fatalf("FetchPage failed: %v", err)
+	}
+	second, err := wf.FetchPage(ctx, server.URL+"/a", FetchRaw)
+	if err != nil {
+		t.Fatalf("FetchPage failed: %v", err)
+	}
+	if first.Cached || !second.Cached || second.Content != "page /a" {
+		t.Errorf("Expected the second fetch to come from the cache, got %+v then %+v", first, second)
+	}
+	if requests["/a"] != 1 {
+		t.Errorf("Expected one request for /a, got %d", requests["/a"])
+	}
+
+	// no-store responses are fetched every time
+	for i := 0; i < 2; i++ {
+		if _, err := wf.FetchPage(ctx, server.URL+"/private", ""); err != nil {
+			t.Fatalf("FetchPage failed: %v", err)
+		}
+	}
+	if requests["/private"] != 2 {
+		t.Errorf("Expected no-store to bypass the cache, got %d requests", requests["/private"])
+	}
+
+	// Entries expire after the TTL
+	now := time.Now()
+	wf.SetCache(time.Minute, 2)
+	wf.cache.now = func() time.Time { return now }
+	if _, err := wf.FetchPage(ctx, server.URL+"/b", ""); err != nil {
+		t.Fatalf("FetchPage failed: %v", err)
+	}
+	now = now.Add(2 * time.Minute)
+	if page, _ := wf.FetchPage(ctx, server.URL+"/b", ""); page.Cached || requests["/b"] != 2 {
+		t.Errorf("Expected an expired entry to be fetched again, got %d requests", requests["/b"])
+	}
+
+	// The least recently used entry is dropped past the cap
+	if _, err := wf.FetchPage(ctx, server.URL+"/c", ""); err != nil {
+		t.Fatalf("FetchPage failed: %v", err)
+	}
+	_, _ = wf.FetchPage(ctx, server.URL+"/b", "")
+	if _, err := wf.FetchPage(ctx, server.URL+"/d", ""); err != nil {
+		t.Fatalf("FetchPage failed: %v", err)
+	}
+	if _, ok := wf.cache.get(server.URL + "/c"); ok {
+		t.Error("Expected /c to be evicted as the least recently used entry")
+	}
+	if _, ok := wf.cache.get(server.URL + "/b"); !ok {
+		t.Error("Expected /b to stay cached")
+	}
+
+	// A zero TTL turns caching off, and the executor notes cached results
+	te := NewToolExecutor(t.TempDir())
+	_, _ = te.webFetch(ctx, map[string]interface{}{"url": server.URL + "/e"})
+	result, err := te.webFetch(ctx, map[string]interface{}{"url": server.URL + "/e"})
+	if err != nil || !strings.HasPrefix(result, fmt.Sprintf("Content from %s/e (readable, 7 bytes, cached):", server.URL)) {
+		t.Errorf("Expected a cached header, got %q (%v)", result, err)
+	}
+	te.SetWebFetchCache(0, 0)
+	if result, _ := te.webFetch(ctx, map[string]interface{}{"url": server.URL + "/e"}); strings.Contains(result, "cached") {
+		t.Errorf("Expected no cache with a zero TTL, got %q", result)
+	}
+}
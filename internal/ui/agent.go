@@ -0,0 +1,200 @@
+package ui
+
+import (
+	"fmt"
+	"github.com/tildaslashalef/bazinga/internal/loggy"
+	"github.com/tildaslashalef/bazinga/internal/session"
+	"github.com/tildaslashalef/bazinga/internal/tools"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	// defaultAgentTurns is how many requests an /agent run may send when no budget is given
+	defaultAgentTurns = 20
+
+	// maxAgentAttempts is how many turns agent mode spends on one todo before giving up
+	maxAgentAttempts = 3
+)
+
+// todoStore is the todo list agent mode works through: the session's tool executor
+type todoStore interface {
+	Todos() ([]tools.TodoItem, error)
+	SetTodoStatus(id, status string) error
+}
+
+// sessionTodos returns the todo list of sess, or nil if it has no tool executor
+func sessionTodos(sess *session.Session) todoStore {
+	if executor := sess.GetToolExecutor(); executor != nil {
+		return executor
+	}
+	return nil
+}
+
+// agentRun is the state of /agent working through the todo list: one request per
+// todo, sent as the previous response completes, until the list is done or a stop
+// condition is hit
+type agentRun struct {
+	maxTurns int
+	turns    int
+	planning bool   // The turn in flight asks the model to break a goal into todos
+	todoID   string // The todo the last turn worked on
+	attempts int    // Turns spent on todoID so far
+}
+
+// nextAgentTodo returns the todo to work on next: one already in progress, otherwise
+// the first pending one in list order
+func nextAgentTodo(todos []tools.TodoItem) (tools.TodoItem, bool) {
+	for _, status := range []string{"in_progress", "pending"} {
+		for _, todo := range todos {
+			if todo.Status == status {
+				return todo, true
+			}
+		}
+	}
+	return tools.TodoItem{}, false
+}
+
+// agentPlanPrompt asks the model to turn a goal into the todo list agent mode works through
+func agentPlanPrompt(goal string) string {
+	return "Agent mode: break this goal down into small, concrete todos with todo_write, " +
+		"in the order they should be done. Don't start on them yet; each will be sent to you " +
+		"in its own message.\n\nGoal: " + goal
+}
+
+// agentTodoPrompt asks the model to complete a single todo
+func agentTodoPrompt(todo tools.TodoItem) string {
+	return fmt.Sprintf("Agent mode: work on this todo only, using tools as needed.\n\n"+
+		"Todo %s: %s\n\n"+
+		"When it is done, mark it completed with todo_write and stop; the next todo will follow "+
+		"in another message. If it can't be done, mark it canceled and explain why.", todo.ID, todo.Content)
+}
+
+// agentRetryPrompt asks the model to finish a todo its last turn left open
+func agentRetryPrompt(todo tools.TodoItem) string {
+	return fmt.Sprintf("Agent mode: todo %s (%s) is not marked completed yet. Finish it and mark it "+
+		"completed with todo_write, or mark it canceled and explain what is blocking it.", todo.ID, todo.Content)
+}
+
+// startAgent starts working through the todo list, first asking the model to plan the
+// todos when a goal is given
+func (m *Model) startAgent(goal string, maxTurns int) tea.Cmd {
+	notice := func(content string) tea.Cmd {
+		m.addMessage(ChatMessage{Role: "system", Content: content, Timestamp: time.Now()})
+		return nil
+	}
+
+	switch {
+	case m.agent != nil:
+		return notice("ℹ Agent mode is already running; /agent stop ends it")
+	case m.isThinking:
+		return notice("ℹ Wait for the current response to finish before starting agent mode")
+	case m.todos == nil:
+		return notice("✗ Agent mode needs an active session with tools")
+	}
+
+	if maxTurns <= 0 {
+		maxTurns = defaultAgentTurns
+	}
+	m.agent = &agentRun{maxTurns: maxTurns}
+
+	if goal != "" {
+		m.agent.planning = true
+		return m.sendAgentTurn(agentPlanPrompt(goal), "planning todos for: "+goal)
+	}
+	return m.advanceAgent()
+}
+
+// advanceAgent sends the next agent turn once a response completes, or stops agent
+// mode when all todos are done, the turn budget is spent or a todo keeps failing
+func (m *Model) advanceAgent() tea.Cmd {
+	run := m.agent
+	todos, err := m.todos.Todos()
+	if err != nil {
+		m.stopAgent(fmt.Sprintf("✗ Agent mode stopped: could not read the todo list: %v", err))
+		return nil
+	}
+
+	if run.planning {
+		run.planning = false
+		if len(todos) == 0 {
+			m.stopAgent("✗ Agent mode stopped: the model did not write any todos")
+			return nil
+		}
+	}
+
+	next, ok := nextAgentTodo(todos)
+	if !ok {
+		done, canceled := 0, 0
+		for _, todo := range todos {
+			switch todo.Status {
+			case "completed":
+				done++
+			case "canceled":
+				canceled++
+			}
+		}
+		if len(todos) == 0 {
+			m.stopAgent("ℹ No todos to work on. Give agent mode a goal to plan (/agent <goal>), or have the model write todos first")
+			return nil
+		}
+		m.stopAgent(fmt.Sprintf("✨ Agent mode finished: %d todo(s) completed, %d canceled, in %d turn(s)", done, canceled, run.turns))
+		return nil
+	}
+
+	prompt, label := agentTodoPrompt(next), next.Content
+	if next.ID == run.todoID {
+		if run.attempts >= maxAgentAttempts {
+			m.stopAgent(fmt.Sprintf("✗ Agent mode stopped: %q is still not done after %d turns. Help the model along, then /agent to continue", next.Content, run.attempts))
+			return nil
+		}
+		prompt, label = agentRetryPrompt(next), next.Content+" (again)"
+	} else {
+		run.todoID, run.attempts = next.ID, 0
+	}
+
+	if run.turns >= run.maxTurns {
+		m.stopAgent(fmt.Sprintf("⏸ Agent mode stopped: the budget of %d turns is spent. /agent continues with the remaining todos", run.maxTurns))
+		return nil
+	}
+
+	if next.Status != "in_progress" {
+		if err := m.todos.SetTodoStatus(next.ID, "in_progress"); err != nil {
+			loggy.Warn("Agent mode could not mark todo in progress", "todo", next.ID, "error", err)
+		}
+	}
+	run.attempts++
+	return m.sendAgentTurn(prompt, label)
+}
+
+// sendAgentTurn sends one agent request, showing which todo it is for
+func (m *Model) sendAgentTurn(prompt, label string) tea.Cmd {
+	run := m.agent
+	run.turns++
+	m.addMessage(ChatMessage{
+		Role:      "system",
+		Content:   fmt.Sprintf("🤖 Agent turn %d/%d: %s (esc to stop)", run.turns, run.maxTurns, label),
+		Timestamp: time.Now(),
+	})
+
+	cmd := m.dispatchRequest(prompt, false)
+	if !m.isThinking && m.pendingSend == nil {
+		// The request was refused before it was sent
+		m.stopAgent("✗ Agent mode stopped: the request could not be sent")
+	}
+	return cmd
+}
+
+// stopAgent ends agent mode, saying why
+func (m *Model) stopAgent(reason string) {
+	if m.agent == nil {
+		return
+	}
+	m.agent = nil
+	m.addMessage(ChatMessage{
+		Role:      "system",
+		Content:   reason,
+		Timestamp: time.Now(),
+	})
+}
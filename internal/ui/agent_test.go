@@ -0,0 +1,141 @@
+package ui
+
+import (
+	"fmt"
+	"github.com/tildaslashalef/bazinga/internal/tools"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTodos is an in-memory todo list for agent mode
+type fakeTodos struct {
+	items []tools.TodoItem
+}
+
+func (f *fakeTodos) Todos() ([]tools.TodoItem, error) {
+	return append([]tools.TodoItem(nil), f.items...), nil
+}
+
+func (f *fakeTodos) SetTodoStatus(id, status string) error {
+	for i := range f.items {
+		if f.items[i].ID == id {
+			f.items[i].Status = status
+			return nil
+		}
+	}
+	return fmt.Errorf("no todo with id %s", id)
+}
+
+// completeAgentTurn finishes the response in flight, as the stream closing does
+func completeAgentTurn(m *Model) {
+	m.handleStreamComplete()
+	if m.agent != nil {
+		m.advanceAgent()
+	}
+}
+
+// TestAgentWorksThroughTodos tests that agent mode sends one turn per todo and stops
+// when they are all done
+func TestAgentWorksThroughTodos(t *testing.T) {
+	m := newTestModel()
+	todos := &fakeTodos{items: []tools.TodoItem{
+		{ID: "1", Content: "Add the parser", Status: "completed"},
+		{ID: "2", Content: "Wire it into the CLI", Status: "pending"},
+		{ID: "3", Content: "Write tests", Status: "pending"},
+	}}
+	m.todos = todos
+
+	require.NotNil(t, m.startAgent("", 0))
+	require.NotNil(t, m.agent)
+	assert.Equal(t, defaultAgentTurns, m.agent.maxTurns)
+	assert.True(t, m.isThinking)
+	assert.Equal(t, "in_progress", todos.items[1].Status, "the todo being worked on is marked in progress")
+	assert.Contains(t, m.inFlight.Message, "Wire it into the CLI")
+	assert.Contains(t, m.renderStatusBar(), "Agent turn 1/20")
+
+	todos.items[1].Status = "completed"
+	completeAgentTurn(m)
+	require.NotNil(t, m.agent)
+	assert.Contains(t, m.inFlight.Message, "Write tests")
+
+	todos.items[2].Status = "canceled"
+	completeAgentTurn(m)
+	assert.Nil(t, m.agent)
+	assert.False(t, m.isThinking)
+	assert.Contains(t, m.messages[len(m.messages)-1].Content, "2 todo(s) completed, 1 canceled, in 2 turn(s)")
+}
+
+// TestAgentStopConditions tests that agent mode gives up on a todo that keeps failing,
+// stops at the turn budget and on interruption
+func TestAgentStopConditions(t *testing.T) {
+	t.Run("repeated failure", func(t *testing.T) {
+		m := newTestModel()
+		m.todos = &fakeTodos{items: []tools.TodoItem{{ID: "1", Content: "Fix the flaky test", Status: "pending"}}}
+
+		m.startAgent("", 0)
+		for i := 1; i < maxAgentAttempts; i++ {
+			completeAgentTurn(m)
+			require.NotNil(t, m.agent)
+			assert.Contains(t, m.inFlight.Message, "is not marked completed yet")
+		}
+		completeAgentTurn(m)
+		assert.Nil(t, m.agent)
+		assert.Contains(t, m.messages[len(m.messages)-1].Content, "still not done after 3 turns")
+	})
+
+	t.Run("turn budget", func(t *testing.T) {
+		m := newTestModel()
+		todos := &fakeTodos{items: []tools.TodoItem{
+			{ID: "1", Content: "First", Status: "pending"},
+			{ID: "2", Content: "Second", Status: "pending"},
+		}}
+		m.todos = todos
+
+		m.startAgent("", 1)
+		todos.items[0].Status = "completed"
+		completeAgentTurn(m)
+		assert.Nil(t, m.agent)
+		assert.Contains(t, m.messages[len(m.messages)-1].Content, "budget of 1 turns is spent")
+		assert.Equal(t, "pending", todos.items[1].Status)
+	})
+
+	t.Run("planning writes no todos", func(t *testing.T) {
+		m := newTestModel()
+		m.todos = &fakeTodos{}
+
+		m.startAgent("add dark mode", 0)
+		require.NotNil(t, m.agent)
+		assert.Contains(t, m.inFlight.Message, "Goal: add dark mode")
+
+		completeAgentTurn(m)
+		assert.Nil(t, m.agent)
+		assert.Contains(t, m.messages[len(m.messages)-1].Content, "did not write any todos")
+	})
+
+	t.Run("interrupted", func(t *testing.T) {
+		m := newTestModel()
+		m.todos = &fakeTodos{items: []tools.TodoItem{{ID: "1", Content: "First", Status: "pending"}}}
+
+		m.startAgent("", 0)
+		m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+		assert.Nil(t, m.agent)
+		assert.False(t, m.isThinking)
+	})
+}
+
+// TestNextAgentTodo tests that a todo in progress is resumed before pending ones
+func TestNextAgentTodo(t *testing.T) {
+	next, ok := nextAgentTodo([]tools.TodoItem{
+		{ID: "1", Status: "completed"},
+		{ID: "2", Status: "pending"},
+		{ID: "3", Status: "in_progress"},
+	})
+	require.True(t, ok)
+	assert.Equal(t, "3", next.ID)
+
+	_, ok = nextAgentTodo([]tools.TodoItem{{ID: "1", Status: "canceled"}})
+	assert.False(t, ok)
+}
@@ -16,6 +16,10 @@ type CommandDefinition struct {
 	Category    string
 }
 
+// ArgumentSource returns the values the next argument of command can take, e.g. the
+// model IDs for "/model", or nil if the command has no known values
+type ArgumentSource func(command string) []CommandDefinition
+
 // AutocompleteState manages command autocomplete functionality
 type AutocompleteState struct {
 	active           bool
@@ -23,6 +27,11 @@ type AutocompleteState struct {
 	filteredCommands []CommandDefinition
 	selectedIndex    int
 	maxVisible       int
+
+	// Argument completion: the source of values, and the command the shown values
+	// complete (empty while completing command names)
+	arguments  ArgumentSource
+	argumentOf string
 }
 
 // NewAutocompleteState creates a new autocomplete state
@@ -30,22 +39,49 @@ func NewAutocompleteState() *AutocompleteState {
 	commands := []CommandDefinition{
 		// Project Setup
 		{Command: "/init", Args: "", Description: "Analyze project and create Bazinga.md", Category: "files"},
+		{Command: "/add", Args: "[path...]", Description: "Add files to context, or pick them from the project", Category: "files"},
+		{Command: "/overview", Args: "[refresh] [save]", Description: "Summarize the codebase architecture from its main files", Category: "files"},
+		{Command: "/rescan", Args: "", Description: "Scan the project again for the file list the AI sees", Category: "files"},
 
 		// Quick Notes
 		// {Command: "/#", Args: "<note>", Description: "Quickly add a timestamped note to Bazinga.md", Category: "files"},
 		// {Command: "#", Args: "<note>", Description: "Quickly add a timestamped note to Bazinga.md", Category: "files"},
 
+		// Planning
+		{Command: "/think", Args: "<question>", Description: "Reason and propose next steps without running tools", Category: "help"},
+		{Command: "/retry", Args: "", Description: "Send the last failed message again", Category: "help"},
+		{Command: "/copy", Args: "[all]", Description: "Copy the last code block, or the whole last response, to the clipboard", Category: "help"},
+		{Command: "/plan", Args: "[show|discard]", Description: "Toggle plan mode: preview file changes and apply them as a batch", Category: "help"},
+		{Command: "/apply", Args: "", Description: "Write the file changes planned in plan mode", Category: "help"},
+		{Command: "/agent", Args: "[--turns N] [goal]", Description: "Work through the todo list autonomously, one todo per request", Category: "help"},
+
 		// Git Operations
-		{Command: "/commit", Args: "[message]", Description: "Commit changes (AI-generated if none provided)", Category: "git"},
+		{Command: "/commit", Args: "[--conventional] [message]", Description: "Commit changes (AI-generated if none provided)", Category: "git"},
+		{Command: "/diff", Args: "[path]", Description: "Show the net change to each file edited this session", Category: "git"},
+		{Command: "/changes", Args: "", Description: "Show the net diff vs HEAD for files edited this session", Category: "git"},
+		{Command: "/undo", Args: "", Description: "Revert the most recent file change", Category: "git"},
 
 		// Memory Management
 		{Command: "/memory", Args: "", Description: "View/manage memory", Category: "memory"},
 
 		// Session Management
-		// {Command: "/sessions", Args: "", Description: "List and manage saved sessions", Category: "sessions"},
+		{Command: "/sessions", Args: "", Description: "List saved sessions to resume", Category: "sessions"},
+		{Command: "/resume", Args: "<number|id>", Description: "Load a saved session and continue its conversation", Category: "sessions"},
+		{Command: "/export", Args: "[path]", Description: "Export the conversation, with tool calls and diffs, to a Markdown file", Category: "sessions"},
 
 		// Configuration
 		{Command: "/config", Args: "", Description: "View/update configuration", Category: "config"},
+		{Command: "/model", Args: "[id]", Description: "Show or switch the model", Category: "config"},
+		{Command: "/provider", Args: "[name]", Description: "Show or switch the LLM provider", Category: "config"},
+		{Command: "/reload-config", Args: "", Description: "Re-read the config file and apply it without restarting", Category: "config"},
+		{Command: "/cost", Args: "", Description: "Show tokens and estimated cost per model this session", Category: "config"},
+		{Command: "/compact", Args: "", Description: "Summarize older conversation history to free up context", Category: "config"},
+		{Command: "/permissions", Args: "export|import <file>", Description: "Share permission rules as a YAML or JSON file", Category: "config"},
+		{Command: "/rules", Args: "", Description: "List the permission rules from security.permission_rules", Category: "config"},
+		{Command: "/tools", Args: "", Description: "List the tools the model can use", Category: "config"},
+		{Command: "/lang", Args: "[code|off]", Description: "Set the language the assistant responds in", Category: "config"},
+		{Command: "/prompt", Args: "show", Description: "Show the system prompt the model currently sees", Category: "config"},
+		{Command: "/theme", Args: "[name|path]", Description: "Switch the markdown theme for chat messages", Category: "config"},
 
 		// Help
 		{Command: "/help", Args: "", Description: "Show available commands", Category: "help"},
@@ -73,11 +109,12 @@ func (a *AutocompleteState) Update(input string) {
 
 	query := parts[0]
 
-	// If command is complete and user is typing args, hide autocomplete
-	if len(parts) > 1 {
-		a.active = false
+	// Past the command name, complete its argument if it has known values
+	if len(parts) > 1 || strings.HasSuffix(input, " ") {
+		a.updateArguments(input)
 		return
 	}
+	a.argumentOf = ""
 
 	// Filter commands based on input
 	a.filteredCommands = []CommandDefinition{}
@@ -112,6 +149,40 @@ func (a *AutocompleteState) Update(input string) {
 	a.selectedIndex = 0
 }
 
+// SetArgumentSource sets where argument values are looked up as the user types them
+func (a *AutocompleteState) SetArgumentSource(source ArgumentSource) {
+	a.arguments = source
+}
+
+// updateArguments offers the values of the argument being typed, filtered by what has
+// been typed of it so far
+func (a *AutocompleteState) updateArguments(input string) {
+	a.active = false
+	a.argumentOf = ""
+	if a.arguments == nil {
+		return
+	}
+
+	fields := strings.Fields(input)
+	query := ""
+	if !strings.HasSuffix(input, " ") {
+		query = strings.ToLower(fields[len(fields)-1])
+		fields = fields[:len(fields)-1]
+	}
+	command := strings.Join(fields, " ")
+
+	a.filteredCommands = []CommandDefinition{}
+	for _, value := range a.arguments(command) {
+		if strings.HasPrefix(strings.ToLower(value.Command), query) {
+			a.filteredCommands = append(a.filteredCommands, value)
+		}
+	}
+
+	a.argumentOf = command
+	a.active = len(a.filteredCommands) > 0
+	a.selectedIndex = 0
+}
+
 // Navigate changes the selected command
 func (a *AutocompleteState) Navigate(direction int) {
 	if !a.active || len(a.filteredCommands) == 0 {
@@ -251,6 +322,85 @@ func (a *AutocompleteState) GetCompletionText() string {
 		return ""
 	}
 
+	// An argument completes the command it belongs to
+	if a.argumentOf != "" {
+		return a.argumentOf + " " + selected.Command
+	}
+
 	// Return just the command part for completion
 	return selected.Command
 }
+
+// argumentSuggestions is the model's ArgumentSource: the current provider's models for
+// /model and the available providers for /provider, as the session reports them now,
+// and the built-in markdown themes for /theme
+func (m *Model) argumentSuggestions(command string) []CommandDefinition {
+	if command == "/theme" {
+		var suggestions []CommandDefinition
+		for _, theme := range markdownThemes() {
+			suggestions = append(suggestions, CommandDefinition{Command: theme, Description: "Markdown theme", Category: "config"})
+		}
+		return suggestions
+	}
+	if command == "/resume" {
+		return m.savedSessionSuggestions()
+	}
+	if m.session == nil {
+		return nil
+	}
+	session := (&CommandAdapter{model: m}).GetSession()
+
+	var suggestions []CommandDefinition
+	switch command {
+	case "/model", "/config model":
+		current := session.GetModel()
+		for _, model := range session.GetAvailableModels()[session.GetProvider()] {
+			description := model.Name
+			if model.ID == current {
+				description += " (current)"
+			}
+			suggestions = append(suggestions, CommandDefinition{Command: model.ID, Description: description, Category: "config"})
+		}
+	case "/provider", "/config provider":
+		current := session.GetProvider()
+		providers := session.GetAvailableProviders()
+		sort.Strings(providers)
+		for _, provider := range providers {
+			description := "Switch to " + provider
+			if provider == current {
+				description = "Current provider"
+			}
+			suggestions = append(suggestions, CommandDefinition{Command: provider, Description: description, Category: "config"})
+		}
+	}
+	return suggestions
+}
+
+// savedSessionSuggestions offers the saved sessions for /resume, most recent first. The
+// list is read once and kept until the next message is sent, as reading it loads every
+// session file.
+func (m *Model) savedSessionSuggestions() []CommandDefinition {
+	if m.savedSessions == nil {
+		sessions, err := (&CommandAdapter{model: m}).GetSessionManager().ListSavedSessions()
+		if err != nil {
+			return nil
+		}
+		sort.SliceStable(sessions, func(i, j int) bool {
+			return sessions[i].UpdatedAt.After(sessions[j].UpdatedAt)
+		})
+		m.savedSessions = sessions
+	}
+
+	var suggestions []CommandDefinition
+	for _, sess := range m.savedSessions {
+		if m.session != nil && sess.ID == m.session.ID {
+			continue
+		}
+		description := fmt.Sprintf("%s · %d messages", sess.UpdatedAt.Format("2006-01-02 15:04"), sess.Messages)
+		if sess.Name != "" {
+			description = sess.Name + " · " + description
+		}
+		suggestions = append(suggestions, CommandDefinition{Command: sess.ID, Description: description, Category: "sessions"})
+	}
+	return suggestions
+}
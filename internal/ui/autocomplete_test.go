@@ -0,0 +1,50 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAutocompleteArguments tests that argument values come from the source and
+// complete into the full command line
+func TestAutocompleteArguments(t *testing.T) {
+	a := NewAutocompleteState()
+	var asked []string
+	a.SetArgumentSource(func(command string) []CommandDefinition {
+		asked = append(asked, command)
+		if command != "/model" {
+			return nil
+		}
+		return []CommandDefinition{
+			{Command: "gpt-4o", Description: "GPT-4o"},
+			{Command: "gpt-4o-mini", Description: "GPT-4o mini"},
+			{Command: "o3", Description: "o3"},
+		}
+	})
+
+	a.Update("/model ")
+	require.True(t, a.IsActive())
+	assert.Equal(t, "/model gpt-4o", a.GetCompletionText())
+
+	a.Update("/model GPT-4o-")
+	require.True(t, a.IsActive())
+	assert.Equal(t, "/model gpt-4o-mini", a.GetCompletionText())
+
+	a.Update("/model o")
+	a.Navigate(1)
+	assert.Equal(t, "/model o3", a.GetCompletionText(), "navigation wraps around")
+
+	a.Update("/model o3 ")
+	assert.False(t, a.IsActive(), "only the first argument is completed")
+
+	a.Update("/lang ")
+	assert.False(t, a.IsActive(), "commands without known values show nothing")
+	assert.Equal(t, []string{"/model", "/model", "/model", "/model o3", "/lang"}, asked)
+
+	// Back to command names
+	a.Update("/mod")
+	require.True(t, a.IsActive())
+	assert.Equal(t, "/model", a.GetCompletionText())
+}
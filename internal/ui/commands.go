@@ -2,6 +2,7 @@ package ui
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/tildaslashalef/bazinga/internal/llm"
 	"github.com/tildaslashalef/bazinga/internal/loggy"
@@ -108,6 +109,25 @@ func (a *CommandAdapter) AddMessage(role, content string, streaming bool) {
 	})
 }
 
+func (a *CommandAdapter) UndoLastChange() (string, error) {
+	return a.model.undoLastChange()
+}
+
+// SessionDiff renders the net change to each file changed this session, or only to path
+func (a *CommandAdapter) SessionDiff(path string) (string, int) {
+	return a.model.sessionDiff(path)
+}
+
+// LastAssistantMessage returns the content of the most recent assistant response
+func (a *CommandAdapter) LastAssistantMessage() string {
+	for i := len(a.model.messages) - 1; i >= 0; i-- {
+		if a.model.messages[i].Role == "assistant" {
+			return a.model.messages[i].Content
+		}
+	}
+	return ""
+}
+
 // SessionAdapter adapts the session to the commands.Session interface
 type SessionAdapter struct {
 	session *session.Session
@@ -137,12 +157,102 @@ func (s *SessionAdapter) GetDiffOutput() (string, error) {
 	return s.session.GetDiffOutput()
 }
 
+func (s *SessionAdapter) GetChangesDiff(ctx context.Context) (string, error) {
+	return s.session.GetChangesDiff(ctx)
+}
+
+func (s *SessionAdapter) TouchedFiles() []string {
+	return s.session.TouchedFiles()
+}
+
+func (s *SessionAdapter) GetResponseLanguage() string {
+	return s.session.GetResponseLanguage()
+}
+
+func (s *SessionAdapter) SetResponseLanguage(language string) {
+	s.session.SetResponseLanguage(language)
+}
+
+func (s *SessionAdapter) SystemPrompt() string {
+	return s.session.SystemPrompt()
+}
+
+func (s *SessionAdapter) SystemPromptFile() string {
+	return s.session.SystemPromptFile()
+}
+
+func (s *SessionAdapter) ReloadConfig() (commands.ConfigReload, error) {
+	result, err := s.session.ReloadConfig()
+	if err != nil {
+		return commands.ConfigReload{}, err
+	}
+	return commands.ConfigReload(*result), nil
+}
+
+func (s *SessionAdapter) CachedOverview() (string, bool) {
+	return s.session.CachedOverview()
+}
+
+func (s *SessionAdapter) PrepareOverview(save bool) (string, error) {
+	return s.session.PrepareOverview(save)
+}
+
+func (s *SessionAdapter) SaveOverviewToMemory(ctx context.Context) error {
+	return s.session.SaveOverviewToMemory(ctx)
+}
+
+func (s *SessionAdapter) GetUsageBreakdown() []commands.ModelUsage {
+	breakdown := s.session.GetUsageBreakdown()
+	usage := make([]commands.ModelUsage, len(breakdown))
+	for i, u := range breakdown {
+		usage[i] = commands.ModelUsage(u)
+	}
+	return usage
+}
+
+func (s *SessionAdapter) Compact(ctx context.Context) (commands.CompactResult, error) {
+	result, err := s.session.Compact(ctx)
+	if err != nil {
+		return commands.CompactResult{}, err
+	}
+	return commands.CompactResult(*result), nil
+}
+
+func (s *SessionAdapter) ToolSet() commands.ToolSet {
+	executor := s.session.GetToolExecutor()
+	if executor == nil {
+		return commands.ToolSet{}
+	}
+	active, disabled := executor.ToolNames()
+	return commands.ToolSet{Active: active, Disabled: disabled, Unknown: executor.UnknownFilterNames()}
+}
+
+func (s *SessionAdapter) PlanMode() bool {
+	return s.session.PlanMode()
+}
+
+func (s *SessionAdapter) SetPlanMode(enabled bool) {
+	s.session.SetPlanMode(enabled)
+}
+
+func (s *SessionAdapter) PendingPlan() []string {
+	return s.session.PendingPlan()
+}
+
+func (s *SessionAdapter) DiscardPlan() int {
+	return s.session.DiscardPlan()
+}
+
+func (s *SessionAdapter) ApplyPlan(ctx context.Context) (int, error) {
+	return s.session.ApplyPlan(ctx)
+}
+
 func (s *SessionAdapter) CommitChanges(ctx context.Context, message string) error {
 	return s.session.CommitChanges(ctx, message)
 }
 
-func (s *SessionAdapter) CommitWithAI(ctx context.Context) (string, error) {
-	return s.session.CommitWithAI(ctx)
+func (s *SessionAdapter) CommitWithAI(ctx context.Context, conventional bool) (string, error) {
+	return s.session.CommitWithAI(ctx, conventional)
 }
 
 func (s *SessionAdapter) SetModel(model string) error {
@@ -188,6 +298,10 @@ func (s *SessionAdapter) GetProjectSummary() string {
 	return s.session.GetProjectSummary()
 }
 
+func (s *SessionAdapter) RescanProject() (added, removed int, err error) {
+	return s.session.RescanProject()
+}
+
 func (s *SessionAdapter) GetBranchInfo() (string, error) {
 	return s.session.GetBranchInfo()
 }
@@ -237,6 +351,39 @@ func (s *SessionAdapter) ID() string {
 	return s.session.GetID()
 }
 
+// Transcript returns the session history with the diff of each file change attached to
+// the result of the tool that made it
+func (s *SessionAdapter) Transcript() []commands.TranscriptEntry {
+	entries := make([]commands.TranscriptEntry, len(s.session.History))
+	for i, msg := range s.session.History {
+		entry := commands.TranscriptEntry{
+			Role:       msg.Role,
+			Content:    llm.DisplayText(msg),
+			ToolCallID: msg.ToolCallID,
+			ToolName:   msg.Name,
+			IsError:    msg.IsError,
+		}
+		for _, call := range msg.ToolCalls {
+			entry.ToolCalls = append(entry.ToolCalls, commands.TranscriptToolCall{
+				ID:    call.ID,
+				Name:  call.CallName(),
+				Input: call.CallInput(),
+			})
+		}
+		entries[i] = entry
+	}
+
+	for _, change := range s.session.FileChanges() {
+		if change.HistoryIndex < 0 || change.HistoryIndex >= len(entries) {
+			continue
+		}
+		diff := GenerateDiff(change.FilePath, change.Before, change.After, change.Operation)
+		entries[change.HistoryIndex].Diffs = append(entries[change.HistoryIndex].Diffs, diff.UnifiedDiff())
+	}
+
+	return entries
+}
+
 // ProjectAdapter adapts the project to the commands.Project interface
 type ProjectAdapter struct {
 	project *project.Project
@@ -264,6 +411,9 @@ func (sm *SessionManagerAdapter) SaveSession(session commands.Session) error {
 }
 
 func (sm *SessionManagerAdapter) ListSavedSessions() ([]commands.SavedSessionInfo, error) {
+	if sm.sm == nil {
+		return nil, fmt.Errorf("session manager not available")
+	}
 	sessions, err := sm.sm.ListSavedSessions()
 	if err != nil {
 		return nil, err
@@ -274,7 +424,10 @@ func (sm *SessionManagerAdapter) ListSavedSessions() ([]commands.SavedSessionInf
 		result = append(result, commands.SavedSessionInfo{
 			ID:        sess.ID,
 			Name:      sess.Name,
+			RootPath:  sess.RootPath,
+			Messages:  len(sess.History),
 			CreatedAt: sess.CreatedAt,
+			UpdatedAt: sess.UpdatedAt,
 		})
 	}
 
@@ -286,10 +439,22 @@ type PermissionManagerAdapter struct {
 	pm *session.PermissionManager
 }
 
-func (pma *PermissionManagerAdapter) GetToolRisk(toolCall interface{}) string {
-	// For the simplified interface, we just return a static value
-	// In a full implementation, this would analyze the actual tool call
-	return "medium"
+// GetToolRisk assesses a tool call from its arguments: the files it touches and the
+// command it runs
+func (pma *PermissionManagerAdapter) GetToolRisk(toolCall *llm.ToolCall) string {
+	return pma.pm.GetToolRisk(toolCall)
+}
+
+func (pma *PermissionManagerAdapter) ExportRules(path string) error {
+	return pma.pm.ExportRules(path)
+}
+
+func (pma *PermissionManagerAdapter) ImportRules(path string) ([]string, error) {
+	return pma.pm.ImportRules(path)
+}
+
+func (pma *PermissionManagerAdapter) ConfigRules() []string {
+	return pma.pm.ConfigRules()
 }
 
 // handleSendMessage processes user input and sends to AI
@@ -303,6 +468,14 @@ func (m *Model) handleSendMessage() tea.Cmd {
 
 	// Clear the input
 	m.textarea.Reset()
+	if m.history != nil {
+		m.history.Add(input)
+	}
+
+	// Sending a message means the user wants to follow the conversation again
+	m.clearToolFocus()
+	m.savedSessions = nil
+	m.viewport.GotoBottom()
 
 	// Add user message to chat
 	m.addMessage(ChatMessage{
@@ -318,24 +491,9 @@ func (m *Model) handleSendMessage() tea.Cmd {
 		return m.handleSessionCommand(input)
 	}
 
-	// Set thinking state and add placeholder message
-	m.isThinking = true
-	m.thinkingStartTime = time.Now()
-	// Estimate input tokens (rough approximation: 1 token ≈ 4 characters)
-	m.inputTokens = len(input) / 4
-	if m.inputTokens < 1 {
-		m.inputTokens = 1
-	}
-	m.addMessage(ChatMessage{
-		Role:      "assistant",
-		Content:   "",
-		Timestamp: time.Now(),
-		Streaming: true,
-	})
-
-	// Send to AI
+	// Send to AI, asking first if the request is expensive
 	loggy.Debug("UI handling send message", "component", "handleSendMessage", "action", "calling_sendToAI", "input", input)
-	return m.sendToAI(input)
+	return m.dispatchRequest(input, false)
 }
 
 // handleSessionCommand processes session commands using the new command registry
@@ -386,11 +544,21 @@ func (m *Model) handleSessionCommand(command string) tea.Cmd {
 
 // sendToAI sends user message to AI and returns streaming response
 func (m *Model) sendToAI(message string) tea.Cmd {
+	return m.streamToAI(message, m.session.ProcessMessageStream)
+}
+
+// sendThinkToAI sends a /think question to AI with tools disabled
+func (m *Model) sendThinkToAI(message string) tea.Cmd {
+	return m.streamToAI(message, m.session.ProcessThinkStream)
+}
+
+// streamToAI starts a streaming request using the given session stream function
+func (m *Model) streamToAI(message string, stream func(context.Context, string) (<-chan *llm.StreamChunk, error)) tea.Cmd {
 	return func() tea.Msg {
 		loggy.Debug("UI sending to AI", "component", "sendToAI", "action", "starting", "message", message)
 
 		// Use streaming response for real-time updates
-		streamChan, err := m.session.ProcessMessageStream(context.Background(), message)
+		streamChan, err := stream(context.Background(), message)
 		if err != nil {
 			loggy.Error("UI send to AI failed", "component", "sendToAI", "error", "ProcessMessageStream_failed", "err", err, "message", message)
 			return ErrorMsg{Error: fmt.Errorf("failed to process message: %w", err)}
@@ -466,10 +634,27 @@ func (m *Model) handleError(msg ErrorMsg) {
 		m.messages = m.messages[:len(m.messages)-1]
 	}
 
+	// The request failed before the model replied; keep it so it can be sent again
+	content := "❌ Error: " + msg.Error.Error()
+	var providerErr *llm.ProviderError
+	if errors.As(msg.Error, &providerErr) {
+		if guidance := providerErr.Guidance(); guidance != "" {
+			content += "\n" + guidance
+		}
+	}
+	if m.inFlight != nil {
+		m.failedRequest = m.inFlight
+		m.inFlight = nil
+		m.retryArmed = true
+		content += "\nPress " + retryKey + " or type /retry to send it again"
+	}
+	m.isThinking = false
+	m.currentStream = nil
+
 	// Add error message
 	m.addMessage(ChatMessage{
 		Role:      "system",
-		Content:   "❌ Error: " + msg.Error.Error(),
+		Content:   content,
 		Timestamp: time.Now(),
 	})
 
@@ -577,10 +762,23 @@ func (m *Model) getToolFileName(toolCall *llm.ToolCall) string {
 	return ""
 }
 
+// imagePlaceholder returns the "[image/png 800×600, 12.3 KB]" line of a read_file result
+// for an image, which stands in for the image in the chat
+func imagePlaceholder(result string) (string, bool) {
+	if !strings.HasPrefix(result, "Image: ") {
+		return "", false
+	}
+	lines := strings.SplitN(result, "\n", 3)
+	if len(lines) < 2 || !strings.HasPrefix(lines[1], "[") {
+		return "", false
+	}
+	return lines[1], true
+}
+
 // getToolActionName returns user-friendly action name for tool
 func (m *Model) getToolActionName(toolName string) string {
 	switch toolName {
-	case "read_file":
+	case "read_file", "read_files":
 		return "Read"
 	case "write_file":
 		return "Write"
@@ -603,6 +801,14 @@ func (m *Model) getToolActionName(toolName string) string {
 func (m *Model) formatToolResult(toolCall *llm.ToolCall, result string) string {
 	switch toolCall.Name {
 	case "read_file":
+		if placeholder, ok := imagePlaceholder(result); ok {
+			return "Viewed image " + placeholder
+		}
+		// A range read reports "Showing: lines 10-40 of 900"
+		if _, showing, ok := strings.Cut(result, "\nShowing: "); ok {
+			showing, _, _ = strings.Cut(showing, "\n")
+			return fmt.Sprintf("Read %s (ctrl+r to expand)", showing)
+		}
 		// Extract line count from our modified readFile result
 		if strings.Contains(result, "Lines: ") {
 			// Parse "File: path\nLines: 123\nContent:\n..." format
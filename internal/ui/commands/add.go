@@ -0,0 +1,58 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// AddCommand handles the /add command, adding files to the session context. Without
+// arguments it opens a picker over the project's files.
+type AddCommand struct{}
+
+func (c *AddCommand) Execute(ctx context.Context, args []string, model CommandModel) tea.Msg {
+	if len(args) == 0 {
+		return OpenFilePickerMsg{}
+	}
+
+	session := model.GetSession()
+	var added, failed []string
+	for _, arg := range args {
+		path := arg
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(session.GetRootPath(), path)
+		}
+		if err := session.AddFile(ctx, path); err != nil {
+			failed = append(failed, fmt.Sprintf("  • %s: %v", arg, err))
+			continue
+		}
+		added = append(added, "  • "+arg)
+	}
+
+	var lines []string
+	if len(added) > 0 {
+		lines = append(lines, fmt.Sprintf("✓ Added %d file(s) to context:", len(added)))
+		lines = append(lines, added...)
+	}
+	if len(failed) > 0 {
+		lines = append(lines, "✗ Could not add:")
+		lines = append(lines, failed...)
+	}
+
+	return ResponseMsg{Content: strings.Join(lines, "\n")}
+}
+
+func (c *AddCommand) GetName() string {
+	return "add"
+}
+
+func (c *AddCommand) GetUsage() string {
+	return "/add [path...]"
+}
+
+func (c *AddCommand) GetDescription() string {
+	return "Add files to context, or pick them from the project"
+}
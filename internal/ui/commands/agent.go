@@ -0,0 +1,46 @@
+package commands
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// AgentCommand handles the /agent command, which works through the todo list one todo
+// per request without waiting for the user between them
+type AgentCommand struct{}
+
+func (c *AgentCommand) Execute(ctx context.Context, args []string, model CommandModel) tea.Msg {
+	if len(args) == 1 && args[0] == "stop" {
+		return StopAgentMsg{}
+	}
+
+	msg := StartAgentMsg{}
+	if len(args) > 0 && args[0] == "--turns" {
+		if len(args) < 2 {
+			return ResponseMsg{Content: "Usage: " + c.GetUsage()}
+		}
+		turns, err := strconv.Atoi(args[1])
+		if err != nil || turns <= 0 {
+			return ResponseMsg{Content: "✗ --turns takes a positive number of requests, got " + args[1]}
+		}
+		msg.MaxTurns = turns
+		args = args[2:]
+	}
+	msg.Goal = strings.Join(args, " ")
+	return msg
+}
+
+func (c *AgentCommand) GetName() string {
+	return "agent"
+}
+
+func (c *AgentCommand) GetUsage() string {
+	return "/agent [--turns N] [goal] | /agent stop"
+}
+
+func (c *AgentCommand) GetDescription() string {
+	return "Work through the todo list autonomously, one todo per request"
+}
@@ -0,0 +1,52 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ChangesCommand handles the /changes command, showing the net git diff for files
+// the assistant edited this session
+type ChangesCommand struct{}
+
+func (c *ChangesCommand) Execute(ctx context.Context, args []string, model CommandModel) tea.Msg {
+	session := model.GetSession()
+	if session == nil {
+		return ResponseMsg{Content: "✗ No active session"}
+	}
+
+	touched := session.TouchedFiles()
+	if len(touched) == 0 {
+		return ResponseMsg{Content: "ℹ No files have been edited this session"}
+	}
+
+	diff, err := session.GetChangesDiff(ctx)
+	if err != nil {
+		return ResponseMsg{Content: "✗ Failed to get changes: " + err.Error()}
+	}
+
+	if strings.TrimSpace(diff) == "" {
+		return ResponseMsg{Content: fmt.Sprintf("ℹ No net changes against HEAD in %d edited file(s)", len(touched))}
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("📋 Changes vs HEAD (%d file(s) edited this session):\n\n", len(touched)))
+	result.WriteString(strings.TrimRight(diff, "\n"))
+
+	return ResponseMsg{Content: result.String()}
+}
+
+func (c *ChangesCommand) GetName() string {
+	return "changes"
+}
+
+func (c *ChangesCommand) GetUsage() string {
+	return "/changes"
+}
+
+func (c *ChangesCommand) GetDescription() string {
+	return "Show the net diff vs HEAD for files edited this session"
+}
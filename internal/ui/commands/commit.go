@@ -30,6 +30,13 @@ func (c *CommitCommand) Execute(ctx context.Context, args []string, model Comman
 	// Show what will be committed
 	statusMsg := c.formatStatusPreview(diffOutput)
 
+	// --conventional asks for a Conventional Commits message whatever the config says
+	conventional := false
+	if len(args) > 0 && args[0] == "--conventional" {
+		conventional = true
+		args = args[1:]
+	}
+
 	if len(args) > 0 {
 		// Manual commit message
 		message := strings.Join(args, " ")
@@ -46,7 +53,7 @@ func (c *CommitCommand) Execute(ctx context.Context, args []string, model Comman
 
 		// Start async commit process
 		go func() {
-			result, err := session.CommitWithAI(ctx)
+			result, err := session.CommitWithAI(ctx, conventional)
 			if err != nil {
 				model.AddMessage("system", c.formatError("AI commit failed: "+err.Error()), false)
 			} else {
@@ -63,11 +70,11 @@ func (c *CommitCommand) GetName() string {
 }
 
 func (c *CommitCommand) GetUsage() string {
-	return "/commit [message]"
+	return "/commit [--conventional] [message]"
 }
 
 func (c *CommitCommand) GetDescription() string {
-	return "Commit changes with optional message (AI-generated if none provided)"
+	return "Commit changes with optional message (AI-generated if none provided, as a Conventional Commit with --conventional)"
 }
 
 // formatStatusPreview creates a preview of changes to be committed
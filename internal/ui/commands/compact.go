@@ -0,0 +1,40 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// CompactCommand handles the /compact command, replacing older conversation history
+// with a summary written by the model while keeping the latest turns as they are
+type CompactCommand struct{}
+
+func (c *CompactCommand) Execute(ctx context.Context, args []string, model CommandModel) tea.Msg {
+	session := model.GetSession()
+	if session == nil {
+		return ResponseMsg{Content: "✗ No active session"}
+	}
+
+	result, err := session.Compact(ctx)
+	if err != nil {
+		return ResponseMsg{Content: fmt.Sprintf("✗ Compaction failed: %v", err)}
+	}
+
+	saved := result.TokensBefore - result.TokensAfter
+	return ResponseMsg{Content: fmt.Sprintf("✓ Compacted %d messages into a summary, kept the latest %d as they were\n  ~%d → ~%d tokens (%d saved)",
+		result.Collapsed, result.Kept, result.TokensBefore, result.TokensAfter, saved)}
+}
+
+func (c *CompactCommand) GetName() string {
+	return "compact"
+}
+
+func (c *CompactCommand) GetUsage() string {
+	return "/compact"
+}
+
+func (c *CompactCommand) GetDescription() string {
+	return "Summarize older conversation history to free up context"
+}
@@ -139,7 +139,7 @@ func (c *ConfigCommand) showUsage() string {
   • /config show         - Show current configuration\n
 \n
 ⚙️ Change Settings:\n
-  • /config provider <name>    - Switch LLM provider (bedrock, openai, anthropic)\n
+  • /config provider <name>    - Switch LLM provider (bedrock, openai, anthropic, ollama, gemini)\n
   • /config model <name>       - Switch model\n
 \n
 💡 Examples:\n
@@ -0,0 +1,112 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// CopyCommand handles the /copy command, copying the last code block or the whole last
+// response to the system clipboard
+type CopyCommand struct{}
+
+func (c *CopyCommand) Execute(ctx context.Context, args []string, model CommandModel) tea.Msg {
+	response := model.LastAssistantMessage()
+	if strings.TrimSpace(response) == "" {
+		return ResponseMsg{Content: "ℹ No response to copy yet"}
+	}
+
+	text, what := response, "last response"
+	if len(args) == 0 || args[0] != "all" {
+		block, ok := lastCodeBlock(response)
+		if !ok {
+			return ResponseMsg{Content: "ℹ The last response has no code block. Use /copy all to copy all of it"}
+		}
+		text, what = block, "last code block"
+	}
+
+	path, err := copyText(text)
+	if err != nil {
+		return ResponseMsg{Content: fmt.Sprintf("✗ Failed to copy: %v", err)}
+	}
+	if path != "" {
+		return ResponseMsg{Content: fmt.Sprintf("ℹ No clipboard available, wrote the %s to %s", what, path)}
+	}
+	return ResponseMsg{Content: fmt.Sprintf("✓ Copied the %s to the clipboard (%d lines)", what, strings.Count(text, "\n")+1)}
+}
+
+func (c *CopyCommand) GetName() string {
+	return "copy"
+}
+
+func (c *CopyCommand) GetUsage() string {
+	return "/copy [all]"
+}
+
+func (c *CopyCommand) GetDescription() string {
+	return "Copy the last code block, or the whole last response, to the clipboard"
+}
+
+// copyText puts text on the system clipboard. Without a clipboard, as over SSH or in a
+// container, it writes text to a temp file and returns its path instead.
+func copyText(text string) (string, error) {
+	if !clipboard.Unsupported {
+		if err := clipboard.WriteAll(text); err == nil {
+			return "", nil
+		}
+	}
+
+	file, err := os.CreateTemp("", "bazinga-copy-*.txt")
+	if err != nil {
+		return "", err
+	}
+	_, err = file.WriteString(text)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+	return file.Name(), nil
+}
+
+// lastCodeBlock returns the body of the last fenced code block in markdown. An
+// unterminated block at the end, as in a cut-off response, counts as well.
+func lastCodeBlock(markdown string) (string, bool) {
+	var (
+		block  []string
+		fence  string
+		inside bool
+		last   string
+		found  bool
+	)
+
+	for _, line := range strings.Split(markdown, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if !inside {
+			if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+				// The closing fence is at least as long as the opening one
+				fence = trimmed[:len(trimmed)-len(strings.TrimLeft(trimmed, trimmed[:1]))]
+				inside = true
+				block = block[:0]
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+			inside = false
+			last, found = strings.Join(block, "\n"), true
+			continue
+		}
+		block = append(block, line)
+	}
+
+	if inside && len(block) > 0 {
+		last, found = strings.Join(block, "\n"), true
+	}
+	return last, found
+}
@@ -0,0 +1,29 @@
+package commands
+
+import "testing"
+
+// TestLastCodeBlock tests that the last fenced block is picked out of a response
+func TestLastCodeBlock(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		want     string
+		found    bool
+	}{
+		{"no block", "Just text.", "", false},
+		{"single block", "Run this:\n\n```bash\ngo test ./...\n```\n\nDone.", "go test ./...", true},
+		{"last of several", "```go\nfirst()\n```\ntext\n~~~\nsecond()\nthird()\n~~~", "second()\nthird()", true},
+		{"nested fence text", "````md\n```go\nx\n```\n````", "```go\nx\n```", true},
+		{"unterminated", "```sh\nmake build", "make build", true},
+		{"keeps indentation", "```py\ndef f():\n    return 1\n```", "def f():\n    return 1", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := lastCodeBlock(tt.markdown)
+			if found != tt.found || got != tt.want {
+				t.Errorf("lastCodeBlock() = %q, %v; want %q, %v", got, found, tt.want, tt.found)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// CostCommand handles the /cost command, showing the tokens the API reported this
+// session and their estimated cost, per model
+type CostCommand struct{}
+
+func (c *CostCommand) Execute(ctx context.Context, args []string, model CommandModel) tea.Msg {
+	session := model.GetSession()
+	if session == nil {
+		return ResponseMsg{Content: "✗ No active session"}
+	}
+
+	breakdown := session.GetUsageBreakdown()
+	if len(breakdown) == 0 {
+		return ResponseMsg{Content: "ℹ No tokens spent in this session yet"}
+	}
+
+	var result strings.Builder
+	result.WriteString("💰 Session cost:\n\n")
+
+	var totalInput, totalOutput int
+	var totalCost float64
+	for _, usage := range breakdown {
+		result.WriteString(fmt.Sprintf("  • %s/%s  ↑ %d  ↓ %d tokens  %s\n",
+			usage.Provider, usage.Model, usage.InputTokens, usage.OutputTokens, formatCost(usage.Cost)))
+		totalInput += usage.InputTokens
+		totalOutput += usage.OutputTokens
+		totalCost += usage.Cost
+	}
+
+	result.WriteString(fmt.Sprintf("\n  Total  ↑ %d  ↓ %d tokens  %s", totalInput, totalOutput, formatCost(totalCost)))
+	return ResponseMsg{Content: result.String()}
+}
+
+// formatCost formats a USD amount, noting models without known pricing
+func formatCost(cost float64) string {
+	if cost == 0 {
+		return "(pricing unknown or free)"
+	}
+	return fmt.Sprintf("$%.4f", cost)
+}
+
+func (c *CostCommand) GetName() string {
+	return "cost"
+}
+
+func (c *CostCommand) GetUsage() string {
+	return "/cost"
+}
+
+func (c *CostCommand) GetDescription() string {
+	return "Show tokens and estimated cost per model this session"
+}
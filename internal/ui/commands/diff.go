@@ -0,0 +1,41 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// DiffCommand handles the /diff command, showing the net change to every file the
+// assistant changed this session. Unlike /changes it compares with the files as they
+// were before the session's first change to them, not with git, so it covers only the
+// assistant's edits and includes files git doesn't know about.
+type DiffCommand struct{}
+
+func (c *DiffCommand) Execute(ctx context.Context, args []string, model CommandModel) tea.Msg {
+	path := strings.Join(args, " ")
+
+	diff, files := model.SessionDiff(path)
+	if files == 0 {
+		if path != "" {
+			return ResponseMsg{Content: fmt.Sprintf("ℹ No changes to %s this session", path)}
+		}
+		return ResponseMsg{Content: "ℹ No files have been changed this session"}
+	}
+
+	return ResponseMsg{Content: fmt.Sprintf("📋 Changes made this session (%d file(s)):\n\n%s", files, diff)}
+}
+
+func (c *DiffCommand) GetName() string {
+	return "diff"
+}
+
+func (c *DiffCommand) GetUsage() string {
+	return "/diff [path]"
+}
+
+func (c *DiffCommand) GetDescription() string {
+	return "Show the net change to each file edited this session"
+}
@@ -0,0 +1,155 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ExportCommand handles the /export command, writing the conversation to a Markdown file
+type ExportCommand struct{}
+
+func (c *ExportCommand) Execute(ctx context.Context, args []string, model CommandModel) tea.Msg {
+	session := model.GetSession()
+	if session == nil {
+		return ResponseMsg{Content: "✗ No active session"}
+	}
+
+	transcript := session.Transcript()
+	if len(transcript) == 0 {
+		return ResponseMsg{Content: "ℹ Nothing to export yet"}
+	}
+
+	now := time.Now()
+	path := strings.Join(args, " ")
+	if path == "" {
+		path = fmt.Sprintf("bazinga-session-%s.md", now.Format("20060102-150405"))
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(session.GetRootPath(), path)
+	}
+
+	header := fmt.Sprintf("# Bazinga session %s\n\nExported %s · %s / %s\n\n",
+		session.ID(), now.Format("2006-01-02 15:04"), session.GetProvider(), session.GetModel())
+	if err := os.WriteFile(path, []byte(header+renderTranscript(transcript)), 0644); err != nil {
+		return ResponseMsg{Content: fmt.Sprintf("✗ Failed to export session: %v", err)}
+	}
+
+	return ResponseMsg{Content: fmt.Sprintf("✓ Exported %d messages to %s", len(transcript), path)}
+}
+
+func (c *ExportCommand) GetName() string {
+	return "export"
+}
+
+func (c *ExportCommand) GetUsage() string {
+	return "/export [path]"
+}
+
+func (c *ExportCommand) GetDescription() string {
+	return "Export the conversation to a Markdown file"
+}
+
+// renderTranscript renders the conversation as Markdown. Each tool call becomes a
+// collapsed <details> block holding its arguments, its result and the diffs of the files
+// it changed.
+func renderTranscript(transcript []TranscriptEntry) string {
+	calls := make(map[string]bool)
+	results := make(map[string]TranscriptEntry)
+	for _, entry := range transcript {
+		for _, call := range entry.ToolCalls {
+			calls[call.ID] = true
+		}
+		if entry.Role == "tool" && entry.ToolCallID != "" {
+			results[entry.ToolCallID] = entry
+		}
+	}
+
+	var out strings.Builder
+	for _, entry := range transcript {
+		switch entry.Role {
+		case "user":
+			out.WriteString("## User\n\n")
+			out.WriteString(quote(entry.Content))
+			out.WriteString("\n\n")
+		case "assistant":
+			out.WriteString("## Assistant\n\n")
+			if content := strings.TrimSpace(entry.Content); content != "" {
+				out.WriteString(content)
+				out.WriteString("\n\n")
+			}
+			for _, call := range entry.ToolCalls {
+				result, ok := results[call.ID]
+				writeToolCall(&out, call, result, ok)
+			}
+		case "tool":
+			// Results are written with their calls; one without a matching call stands alone
+			if !calls[entry.ToolCallID] {
+				writeToolCall(&out, TranscriptToolCall{Name: entry.ToolName}, entry, true)
+			}
+		default:
+			// System messages, such as the summary left by /compact
+			out.WriteString("## System\n\n")
+			out.WriteString(quote(entry.Content))
+			out.WriteString("\n\n")
+		}
+	}
+
+	return out.String()
+}
+
+// writeToolCall writes one tool call with its result as a collapsed block
+func writeToolCall(out *strings.Builder, call TranscriptToolCall, result TranscriptEntry, hasResult bool) {
+	summary := "🔧 " + call.Name
+	if hasResult && result.IsError {
+		summary += " (failed)"
+	}
+	fmt.Fprintf(out, "<details>\n<summary>%s</summary>\n\n", summary)
+
+	if len(call.Input) > 0 {
+		args, err := json.MarshalIndent(call.Input, "", "  ")
+		if err == nil {
+			out.WriteString("**Arguments**\n\n")
+			out.WriteString(fence("json", string(args)))
+		}
+	}
+	if hasResult {
+		out.WriteString("**Result**\n\n")
+		out.WriteString(fence("", result.Content))
+		for _, diff := range result.Diffs {
+			out.WriteString(fence("diff", diff))
+		}
+	}
+
+	out.WriteString("</details>\n\n")
+}
+
+// fence wraps text in a fenced code block longer than any backtick run inside it
+func fence(lang, text string) string {
+	longest, run := 0, 0
+	for _, r := range text {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	marker := strings.Repeat("`", max(3, longest+1))
+	return marker + lang + "\n" + strings.TrimRight(text, "\n") + "\n" + marker + "\n\n"
+}
+
+// quote renders text as a Markdown blockquote
+func quote(text string) string {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight("> "+line, " ")
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,37 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestRenderTranscript tests the Markdown written by /export
+func TestRenderTranscript(t *testing.T) {
+	transcript := []TranscriptEntry{
+		{Role: "user", Content: "Fix the typo\nin main.go"},
+		{Role: "assistant", Content: "Let me fix it.", ToolCalls: []TranscriptToolCall{
+			{ID: "call_1", Name: "edit_file", Input: map[string]interface{}{"file_path": "main.go"}},
+		}},
+		{Role: "tool", ToolCallID: "call_1", ToolName: "edit_file", Content: "Edited main.go with ``` inside",
+			Diffs: []string{"--- a/main.go\n+++ b/main.go\n-helo\n+hello\n"}},
+		{Role: "assistant", Content: "Done."},
+	}
+
+	markdown := renderTranscript(transcript)
+
+	for _, want := range []string{
+		"## User\n\n> Fix the typo\n> in main.go\n\n",
+		"## Assistant\n\nLet me fix it.\n\n<details>\n<summary>🔧 edit_file</summary>",
+		"```json\n{\n  \"file_path\": \"main.go\"\n}\n```",
+		"````\nEdited main.go with ``` inside\n````",
+		"```diff\n--- a/main.go\n+++ b/main.go\n-helo\n+hello\n```",
+		"</details>\n\n## Assistant\n\nDone.",
+	} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("export is missing %q:\n%s", want, markdown)
+		}
+	}
+	if strings.Count(markdown, "<details>") != 1 {
+		t.Errorf("the tool result should only be written with its call:\n%s", markdown)
+	}
+}
@@ -35,11 +35,27 @@ func (c *HelpCommand) formatHelp() string {
 	// Project Setup
 	result.WriteString("📁 Project Setup:\n")
 	result.WriteString("  • /init            Analyze project and create Bazinga.md\n")
+	result.WriteString("  • /add [path...]   Add files to context; without paths, pick them from the project\n")
+	result.WriteString("  • /overview [refresh] [save]  Summarize the codebase (cached until files change)\n")
+	result.WriteString("  • /rescan          Scan the project again for the file list the AI sees\n")
+	result.WriteString("\n")
+
+	// Planning
+	result.WriteString("🤔 Planning:\n")
+	result.WriteString("  • /think <question> Reason and propose next steps (no tools)\n")
+	result.WriteString("  • /retry           Send the last failed message again (or press r right after the error)\n")
+	result.WriteString("  • /copy [all]      Copy the last code block, or all of the last response (or press Ctrl+Y)\n")
+	result.WriteString("  • /plan [show|discard] Preview file changes instead of writing them\n")
+	result.WriteString("  • /apply           Write the changes planned in plan mode\n")
+	result.WriteString("  • /agent [goal]    Work through the todos one request each until done (Esc or /agent stop ends it)\n")
 	result.WriteString("\n")
 
 	// Git Operations
 	result.WriteString("🌿 Git Operations:\n")
-	result.WriteString("  • /commit [msg]    Commit changes (AI message if none provided)\n")
+	result.WriteString("  • /commit [msg]    Commit changes (AI message if none provided; --conventional for type(scope): summary)\n")
+	result.WriteString("  • /diff [path]     Net change to each file edited this session, including files git doesn't track\n")
+	result.WriteString("  • /changes         Net diff vs HEAD for files edited this session\n")
+	result.WriteString("  • /undo            Revert the most recent file change (repeat to go further back)\n")
 	result.WriteString("\n")
 
 	// Memory Management
@@ -47,9 +63,27 @@ func (c *HelpCommand) formatHelp() string {
 	result.WriteString("  • /memory          View/manage memory\n")
 	result.WriteString("\n")
 
+	// Sessions
+	result.WriteString("💾 Sessions:\n")
+	result.WriteString("  • /sessions        List saved sessions\n")
+	result.WriteString("  • /resume <n|id>   Load a saved session and continue it\n")
+	result.WriteString("  • /export [path]   Write the conversation to Markdown (default bazinga-session-<time>.md)\n")
+	result.WriteString("\n")
+
 	// Configuration
 	result.WriteString("⚙ Configuration:\n")
 	result.WriteString("  • /config          View/update configuration\n")
+	result.WriteString("  • /model [id]      Show or switch the model\n")
+	result.WriteString("  • /provider [name] Show or switch the LLM provider\n")
+	result.WriteString("  • /reload-config   Re-read the config file without restarting\n")
+	result.WriteString("  • /cost            Tokens and estimated cost per model this session\n")
+	result.WriteString("  • /compact         Summarize older history to free up context\n")
+	result.WriteString("  • /lang [code|off] Language the assistant responds in\n")
+	result.WriteString("  • /prompt show     The system prompt the model currently sees\n")
+	result.WriteString("  • /theme [name]    Markdown theme for chat messages\n")
+	result.WriteString("  • /permissions export|import <file>  Share permission rules\n")
+	result.WriteString("  • /rules           List the configured permission rules\n")
+	result.WriteString("  • /tools           List the tools the model can use\n")
 	result.WriteString("\n")
 
 	result.WriteString("💡 Tips:\n")
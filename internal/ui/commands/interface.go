@@ -2,6 +2,7 @@ package commands
 
 import (
 	"context"
+	"github.com/tildaslashalef/bazinga/internal/llm"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -21,6 +22,9 @@ type CommandModel interface {
 	GetSessionManager() SessionManager
 	LoadFiles()
 	AddMessage(role, content string, streaming bool)
+	UndoLastChange() (string, error)
+	SessionDiff(path string) (diff string, files int)
+	LastAssistantMessage() string
 }
 
 // Session interface for command access
@@ -30,8 +34,10 @@ type Session interface {
 	GetRootPath() string
 	AddFile(ctx context.Context, path string) error
 	GetDiffOutput() (string, error)
+	GetChangesDiff(ctx context.Context) (string, error)
+	TouchedFiles() []string
 	CommitChanges(ctx context.Context, message string) error
-	CommitWithAI(ctx context.Context) (string, error)
+	CommitWithAI(ctx context.Context, conventional bool) (string, error)
 	SetModel(model string) error
 	GetModel() string
 	SetProvider(provider string) error
@@ -39,6 +45,7 @@ type Session interface {
 	GetAvailableProviders() []string
 	GetAvailableModels() map[string][]ModelInfo
 	GetProjectSummary() string
+	RescanProject() (added, removed int, err error)
 	GetBranchInfo() (string, error)
 	GetCommitHistory(limit int) (string, error)
 	GetMemoryContent() *MemoryContent
@@ -47,7 +54,24 @@ type Session interface {
 	ReloadMemory(ctx context.Context) error
 	AddQuickMemory(ctx context.Context, note string, isUserMemory bool) error
 	GetPermissionManager() PermissionManager
+	GetResponseLanguage() string
+	SetResponseLanguage(language string)
+	SystemPrompt() string
+	SystemPromptFile() string
+	ReloadConfig() (ConfigReload, error)
+	CachedOverview() (string, bool)
+	PrepareOverview(save bool) (string, error)
+	SaveOverviewToMemory(ctx context.Context) error
+	GetUsageBreakdown() []ModelUsage
+	Compact(ctx context.Context) (CompactResult, error)
+	PlanMode() bool
+	SetPlanMode(enabled bool)
+	PendingPlan() []string
+	DiscardPlan() int
+	ApplyPlan(ctx context.Context) (int, error)
 	ID() string
+	Transcript() []TranscriptEntry
+	ToolSet() ToolSet
 }
 
 // SessionManager interface for command access
@@ -70,12 +94,61 @@ type ChatMessage struct {
 	Streaming bool
 }
 
+// TranscriptEntry is one message of the conversation history
+type TranscriptEntry struct {
+	Role       string // "user", "assistant", "tool" or "system"
+	Content    string
+	ToolCalls  []TranscriptToolCall // Assistant turns: the tools it called
+	ToolCallID string               // Tool results: the call this answers
+	ToolName   string               // Tool results: the tool that ran
+	IsError    bool                 // Tool results: the tool failed
+	Diffs      []string             // Tool results: unified diffs of the files the tool changed
+}
+
+// TranscriptToolCall is a tool call made by the assistant
+type TranscriptToolCall struct {
+	ID    string
+	Name  string
+	Input map[string]interface{}
+}
+
+// ToolSet lists the session's tools by name
+type ToolSet struct {
+	Active   []string // Offered to the model
+	Disabled []string // Turned off by the tool filter
+	Unknown  []string // Names in the tool filter that match no tool
+}
+
 // ModelInfo represents model information
 type ModelInfo struct {
 	ID   string
 	Name string
 }
 
+// ModelUsage represents the tokens and cost spent on one model
+type ModelUsage struct {
+	Provider     string
+	Model        string
+	InputTokens  int
+	OutputTokens int
+	Cost         float64
+}
+
+// CompactResult describes what compacting the conversation history removed
+type CompactResult struct {
+	Collapsed    int
+	Kept         int
+	TokensBefore int
+	TokensAfter  int
+}
+
+// ConfigReload describes what reloading the configuration changed
+type ConfigReload struct {
+	Changed   []string // Changed settings as YAML keys
+	Providers []string // What happened to providers whose settings changed
+	Warnings  []string
+}
+
 // MemoryContent represents memory content
 type MemoryContent struct {
 	UserMemory    string
@@ -87,12 +160,18 @@ type MemoryContent struct {
 type SavedSessionInfo struct {
 	ID        string
 	Name      string
+	RootPath  string
+	Messages  int
 	CreatedAt time.Time
+	UpdatedAt time.Time
 }
 
 // PermissionManager interface for command access
 type PermissionManager interface {
-	GetToolRisk(toolCall interface{}) string
+	GetToolRisk(toolCall *llm.ToolCall) string
+	ExportRules(path string) error
+	ImportRules(path string) ([]string, error)
+	ConfigRules() []string
 }
 
 // ResponseMsg represents a command response message
@@ -106,7 +185,40 @@ type StatusUpdateMsg struct {
 	ModelName string
 }
 
+// ConfigReloadedMsg reports a configuration reload, after which the UI applies its
+// own settings again
+type ConfigReloadedMsg struct {
+	Response string
+}
+
+// ThemeChangeMsg asks the UI to switch its markdown theme; an empty Theme shows the current one
+type ThemeChangeMsg struct {
+	Theme string
+}
+
+// ResumeSessionMsg asks the UI to load a saved session and continue its conversation
+type ResumeSessionMsg struct {
+	ID string
+}
+
+// RetryRequestMsg asks the UI to send the last failed message again
+type RetryRequestMsg struct{}
+
+// OpenFilePickerMsg asks the UI to open the file picker for choosing files to add
+type OpenFilePickerMsg struct{}
+
+// StartAgentMsg asks the UI to work through the todo list autonomously, first
+// planning todos for Goal if one is given
+type StartAgentMsg struct {
+	Goal     string
+	MaxTurns int // Most requests the run may send (0 = default)
+}
+
+// StopAgentMsg asks the UI to stop agent mode after the current response
+type StopAgentMsg struct{}
+
 // LLMRequestMsg represents a request to send a message to the LLM
 type LLMRequestMsg struct {
-	Message string
+	Message   string
+	ThinkOnly bool // Send without tools so the model only reasons and proposes steps
 }
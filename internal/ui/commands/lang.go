@@ -0,0 +1,49 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// LangCommand handles the /lang command, setting the language the assistant responds in
+type LangCommand struct{}
+
+func (c *LangCommand) Execute(ctx context.Context, args []string, model CommandModel) tea.Msg {
+	session := model.GetSession()
+	if session == nil {
+		return ResponseMsg{Content: "✗ No active session"}
+	}
+
+	if len(args) == 0 {
+		current := session.GetResponseLanguage()
+		if current == "" {
+			return ResponseMsg{Content: "ℹ No response language set, the assistant picks one\nUsage: /lang <code>  (e.g. /lang de, /lang Japanese, /lang off)"}
+		}
+		return ResponseMsg{Content: fmt.Sprintf("ℹ Response language: %s\nUse /lang off to clear it", current)}
+	}
+
+	language := strings.Join(args, " ")
+	switch strings.ToLower(language) {
+	case "off", "none", "clear", "default":
+		session.SetResponseLanguage("")
+		return ResponseMsg{Content: "✓ Response language cleared"}
+	}
+
+	session.SetResponseLanguage(language)
+	return ResponseMsg{Content: fmt.Sprintf("✓ Responses will be in %s (code and tool arguments stay unchanged)", language)}
+}
+
+func (c *LangCommand) GetName() string {
+	return "lang"
+}
+
+func (c *LangCommand) GetUsage() string {
+	return "/lang [code|off]"
+}
+
+func (c *LangCommand) GetDescription() string {
+	return "Set the language the assistant responds in"
+}
@@ -0,0 +1,45 @@
+package commands
+
+import (
+	"context"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ModelCommand handles the /model command, a shortcut for /config model
+type ModelCommand struct{}
+
+func (c *ModelCommand) Execute(ctx context.Context, args []string, model CommandModel) tea.Msg {
+	return (&ConfigCommand{}).handleModelConfig(model.GetSession(), args)
+}
+
+func (c *ModelCommand) GetName() string {
+	return "model"
+}
+
+func (c *ModelCommand) GetUsage() string {
+	return "/model [id]"
+}
+
+func (c *ModelCommand) GetDescription() string {
+	return "Show or switch the model"
+}
+
+// ProviderCommand handles the /provider command, a shortcut for /config provider
+type ProviderCommand struct{}
+
+func (c *ProviderCommand) Execute(ctx context.Context, args []string, model CommandModel) tea.Msg {
+	return (&ConfigCommand{}).handleProviderConfig(model.GetSession(), args)
+}
+
+func (c *ProviderCommand) GetName() string {
+	return "provider"
+}
+
+func (c *ProviderCommand) GetUsage() string {
+	return "/provider [name]"
+}
+
+func (c *ProviderCommand) GetDescription() string {
+	return "Show or switch the LLM provider"
+}
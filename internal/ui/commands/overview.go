@@ -0,0 +1,61 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// OverviewCommand handles the /overview command, summarizing the codebase from its main files
+type OverviewCommand struct{}
+
+func (c *OverviewCommand) Execute(ctx context.Context, args []string, model CommandModel) tea.Msg {
+	session := model.GetSession()
+	if session == nil {
+		return ResponseMsg{Content: "✗ No active session"}
+	}
+
+	refresh, save := false, false
+	for _, arg := range args {
+		switch arg {
+		case "refresh":
+			refresh = true
+		case "save":
+			save = true
+		default:
+			return ResponseMsg{Content: "Usage: /overview [refresh] [save]"}
+		}
+	}
+
+	if !refresh {
+		if cached, ok := session.CachedOverview(); ok {
+			if !save {
+				return ResponseMsg{Content: cached + "\n\nℹ Cached overview, files unchanged. Use /overview refresh to rebuild it"}
+			}
+			if err := session.SaveOverviewToMemory(ctx); err != nil {
+				return ResponseMsg{Content: fmt.Sprintf("✗ Failed to save overview: %v", err)}
+			}
+			return ResponseMsg{Content: "✓ Overview saved to MEMORY.md"}
+		}
+	}
+
+	prompt, err := session.PrepareOverview(save)
+	if err != nil {
+		return ResponseMsg{Content: fmt.Sprintf("✗ %v", err)}
+	}
+
+	return LLMRequestMsg{Message: prompt}
+}
+
+func (c *OverviewCommand) GetName() string {
+	return "overview"
+}
+
+func (c *OverviewCommand) GetUsage() string {
+	return "/overview [refresh] [save]"
+}
+
+func (c *OverviewCommand) GetDescription() string {
+	return "Summarize the codebase architecture from its main files"
+}
@@ -0,0 +1,70 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// PermissionsCommand handles the /permissions command, exporting and importing the
+// active permission rules so a reviewed ruleset can be shared
+type PermissionsCommand struct{}
+
+func (c *PermissionsCommand) Execute(ctx context.Context, args []string, model CommandModel) tea.Msg {
+	session := model.GetSession()
+	if session == nil {
+		return ResponseMsg{Content: "✗ No active session"}
+	}
+
+	if len(args) != 2 || (args[0] != "export" && args[0] != "import") {
+		return ResponseMsg{Content: "Usage: " + c.GetUsage() + "\nFiles ending in .json use JSON, anything else YAML"}
+	}
+
+	pm := session.GetPermissionManager()
+	if pm == nil {
+		return ResponseMsg{Content: "✗ Permissions are not available in this session"}
+	}
+
+	path := args[1]
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(session.GetRootPath(), path)
+	}
+
+	if args[0] == "export" {
+		if err := pm.ExportRules(path); err != nil {
+			return ResponseMsg{Content: "✗ " + err.Error()}
+		}
+		return ResponseMsg{Content: fmt.Sprintf("✓ Exported permission rules to %s", args[1])}
+	}
+
+	notes, err := pm.ImportRules(path)
+	if err != nil {
+		return ResponseMsg{Content: "✗ Import failed, no rules changed:\n" + err.Error()}
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("✓ Imported permission rules from %s", args[1]))
+	if len(notes) > 0 {
+		result.WriteString("\n\n⚠ Differences from the defaults:\n")
+		for _, note := range notes {
+			result.WriteString("  • " + note + "\n")
+		}
+	}
+
+	return ResponseMsg{Content: strings.TrimRight(result.String(), "\n")}
+}
+
+func (c *PermissionsCommand) GetName() string {
+	return "permissions"
+}
+
+func (c *PermissionsCommand) GetUsage() string {
+	return "/permissions export|import <file>"
+}
+
+func (c *PermissionsCommand) GetDescription() string {
+	return "Export or import permission rules"
+}
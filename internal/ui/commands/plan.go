@@ -0,0 +1,108 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// PlanCommand handles the /plan command, toggling plan mode. In plan mode write tools
+// only preview their diff and are collected into a batch that /apply runs.
+type PlanCommand struct{}
+
+func (c *PlanCommand) Execute(ctx context.Context, args []string, model CommandModel) tea.Msg {
+	session := model.GetSession()
+	if session == nil {
+		return ResponseMsg{Content: "✗ No active session"}
+	}
+
+	if len(args) > 0 {
+		switch args[0] {
+		case "discard":
+			discarded := session.DiscardPlan()
+			if discarded == 0 {
+				return ResponseMsg{Content: "ℹ No planned changes to discard"}
+			}
+			return ResponseMsg{Content: fmt.Sprintf("🗑️ Discarded %d planned change(s)", discarded)}
+		case "show":
+			return ResponseMsg{Content: formatPendingPlan(session.PendingPlan())}
+		default:
+			return ResponseMsg{Content: "Usage: " + c.GetUsage()}
+		}
+	}
+
+	if !session.PlanMode() {
+		session.SetPlanMode(true)
+		return ResponseMsg{Content: "📝 Plan mode on: file changes are previewed, not written. Run /apply to write them, /plan discard to drop them."}
+	}
+
+	session.SetPlanMode(false)
+	pending := session.PendingPlan()
+	if len(pending) == 0 {
+		return ResponseMsg{Content: "✓ Plan mode off: file changes are written as they are made"}
+	}
+	return ResponseMsg{Content: fmt.Sprintf("✓ Plan mode off. %d planned change(s) are still pending; /apply writes them, /plan discard drops them.", len(pending))}
+}
+
+func (c *PlanCommand) GetName() string {
+	return "plan"
+}
+
+func (c *PlanCommand) GetUsage() string {
+	return "/plan [show|discard]"
+}
+
+func (c *PlanCommand) GetDescription() string {
+	return "Toggle plan mode: preview file changes and apply them as a batch"
+}
+
+// ApplyCommand handles the /apply command, writing the changes planned in plan mode
+type ApplyCommand struct{}
+
+func (c *ApplyCommand) Execute(ctx context.Context, args []string, model CommandModel) tea.Msg {
+	session := model.GetSession()
+	if session == nil {
+		return ResponseMsg{Content: "✗ No active session"}
+	}
+
+	pending := session.PendingPlan()
+	if len(pending) == 0 {
+		return ResponseMsg{Content: "ℹ No planned changes to apply"}
+	}
+
+	applied, err := session.ApplyPlan(ctx)
+	if err != nil {
+		return ResponseMsg{Content: fmt.Sprintf("✗ Applied %d of %d planned change(s), then %s failed: %v\nThe rest are still pending; fix the cause and /apply again, or /plan discard them.", applied, len(pending), pending[applied], err)}
+	}
+
+	model.LoadFiles()
+	return ResponseMsg{Content: fmt.Sprintf("✓ Applied %d planned change(s)", applied)}
+}
+
+func (c *ApplyCommand) GetName() string {
+	return "apply"
+}
+
+func (c *ApplyCommand) GetUsage() string {
+	return "/apply"
+}
+
+func (c *ApplyCommand) GetDescription() string {
+	return "Write the file changes planned in plan mode"
+}
+
+// formatPendingPlan lists the planned changes in the order they will be applied
+func formatPendingPlan(pending []string) string {
+	if len(pending) == 0 {
+		return "ℹ No planned changes"
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("📝 %d planned change(s):\n", len(pending)))
+	for i, change := range pending {
+		result.WriteString(fmt.Sprintf("  %d. %s\n", i+1, change))
+	}
+	return strings.TrimRight(result.String(), "\n")
+}
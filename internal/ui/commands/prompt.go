@@ -0,0 +1,43 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// PromptCommand handles the /prompt command, showing the system prompt the model sees
+type PromptCommand struct{}
+
+func (c *PromptCommand) Execute(ctx context.Context, args []string, model CommandModel) tea.Msg {
+	session := model.GetSession()
+	if session == nil {
+		return ResponseMsg{Content: "✗ No active session"}
+	}
+
+	if len(args) == 0 || strings.ToLower(args[0]) != "show" {
+		return ResponseMsg{Content: "Usage: /prompt show\nShows the system prompt sent with the next message. Put a .bazinga/system_prompt.md in the project root to replace the default base prompt."}
+	}
+
+	base := "built-in default"
+	if path := session.SystemPromptFile(); path != "" {
+		base = path
+	}
+
+	prompt := session.SystemPrompt()
+	return ResponseMsg{Content: fmt.Sprintf("📜 System prompt (base: %s, %d characters):\n\n%s", base, len(prompt), prompt)}
+}
+
+func (c *PromptCommand) GetName() string {
+	return "prompt"
+}
+
+func (c *PromptCommand) GetUsage() string {
+	return "/prompt show"
+}
+
+func (c *PromptCommand) GetDescription() string {
+	return "Show the system prompt the model currently sees"
+}
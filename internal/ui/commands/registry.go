@@ -21,10 +21,36 @@ func NewRegistry() *Registry {
 	// Register essential commands only
 	registry.Register(&HelpCommand{})
 	registry.Register(&InitCommand{})
+	registry.Register(&AddCommand{})
+	registry.Register(&OverviewCommand{})
+	registry.Register(&RescanCommand{})
 	registry.Register(&CommitCommand{})
+	registry.Register(&DiffCommand{})
+	registry.Register(&ChangesCommand{})
+	registry.Register(&UndoCommand{})
+	registry.Register(&PlanCommand{})
+	registry.Register(&ApplyCommand{})
+	registry.Register(&AgentCommand{})
 	registry.Register(&MemoryCommand{})
 	registry.Register(&ConfigCommand{})
+	registry.Register(&ModelCommand{})
+	registry.Register(&ProviderCommand{})
+	registry.Register(&ReloadConfigCommand{})
+	registry.Register(&CostCommand{})
+	registry.Register(&CompactCommand{})
+	registry.Register(&LangCommand{})
+	registry.Register(&PromptCommand{})
+	registry.Register(&ThemeCommand{})
+	registry.Register(&PermissionsCommand{})
+	registry.Register(&RulesCommand{})
+	registry.Register(&ToolsCommand{})
 	registry.Register(&NoteCommand{})
+	registry.Register(&ThinkCommand{})
+	registry.Register(&RetryCommand{})
+	registry.Register(&CopyCommand{})
+	registry.Register(&ExportCommand{})
+	registry.Register(&SessionsCommand{})
+	registry.Register(&ResumeCommand{})
 
 	return registry
 }
@@ -0,0 +1,55 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ReloadConfigCommand handles the /reload-config command, applying changes to the
+// config file without restarting or losing the conversation
+type ReloadConfigCommand struct{}
+
+func (c *ReloadConfigCommand) Execute(ctx context.Context, args []string, model CommandModel) tea.Msg {
+	session := model.GetSession()
+	if session == nil {
+		return ResponseMsg{Content: "✗ No active session"}
+	}
+
+	result, err := session.ReloadConfig()
+	if err != nil {
+		return ResponseMsg{Content: fmt.Sprintf("✗ Configuration not reloaded, keeping the current one:\n%v", err)}
+	}
+	if len(result.Changed) == 0 {
+		return ResponseMsg{Content: "ℹ Configuration reloaded, nothing changed"}
+	}
+
+	var response strings.Builder
+	response.WriteString("✓ Configuration reloaded. Changed settings:\n")
+	for _, key := range result.Changed {
+		response.WriteString(fmt.Sprintf("  • %s\n", key))
+	}
+	if len(result.Providers) > 0 {
+		response.WriteString(fmt.Sprintf("\nProviders: %s\n", strings.Join(result.Providers, ", ")))
+	}
+	for _, warning := range result.Warnings {
+		response.WriteString(fmt.Sprintf("⚠ %s\n", warning))
+	}
+	response.WriteString(fmt.Sprintf("\nNow using %s/%s", session.GetProvider(), session.GetModel()))
+
+	return ConfigReloadedMsg{Response: response.String()}
+}
+
+func (c *ReloadConfigCommand) GetName() string {
+	return "reload-config"
+}
+
+func (c *ReloadConfigCommand) GetUsage() string {
+	return "/reload-config"
+}
+
+func (c *ReloadConfigCommand) GetDescription() string {
+	return "Re-read the config file and apply it without restarting"
+}
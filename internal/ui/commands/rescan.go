@@ -0,0 +1,39 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// RescanCommand handles the /rescan command, scanning the project again for files
+// created, moved or deleted outside bazinga
+type RescanCommand struct{}
+
+func (c *RescanCommand) Execute(ctx context.Context, args []string, model CommandModel) tea.Msg {
+	session := model.GetSession()
+	if session == nil {
+		return ResponseMsg{Content: "✗ No active session"}
+	}
+
+	added, removed, err := session.RescanProject()
+	if err != nil {
+		return ResponseMsg{Content: fmt.Sprintf("✗ %v", err)}
+	}
+
+	return ResponseMsg{Content: fmt.Sprintf("✓ Project rescanned: %d file(s) added, %d removed\n\n%s",
+		added, removed, session.GetProjectSummary())}
+}
+
+func (c *RescanCommand) GetName() string {
+	return "rescan"
+}
+
+func (c *RescanCommand) GetUsage() string {
+	return "/rescan"
+}
+
+func (c *RescanCommand) GetDescription() string {
+	return "Scan the project again for the file list the AI sees"
+}
@@ -0,0 +1,26 @@
+package commands
+
+import (
+	"context"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// RetryCommand handles the /retry command, sending the last failed message again
+type RetryCommand struct{}
+
+func (c *RetryCommand) Execute(ctx context.Context, args []string, model CommandModel) tea.Msg {
+	return RetryRequestMsg{}
+}
+
+func (c *RetryCommand) GetName() string {
+	return "retry"
+}
+
+func (c *RetryCommand) GetUsage() string {
+	return "/retry"
+}
+
+func (c *RetryCommand) GetDescription() string {
+	return "Send the last failed message again"
+}
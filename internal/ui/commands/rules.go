@@ -0,0 +1,53 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// RulesCommand handles the /rules command, listing the permission rules from
+// security.permission_rules
+type RulesCommand struct{}
+
+func (c *RulesCommand) Execute(ctx context.Context, args []string, model CommandModel) tea.Msg {
+	session := model.GetSession()
+	if session == nil {
+		return ResponseMsg{Content: "✗ No active session"}
+	}
+
+	pm := session.GetPermissionManager()
+	if pm == nil {
+		return ResponseMsg{Content: "✗ Permissions are not available in this session"}
+	}
+
+	rules := pm.ConfigRules()
+	if len(rules) == 0 {
+		return ResponseMsg{Content: "ℹ No permission rules configured. Add them under security.permission_rules, e.g.:\n" +
+			"  - allow edit_file internal/**\n  - allow bash \"go test*\"\n  - deny delete_file **"}
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("🛡 %d permission rule(s) from security.permission_rules:\n", len(rules)))
+	for _, rule := range rules {
+		result.WriteString("  • " + rule + "\n")
+	}
+	result.WriteString("\nDeny beats prompt, which beats allow. Calls no rule covers use the built-in defaults; " +
+		"allowed calls that touch sensitive files or run risky commands still prompt.")
+
+	return ResponseMsg{Content: result.String()}
+}
+
+func (c *RulesCommand) GetName() string {
+	return "rules"
+}
+
+func (c *RulesCommand) GetUsage() string {
+	return "/rules"
+}
+
+func (c *RulesCommand) GetDescription() string {
+	return "List the configured permission rules"
+}
@@ -0,0 +1,146 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// SessionsCommand handles the /sessions command, listing saved sessions to resume
+type SessionsCommand struct{}
+
+func (c *SessionsCommand) Execute(ctx context.Context, args []string, model CommandModel) tea.Msg {
+	sessions, err := savedSessions(model)
+	if err != nil {
+		return ResponseMsg{Content: fmt.Sprintf("✗ Failed to list sessions: %v", err)}
+	}
+	if len(sessions) == 0 {
+		return ResponseMsg{Content: "ℹ No saved sessions"}
+	}
+
+	current := ""
+	if session := model.GetSession(); session != nil {
+		current = session.ID()
+	}
+
+	var result strings.Builder
+	result.WriteString("💾 Saved sessions (most recent first):\n\n")
+	for i, sess := range sessions {
+		name := sess.Name
+		if name == "" {
+			name = "(unnamed)"
+		}
+		marker := ""
+		if sess.ID == current {
+			marker = " (current)"
+		}
+		result.WriteString(fmt.Sprintf("  %d. %s  %s%s\n", i+1, sess.ID, name, marker))
+
+		location := sess.RootPath
+		if _, err := os.Stat(sess.RootPath); err != nil {
+			location += " (missing)"
+		}
+		result.WriteString(fmt.Sprintf("     %s · %d messages · %s\n",
+			sess.CreatedAt.Format("2006-01-02 15:04"), sess.Messages, location))
+	}
+	result.WriteString("\nResume one with /resume <number|id>")
+
+	return ResponseMsg{Content: result.String()}
+}
+
+func (c *SessionsCommand) GetName() string {
+	return "sessions"
+}
+
+func (c *SessionsCommand) GetUsage() string {
+	return "/sessions"
+}
+
+func (c *SessionsCommand) GetDescription() string {
+	return "List saved sessions to resume"
+}
+
+// ResumeCommand handles the /resume command, continuing a saved session
+type ResumeCommand struct{}
+
+func (c *ResumeCommand) Execute(ctx context.Context, args []string, model CommandModel) tea.Msg {
+	if len(args) == 0 {
+		return ResponseMsg{Content: "Usage: /resume <number|id>\nRun /sessions to see the saved sessions"}
+	}
+
+	sessions, err := savedSessions(model)
+	if err != nil {
+		return ResponseMsg{Content: fmt.Sprintf("✗ Failed to list sessions: %v", err)}
+	}
+
+	id, err := findSession(sessions, args[0])
+	if err != nil {
+		return ResponseMsg{Content: "✗ " + err.Error()}
+	}
+	if session := model.GetSession(); session != nil && session.ID() == id {
+		return ResponseMsg{Content: "ℹ That is the current session"}
+	}
+
+	return ResumeSessionMsg{ID: id}
+}
+
+func (c *ResumeCommand) GetName() string {
+	return "resume"
+}
+
+func (c *ResumeCommand) GetUsage() string {
+	return "/resume <number|id>"
+}
+
+func (c *ResumeCommand) GetDescription() string {
+	return "Load a saved session and continue its conversation"
+}
+
+// savedSessions returns the saved sessions, most recently updated first, in the order
+// /sessions numbers them
+func savedSessions(model CommandModel) ([]SavedSessionInfo, error) {
+	manager := model.GetSessionManager()
+	if manager == nil {
+		return nil, fmt.Errorf("session manager not available")
+	}
+
+	sessions, err := manager.ListSavedSessions()
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(sessions, func(i, j int) bool {
+		return sessions[i].UpdatedAt.After(sessions[j].UpdatedAt)
+	})
+	return sessions, nil
+}
+
+// findSession resolves a /sessions number, a full session ID or an unambiguous ID prefix
+func findSession(sessions []SavedSessionInfo, ref string) (string, error) {
+	if n, err := strconv.Atoi(ref); err == nil && n >= 1 && n <= len(sessions) {
+		return sessions[n-1].ID, nil
+	}
+
+	var matches []string
+	for _, sess := range sessions {
+		if sess.ID == ref {
+			return sess.ID, nil
+		}
+		if strings.HasPrefix(sess.ID, ref) {
+			matches = append(matches, sess.ID)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("no saved session matches %q", ref)
+	case 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf("%q matches %d sessions, use more of the ID", ref, len(matches))
+	}
+}
@@ -0,0 +1,33 @@
+package commands
+
+import "testing"
+
+// TestFindSession tests resolving the argument of /resume
+func TestFindSession(t *testing.T) {
+	sessions := []SavedSessionInfo{
+		{ID: "01HZX4A"},
+		{ID: "01HZX4B"},
+		{ID: "01JAB9C"},
+	}
+
+	tests := []struct {
+		ref     string
+		want    string
+		wantErr bool
+	}{
+		{"1", "01HZX4A", false},
+		{"3", "01JAB9C", false},
+		{"01HZX4B", "01HZX4B", false},
+		{"01J", "01JAB9C", false},
+		{"01HZX", "", true},
+		{"4", "", true},
+		{"zzz", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := findSession(sessions, tt.ref)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("findSession(%q) = %q, %v; want %q, error %v", tt.ref, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
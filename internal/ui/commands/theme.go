@@ -0,0 +1,27 @@
+package commands
+
+import (
+	"context"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ThemeCommand handles the /theme command, switching the markdown theme of the chat
+type ThemeCommand struct{}
+
+func (c *ThemeCommand) Execute(ctx context.Context, args []string, model CommandModel) tea.Msg {
+	return ThemeChangeMsg{Theme: strings.Join(args, " ")}
+}
+
+func (c *ThemeCommand) GetName() string {
+	return "theme"
+}
+
+func (c *ThemeCommand) GetUsage() string {
+	return "/theme [name|path]"
+}
+
+func (c *ThemeCommand) GetDescription() string {
+	return "Switch the markdown theme for chat messages"
+}
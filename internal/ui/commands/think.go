@@ -0,0 +1,34 @@
+package commands
+
+import (
+	"context"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ThinkCommand handles the /think command for planning without tool execution
+type ThinkCommand struct{}
+
+func (c *ThinkCommand) Execute(ctx context.Context, args []string, model CommandModel) tea.Msg {
+	if len(args) == 0 {
+		return ResponseMsg{Content: "Usage: /think <question>\nExample: /think how should we split the storage layer?"}
+	}
+
+	return LLMRequestMsg{
+		Message:   strings.Join(args, " "),
+		ThinkOnly: true,
+	}
+}
+
+func (c *ThinkCommand) GetName() string {
+	return "think"
+}
+
+func (c *ThinkCommand) GetUsage() string {
+	return "/think <question>"
+}
+
+func (c *ThinkCommand) GetDescription() string {
+	return "Reason and propose next steps without running tools"
+}
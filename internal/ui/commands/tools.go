@@ -0,0 +1,48 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ToolsCommand handles the /tools command, listing the tools the model can use and
+// those the configuration turned off
+type ToolsCommand struct{}
+
+func (c *ToolsCommand) Execute(ctx context.Context, args []string, model CommandModel) tea.Msg {
+	session := model.GetSession()
+	if session == nil {
+		return ResponseMsg{Content: "✗ No active session"}
+	}
+
+	tools := session.ToolSet()
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("🧰 %d active tool(s):\n", len(tools.Active)))
+	for _, name := range tools.Active {
+		result.WriteString("  • " + name + "\n")
+	}
+
+	if len(tools.Disabled) > 0 {
+		result.WriteString(fmt.Sprintf("\n🚫 Disabled by tools.enabled_tools / tools.disabled_tools: %s\n", strings.Join(tools.Disabled, ", ")))
+	}
+	if len(tools.Unknown) > 0 {
+		result.WriteString(fmt.Sprintf("\n⚠️ No tool is named %s; check the tool lists in your config\n", strings.Join(tools.Unknown, ", ")))
+	}
+
+	return ResponseMsg{Content: strings.TrimRight(result.String(), "\n")}
+}
+
+func (c *ToolsCommand) GetName() string {
+	return "tools"
+}
+
+func (c *ToolsCommand) GetUsage() string {
+	return "/tools"
+}
+
+func (c *ToolsCommand) GetDescription() string {
+	return "List the tools the model can use"
+}
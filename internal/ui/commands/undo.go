@@ -0,0 +1,35 @@
+package commands
+
+import (
+	"context"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// UndoCommand handles the /undo command, reverting the most recent file change the
+// assistant made. Repeating it walks back through earlier changes.
+type UndoCommand struct{}
+
+func (c *UndoCommand) Execute(ctx context.Context, args []string, model CommandModel) tea.Msg {
+	summary, err := model.UndoLastChange()
+	if err != nil {
+		return ResponseMsg{Content: "✗ " + err.Error()}
+	}
+	if summary == "" {
+		return ResponseMsg{Content: "ℹ No file changes to undo"}
+	}
+
+	return ResponseMsg{Content: "↩ " + summary}
+}
+
+func (c *UndoCommand) GetName() string {
+	return "undo"
+}
+
+func (c *UndoCommand) GetUsage() string {
+	return "/undo"
+}
+
+func (c *UndoCommand) GetDescription() string {
+	return "Revert the most recent file change"
+}
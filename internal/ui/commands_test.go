@@ -0,0 +1,30 @@
+package ui
+
+import (
+	"github.com/tildaslashalef/bazinga/internal/llm"
+	"github.com/tildaslashalef/bazinga/internal/session"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestPermissionManagerAdapterGetToolRisk tests that commands see the risk the session
+// assesses from a call's arguments
+func TestPermissionManagerAdapterGetToolRisk(t *testing.T) {
+	adapter := &PermissionManagerAdapter{pm: session.NewPermissionManager()}
+
+	tests := []struct {
+		name string
+		call *llm.ToolCall
+		want string
+	}{
+		{"read", &llm.ToolCall{Name: "read_file", Input: map[string]interface{}{"file_path": "main.go"}}, "low"},
+		{"edit", &llm.ToolCall{Name: "edit_file", Input: map[string]interface{}{"file_path": "main.go"}}, "medium"},
+		{"sensitive read", &llm.ToolCall{Name: "read_file", Input: map[string]interface{}{"file_path": ".env"}}, "high"},
+		{"destructive command", &llm.ToolCall{Name: "bash", Input: map[string]interface{}{"command": "rm -rf build"}}, "high"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, adapter.GetToolRisk(tt.call), tt.name)
+	}
+}
@@ -0,0 +1,152 @@
+package ui
+
+import (
+	"fmt"
+	"github.com/tildaslashalef/bazinga/internal/config"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// costGuard decides when a request is expensive enough to ask before sending it
+type costGuard struct {
+	enabled   bool
+	maxCost   float64 // USD, 0 = ignore cost
+	maxTokens int     // 0 = ignore tokens
+	dismissed bool    // "don't ask again this session"
+}
+
+// newCostGuard builds a cost guard from the UI config
+func newCostGuard(cfg *config.Config) costGuard {
+	if cfg == nil {
+		return costGuard{}
+	}
+	return costGuard{
+		enabled:   cfg.UI.ConfirmExpensive,
+		maxCost:   cfg.UI.ConfirmCostThreshold,
+		maxTokens: cfg.UI.ConfirmTokenThreshold,
+	}
+}
+
+// exceeds reports whether a request with the given estimate needs confirmation
+func (g costGuard) exceeds(tokens int, cost float64) bool {
+	if !g.enabled || g.dismissed {
+		return false
+	}
+	return (g.maxCost > 0 && cost > g.maxCost) || (g.maxTokens > 0 && tokens > g.maxTokens)
+}
+
+// costConfirmation is a request held back until the user accepts its estimated cost
+type costConfirmation struct {
+	Message   string
+	ThinkOnly bool
+	Tokens    int
+	Cost      float64
+}
+
+// dispatchRequest sends a message to the AI, first asking for confirmation if its
+// estimated size or cost is over the configured threshold
+func (m *Model) dispatchRequest(message string, thinkOnly bool) tea.Cmd {
+	if !m.providerAvailable() {
+		m.addMessage(ChatMessage{
+			Role:      "system",
+			Content:   "✗ Request not sent: no LLM provider is configured. " + noProviderHint,
+			Timestamp: time.Now(),
+		})
+		return nil
+	}
+
+	var tokens int
+	var cost float64
+	if m.estimateRequest != nil {
+		tokens, cost = m.estimateRequest(message)
+	}
+
+	if m.costGuard.exceeds(tokens, cost) {
+		m.pendingSend = &costConfirmation{
+			Message:   message,
+			ThinkOnly: thinkOnly,
+			Tokens:    tokens,
+			Cost:      cost,
+		}
+		return nil
+	}
+
+	return m.startRequest(message, thinkOnly, tokens)
+}
+
+// startRequest shows the thinking state and starts streaming the response
+func (m *Model) startRequest(message string, thinkOnly bool, tokens int) tea.Cmd {
+	m.isThinking = true
+	m.thinkingStartTime = time.Now()
+	// Estimate of the full prompt (system prompt, files, history and this message),
+	// replaced by the API's counts once the response reports them
+	m.inputTokens = tokens
+	m.resetTurnUsage()
+	m.inFlight = &sentRequest{Message: message, ThinkOnly: thinkOnly}
+	m.addMessage(ChatMessage{
+		Role:      "assistant",
+		Content:   "",
+		Timestamp: time.Now(),
+		Streaming: true,
+	})
+
+	if thinkOnly {
+		return m.sendThinkToAI(message)
+	}
+	return m.sendToAI(message)
+}
+
+// handleCostConfirmationKey answers a pending cost confirmation
+func (m *Model) handleCostConfirmationKey(key string) tea.Cmd {
+	pending := m.pendingSend
+
+	switch key {
+	case "y", "Y", "enter":
+		m.pendingSend = nil
+		return m.startRequest(pending.Message, pending.ThinkOnly, pending.Tokens)
+	case "a", "A":
+		m.pendingSend = nil
+		m.costGuard.dismissed = true
+		return m.startRequest(pending.Message, pending.ThinkOnly, pending.Tokens)
+	case "n", "N", "esc":
+		m.pendingSend = nil
+		m.addMessage(ChatMessage{
+			Role:      "system",
+			Content:   "✗ Request not sent",
+			Timestamp: time.Now(),
+		})
+		m.stopAgent("⏸ Agent mode stopped")
+	}
+
+	return nil
+}
+
+// renderCostConfirmation renders the prompt asking whether to send an expensive request
+func (m *Model) renderCostConfirmation() string {
+	if m.pendingSend == nil {
+		return ""
+	}
+
+	promptStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(WarningColor).
+		Padding(0, 2).
+		Width(m.width - 4)
+
+	var content strings.Builder
+	content.WriteString(lipgloss.NewStyle().Foreground(WarningColor).Bold(true).Render("💸 Large request"))
+	content.WriteString("\n\n")
+	if m.pendingSend.Cost > 0 {
+		content.WriteString(fmt.Sprintf("This request is estimated at $%.2f / %d tokens — send?", m.pendingSend.Cost, m.pendingSend.Tokens))
+	} else {
+		content.WriteString(fmt.Sprintf("This request is estimated at %d tokens — send?", m.pendingSend.Tokens))
+	}
+	content.WriteString("\n\n")
+	content.WriteString(lipgloss.NewStyle().Foreground(TextSecondary).Render(
+		"y send anyway • a send and don't ask again this session • n cancel"))
+
+	return promptStyle.Render(content.String())
+}
@@ -0,0 +1,79 @@
+package ui
+
+import (
+	"github.com/tildaslashalef/bazinga/internal/config"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newCostGuardModel(tokens int, cost float64) *Model {
+	cfg := config.DefaultConfig()
+	cfg.UI.ConfirmCostThreshold = 0.50
+	cfg.UI.ConfirmTokenThreshold = 100000
+
+	m := newTestModel()
+	m.costGuard = newCostGuard(cfg)
+	m.estimateRequest = func(string) (int, float64) { return tokens, cost }
+	return m
+}
+
+// TestCostConfirmationOverThreshold tests that an expensive request waits for confirmation
+func TestCostConfirmationOverThreshold(t *testing.T) {
+	m := newCostGuardModel(20000, 0.75)
+
+	cmd := m.dispatchRequest("refactor everything", false)
+	assert.Nil(t, cmd, "nothing should be sent before confirmation")
+	require.NotNil(t, m.pendingSend)
+	assert.False(t, m.isThinking)
+	assert.Contains(t, m.renderCostConfirmation(), "$0.75 / 20000 tokens")
+
+	// "don't ask again this session" sends and disables the guard
+	cmd = m.handleCostConfirmationKey("a")
+	assert.NotNil(t, cmd)
+	assert.Nil(t, m.pendingSend)
+	assert.True(t, m.isThinking)
+
+	m.isThinking = false
+	assert.NotNil(t, m.dispatchRequest("and again", false))
+	assert.Nil(t, m.pendingSend, "guard should not ask again after 'a'")
+}
+
+// TestCostConfirmationUnderThreshold tests that cheap requests are sent immediately
+func TestCostConfirmationUnderThreshold(t *testing.T) {
+	m := newCostGuardModel(20000, 0.10)
+
+	cmd := m.dispatchRequest("small question", false)
+	assert.NotNil(t, cmd)
+	assert.Nil(t, m.pendingSend)
+	assert.True(t, m.isThinking)
+	assert.Equal(t, 20000, m.inputTokens)
+}
+
+// TestCostConfirmationTokenThreshold tests the token threshold when pricing is unknown
+func TestCostConfirmationTokenThreshold(t *testing.T) {
+	m := newCostGuardModel(250000, 0)
+
+	assert.Nil(t, m.dispatchRequest("huge context", true))
+	require.NotNil(t, m.pendingSend)
+	assert.True(t, m.pendingSend.ThinkOnly)
+
+	// Cancelling drops the request
+	assert.Nil(t, m.handleCostConfirmationKey("n"))
+	assert.Nil(t, m.pendingSend)
+	assert.False(t, m.isThinking)
+}
+
+// TestDispatchRequestWithoutProvider tests that requests are held back when no provider is configured
+func TestDispatchRequestWithoutProvider(t *testing.T) {
+	m := newCostGuardModel(100, 0.01)
+	m.hasProvider = func() bool { return false }
+
+	assert.Nil(t, m.dispatchRequest("hello", false))
+	assert.False(t, m.isThinking)
+	assert.Nil(t, m.pendingSend)
+	require.NotEmpty(t, m.messages)
+	assert.Contains(t, m.messages[len(m.messages)-1].Content, "bazinga doctor")
+	assert.Contains(t, m.createWelcomeMessage(), "No LLM provider is configured")
+}
@@ -0,0 +1,68 @@
+package ui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maxDenyReasonLength caps the reason typed when denying a tool call
+const maxDenyReasonLength = 300
+
+// denyPermission denies the pending tool call and moves on to the next one. A reason
+// is passed on to the model; a plain denial is also remembered for identical calls.
+func (m *Model) denyPermission(reason string) {
+	request := m.pendingPermission
+	request.enteringReason = false
+	request.DenyReason = strings.TrimSpace(reason)
+	if request.DenyReason == "" {
+		m.permissionHistory[m.generatePermissionKey(request.ToolCall)] = false
+	}
+	request.ResponseChan <- false
+
+	// Remove from queue and move to next
+	m.removePermissionFromQueue(request.ToolID)
+}
+
+// handleDenyReasonKey edits the reason for denying the pending tool call: enter denies
+// with it, esc goes back to the other choices
+func (m *Model) handleDenyReasonKey(msg tea.KeyMsg) {
+	request := m.pendingPermission
+
+	switch msg.Type {
+	case tea.KeyEnter:
+		m.denyPermission(request.DenyReason)
+	case tea.KeyEsc:
+		request.enteringReason = false
+		request.DenyReason = ""
+	case tea.KeyBackspace:
+		if request.DenyReason != "" {
+			runes := []rune(request.DenyReason)
+			request.DenyReason = string(runes[:len(runes)-1])
+		}
+	case tea.KeyCtrlU:
+		request.DenyReason = ""
+	case tea.KeySpace:
+		request.DenyReason += " "
+	case tea.KeyRunes:
+		request.DenyReason += string(msg.Runes)
+	}
+
+	if runes := []rune(request.DenyReason); len(runes) > maxDenyReasonLength {
+		request.DenyReason = string(runes[:maxDenyReasonLength])
+	}
+}
+
+// renderDenyReasonInput renders the line the reason is typed into
+func (m *Model) renderDenyReasonInput() string {
+	label := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#FF6B6B")).
+		Bold(true).
+		Render("💬 Why deny it? The AI gets this as the tool result:")
+	hint := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#87CEEB")). // Sky blue
+		Render("⏎ (enter) Deny with reason  •  (esc) Back")
+
+	return label + "\n> " + m.pendingPermission.DenyReason + "█\n\n" + hint
+}
@@ -0,0 +1,77 @@
+package ui
+
+import (
+	"github.com/tildaslashalef/bazinga/internal/llm"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newPermissionModel() (*Model, *PermissionRequest) {
+	m := newTestModel()
+	m.width = 100
+	m.permissionHistory = make(map[string]bool)
+	request := &PermissionRequest{
+		ToolID:       "tool-1",
+		ToolCall:     &llm.ToolCall{Name: "edit_file", Input: map[string]interface{}{"file_path": "config/prod.yaml"}},
+		RiskLevel:    "medium",
+		PromptText:   "Edit config/prod.yaml",
+		ResponseChan: make(chan bool, 1),
+	}
+	m.permissionQueue = []*PermissionRequest{request}
+	m.pendingPermission = request
+	return m, request
+}
+
+func typeKeys(m *Model, text string) {
+	for _, r := range text {
+		if r == ' ' {
+			m.Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{r}})
+			continue
+		}
+		m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+}
+
+// TestDenyWithReason tests that d opens a reason input whose text goes out with the denial
+func TestDenyWithReason(t *testing.T) {
+	m, request := newPermissionModel()
+
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("d")})
+	require.True(t, request.enteringReason)
+	assert.Contains(t, m.renderPermissionPrompt(), "Why deny it?")
+
+	// Keys that answer the prompt otherwise are typed into the reason
+	typeKeys(m, "use stagingx")
+	m.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	typeKeys(m, " yaml")
+	assert.Equal(t, "use staging yaml", request.DenyReason)
+	assert.Empty(t, request.ResponseChan, "nothing is sent before enter")
+
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	assert.False(t, <-request.ResponseChan)
+	assert.Equal(t, "use staging yaml", request.DenyReason)
+	assert.Nil(t, m.pendingPermission)
+	assert.Empty(t, m.permissionHistory, "a denial with a reason is not remembered")
+}
+
+// TestDenyReasonEscGoesBack tests that esc leaves the reason input without answering
+func TestDenyReasonEscGoesBack(t *testing.T) {
+	m, request := newPermissionModel()
+
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("d")})
+	typeKeys(m, "no")
+	m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	assert.False(t, request.enteringReason)
+	assert.Empty(t, request.DenyReason)
+	assert.Same(t, request, m.pendingPermission)
+	assert.Contains(t, m.renderPermissionPrompt(), "(d) Deny & Explain")
+
+	// A plain denial is remembered for identical calls
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+	assert.False(t, <-request.ResponseChan)
+	assert.Empty(t, request.DenyReason)
+	assert.Equal(t, map[string]bool{"edit_file:config/prod.yaml": false}, m.permissionHistory)
+}
@@ -0,0 +1,83 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// diffPagerClosedMsg is sent when the pager showing a full diff exits
+type diffPagerClosedMsg struct {
+	path string
+	err  error
+}
+
+// diffPagerCommand returns the command used to open a full diff: the configured pager,
+// then $PAGER, then $EDITOR, falling back to less
+func diffPagerCommand(configured string) []string {
+	for _, candidate := range []string{configured, os.Getenv("PAGER"), os.Getenv("EDITOR")} {
+		if fields := strings.Fields(candidate); len(fields) > 0 {
+			return fields
+		}
+	}
+	return []string{"less", "-R"}
+}
+
+// openLatestDiff opens the most recent file diff in full, suspending the TUI until the
+// pager exits
+func (m *Model) openLatestDiff() tea.Cmd {
+	if len(m.fileDiffs) == 0 {
+		m.addMessage(ChatMessage{
+			Role:      "system",
+			Content:   "ℹ No file changes to show yet",
+			Timestamp: time.Now(),
+		})
+		return nil
+	}
+	diff := m.fileDiffs[len(m.fileDiffs)-1]
+
+	file, err := os.CreateTemp("", "bazinga-*.diff")
+	if err != nil {
+		m.addMessage(ChatMessage{
+			Role:      "system",
+			Content:   fmt.Sprintf("✗ Failed to open diff: %v", err),
+			Timestamp: time.Now(),
+		})
+		return nil
+	}
+	_, err = file.WriteString(diff.UnifiedDiff())
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		m.addMessage(ChatMessage{
+			Role:      "system",
+			Content:   fmt.Sprintf("✗ Failed to open diff: %v", err),
+			Timestamp: time.Now(),
+		})
+		return nil
+	}
+
+	args := append(diffPagerCommand(m.diffPager), file.Name())
+	path := file.Name()
+	return tea.ExecProcess(exec.Command(args[0], args[1:]...), func(err error) tea.Msg {
+		return diffPagerClosedMsg{path: path, err: err}
+	})
+}
+
+// handleDiffPagerClosed removes the temporary diff file and reports pager failures
+func (m *Model) handleDiffPagerClosed(msg diffPagerClosedMsg) {
+	os.Remove(msg.path)
+	if msg.err != nil {
+		m.addMessage(ChatMessage{
+			Role:      "system",
+			Content:   fmt.Sprintf("✗ Diff pager failed: %v", msg.err),
+			Timestamp: time.Now(),
+		})
+	}
+}
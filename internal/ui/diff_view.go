@@ -2,6 +2,7 @@ package ui
 
 import (
 	"fmt"
+	"path/filepath"
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
@@ -10,13 +11,18 @@ import (
 // FileDiff represents a file change with before/after content
 type FileDiff struct {
 	FilePath     string
+	FromPath     string // Source path of a move or copy
 	Before       string
 	After        string
 	Operation    string // "edit", "create", "write"
 	LinesAdded   int
 	LinesRemoved int
+	MaxLines     int // Diff lines rendered inline before truncating (0 = no limit)
 }
 
+// diffOpenKey is the key that opens the most recent diff in full
+const diffOpenKey = "ctrl+o"
+
 // DiffLine represents a single line in a diff
 type DiffLine struct {
 	Type    string // "unchanged", "added", "removed"
@@ -33,6 +39,14 @@ func GenerateDiff(filePath, before, after, operation string) *FileDiff {
 		Operation: operation,
 	}
 
+	// Moves and copies are reported as "source → destination"
+	if operation == "move" || operation == "copy" {
+		if from, to, ok := strings.Cut(filePath, " → "); ok {
+			diff.FromPath = from
+			diff.FilePath = to
+		}
+	}
+
 	// Calculate line changes
 	beforeLines := strings.Split(before, "\n")
 	afterLines := strings.Split(after, "\n")
@@ -49,7 +63,7 @@ func GenerateDiff(filePath, before, after, operation string) *FileDiff {
 
 // RenderDiff renders a diff
 func (d *FileDiff) RenderDiff() string {
-	if d.Before == d.After {
+	if d.Before == d.After && d.FromPath == "" {
 		return "" // No changes
 	}
 
@@ -68,9 +82,19 @@ func (d *FileDiff) RenderDiff() string {
 	case "write":
 		headerText = fmt.Sprintf("💾 Updated %s", d.FilePath)
 	case "move":
-		headerText = fmt.Sprintf("📁 Moved %s", d.FilePath)
+		if d.FromPath != "" && filepath.Dir(d.FromPath) == filepath.Dir(d.FilePath) {
+			headerText = fmt.Sprintf("📁 Renamed %s → %s", d.FromPath, filepath.Base(d.FilePath))
+		} else if d.FromPath != "" {
+			headerText = fmt.Sprintf("📁 Moved %s → %s", d.FromPath, d.FilePath)
+		} else {
+			headerText = fmt.Sprintf("📁 Moved %s", d.FilePath)
+		}
 	case "copy":
-		headerText = fmt.Sprintf("📋 Copied %s", d.FilePath)
+		if d.FromPath != "" {
+			headerText = fmt.Sprintf("📋 Copied %s → %s", d.FromPath, d.FilePath)
+		} else {
+			headerText = fmt.Sprintf("📋 Copied %s", d.FilePath)
+		}
 	case "delete":
 		headerText = fmt.Sprintf("🗑️ Deleted %s", d.FilePath)
 	default:
@@ -79,6 +103,12 @@ func (d *FileDiff) RenderDiff() string {
 
 	result = append(result, headerStyle.Render(headerText))
 
+	// A move or copy without edits is shown as a single line, not a delete and add
+	if d.Before == d.After {
+		result = append(result, lipgloss.NewStyle().Foreground(TextSecondary).Render("(content unchanged)"))
+		return strings.Join(result, "\n")
+	}
+
 	if d.LinesAdded > 0 || d.LinesRemoved > 0 {
 		statsStyle := lipgloss.NewStyle().Foreground(TextSecondary)
 		statsText := ""
@@ -97,6 +127,12 @@ func (d *FileDiff) RenderDiff() string {
 	// Generate and render diff lines
 	diffLines := d.generateDiffLines()
 	renderedLines := d.renderDiffLines(diffLines)
+	if d.MaxLines > 0 && len(renderedLines) > d.MaxLines {
+		hidden := len(renderedLines) - d.MaxLines
+		renderedLines = append(renderedLines[:d.MaxLines], lipgloss.NewStyle().
+			Foreground(TextMuted).
+			Render(fmt.Sprintf("… %d more diff lines hidden (%s opens the full diff)", hidden, diffOpenKey)))
+	}
 	result = append(result, renderedLines...)
 
 	return strings.Join(result, "\n")
@@ -253,9 +289,46 @@ func (d *FileDiff) renderDiffLines(diffLines []DiffLine) []string {
 	return result
 }
 
+// UnifiedDiff returns the full diff as plain text, without styling or context trimming,
+// for viewing in a pager or editor
+func (d *FileDiff) UnifiedDiff() string {
+	from := d.FilePath
+	if d.FromPath != "" {
+		from = d.FromPath
+	}
+
+	var result strings.Builder
+	switch d.Operation {
+	case "create":
+		result.WriteString("--- /dev/null\n")
+	default:
+		result.WriteString(fmt.Sprintf("--- a/%s\n", from))
+	}
+	result.WriteString(fmt.Sprintf("+++ b/%s\n", d.FilePath))
+
+	if d.Before == d.After {
+		result.WriteString("(content unchanged)\n")
+		return result.String()
+	}
+
+	for _, line := range d.generateDiffLines() {
+		switch line.Type {
+		case "added":
+			result.WriteString("+")
+		case "removed":
+			result.WriteString("-")
+		default:
+			result.WriteString(" ")
+		}
+		result.WriteString(line.Content + "\n")
+	}
+
+	return result.String()
+}
+
 // RenderCompactDiff renders a compact single-line diff summary
 func (d *FileDiff) RenderCompactDiff() string {
-	if d.Before == d.After {
+	if d.Before == d.After && d.FromPath == "" {
 		return ""
 	}
 
@@ -289,8 +362,13 @@ func (d *FileDiff) RenderCompactDiff() string {
 		statsText = fmt.Sprintf(" (-%d)", d.LinesRemoved)
 	}
 
+	path := d.FilePath
+	if d.FromPath != "" {
+		path = d.FromPath + " → " + d.FilePath
+	}
+
 	return fmt.Sprintf("%s %s%s",
 		icon,
-		fileStyle.Render(d.FilePath),
+		fileStyle.Render(path),
 		statsStyle.Render(statsText))
 }
@@ -0,0 +1,48 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRenderDiffTruncatesLongDiffs tests that diffs over the cap end with a truncation marker
+func TestRenderDiffTruncatesLongDiffs(t *testing.T) {
+	var after []string
+	for i := 1; i <= 50; i++ {
+		after = append(after, fmt.Sprintf("line %d", i))
+	}
+
+	diff := GenerateDiff("big.go", "", strings.Join(after, "\n"), "create")
+	diff.MaxLines = 10
+
+	rendered := diff.RenderDiff()
+	assert.Contains(t, rendered, "line 10")
+	assert.NotContains(t, rendered, "line 11")
+	assert.Contains(t, rendered, "… 40 more diff lines hidden (ctrl+o opens the full diff)")
+
+	// The full diff keeps every line
+	assert.Contains(t, diff.UnifiedDiff(), "+line 50\n")
+
+	// Without a cap nothing is hidden
+	diff.MaxLines = 0
+	assert.Contains(t, diff.RenderDiff(), "line 50")
+	assert.NotContains(t, diff.RenderDiff(), "more diff lines hidden")
+}
+
+// TestRenderDiffShowsRenames tests that unchanged moves render as a rename, not a delete and add
+func TestRenderDiffShowsRenames(t *testing.T) {
+	diff := GenerateDiff("internal/old.go → internal/new.go", "package x\n", "package x\n", "move")
+	assert.Equal(t, "internal/old.go", diff.FromPath)
+	assert.Equal(t, "internal/new.go", diff.FilePath)
+
+	rendered := diff.RenderDiff()
+	assert.Contains(t, rendered, "Renamed internal/old.go → new.go")
+	assert.Contains(t, rendered, "(content unchanged)")
+	assert.NotContains(t, rendered, "package x")
+
+	moved := GenerateDiff("old.go → pkg/new.go", "a", "a", "move")
+	assert.Contains(t, moved.RenderDiff(), "Moved old.go → pkg/new.go")
+}
@@ -0,0 +1,258 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"github.com/tildaslashalef/bazinga/internal/project"
+	"github.com/tildaslashalef/bazinga/internal/tools"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// filePickerVisible is how many matching files the picker shows at once
+const filePickerVisible = 10
+
+// filePicker is the overlay /add opens to choose files for the session context. Typing
+// filters the project's files, space marks them and enter adds the marked files, or
+// the highlighted one when none are marked.
+type filePicker struct {
+	files    []string // Candidates, relative to the project root
+	query    string
+	matches  []string
+	cursor   int
+	selected map[string]bool
+}
+
+// newFilePicker returns a picker over files, which are relative to the project root
+func newFilePicker(files []string) *filePicker {
+	p := &filePicker{files: files, selected: make(map[string]bool)}
+	p.filter()
+	return p
+}
+
+// filter matches the files against the query, ranking those whose name matches before
+// those that only match along their path, and shorter paths first
+func (p *filePicker) filter() {
+	query := strings.ToLower(p.query)
+
+	type match struct {
+		path   string
+		inName bool
+	}
+	var matches []match
+	for _, file := range p.files {
+		lower := strings.ToLower(file)
+		if !tools.FuzzyMatch(lower, query) {
+			continue
+		}
+		matches = append(matches, match{path: file, inName: tools.FuzzyMatch(filepath.Base(lower), query)})
+	}
+
+	if query != "" {
+		sort.SliceStable(matches, func(i, j int) bool {
+			if matches[i].inName != matches[j].inName {
+				return matches[i].inName
+			}
+			return len(matches[i].path) < len(matches[j].path)
+		})
+	}
+
+	p.matches = p.matches[:0]
+	for _, m := range matches {
+		p.matches = append(p.matches, m.path)
+	}
+	p.cursor = min(p.cursor, max(len(p.matches)-1, 0))
+}
+
+// move moves the highlight by delta, stopping at either end
+func (p *filePicker) move(delta int) {
+	p.cursor = max(0, min(p.cursor+delta, len(p.matches)-1))
+}
+
+// toggle marks or unmarks the highlighted file
+func (p *filePicker) toggle() {
+	if p.cursor >= len(p.matches) {
+		return
+	}
+	file := p.matches[p.cursor]
+	if p.selected[file] {
+		delete(p.selected, file)
+	} else {
+		p.selected[file] = true
+	}
+}
+
+// choice returns the marked files in list order, or the highlighted file if none are
+// marked
+func (p *filePicker) choice() []string {
+	var chosen []string
+	for _, file := range p.files {
+		if p.selected[file] {
+			chosen = append(chosen, file)
+		}
+	}
+	if len(chosen) == 0 && p.cursor < len(p.matches) {
+		chosen = append(chosen, p.matches[p.cursor])
+	}
+	return chosen
+}
+
+// render draws the picker: the query, a window of matches around the highlight, and
+// the keys it takes
+func (p *filePicker) render(width int) string {
+	muted := lipgloss.NewStyle().Foreground(lipgloss.Color("#928374"))
+
+	lines := []string{
+		lipgloss.NewStyle().Foreground(lipgloss.Color("#b8bb26")).Bold(true).Render("Add files: ") + p.query + "█",
+	}
+
+	start := max(0, min(p.cursor-filePickerVisible/2, len(p.matches)-filePickerVisible))
+	end := min(start+filePickerVisible, len(p.matches))
+	for i := start; i < end; i++ {
+		file := p.matches[i]
+		mark := "[ ]"
+		if p.selected[file] {
+			mark = "[x]"
+		}
+
+		style := lipgloss.NewStyle().Foreground(lipgloss.Color("#a9b1d6")).Padding(0, 1)
+		if i == p.cursor {
+			style = lipgloss.NewStyle().
+				Background(lipgloss.Color("#b8bb26")).
+				Foreground(lipgloss.Color("#1d2021")).
+				Bold(true).
+				Padding(0, 1)
+		}
+		lines = append(lines, style.Render(mark+" "+file))
+	}
+
+	if len(p.matches) == 0 {
+		lines = append(lines, muted.Render("  No matching files"))
+	}
+
+	status := fmt.Sprintf("%d of %d files", len(p.matches), len(p.files))
+	if len(p.selected) > 0 {
+		status += fmt.Sprintf(" • %d selected", len(p.selected))
+	}
+	lines = append(lines, muted.Render(status+" • ↑↓ move • space select • enter add • esc cancel"))
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("#928374")).
+		Padding(0, 1).
+		MaxWidth(width - 4).
+		Render(strings.Join(lines, "\n"))
+}
+
+// openFilePicker shows the picker over the project's files that are not in the
+// session context yet. The project scan already leaves out gitignored files; the
+// .bazingaignore is read again in case it changed since.
+func (m *Model) openFilePicker() {
+	proj := m.session.GetProject()
+	if proj == nil || len(proj.Files) == 0 {
+		m.addMessage(ChatMessage{
+			Role:      "system",
+			Content:   "ℹ No project files found. Use /add <path> to add a file by path",
+			Timestamp: time.Now(),
+		})
+		return
+	}
+
+	root := m.session.GetRootPath()
+	hidden := project.LoadBazingaIgnore(root)
+	inContext := m.session.GetFiles()
+
+	var candidates []string
+	for _, file := range proj.Files {
+		if hidden.Matches(file, false) || slices.Contains(inContext, filepath.Join(root, file)) {
+			continue
+		}
+		candidates = append(candidates, file)
+	}
+	if len(candidates) == 0 {
+		m.addMessage(ChatMessage{
+			Role:      "system",
+			Content:   "ℹ All project files are already in context",
+			Timestamp: time.Now(),
+		})
+		return
+	}
+
+	m.autocomplete.Deactivate()
+	m.showShortcuts = false
+	m.filePicker = newFilePicker(candidates)
+}
+
+// handleFilePickerKey handles a key while the file picker is open
+func (m *Model) handleFilePickerKey(msg tea.KeyMsg) tea.Cmd {
+	picker := m.filePicker
+
+	switch msg.String() {
+	case "esc", "ctrl+c":
+		m.filePicker = nil
+	case "up", "ctrl+k":
+		picker.move(-1)
+	case "down", "ctrl+j":
+		picker.move(1)
+	case " ":
+		picker.toggle()
+		picker.move(1)
+	case "enter":
+		m.filePicker = nil
+		m.addPickedFiles(picker.choice())
+	case "backspace":
+		if picker.query != "" {
+			runes := []rune(picker.query)
+			picker.query = string(runes[:len(runes)-1])
+			picker.filter()
+		}
+	default:
+		if msg.Type == tea.KeyRunes {
+			picker.query += string(msg.Runes)
+			picker.cursor = 0
+			picker.filter()
+		}
+	}
+	return nil
+}
+
+// addPickedFiles adds files chosen in the picker to the session context
+func (m *Model) addPickedFiles(files []string) {
+	if len(files) == 0 {
+		return
+	}
+
+	root := m.session.GetRootPath()
+	var added, failed []string
+	for _, file := range files {
+		if err := m.session.AddFile(context.Background(), filepath.Join(root, file)); err != nil {
+			failed = append(failed, fmt.Sprintf("  • %s: %v", file, err))
+			continue
+		}
+		added = append(added, "  • "+file)
+	}
+
+	var content strings.Builder
+	if len(added) > 0 {
+		content.WriteString(fmt.Sprintf("✓ Added %d file(s) to context:\n%s", len(added), strings.Join(added, "\n")))
+	}
+	if len(failed) > 0 {
+		if content.Len() > 0 {
+			content.WriteString("\n")
+		}
+		content.WriteString("✗ Could not add:\n" + strings.Join(failed, "\n"))
+	}
+
+	m.addMessage(ChatMessage{
+		Role:      "system",
+		Content:   content.String(),
+		Timestamp: time.Now(),
+	})
+	m.refreshTokenEstimate()
+}
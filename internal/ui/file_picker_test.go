@@ -0,0 +1,51 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestFilePickerFilter tests that the query filters files fuzzily, ranking file name
+// matches first
+func TestFilePickerFilter(t *testing.T) {
+	p := newFilePicker([]string{"internal/model/user.go", "cmd/main.go", "internal/ui/model.go", "README.md"})
+	assert.Len(t, p.matches, 4, "an empty query lists every file in order")
+	assert.Equal(t, "internal/model/user.go", p.matches[0])
+
+	p.query = "model"
+	p.filter()
+	assert.Equal(t, []string{"internal/ui/model.go", "internal/model/user.go"}, p.matches)
+
+	p.query = "MDL"
+	p.filter()
+	assert.Equal(t, []string{"internal/ui/model.go", "internal/model/user.go"}, p.matches, "matching ignores case")
+
+	p.query = "xyz"
+	p.filter()
+	assert.Empty(t, p.matches)
+	assert.Empty(t, p.choice(), "nothing to add without matches")
+}
+
+// TestFilePickerSelection tests that marked files are added in list order, and the
+// highlighted file when none are marked
+func TestFilePickerSelection(t *testing.T) {
+	p := newFilePicker([]string{"a.go", "b.go", "c.go"})
+	p.move(-1)
+	assert.Equal(t, 0, p.cursor, "the highlight stops at the top")
+	p.move(5)
+	assert.Equal(t, 2, p.cursor, "the highlight stops at the bottom")
+	assert.Equal(t, []string{"c.go"}, p.choice())
+
+	p.toggle()
+	p.move(-2)
+	p.toggle()
+	assert.Equal(t, []string{"a.go", "c.go"}, p.choice())
+
+	p.toggle()
+	assert.Equal(t, []string{"c.go"}, p.choice(), "toggling again unmarks")
+
+	p.query = "b"
+	p.filter()
+	assert.Equal(t, []string{"c.go"}, p.choice(), "marks survive filtering")
+}
@@ -0,0 +1,85 @@
+package ui
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// gitStatusRefreshInterval is how often the tick re-checks the branch and dirty state
+const gitStatusRefreshInterval = 10 * time.Second
+
+// gitStatusIndicator caches the branch and dirty state shown in the status bar
+type gitStatusIndicator struct {
+	branch     string
+	dirty      bool
+	available  bool // false outside a git repository or when git fails
+	checkedAt  time.Time
+	stale      bool // set when a tool may have changed the working tree
+	refreshing bool
+}
+
+// gitStatusMsg carries the result of a background git status check
+type gitStatusMsg struct {
+	branch string
+	dirty  bool
+	err    error
+}
+
+// refreshGitStatus checks the branch and dirty state in the background
+func (m *Model) refreshGitStatus() tea.Cmd {
+	if m.gitStateFn == nil || m.gitStatus.refreshing {
+		return nil
+	}
+
+	m.gitStatus.refreshing = true
+	m.gitStatus.stale = false
+	check := m.gitStateFn
+
+	return func() tea.Msg {
+		branch, dirty, err := check()
+		return gitStatusMsg{branch: branch, dirty: dirty, err: err}
+	}
+}
+
+// maybeRefreshGitStatus refreshes the cached git state if a tool changed the working
+// tree or the cache is older than gitStatusRefreshInterval
+func (m *Model) maybeRefreshGitStatus(now time.Time) tea.Cmd {
+	if !m.gitStatus.stale && now.Sub(m.gitStatus.checkedAt) < gitStatusRefreshInterval {
+		return nil
+	}
+	return m.refreshGitStatus()
+}
+
+// handleGitStatus stores the result of a git status check
+func (m *Model) handleGitStatus(msg gitStatusMsg) {
+	m.gitStatus.refreshing = false
+	m.gitStatus.checkedAt = time.Now()
+	m.gitStatus.available = msg.err == nil && msg.branch != ""
+	m.gitStatus.branch = msg.branch
+	m.gitStatus.dirty = msg.dirty
+}
+
+// affectsGitStatus reports whether a tool can change the branch or working tree
+func affectsGitStatus(toolName string) bool {
+	switch toolName {
+	case "write_file", "create_file", "edit_file", "multi_edit_file", "apply_patch", "move_file", "copy_file",
+		"delete_file", "create_dir", "delete_dir", "bash", "git_add", "git_commit", "git_branch", "git_stash", "git_stash_pop", "git_restore":
+		return true
+	}
+	return false
+}
+
+// render returns the status bar segment, e.g. "⎇ main *" when there are uncommitted changes
+func (g gitStatusIndicator) render() string {
+	if !g.available {
+		return ""
+	}
+
+	segment := lipgloss.NewStyle().Foreground(TextSecondary).Render("⎇ " + g.branch)
+	if g.dirty {
+		segment += lipgloss.NewStyle().Foreground(WarningColor).Render(" *")
+	}
+	return segment
+}
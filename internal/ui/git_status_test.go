@@ -0,0 +1,85 @@
+package ui
+
+import (
+	"context"
+	"github.com/tildaslashalef/bazinga/internal/llm"
+	"github.com/tildaslashalef/bazinga/internal/session"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runGitStatusCmd runs a refresh command and feeds its result back into the model
+func runGitStatusCmd(t *testing.T, m *Model, now time.Time) {
+	t.Helper()
+	cmd := m.maybeRefreshGitStatus(now)
+	require.NotNil(t, cmd, "expected a git status refresh")
+	m.handleGitStatus(cmd().(gitStatusMsg))
+}
+
+// TestGitStatusIndicatorUpdatesAfterFileChange tests that the branch indicator turns dirty after a write tool
+func TestGitStatusIndicatorUpdatesAfterFileChange(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"checkout", "-q", "-b", "feature/status"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "--allow-empty", "-m", "initial"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		output, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(output))
+	}
+
+	sess := &session.Session{RootPath: dir}
+	m := newTestModel()
+	m.gitStateFn = func() (string, bool, error) { return sess.GitState(context.Background()) }
+
+	now := time.Now()
+	runGitStatusCmd(t, m, now)
+	assert.Equal(t, "feature/status", m.gitStatus.branch)
+	assert.False(t, m.gitStatus.dirty)
+	assert.Contains(t, m.renderStatusBar(), "⎇ feature/status")
+
+	// The cached state is reused until it expires or a tool changes the tree
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0o644))
+	assert.Nil(t, m.maybeRefreshGitStatus(now.Add(time.Second)))
+
+	m.handleStreamChunk(StreamChunkMsg{Chunk: &llm.StreamChunk{
+		Type: "tool_completion",
+		ToolCompletion: &llm.ToolCompletion{
+			ToolName: "write_file",
+			Args:     map[string]interface{}{"file_path": "main.go"},
+			Result:   "Wrote main.go",
+			State:    "complete",
+		},
+	}})
+
+	runGitStatusCmd(t, m, now.Add(time.Second))
+	assert.True(t, m.gitStatus.dirty)
+	assert.Contains(t, m.renderStatusBar(), "⎇ feature/status *")
+}
+
+// TestGitStatusIndicatorOutsideRepository tests that no segment is shown when git status fails
+func TestGitStatusIndicatorOutsideRepository(t *testing.T) {
+	m := newTestModel()
+	m.gitStateFn = func() (string, bool, error) { return "", false, assert.AnError }
+
+	runGitStatusCmd(t, m, time.Now())
+	assert.NotContains(t, m.renderStatusBar(), "⎇")
+
+	// Read-only tools don't invalidate the cache
+	m.handleStreamChunk(StreamChunkMsg{Chunk: &llm.StreamChunk{
+		ToolCompletion: &llm.ToolCompletion{ToolName: "read_file", State: "complete"},
+	}})
+	assert.Nil(t, m.maybeRefreshGitStatus(time.Now()))
+}
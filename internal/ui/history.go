@@ -0,0 +1,165 @@
+package ui
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/tildaslashalef/bazinga/internal/config"
+	"github.com/tildaslashalef/bazinga/internal/loggy"
+	"os"
+	"path/filepath"
+)
+
+// maxInputHistory caps how many submitted inputs are kept; the oldest are dropped first
+const maxInputHistory = 200
+
+// inputHistory holds the inputs submitted in a project, oldest first, for up and down to
+// recall like a shell history
+type inputHistory struct {
+	entries []string
+	path    string // File the history is saved to ("" = not saved)
+
+	index int    // Entry shown while browsing; len(entries) when not browsing
+	draft string // Input typed before browsing started, restored after the newest entry
+}
+
+// inputHistoryPath returns the file the input history of the project at rootPath is
+// saved to, in the config directory. The name includes a hash of the root so projects
+// with the same directory name keep separate histories.
+func inputHistoryPath(rootPath string) (string, error) {
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	projectName := filepath.Base(rootPath)
+	if projectName == "" || projectName == "." || projectName == string(filepath.Separator) {
+		projectName = "default"
+	}
+	sum := sha256.Sum256([]byte(rootPath))
+	return filepath.Join(configDir, "history", fmt.Sprintf("%s_%s.json", projectName, hex.EncodeToString(sum[:4]))), nil
+}
+
+// loadInputHistory reads the history saved at path. A missing or unreadable file starts
+// an empty history that is still saved there.
+func loadInputHistory(path string) *inputHistory {
+	h := &inputHistory{path: path}
+
+	if data, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(data, &h.entries); err != nil {
+			loggy.Warn("Could not parse input history, starting a new one", "path", path, "error", err)
+			h.entries = nil
+		}
+	} else if !os.IsNotExist(err) {
+		loggy.Debug("Could not read input history", "path", path, "error", err)
+	}
+
+	if len(h.entries) > maxInputHistory {
+		h.entries = h.entries[len(h.entries)-maxInputHistory:]
+	}
+	h.index = len(h.entries)
+	return h
+}
+
+// Add records a submitted input and stops browsing. Repeating the latest input is not
+// recorded twice.
+func (h *inputHistory) Add(input string) {
+	if input != "" && (len(h.entries) == 0 || h.entries[len(h.entries)-1] != input) {
+		h.entries = append(h.entries, input)
+		if len(h.entries) > maxInputHistory {
+			h.entries = append([]string(nil), h.entries[len(h.entries)-maxInputHistory:]...)
+		}
+		h.save()
+	}
+
+	h.index = len(h.entries)
+	h.draft = ""
+}
+
+// browsing reports whether current is the entry being browsed, unedited
+func (h *inputHistory) browsing(current string) bool {
+	return h.index < len(h.entries) && h.entries[h.index] == current
+}
+
+// Previous returns the entry before the one being browsed, starting from the newest and
+// keeping current as the draft. It returns false at the oldest entry.
+func (h *inputHistory) Previous(current string) (string, bool) {
+	if !h.browsing(current) {
+		h.index = len(h.entries)
+		h.draft = current
+	}
+	if h.index == 0 {
+		return "", false
+	}
+
+	h.index--
+	return h.entries[h.index], true
+}
+
+// Next returns the entry after the one being browsed, or the draft after the newest. It
+// returns false when not browsing.
+func (h *inputHistory) Next(current string) (string, bool) {
+	if !h.browsing(current) {
+		return "", false
+	}
+
+	h.index++
+	if h.index == len(h.entries) {
+		return h.draft, true
+	}
+	return h.entries[h.index], true
+}
+
+// save writes the history to its file
+func (h *inputHistory) save() {
+	if h.path == "" {
+		return
+	}
+
+	data, err := json.Marshal(h.entries)
+	if err == nil {
+		if err = os.MkdirAll(filepath.Dir(h.path), 0o755); err == nil {
+			err = os.WriteFile(h.path, data, 0o600)
+		}
+	}
+	if err != nil {
+		loggy.Warn("Could not save input history", "path", h.path, "error", err)
+	}
+}
+
+// recallHistory replaces the input with the previous (step -1) or next (step 1) history
+// entry. Up recalls when the input is empty or the cursor is on its first line, down while
+// a recalled entry is shown and the cursor is on its last line; otherwise the keys move the
+// cursor or the autocomplete selection as usual. It reports whether the key was used.
+func (m *Model) recallHistory(step int) bool {
+	if m.history == nil {
+		return false
+	}
+
+	current := m.textarea.Value()
+	if m.history.browsing(current) {
+		if (step < 0 && m.textarea.Line() > 0) || (step > 0 && m.textarea.Line() < m.textarea.LineCount()-1) {
+			return false
+		}
+	} else if step > 0 || m.autocomplete.IsActive() || (current != "" && m.textarea.Line() > 0) {
+		return false
+	}
+
+	var entry string
+	var ok bool
+	if step < 0 {
+		entry, ok = m.history.Previous(current)
+	} else {
+		entry, ok = m.history.Next(current)
+	}
+	if !ok {
+		return m.history.browsing(current)
+	}
+
+	m.textarea.SetValue(entry)
+	m.textarea.CursorEnd()
+	// A recalled command shouldn't open the command menu; up and down keep browsing
+	m.autocomplete.Deactivate()
+	return true
+}
@@ -0,0 +1,104 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestInputHistoryBrowse tests walking back and forth through the history, with the
+// draft restored after the newest entry
+func TestInputHistoryBrowse(t *testing.T) {
+	h := loadInputHistory("")
+	h.Add("first")
+	h.Add("second")
+	h.Add("second")
+	h.Add("")
+	assert.Equal(t, []string{"first", "second"}, h.entries)
+
+	entry, ok := h.Previous("half typed")
+	assert.True(t, ok)
+	assert.Equal(t, "second", entry)
+	entry, _ = h.Previous(entry)
+	assert.Equal(t, "first", entry)
+	_, ok = h.Previous(entry)
+	assert.False(t, ok, "there is nothing before the oldest entry")
+
+	entry, _ = h.Next("first")
+	assert.Equal(t, "second", entry)
+	entry, _ = h.Next(entry)
+	assert.Equal(t, "half typed", entry)
+	_, ok = h.Next(entry)
+	assert.False(t, ok, "down does nothing once the draft is back")
+
+	// Editing a recalled entry starts over from the newest
+	h.Previous("")
+	entry, _ = h.Previous("second, edited")
+	assert.Equal(t, "second", entry)
+}
+
+// TestInputHistoryPersists tests that the history is saved per project and capped
+func TestInputHistoryPersists(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	path, err := inputHistoryPath("/work/api")
+	require.NoError(t, err)
+	otherPath, err := inputHistoryPath("/other/api")
+	require.NoError(t, err)
+	assert.NotEqual(t, path, otherPath, "projects with the same name keep separate histories")
+	assert.True(t, strings.HasPrefix(filepath.Base(path), "api_"))
+
+	h := loadInputHistory(path)
+	for i := 0; i < maxInputHistory+5; i++ {
+		h.Add(strings.Repeat("x", i+1))
+	}
+
+	reloaded := loadInputHistory(path)
+	require.Len(t, reloaded.entries, maxInputHistory)
+	assert.Equal(t, strings.Repeat("x", 6), reloaded.entries[0], "the oldest entries are dropped")
+
+	require.NoError(t, os.WriteFile(path, []byte("not json"), 0o600))
+	assert.Empty(t, loadInputHistory(path).entries)
+}
+
+// TestHistoryKeys tests that up and down recall inputs in the textarea, and leave the
+// autocomplete and multi-line input alone
+func TestHistoryKeys(t *testing.T) {
+	m := newTestModel()
+	m.textarea = textarea.New()
+	m.textarea.Focus()
+	m.history = loadInputHistory("")
+	m.history.Add("explain main.go")
+	m.history.Add("/rescan")
+
+	m.Update(tea.KeyMsg{Type: tea.KeyUp})
+	assert.Equal(t, "/rescan", m.textarea.Value())
+	assert.False(t, m.autocomplete.IsActive(), "a recalled command doesn't open the menu")
+	m.Update(tea.KeyMsg{Type: tea.KeyUp})
+	assert.Equal(t, "explain main.go", m.textarea.Value())
+	m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	assert.Equal(t, "", m.textarea.Value())
+
+	// While typing a command, up moves through the suggestions instead
+	m.textarea.SetValue("/mo")
+	m.autocomplete.Update("/mo")
+	require.True(t, m.autocomplete.IsActive())
+	m.Update(tea.KeyMsg{Type: tea.KeyUp})
+	assert.Equal(t, "/mo", m.textarea.Value())
+
+	// Below the first line of a multi-line input, up moves the cursor
+	m.autocomplete.Deactivate()
+	m.textarea.SetValue("line one\nline two")
+	m.Update(tea.KeyMsg{Type: tea.KeyUp})
+	assert.Equal(t, "line one\nline two", m.textarea.Value())
+	assert.Equal(t, 0, m.textarea.Line())
+	m.Update(tea.KeyMsg{Type: tea.KeyUp})
+	assert.Equal(t, "/rescan", m.textarea.Value())
+}
@@ -1,11 +1,14 @@
 package ui
 
 import (
+	"context"
 	"fmt"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"time"
 
+	"github.com/tildaslashalef/bazinga/internal/config"
 	"github.com/tildaslashalef/bazinga/internal/llm"
 	"github.com/tildaslashalef/bazinga/internal/loggy"
 	"github.com/tildaslashalef/bazinga/internal/session"
@@ -23,18 +26,26 @@ import (
 type ChatMessage struct {
 	Role      string // "user", "assistant", "system", "tool"
 	Content   string
+	Thinking  string // Reasoning streamed before an assistant response
 	Timestamp time.Time
 	Streaming bool                   // For streaming responses
 	IsToolMsg bool                   // Flag for tool-related messages
 	ToolName  string                 // Name of tool if it's a tool message
 	ToolArgs  map[string]interface{} // Arguments for tool call
-	ToolState string                 // "start", "complete", or "error"
+	ToolState string                 // "start", "output", "complete", or "error"
 	TaskGroup string                 // Optional task group for grouping related tools
+
+	// A completed tool's full result, shown in place of the summary while Expanded
+	ToolResult string
+	Expanded   bool
+	Focused    bool // Selected with the focus keys; expandKey acts on it
 }
 
 // Model represents the main UI state for the chat interface
 type Model struct {
+	program    *tea.Program // Callbacks on other goroutines send messages through it
 	session    *session.Session
+	version    string
 	viewport   viewport.Model
 	textarea   textarea.Model
 	messages   []ChatMessage
@@ -45,37 +56,81 @@ type Model struct {
 
 	// Streaming state
 	currentStream <-chan *llm.StreamChunk
+	followTail    bool // Keep the viewport pinned to the newest output
 
 	// Status tracking
 	inputTokens       int
 	outputTokens      int
+	usage             llm.Usage       // Token counts the API reported for the current turn
+	streamedText      strings.Builder // Response text of the current turn, for estimates
 	toolCount         int
 	thinkingStartTime time.Time
+	rateLimitedUntil  time.Time // When the request waiting on the rate limit goes out
 
 	// Simplified tool tracking via chat messages
+	toolOutput toolOutputSettings // How tool completions are rendered
 
 	// Compatibility fields for commands.go
 	status          []StatusItem
 	glamourRenderer *glamour.TermRenderer
+	markdownTheme   string // Theme glamourRenderer was built from
 	sessionManager  *session.Manager
 	chatViewport    viewport.Model
 
 	// Autocomplete system
 	autocomplete *AutocompleteState
+	// Saved sessions offered for /resume, read on first use
+	savedSessions []commands.SavedSessionInfo
+	// Inputs submitted in this project, recalled with up and down
+	history *inputHistory
+
+	// First line of each message in the rendered chat, for scrolling to a focused message
+	messageLines []int
 
 	// Shortcuts overlay system
 	showShortcuts bool
 
+	// File picker opened by /add without arguments
+	filePicker *filePicker
+
+	// Show the reasoning above responses instead of only its label
+	showThinking bool
+
+	// /agent working through the todo list, one request per todo (nil = off)
+	agent *agentRun
+	todos todoStore
+
 	// Command registry for modular command handling
 	commandRegistry *commands.Registry
 
 	// File diff tracking
-	fileDiffs []*FileDiff
+	fileDiffs     []*FileDiff
+	runningOutput []string // Latest output lines of the running tool
+	diffMaxLines  int      // Inline diff lines before truncating (0 = no limit)
+	diffPager     string   // Configured command for opening a full diff
 
 	// Permission system state
 	pendingPermission *PermissionRequest
 	permissionHistory map[string]bool      // Remember permissions for session
 	permissionQueue   []*PermissionRequest // Queue of pending permissions
+
+	// Confirmation before sending expensive requests
+	costGuard       costGuard
+	pendingSend     *costConfirmation
+	estimateRequest func(message string) (tokens int, cost float64)
+
+	// The request being sent, and the last one that failed before the model replied;
+	// retryArmed lets retryKey resend it until another key is pressed
+	inFlight      *sentRequest
+	failedRequest *sentRequest
+	retryArmed    bool
+
+	// Reports whether any provider can take requests; nil means one is assumed
+	hasProvider func() bool
+
+	// Branch and dirty indicator in the status bar
+	gitStatus  gitStatusIndicator
+	gitStateFn func() (branch string, dirty bool, err error)
 }
 
 // PermissionRequest represents a pending permission request
@@ -89,6 +144,13 @@ type PermissionRequest struct {
 	TotalQueued   int
 	PromptText    string
 	ResponseChan  chan bool
+
+	// Calls of the same response still to come, listed so A approves nothing unseen
+	BatchCalls []*llm.ToolCall
+
+	// The reason typed after pressing d, passed on to the model with the denial
+	DenyReason     string
+	enteringReason bool
 }
 
 // SetupFileChangeCallback configures the file change callback for diff tracking
@@ -97,7 +159,10 @@ func (m *Model) SetupFileChangeCallback() {
 		toolExecutor := m.session.GetToolExecutor()
 		if toolExecutor != nil {
 			toolExecutor.SetFileChangeCallback(func(change tools.FileChange) {
+				m.session.RecordFileChange(change)
+
 				diff := GenerateDiff(change.FilePath, change.Before, change.After, change.Operation)
+				diff.MaxLines = m.diffMaxLines
 				m.fileDiffs = append(m.fileDiffs, diff)
 
 				// Add diff message to chat
@@ -110,40 +175,81 @@ func (m *Model) SetupFileChangeCallback() {
 					})
 				}
 			})
+
+			// Plan mode previews changes without writing them; they are not undoable
+			// until /apply runs them, so they stay off the diff stack
+			toolExecutor.SetPlanCallback(func(change tools.FileChange) {
+				diff := GenerateDiff(change.FilePath, change.Before, change.After, change.Operation)
+				diff.MaxLines = m.diffMaxLines
+
+				if diffContent := diff.RenderDiff(); diffContent != "" {
+					m.addMessage(ChatMessage{
+						Role:      "system",
+						Content:   "📝 Planned, not applied yet (/apply to write it)\n" + diffContent,
+						Timestamp: time.Now(),
+					})
+				}
+			})
 		}
 	}
 }
 
+// rateLimitMsg reports that a request is waiting on the rate limit until the given time
+type rateLimitMsg struct {
+	until time.Time
+}
+
+// SetProgram sets the program running the model, before it starts
+func (m *Model) SetProgram(program *tea.Program) {
+	m.program = program
+}
+
+// SetupRateLimitCallback shows in the status bar when a request waits on the rate limit.
+// The wait is reported from the provider's goroutine, so it goes through the program.
+func (m *Model) SetupRateLimitCallback() {
+	if m.session != nil {
+		m.session.SetRateLimitCallback(func(wait time.Duration) {
+			if m.program != nil {
+				m.program.Send(rateLimitMsg{until: time.Now().Add(wait)})
+			}
+		})
+	}
+}
+
 // SetupPermissionCallback configures the permission callback for tool execution approval
 func (m *Model) SetupPermissionCallback() {
 	if m.session != nil {
 		permissionManager := m.session.GetPermissionManager()
 		if permissionManager != nil {
 			// Set up permission callback with terminator support
-			permissionManager.SetPromptCallback(func(toolCall *llm.ToolCall) bool {
-				// Check if terminator mode is enabled (bypass all permissions)
-				if m.session.IsTerminatorMode() {
-					loggy.Info("Terminator mode enabled, bypassing permission check", "tool", toolCall.Name)
-					return true
+			permissionManager.SetPromptDecisionCallback(func(toolCall *llm.ToolCall) session.PermissionDecision {
+				// Terminator mode approves the calls its scope covers without asking
+				if m.session.TerminatorApproves(toolCall) {
+					loggy.Info("Terminator mode enabled, bypassing permission check", "tool", toolCall.Name, "scope", m.session.TerminatorScope())
+					return session.PermissionDecision{Approved: true}
 				}
 
+				// Calls a prompt rule or special conditions escalated are always asked
+				// about, even when an earlier answer or a low risk would cover them
+				required := permissionManager.RequiresPrompt(toolCall)
+
 				// Check if we already have permission for this tool call (session memory)
 				key := m.generatePermissionKey(toolCall)
-				if approved, exists := m.permissionHistory[key]; exists {
+				if approved, exists := m.permissionHistory[key]; exists && !required {
 					loggy.Debug("Using cached permission decision", "tool", toolCall.Name, "approved", approved)
-					return approved
+					return session.PermissionDecision{Approved: approved}
 				}
 
 				// Get risk level
 				risk := permissionManager.GetToolRisk(toolCall)
 
 				// Auto-approve low risk tools, prompt for medium/high risk
-				switch risk {
-				case "low":
+				switch {
+				case risk == "low" && !required:
 					loggy.Debug("Auto-approving low risk tool", "tool", toolCall.Name)
 					m.permissionHistory[key] = true
-					return true
-				case "medium", "high":
+					return session.PermissionDecision{Approved: true}
+				case risk == "low" || risk == "medium" || risk == "high":
 					// Use async permission system for real user prompts
 					loggy.Info("Tool requires permission, requesting async approval", "tool", toolCall.Name, "risk", risk)
 
@@ -162,12 +268,18 @@ func (m *Model) SetupPermissionCallback() {
 						ToolCall:      toolCall,
 						RiskLevel:     risk,
 						RiskReasons:   riskReasons,
-						AffectedFiles: []string{}, // TODO: Extract from tool call
+						AffectedFiles: m.affectedFiles(toolCall),
 						QueuePosition: len(m.permissionQueue) + 1,
 						TotalQueued:   len(m.permissionQueue) + 1,
 						PromptText:    promptText,
 						ResponseChan:  responseChan,
 					}
+					// Calls from the same response are answered one at a time, so show
+					// where this one is in the response's batch
+					if position, total := permissionManager.BatchPosition(toolCall); total > request.TotalQueued {
+						request.QueuePosition, request.TotalQueued = position, total
+						request.BatchCalls = permissionManager.BatchRemaining(toolCall)
+					}
 
 					// Add to queue and set as current pending permission
 					m.permissionQueue = append(m.permissionQueue, request)
@@ -178,34 +290,42 @@ func (m *Model) SetupPermissionCallback() {
 					// Wait for user decision (this will block until user responds)
 					approved := <-responseChan
 
-					// Cache the decision
-					m.permissionHistory[key] = approved
+					// Cache the decision; a denial with a reason asks again next time, since
+					// the model is expected to change the call
+					if approved || request.DenyReason == "" {
+						m.permissionHistory[key] = approved
+					}
 
 					// Remove from queue
 					m.removePermissionFromQueue(toolID)
 
-					return approved
+					return session.PermissionDecision{Approved: approved, Reason: request.DenyReason, Timestamp: time.Now()}
 				default:
 					loggy.Warn("Unknown risk level, denying tool execution", "tool", toolCall.Name, "risk", risk)
 					m.permissionHistory[key] = false
-					return false
+					return session.PermissionDecision{}
 				}
 			})
 		}
 	}
 }
 
-// generatePermissionKey creates a key for remembering permission decisions
+// generatePermissionKey creates a key for remembering permission decisions, the same key
+// the permission manager remembers them under
 func (m *Model) generatePermissionKey(toolCall *llm.ToolCall) string {
-	// Create a simple key based on tool name and main parameters
-	key := toolCall.Name
-	if filePath, ok := toolCall.Input["file_path"].(string); ok {
-		key += ":" + filePath
+	return m.permissionManager().PermissionKey(toolCall)
+}
+
+// affectedFiles lists the files and directories a tool call will touch, for the
+// permission prompt
+func (m *Model) affectedFiles(toolCall *llm.ToolCall) []string {
+	if m.session == nil {
+		return nil
 	}
-	if command, ok := toolCall.Input["command"].(string); ok {
-		key += ":" + command
+	if executor := m.session.GetToolExecutor(); executor != nil {
+		return executor.AffectedPaths(toolCall.Name, toolCall.Input)
 	}
-	return key
+	return nil
 }
 
 // removePermissionFromQueue removes a permission request from the queue and updates current pending
@@ -230,7 +350,7 @@ func (m *Model) removePermissionFromQueue(toolID string) {
 }
 
 // NewModel creates a new UI model for the chat interface
-func NewModel(sess *session.Session, sessionManager *session.Manager) *Model {
+func NewModel(sess *session.Session, sessionManager *session.Manager, version string) *Model {
 	// Initialize textarea for input
 	ta := textarea.New()
 	ta.Placeholder = "Ask bazinga anything about your code..."
@@ -242,33 +362,37 @@ func NewModel(sess *session.Session, sessionManager *session.Manager) *Model {
 	// Initialize viewport for chat
 	vp := viewport.New(80, 20)
 
-	// Initialize glamour renderer for markdown with custom Gruvbox styling
-	// To use built-in Dracula theme instead: glamour.WithStylePath("dracula")
-	// Other built-in themes: "dark", "light", "notty", "ascii"
-	glamourRenderer, err := glamour.NewTermRenderer(
-		glamour.WithStylePath("dracula"),
-		glamour.WithWordWrap(80),
-	)
-	if err != nil {
-		glamourRenderer = nil
-		loggy.Warn("Failed to initialize glamour renderer", "error", err)
-	}
-
 	model := &Model{
 		session:         sess,
+		version:         version,
 		viewport:        vp,
 		textarea:        ta,
 		messages:        make([]ChatMessage, 0),
 		isThinking:      false,
+		followTail:      true,
+		estimateRequest: sess.EstimatePromptCost,
+		hasProvider:     sess.HasProvider,
+		todos:           sessionTodos(sess),
 		status:          make([]StatusItem, 0),
-		glamourRenderer: glamourRenderer,
 		chatViewport:    vp, // Same as viewport for compatibility
 		sessionManager:  sessionManager,
 		autocomplete:    NewAutocompleteState(),
 		// Tool display handled via chat messages
 		permissionHistory: make(map[string]bool),
 		permissionQueue:   make([]*PermissionRequest, 0),
+		gitStateFn: func() (string, bool, error) {
+			return sess.GitState(context.Background())
+		},
+	}
+
+	model.applyUIConfig()
+	model.autocomplete.SetArgumentSource(model.argumentSuggestions)
+
+	historyPath, err := inputHistoryPath(sess.RootPath)
+	if err != nil {
+		loggy.Warn("Could not locate input history, it won't be saved", "error", err)
 	}
+	model.history = loadInputHistory(historyPath)
 
 	welcomeMessage := model.createWelcomeMessage()
 	model.addMessage(ChatMessage{
@@ -277,16 +401,45 @@ func NewModel(sess *session.Session, sessionManager *session.Manager) *Model {
 		Timestamp: time.Now(),
 	})
 
+	// A session resumed from the command line continues where it left off
+	model.addHistoryMessages(sess.History)
+
 	// Setup file change callback for diff tracking
 	model.SetupFileChangeCallback()
 
 	// Setup permission callback for tool execution approval
 	model.SetupPermissionCallback()
+	model.SetupRateLimitCallback()
+
+	// Show the size of the initial context (system prompt, memory, files)
+	model.refreshTokenEstimate()
 
 	loggy.Debug("UI model initialized", "component", "NewModel", "provider", sess.GetProvider(), "model", sess.GetModel())
 	return model
 }
 
+// applyUIConfig reads the chat settings from the session's config, at startup and
+// again after /reload-config
+func (m *Model) applyUIConfig() {
+	cfg := m.session.GetConfig()
+	m.toolOutput = newToolOutputSettings(cfg)
+	m.costGuard = newCostGuard(cfg)
+	if cfg != nil {
+		m.diffMaxLines = cfg.UI.DiffMaxLines
+		m.diffPager = cfg.UI.DiffPager
+	}
+
+	// The markdown renderer is built from the configured theme; one that fails to load
+	// leaves messages as raw text
+	theme := defaultMarkdownTheme
+	if cfg != nil && cfg.UI.Theme != "" {
+		theme = cfg.UI.Theme
+	}
+	if m.glamourRenderer == nil || theme != m.markdownTheme {
+		_ = m.applyMarkdownTheme(theme)
+	}
+}
+
 // TickMsg is sent periodically to update the UI
 type TickMsg time.Time
 
@@ -295,6 +448,7 @@ func (m *Model) Init() tea.Cmd {
 	return tea.Batch(
 		textarea.Blink,
 		m.tickCmd(),
+		m.refreshGitStatus(),
 	)
 }
 
@@ -347,6 +501,10 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		// Handle permission prompts first (highest priority)
 		if m.pendingPermission != nil {
+			if m.pendingPermission.enteringReason {
+				m.handleDenyReasonKey(msg)
+				return m, nil
+			}
 			switch key {
 			case "y", "Y":
 				// Send approval and cache decision
@@ -358,24 +516,35 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.removePermissionFromQueue(toolID)
 				return m, nil
 
-			case "n", "N", "esc":
-				// Send denial and cache decision
-				m.pendingPermission.ResponseChan <- false
-				m.permissionHistory[m.generatePermissionKey(m.pendingPermission.ToolCall)] = false
+			case "n", "esc":
+				m.denyPermission("")
+				return m, nil
 
-				// Remove from queue and move to next
-				toolID := m.pendingPermission.ToolID
-				m.removePermissionFromQueue(toolID)
+			case "A":
+				// Approve every queued call and the rest of the batch
+				m.decidePermissionQueue(true)
 				return m, nil
 
-			case "a", "A":
-				// Approve and remember for session
+			case "N":
+				// Deny every queued call and the rest of the batch
+				m.decidePermissionQueue(false)
+				return m, nil
+
+			case "d", "D":
+				// Deny with a reason for the model; the prompt takes a line of input
+				m.pendingPermission.enteringReason = true
+				return m, nil
+
+			case "a":
+				// Approve and remember for similar calls; the decision is saved with the
+				// session until it expires
+				if m.session != nil && m.session.GetPermissionManager() != nil {
+					m.session.GetPermissionManager().RememberDecision(m.pendingPermission.ToolCall, true)
+				}
 				m.pendingPermission.ResponseChan <- true
 				key := m.generatePermissionKey(m.pendingPermission.ToolCall)
 				m.permissionHistory[key] = true
 
-				// TODO: Add to session rules for pattern matching
-
 				// Remove from queue and move to next
 				toolID := m.pendingPermission.ToolID
 				m.removePermissionFromQueue(toolID)
@@ -387,6 +556,24 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 
+		// Then a pending confirmation for an expensive request
+		if m.pendingSend != nil {
+			return m, m.handleCostConfirmationKey(key)
+		}
+
+		// The file picker takes all keys while open
+		if m.filePicker != nil {
+			return m, m.handleFilePickerKey(msg)
+		}
+
+		// Right after a failed request, retryKey sends it again; any other key types as usual
+		if m.retryArmed {
+			m.retryArmed = false
+			if key == retryKey && strings.TrimSpace(m.textarea.Value()) == "" {
+				return m, m.retryFailedRequest()
+			}
+		}
+
 		switch key {
 		case "ctrl+c":
 			return m, tea.Quit
@@ -396,6 +583,30 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			// Toggle shortcuts overlay
 			m.showShortcuts = !m.showShortcuts
 			return m, nil
+		case "ctrl+p":
+			// Pause or resume auto-scroll so long answers can be read
+			m.toggleFollowTail()
+			return m, nil
+		case expandKey:
+			// Show the full result of the focused (or latest) tool, or collapse it again
+			m.toggleToolResult()
+			return m, nil
+		case focusPrevKey, focusPrevAltKey:
+			m.moveToolFocus(-1)
+			return m, nil
+		case focusNextKey, focusNextAltKey:
+			m.moveToolFocus(1)
+			return m, nil
+		case thinkingKey:
+			// Show or hide the model's reasoning above its responses
+			m.toggleThinking()
+			return m, nil
+		case "ctrl+y":
+			// Copy the last code block of the latest response
+			return m, m.handleSessionCommand("/copy")
+		case diffOpenKey:
+			// Open the most recent diff in full in a pager or editor
+			return m, m.openLatestDiff()
 		case "shift+enter", "alt+enter", "ctrl+j", "ctrl+m":
 			// Shift+Enter, Alt+Enter, Ctrl+J, or Ctrl+M: Insert new line
 			loggy.Info("KeyMsg: New line key pressed - inserting new line", "key", key)
@@ -412,12 +623,13 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				currentInput := strings.TrimSpace(m.textarea.Value())
 
 				if selected != nil {
-					if selected.Command != currentInput {
+					completion := m.autocomplete.GetCompletionText()
+					if completion != currentInput {
 						// Replace current input with selected command
-						m.textarea.SetValue(selected.Command + " ")
+						m.textarea.SetValue(completion + " ")
 						m.textarea.CursorEnd()
 						m.autocomplete.Deactivate()
-						loggy.Info("KeyMsg: Enter - autocomplete selection made", "selected_command", selected.Command, "was_different_from_input", true)
+						loggy.Info("KeyMsg: Enter - autocomplete selection made", "selected_command", completion, "was_different_from_input", true)
 						return m, nil
 					} else {
 						// When exact match, deactivate autocomplete and proceed with execution
@@ -444,18 +656,24 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if m.autocomplete.IsActive() {
 				selected := m.autocomplete.GetSelected()
 				if selected != nil {
-					m.textarea.SetValue(selected.Command + " ")
+					m.textarea.SetValue(m.autocomplete.GetCompletionText() + " ")
 					m.textarea.CursorEnd()
 					m.autocomplete.Deactivate()
 					return m, nil
 				}
 			}
 		case "up":
+			if m.recallHistory(-1) {
+				return m, nil
+			}
 			if m.autocomplete.IsActive() {
 				m.autocomplete.Navigate(-1)
 				return m, nil
 			}
 		case "down":
+			if m.recallHistory(1) {
+				return m, nil
+			}
 			if m.autocomplete.IsActive() {
 				m.autocomplete.Navigate(1)
 				return m, nil
@@ -469,8 +687,13 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.autocomplete.Deactivate()
 				return m, nil
 			}
+			if m.focusedToolIndex() >= 0 {
+				m.clearToolFocus()
+				return m, nil
+			}
 			// Allow ESC to interrupt AI response
 			if m.isThinking {
+				m.stopAgent("⏸ Agent mode stopped by user. /agent continues with the remaining todos")
 				m.isThinking = false
 				m.currentStream = nil
 				// Remove streaming message if present
@@ -490,6 +713,9 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case StreamChunkMsg:
 		loggy.Debug("UI model update", "event", "StreamChunkMsg_received", "content", msg.Chunk.Content)
 		m.handleStreamChunk(msg)
+		if cmd := m.maybeRefreshGitStatus(time.Now()); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
 		// Continue listening for more chunks
 		if m.currentStream != nil {
 			cmds = append(cmds, listenForStreamChunks(m.currentStream))
@@ -498,11 +724,17 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case StreamStartMsg:
 		loggy.Debug("UI model update", "event", "StreamStartMsg_received", "action", "starting_listener")
 		m.currentStream = msg.StreamChan
+		// The request went through, so there is nothing left to retry
+		m.inFlight = nil
+		m.failedRequest = nil
 		cmds = append(cmds, listenForStreamChunks(msg.StreamChan))
 
 	case StreamCompleteMsg:
 		loggy.Debug("StreamCompleteMsg received", "tool_calls_count", len(msg.ToolCalls))
 		m.handleStreamComplete()
+		if m.agent != nil {
+			cmds = append(cmds, m.advanceAgent())
+		}
 		// Process tool completions from the completed stream
 		for _, toolCall := range msg.ToolCalls {
 			loggy.Debug("Processing tool completion from StreamCompleteMsg", "tool_name", toolCall.Name, "llm_tool_id", toolCall.ID)
@@ -514,10 +746,51 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case ErrorMsg:
 		m.handleError(msg)
+		m.stopAgent("✗ Agent mode stopped: the request failed")
 
 	case ResponseMsg:
 		loggy.Debug("Model: received ResponseMsg", "content_length", len(msg.Content))
 		m.handleResponse(msg)
+		// Commands may have changed files or memory
+		m.refreshTokenEstimate()
+
+	case commands.ConfigReloadedMsg:
+		m.applyUIConfig()
+		m.handleResponse(ResponseMsg{Content: msg.Response})
+		m.refreshTokenEstimate()
+
+	case commands.ThemeChangeMsg:
+		m.handleResponse(ResponseMsg{Content: m.switchMarkdownTheme(msg.Theme)})
+
+	case commands.ResumeSessionMsg:
+		cmds = append(cmds, m.loadSession(msg.ID))
+
+	case sessionLoadedMsg:
+		if msg.err != nil {
+			m.addMessage(ChatMessage{
+				Role:      "system",
+				Content:   fmt.Sprintf("✗ Failed to resume session: %v", msg.err),
+				Timestamp: time.Now(),
+			})
+		} else {
+			m.resumeSession(msg.session)
+		}
+
+	case commands.RetryRequestMsg:
+		cmds = append(cmds, m.retryFailedRequest())
+
+	case commands.OpenFilePickerMsg:
+		m.openFilePicker()
+
+	case commands.StartAgentMsg:
+		cmds = append(cmds, m.startAgent(msg.Goal, msg.MaxTurns))
+
+	case commands.StopAgentMsg:
+		if m.agent == nil {
+			m.handleResponse(ResponseMsg{Content: "ℹ Agent mode is not running"})
+		} else {
+			m.stopAgent("⏸ Agent mode stopped; the current response finishes first. /agent continues with the remaining todos")
+		}
 
 	case commands.LLMRequestMsg:
 		// Handle LLM request from commands
@@ -530,31 +803,32 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			Timestamp: time.Now(),
 		})
 
-		// Set thinking state and add placeholder message
-		m.isThinking = true
-		m.thinkingStartTime = time.Now()
-		m.inputTokens = len(msg.Message) / 4
-		if m.inputTokens < 1 {
-			m.inputTokens = 1
-		}
-		m.addMessage(ChatMessage{
-			Role:      "assistant",
-			Content:   "",
-			Timestamp: time.Now(),
-			Streaming: true,
-		})
-
-		// Trigger LLM processing
-		cmds = append(cmds, m.sendToAI(msg.Message))
+		// Trigger LLM processing, asking first if the request is expensive
+		cmds = append(cmds, m.dispatchRequest(msg.Message, msg.ThinkOnly))
 
 	case TickMsg:
 		// Continue ticking and force a re-render if thinking (for dynamic timer)
 		cmds = append(cmds, m.tickCmd())
+		if cmd := m.maybeRefreshGitStatus(time.Time(msg)); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
 		if m.isThinking {
 			// Force a re-render to update the thinking timer
 			return m, tea.Batch(cmds...)
 		}
 
+	case gitStatusMsg:
+		m.handleGitStatus(msg)
+		return m, nil
+
+	case diffPagerClosedMsg:
+		m.handleDiffPagerClosed(msg)
+		return m, nil
+
+	case rateLimitMsg:
+		m.rateLimitedUntil = msg.until
+		return m, nil
+
 	case PermissionRequestMsg:
 		// Handle permission request from tool execution
 		m.pendingPermission = &PermissionRequest{
@@ -594,9 +868,11 @@ func (m *Model) View() string {
 	}
 
 	header := HeaderStyle.Width(m.width).Render("bazinga")
+	if banner := m.terminatorBanner(); banner != "" {
+		header = HeaderStyle.Width(m.width).Render("bazinga  " + banner)
+	}
 
-	chatContent := m.renderChatContent()
-	m.viewport.SetContent(chatContent)
+	m.refreshViewport()
 
 	statusBar := m.renderStatusBar()
 	inputArea := m.renderInputWithBorder()
@@ -629,6 +905,10 @@ func (m *Model) View() string {
 	if m.pendingPermission != nil {
 		permissionPrompt := m.renderPermissionPrompt()
 		parts = append(parts, permissionPrompt)
+	} else if m.pendingSend != nil {
+		parts = append(parts, m.renderCostConfirmation())
+	} else if m.filePicker != nil {
+		parts = append(parts, m.filePicker.render(m.width))
 	} else {
 		// Add overlays before input (only when no permission prompt)
 		if shortcutsOverlay != "" {
@@ -643,6 +923,27 @@ func (m *Model) View() string {
 	return lipgloss.JoinVertical(lipgloss.Left, parts...)
 }
 
+// terminatorBanner returns the warning shown in the header for as long as terminator
+// mode approves tool calls without asking, or "" when it is off
+func (m *Model) terminatorBanner() string {
+	if m.session == nil {
+		return ""
+	}
+
+	var text string
+	switch m.session.TerminatorScope() {
+	case "":
+		return ""
+	case config.TerminatorEdits:
+		text = "⚠️ TERMINATOR MODE (edits): file changes run without asking"
+	case config.TerminatorSafe:
+		text = "⚠️ TERMINATOR MODE (safe): everything but destructive operations runs without asking"
+	default:
+		text = "⚠️ TERMINATOR MODE: every tool call runs without asking"
+	}
+	return lipgloss.NewStyle().Foreground(ErrorColor).Bold(true).Render(text)
+}
+
 // renderStatusBar renders the status bar showing thinking state
 func (m *Model) renderStatusBar() string {
 	var leftStatus, rightStatus string
@@ -658,22 +959,62 @@ func (m *Model) renderStatusBar() string {
 		if m.inputTokens > 0 {
 			statusParts = append(statusParts, fmt.Sprintf("↑ %d tokens", m.inputTokens))
 		}
+		if m.outputTokens > 0 {
+			statusParts = append(statusParts, fmt.Sprintf("↓ %d tokens", m.outputTokens))
+		}
+		if cost := m.sessionCost(); cost != "" {
+			statusParts = append(statusParts, cost)
+		}
 
 		statusParts = append(statusParts, "esc to interrupt")
 
+		label := "✨ Thinking..."
+		if m.agent != nil {
+			label = fmt.Sprintf("🤖 Agent turn %d/%d...", m.agent.turns, m.agent.maxTurns)
+		}
+		if wait := time.Until(m.rateLimitedUntil); wait > 0 {
+			label = fmt.Sprintf("⏳ Waiting on rate limit (%ds)...", int(wait.Seconds())+1)
+		}
 		leftStatus = lipgloss.NewStyle().Foreground(WarningColor).Render(
-			fmt.Sprintf("✨ Thinking... (%s)", strings.Join(statusParts, " • ")))
+			fmt.Sprintf("%s (%s)", label, strings.Join(statusParts, " • ")))
 	} else {
-		leftStatus = lipgloss.NewStyle().Foreground(SuccessColor).Render("● Ready")
+		var readyParts []string
+		if m.inputTokens > 0 {
+			readyParts = append(readyParts, fmt.Sprintf("↑ %d tokens in context", m.inputTokens))
+		}
+		if usage := m.lastTurnUsage(); usage != "" {
+			readyParts = append(readyParts, usage)
+		}
+		if cost := m.sessionCost(); cost != "" {
+			readyParts = append(readyParts, cost+" this session")
+		}
+		readyText := "● Ready"
+		if len(readyParts) > 0 {
+			readyText = fmt.Sprintf("● Ready (%s)", strings.Join(readyParts, " • "))
+		}
+		leftStatus = lipgloss.NewStyle().Foreground(SuccessColor).Render(readyText)
 	}
 
-	if m.isThinking {
+	if !m.followTail {
+		rightStatus = lipgloss.NewStyle().Foreground(WarningColor).Render(
+			fmt.Sprintf("⏸ paused — %d new lines below (ctrl+p to resume)", m.linesBelowViewport()))
+	} else if below := m.linesBelowViewport(); below > 0 {
+		rightStatus = lipgloss.NewStyle().Foreground(AccentColor).Render(
+			fmt.Sprintf("↓ %d unread lines below", below))
+	} else if m.isThinking {
 		rightStatus = lipgloss.NewStyle().Foreground(TextSecondary).Render("AI responding...")
 	} else {
 		// Remove model display - keep right side empty when not thinking
 		rightStatus = ""
 	}
 
+	if branch := m.gitStatus.render(); branch != "" {
+		if rightStatus != "" {
+			rightStatus += "  "
+		}
+		rightStatus += branch
+	}
+
 	// Create left/right layout
 	leftWidth := lipgloss.Width(leftStatus)
 	rightWidth := lipgloss.Width(rightStatus)
@@ -770,7 +1111,7 @@ func (m *Model) renderHelpMessages() string {
 	if m.autocomplete.IsActive() {
 		helpText = "↑↓ navigate • Enter/Tab to select • Esc to cancel"
 	} else if m.isThinking {
-		helpText = "AI is responding... • Esc to interrupt"
+		helpText = "AI is responding... • Ctrl+P to pause scrolling • Esc to interrupt"
 	} else if len(m.messages) <= 1 { // Only welcome message
 		helpText = "? for shortcuts"
 	} else {
@@ -841,11 +1182,20 @@ func (m *Model) renderPermissionPrompt() string {
 		content.WriteString(queueInfo)
 	}
 
-	// Response instructions with enhanced options
+	// Response instructions with enhanced options, or the reason being typed
 	content.WriteString("\n\n")
+	if m.pendingPermission.enteringReason {
+		content.WriteString(m.renderDenyReasonInput())
+		return promptStyle.Render(content.String())
+	}
+	options := "🔑 (y) Approve  •  🚫 (n) Deny  •  💬 (d) Deny & Explain  •  🔒 (a) Approve & Remember  •  ⏎ (esc) Cancel"
+	if m.pendingPermission.TotalQueued > 1 {
+		content.WriteString(m.renderQueuedCalls())
+		options += "\n✅ (A) Approve All Queued  •  ⛔ (N) Deny All Queued"
+	}
 	instructions := lipgloss.NewStyle().
 		Foreground(lipgloss.Color("#87CEEB")). // Sky blue
-		Render("🔑 (y) Approve  •  🚫 (n) Deny  •  🔒 (a) Approve & Remember  •  ⏎ (esc) Cancel")
+		Render(options)
 	content.WriteString(instructions)
 
 	return promptStyle.Render(content.String())
@@ -853,73 +1203,97 @@ func (m *Model) renderPermissionPrompt() string {
 
 // handleStreamChunk processes streaming chunks
 func (m *Model) handleStreamChunk(msg StreamChunkMsg) {
-	if msg.Chunk.Content != "" {
+	m.trackTurnUsage(msg.Chunk)
+	m.streamHandler().Handle(msg.Chunk)
+
+	// Auto-scroll happens in refreshViewport, which knows whether the user was at the tail
+}
+
+// streamHandler returns the handler that renders stream chunks into the chat
+func (m *Model) streamHandler() session.StreamHandler {
+	appendText := func(text string) {
 		// Update the last streaming message, or create a new one if none exists
 		if len(m.messages) > 0 && m.messages[len(m.messages)-1].Streaming {
-			m.messages[len(m.messages)-1].Content += msg.Chunk.Content
+			m.messages[len(m.messages)-1].Content += text
 		} else {
 			// No streaming message exists, create a new one (for follow-up responses)
 			m.addMessage(ChatMessage{
 				Role:      "assistant",
-				Content:   msg.Chunk.Content,
+				Content:   text,
 				Timestamp: time.Now(),
 				Streaming: true,
 			})
 		}
 	}
 
-	// Track and display tool calls
-	if msg.Chunk.ToolCall != nil {
-		m.toolCount++
+	showTool := func(completion *llm.ToolCompletion) {
+		loggy.Debug("Tool notification received", "tool_name", completion.ToolName, "state", completion.State, "task_group", completion.TaskGroup)
 
-		loggy.Debug("Tool call detected", "tool_id", msg.Chunk.ToolCall.ID, "tool_name", msg.Chunk.ToolCall.Name, "input_args", msg.Chunk.ToolCall.Input)
+		m.clearRunningOutput()
+		content := completion.Result
+		if completion.State == "error" {
+			content = completion.Error
+		}
+		m.addToolMessageWithTask(completion.ToolName, completion.Args, completion.State, content, completion.TaskGroup)
 
-		// Don't show tool start message here - it will be shown when execution actually starts
-		// This is because streaming providers may not have complete arguments yet
+		if completion.State == "complete" && affectsGitStatus(completion.ToolName) {
+			m.gitStatus.stale = true
+		}
 	}
 
-	// Handle tool start and completion notifications
-	if msg.Chunk.ToolCompletion != nil {
-		loggy.Debug("Tool notification received", "tool_name", msg.Chunk.ToolCompletion.ToolName, "state", msg.Chunk.ToolCompletion.State, "chunk_type", msg.Chunk.Type, "task_group", msg.Chunk.ToolCompletion.TaskGroup)
-
-		switch msg.Chunk.ToolCompletion.State {
-		case "task_start":
-			// Handle task group start
-			m.addTaskGroupMessage(msg.Chunk.ToolCompletion.Args["task_name"].(string))
-		case "start":
-			m.addToolMessageWithTask(
-				msg.Chunk.ToolCompletion.ToolName,
-				msg.Chunk.ToolCompletion.Args,
-				"start",
-				"",
-				msg.Chunk.ToolCompletion.TaskGroup,
-			)
-		case "error":
-			m.addToolMessageWithTask(
-				msg.Chunk.ToolCompletion.ToolName,
-				msg.Chunk.ToolCompletion.Args,
-				"error",
-				msg.Chunk.ToolCompletion.Error,
-				msg.Chunk.ToolCompletion.TaskGroup,
-			)
-		case "complete":
-			m.addToolMessageWithTask(
-				msg.Chunk.ToolCompletion.ToolName,
-				msg.Chunk.ToolCompletion.Args,
-				"complete",
-				msg.Chunk.ToolCompletion.Result,
-				msg.Chunk.ToolCompletion.TaskGroup,
-			)
-		}
+	return session.StreamHandler{
+		OnText:     appendText,
+		OnThinking: m.appendThinking,
+		// Stream errors are shown inline, where the response stopped
+		OnError: appendText,
+		OnToolCall: func(toolCall *llm.ToolCall) {
+			m.toolCount++
+
+			// Don't show tool start message here - it will be shown when execution actually starts
+			// This is because streaming providers may not have complete arguments yet
+			loggy.Debug("Tool call detected", "tool_id", toolCall.ID, "tool_name", toolCall.Name, "input_args", toolCall.Input)
+		},
+		OnNotice: func(message string) {
+			m.addMessage(ChatMessage{
+				Role:      "system",
+				Content:   "ℹ " + message,
+				Timestamp: time.Now(),
+			})
+		},
+		OnTaskStart: m.addTaskGroupMessage,
+		OnToolStart: showTool,
+		OnToolOutput: func(completion *llm.ToolCompletion) {
+			m.showRunningOutput(completion.ToolName, completion.Result, completion.TaskGroup)
+		},
+		OnToolEnd: showTool,
 	}
+}
 
-	// Always force refresh to show updated content
-	m.viewport.GotoBottom()
+// refreshViewport renders the chat into the viewport. The tail stays in view only if
+// the user was already at the bottom; if they scrolled up to read, the offset is kept.
+func (m *Model) refreshViewport() {
+	atTail := m.viewport.AtBottom()
+	m.viewport.SetContent(m.renderChatContent())
+	if m.followTail && atTail {
+		m.viewport.GotoBottom()
+	}
+}
 
-	// Store stream channel on first chunk (set by StreamStartMsg)
+// toggleFollowTail pauses auto-scroll, or resumes it and jumps back to the bottom
+func (m *Model) toggleFollowTail() {
+	m.followTail = !m.followTail
+	if m.followTail {
+		m.viewport.GotoBottom()
+	}
+}
 
-	// Auto-scroll to bottom
-	m.viewport.GotoBottom()
+// linesBelowViewport returns how many rendered lines are below the visible area
+func (m *Model) linesBelowViewport() int {
+	below := m.viewport.TotalLineCount() - m.viewport.YOffset - m.viewport.VisibleLineCount()
+	if below < 0 {
+		return 0
+	}
+	return below
 }
 
 // handleStreamComplete finalizes streaming response
@@ -927,16 +1301,21 @@ func (m *Model) handleStreamComplete() {
 	// Mark streaming as complete
 	if len(m.messages) > 0 && m.messages[len(m.messages)-1].Streaming {
 		m.messages[len(m.messages)-1].Streaming = false
-		// Estimate output tokens from response content
-		responseContent := m.messages[len(m.messages)-1].Content
-		m.outputTokens = len(responseContent) / 4
-		if m.outputTokens < 1 && responseContent != "" {
-			m.outputTokens = 1
-		}
 	}
 
 	m.isThinking = false
 	m.currentStream = nil
+
+	// History changed, so the next prompt is larger
+	m.refreshTokenEstimate()
+}
+
+// refreshTokenEstimate updates the displayed prompt size from the session's current context
+func (m *Model) refreshTokenEstimate() {
+	if m.session == nil {
+		return
+	}
+	m.inputTokens = m.session.EstimatePromptTokens("")
 }
 
 // renderChatContent renders the chat messages with enhanced formatting
@@ -946,6 +1325,8 @@ func (m *Model) renderChatContent() string {
 	}
 
 	var content []string
+	m.messageLines = m.messageLines[:0]
+	offset := 0
 	for i, msg := range m.messages {
 		var renderedMsg string
 
@@ -998,12 +1379,24 @@ func (m *Model) renderChatContent() string {
 				Render(strings.Join(formattedLines, "\n"))
 
 			renderedMsg = styledContent
+			if msg.Thinking != "" {
+				thinking := renderThinking(msg.Thinking, m.showThinking, m.viewport.Width)
+				if strings.TrimSpace(msg.Content) == "" {
+					renderedMsg = thinking
+				} else {
+					renderedMsg = thinking + "\n" + styledContent
+				}
+			}
 
 			// Streaming cursor removed - no longer needed
 
 		case "system":
 			// System messages - keep minimal styling
 			content := msg.Content
+			if msg.IsToolMsg {
+				// Tool results can be wider than the chat, e.g. diffs and tables
+				content = renderToolMessage(msg, m.viewport.Width)
+			}
 
 			// Clean icons without heavy styling
 			if strings.HasPrefix(content, "✅") {
@@ -1014,8 +1407,12 @@ func (m *Model) renderChatContent() string {
 				content = strings.Replace(content, "🔧", "⚡", 1)
 			}
 
+			color := TextMuted
+			if msg.Focused {
+				color = AccentColor
+			}
 			renderedMsg = lipgloss.NewStyle().
-				Foreground(TextMuted).
+				Foreground(color).
 				Italic(true).
 				Render(content)
 
@@ -1038,6 +1435,8 @@ func (m *Model) renderChatContent() string {
 		}
 
 		content = append(content, renderedMsg)
+		m.messageLines = append(m.messageLines, offset)
+		offset += strings.Count(renderedMsg, "\n") + 2
 
 		if i < len(m.messages)-1 {
 			content = append(content, "") // Empty line for spacing
@@ -1050,7 +1449,6 @@ func (m *Model) renderChatContent() string {
 // addMessage adds a message to the chat
 func (m *Model) addMessage(msg ChatMessage) {
 	m.messages = append(m.messages, msg)
-	m.viewport.GotoBottom()
 	loggy.Debug("UI message added",
 		"component", "addMessage",
 		"role", msg.Role,
@@ -1075,7 +1473,7 @@ func (m *Model) addTaskGroupMessage(taskName string) {
 
 // addToolMessageWithTask adds a tool execution message with optional task group
 func (m *Model) addToolMessageWithTask(toolName string, args map[string]interface{}, state string, result string, taskGroup string) {
-	var content string
+	var content, fullResult string
 
 	switch state {
 	case "start":
@@ -1086,11 +1484,26 @@ func (m *Model) addToolMessageWithTask(toolName string, args map[string]interfac
 			content = m.formatToolStart(toolName, args)
 		}
 	case "complete":
+		mode := m.toolOutput.modeFor(toolName)
+		if mode == ToolOutputHidden {
+			return
+		}
+
 		// Show result summary like "Read 665 lines (ctrl+r to expand)" with indentation if part of task group
+		indent := ""
 		if taskGroup != "" {
-			content = "     " + m.formatToolComplete(toolName, args, result)
-		} else {
-			content = m.formatToolComplete(toolName, args, result)
+			indent = "     "
+		}
+		content = indent + m.formatToolComplete(toolName, args, result)
+
+		// Todo tools already render their result as a formatted list
+		if toolName != "todo_read" && toolName != "todo_write" {
+			fullResult = result
+			if mode == ToolOutputInline {
+				if inline := formatInlineResult(result, indent, m.toolOutput.maxLines); inline != "" {
+					content += "\n" + inline
+				}
+			}
 		}
 	case "error":
 		// Show error message with indentation if part of task group
@@ -1103,14 +1516,15 @@ func (m *Model) addToolMessageWithTask(toolName string, args map[string]interfac
 
 	if content != "" {
 		m.addMessage(ChatMessage{
-			Role:      "system",
-			Content:   content,
-			Timestamp: time.Now(),
-			IsToolMsg: true,
-			ToolName:  toolName,
-			ToolArgs:  args,
-			ToolState: state,
-			TaskGroup: taskGroup,
+			Role:       "system",
+			Content:    content,
+			Timestamp:  time.Now(),
+			IsToolMsg:  true,
+			ToolName:   toolName,
+			ToolArgs:   args,
+			ToolState:  state,
+			TaskGroup:  taskGroup,
+			ToolResult: strings.TrimRight(fullResult, "\n"),
 		})
 	}
 }
@@ -1131,6 +1545,22 @@ func (m *Model) formatToolStart(toolName string, args map[string]interface{}) st
 		}
 		loggy.Debug("formatToolStart read_file", "file_path_missing", true, "args_keys", getMapKeys(args))
 		return fmt.Sprintf("%s Read(file)", dot)
+	case "read_files":
+		if files, ok := args["files"].([]interface{}); ok {
+			var names []string
+			for _, file := range files {
+				if entry, ok := file.(map[string]interface{}); ok {
+					if filePath, ok := entry["file_path"].(string); ok {
+						names = append(names, m.getDisplayPath(filePath))
+					}
+				}
+			}
+			if len(names) > 3 {
+				names = append(names[:3], fmt.Sprintf("+%d more", len(names)-3))
+			}
+			return fmt.Sprintf("%s Read(%s)", dot, strings.Join(names, ", "))
+		}
+		return fmt.Sprintf("%s Read(files)", dot)
 	case "write_file":
 		if filePath, ok := args["file_path"].(string); ok {
 			filename := m.getDisplayPath(filePath)
@@ -1155,6 +1585,15 @@ func (m *Model) formatToolStart(toolName string, args map[string]interface{}) st
 			return fmt.Sprintf("%s Edit(%s)", dot, filename)
 		}
 		return fmt.Sprintf("%s Edit(file)", dot)
+	case "apply_patch":
+		if patch, ok := args["patch"].(string); ok {
+			if files := tools.PatchFiles(patch); len(files) == 1 {
+				return fmt.Sprintf("%s Patch(%s)", dot, m.getDisplayPath(files[0]))
+			} else if len(files) > 1 {
+				return fmt.Sprintf("%s Patch(%d files)", dot, len(files))
+			}
+		}
+		return fmt.Sprintf("%s Patch(files)", dot)
 	case "bash":
 		if command, ok := args["command"].(string); ok {
 			// Show first word of command
@@ -1164,6 +1603,11 @@ func (m *Model) formatToolStart(toolName string, args map[string]interface{}) st
 			}
 		}
 		return fmt.Sprintf("%s Run(command)", dot)
+	case "run_tests":
+		if target, ok := args["target"].(string); ok && target != "" {
+			return fmt.Sprintf("%s Test(%s)", dot, target)
+		}
+		return fmt.Sprintf("%s Test", dot)
 	case "grep":
 		if pattern, ok := args["pattern"].(string); ok {
 			return fmt.Sprintf("%s Search('%s')", dot, pattern)
@@ -1185,6 +1629,22 @@ func (m *Model) formatToolStart(toolName string, args map[string]interface{}) st
 			return fmt.Sprintf("%s Search('%s')", dot, query)
 		}
 		return fmt.Sprintf("%s Search", dot)
+	case "symbols":
+		query, _ := args["query"].(string)
+		if symbol, ok := args["symbol"].(string); ok && symbol != "" {
+			return fmt.Sprintf("%s Symbols(%s '%s')", dot, query, symbol)
+		}
+		return fmt.Sprintf("%s Symbols(%s)", dot, query)
+	case "ast_outline":
+		if path, ok := args["file_path"].(string); ok {
+			return fmt.Sprintf("%s Outline(%s)", dot, m.getDisplayPath(path))
+		}
+		return fmt.Sprintf("%s Outline", dot)
+	case "dependencies":
+		if path, ok := args["dir_path"].(string); ok && path != "" {
+			return fmt.Sprintf("%s Dependencies(%s)", dot, m.getDisplayPath(path))
+		}
+		return fmt.Sprintf("%s Dependencies", dot)
 	case "git_status":
 		return fmt.Sprintf("%s Git(status)", dot)
 	case "git_diff":
@@ -1195,8 +1655,36 @@ func (m *Model) formatToolStart(toolName string, args map[string]interface{}) st
 		return fmt.Sprintf("%s Git(commit)", dot)
 	case "git_log":
 		return fmt.Sprintf("%s Git(log)", dot)
+	case "git_blame":
+		if path, ok := args["file_path"].(string); ok {
+			if start, ok := args["start_line"].(float64); ok {
+				if end, ok := args["end_line"].(float64); ok {
+					return fmt.Sprintf("%s Git(blame %s:%d-%d)", dot, m.getDisplayPath(path), int(start), int(end))
+				}
+				return fmt.Sprintf("%s Git(blame %s:%d)", dot, m.getDisplayPath(path), int(start))
+			}
+			return fmt.Sprintf("%s Git(blame %s)", dot, m.getDisplayPath(path))
+		}
+		return fmt.Sprintf("%s Git(blame)", dot)
+	case "git_show":
+		ref, _ := args["ref"].(string)
+		if path, ok := args["file_path"].(string); ok && path != "" {
+			return fmt.Sprintf("%s Git(show %s:%s)", dot, ref, m.getDisplayPath(path))
+		}
+		return fmt.Sprintf("%s Git(show %s)", dot, ref)
 	case "git_branch":
 		return fmt.Sprintf("%s Git(branch)", dot)
+	case "git_stash":
+		return fmt.Sprintf("%s Git(stash)", dot)
+	case "git_stash_pop":
+		return fmt.Sprintf("%s Git(stash pop)", dot)
+	case "git_restore":
+		if paths, ok := args["paths"].([]interface{}); ok && len(paths) == 1 {
+			if path, ok := paths[0].(string); ok {
+				return fmt.Sprintf("%s Git(restore %s)", dot, m.getDisplayPath(path))
+			}
+		}
+		return fmt.Sprintf("%s Git(restore)", dot)
 	case "todo_read":
 		return fmt.Sprintf("%s Todo(read)", dot)
 	case "todo_write":
@@ -1241,6 +1729,12 @@ func (m *Model) formatToolStart(toolName string, args map[string]interface{}) st
 	}
 }
 
+// grepCountPattern reads the match count from the first line of a grep result
+var grepCountPattern = regexp.MustCompile(`^Found (\d+)(\+?) matches`)
+
+// webFetchSizePattern reads the mode and extracted size from the header of a web_fetch result
+var webFetchSizePattern = regexp.MustCompile(`^Content from \S+ \((\w+), ([^)]+)\):`)
+
 func (m *Model) formatToolComplete(toolName string, args map[string]interface{}, result string) string {
 	// Get the colored completion indicator (green for success)
 	completionDot := lipgloss.NewStyle().Foreground(SuccessColor).Render("⎿")
@@ -1248,12 +1742,22 @@ func (m *Model) formatToolComplete(toolName string, args map[string]interface{},
 
 	switch toolName {
 	case "read_file":
+		if placeholder, ok := imagePlaceholder(result); ok {
+			return fmt.Sprintf("%s%s Viewed image %s", indent, completionDot, placeholder)
+		}
+		if _, showing, ok := strings.Cut(result, "\nShowing: "); ok {
+			showing, _, _ = strings.Cut(showing, "\n")
+			return fmt.Sprintf("%s%s Read %s", indent, completionDot, showing)
+		}
 		// Count lines in result for display
 		lines := strings.Count(result, "\n") + 1
 		if result == "" {
 			lines = 0
 		}
 		return fmt.Sprintf("%s%s Read %d lines", indent, completionDot, lines)
+	case "read_files":
+		files := strings.Count(result, "==> ")
+		return fmt.Sprintf("%s%s Read %d files", indent, completionDot, files)
 	case "write_file":
 		lines := strings.Count(result, "\n") + 1
 		if result == "" {
@@ -1272,15 +1776,30 @@ func (m *Model) formatToolComplete(toolName string, args map[string]interface{},
 			return fmt.Sprintf("%s%s Updated %s", indent, completionDot, filename)
 		}
 		return fmt.Sprintf("%s%s Edit completed", indent, completionDot)
+	case "apply_patch":
+		// The first line of the result summarises how many hunks applied
+		summary, _, _ := strings.Cut(result, "\n")
+		return fmt.Sprintf("%s%s %s", indent, completionDot, summary)
 	case "bash":
 		if strings.TrimSpace(result) == "" {
 			return fmt.Sprintf("%s%s Run completed", indent, completionDot)
 		}
 		lines := strings.Count(result, "\n") + 1
 		return fmt.Sprintf("%s%s Run output (%d lines)", indent, completionDot, lines)
+	case "run_tests":
+		// The second line reads "Result: FAILED (2 passed, 1 failed, ...) in 1.2s"
+		if _, rest, ok := strings.Cut(result, "\nResult: "); ok {
+			summary, _, _ := strings.Cut(rest, "\n")
+			return fmt.Sprintf("%s%s Tests %s", indent, completionDot, summary)
+		}
+		return fmt.Sprintf("%s%s Tests completed", indent, completionDot)
 	case "grep":
+		if match := grepCountPattern.FindStringSubmatch(result); match != nil {
+			// A "+" marks a result truncated at max_results, where the count is a lower bound
+			return fmt.Sprintf("%s%s Found %s%s matches", indent, completionDot, match[1], match[2])
+		}
 		lines := strings.Count(result, "\n")
-		if lines == 0 && strings.TrimSpace(result) == "" {
+		if (lines == 0 && strings.TrimSpace(result) == "") || strings.HasPrefix(result, "No matches found") {
 			return fmt.Sprintf("%s%s No matches found", indent, completionDot)
 		}
 		return fmt.Sprintf("%s%s Found %d matches", indent, completionDot, lines+1)
@@ -1296,8 +1815,32 @@ func (m *Model) formatToolComplete(toolName string, args map[string]interface{},
 			return fmt.Sprintf("%s%s No files found", indent, completionDot)
 		}
 		return fmt.Sprintf("%s%s Found %d files", indent, completionDot, lines+1)
+	case "ast_outline":
+		if _, outline, ok := strings.Cut(result, "):\n"); ok {
+			return fmt.Sprintf("%s%s Outlined %d symbols", indent, completionDot, strings.Count(outline, "\n")+1)
+		}
+		return fmt.Sprintf("%s%s No symbols found", indent, completionDot)
+	case "dependencies":
+		if strings.HasPrefix(result, "No recognized manifest") {
+			return fmt.Sprintf("%s%s No recognized manifest", indent, completionDot)
+		}
+		// Each dependency is listed on an indented line
+		count := strings.Count(result, "\n  ")
+		return fmt.Sprintf("%s%s Listed %d dependencies", indent, completionDot, count)
 	case "git_status", "git_diff", "git_add", "git_commit", "git_log", "git_branch":
 		return fmt.Sprintf("%s%s Git operation completed", indent, completionDot)
+	case "git_show":
+		// A commit starts with "commit <hash>"; a file with the file, ref and hash
+		if hash, ok := strings.CutPrefix(result, "commit "); ok {
+			return fmt.Sprintf("%s%s Showed commit %s", indent, completionDot, hash[:min(len(hash), 8)])
+		}
+		return fmt.Sprintf("%s%s %s", indent, completionDot, strings.SplitN(strings.TrimSpace(result), "\n", 2)[0])
+	case "git_blame":
+		// The first line names the file and the range blamed
+		return fmt.Sprintf("%s%s %s", indent, completionDot, strings.TrimSuffix(strings.SplitN(strings.TrimSpace(result), "\n", 2)[0], ":"))
+	case "git_stash", "git_stash_pop", "git_restore":
+		// The first line names the stash ref or how many paths changed
+		return fmt.Sprintf("%s%s %s", indent, completionDot, strings.SplitN(strings.TrimSpace(result), "\n", 2)[0])
 	case "todo_read":
 		// Special handling for todo_read - show formatted todo list instead of raw JSON
 		if m.session != nil {
@@ -1320,6 +1863,14 @@ func (m *Model) formatToolComplete(toolName string, args map[string]interface{},
 		}
 		return fmt.Sprintf("%s%s Todo list updated", indent, completionDot)
 	case "web_fetch":
+		// The header reads "Content from <url> (readable, 12.3 KB extracted from 240.0 KB[, cached]):"
+		if match := webFetchSizePattern.FindStringSubmatch(result); match != nil {
+			size, cached := strings.CutSuffix(match[2], ", cached")
+			if cached {
+				return fmt.Sprintf("%s%s Fetched %s (%s, cached)", indent, completionDot, size, match[1])
+			}
+			return fmt.Sprintf("%s%s Fetched %s (%s)", indent, completionDot, size, match[1])
+		}
 		lines := strings.Count(result, "\n")
 		return fmt.Sprintf("%s%s Fetched content (%d lines)", indent, completionDot, lines+1)
 	case "move_file":
@@ -1375,10 +1926,7 @@ func (m *Model) updateDimensions() {
 	m.textarea.SetWidth(chatWidth - 4) // Leave space for border padding
 
 	if m.glamourRenderer != nil {
-		if newRenderer, err := glamour.NewTermRenderer(
-			glamour.WithStylePath("dracula"),
-			glamour.WithWordWrap(chatWidth-4), // Match content width
-		); err == nil {
+		if newRenderer, err := newMarkdownRenderer(m.markdownTheme, m.markdownWidth()); err == nil {
 			m.glamourRenderer = newRenderer
 		}
 	}
@@ -1418,6 +1966,12 @@ func (m *Model) renderShortcutsOverlay() string {
 		"/ for commands",
 		"↑↓ navigate history",
 		"Shift+Enter new line",
+		"Ctrl+P pause/resume auto-scroll",
+		"Ctrl+O open last diff in pager",
+		"Ctrl+Y copy last code block",
+		"Ctrl+R expand/collapse tool result",
+		"Shift+↑↓ focus previous/next tool result",
+		"Ctrl+T show/hide model reasoning",
 		"Esc close overlay",
 	}
 
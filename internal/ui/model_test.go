@@ -0,0 +1,127 @@
+package ui
+
+import (
+	"fmt"
+	"github.com/tildaslashalef/bazinga/internal/llm"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestModel creates a model with enough chat content to scroll
+func newTestModel() *Model {
+	m := &Model{
+		viewport:     viewport.New(80, 5),
+		followTail:   true,
+		autocomplete: NewAutocompleteState(),
+	}
+
+	var lines []string
+	for i := 0; i < 30; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	m.addMessage(ChatMessage{
+		Role:      "assistant",
+		Content:   strings.Join(lines, "\n"),
+		Timestamp: time.Now(),
+		Streaming: true,
+	})
+	m.refreshViewport()
+
+	return m
+}
+
+// TestPausedStreamKeepsViewportOffset tests that paused auto-scroll leaves the viewport in place
+func TestPausedStreamKeepsViewportOffset(t *testing.T) {
+	m := newTestModel()
+	assert.True(t, m.viewport.AtBottom(), "viewport should follow the tail by default")
+
+	m.toggleFollowTail()
+	m.viewport.ScrollUp(3)
+	offset := m.viewport.YOffset
+
+	for i := 0; i < 10; i++ {
+		m.handleStreamChunk(StreamChunkMsg{Chunk: &llm.StreamChunk{Content: fmt.Sprintf("\nmore %d", i)}})
+		m.refreshViewport()
+	}
+
+	assert.Equal(t, offset, m.viewport.YOffset, "new chunks should not move a paused viewport")
+	assert.Greater(t, m.linesBelowViewport(), 10, "buffered lines should be counted below the viewport")
+
+	// Resuming jumps back to the bottom
+	m.toggleFollowTail()
+	assert.True(t, m.viewport.AtBottom())
+	assert.Equal(t, 0, m.linesBelowViewport())
+}
+
+// TestFollowingStreamScrollsToBottom tests that chunks keep the viewport at the bottom when following
+func TestFollowingStreamScrollsToBottom(t *testing.T) {
+	m := newTestModel()
+
+	m.handleStreamChunk(StreamChunkMsg{Chunk: &llm.StreamChunk{Content: "\nmore\nand more\nand more"}})
+	m.refreshViewport()
+
+	assert.True(t, m.viewport.AtBottom())
+}
+
+// TestScrolledUpStreamKeepsViewportOffset tests that new output does not yank a user who scrolled up
+func TestScrolledUpStreamKeepsViewportOffset(t *testing.T) {
+	m := newTestModel()
+	assert.True(t, m.followTail)
+
+	m.viewport.ScrollUp(4)
+	offset := m.viewport.YOffset
+
+	for i := 0; i < 5; i++ {
+		m.handleStreamChunk(StreamChunkMsg{Chunk: &llm.StreamChunk{Content: fmt.Sprintf("\nmore %d", i)}})
+		m.refreshViewport()
+	}
+	m.addMessage(ChatMessage{Role: "system", Content: "tool finished", Timestamp: time.Now()})
+	m.refreshViewport()
+
+	assert.Equal(t, offset, m.viewport.YOffset, "appending while scrolled up should keep the offset")
+	assert.Greater(t, m.linesBelowViewport(), 4, "new lines should be counted as unread")
+
+	// Scrolling back to the bottom resumes following
+	m.viewport.GotoBottom()
+	m.handleStreamChunk(StreamChunkMsg{Chunk: &llm.StreamChunk{Content: "\nlatest"}})
+	m.refreshViewport()
+	assert.True(t, m.viewport.AtBottom())
+}
+
+// TestTurnUsagePrefersReportedTokens tests that API-reported counts replace estimates and add up across requests
+func TestTurnUsagePrefersReportedTokens(t *testing.T) {
+	m := &Model{inputTokens: 500}
+
+	m.trackTurnUsage(&llm.StreamChunk{Content: strings.Repeat("word ", 40)})
+	assert.Equal(t, 50, m.outputTokens, "streamed text should be estimated")
+
+	m.trackTurnUsage(&llm.StreamChunk{Type: "content_block_stop", Usage: &llm.Usage{InputTokens: 620, OutputTokens: 45}})
+	assert.Equal(t, 620, m.inputTokens)
+	assert.Equal(t, 45, m.outputTokens)
+
+	// A follow-up request after tool calls reports its own counts
+	m.trackTurnUsage(&llm.StreamChunk{Usage: &llm.Usage{InputTokens: 700}})
+	m.trackTurnUsage(&llm.StreamChunk{Content: "more text"})
+	m.trackTurnUsage(&llm.StreamChunk{Usage: &llm.Usage{OutputTokens: 30}})
+	assert.Equal(t, 1320, m.inputTokens)
+	assert.Equal(t, 75, m.outputTokens, "reported counts should not be replaced by estimates")
+	assert.Equal(t, "last reply ↑ 1320 ↓ 75 tokens", m.lastTurnUsage())
+
+	m.resetTurnUsage()
+	assert.Zero(t, m.outputTokens)
+	assert.Empty(t, m.lastTurnUsage())
+}
+
+// TestRateLimitMsgShowsWait tests that a rate limit wait reported through the program
+// shows in the status bar while thinking
+func TestRateLimitMsgShowsWait(t *testing.T) {
+	m := newTestModel()
+	m.isThinking = true
+
+	m.Update(rateLimitMsg{until: time.Now().Add(3 * time.Second)})
+	assert.Contains(t, m.renderStatusBar(), "Waiting on rate limit")
+}
@@ -0,0 +1,99 @@
+package ui
+
+import (
+	"fmt"
+	"github.com/tildaslashalef/bazinga/internal/llm"
+	"github.com/tildaslashalef/bazinga/internal/session"
+	"slices"
+	"strings"
+)
+
+// decidePermissionQueue answers the pending and every queued permission request the same
+// way. The calls of one response are prompted one after another, so the permission
+// manager is told to answer the rest of the current batch too. Approving leaves queued
+// calls that are high risk or escalated for special conditions waiting for their own
+// answer.
+func (m *Model) decidePermissionQueue(approved bool) {
+	permissionManager := m.permissionManager()
+	if permissionManager != nil {
+		permissionManager.DecideBatch(approved)
+	}
+
+	pending := m.pendingPermission
+	requests := m.permissionQueue
+	if pending != nil && (len(requests) == 0 || requests[0] != pending) {
+		requests = append([]*PermissionRequest{pending}, requests...)
+	}
+
+	var waiting []*PermissionRequest
+	for _, request := range requests {
+		// The risk level of a request already counts special conditions as high
+		if approved && request != pending && request.RiskLevel == "high" {
+			waiting = append(waiting, request)
+			continue
+		}
+		m.permissionHistory[m.generatePermissionKey(request.ToolCall)] = approved
+		request.ResponseChan <- approved
+	}
+
+	m.permissionQueue = waiting
+	m.pendingPermission = nil
+	if len(waiting) > 0 {
+		m.pendingPermission = waiting[0]
+	}
+}
+
+// renderQueuedCalls lists the other calls A and N would answer, marking those that
+// approving all leaves to prompt on their own
+func (m *Model) renderQueuedCalls() string {
+	permissionManager := m.permissionManager()
+	describe := func(call *llm.ToolCall) string {
+		if permissionManager != nil {
+			return permissionManager.DescribeAction(call)
+		}
+		return call.Name
+	}
+
+	var lines []string
+	var listed []*llm.ToolCall
+	for _, request := range m.permissionQueue {
+		if request == m.pendingPermission {
+			continue
+		}
+		line := describe(request.ToolCall)
+		if request.RiskLevel == "high" {
+			line += " (asks separately)"
+		}
+		lines = append(lines, line)
+		listed = append(listed, request.ToolCall)
+	}
+	for _, call := range m.pendingPermission.BatchCalls {
+		if slices.Contains(listed, call) {
+			continue
+		}
+		line := describe(call)
+		if permissionManager == nil || !permissionManager.BatchApprovable(call) {
+			line += " (asks separately)"
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("📋 Also queued:")
+	for _, line := range lines {
+		b.WriteString(fmt.Sprintf("\n  • %s", line))
+	}
+	b.WriteString("\n\n")
+	return b.String()
+}
+
+// permissionManager returns the session's permission manager, or nil without a session
+func (m *Model) permissionManager() *session.PermissionManager {
+	if m.session == nil {
+		return nil
+	}
+	return m.session.GetPermissionManager()
+}
@@ -0,0 +1,98 @@
+package ui
+
+import (
+	"github.com/tildaslashalef/bazinga/internal/llm"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+)
+
+func queueAnother(m *Model, path string) *PermissionRequest {
+	request := &PermissionRequest{
+		ToolID:       "tool-" + path,
+		ToolCall:     &llm.ToolCall{Name: "write_file", Input: map[string]interface{}{"file_path": path}},
+		RiskLevel:    "medium",
+		ResponseChan: make(chan bool, 1),
+	}
+	m.permissionQueue = append(m.permissionQueue, request)
+	for i, queued := range m.permissionQueue {
+		queued.QueuePosition, queued.TotalQueued = i+1, len(m.permissionQueue)
+	}
+	return request
+}
+
+// TestApproveAllQueued tests that A answers every queued request at once
+func TestApproveAllQueued(t *testing.T) {
+	m, first := newPermissionModel()
+	second := queueAnother(m, "b.go")
+	assert.Contains(t, m.renderPermissionPrompt(), "(A) Approve All Queued")
+
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("A")})
+	assert.True(t, <-first.ResponseChan)
+	assert.True(t, <-second.ResponseChan)
+	assert.Nil(t, m.pendingPermission)
+	assert.Empty(t, m.permissionQueue)
+}
+
+// TestApproveAllQueuedLeavesRiskyCalls tests that A lists the queued calls and leaves
+// high-risk ones to be answered on their own
+func TestApproveAllQueuedLeavesRiskyCalls(t *testing.T) {
+	m, first := newPermissionModel()
+	second := queueAnother(m, "b.go")
+	risky := queueAnother(m, "/etc/hosts")
+	risky.RiskLevel = "high"
+
+	prompt := m.renderPermissionPrompt()
+	assert.Contains(t, prompt, "Also queued:")
+	assert.Contains(t, prompt, "write_file")
+	assert.Contains(t, prompt, "(asks separately)")
+
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("A")})
+	assert.True(t, <-first.ResponseChan)
+	assert.True(t, <-second.ResponseChan)
+	assert.Same(t, risky, m.pendingPermission, "the high-risk call still prompts")
+	assert.Empty(t, risky.ResponseChan)
+
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	assert.True(t, <-risky.ResponseChan)
+	assert.Nil(t, m.pendingPermission)
+}
+
+// TestDenyAllQueued tests that N denies every queued request, while n only the first
+func TestDenyAllQueued(t *testing.T) {
+	m, first := newPermissionModel()
+	second := queueAnother(m, "b.go")
+	third := queueAnother(m, "c.go")
+
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+	assert.False(t, <-first.ResponseChan)
+	assert.Same(t, second, m.pendingPermission)
+
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("N")})
+	assert.False(t, <-second.ResponseChan)
+	assert.False(t, <-third.ResponseChan)
+	assert.Nil(t, m.pendingPermission)
+	assert.Equal(t, false, m.permissionHistory["write_file:c.go"])
+
+	// A single request shows no batch options
+	m, _ = newPermissionModel()
+	assert.NotContains(t, m.renderPermissionPrompt(), "All Queued")
+}
+
+// TestApprovalCoversSameCallOnly tests that an approval the UI caches covers only calls
+// the permission manager would remember it for
+func TestApprovalCoversSameCallOnly(t *testing.T) {
+	runTests := func(target string) *llm.ToolCall {
+		return &llm.ToolCall{Name: "run_tests", Input: map[string]interface{}{"target": target}}
+	}
+
+	m, request := newPermissionModel()
+	request.ToolCall = runTests("./internal/api")
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	assert.True(t, <-request.ResponseChan)
+
+	assert.True(t, m.permissionHistory[m.generatePermissionKey(runTests("./internal/api"))])
+	_, cached := m.permissionHistory[m.generatePermissionKey(runTests("./internal/db"))]
+	assert.False(t, cached, "approving one test target doesn't approve another")
+}
@@ -0,0 +1,142 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"github.com/tildaslashalef/bazinga/internal/llm"
+	"github.com/tildaslashalef/bazinga/internal/loggy"
+	"github.com/tildaslashalef/bazinga/internal/session"
+	"os"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// sessionLoadedMsg carries a saved session loaded by /resume
+type sessionLoadedMsg struct {
+	session *session.Session
+	err     error
+}
+
+// loadSession loads a saved session in the background
+func (m *Model) loadSession(id string) tea.Cmd {
+	if m.isThinking {
+		m.addMessage(ChatMessage{
+			Role:      "system",
+			Content:   "ℹ Wait for the current response to finish before resuming another session",
+			Timestamp: time.Now(),
+		})
+		return nil
+	}
+	if m.sessionManager == nil {
+		m.addMessage(ChatMessage{
+			Role:      "system",
+			Content:   "✗ Session manager not available",
+			Timestamp: time.Now(),
+		})
+		return nil
+	}
+
+	manager := m.sessionManager
+	return func() tea.Msg {
+		sess, err := manager.LoadSession(context.Background(), id)
+		return sessionLoadedMsg{session: sess, err: err}
+	}
+}
+
+// resumeSession switches the UI to a loaded session and shows its conversation so far.
+// The previous session is saved and closed.
+func (m *Model) resumeSession(sess *session.Session) {
+	previous := m.session
+	m.session = sess
+	m.estimateRequest = sess.EstimatePromptCost
+	m.hasProvider = sess.HasProvider
+	m.todos = sessionTodos(sess)
+	m.gitStateFn = func() (string, bool, error) {
+		return sess.GitState(context.Background())
+	}
+
+	// Undo, retry and remembered approvals belong to the previous conversation
+	m.fileDiffs = nil
+	m.permissionHistory = make(map[string]bool)
+	m.failedRequest = nil
+	m.inFlight = nil
+	m.retryArmed = false
+	m.agent = nil
+	m.savedSessions = nil
+	m.clearRunningOutput()
+
+	m.SetupFileChangeCallback()
+	m.SetupPermissionCallback()
+	m.SetupRateLimitCallback()
+
+	if previous != nil {
+		if err := previous.Close(); err != nil {
+			loggy.Warn("Failed to close previous session", "session_id", previous.ID, "error", err)
+		}
+	}
+
+	name := sess.Name
+	if name == "" {
+		name = sess.ID
+	}
+	m.messages = nil
+	m.addMessage(ChatMessage{
+		Role:      "system",
+		Content:   fmt.Sprintf("↻ Resumed session %s (%d messages)", name, len(sess.History)),
+		Timestamp: time.Now(),
+	})
+	if _, err := os.Stat(sess.RootPath); err != nil {
+		m.addMessage(ChatMessage{
+			Role:      "system",
+			Content:   fmt.Sprintf("⚠ The project directory of this session, %s, no longer exists. File and shell tools will fail until it is restored", sess.RootPath),
+			Timestamp: time.Now(),
+		})
+	}
+	m.addHistoryMessages(sess.History)
+
+	m.refreshTokenEstimate()
+	m.viewport.GotoBottom()
+	loggy.Info("Resumed session", "session_id", sess.ID, "messages", len(sess.History))
+}
+
+// addHistoryMessages shows a session's history in the chat the way it looked live:
+// prompts and answers, and each tool call with its result
+func (m *Model) addHistoryMessages(history []llm.Message) {
+	calls := make(map[string]llm.ToolCall)
+	for _, msg := range history {
+		for _, call := range msg.ToolCalls {
+			calls[call.ID] = call
+		}
+	}
+
+	for _, msg := range history {
+		text := llm.DisplayText(msg)
+		switch msg.Role {
+		case "user", "assistant":
+			if text == "" {
+				continue
+			}
+			m.addMessage(ChatMessage{Role: msg.Role, Content: text, Timestamp: time.Now()})
+		case "tool":
+			call, ok := calls[msg.ToolCallID]
+			if !ok {
+				call = llm.ToolCall{Name: msg.Name}
+			}
+			name, input := call.CallName(), call.CallInput()
+			if name == "" {
+				continue
+			}
+			m.addToolMessageWithTask(name, input, "start", "", "")
+			if msg.IsError {
+				m.addToolMessageWithTask(name, input, "error", strings.TrimPrefix(text, "Error: "), "")
+			} else {
+				m.addToolMessageWithTask(name, input, "complete", text, "")
+			}
+		case "system":
+			// The summary /compact left in place of older messages
+			m.addMessage(ChatMessage{Role: "system", Content: "ℹ " + text, Timestamp: time.Now()})
+		}
+	}
+}
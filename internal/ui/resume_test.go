@@ -0,0 +1,42 @@
+package ui
+
+import (
+	"github.com/tildaslashalef/bazinga/internal/config"
+	"github.com/tildaslashalef/bazinga/internal/llm"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAddHistoryMessages tests that a resumed session's history is shown with its roles
+// and each tool call paired with its result
+func TestAddHistoryMessages(t *testing.T) {
+	m := newToolOutputModel(config.DefaultConfig())
+	m.messages = nil
+
+	call := llm.ToolCall{ID: "call_1", Name: "read_file", Input: map[string]interface{}{"file_path": "main.go"}}
+	failed := llm.ToolCall{ID: "call_2", Name: "bash", Input: map[string]interface{}{"command": "make"}}
+	m.addHistoryMessages([]llm.Message{
+		{Role: "system", Content: "Summary of the conversation so far"},
+		{Role: "user", Content: "What does main.go do?"},
+		{Role: "assistant", Content: "Let me look.", ToolCalls: []llm.ToolCall{call, failed}},
+		llm.NewToolResultMessage(&call, "package main\n\nfunc main() {}", false),
+		llm.NewToolResultMessage(&failed, "Error: make: not found", true),
+		{Role: "assistant", Content: "It does nothing yet."},
+	})
+
+	var roles []string
+	for _, msg := range m.messages {
+		roles = append(roles, msg.Role)
+	}
+	require.Equal(t, []string{"system", "user", "assistant", "system", "system", "system", "system", "assistant"}, roles)
+
+	assert.Contains(t, m.messages[0].Content, "Summary of the conversation so far")
+	assert.Equal(t, "What does main.go do?", m.messages[1].Content)
+	assert.True(t, m.messages[4].IsToolMsg)
+	assert.Equal(t, "package main\n\nfunc main() {}", m.messages[4].ToolResult, "the full result should be kept for ctrl+r")
+	assert.Contains(t, m.messages[6].Content, "make: not found")
+	assert.NotContains(t, m.messages[6].Content, "Error: make")
+	assert.Equal(t, "It does nothing yet.", m.messages[7].Content)
+}
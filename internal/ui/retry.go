@@ -0,0 +1,53 @@
+package ui
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// retryKey sends a failed request again when pressed right after its error
+const retryKey = "r"
+
+// sentRequest is a message sent to the model, kept until the response starts streaming
+// so it can be sent again if the request fails
+type sentRequest struct {
+	Message   string
+	ThinkOnly bool
+}
+
+// retryFailedRequest sends the last failed message again. The session drops the copy
+// that went unanswered first, so the turn isn't recorded twice.
+func (m *Model) retryFailedRequest() tea.Cmd {
+	m.retryArmed = false
+
+	failed := m.failedRequest
+	if failed == nil {
+		m.addMessage(ChatMessage{
+			Role:      "system",
+			Content:   "ℹ Nothing to retry: the last request didn't fail",
+			Timestamp: time.Now(),
+		})
+		return nil
+	}
+	if m.isThinking {
+		m.addMessage(ChatMessage{
+			Role:      "system",
+			Content:   "ℹ Wait for the current response to finish before retrying",
+			Timestamp: time.Now(),
+		})
+		return nil
+	}
+
+	m.failedRequest = nil
+	if m.session != nil {
+		m.session.DropUnansweredMessage()
+	}
+
+	m.addMessage(ChatMessage{
+		Role:      "system",
+		Content:   "↻ Retrying: " + failed.Message,
+		Timestamp: time.Now(),
+	})
+	return m.dispatchRequest(failed.Message, failed.ThinkOnly)
+}
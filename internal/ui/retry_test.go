@@ -0,0 +1,36 @@
+package ui
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRetryFailedRequest tests that a request failing before the reply can be sent again
+func TestRetryFailedRequest(t *testing.T) {
+	m := newTestModel()
+
+	require.NotNil(t, m.dispatchRequest("fix the build", false))
+	require.True(t, m.isThinking)
+
+	m.handleError(ErrorMsg{Error: errors.New("overloaded")})
+	assert.False(t, m.isThinking, "the error ends the request")
+	assert.True(t, m.retryArmed)
+	require.NotNil(t, m.failedRequest)
+	assert.Contains(t, m.messages[len(m.messages)-1].Content, "Press r or type /retry")
+
+	cmd := m.retryFailedRequest()
+	assert.NotNil(t, cmd)
+	assert.True(t, m.isThinking)
+	assert.Nil(t, m.failedRequest)
+	assert.False(t, m.retryArmed)
+	assert.Equal(t, "fix the build", m.inFlight.Message)
+
+	// Once a request goes through there is nothing to retry
+	m.isThinking = false
+	m.inFlight = nil
+	assert.Nil(t, m.retryFailedRequest())
+	assert.Contains(t, m.messages[len(m.messages)-1].Content, "Nothing to retry")
+}
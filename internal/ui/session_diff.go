@@ -0,0 +1,143 @@
+package ui
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// netChange is what the session's changes to one file add up to: its content before
+// the first change and after the last, and where it was moved from
+type netChange struct {
+	path     string
+	fromPath string // Original path of a moved file, or the source of a copy
+	before   string
+	after    string
+	created  bool
+	copied   bool
+	deleted  bool
+}
+
+// netChanges folds the session's file diffs into one change per file, in the order the
+// files were first changed. A file created and deleted again drops out.
+func netChanges(root string, diffs []*FileDiff) []*netChange {
+	var order []*netChange
+	byPath := make(map[string]*netChange)
+
+	for _, diff := range diffs {
+		path := relativeDiffPath(root, diff.FilePath)
+
+		switch diff.Operation {
+		case "move":
+			from := relativeDiffPath(root, diff.FromPath)
+			change, ok := byPath[from]
+			if ok {
+				delete(byPath, from)
+				change.after = diff.After
+			} else {
+				change = &netChange{before: diff.Before, after: diff.After}
+				order = append(order, change)
+			}
+			if change.fromPath == "" && !change.created {
+				change.fromPath = from
+			}
+			if change.fromPath == path && !change.copied {
+				change.fromPath = "" // Moved back where it started
+			}
+			change.path = path
+			byPath[path] = change
+
+		case "copy":
+			change := &netChange{path: path, fromPath: relativeDiffPath(root, diff.FromPath), after: diff.After, copied: true}
+			order = append(order, change)
+			byPath[path] = change
+
+		default:
+			change, ok := byPath[path]
+			if !ok {
+				change = &netChange{path: path, before: diff.Before, created: diff.Operation == "create"}
+				order = append(order, change)
+				byPath[path] = change
+			}
+			change.after = diff.After
+			change.deleted = diff.Operation == "delete"
+		}
+	}
+
+	var changes []*netChange
+	for _, change := range order {
+		if byPath[change.path] != change {
+			continue // Replaced by a later change at the same path
+		}
+		if (change.created || change.copied) && change.deleted {
+			continue
+		}
+		if change.before == change.after && change.fromPath == "" && !change.created {
+			continue
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// fileDiff renders the net change with the same formatter as single changes
+func (c *netChange) fileDiff() *FileDiff {
+	operation := "edit"
+	switch {
+	case c.deleted:
+		operation = "delete"
+	case c.copied:
+		operation = "copy"
+	case c.created:
+		operation = "create"
+	case c.fromPath != "":
+		operation = "move"
+	}
+
+	path := c.path
+	if operation == "move" || operation == "copy" {
+		path = c.fromPath + " → " + c.path
+	}
+	return GenerateDiff(path, c.before, c.after, operation)
+}
+
+// matches reports whether the change is to path, before or after a move
+func (c *netChange) matches(path string) bool {
+	return c.path == path || (c.fromPath != "" && c.fromPath == path)
+}
+
+// sessionDiff renders the net change to every file changed this session, or only to
+// path, and returns how many files it covers
+func (m *Model) sessionDiff(path string) (string, int) {
+	root := ""
+	if m.session != nil {
+		root = m.session.GetRootPath()
+	}
+	if path != "" {
+		path = relativeDiffPath(root, path)
+	}
+
+	var rendered []string
+	for _, change := range netChanges(root, m.fileDiffs) {
+		if path != "" && !change.matches(path) {
+			continue
+		}
+		if diff := change.fileDiff().RenderDiff(); diff != "" {
+			rendered = append(rendered, diff)
+		}
+	}
+	return strings.Join(rendered, "\n\n"), len(rendered)
+}
+
+// relativeDiffPath returns path relative to root with forward slashes, so the same
+// file is recognized however a tool named it
+func relativeDiffPath(root, path string) string {
+	if path == "" {
+		return ""
+	}
+	if filepath.IsAbs(path) && root != "" {
+		if rel, err := filepath.Rel(root, path); err == nil && !strings.HasPrefix(rel, "..") {
+			path = rel
+		}
+	}
+	return filepath.ToSlash(filepath.Clean(path))
+}
@@ -0,0 +1,59 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNetChangesFoldsEachFile tests that the changes to a file add up to one change
+// from its first state to its last
+func TestNetChangesFoldsEachFile(t *testing.T) {
+	changes := netChanges("/repo", []*FileDiff{
+		GenerateDiff("/repo/main.go", "v1", "v2", "edit"),
+		GenerateDiff("scratch.txt", "", "tmp", "create"),
+		GenerateDiff("main.go", "v2", "v3", "write"),
+		GenerateDiff("scratch.txt", "tmp", "", "delete"),
+		GenerateDiff("new.go", "", "package x", "create"),
+		GenerateDiff("old.go → renamed.go", "same", "same", "move"),
+		GenerateDiff("undone.go", "a", "b", "edit"),
+		GenerateDiff("undone.go", "b", "a", "edit"),
+	})
+
+	require.Len(t, changes, 3)
+
+	assert.Equal(t, "main.go", changes[0].path, "absolute and relative paths are the same file")
+	assert.Equal(t, "v1", changes[0].before)
+	assert.Equal(t, "v3", changes[0].after)
+	assert.Equal(t, "edit", changes[0].fileDiff().Operation)
+
+	assert.Equal(t, "create", changes[1].fileDiff().Operation)
+
+	assert.Equal(t, "renamed.go", changes[2].path)
+	assert.Equal(t, "old.go", changes[2].fromPath)
+	assert.Equal(t, "move", changes[2].fileDiff().Operation)
+}
+
+// TestSessionDiffNarrowsToPath tests that /diff with a path shows only that file,
+// matching it by either name of a moved file
+func TestSessionDiffNarrowsToPath(t *testing.T) {
+	m := &Model{fileDiffs: []*FileDiff{
+		GenerateDiff("main.go", "one\n", "two\n", "edit"),
+		GenerateDiff("util.go → helpers.go", "x\n", "x\n", "move"),
+	}}
+
+	_, files := m.sessionDiff("")
+	assert.Equal(t, 2, files)
+
+	diff, files := m.sessionDiff("main.go")
+	assert.Equal(t, 1, files)
+	assert.Contains(t, diff, "main.go")
+	assert.NotContains(t, diff, "helpers.go")
+
+	_, files = m.sessionDiff("util.go")
+	assert.Equal(t, 1, files)
+
+	_, files = m.sessionDiff("other.go")
+	assert.Zero(t, files)
+}
@@ -0,0 +1,92 @@
+package ui
+
+import (
+	"fmt"
+	"github.com/tildaslashalef/bazinga/internal/loggy"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/glamour"
+)
+
+// defaultMarkdownTheme is the markdown style used when the config names none
+const defaultMarkdownTheme = "dracula"
+
+// markdownThemes returns the names of glamour's built-in styles
+func markdownThemes() []string {
+	names := make([]string, 0, len(glamour.DefaultStyles))
+	for name := range glamour.DefaultStyles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// newMarkdownRenderer builds a markdown renderer for a built-in style name or a path to
+// a glamour JSON style file, wrapping at width
+func newMarkdownRenderer(theme string, width int) (*glamour.TermRenderer, error) {
+	if theme == "" {
+		theme = defaultMarkdownTheme
+	}
+
+	renderer, err := glamour.NewTermRenderer(
+		glamour.WithStylePath(theme),
+		glamour.WithWordWrap(width),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load markdown theme %q: %w", theme, err)
+	}
+	return renderer, nil
+}
+
+// markdownWidth returns the wrap width for rendered markdown: the chat content width,
+// or 80 columns before the terminal size is known
+func (m *Model) markdownWidth() int {
+	if m.width == 0 {
+		return 80
+	}
+	chatWidth, _ := GetChatDimensions(m.width, m.height)
+	return chatWidth - 4 // Match content width
+}
+
+// applyMarkdownTheme switches the chat to theme. If the theme can't be loaded, chat
+// messages are shown as raw text and the error is returned.
+func (m *Model) applyMarkdownTheme(theme string) error {
+	m.markdownTheme = theme
+
+	renderer, err := newMarkdownRenderer(theme, m.markdownWidth())
+	if err != nil {
+		loggy.Warn("Markdown theme unavailable, showing raw text", "theme", theme, "error", err)
+	}
+	m.glamourRenderer = renderer
+	return err
+}
+
+// switchMarkdownTheme handles /theme: it changes the theme and re-renders the chat, or
+// keeps the current theme if the new one can't be loaded. Without a theme it describes
+// the current one and the choices.
+func (m *Model) switchMarkdownTheme(theme string) string {
+	if theme == "" {
+		return fmt.Sprintf("ℹ Markdown theme: %s\nBuilt-in themes: %s, or a path to a glamour JSON style file\nUsage: /theme <name|path>",
+			m.currentMarkdownTheme(), strings.Join(markdownThemes(), ", "))
+	}
+
+	renderer, err := newMarkdownRenderer(theme, m.markdownWidth())
+	if err != nil {
+		loggy.Warn("Markdown theme unavailable", "theme", theme, "error", err)
+		return fmt.Sprintf("✗ %v\nKept the %s theme", err, m.currentMarkdownTheme())
+	}
+
+	m.markdownTheme = theme
+	m.glamourRenderer = renderer
+	m.refreshViewport()
+	return fmt.Sprintf("✓ Markdown theme set to %s", theme)
+}
+
+// currentMarkdownTheme returns the theme in use
+func (m *Model) currentMarkdownTheme() string {
+	if m.markdownTheme == "" {
+		return defaultMarkdownTheme
+	}
+	return m.markdownTheme
+}
@@ -0,0 +1,42 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestApplyMarkdownTheme tests built-in and file themes, and the raw-text fallback
+func TestApplyMarkdownTheme(t *testing.T) {
+	m := newTestModel()
+
+	require.NoError(t, m.applyMarkdownTheme("light"))
+	assert.NotNil(t, m.glamourRenderer)
+
+	style := filepath.Join(t.TempDir(), "style.json")
+	require.NoError(t, os.WriteFile(style, []byte(`{"document": {"margin": 1}}`), 0o644))
+	require.NoError(t, m.applyMarkdownTheme(style))
+	assert.NotNil(t, m.glamourRenderer)
+
+	assert.Error(t, m.applyMarkdownTheme(filepath.Join(t.TempDir(), "missing.json")))
+	assert.Nil(t, m.glamourRenderer, "an unavailable theme falls back to raw text")
+	assert.Contains(t, m.renderChatContent(), "line 29")
+}
+
+// TestSwitchMarkdownTheme tests that /theme keeps the current theme when the new one fails
+func TestSwitchMarkdownTheme(t *testing.T) {
+	m := newTestModel()
+	require.NoError(t, m.applyMarkdownTheme("dark"))
+
+	assert.Contains(t, m.switchMarkdownTheme("ascii"), "set to ascii")
+	assert.Equal(t, "ascii", m.currentMarkdownTheme())
+
+	reply := m.switchMarkdownTheme("no-such-theme")
+	assert.Contains(t, reply, "Kept the ascii theme")
+	assert.NotNil(t, m.glamourRenderer)
+
+	assert.Contains(t, m.switchMarkdownTheme(""), "dracula")
+}
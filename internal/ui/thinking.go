@@ -0,0 +1,54 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// thinkingKey shows or hides the reasoning above assistant responses
+const thinkingKey = "ctrl+t"
+
+// appendThinking adds streamed reasoning to the response being streamed, starting one
+// if the reasoning comes first
+func (m *Model) appendThinking(text string) {
+	if len(m.messages) > 0 && m.messages[len(m.messages)-1].Streaming {
+		m.messages[len(m.messages)-1].Thinking += text
+		return
+	}
+
+	m.addMessage(ChatMessage{
+		Role:      "assistant",
+		Thinking:  text,
+		Timestamp: time.Now(),
+		Streaming: true,
+	})
+}
+
+// toggleThinking shows or hides the reasoning of every response
+func (m *Model) toggleThinking() {
+	m.showThinking = !m.showThinking
+	m.refreshViewport()
+}
+
+// renderThinking renders a response's reasoning, dimmed under a "Thinking" label. While
+// hidden only the label is shown, with how long the reasoning is.
+func renderThinking(thinking string, expanded bool, width int) string {
+	thinking = strings.TrimSpace(thinking)
+	lines := strings.Count(thinking, "\n") + 1
+
+	label := lipgloss.NewStyle().Foreground(TextMuted).Italic(true)
+	if !expanded {
+		return label.Render(fmt.Sprintf("▸ Thinking (%d lines, %s to show)", lines, thinkingKey))
+	}
+
+	body := lipgloss.NewStyle().
+		Foreground(TextMuted).
+		Faint(true).
+		PaddingLeft(2).
+		Width(max(width-2, 20)).
+		Render(thinking)
+	return label.Render(fmt.Sprintf("▾ Thinking (%s to hide)", thinkingKey)) + "\n" + body
+}
@@ -0,0 +1,35 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAppendThinking tests that reasoning streamed before the answer lands on the same
+// assistant message
+func TestAppendThinking(t *testing.T) {
+	m := &Model{}
+	handler := m.streamHandler()
+
+	handler.OnThinking("Check the ")
+	handler.OnThinking("caller first.")
+	handler.OnText("The caller passes nil.")
+
+	require.Len(t, m.messages, 1)
+	assert.Equal(t, "assistant", m.messages[0].Role)
+	assert.Equal(t, "Check the caller first.", m.messages[0].Thinking)
+	assert.Equal(t, "The caller passes nil.", m.messages[0].Content)
+}
+
+// TestRenderThinking tests that reasoning shows only its label until expanded
+func TestRenderThinking(t *testing.T) {
+	collapsed := renderThinking("First step.\nSecond step.", false, 80)
+	assert.Contains(t, collapsed, "Thinking (2 lines")
+	assert.NotContains(t, collapsed, "First step.")
+
+	expanded := renderThinking("First step.\nSecond step.", true, 80)
+	assert.Contains(t, expanded, "First step.")
+	assert.Contains(t, expanded, "Second step.")
+}
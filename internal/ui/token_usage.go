@@ -0,0 +1,67 @@
+package ui
+
+import (
+	"fmt"
+	"github.com/tildaslashalef/bazinga/internal/llm"
+)
+
+// estimateTokens estimates the token count of text with the active provider's
+// estimator, or about four characters per token without a session
+func (m *Model) estimateTokens(text string) int {
+	if m.session != nil {
+		return m.session.EstimateTokens(text)
+	}
+	return len(text) / 4
+}
+
+// resetTurnUsage clears the token counts left from the previous request
+func (m *Model) resetTurnUsage() {
+	m.outputTokens = 0
+	m.usage = llm.Usage{}
+	m.streamedText.Reset()
+}
+
+// trackTurnUsage updates the displayed token counts from a stream chunk. Counts the
+// API reports replace the estimates; a turn with tool calls makes several requests,
+// so the reported counts add up.
+func (m *Model) trackTurnUsage(chunk *llm.StreamChunk) {
+	if chunk.Content != "" {
+		m.streamedText.WriteString(chunk.Content)
+		if m.usage.OutputTokens == 0 {
+			m.outputTokens = max(1, m.estimateTokens(m.streamedText.String()))
+		}
+	}
+
+	if chunk.Usage == nil {
+		return
+	}
+	m.usage.InputTokens += chunk.Usage.InputTokens
+	m.usage.OutputTokens += chunk.Usage.OutputTokens
+	if m.usage.InputTokens > 0 {
+		m.inputTokens = m.usage.InputTokens
+	}
+	if m.usage.OutputTokens > 0 {
+		m.outputTokens = m.usage.OutputTokens
+	}
+}
+
+// lastTurnUsage describes the tokens the API reported for the last turn, or "" if it
+// reported none
+func (m *Model) lastTurnUsage() string {
+	if m.usage.InputTokens == 0 && m.usage.OutputTokens == 0 {
+		return ""
+	}
+	return fmt.Sprintf("last reply ↑ %d ↓ %d tokens", m.usage.InputTokens, m.usage.OutputTokens)
+}
+
+// sessionCost formats the session's estimated cost so far, or "" if nothing with
+// known pricing has been spent
+func (m *Model) sessionCost() string {
+	if m.session == nil {
+		return ""
+	}
+	if cost := m.session.GetSessionCost(); cost > 0 {
+		return fmt.Sprintf("$%.4f", cost)
+	}
+	return ""
+}
@@ -0,0 +1,120 @@
+package ui
+
+// Keys that expand a tool result and move the focus between tool messages. Plain up
+// and down stay with the input and autocomplete.
+const (
+	expandKey       = "ctrl+r"
+	focusPrevKey    = "shift+up"
+	focusNextKey    = "shift+down"
+	focusPrevAltKey = "alt+k"
+	focusNextAltKey = "alt+j"
+)
+
+// expandableToolIndices returns the indices of tool messages that hold a full result
+func (m *Model) expandableToolIndices() []int {
+	var indices []int
+	for i, msg := range m.messages {
+		if msg.IsToolMsg && msg.ToolResult != "" {
+			indices = append(indices, i)
+		}
+	}
+	return indices
+}
+
+// focusedToolIndex returns the index of the focused tool message, or -1
+func (m *Model) focusedToolIndex() int {
+	for i, msg := range m.messages {
+		if msg.Focused {
+			return i
+		}
+	}
+	return -1
+}
+
+// moveToolFocus moves the focus to the previous (direction < 0) or next tool message
+// with a result. Without a focus, moving back starts at the latest one.
+func (m *Model) moveToolFocus(direction int) {
+	indices := m.expandableToolIndices()
+	if len(indices) == 0 {
+		return
+	}
+
+	current := m.focusedToolIndex()
+	target := -1
+	switch {
+	case current < 0 && direction < 0:
+		target = indices[len(indices)-1]
+	case current < 0:
+		return
+	case direction < 0:
+		for _, i := range indices {
+			if i < current {
+				target = i
+			}
+		}
+	default:
+		for j := len(indices) - 1; j >= 0; j-- {
+			if indices[j] > current {
+				target = indices[j]
+			}
+		}
+	}
+	if target < 0 {
+		return
+	}
+
+	if current >= 0 {
+		m.messages[current].Focused = false
+	}
+	m.messages[target].Focused = true
+	m.refreshViewport()
+	m.scrollToMessage(target)
+}
+
+// clearToolFocus removes the focus from the focused tool message
+func (m *Model) clearToolFocus() {
+	if i := m.focusedToolIndex(); i >= 0 {
+		m.messages[i].Focused = false
+		m.refreshViewport()
+	}
+}
+
+// toggleToolResult expands the focused tool message, or the latest one with a result if
+// none is focused, or collapses it back to its summary
+func (m *Model) toggleToolResult() {
+	target := m.focusedToolIndex()
+	if target < 0 || m.messages[target].ToolResult == "" {
+		indices := m.expandableToolIndices()
+		if len(indices) == 0 {
+			return
+		}
+		target = indices[len(indices)-1]
+	}
+
+	m.messages[target].Expanded = !m.messages[target].Expanded
+	m.refreshViewport()
+	if m.messages[target].Focused {
+		m.scrollToMessage(target)
+	}
+}
+
+// scrollToMessage scrolls the chat so that message i starts in view, if it is not
+// fully visible already
+func (m *Model) scrollToMessage(i int) {
+	if i < 0 || i >= len(m.messageLines) {
+		return
+	}
+
+	start := m.messageLines[i]
+	end := m.viewport.TotalLineCount()
+	if i+1 < len(m.messageLines) {
+		end = m.messageLines[i+1] - 1
+	}
+
+	top := m.viewport.YOffset
+	bottom := top + m.viewport.VisibleLineCount()
+	if start >= top && end <= bottom {
+		return
+	}
+	m.viewport.SetYOffset(start)
+}
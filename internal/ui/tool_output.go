@@ -0,0 +1,224 @@
+package ui
+
+import (
+	"fmt"
+	"github.com/tildaslashalef/bazinga/internal/config"
+	"github.com/tildaslashalef/bazinga/internal/loggy"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Tool output modes control how tool completions are shown in the chat
+const (
+	ToolOutputSummary = "summary" // One-line summary such as "Read 245 lines"
+	ToolOutputInline  = "inline"  // Summary followed by the result content, up to a cap
+	ToolOutputHidden  = "hidden"  // Only the tool start line is shown
+)
+
+// defaultToolOutputMaxLines caps the result lines shown in inline mode
+const defaultToolOutputMaxLines = 20
+
+// runningOutputLines is how many of the latest output lines of a running command are shown
+const runningOutputLines = 5
+
+// toolOutputSettings holds the configured tool output mode and per-tool overrides
+type toolOutputSettings struct {
+	mode      string
+	overrides map[string]string
+	maxLines  int
+}
+
+// newToolOutputSettings builds tool output settings from config, ignoring unknown modes
+func newToolOutputSettings(cfg *config.Config) toolOutputSettings {
+	settings := toolOutputSettings{
+		mode:      ToolOutputSummary,
+		overrides: make(map[string]string),
+		maxLines:  defaultToolOutputMaxLines,
+	}
+	if cfg == nil {
+		return settings
+	}
+
+	if isToolOutputMode(cfg.UI.ToolOutput) {
+		settings.mode = cfg.UI.ToolOutput
+	} else if cfg.UI.ToolOutput != "" {
+		loggy.Warn("Unknown tool_output mode, using summary", "mode", cfg.UI.ToolOutput)
+	}
+
+	for tool, mode := range cfg.UI.ToolOutputOverrides {
+		if !isToolOutputMode(mode) {
+			loggy.Warn("Unknown tool_output override, ignoring", "tool", tool, "mode", mode)
+			continue
+		}
+		settings.overrides[tool] = mode
+	}
+
+	if cfg.UI.ToolOutputMaxLines > 0 {
+		settings.maxLines = cfg.UI.ToolOutputMaxLines
+	}
+
+	return settings
+}
+
+// modeFor returns the output mode for a tool, honouring per-tool overrides
+func (s toolOutputSettings) modeFor(toolName string) string {
+	if mode, ok := s.overrides[toolName]; ok {
+		return mode
+	}
+	if s.mode == "" {
+		return ToolOutputSummary
+	}
+	return s.mode
+}
+
+// isToolOutputMode reports whether mode is a known tool output mode
+func isToolOutputMode(mode string) bool {
+	switch mode {
+	case ToolOutputSummary, ToolOutputInline, ToolOutputHidden:
+		return true
+	}
+	return false
+}
+
+// formatInlineResult indents a tool result below its summary line, truncated to maxLines
+func formatInlineResult(result, indent string, maxLines int) string {
+	result = strings.TrimRight(result, "\n")
+	if strings.TrimSpace(result) == "" {
+		return ""
+	}
+	if maxLines <= 0 {
+		maxLines = defaultToolOutputMaxLines
+	}
+
+	lines := strings.Split(result, "\n")
+	hidden := 0
+	if len(lines) > maxLines {
+		hidden = len(lines) - maxLines
+		lines = lines[:maxLines]
+	}
+
+	var formatted []string
+	for _, line := range lines {
+		formatted = append(formatted, indent+"  "+line)
+	}
+	if hidden > 0 {
+		formatted = append(formatted, fmt.Sprintf("%s  … %d more lines", indent, hidden))
+	}
+
+	return strings.Join(formatted, "\n")
+}
+
+// wrapLines soft-wraps each line of content to width columns. Wrapped parts keep the
+// indentation of their line, so results stay aligned under their summary.
+func wrapLines(content string, width int) string {
+	if width <= 0 {
+		return content
+	}
+
+	var wrapped []string
+	for _, line := range strings.Split(content, "\n") {
+		if ansi.StringWidth(line) <= width {
+			wrapped = append(wrapped, line)
+			continue
+		}
+
+		text := strings.TrimLeft(line, " ")
+		indent := line[:len(line)-len(text)]
+		available := width - len(indent)
+		if available < width/2 {
+			indent, available = "", width
+		}
+		for _, part := range strings.Split(ansi.Wrap(text, available, ""), "\n") {
+			wrapped = append(wrapped, indent+part)
+		}
+	}
+	return strings.Join(wrapped, "\n")
+}
+
+// renderToolMessage returns the text of a tool message: its summary, plus the full
+// result when the message is expanded, wrapped to width. The focused message says
+// how to expand or collapse it.
+func renderToolMessage(msg ChatMessage, width int) string {
+	content := msg.Content
+	if msg.Focused && msg.ToolResult != "" {
+		action := "expand"
+		if msg.Expanded {
+			action = "collapse"
+		}
+		summary, rest, hasRest := strings.Cut(content, "\n")
+		content = summary + " (" + expandKey + " to " + action + ")"
+		if hasRest {
+			content += "\n" + rest
+		}
+	}
+	if msg.Expanded && msg.ToolResult != "" {
+		indent := ""
+		if msg.TaskGroup != "" {
+			indent = "     "
+		}
+		summary, _, _ := strings.Cut(content, "\n")
+		lines := strings.Count(msg.ToolResult, "\n") + 1
+		if full := formatInlineResult(msg.ToolResult, indent, lines); full != "" {
+			content = summary + "\n" + full
+		}
+	}
+	return wrapLines(content, width)
+}
+
+// showRunningOutput shows a line of a running tool's output below its start message.
+// Only the latest lines are kept, in one message that is replaced as lines arrive.
+func (m *Model) showRunningOutput(toolName, line, taskGroup string) {
+	if m.toolOutput.modeFor(toolName) == ToolOutputHidden {
+		return
+	}
+
+	m.runningOutput = append(m.runningOutput, line)
+	if len(m.runningOutput) > runningOutputLines {
+		m.runningOutput = m.runningOutput[len(m.runningOutput)-runningOutputLines:]
+	}
+
+	indent := ""
+	if taskGroup != "" {
+		indent = "     "
+	}
+	content := formatInlineResult(strings.Join(m.runningOutput, "\n"), indent, runningOutputLines)
+	if content == "" {
+		return
+	}
+
+	if i := m.runningOutputIndex(); i >= 0 {
+		m.messages[i].Content = content
+		return
+	}
+	m.addMessage(ChatMessage{
+		Role:      "system",
+		Content:   content,
+		Timestamp: time.Now(),
+		IsToolMsg: true,
+		ToolName:  toolName,
+		ToolState: "output",
+		TaskGroup: taskGroup,
+	})
+}
+
+// clearRunningOutput removes the live output of a tool that finished; its result
+// summary takes its place
+func (m *Model) clearRunningOutput() {
+	m.runningOutput = nil
+	if i := m.runningOutputIndex(); i >= 0 {
+		m.messages = append(m.messages[:i], m.messages[i+1:]...)
+		m.messageLines = nil
+	}
+}
+
+// runningOutputIndex returns the index of the live output message, or -1 if there is none
+func (m *Model) runningOutputIndex() int {
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		if m.messages[i].IsToolMsg && m.messages[i].ToolState == "output" {
+			return i
+		}
+	}
+	return -1
+}
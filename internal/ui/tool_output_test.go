@@ -0,0 +1,183 @@
+package ui
+
+import (
+	"fmt"
+	"github.com/tildaslashalef/bazinga/internal/config"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// toolMessages returns the tool messages added to the chat in the given state
+func toolMessages(m *Model, state string) []ChatMessage {
+	var result []ChatMessage
+	for _, msg := range m.messages {
+		if msg.IsToolMsg && msg.ToolState == state {
+			result = append(result, msg)
+		}
+	}
+	return result
+}
+
+func newToolOutputModel(cfg *config.Config) *Model {
+	m := newTestModel()
+	m.toolOutput = newToolOutputSettings(cfg)
+	return m
+}
+
+// TestToolOutputInlineIncludesResult tests that inline mode shows the result content, capped
+func TestToolOutputInlineIncludesResult(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.UI.ToolOutput = ToolOutputInline
+	cfg.UI.ToolOutputMaxLines = 3
+	m := newToolOutputModel(cfg)
+
+	var lines []string
+	for i := 1; i <= 5; i++ {
+		lines = append(lines, fmt.Sprintf("main.go:%d: match", i))
+	}
+	m.addToolMessageWithTask("grep", map[string]interface{}{"pattern": "match"}, "complete", strings.Join(lines, "\n"), "")
+
+	completions := toolMessages(m, "complete")
+	require.Len(t, completions, 1)
+	assert.Contains(t, completions[0].Content, "Found 5 matches")
+	assert.Contains(t, completions[0].Content, "main.go:3: match")
+	assert.NotContains(t, completions[0].Content, "main.go:4: match", "inline output should be capped")
+	assert.Contains(t, completions[0].Content, "2 more lines")
+}
+
+// TestToolOutputHiddenSuppressesCompletion tests that hidden mode shows the start line only
+func TestToolOutputHiddenSuppressesCompletion(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.UI.ToolOutput = ToolOutputHidden
+	m := newToolOutputModel(cfg)
+
+	args := map[string]interface{}{"file_path": "main.go"}
+	m.addToolMessageWithTask("read_file", args, "start", "", "")
+	m.addToolMessageWithTask("read_file", args, "complete", "package main\n", "")
+
+	assert.Len(t, toolMessages(m, "start"), 1)
+	assert.Empty(t, toolMessages(m, "complete"), "hidden mode must not add completion lines")
+}
+
+// TestToolOutputOverride tests that a per-tool override wins over the default mode
+func TestToolOutputOverride(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.UI.ToolOutput = ToolOutputHidden
+	cfg.UI.ToolOutputOverrides = map[string]string{"grep": ToolOutputInline, "bash": "loud"}
+	settings := newToolOutputSettings(cfg)
+
+	assert.Equal(t, ToolOutputInline, settings.modeFor("grep"))
+	assert.Equal(t, ToolOutputHidden, settings.modeFor("bash"), "unknown override modes are ignored")
+	assert.Equal(t, ToolOutputHidden, settings.modeFor("read_file"))
+	assert.Equal(t, ToolOutputSummary, newToolOutputSettings(nil).modeFor("grep"))
+}
+
+// TestRunningOutputShowsLatestLines tests that a running command's output is shown live
+// and replaced by its result summary once it completes
+func TestRunningOutputShowsLatestLines(t *testing.T) {
+	m := newToolOutputModel(config.DefaultConfig())
+	args := map[string]interface{}{"command": "go test ./..."}
+
+	m.addToolMessageWithTask("bash", args, "start", "", "")
+	for i := 1; i <= 8; i++ {
+		m.showRunningOutput("bash", fmt.Sprintf("line %d", i), "")
+	}
+
+	output := toolMessages(m, "output")
+	require.Len(t, output, 1, "output lines should share one message")
+	assert.NotContains(t, output[0].Content, "line 3")
+	assert.Contains(t, output[0].Content, "line 4")
+	assert.Contains(t, output[0].Content, "line 8")
+
+	m.clearRunningOutput()
+	m.addToolMessageWithTask("bash", args, "complete", "Exit Code: 0", "")
+	assert.Empty(t, toolMessages(m, "output"))
+	assert.Len(t, toolMessages(m, "complete"), 1)
+}
+
+// TestWrapLinesKeepsIndent tests that long lines wrap to the width under their indentation
+func TestWrapLinesKeepsIndent(t *testing.T) {
+	line := "       " + strings.Repeat("word ", 12)
+	wrapped := strings.Split(wrapLines(line+"\nshort", 30), "\n")
+
+	require.Greater(t, len(wrapped), 2)
+	for _, part := range wrapped[:len(wrapped)-1] {
+		assert.LessOrEqual(t, len(part), 30)
+		assert.True(t, strings.HasPrefix(part, "       "), "wrapped part %q should keep the indent", part)
+	}
+	assert.Equal(t, "short", wrapped[len(wrapped)-1])
+
+	// Lines without spaces, like long diff lines, are broken anyway
+	for _, part := range strings.Split(wrapLines(strings.Repeat("x", 70), 30), "\n") {
+		assert.LessOrEqual(t, len(part), 30)
+	}
+}
+
+// TestToggleToolResult tests that ctrl+r shows the full result of the latest tool and hides it again
+func TestToggleToolResult(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.UI.ToolOutput = ToolOutputInline
+	cfg.UI.ToolOutputMaxLines = 2
+	m := newToolOutputModel(cfg)
+
+	var lines []string
+	for i := 1; i <= 6; i++ {
+		lines = append(lines, fmt.Sprintf("main.go:%d: match", i))
+	}
+	m.addToolMessageWithTask("grep", map[string]interface{}{"pattern": "match"}, "complete", strings.Join(lines, "\n"), "")
+	m.refreshViewport()
+	assert.NotContains(t, m.renderChatContent(), "main.go:6: match")
+
+	m.toggleToolResult()
+	rendered := m.renderChatContent()
+	assert.Contains(t, rendered, "Found 6 matches")
+	assert.Contains(t, rendered, "main.go:6: match")
+	assert.NotContains(t, rendered, "more lines")
+
+	m.toggleToolResult()
+	assert.NotContains(t, m.renderChatContent(), "main.go:6: match")
+}
+
+// TestToolFocus tests moving the focus between tool messages and expanding the focused one
+func TestToolFocus(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.UI.ToolOutput = ToolOutputInline
+	cfg.UI.ToolOutputMaxLines = 1
+	m := newToolOutputModel(cfg)
+
+	m.addToolMessageWithTask("grep", map[string]interface{}{"pattern": "first"}, "complete", "a.go:1: first\na.go:2: first\na.go:3: first", "")
+	m.addToolMessageWithTask("grep", map[string]interface{}{"pattern": "second"}, "complete", "b.go:1: second\nb.go:2: second\nb.go:3: second", "")
+	first, second := len(m.messages)-2, len(m.messages)-1
+	assert.Equal(t, -1, m.focusedToolIndex())
+
+	// Moving forward without a focus does nothing, moving back starts at the latest
+	m.moveToolFocus(1)
+	assert.Equal(t, -1, m.focusedToolIndex())
+	m.moveToolFocus(-1)
+	assert.Equal(t, second, m.focusedToolIndex())
+	assert.Contains(t, m.renderChatContent(), "ctrl+r to expand")
+
+	m.moveToolFocus(-1)
+	assert.Equal(t, first, m.focusedToolIndex())
+	m.moveToolFocus(-1)
+	assert.Equal(t, first, m.focusedToolIndex())
+
+	// ctrl+r expands the focused message, not the latest one
+	m.toggleToolResult()
+	assert.True(t, m.messages[first].Expanded)
+	assert.False(t, m.messages[second].Expanded)
+	rendered := m.renderChatContent()
+	assert.Contains(t, rendered, "a.go:3: first")
+	assert.NotContains(t, rendered, "b.go:3: second")
+	assert.Contains(t, rendered, "ctrl+r to collapse")
+
+	m.moveToolFocus(1)
+	assert.Equal(t, second, m.focusedToolIndex())
+
+	m.clearToolFocus()
+	assert.Equal(t, -1, m.focusedToolIndex())
+	assert.NotContains(t, m.renderChatContent(), "ctrl+r to")
+}
@@ -90,7 +90,7 @@ func (tds *ToolDisplayState) StartToolExecutionWithOptions(toolCall *llm.ToolCal
 		status.Status = "writing"
 	case "edit_file":
 		status.Status = "editing"
-	case "bash":
+	case "bash", "run_tests":
 		status.Status = "running"
 	case "grep", "find":
 		status.Status = "searching"
@@ -289,7 +289,7 @@ func formatDuration(d time.Duration) string {
 // GetToolActionName returns a user-friendly action name for a tool
 func GetToolActionName(toolName string) string {
 	switch toolName {
-	case "read_file":
+	case "read_file", "read_files":
 		return "Read"
 	case "write_file":
 		return "Write"
@@ -309,6 +309,8 @@ func GetToolActionName(toolName string) string {
 		return "Delete directory"
 	case "bash":
 		return "Run"
+	case "run_tests":
+		return "Test"
 	case "grep":
 		return "Search"
 	case "find":
@@ -331,8 +333,18 @@ func GetToolActionName(toolName string) string {
 		return "Git commit"
 	case "git_log":
 		return "Git log"
+	case "git_blame":
+		return "Git blame"
+	case "git_show":
+		return "Git show"
 	case "git_branch":
 		return "Git branch"
+	case "git_stash":
+		return "Git stash"
+	case "git_stash_pop":
+		return "Git stash pop"
+	case "git_restore":
+		return "Git restore"
 	default:
 		return "Executing"
 	}
@@ -341,7 +353,7 @@ func GetToolActionName(toolName string) string {
 // GetToolDisplayFile returns the display name for the file/target of a tool operation
 func GetToolDisplayFile(toolName string, args map[string]interface{}) string {
 	switch toolName {
-	case "read_file", "write_file", "create_file", "edit_file", "delete_file":
+	case "read_file", "write_file", "create_file", "edit_file", "delete_file", "git_blame", "git_show":
 		if filePath, ok := args["file_path"].(string); ok {
 			return filepath.Base(filePath)
 		}
@@ -0,0 +1,114 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// undoLastChange reverts the most recent file change and pops it off the diff stack,
+// so repeated calls walk back through the session's changes. A change that can't be
+// reverted stays on the stack. An empty summary means there was nothing to undo.
+func (m *Model) undoLastChange() (string, error) {
+	if len(m.fileDiffs) == 0 {
+		return "", nil
+	}
+
+	root := ""
+	if m.session != nil {
+		root = m.session.GetRootPath()
+	}
+
+	diff := m.fileDiffs[len(m.fileDiffs)-1]
+	summary, err := revertFileDiff(root, diff)
+	if err != nil {
+		return "", err
+	}
+
+	m.fileDiffs = m.fileDiffs[:len(m.fileDiffs)-1]
+	return summary, nil
+}
+
+// revertFileDiff restores the files a change touched to their state before it. The
+// files must still hold what the change left behind, so edits made since then are
+// never overwritten.
+func revertFileDiff(root string, diff *FileDiff) (string, error) {
+	path := resolveDiffPath(root, diff.FilePath)
+
+	switch diff.Operation {
+	case "move":
+		from := resolveDiffPath(root, diff.FromPath)
+		if err := checkContent(path, diff.After, diff.Operation, diff.FilePath); err != nil {
+			return "", err
+		}
+		if _, err := os.Stat(from); err == nil {
+			return "", fmt.Errorf("cannot undo move: %s exists again", diff.FromPath)
+		}
+		if err := os.MkdirAll(filepath.Dir(from), 0o755); err != nil {
+			return "", fmt.Errorf("failed to recreate directory for %s: %w", diff.FromPath, err)
+		}
+		if err := os.Rename(path, from); err != nil {
+			return "", fmt.Errorf("failed to move %s back to %s: %w", diff.FilePath, diff.FromPath, err)
+		}
+		return fmt.Sprintf("Moved %s back to %s", diff.FilePath, diff.FromPath), nil
+
+	case "delete":
+		if _, err := os.Stat(path); err == nil {
+			return "", fmt.Errorf("cannot undo delete: %s exists again", diff.FilePath)
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return "", fmt.Errorf("failed to recreate directory for %s: %w", diff.FilePath, err)
+		}
+		if err := os.WriteFile(path, []byte(diff.Before), 0o644); err != nil {
+			return "", fmt.Errorf("failed to restore %s: %w", diff.FilePath, err)
+		}
+		return fmt.Sprintf("Restored deleted file %s", diff.FilePath), nil
+	}
+
+	if err := checkContent(path, diff.After, diff.Operation, diff.FilePath); err != nil {
+		return "", err
+	}
+
+	// Created and copied files are removed again; a write with no previous content
+	// created its file too
+	if diff.Operation == "create" || diff.Operation == "copy" || (diff.Operation == "write" && diff.Before == "") {
+		if err := os.Remove(path); err != nil {
+			return "", fmt.Errorf("failed to remove %s: %w", diff.FilePath, err)
+		}
+		return fmt.Sprintf("Removed %s (undid %s)", diff.FilePath, diff.Operation), nil
+	}
+
+	mode := fs.FileMode(0o644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := os.WriteFile(path, []byte(diff.Before), mode); err != nil {
+		return "", fmt.Errorf("failed to restore %s: %w", diff.FilePath, err)
+	}
+	return fmt.Sprintf("Restored %s to its content before the %s", diff.FilePath, diff.Operation), nil
+}
+
+// checkContent verifies a file still holds the content a change left in it
+func checkContent(path, want, operation, displayPath string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("cannot undo %s: %s no longer exists", operation, displayPath)
+		}
+		return fmt.Errorf("cannot undo %s: %w", operation, err)
+	}
+	if string(content) != want {
+		return fmt.Errorf("cannot undo %s: %s has changed since then; revert it with git or edit it by hand", operation, displayPath)
+	}
+	return nil
+}
+
+// resolveDiffPath resolves a path recorded relative to the project root
+func resolveDiffPath(root, path string) string {
+	if filepath.IsAbs(path) || root == "" {
+		return path
+	}
+	return filepath.Join(root, path)
+}
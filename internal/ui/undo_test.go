@@ -0,0 +1,88 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestUndoWalksBackThroughChanges tests that repeated undos revert changes newest first
+func TestUndoWalksBackThroughChanges(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.go")
+	require.NoError(t, os.WriteFile(path, []byte("v3"), 0o644))
+
+	m := &Model{fileDiffs: []*FileDiff{
+		GenerateDiff(path, "", "v1", "create"),
+		GenerateDiff(path, "v1", "v2", "edit"),
+		GenerateDiff(path, "v2", "v3", "write"),
+	}}
+
+	summary, err := m.undoLastChange()
+	require.NoError(t, err)
+	assert.Contains(t, summary, "before the write")
+	assertFileContent(t, path, "v2")
+
+	_, err = m.undoLastChange()
+	require.NoError(t, err)
+	assertFileContent(t, path, "v1")
+
+	_, err = m.undoLastChange()
+	require.NoError(t, err)
+	assert.NoFileExists(t, path, "undoing a create should remove the file")
+
+	summary, err = m.undoLastChange()
+	require.NoError(t, err)
+	assert.Empty(t, summary, "nothing should be left to undo")
+}
+
+// TestUndoRefusesChangedFiles tests that a file edited since the change is left alone
+func TestUndoRefusesChangedFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.go")
+	require.NoError(t, os.WriteFile(path, []byte("edited by hand"), 0o644))
+
+	m := &Model{fileDiffs: []*FileDiff{GenerateDiff(path, "v1", "v2", "edit")}}
+
+	_, err := m.undoLastChange()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "has changed since then")
+	assertFileContent(t, path, "edited by hand")
+	assert.Len(t, m.fileDiffs, 1, "a refused undo should stay on the stack")
+}
+
+// TestUndoReversesMoveCopyAndDelete tests the operations that touch more than file content
+func TestUndoReversesMoveCopyAndDelete(t *testing.T) {
+	dir := t.TempDir()
+	moved := filepath.Join(dir, "new", "a.go")
+	copied := filepath.Join(dir, "b.go")
+	require.NoError(t, os.MkdirAll(filepath.Dir(moved), 0o755))
+	require.NoError(t, os.WriteFile(moved, []byte("a"), 0o644))
+	require.NoError(t, os.WriteFile(copied, []byte("a"), 0o644))
+
+	diffs := []*FileDiff{
+		GenerateDiff("old/a.go → new/a.go", "a", "a", "move"),
+		GenerateDiff("b.go", "", "a", "copy"),
+		GenerateDiff("gone.go", "deleted content", "", "delete"),
+	}
+
+	for _, diff := range diffs {
+		_, err := revertFileDiff(dir, diff)
+		require.NoError(t, err, diff.Operation)
+	}
+
+	assertFileContent(t, filepath.Join(dir, "gone.go"), "deleted content")
+	assert.NoFileExists(t, copied)
+	assert.NoFileExists(t, moved)
+	assertFileContent(t, filepath.Join(dir, "old", "a.go"), "a")
+}
+
+func assertFileContent(t *testing.T, path, want string) {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, want, string(content))
+}
@@ -8,6 +8,14 @@ import (
 	"github.com/charmbracelet/lipgloss"
 )
 
+// noProviderHint tells the user how to get a provider working
+const noProviderHint = "Set a provider's credentials and restart, or run `bazinga doctor` to see what is missing."
+
+// providerAvailable reports whether requests can be sent to an LLM provider
+func (m *Model) providerAvailable() bool {
+	return m.hasProvider == nil || m.hasProvider()
+}
+
 // createWelcomeMessage creates the initial welcome message displayed to users
 func (m *Model) createWelcomeMessage() string {
 	cwd, err := os.Getwd()
@@ -16,12 +24,25 @@ func (m *Model) createWelcomeMessage() string {
 	}
 
 	var parts []string
-	parts = append(parts, "🧙 Welcome to Bazinga!")
+	if m.version != "" {
+		parts = append(parts, fmt.Sprintf("🧙 Welcome to Bazinga! (%s)", m.version))
+	} else {
+		parts = append(parts, "🧙 Welcome to Bazinga!")
+	}
 	parts = append(parts, "")
-	parts = append(parts, "💡 Quick Start:")
-	parts = append(parts, "  • Run /init to analyze your project")
-	parts = append(parts, "  • Ask questions about your code")
-	parts = append(parts, "  • Use /help to see all commands")
+	if m.providerAvailable() {
+		parts = append(parts, "💡 Quick Start:")
+		parts = append(parts, "  • Run /init to analyze your project")
+		parts = append(parts, "  • Ask questions about your code")
+		parts = append(parts, "  • Use /help to see all commands")
+	} else {
+		parts = append(parts, "⚠️  No LLM provider is configured")
+		parts = append(parts, "  • Set ANTHROPIC_API_KEY, OPENAI_API_KEY or GEMINI_API_KEY, configure AWS")
+		parts = append(parts, "    credentials for Bedrock, or enable Ollama in")
+		parts = append(parts, "    ~/.bazinga/config.yaml, then restart")
+		parts = append(parts, "  • Run `bazinga doctor` to see what is missing")
+		parts = append(parts, "  • /help, /config and /memory work without a provider")
+	}
 	parts = append(parts, "")
 	parts = append(parts, fmt.Sprintf("📁 Working directory: %s", cwd))
 